@@ -3,6 +3,8 @@ package cache
 import (
 	"sync"
 	"time"
+
+	"github.com/lionelgarnier/validate-api-request/pkg/clock"
 )
 
 // CacheStats holds cache performance metrics
@@ -36,6 +38,7 @@ type BaseCache[T any] struct {
 	maxSize int
 	stats   CacheStats
 	ttl     time.Duration
+	clock   clock.Clock
 	mu      sync.RWMutex
 }
 
@@ -44,9 +47,17 @@ func NewBaseCache[T any](maxSize int, ttl time.Duration) *BaseCache[T] {
 		entries: make(map[string]*CacheEntry[T]),
 		maxSize: maxSize,
 		ttl:     ttl,
+		clock:   clock.Real(),
 	}
 }
 
+// SetClock replaces the clock used for expiry and access tracking
+func (c *BaseCache[T]) SetClock(clk clock.Clock) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.clock = clk
+}
+
 // Common methods implementation
 func (c *BaseCache[T]) Get(key string) (T, bool) {
 	c.mu.RLock()
@@ -58,10 +69,10 @@ func (c *BaseCache[T]) Get(key string) (T, bool) {
 		return zero, false
 	}
 
-	if time.Now().After(entry.ExpiresAt) {
+	if c.clock.Now().After(entry.ExpiresAt) {
 		c.mu.RUnlock()
 		c.mu.Lock()
-		if entry, exists = c.entries[key]; exists && time.Now().After(entry.ExpiresAt) {
+		if entry, exists = c.entries[key]; exists && c.clock.Now().After(entry.ExpiresAt) {
 			delete(c.entries, key)
 			c.stats.Size = len(c.entries)
 			c.stats.Misses++
@@ -71,7 +82,7 @@ func (c *BaseCache[T]) Get(key string) (T, bool) {
 		return zero, false
 	}
 
-	entry.LastAccess = time.Now()
+	entry.LastAccess = c.clock.Now()
 	value := entry.Value
 	c.mu.RUnlock()
 	c.stats.Hits++
@@ -88,8 +99,8 @@ func (c *BaseCache[T]) Set(key string, value T) {
 
 	c.entries[key] = &CacheEntry[T]{
 		Value:      value,
-		ExpiresAt:  time.Now().Add(c.ttl),
-		LastAccess: time.Now(),
+		ExpiresAt:  c.clock.Now().Add(c.ttl),
+		LastAccess: c.clock.Now(),
 	}
 	c.stats.Size = len(c.entries)
 }
@@ -4,9 +4,19 @@ go 1.23.2
 
 require github.com/stretchr/testify v1.10.0
 
-require gopkg.in/yaml.v3 v3.0.1
+require (
+	github.com/fsnotify/fsnotify v1.7.0
+	golang.org/x/text v0.16.0
+	gopkg.in/yaml.v3 v3.0.1
+)
 
-require github.com/klauspost/cpuid/v2 v2.0.9 // indirect
+require (
+	github.com/klauspost/cpuid/v2 v2.2.7 // indirect
+	github.com/kr/pretty v0.3.1 // indirect
+	github.com/rogpeppe/go-internal v1.10.0 // indirect
+	golang.org/x/sys v0.22.0 // indirect
+	gopkg.in/check.v1 v1.0.0-20201130134442-10cb98267c6c // indirect
+)
 
 require (
 	github.com/davecgh/go-spew v1.1.1 // indirect
@@ -0,0 +1,126 @@
+package metrics
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/lionelgarnier/validate-api-request/oas"
+	"github.com/lionelgarnier/validate-api-request/validation"
+)
+
+// Namespace prefixes the names of the metrics
+const Namespace = "oas_validation"
+
+// Collector collects the validation metrics of a middleware, to be registered by the host application
+type Collector struct {
+	requests *prometheus.CounterVec
+	failures *prometheus.CounterVec
+	duration *prometheus.HistogramVec
+	// deprecations counts the requests using deprecated parts of their spec
+	deprecations *prometheus.CounterVec
+
+	specHits   *prometheus.Desc
+	specMisses *prometheus.Desc
+	specs      *prometheus.Desc
+
+	mu      sync.RWMutex
+	manager func() *oas.OASManager // Source of the active manager, for spec cache metrics
+}
+
+// NewCollector creates a collector of validation metrics
+func NewCollector() *Collector {
+	return &Collector{
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: Namespace,
+			Name:      "requests_total",
+			Help:      "Requests validated, by API and result (valid or invalid).",
+		}, []string{"api", "result"}),
+		failures: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: Namespace,
+			Name:      "failures_total",
+			Help:      "Validation failures, by API, stage and category.",
+		}, []string{"api", "stage", "category"}),
+		duration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: Namespace,
+			Name:      "duration_seconds",
+			Help:      "Latency of request validation, by API.",
+			Buckets:   []float64{.00005, .0001, .00025, .0005, .001, .0025, .005, .01, .025, .05, .1},
+		}, []string{"api"}),
+		deprecations: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: Namespace,
+			Name:      "deprecated_requests_total",
+			Help:      "Requests using deprecated operations, parameters or properties, by API, method and route.",
+		}, []string{"api", "method", "route"}),
+		specHits: prometheus.NewDesc(Namespace+"_spec_cache_hits_total",
+			"Spec lookups finding a loaded spec.", nil, nil),
+		specMisses: prometheus.NewDesc(Namespace+"_spec_cache_misses_total",
+			"Spec lookups of unknown APIs.", nil, nil),
+		specs: prometheus.NewDesc(Namespace+"_specs_loaded",
+			"Specs loaded in the manager.", nil, nil),
+	}
+}
+
+// WatchManager sets the source of the manager whose spec cache is reported, called at each collection
+// as the manager changes when the configuration is reloaded
+func (c *Collector) WatchManager(manager func() *oas.OASManager) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.manager = manager
+}
+
+// ObserveRequest records the validation of a request by an API, failing with err if not nil.
+// Each failure of a request collecting all its failures is counted.
+func (c *Collector) ObserveRequest(api string, duration time.Duration, err error) {
+	c.duration.WithLabelValues(api).Observe(duration.Seconds())
+	if err == nil {
+		c.requests.WithLabelValues(api, "valid").Inc()
+		return
+	}
+	c.requests.WithLabelValues(api, "invalid").Inc()
+
+	var failures validation.ValidationErrors
+	if !errors.As(err, &failures) {
+		failures = validation.ValidationErrors{{Stage: validation.StageOf(err), Category: validation.CategoryOf(err)}}
+	}
+	for _, failure := range failures {
+		c.failures.WithLabelValues(api, string(failure.Stage), string(validation.CategoryOf(failure))).Inc()
+	}
+}
+
+// ObserveDeprecation records a request to a route of an API using deprecated parts of its spec
+func (c *Collector) ObserveDeprecation(api, method, route string) {
+	c.deprecations.WithLabelValues(api, method, route).Inc()
+}
+
+// Describe sends the descriptors of the metrics
+func (c *Collector) Describe(ch chan<- *prometheus.Desc) {
+	c.requests.Describe(ch)
+	c.failures.Describe(ch)
+	c.duration.Describe(ch)
+	c.deprecations.Describe(ch)
+	ch <- c.specHits
+	ch <- c.specMisses
+	ch <- c.specs
+}
+
+// Collect sends the current values of the metrics
+func (c *Collector) Collect(ch chan<- prometheus.Metric) {
+	c.requests.Collect(ch)
+	c.failures.Collect(ch)
+	c.duration.Collect(ch)
+	c.deprecations.Collect(ch)
+
+	c.mu.RLock()
+	manager := c.manager
+	c.mu.RUnlock()
+	if manager == nil {
+		return
+	}
+	stats := manager().Stats()
+	ch <- prometheus.MustNewConstMetric(c.specHits, prometheus.CounterValue, float64(stats.Hits))
+	ch <- prometheus.MustNewConstMetric(c.specMisses, prometheus.CounterValue, float64(stats.Misses))
+	ch <- prometheus.MustNewConstMetric(c.specs, prometheus.GaugeValue, float64(stats.Size))
+}
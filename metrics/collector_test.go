@@ -0,0 +1,118 @@
+package metrics
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/lionelgarnier/validate-api-request/middleware"
+)
+
+func inlineConfig(spec string) *middleware.Config {
+	config := middleware.CreateConfig()
+	config.SelectorType = "fixed"
+	config.Selector = map[string]string{"default": "inline"}
+	config.APIs = []middleware.APIConfig{{Name: "inline", SpecText: spec}}
+	return config
+}
+
+func TestMetrics(t *testing.T) {
+	nextHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("OK"))
+	})
+
+	config := inlineConfig(`{
+        "openapi": "3.0.0",
+        "paths": {
+            "/pet": {
+                "get": {
+                    "parameters": [
+                        {"name": "limit", "in": "query", "schema": {"type": "integer"}},
+                        {"name": "offset", "in": "query", "schema": {"type": "integer"}}
+                    ]
+                }
+            }
+        }
+    }`)
+	config.CollectAllErrors = true
+
+	collector := NewCollector()
+	registry := prometheus.NewPedanticRegistry()
+	assert.NoError(t, registry.Register(collector))
+
+	mw, err := middleware.New(nextHandler, config, middleware.WithMetrics(collector))
+	assert.NoError(t, err)
+
+	for _, path := range []string{"/pet?limit=10", "/pet?limit=ten&offset=none", "/pets"} {
+		mw.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+	mw.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/pet", nil))
+
+	expected := `
+# HELP oas_validation_failures_total Validation failures, by API, stage and category.
+# TYPE oas_validation_failures_total counter
+oas_validation_failures_total{api="inline",category="invalidParameter",stage="parameters"} 2
+oas_validation_failures_total{api="inline",category="methodNotAllowed",stage="method"} 1
+oas_validation_failures_total{api="inline",category="pathNotFound",stage="path"} 1
+# HELP oas_validation_requests_total Requests validated, by API and result (valid or invalid).
+# TYPE oas_validation_requests_total counter
+oas_validation_requests_total{api="inline",result="invalid"} 3
+oas_validation_requests_total{api="inline",result="valid"} 1
+# HELP oas_validation_spec_cache_hits_total Spec lookups finding a loaded spec.
+# TYPE oas_validation_spec_cache_hits_total counter
+oas_validation_spec_cache_hits_total 4
+# HELP oas_validation_spec_cache_misses_total Spec lookups of unknown APIs.
+# TYPE oas_validation_spec_cache_misses_total counter
+oas_validation_spec_cache_misses_total 0
+# HELP oas_validation_specs_loaded Specs loaded in the manager.
+# TYPE oas_validation_specs_loaded gauge
+oas_validation_specs_loaded 1
+`
+	assert.NoError(t, testutil.GatherAndCompare(registry, strings.NewReader(expected),
+		"oas_validation_failures_total", "oas_validation_requests_total", "oas_validation_spec_cache_hits_total",
+		"oas_validation_spec_cache_misses_total", "oas_validation_specs_loaded"))
+	assert.Equal(t, 1, testutil.CollectAndCount(collector, "oas_validation_duration_seconds"))
+}
+
+func TestDeprecationMetrics(t *testing.T) {
+	nextHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("OK"))
+	})
+
+	config := inlineConfig(`{
+        "openapi": "3.0.0",
+        "paths": {
+            "/pets/{id}": {
+                "parameters": [{"name": "id", "in": "path", "required": true, "schema": {"type": "integer"}}],
+                "get": {"x-deprecated-at": "2001-01-01"},
+                "delete": {"parameters": [{"name": "force", "in": "query", "deprecated": true, "schema": {"type": "boolean"}}]}
+            }
+        }
+    }`)
+
+	collector := NewCollector()
+	mw, err := middleware.New(nextHandler, config, middleware.WithMetrics(collector))
+	assert.NoError(t, err)
+
+	for _, req := range []*http.Request{
+		httptest.NewRequest(http.MethodGet, "/pets/1", nil),
+		httptest.NewRequest(http.MethodGet, "/pets/2", nil),
+		httptest.NewRequest(http.MethodDelete, "/pets/1?force=true", nil),
+		httptest.NewRequest(http.MethodDelete, "/pets/1", nil),
+	} {
+		mw.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	expected := `
+# HELP oas_validation_deprecated_requests_total Requests using deprecated operations, parameters or properties, by API, method and route.
+# TYPE oas_validation_deprecated_requests_total counter
+oas_validation_deprecated_requests_total{api="inline",method="DELETE",route="/pets/{id}"} 1
+oas_validation_deprecated_requests_total{api="inline",method="GET",route="/pets/{id}"} 2
+`
+	assert.NoError(t, testutil.CollectAndCompare(collector, strings.NewReader(expected), "oas_validation_deprecated_requests_total"))
+}
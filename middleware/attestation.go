@@ -0,0 +1,75 @@
+package middleware
+
+import (
+	"encoding/base64"
+	"fmt"
+	"time"
+
+	"github.com/lionelgarnier/validate-api-request/oas"
+	"github.com/lionelgarnier/validate-api-request/validation"
+)
+
+// AttestationMode is the role of the middleware in a chain of gateways validating the same requests
+type AttestationMode string
+
+const (
+	// AttestationSign signs the requests it accepts, for the first hop of a chain. It is the default, so that an edge
+	// gateway does not trust the attestations sent by clients.
+	AttestationSign AttestationMode = "sign"
+	// AttestationTrust trusts the attestations of the previous hops without signing requests, for the last hop
+	AttestationTrust AttestationMode = "trust"
+	// AttestationSignAndTrust trusts the attestations of the previous hops and signs the requests it accepts, for
+	// the intermediate hops
+	AttestationSignAndTrust AttestationMode = "signAndTrust"
+)
+
+// AttestationConfig configures the signed header attesting that a request was validated, so that the next hops of
+// a gateway chain sharing the key do not validate it again
+type AttestationConfig struct {
+	// Key is the base64 encoded HMAC key shared by the hops, at least 32 bytes
+	Key string `json:"key" yaml:"key"`
+	// Header carries the attestations, `X-OAS-Validated` by default
+	Header string `json:"header,omitempty" yaml:"header,omitempty"`
+	// MaxAge is the time an attestation is trusted for, 1m by default
+	MaxAge oas.Duration `json:"maxAge,omitempty" yaml:"maxAge,omitempty"`
+	// Mode only signs attestations by default (`sign`), or only trusts them (`trust`), or both (`signAndTrust`)
+	Mode AttestationMode `json:"mode,omitempty" yaml:"mode,omitempty"`
+}
+
+// newAttestation creates the attestation described by the configuration, nil when it is not configured
+func (c *AttestationConfig) newAttestation() (*validation.Attestation, error) {
+	if c == nil {
+		return nil, nil
+	}
+	key, err := base64.StdEncoding.DecodeString(c.Key)
+	if err != nil {
+		return nil, fmt.Errorf("invalid attestation key: %w", err)
+	}
+	if len(key) < 32 {
+		return nil, fmt.Errorf("attestation key must be at least 32 bytes, got %d", len(key))
+	}
+	maxAge := c.MaxAge.Duration
+	if maxAge <= 0 {
+		maxAge = time.Minute
+	}
+	switch c.Mode {
+	case "", AttestationSign, AttestationTrust, AttestationSignAndTrust:
+	default:
+		return nil, fmt.Errorf("unknown attestation mode '%s'", c.Mode)
+	}
+	sign := c.Mode != AttestationTrust
+	trust := c.Mode == AttestationTrust || c.Mode == AttestationSignAndTrust
+	return validation.NewAttestation(key, c.Header, maxAge, sign, trust), nil
+}
+
+// header returns the header carrying the attestations, empty when they are not configured
+func (c *AttestationConfig) header() string {
+	switch {
+	case c == nil:
+		return ""
+	case c.Header == "":
+		return validation.DefaultAttestationHeader
+	default:
+		return c.Header
+	}
+}
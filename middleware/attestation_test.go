@@ -0,0 +1,85 @@
+package middleware
+
+import (
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAttestation(t *testing.T) {
+	spec := `{
+        "openapi": "3.0.0",
+        "paths": {
+            "/pets": {
+                "post": {
+                    "requestBody": {
+                        "content": {"application/json": {"schema": {"type": "object", "required": ["name"], "properties": {"name": {"type": "string"}}}}}
+                    }
+                }
+            }
+        }
+    }`
+	key := base64.StdEncoding.EncodeToString([]byte("0123456789abcdef0123456789abcdef"))
+
+	var attestations []string
+	backend := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attestations = append(attestations, r.Header.Get("X-OAS-Validated"))
+		w.Write([]byte("OK"))
+	})
+
+	// The inner hop bounds the schema evaluations, it accepts the requests exceeding them only when the edge attested them
+	innerConfig := inlineConfig(spec)
+	innerConfig.Attestation = &AttestationConfig{Key: key, Mode: AttestationTrust}
+	innerConfig.StrictHeaders = true
+	innerConfig.MaxSchemaEvaluations = 1
+	inner, err := New(backend, innerConfig)
+	assert.NoError(t, err)
+
+	edgeConfig := inlineConfig(spec)
+	edgeConfig.Attestation = &AttestationConfig{Key: key, Mode: AttestationSign}
+	edge, err := New(inner, edgeConfig)
+	assert.NoError(t, err)
+
+	send := func(handler http.Handler, body, attestation string) int {
+		req := httptest.NewRequest(http.MethodPost, "/pets", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		if attestation != "" {
+			req.Header.Set("X-OAS-Validated", attestation)
+		}
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+		return rr.Code
+	}
+
+	assert.Equal(t, http.StatusOK, send(edge, `{"name": "Rex"}`, ""))
+	assert.Equal(t, http.StatusBadRequest, send(edge, `{}`, ""))
+	assert.Len(t, attestations, 1)
+	assert.Regexp(t, `^t=\d+, sig=[0-9a-f]{64}$`, attestations[0])
+
+	// Requests sent to the inner hop directly are validated, whatever their attestation
+	assert.Equal(t, http.StatusUnprocessableEntity, send(inner, `{"name": "Rex"}`, ""))
+	assert.Equal(t, http.StatusUnprocessableEntity, send(inner, `{"name": "Rex"}`, "t=1, sig=00"))
+	assert.Equal(t, http.StatusOK, send(inner, `{"name": "Rex"}`, attestations[0]))
+
+	// Attestations are only trusted by hops validating against the same spec
+	otherConfig := inlineConfig(strings.Replace(spec, `["name"]`, `["name", "age"]`, 1))
+	otherConfig.Attestation = &AttestationConfig{Key: key, Mode: AttestationTrust}
+	other, err := New(backend, otherConfig)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusBadRequest, send(other, `{"name": "Rex"}`, attestations[0]))
+
+	for _, config := range []*AttestationConfig{
+		{Key: "not base64!"},
+		{Key: base64.StdEncoding.EncodeToString([]byte("short"))},
+		{Key: key, Mode: "verify"},
+	} {
+		invalid := inlineConfig(spec)
+		invalid.Attestation = config
+		_, err := New(backend, invalid)
+		assert.Error(t, err)
+	}
+}
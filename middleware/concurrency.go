@@ -0,0 +1,131 @@
+package middleware
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/lionelgarnier/validate-api-request/oas"
+)
+
+// ConcurrencyExtension is the operation extension declaring a per-operation in-flight limit
+const ConcurrencyExtension = "x-concurrency"
+
+// ConcurrencyConfig configures the in-flight request limit stage of an API
+type ConcurrencyConfig struct {
+	MaxInFlight int                 `json:"maxInFlight,omitempty" yaml:"maxInFlight,omitempty"`
+	Status      int                 `json:"status,omitempty" yaml:"status,omitempty"`
+	RetryAfter  oas.Duration        `json:"retryAfter,omitempty" yaml:"retryAfter,omitempty"`
+	Headers     *LimitHeadersConfig `json:"headers,omitempty" yaml:"headers,omitempty"`
+}
+
+// concurrencyLimiter counts in-flight requests per API and per operation
+type concurrencyLimiter struct {
+	inFlight map[string]int
+	mu       sync.Mutex
+}
+
+func newConcurrencyLimiter() *concurrencyLimiter {
+	return &concurrencyLimiter{
+		inFlight: make(map[string]int),
+	}
+}
+
+// acquire reserves a slot for the key, returning false when the limit is reached
+func (l *concurrencyLimiter) acquire(key string, limit int) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.inFlight[key] >= limit {
+		return false
+	}
+	l.inFlight[key]++
+	return true
+}
+
+// release frees a slot previously reserved for the key
+func (l *concurrencyLimiter) release(key string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.inFlight[key]--
+	if l.inFlight[key] <= 0 {
+		delete(l.inFlight, key)
+	}
+}
+
+// enter reserves the API and operation slots of a validated request.
+// It returns the function releasing them, or the limit that was reached.
+func (l *concurrencyLimiter) enter(apiConfig *APIConfig, req *oas.OASRequest) (func(), int) {
+	var keys []string
+
+	apiName := apiConfig.Name
+	if apiConfig.Concurrency != nil && apiConfig.Concurrency.MaxInFlight > 0 {
+		if !l.acquire(apiName, apiConfig.Concurrency.MaxInFlight) {
+			return nil, apiConfig.Concurrency.MaxInFlight
+		}
+		keys = append(keys, apiName)
+	}
+
+	if limit := operationConcurrency(req.Operation); limit > 0 {
+		key := fmt.Sprintf("%s %s %s", apiName, req.Request.Method, req.Route)
+		if !l.acquire(key, limit) {
+			for _, k := range keys {
+				l.release(k)
+			}
+			return nil, limit
+		}
+		keys = append(keys, key)
+	}
+
+	return func() {
+		for _, k := range keys {
+			l.release(k)
+		}
+	}, 0
+}
+
+// operationConcurrency returns the in-flight limit declared by the operation extension, 0 if none
+func operationConcurrency(operation *oas.Operation) int {
+	if operation == nil {
+		return 0
+	}
+	value, exists := operation.Extension(ConcurrencyExtension)
+	if !exists {
+		return 0
+	}
+
+	switch v := value.(type) {
+	case float64:
+		return int(v)
+	case string:
+		limit, err := strconv.Atoi(v)
+		if err != nil {
+			return 0
+		}
+		return limit
+	default:
+		return 0
+	}
+}
+
+// rejectOverload writes the load shedding response of the concurrency stage
+func rejectOverload(w http.ResponseWriter, config *ConcurrencyConfig, limit int) {
+	status := http.StatusServiceUnavailable
+	retryAfter := time.Second
+	var headers *LimitHeadersConfig
+	if config != nil {
+		if config.Status != 0 {
+			status = config.Status
+		}
+		if config.RetryAfter.Duration > 0 {
+			retryAfter = config.RetryAfter.Duration
+		}
+		headers = config.Headers
+	}
+
+	WriteLimitHeaders(w, LimitInfo{Limit: limit, Remaining: 0, Reset: retryAfter}, headers)
+	http.Error(w, fmt.Sprintf("too many concurrent requests (limit %d)", limit), status)
+}
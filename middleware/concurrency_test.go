@@ -0,0 +1,67 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/lionelgarnier/validate-api-request/oas"
+)
+
+func TestConcurrencyLimit(t *testing.T) {
+	entered := make(chan struct{}, 2)
+	unblock := make(chan struct{})
+	nextHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/slow" {
+			entered <- struct{}{}
+			<-unblock
+		}
+		w.Write([]byte("OK"))
+	})
+
+	config := inlineConfig(`{
+		"openapi": "3.0.0",
+		"paths": {
+			"/slow": {"get": {"x-concurrency": 1}},
+			"/fast": {"get": {}}
+		}
+	}`)
+	config.APIs[0].Concurrency = &ConcurrencyConfig{
+		MaxInFlight: 2,
+		Status:      http.StatusTooManyRequests,
+		RetryAfter:  oas.Duration{Duration: 1500 * time.Millisecond},
+		Headers:     &LimitHeadersConfig{RateLimit: true, Policy: "2"},
+	}
+
+	middleware, err := New(nextHandler, config)
+	assert.NoError(t, err)
+
+	serve := func(path string) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		middleware.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, path, nil))
+		return rr
+	}
+
+	done := make(chan *httptest.ResponseRecorder)
+	go func() { done <- serve("/slow") }()
+	<-entered
+
+	// The operation limit is reached
+	rr := serve("/slow")
+	assert.Equal(t, http.StatusTooManyRequests, rr.Code)
+	assert.Equal(t, "2", rr.Header().Get("Retry-After"))
+	assert.Equal(t, "1", rr.Header().Get("RateLimit-Limit"))
+	assert.Equal(t, "0", rr.Header().Get("RateLimit-Remaining"))
+	assert.Equal(t, "2", rr.Header().Get("RateLimit-Reset"))
+	assert.Equal(t, "2", rr.Header().Get("RateLimit-Policy"))
+
+	// The API still has one slot left
+	assert.Equal(t, http.StatusOK, serve("/fast").Code)
+
+	close(unblock)
+	assert.Equal(t, http.StatusOK, (<-done).Code)
+	assert.Equal(t, http.StatusOK, serve("/slow").Code)
+}
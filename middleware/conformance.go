@@ -0,0 +1,67 @@
+package middleware
+
+import (
+	"encoding/json"
+	"maps"
+	"net/http"
+	"slices"
+
+	"github.com/lionelgarnier/validate-api-request/oas"
+	"github.com/lionelgarnier/validate-api-request/validation"
+)
+
+// ConformanceHandler returns an admin handler answering GET requests with the conformance reports of the loaded
+// specs by API name, or of the API named by the `api` query parameter. Reports are JSON, or Markdown with
+// `format=markdown`.
+func (m *OASMiddleware) ConformanceHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			w.Header().Set("Allow", http.MethodGet)
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		state := m.state.Load()
+		report := func(spec *oas.APISpec) validation.ConformanceReport {
+			opts := append([]validation.Option{}, m.validatorOptions...)
+			if apiConfig, exists := state.apis[spec.Name]; exists {
+				opts = append(opts, apiConfig.validatorOptions()...)
+			}
+			return validation.NewConformanceReport(spec, opts...)
+		}
+
+		reports := make(map[string]validation.ConformanceReport)
+		name := r.URL.Query().Get("api")
+		if name != "" {
+			spec, err := state.manager.GetApiSpec(name)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusNotFound)
+				return
+			}
+			reports[name] = report(spec)
+		} else {
+			state.manager.RangeSpecs(func(name string, spec *oas.APISpec) bool {
+				reports[name] = report(spec)
+				return true
+			})
+		}
+
+		if r.URL.Query().Get("format") == "markdown" {
+			w.Header().Set("Content-Type", "text/markdown; charset=utf-8")
+			for i, name := range slices.Sorted(maps.Keys(reports)) {
+				if i > 0 {
+					w.Write([]byte("\n"))
+				}
+				w.Write([]byte(reports[name].Markdown()))
+			}
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if name != "" {
+			json.NewEncoder(w).Encode(reports[name])
+			return
+		}
+		json.NewEncoder(w).Encode(reports)
+	})
+}
@@ -0,0 +1,106 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"github.com/lionelgarnier/validate-api-request/oas"
+	"github.com/lionelgarnier/validate-api-request/validation"
+)
+
+// oasRequestKey is the context key of the validated request
+type oasRequestKey struct{}
+
+// routeKey is the context key of the route matched by the request
+type routeKey struct{}
+
+// ValidatedRequest returns the OAS request of a request forwarded by the middleware, carrying its route, path item
+// and operation, e.g. to read its path parameters with validation.PathParams
+func ValidatedRequest(r *http.Request) (*oas.OASRequest, bool) {
+	oasRequest, ok := r.Context().Value(oasRequestKey{}).(*oas.OASRequest)
+	return oasRequest, ok
+}
+
+// Route is the match of a request forwarded by the middleware, so that handlers reuse it instead of matching the
+// path again
+type Route struct {
+	API         string            // Name of the API serving the request
+	Template    string            // Path template of the spec, e.g. /pets/{petId}
+	Method      string            // Method of the operation
+	OperationID string            // operationId of the operation, empty if it has none
+	PathParams  map[string]string // Raw values of the path parameters by name
+	Principal   *Principal        // Credentials satisfying the security of the operation, nil for anonymous requests
+}
+
+// Principal is the security requirement satisfied by a request
+type Principal struct {
+	Schemes  oas.SecurityRequirement // Security schemes of the requirement and their required scopes
+	Username string                  // Username of the HTTP Basic credentials, empty for other schemes
+}
+
+// RouteOf returns the route matched by a request forwarded by the middleware
+func RouteOf(r *http.Request) (*Route, bool) {
+	route, ok := r.Context().Value(routeKey{}).(*Route)
+	return route, ok
+}
+
+// PathParam returns the raw value of a path parameter of a request forwarded by the middleware, or an empty string
+func PathParam(r *http.Request, name string) string {
+	if route, ok := RouteOf(r); ok {
+		return route.PathParams[name]
+	}
+	return ""
+}
+
+// OperationID returns the operationId of the operation of a request forwarded by the middleware, or an empty string
+func OperationID(r *http.Request) string {
+	if route, ok := RouteOf(r); ok {
+		return route.OperationID
+	}
+	return ""
+}
+
+// withValidatedRequest stores the OAS request and its route in the context of the request it forwards
+func withValidatedRequest(oasRequest *oas.OASRequest, spec *oas.APISpec) {
+	ctx := context.WithValue(oasRequest.Request.Context(), oasRequestKey{}, oasRequest)
+	if oasRequest.Operation != nil {
+		ctx = context.WithValue(ctx, routeKey{}, newRoute(oasRequest, spec))
+	}
+	oasRequest.Request = oasRequest.Request.WithContext(ctx)
+}
+
+// newRoute returns the route matched by a validated request
+func newRoute(oasRequest *oas.OASRequest, spec *oas.APISpec) *Route {
+	route := &Route{
+		API:         spec.Name,
+		Template:    oasRequest.Route,
+		Method:      oasRequest.Request.Method,
+		OperationID: oasRequest.Operation.OperationId,
+		PathParams:  validation.PathParams(oasRequest),
+	}
+	if oasRequest.Security != nil {
+		route.Principal = &Principal{Schemes: oasRequest.Security}
+		if username, _, ok := oasRequest.Request.BasicAuth(); ok && requiresBasicAuth(spec, oasRequest.Security) {
+			route.Principal.Username = username
+		}
+	}
+	return route
+}
+
+// requiresBasicAuth reports whether a security requirement includes an HTTP Basic scheme
+func requiresBasicAuth(spec *oas.APISpec, requirement oas.SecurityRequirement) bool {
+	if spec.Components == nil {
+		return false
+	}
+	for name := range requirement {
+		secScheme, exists := spec.Components.SecuritySchemes[name]
+		if !exists {
+			continue
+		}
+		if secScheme, err := spec.ResolveSecurityScheme(secScheme); err == nil && secScheme.Type == "http" && strings.EqualFold(secScheme.Scheme, "basic") {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,74 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/lionelgarnier/validate-api-request/oas"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRouteOf(t *testing.T) {
+	var route *Route
+	var found bool
+	nextHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		route, found = RouteOf(r)
+		w.Write([]byte(PathParam(r, "petId") + " " + OperationID(r)))
+	})
+
+	config := inlineConfig(`{
+        "openapi": "3.0.0",
+        "paths": {
+            "/pets/{petId}": {
+                "get": {
+                    "operationId": "getPet",
+                    "parameters": [{"name": "petId", "in": "path", "required": true, "schema": {"type": "integer"}}],
+                    "security": [{}, {"basic": []}, {"oauth": ["read"]}]
+                }
+            }
+        },
+        "components": {
+            "securitySchemes": {
+                "basic": {"type": "http", "scheme": "basic"},
+                "oauth": {"type": "oauth2", "flows": {}}
+            }
+        }
+    }`)
+	middleware, err := New(nextHandler, config)
+	assert.NoError(t, err)
+
+	tests := []struct {
+		name      string
+		setup     func(r *http.Request)
+		principal *Principal
+	}{
+		{name: "Anonymous", setup: func(r *http.Request) {}},
+		{name: "Basic", setup: func(r *http.Request) { r.SetBasicAuth("alice", "secret") },
+			principal: &Principal{Schemes: oas.SecurityRequirement{"basic": {}}, Username: "alice"}},
+		{name: "Bearer", setup: func(r *http.Request) { r.Header.Set("Authorization", "Bearer token") },
+			principal: &Principal{Schemes: oas.SecurityRequirement{"oauth": {"read"}}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			route, found = nil, false
+			req := httptest.NewRequest(http.MethodGet, "/pets/42", nil)
+			tt.setup(req)
+			rr := httptest.NewRecorder()
+			middleware.ServeHTTP(rr, req)
+
+			assert.Equal(t, http.StatusOK, rr.Code)
+			assert.Equal(t, "42 getPet", rr.Body.String())
+			assert.True(t, found)
+			assert.Equal(t, &Route{
+				API:         "inline",
+				Template:    "/pets/{petId}",
+				Method:      http.MethodGet,
+				OperationID: "getPet",
+				PathParams:  map[string]string{"petId": "42"},
+				Principal:   tt.principal,
+			}, route)
+		})
+	}
+}
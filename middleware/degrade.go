@@ -0,0 +1,111 @@
+package middleware
+
+import (
+	"fmt"
+	"maps"
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/lionelgarnier/validate-api-request/oas"
+)
+
+// LoadPolicy is the behavior of the middleware when the spec of an API fails to load
+type LoadPolicy string
+
+const (
+	// LoadPolicyAbort fails the construction or reload of the middleware
+	LoadPolicyAbort LoadPolicy = ""
+	// LoadPolicyDegrade serves the APIs that loaded, rejects the traffic of the others and retries loading them in the background
+	LoadPolicyDegrade LoadPolicy = "degrade"
+)
+
+// defaultLoadRetryInterval is the delay between the attempts to load an unavailable API
+const defaultLoadRetryInterval = 30 * time.Second
+
+// markUnavailable records the load failure of an API and retries loading it in the background until it succeeds
+func (s *middlewareState) markUnavailable(apiConfig *APIConfig, err error) {
+	s.setUnavailable(apiConfig.Name, err)
+	s.emit(oas.Event{Type: oas.EventLoadFailed, API: apiConfig.Name, Err: err})
+
+	interval := s.config.LoadRetryInterval.Duration
+	if interval <= 0 {
+		interval = defaultLoadRetryInterval
+	}
+
+	done := make(chan struct{})
+	var once sync.Once
+	s.addWatcher(func() {
+		once.Do(func() { close(done) })
+	})
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				err := loadAPIConfig(s, apiConfig)
+				s.setUnavailable(apiConfig.Name, err)
+				if err == nil {
+					return
+				}
+				s.emit(oas.Event{Type: oas.EventLoadFailed, API: apiConfig.Name, Err: err})
+			case <-done:
+				return
+			}
+		}
+	}()
+}
+
+// setUnavailable records the load failure of an API, or clears it when err is nil. The failures are replaced by a
+// copy rather than updated, so that requests read them without locking.
+func (s *middlewareState) setUnavailable(name string, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	unavailable := maps.Clone(s.unavailableAPIs())
+	if unavailable == nil {
+		unavailable = make(map[string]error)
+	}
+	if err == nil {
+		delete(unavailable, name)
+	} else {
+		unavailable[name] = err
+	}
+	if len(unavailable) == 0 {
+		s.unavailable.Store(nil)
+		return
+	}
+	s.unavailable.Store(&unavailable)
+}
+
+// unavailableAPIs returns the load failures of the unavailable APIs, nil when every API is available.
+// The map must not be modified.
+func (s *middlewareState) unavailableAPIs() map[string]error {
+	if unavailable := s.unavailable.Load(); unavailable != nil {
+		return *unavailable
+	}
+	return nil
+}
+
+// Unavailable returns the load failures of the APIs of the active configuration that are not served, by name
+func (m *OASMiddleware) Unavailable() map[string]error {
+	unavailable := maps.Clone(m.state.Load().unavailableAPIs())
+	if unavailable == nil {
+		unavailable = make(map[string]error)
+	}
+	return unavailable
+}
+
+// rejectUnavailable writes the 503 response of a request for an API that failed to load
+func rejectUnavailable(w http.ResponseWriter, config *Config, name string) {
+	retryAfter := config.LoadRetryInterval.Duration
+	if retryAfter <= 0 {
+		retryAfter = defaultLoadRetryInterval
+	}
+	w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+	http.Error(w, fmt.Sprintf("API '%s' is unavailable", name), http.StatusServiceUnavailable)
+}
@@ -0,0 +1,93 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/lionelgarnier/validate-api-request/oas"
+)
+
+func TestLoadPolicy(t *testing.T) {
+	nextHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("OK"))
+	})
+
+	specFile := filepath.Join(t.TempDir(), "users.json")
+	newConfig := func(policy LoadPolicy) *Config {
+		config := CreateConfig()
+		config.SelectorType = "pathprefix"
+		config.Selector = map[string]string{"/pets": "pets", "/users": "users"}
+		config.APIs = []APIConfig{
+			{Name: "pets", SpecText: `{"openapi": "3.0.0", "paths": {"/pets": {"get": {}}}}`},
+			{Name: "users", SpecFile: specFile},
+		}
+		config.LoadPolicy = policy
+		config.LoadRetryInterval = oas.Duration{Duration: 10 * time.Millisecond}
+		return config
+	}
+
+	// The default policy refuses a configuration with a spec failing to load
+	_, err := New(nextHandler, newConfig(LoadPolicyAbort))
+	assert.Error(t, err)
+
+	var mu sync.Mutex
+	var failures []string
+	eventHandler := func(event oas.Event) {
+		if event.Type == oas.EventLoadFailed {
+			mu.Lock()
+			failures = append(failures, event.API)
+			mu.Unlock()
+		}
+	}
+
+	middleware, err := New(nextHandler, newConfig(LoadPolicyDegrade), WithEventHandler(eventHandler))
+	assert.NoError(t, err)
+	defer middleware.Close()
+
+	serve := func(path string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		rr := httptest.NewRecorder()
+		middleware.ServeHTTP(rr, req)
+		return rr
+	}
+
+	// The healthy API is served, the failed one is unavailable
+	assert.Equal(t, http.StatusOK, serve("/pets").Code)
+	rr := serve("/users")
+	assert.Equal(t, http.StatusServiceUnavailable, rr.Code)
+	assert.Equal(t, "1", rr.Header().Get("Retry-After"))
+	assert.Contains(t, middleware.Unavailable(), "users")
+	mu.Lock()
+	assert.Contains(t, failures, "users")
+	mu.Unlock()
+
+	// Requests read the unavailable APIs without taking the lock of the state, e.g. while a retry records its outcome
+	state := middleware.state.Load()
+	state.mu.Lock()
+	served := make(chan int, 1)
+	go func() {
+		served <- serve("/users").Code
+	}()
+	select {
+	case code := <-served:
+		assert.Equal(t, http.StatusServiceUnavailable, code)
+	case <-time.After(time.Second):
+		t.Error("request blocked by the lock of the state")
+	}
+	state.mu.Unlock()
+
+	// The failed API is loaded in the background once its spec is fixed
+	assert.NoError(t, os.WriteFile(specFile, []byte(`{"openapi": "3.0.0", "paths": {"/users": {"get": {}}}}`), 0o644))
+	assert.Eventually(t, func() bool {
+		return len(middleware.Unavailable()) == 0
+	}, time.Second, 5*time.Millisecond)
+	assert.Equal(t, http.StatusOK, serve("/users").Code)
+	assert.Nil(t, state.unavailableAPIs())
+}
@@ -0,0 +1,64 @@
+package middleware
+
+import (
+	"net/http"
+	"slices"
+	"strconv"
+
+	"github.com/lionelgarnier/validate-api-request/oas"
+	"github.com/lionelgarnier/validate-api-request/validation"
+)
+
+// DeprecationHandler receives the requests using deprecated operations, parameters or properties, e.g. to log
+// the clients still using them
+type DeprecationHandler func(r *http.Request, deprecations []oas.Deprecation)
+
+// WithDeprecationHandler sets the handler receiving the requests using deprecated parts of their spec
+func WithDeprecationHandler(handler DeprecationHandler) Option {
+	return func(m *OASMiddleware) {
+		m.deprecationHandler = handler
+	}
+}
+
+// reportDeprecations reports the deprecated parts of the spec used by an admitted request to the deprecation
+// handler and to the metrics and, when enabled, announces the deprecation of its operation in the `Deprecation`
+// response header, with the date of its `x-sunset` extension in the `Sunset` header
+func (m *OASMiddleware) reportDeprecations(w http.ResponseWriter, api string, oasRequest *oas.OASRequest, config *Config) {
+	if len(oasRequest.Deprecations) == 0 {
+		return
+	}
+	if m.deprecationHandler != nil {
+		m.deprecationHandler(oasRequest.Request, oasRequest.Deprecations)
+	}
+	if m.metrics != nil {
+		m.metrics.ObserveDeprecation(api, oasRequest.Request.Method, oasRequest.Route)
+	}
+
+	if !config.DeprecationHeaders || !slices.Contains(oasRequest.Deprecations, oas.Deprecation{Location: "operation"}) {
+		return
+	}
+	// RFC 9745 dates the deprecation as `@` followed by a Unix time
+	deprecation := "true"
+	if deprecatedAt := oasRequest.Operation.Lifecycle().DeprecatedAt; !deprecatedAt.IsZero() {
+		deprecation = "@" + strconv.FormatInt(deprecatedAt.Unix(), 10)
+	}
+	w.Header().Set("Deprecation", deprecation)
+	setSunset(w, oasRequest.Operation)
+}
+
+// announceSunset sets the `Sunset` header of the rejection of a request to an operation past its sunset
+func announceSunset(w http.ResponseWriter, oasRequest *oas.OASRequest, err error) {
+	if validation.CategoryOf(err) == validation.CategorySunset && oasRequest.Operation != nil {
+		setSunset(w, oasRequest.Operation)
+	}
+}
+
+// setSunset sets the `Sunset` header to the date of the `x-sunset` extension of an operation, as an HTTP date
+// when it is valid and unchanged otherwise
+func setSunset(w http.ResponseWriter, operation *oas.Operation) {
+	if sunset := operation.Lifecycle().Sunset; !sunset.IsZero() {
+		w.Header().Set("Sunset", sunset.UTC().Format(http.TimeFormat))
+	} else if sunset, ok := operation.Extensions["x-sunset"].(string); ok {
+		w.Header().Set("Sunset", sunset)
+	}
+}
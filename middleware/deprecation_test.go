@@ -0,0 +1,115 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/lionelgarnier/validate-api-request/oas"
+)
+
+func TestDeprecationHandler(t *testing.T) {
+	nextHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("OK"))
+	})
+
+	spec := `{
+        "openapi": "3.0.0",
+        "paths": {
+            "/pets": {
+                "get": {"deprecated": true, "x-sunset": "Wed, 01 Jul 2026 00:00:00 GMT"},
+                "post": {"parameters": [{"name": "dryRun", "in": "query", "deprecated": true, "schema": {"type": "boolean"}}]}
+            }
+        }
+    }`
+
+	tests := []struct {
+		name         string
+		method       string
+		path         string
+		headers      bool
+		deprecations []oas.Deprecation
+		deprecation  string
+		sunset       string
+	}{
+		{name: "Deprecated operation", method: http.MethodGet, path: "/pets", deprecations: []oas.Deprecation{{Location: "operation"}}},
+		{name: "Deprecated operation with headers", method: http.MethodGet, path: "/pets", headers: true, deprecations: []oas.Deprecation{{Location: "operation"}}, deprecation: "true", sunset: "Wed, 01 Jul 2026 00:00:00 GMT"},
+		{name: "Deprecated parameter", method: http.MethodPost, path: "/pets?dryRun=true", headers: true, deprecations: []oas.Deprecation{{Location: "query", Path: "dryRun"}}},
+		{name: "Nothing deprecated used", method: http.MethodPost, path: "/pets", headers: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := inlineConfig(spec)
+			config.DeprecationHeaders = tt.headers
+
+			var deprecations []oas.Deprecation
+			middleware, err := New(nextHandler, config, WithDeprecationHandler(func(r *http.Request, used []oas.Deprecation) {
+				deprecations = used
+			}))
+			assert.NoError(t, err)
+
+			rr := httptest.NewRecorder()
+			middleware.ServeHTTP(rr, httptest.NewRequest(tt.method, tt.path, nil))
+
+			assert.Equal(t, http.StatusOK, rr.Code)
+			assert.Equal(t, tt.deprecations, deprecations)
+			assert.Equal(t, tt.deprecation, rr.Header().Get("Deprecation"))
+			assert.Equal(t, tt.sunset, rr.Header().Get("Sunset"))
+		})
+	}
+}
+
+func TestSunset(t *testing.T) {
+	nextHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("OK"))
+	})
+
+	spec := `{
+        "openapi": "3.0.0",
+        "paths": {
+            "/v1/pets": {
+                "get": {"x-deprecated-at": "2001-01-01T00:00:00Z", "x-sunset": "2002-01-01"}
+            },
+            "/v2/pets": {
+                "get": {"x-deprecated-at": "2001-01-01T00:00:00Z", "x-sunset": "Sat, 01 Jan 2100 00:00:00 GMT"}
+            },
+            "/v3/pets": {
+                "get": {"x-deprecated-at": "2100-01-01T00:00:00Z"}
+            }
+        }
+    }`
+
+	tests := []struct {
+		name        string
+		path        string
+		enforce     bool
+		status      int
+		deprecation string
+		sunset      string
+	}{
+		{name: "Past sunset", path: "/v1/pets", status: http.StatusOK, deprecation: "@978307200", sunset: "Tue, 01 Jan 2002 00:00:00 GMT"},
+		{name: "Past sunset enforced", path: "/v1/pets", enforce: true, status: http.StatusGone, sunset: "Tue, 01 Jan 2002 00:00:00 GMT"},
+		{name: "Future sunset enforced", path: "/v2/pets", enforce: true, status: http.StatusOK, deprecation: "@978307200", sunset: "Fri, 01 Jan 2100 00:00:00 GMT"},
+		{name: "Future deprecation", path: "/v3/pets", enforce: true, status: http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := inlineConfig(spec)
+			config.DeprecationHeaders = true
+			config.EnforceSunset = tt.enforce
+			middleware, err := New(nextHandler, config)
+			assert.NoError(t, err)
+
+			rr := httptest.NewRecorder()
+			middleware.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, tt.path, nil))
+
+			assert.Equal(t, tt.status, rr.Code, rr.Body.String())
+			assert.Equal(t, tt.deprecation, rr.Header().Get("Deprecation"))
+			assert.Equal(t, tt.sunset, rr.Header().Get("Sunset"))
+		})
+	}
+}
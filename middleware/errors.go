@@ -0,0 +1,210 @@
+package middleware
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/lionelgarnier/validate-api-request/validation"
+)
+
+// StatusClientClosedRequest is the non-standard status of the requests canceled by their client, for logs and
+// metrics as the client does not read it
+const StatusClientClosedRequest = 499
+
+// StatusTable maps validation failure categories to HTTP statuses
+type StatusTable map[validation.Category]int
+
+// DefaultStatusTable returns the statuses matching the semantics of each failure category
+func DefaultStatusTable() StatusTable {
+	return StatusTable{
+		validation.CategoryPathNotFound:         http.StatusNotFound,
+		validation.CategoryMethodNotAllowed:     http.StatusMethodNotAllowed,
+		validation.CategorySunset:               http.StatusGone,
+		validation.CategoryInvalidParameter:     http.StatusBadRequest,
+		validation.CategoryLocaleNumber:         http.StatusBadRequest,
+		validation.CategoryUnsupportedMediaType: http.StatusUnsupportedMediaType,
+		validation.CategoryMalformedBody:        http.StatusBadRequest,
+		validation.CategoryBodyTooLarge:         http.StatusRequestEntityTooLarge,
+		validation.CategoryInvalidBody:          http.StatusBadRequest,
+		validation.CategoryComplexityExceeded:   http.StatusUnprocessableEntity,
+		validation.CategoryCanceled:             StatusClientClosedRequest,
+		validation.CategoryUnauthorized:         http.StatusUnauthorized,
+		validation.CategoryMalformedCredentials: http.StatusUnauthorized,
+		validation.CategoryForbidden:            http.StatusForbidden,
+		validation.CategoryUndeclaredStatus:     http.StatusBadGateway,
+		validation.CategoryInvalidResponse:      http.StatusBadGateway,
+	}
+}
+
+// ErrorFormat is the format of the body of the responses to requests failing validation
+type ErrorFormat string
+
+const (
+	// ErrorFormatText writes the failure message as plain text
+	ErrorFormatText ErrorFormat = ""
+	// ErrorFormatJSON writes the failure and its violations as a JSON object
+	ErrorFormatJSON ErrorFormat = "json"
+	// ErrorFormatProblem writes the failure as an RFC 7807 `application/problem+json` object
+	ErrorFormatProblem ErrorFormat = "problem"
+)
+
+// ErrorEncoder writes the response of a request failing validation
+type ErrorEncoder struct {
+	// Statuses maps failure categories to response statuses, uncategorized failures are rejected with DefaultStatus
+	Statuses      StatusTable
+	DefaultStatus int
+	Format        ErrorFormat
+	// SpecURL prefixes the spec pointers of JSON violations, e.g. the URL the spec is served from
+	SpecURL string
+	// ProblemTypeURL prefixes the failure category to build the problem type, problems are `about:blank` without it
+	ProblemTypeURL string
+}
+
+// problemBody is the RFC 7807 body of the responses to requests failing validation
+type problemBody struct {
+	Type          string         `json:"type"`
+	Title         string         `json:"title"`
+	Status        int            `json:"status"`
+	Detail        string         `json:"detail"`
+	InvalidParams []invalidParam `json:"invalid-params,omitempty"`
+	RequestID     string         `json:"requestId,omitempty"`
+}
+
+// invalidParam is a violation of a problem
+type invalidParam struct {
+	Name   string `json:"name,omitempty"`
+	Reason string `json:"reason"`
+	Spec   string `json:"spec,omitempty"`
+}
+
+// errorBody is the JSON body of the responses to requests failing validation
+type errorBody struct {
+	Status    int                    `json:"status"`
+	Category  validation.Category    `json:"category,omitempty"`
+	Message   string                 `json:"message"`
+	Errors    []validation.Violation `json:"errors"`
+	RequestID string                 `json:"requestId,omitempty"`
+}
+
+// NewErrorEncoder creates an encoder using the default status table
+func NewErrorEncoder() *ErrorEncoder {
+	return &ErrorEncoder{
+		Statuses:      DefaultStatusTable(),
+		DefaultStatus: http.StatusBadRequest,
+	}
+}
+
+// Status returns the response status of a validation failure
+func (e *ErrorEncoder) Status(err error) int {
+	if status, exists := e.Statuses[validation.CategoryOf(err)]; exists {
+		return status
+	}
+	return e.DefaultStatus
+}
+
+// Encode writes the validation failure to the response
+func (e *ErrorEncoder) Encode(w http.ResponseWriter, r *http.Request, err error) {
+	status := e.Status(err)
+
+	var validationErr *validation.ValidationError
+	if errors.As(err, &validationErr) {
+		if status == http.StatusMethodNotAllowed && len(validationErr.Allow) > 0 {
+			w.Header().Set("Allow", strings.Join(validationErr.Allow, ", "))
+		}
+		if status == http.StatusUnauthorized || status == http.StatusForbidden {
+			for _, challenge := range validationErr.Challenges {
+				w.Header().Add("WWW-Authenticate", challenge)
+			}
+		}
+	}
+
+	switch e.Format {
+	case ErrorFormatJSON:
+		e.encodeJSON(w, r, status, err)
+		return
+	case ErrorFormatProblem:
+		e.encodeProblem(w, r, status, err)
+		return
+	}
+	message := err.Error()
+	if id := RequestID(r); id != "" {
+		message += "\nrequest ID: " + id
+	}
+	http.Error(w, message, status)
+}
+
+// encodeJSON writes the failure and its violations, linked to the spec, as a JSON object
+func (e *ErrorEncoder) encodeJSON(w http.ResponseWriter, r *http.Request, status int, err error) {
+	violations := validation.Violations(err)
+	for i := range violations {
+		if violations[i].Spec != "" {
+			violations[i].Spec = e.SpecURL + violations[i].Spec
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(errorBody{
+		Status:    status,
+		Category:  validation.CategoryOf(err),
+		Message:   err.Error(),
+		Errors:    violations,
+		RequestID: RequestID(r),
+	})
+}
+
+// encodeProblem writes the failure as an RFC 7807 problem, its violations are the invalid params
+func (e *ErrorEncoder) encodeProblem(w http.ResponseWriter, r *http.Request, status int, err error) {
+	problem := problemBody{
+		Type:      "about:blank",
+		Title:     http.StatusText(status),
+		Status:    status,
+		Detail:    err.Error(),
+		RequestID: RequestID(r),
+	}
+	if category := validation.CategoryOf(err); category != "" && e.ProblemTypeURL != "" {
+		problem.Type = e.ProblemTypeURL + string(category)
+	}
+	for _, violation := range validation.Violations(err) {
+		param := invalidParam{Name: violation.Path, Reason: violation.Reason}
+		if violation.Spec != "" {
+			param.Spec = e.SpecURL + violation.Spec
+		}
+		problem.InvalidParams = append(problem.InvalidParams, param)
+	}
+
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(problem)
+}
+
+// WithErrorEncoder sets the encoder writing the responses of requests failing validation
+func WithErrorEncoder(encoder *ErrorEncoder) Option {
+	return func(m *OASMiddleware) {
+		m.errorEncoder = encoder
+	}
+}
+
+// ErrorHandler writes the response of a request failing validation at a stage, replacing the error encoder,
+// e.g. to map failures to another response format, log them or count them
+type ErrorHandler func(w http.ResponseWriter, r *http.Request, err error, stage validation.Stage)
+
+// WithErrorHandler sets the handler writing the responses of requests failing validation
+func WithErrorHandler(handler ErrorHandler) Option {
+	return func(m *OASMiddleware) {
+		m.errorHandler = handler
+	}
+}
+
+// handleError writes the response of a request failing validation, with the error handler if any
+func (m *OASMiddleware) handleError(w http.ResponseWriter, r *http.Request, err error) {
+	if m.errorHandler != nil {
+		m.errorHandler(w, r, err, validation.StageOf(err))
+		return
+	}
+	m.errorEncoder.Encode(w, r, err)
+}
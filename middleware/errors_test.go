@@ -0,0 +1,478 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/lionelgarnier/validate-api-request/validation"
+)
+
+func TestErrorStatuses(t *testing.T) {
+	nextHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("OK"))
+	})
+
+	config := inlineConfig(`{
+        "openapi": "3.0.0",
+        "paths": {
+            "/pets": {
+                "get": {
+                    "parameters": [{"name": "limit", "in": "query", "schema": {"type": "integer"}}]
+                },
+                "post": {
+                    "requestBody": {"content": {"application/json": {"schema": {"type": "object", "required": ["name"]}}}}
+                }
+            },
+            "/admin": {
+                "get": {"security": [{"token": []}]}
+            }
+        },
+        "components": {
+            "securitySchemes": {"token": {"type": "http", "scheme": "bearer"}}
+        }
+    }`)
+
+	tests := []struct {
+		name        string
+		method      string
+		path        string
+		contentType string
+		body        string
+		status      int
+		allow       string
+	}{
+		{name: "Valid request", method: http.MethodGet, path: "/pets?limit=5", status: http.StatusOK},
+		{name: "Unknown path", method: http.MethodGet, path: "/users", status: http.StatusNotFound},
+		{name: "Method not allowed", method: http.MethodDelete, path: "/pets", status: http.StatusMethodNotAllowed, allow: "GET, POST"},
+		{name: "Invalid parameter", method: http.MethodGet, path: "/pets?limit=five", status: http.StatusBadRequest},
+		{name: "Unsupported media type", method: http.MethodPost, path: "/pets", contentType: "text/plain", body: "name", status: http.StatusUnsupportedMediaType},
+		{name: "Malformed body", method: http.MethodPost, path: "/pets", contentType: "application/json", body: "{", status: http.StatusBadRequest},
+		{name: "Invalid body", method: http.MethodPost, path: "/pets", contentType: "application/json", body: "{}", status: http.StatusBadRequest},
+		{name: "Missing credentials", method: http.MethodGet, path: "/admin", status: http.StatusUnauthorized},
+	}
+
+	middleware, err := New(nextHandler, config)
+	assert.NoError(t, err)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body))
+			if tt.contentType != "" {
+				req.Header.Set("Content-Type", tt.contentType)
+			}
+			rr := httptest.NewRecorder()
+			middleware.ServeHTTP(rr, req)
+
+			assert.Equal(t, tt.status, rr.Code)
+			assert.Equal(t, tt.allow, rr.Header().Get("Allow"))
+		})
+	}
+
+	// The status table can be customized
+	encoder := NewErrorEncoder()
+	encoder.Statuses[validation.CategoryInvalidBody] = http.StatusUnprocessableEntity
+	middleware, err = New(nextHandler, config, WithErrorEncoder(encoder))
+	assert.NoError(t, err)
+
+	req := httptest.NewRequest(http.MethodPost, "/pets", strings.NewReader("{}"))
+	req.Header.Set("Content-Type", "application/json")
+	rr := httptest.NewRecorder()
+	middleware.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusUnprocessableEntity, rr.Code)
+
+	// Requests exceeding the schema evaluations are rejected as too complex
+	config = inlineConfig(`{
+        "openapi": "3.0.0",
+        "paths": {
+            "/tags": {
+                "post": {
+                    "requestBody": {"content": {"application/json": {"schema": {"type": "array", "items": {"type": "string"}}}}}
+                }
+            }
+        }
+    }`)
+	config.MaxSchemaEvaluations = 2
+	middleware, err = New(nextHandler, config)
+	assert.NoError(t, err)
+
+	for body, status := range map[string]int{`["a"]`: http.StatusOK, `["a", "b"]`: http.StatusUnprocessableEntity} {
+		req = httptest.NewRequest(http.MethodPost, "/tags", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		rr = httptest.NewRecorder()
+		middleware.ServeHTTP(rr, req)
+		assert.Equal(t, status, rr.Code, body)
+	}
+}
+
+func TestAuthenticateChallenges(t *testing.T) {
+	nextHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("OK"))
+	})
+
+	config := inlineConfig(`{
+        "openapi": "3.0.0",
+        "paths": {
+            "/admin": {
+                "get": {"security": [{"basic": []}, {"oauth": ["admin", "read"]}, {"key": []}]}
+            },
+            "/reports": {
+                "get": {"security": [{"key": []}]}
+            },
+            "/tokens": {
+                "get": {"security": [{"jwt": []}]}
+            }
+        },
+        "components": {
+            "securitySchemes": {
+                "basic": {"type": "http", "scheme": "basic"},
+                "oauth": {"type": "oauth2", "flows": {}},
+                "key": {"type": "apiKey", "in": "header", "name": "X-API-Key"},
+                "jwt": {"type": "http", "scheme": "bearer", "bearerFormat": "JWT"}
+            }
+        }
+    }`)
+
+	tests := []struct {
+		name          string
+		path          string
+		authorization string
+		challenges    []string
+	}{
+		{
+			name:       "Missing credentials",
+			path:       "/admin",
+			challenges: []string{`Basic realm="inline", charset="UTF-8"`, `Bearer realm="inline", scope="admin read"`},
+		},
+		{
+			name:          "Empty bearer token",
+			path:          "/admin",
+			authorization: "Bearer ",
+			challenges:    []string{`Basic realm="inline", charset="UTF-8"`, `Bearer realm="inline", scope="admin read"`},
+		},
+		{
+			name:       "API key only",
+			path:       "/reports",
+			challenges: nil,
+		},
+		{
+			name:          "Malformed JWT",
+			path:          "/tokens",
+			authorization: "Bearer not-a-jwt",
+			challenges:    []string{`Bearer realm="inline", error="invalid_token", error_description="bearer token is not a well-formed JWT token"`},
+		},
+	}
+
+	middleware, err := New(nextHandler, config)
+	assert.NoError(t, err)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			if tt.authorization != "" {
+				req.Header.Set("Authorization", tt.authorization)
+			}
+			rr := httptest.NewRecorder()
+			middleware.ServeHTTP(rr, req)
+
+			assert.Equal(t, http.StatusUnauthorized, rr.Code)
+			assert.Equal(t, tt.challenges, rr.Header().Values("WWW-Authenticate"))
+		})
+	}
+}
+
+func TestInsufficientScope(t *testing.T) {
+	nextHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("OK"))
+	})
+
+	config := inlineConfig(`{
+        "openapi": "3.0.0",
+        "paths": {
+            "/admin": {
+                "get": {"security": [{"oauth": ["admin"]}]}
+            }
+        },
+        "components": {
+            "securitySchemes": {
+                "oauth": {"type": "oauth2", "flows": {}}
+            }
+        }
+    }`)
+
+	resolver := func(r *http.Request, scheme string) []string {
+		if r.Header.Get("Authorization") == "Bearer admin-token" {
+			return []string{"admin"}
+		}
+		return []string{"read"}
+	}
+	middleware, err := New(nextHandler, config, WithScopeResolver(resolver))
+	assert.NoError(t, err)
+
+	req := httptest.NewRequest(http.MethodGet, "/admin", nil)
+	req.Header.Set("Authorization", "Bearer read-token")
+	rr := httptest.NewRecorder()
+	middleware.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusForbidden, rr.Code)
+	assert.Equal(t, []string{`Bearer realm="inline", scope="admin", error="insufficient_scope"`}, rr.Header().Values("WWW-Authenticate"))
+
+	req = httptest.NewRequest(http.MethodGet, "/admin", nil)
+	req.Header.Set("Authorization", "Bearer admin-token")
+	rr = httptest.NewRecorder()
+	middleware.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusOK, rr.Code)
+}
+
+func TestJSONErrors(t *testing.T) {
+	nextHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("OK"))
+	})
+
+	config := inlineConfig(`{
+        "openapi": "3.0.0",
+        "paths": {
+            "/pet": {
+                "post": {
+                    "requestBody": {"content": {"application/json": {"schema": {"type": "object", "properties": {"age": {"type": "integer"}}}}}}
+                }
+            }
+        }
+    }`)
+
+	encoder := NewErrorEncoder()
+	encoder.Format = ErrorFormatJSON
+	encoder.SpecURL = "https://api.example.com/openapi.json"
+	middleware, err := New(nextHandler, config, WithErrorEncoder(encoder))
+	assert.NoError(t, err)
+
+	req := httptest.NewRequest(http.MethodPost, "/pet", strings.NewReader(`{"age": "old"}`))
+	req.Header.Set("Content-Type", "application/json")
+	rr := httptest.NewRecorder()
+	middleware.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+	assert.Equal(t, "application/json", rr.Header().Get("Content-Type"))
+	assert.JSONEq(t, `{
+        "status": 400,
+        "category": "invalidBody",
+        "message": "request body does not match schema: age: expected integer",
+        "errors": [{
+            "message": "request body does not match schema: age: expected integer",
+            "path": "age",
+            "spec": "https://api.example.com/openapi.json#/paths/~1pet/post/requestBody/content/application~1json/schema/properties/age"
+        }]
+    }`, rr.Body.String())
+}
+
+func TestCollectAllErrors(t *testing.T) {
+	nextHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("OK"))
+	})
+
+	config := inlineConfig(`{
+        "openapi": "3.0.0",
+        "paths": {
+            "/pet": {
+                "post": {
+                    "requestBody": {"content": {"application/json": {"schema": {"type": "object", "properties": {"age": {"type": "integer"}, "name": {"type": "string"}}}}}}
+                }
+            }
+        }
+    }`)
+	config.CollectAllErrors = true
+
+	encoder := NewErrorEncoder()
+	encoder.Format = ErrorFormatJSON
+	encoder.Statuses[validation.CategoryInvalidBody] = http.StatusUnprocessableEntity
+	middleware, err := New(nextHandler, config, WithErrorEncoder(encoder))
+	assert.NoError(t, err)
+
+	req := httptest.NewRequest(http.MethodPost, "/pet", strings.NewReader(`{"age": "old", "name": 3}`))
+	req.Header.Set("Content-Type", "application/json")
+	rr := httptest.NewRecorder()
+	middleware.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusUnprocessableEntity, rr.Code)
+	assert.JSONEq(t, `{
+        "status": 422,
+        "category": "invalidBody",
+        "message": "request body does not match schema: age: expected integer; request body does not match schema: name: expected string",
+        "errors": [
+            {
+                "message": "request body does not match schema: age: expected integer",
+                "path": "age",
+                "spec": "#/paths/~1pet/post/requestBody/content/application~1json/schema/properties/age"
+            },
+            {
+                "message": "request body does not match schema: name: expected string",
+                "path": "name",
+                "spec": "#/paths/~1pet/post/requestBody/content/application~1json/schema/properties/name"
+            }
+        ]
+    }`, rr.Body.String())
+}
+
+func TestProblemErrors(t *testing.T) {
+	nextHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("OK"))
+	})
+
+	config := inlineConfig(`{
+        "openapi": "3.0.0",
+        "paths": {
+            "/pet": {
+                "post": {
+                    "parameters": [{"name": "limit", "in": "query", "required": true, "schema": {"type": "integer"}}],
+                    "requestBody": {"content": {"application/json": {"schema": {"type": "object", "properties": {"age": {"type": "integer"}}}}}}
+                }
+            }
+        }
+    }`)
+	config.CollectAllErrors = true
+
+	tests := []struct {
+		name     string
+		encoder  func(*ErrorEncoder)
+		path     string
+		body     string
+		expected string
+	}{
+		{
+			name: "Invalid params",
+			path: "/pet?limit=ten",
+			body: `{"age": "old"}`,
+			expected: `{
+                "type": "about:blank",
+                "title": "Bad Request",
+                "status": 400,
+                "detail": "invalid type for parameter 'limit': limit: expected integer; request body does not match schema: age: expected integer",
+                "invalid-params": [
+                    {"name": "limit", "reason": "expected integer", "spec": "#/paths/~1pet/post/parameters/0/schema"},
+                    {"name": "age", "reason": "expected integer", "spec": "#/paths/~1pet/post/requestBody/content/application~1json/schema/properties/age"}
+                ]
+            }`,
+		},
+		{
+			name:    "Problem type by category",
+			encoder: func(e *ErrorEncoder) { e.ProblemTypeURL = "https://errors.example.com/" },
+			path:    "/pet",
+			body:    `{}`,
+			expected: `{
+                "type": "https://errors.example.com/invalidParameter",
+                "title": "Bad Request",
+                "status": 400,
+                "detail": "missing required parameter 'limit'",
+                "invalid-params": [
+                    {"reason": "missing required parameter 'limit'", "spec": "#/paths/~1pet/post/parameters/0"}
+                ]
+            }`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			encoder := NewErrorEncoder()
+			encoder.Format = ErrorFormatProblem
+			if tt.encoder != nil {
+				tt.encoder(encoder)
+			}
+			middleware, err := New(nextHandler, config, WithErrorEncoder(encoder))
+			assert.NoError(t, err)
+
+			req := httptest.NewRequest(http.MethodPost, tt.path, strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			rr := httptest.NewRecorder()
+			middleware.ServeHTTP(rr, req)
+
+			assert.Equal(t, http.StatusBadRequest, rr.Code)
+			assert.Equal(t, "application/problem+json", rr.Header().Get("Content-Type"))
+			assert.JSONEq(t, tt.expected, rr.Body.String())
+		})
+	}
+}
+
+func TestErrorHandler(t *testing.T) {
+	nextHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("OK"))
+	})
+
+	config := inlineConfig(`{
+        "openapi": "3.0.0",
+        "paths": {
+            "/pet": {
+                "post": {
+                    "parameters": [{"name": "limit", "in": "query", "schema": {"type": "integer"}}],
+                    "requestBody": {"content": {"application/json": {"schema": {"type": "object", "required": ["name"]}}}}
+                }
+            }
+        }
+    }`)
+
+	var stage validation.Stage
+	handler := func(w http.ResponseWriter, r *http.Request, err error, s validation.Stage) {
+		stage = s
+		w.WriteHeader(http.StatusTeapot)
+		w.Write([]byte(string(validation.CategoryOf(err))))
+	}
+
+	tests := []struct {
+		name          string
+		method        string
+		path          string
+		body          string
+		expectedStage validation.Stage
+		expectedBody  string
+	}{
+		{
+			name:          "Unknown path",
+			method:        http.MethodPost,
+			path:          "/unknown",
+			body:          `{"name": "Rex"}`,
+			expectedStage: validation.StagePath,
+			expectedBody:  "pathNotFound",
+		},
+		{
+			name:          "Undeclared method",
+			method:        http.MethodGet,
+			path:          "/pet",
+			expectedStage: validation.StageMethod,
+			expectedBody:  "methodNotAllowed",
+		},
+		{
+			name:          "Invalid parameter",
+			method:        http.MethodPost,
+			path:          "/pet?limit=ten",
+			body:          `{"name": "Rex"}`,
+			expectedStage: validation.StageParameters,
+			expectedBody:  "invalidParameter",
+		},
+		{
+			name:          "Invalid body",
+			method:        http.MethodPost,
+			path:          "/pet",
+			body:          `{}`,
+			expectedStage: validation.StageBody,
+			expectedBody:  "invalidBody",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			middleware, err := New(nextHandler, config, WithErrorHandler(handler))
+			assert.NoError(t, err)
+
+			stage = ""
+			req := httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			rr := httptest.NewRecorder()
+			middleware.ServeHTTP(rr, req)
+
+			assert.Equal(t, http.StatusTeapot, rr.Code)
+			assert.Equal(t, tt.expectedStage, stage)
+			assert.Equal(t, tt.expectedBody, rr.Body.String())
+		})
+	}
+}
@@ -0,0 +1,227 @@
+package fibermiddleware
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/valyala/fasthttp"
+
+	"github.com/lionelgarnier/validate-api-request/middleware"
+	"github.com/lionelgarnier/validate-api-request/validation"
+)
+
+func TestFiberHandler(t *testing.T) {
+	config := middleware.CreateConfig()
+	config.SelectorType = "fixed"
+	config.Selector = map[string]string{"default": "inline"}
+	config.RequestIDHeader = "X-Request-Id"
+	config.APIs = []middleware.APIConfig{{Name: "inline", SpecText: `{
+        "openapi": "3.0.0",
+        "paths": {
+            "/pets/{petId}": {
+                "get": {
+                    "parameters": [
+                        {"name": "petId", "in": "path", "required": true, "schema": {"type": "integer"}},
+                        {"name": "fields", "in": "query", "schema": {"type": "string", "enum": ["name", "all"]}}
+                    ]
+                },
+                "put": {
+                    "parameters": [{"name": "petId", "in": "path", "required": true, "schema": {"type": "integer"}}],
+                    "requestBody": {
+                        "required": true,
+                        "content": {"application/json": {"schema": {"type": "object", "required": ["name"]}}}
+                    }
+                }
+            }
+        }
+    }`}}
+
+	handler, _, err := New(config)
+	assert.NoError(t, err)
+
+	app := fiber.New()
+	app.Use(handler)
+	app.Get("/pets/:petId", func(c *fiber.Ctx) error {
+		oasRequest, ok := Request(c)
+		assert.True(t, ok)
+		assert.Equal(t, "/pets/{petId}", oasRequest.Route)
+		return c.SendString("pet " + PathParam(c, "petId"))
+	})
+	app.Put("/pets/:petId", func(c *fiber.Ctx) error {
+		// The body is still readable once validated
+		return c.Send(c.Body())
+	})
+
+	tests := []struct {
+		name     string
+		method   string
+		path     string
+		body     string
+		expected int
+		response string
+	}{
+		{name: "Valid request", method: http.MethodGet, path: "/pets/42?fields=all", expected: http.StatusOK, response: "pet 42"},
+		{name: "Invalid path parameter", method: http.MethodGet, path: "/pets/kitty", expected: http.StatusBadRequest},
+		{name: "Invalid query parameter", method: http.MethodGet, path: "/pets/42?fields=none", expected: http.StatusBadRequest},
+		{name: "Valid body", method: http.MethodPut, path: "/pets/42", body: `{"name": "Kitty"}`, expected: http.StatusOK, response: `{"name": "Kitty"}`},
+		{name: "Invalid body", method: http.MethodPut, path: "/pets/42", body: `{}`, expected: http.StatusBadRequest},
+		{name: "Undeclared method", method: http.MethodDelete, path: "/pets/42", expected: http.StatusMethodNotAllowed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body))
+			if tt.body != "" {
+				req.Header.Set("Content-Type", "application/json")
+			}
+			resp, err := app.Test(req)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, resp.StatusCode)
+			assert.NotEmpty(t, resp.Header.Get("X-Request-Id"))
+			if tt.response != "" {
+				body, _ := io.ReadAll(resp.Body)
+				assert.Equal(t, tt.response, string(body))
+			}
+		})
+	}
+}
+
+func TestFiberForwarding(t *testing.T) {
+	config := middleware.CreateConfig()
+	config.SelectorType = "fixed"
+	config.Selector = map[string]string{"default": "inline"}
+	config.RequestIDHeader = "X-Request-Id"
+	config.Responses = middleware.ResponsesEnforce
+	config.APIs = []middleware.APIConfig{{Name: "inline", ReadOnlyProperties: validation.ReadOnlyStrip, SpecText: `{
+        "openapi": "3.0.0",
+        "paths": {
+            "/pets": {
+                "post": {
+                    "requestBody": {
+                        "content": {"application/json": {"schema": {"type": "object", "properties": {"id": {"type": "integer", "readOnly": true}}}}}
+                    },
+                    "responses": {"200": {"description": "OK", "content": {"application/json": {"schema": {"type": "object", "required": ["id"]}}}}}
+                }
+            }
+        }
+    }`}}
+
+	handler, _, err := New(config)
+	assert.NoError(t, err)
+
+	app := fiber.New()
+	app.Use(handler)
+	app.Post("/pets", func(c *fiber.Ctx) error {
+		// The generated request ID and the stripped body are forwarded
+		assert.Equal(t, c.Locals(RequestIDKey), c.Get("X-Request-Id"))
+		assert.JSONEq(t, `{"name": "Rex"}`, string(c.Body()))
+		// Responses are written by the Fiber chain, so they are not validated
+		return c.JSON(fiber.Map{"name": "Rex"})
+	})
+
+	req := httptest.NewRequest(http.MethodPost, "/pets", strings.NewReader(`{"id": 1, "name": "Rex"}`))
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := app.Test(req)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.NotEmpty(t, resp.Header.Get("X-Request-Id"))
+}
+
+func TestFiberConcurrency(t *testing.T) {
+	config := middleware.CreateConfig()
+	config.SelectorType = "fixed"
+	config.Selector = map[string]string{"default": "inline"}
+	config.APIs = []middleware.APIConfig{{
+		Name:        "inline",
+		Concurrency: &middleware.ConcurrencyConfig{MaxInFlight: 1},
+		SpecText:    `{"openapi": "3.0.0", "paths": {"/pets": {"get": {}}}}`,
+	}}
+	m, err := middleware.New(http.NotFoundHandler(), config)
+	assert.NoError(t, err)
+
+	newCtx := func() *fasthttp.RequestCtx {
+		var req fasthttp.Request
+		req.Header.SetMethod(http.MethodGet)
+		req.SetRequestURI("/pets")
+		ctx := &fasthttp.RequestCtx{}
+		ctx.Init(&req, nil, nil)
+		return ctx
+	}
+
+	_, release, ok := AdmitLimited(m, newCtx())
+	assert.True(t, ok)
+
+	// The slot of the first request is taken until it is released
+	ctx := newCtx()
+	_, _, ok = AdmitLimited(m, ctx)
+	assert.False(t, ok)
+	assert.Equal(t, http.StatusServiceUnavailable, ctx.Response.StatusCode())
+
+	release()
+	_, release, ok = AdmitLimited(m, newCtx())
+	assert.True(t, ok)
+	release()
+
+	// Admit does not apply the limits
+	_, release, _ = AdmitLimited(m, newCtx())
+	defer release()
+	_, ok = Admit(m, newCtx())
+	assert.True(t, ok)
+}
+
+func BenchmarkAdmit(b *testing.B) {
+	config := middleware.CreateConfig()
+	config.SelectorType = "fixed"
+	config.Selector = map[string]string{"default": "inline"}
+	config.APIs = []middleware.APIConfig{{Name: "inline", SpecText: `{
+        "openapi": "3.0.0",
+        "paths": {
+            "/pets/{petId}": {
+                "put": {
+                    "parameters": [
+                        {"name": "petId", "in": "path", "required": true, "schema": {"type": "integer"}},
+                        {"name": "X-Tenant", "in": "header", "schema": {"type": "string"}}
+                    ],
+                    "requestBody": {
+                        "content": {"application/json": {"schema": {"type": "object", "required": ["name"], "properties": {"name": {"type": "string"}}}}}
+                    }
+                }
+            }
+        }
+    }`}}
+	m, err := middleware.New(http.NotFoundHandler(), config)
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	ctx := &fasthttp.RequestCtx{}
+	ctx.Init(&fasthttp.Request{}, nil, nil)
+	ctx.Request.Header.SetMethod(http.MethodPut)
+	ctx.Request.SetRequestURI("/pets/1?fields=all")
+	ctx.Request.Header.SetContentType("application/json")
+	ctx.Request.Header.Set("X-Tenant", "acme")
+	ctx.Request.Header.Set("User-Agent", "benchmark")
+	ctx.Request.Header.Set("Accept", "application/json")
+	ctx.Request.SetBodyString(`{"name": "Rex"}`)
+
+	// The conversion to a net/http request, and the whole admission it is part of
+	b.Run("newRequest", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			newRequest(ctx)
+		}
+	})
+	b.Run("Admit", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			if _, ok := Admit(m, ctx); !ok {
+				b.Fatal("request rejected")
+			}
+		}
+	})
+}
@@ -0,0 +1,50 @@
+package fibermiddleware
+
+import (
+	"net/http"
+
+	"github.com/valyala/fasthttp"
+)
+
+// responseWriter writes the responses of the OAS middleware to a fasthttp response
+type responseWriter struct {
+	ctx           *fasthttp.RequestCtx
+	header        http.Header
+	headerWritten bool
+}
+
+// Header returns the headers to write
+func (w *responseWriter) Header() http.Header {
+	return w.header
+}
+
+// WriteHeader writes the headers and the status
+func (w *responseWriter) WriteHeader(status int) {
+	if w.headerWritten {
+		return
+	}
+	w.writeHeaders()
+	w.ctx.SetStatusCode(status)
+}
+
+// Write writes the body
+func (w *responseWriter) Write(data []byte) (int, error) {
+	if !w.headerWritten {
+		w.WriteHeader(http.StatusOK)
+	}
+	return w.ctx.Write(data)
+}
+
+// writeHeaders copies the headers to the fasthttp response, including those set on accepted requests
+func (w *responseWriter) writeHeaders() {
+	if w.headerWritten {
+		return
+	}
+	w.headerWritten = true
+	for key, values := range w.header {
+		w.ctx.Response.Header.Del(key)
+		for _, value := range values {
+			w.ctx.Response.Header.Add(key, value)
+		}
+	}
+}
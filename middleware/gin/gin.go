@@ -0,0 +1,94 @@
+// Package ginmiddleware adapts the OAS middleware to Gin
+package ginmiddleware
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/lionelgarnier/validate-api-request/middleware"
+	"github.com/lionelgarnier/validate-api-request/oas"
+	"github.com/lionelgarnier/validate-api-request/validation"
+)
+
+// Keys of the values set on the Gin context of validated requests
+const (
+	// RequestKey holds the *oas.OASRequest, with the route, path item and operation of the request
+	RequestKey = "oas.request"
+	// PathParamsKey holds the values of the path parameters declared by the route, by name
+	PathParamsKey = "oas.pathParams"
+	// RouteKey holds the *middleware.Route, with the path template, operationId and principal of the request
+	RouteKey = "oas.route"
+)
+
+// acceptedKey marks the requests accepted by the OAS middleware
+const acceptedKey = "oas.accepted"
+
+// contextKey is the key of the Gin context in the context of the requests passed to the OAS middleware
+type contextKey struct{}
+
+// New creates an OAS middleware from the configuration and returns it as a Gin handler.
+// The middleware is returned too, e.g. to reload its configuration.
+func New(config *middleware.Config, opts ...middleware.Option) (gin.HandlerFunc, *middleware.OASMiddleware, error) {
+	m, err := middleware.New(http.HandlerFunc(next), config, opts...)
+	if err != nil {
+		return nil, nil, err
+	}
+	return Handler(m), m, nil
+}
+
+// Handler returns a Gin handler validating requests with an OAS middleware created with New, whose next handler
+// continues the Gin chain. Requests failing validation get the response of the middleware and abort the chain.
+func Handler(m *middleware.OASMiddleware) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		r := c.Request.WithContext(context.WithValue(c.Request.Context(), contextKey{}, c))
+		m.ServeHTTP(c.Writer, r)
+		if _, accepted := c.Get(acceptedKey); !accepted {
+			c.Abort()
+		}
+	}
+}
+
+// next continues the Gin chain of a request accepted by the OAS middleware, with the request it forwards and
+// the response writer it validates
+func next(w http.ResponseWriter, r *http.Request) {
+	c, ok := r.Context().Value(contextKey{}).(*gin.Context)
+	if !ok {
+		http.Error(w, "OAS middleware is not running in a Gin chain", http.StatusInternalServerError)
+		return
+	}
+
+	c.Set(acceptedKey, true)
+	if oasRequest, ok := middleware.ValidatedRequest(r); ok {
+		c.Set(RequestKey, oasRequest)
+		c.Set(PathParamsKey, validation.PathParams(oasRequest))
+		if route, ok := middleware.RouteOf(r); ok {
+			c.Set(RouteKey, route)
+		}
+	}
+
+	writer := c.Writer
+	if w != http.ResponseWriter(writer) {
+		c.Writer = &responseWriter{ResponseWriter: w, status: http.StatusOK, size: -1}
+		defer func() { c.Writer = writer }()
+	}
+	c.Request = r
+	c.Next()
+}
+
+// Request returns the OAS request of a request validated by the middleware
+func Request(c *gin.Context) (*oas.OASRequest, bool) {
+	value, exists := c.Get(RequestKey)
+	if !exists {
+		return nil, false
+	}
+	oasRequest, ok := value.(*oas.OASRequest)
+	return oasRequest, ok
+}
+
+// PathParam returns the value of a path parameter declared by the route of a request validated by the middleware
+func PathParam(c *gin.Context, name string) string {
+	params, _ := c.Value(PathParamsKey).(map[string]string)
+	return params[name]
+}
@@ -0,0 +1,69 @@
+package ginmiddleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/lionelgarnier/validate-api-request/middleware"
+)
+
+func TestGinHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	config := middleware.CreateConfig()
+	config.SelectorType = "fixed"
+	config.Selector = map[string]string{"default": "inline"}
+	config.Responses = middleware.ResponsesEnforce
+	config.APIs = []middleware.APIConfig{{Name: "inline", SpecText: `{
+        "openapi": "3.0.0",
+        "paths": {
+            "/pets/{petId}": {
+                "get": {
+                    "parameters": [{"name": "petId", "in": "path", "required": true, "schema": {"type": "integer"}}],
+                    "responses": {
+                        "200": {"description": "A pet", "content": {"application/json": {"schema": {"type": "object", "required": ["id"]}}}}
+                    }
+                }
+            }
+        }
+    }`}}
+
+	handler, _, err := New(config)
+	assert.NoError(t, err)
+
+	router := gin.New()
+	router.Use(handler)
+	router.GET("/pets/:petId", func(c *gin.Context) {
+		if PathParam(c, "petId") == "13" {
+			c.JSON(http.StatusOK, gin.H{"name": "Kitty"})
+			return
+		}
+		oasRequest, ok := Request(c)
+		assert.True(t, ok)
+		assert.Equal(t, "/pets/{petId}", oasRequest.Route)
+		c.JSON(http.StatusOK, gin.H{"id": PathParam(c, "petId")})
+	})
+
+	tests := []struct {
+		name     string
+		path     string
+		expected int
+	}{
+		{name: "Valid request", path: "/pets/42", expected: http.StatusOK},
+		{name: "Invalid path parameter", path: "/pets/kitty", expected: http.StatusBadRequest},
+		{name: "Invalid response", path: "/pets/13", expected: http.StatusBadGateway},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, req)
+			assert.Equal(t, tt.expected, rr.Code)
+		})
+	}
+}
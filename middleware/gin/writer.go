@@ -0,0 +1,97 @@
+package ginmiddleware
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+)
+
+// responseWriter adapts the response writer of the OAS middleware, which buffers responses to validate them,
+// to the Gin response writer
+type responseWriter struct {
+	http.ResponseWriter
+	status int
+	size   int // -1 until the header is written
+}
+
+// WriteHeader records the status, written with the first byte of the body
+func (w *responseWriter) WriteHeader(status int) {
+	if status > 0 && !w.Written() {
+		w.status = status
+	}
+}
+
+// WriteHeaderNow writes the status
+func (w *responseWriter) WriteHeaderNow() {
+	if !w.Written() {
+		w.size = 0
+		w.ResponseWriter.WriteHeader(w.status)
+	}
+}
+
+// Write writes the body
+func (w *responseWriter) Write(data []byte) (int, error) {
+	w.WriteHeaderNow()
+	n, err := w.ResponseWriter.Write(data)
+	w.size += n
+	return n, err
+}
+
+// WriteString writes the body
+func (w *responseWriter) WriteString(s string) (int, error) {
+	w.WriteHeaderNow()
+	n, err := io.WriteString(w.ResponseWriter, s)
+	w.size += n
+	return n, err
+}
+
+// Status returns the response status
+func (w *responseWriter) Status() int {
+	return w.status
+}
+
+// Size returns the number of body bytes written
+func (w *responseWriter) Size() int {
+	return w.size
+}
+
+// Written reports whether the header was written
+func (w *responseWriter) Written() bool {
+	return w.size != -1
+}
+
+// Flush flushes the response, the OAS middleware then streams it
+func (w *responseWriter) Flush() {
+	w.WriteHeaderNow()
+	if flusher, ok := w.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// Hijack takes over the connection, when the response writer supports it
+func (w *responseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	hijacker, ok := w.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, fmt.Errorf("response writer does not support hijacking")
+	}
+	if w.size < 0 {
+		w.size = 0
+	}
+	return hijacker.Hijack()
+}
+
+// CloseNotify returns a channel closed when the client goes away, when the response writer supports it
+func (w *responseWriter) CloseNotify() <-chan bool {
+	if notifier, ok := w.ResponseWriter.(http.CloseNotifier); ok {
+		return notifier.CloseNotify()
+	}
+	return make(chan bool)
+}
+
+// Pusher returns the HTTP/2 server pusher, when the response writer supports it
+func (w *responseWriter) Pusher() http.Pusher {
+	pusher, _ := w.ResponseWriter.(http.Pusher)
+	return pusher
+}
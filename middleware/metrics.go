@@ -0,0 +1,35 @@
+package middleware
+
+import (
+	"time"
+
+	"github.com/lionelgarnier/validate-api-request/oas"
+)
+
+// MetricsCollector records the validation of requests, e.g. the Prometheus collector of the
+// github.com/lionelgarnier/validate-api-request/metrics module, kept apart so the Prometheus client is not a
+// dependency of this module
+type MetricsCollector interface {
+	// ObserveRequest records the validation of a request by an API, failing with err if not nil
+	ObserveRequest(api string, duration time.Duration, err error)
+	// ObserveDeprecation records a request to a route of an API using deprecated parts of its spec
+	ObserveDeprecation(api, method, route string)
+	// WatchManager sets the source of the manager whose spec cache is reported
+	WatchManager(manager func() *oas.OASManager)
+}
+
+// WithMetrics records the validation of requests and the spec cache of the manager in a collector,
+// registered by the host application
+func WithMetrics(collector MetricsCollector) Option {
+	return func(m *OASMiddleware) {
+		m.metrics = collector
+		collector.WatchManager(m.Manager)
+	}
+}
+
+// observe records the validation of a request by an API started at start, if metrics are collected
+func (m *OASMiddleware) observe(api string, start time.Time, err error) {
+	if m.metrics != nil {
+		m.metrics.ObserveRequest(api, time.Since(start), err)
+	}
+}
@@ -0,0 +1,73 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/lionelgarnier/validate-api-request/oas"
+	"github.com/lionelgarnier/validate-api-request/validation"
+)
+
+// recordingCollector records the observations of the middleware
+type recordingCollector struct {
+	mu           sync.Mutex
+	requests     []string
+	failures     []validation.Category
+	deprecations []string
+	manager      func() *oas.OASManager
+}
+
+func (c *recordingCollector) ObserveRequest(api string, duration time.Duration, err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.requests = append(c.requests, api)
+	if err != nil {
+		c.failures = append(c.failures, validation.CategoryOf(err))
+	}
+}
+
+func (c *recordingCollector) ObserveDeprecation(api, method, route string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.deprecations = append(c.deprecations, api+" "+method+" "+route)
+}
+
+func (c *recordingCollector) WatchManager(manager func() *oas.OASManager) {
+	c.manager = manager
+}
+
+func TestMetrics(t *testing.T) {
+	nextHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("OK"))
+	})
+
+	config := inlineConfig(`{
+        "openapi": "3.0.0",
+        "paths": {
+            "/pets/{id}": {
+                "parameters": [{"name": "id", "in": "path", "required": true, "schema": {"type": "integer"}}],
+                "get": {"x-deprecated-at": "2001-01-01"}
+            }
+        }
+    }`)
+
+	collector := &recordingCollector{}
+	middleware, err := New(nextHandler, config, WithMetrics(collector))
+	assert.NoError(t, err)
+
+	for _, path := range []string{"/pets/1", "/pets/one", "/cats"} {
+		middleware.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+
+	assert.Equal(t, []string{"inline", "inline", "inline"}, collector.requests)
+	assert.Equal(t, []validation.Category{validation.CategoryInvalidParameter, validation.CategoryPathNotFound}, collector.failures)
+	assert.Equal(t, []string{"inline GET /pets/{id}"}, collector.deprecations)
+	if assert.NotNil(t, collector.manager) {
+		assert.Equal(t, middleware.Manager(), collector.manager())
+	}
+}
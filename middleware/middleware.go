@@ -4,6 +4,9 @@ import (
 	"fmt"
 	"net/http"
 	"os"
+	"sync"
+	"sync/atomic"
+	"time"
 
 	"gopkg.in/yaml.v3"
 
@@ -13,17 +16,109 @@ import (
 
 // APIConfig represents the configuration for an API
 type APIConfig struct {
-	Name     string `json:"name,omitempty" yaml:"name,omitempty"`
-	SpecFile string `json:"specFile,omitempty" yaml:"specFile,omitempty"`
-	SpecText string `json:"specText,omitempty" yaml:"specText,omitempty"`
+	Name        string             `json:"name,omitempty" yaml:"name,omitempty"`
+	SpecFile    string             `json:"specFile,omitempty" yaml:"specFile,omitempty"`
+	WatchFile   bool               `json:"watchFile,omitempty" yaml:"watchFile,omitempty"` // Reload specFile when it changes
+	SpecText    string             `json:"specText,omitempty" yaml:"specText,omitempty"`
+	SpecURL     string             `json:"specURL,omitempty" yaml:"specURL,omitempty"`
+	Concurrency *ConcurrencyConfig `json:"concurrency,omitempty" yaml:"concurrency,omitempty"`
+	// NormalizeUnicode normalizes string values to NFC before validating them
+	NormalizeUnicode bool `json:"normalizeUnicode,omitempty" yaml:"normalizeUnicode,omitempty"`
+	// Booleans sets the strings accepted as booleans (`strict` or `numeric`), to match the parsing of the backend
+	Booleans validation.BooleanMode `json:"booleans,omitempty" yaml:"booleans,omitempty"`
+	// BodyBackend validates JSON request bodies while scanning them (`scan`) instead of decoding them, for hot APIs
+	BodyBackend validation.BodyBackend `json:"bodyBackend,omitempty" yaml:"bodyBackend,omitempty"`
+	// MissingContentType handles request bodies without Content-Type: `infer` the single declared media type,
+	// `require` the header, or assume the given media type, instead of `application/json`
+	MissingContentType validation.MissingContentType `json:"missingContentType,omitempty" yaml:"missingContentType,omitempty"`
+	// MaxBinaryBodySize bounds the size in bytes of binary request bodies, e.g. `application/octet-stream` uploads
+	MaxBinaryBodySize int64 `json:"maxBinaryBodySize,omitempty" yaml:"maxBinaryBodySize,omitempty"`
+	// ReadOnlyProperties handles the readOnly properties sent in request bodies: `reject` or `strip` them
+	ReadOnlyProperties validation.ReadOnlyMode `json:"readOnlyProperties,omitempty" yaml:"readOnlyProperties,omitempty"`
+	// PreciseNumbers decodes the numbers of JSON bodies exactly, to validate int64 identifiers beyond 2^53
+	PreciseNumbers bool `json:"preciseNumbers,omitempty" yaml:"preciseNumbers,omitempty"`
+	// UnknownFormats rejects the strings of formats neither built in nor registered with validation.RegisterFormat (`fail`)
+	UnknownFormats validation.UnknownFormatMode `json:"unknownFormats,omitempty" yaml:"unknownFormats,omitempty"`
+	// MaxBodyBufferSize bounds the size in bytes of the request bodies buffered to be validated
+	MaxBodyBufferSize int64 `json:"maxBodyBufferSize,omitempty" yaml:"maxBodyBufferSize,omitempty"`
+	// Tags configures the validation of the operations declaring a tag, by tag
+	Tags map[string]validation.TagRule `json:"tags,omitempty" yaml:"tags,omitempty"`
+	// Mock answers valid requests with the examples of their documented responses instead of calling the next handler
+	Mock bool `json:"mock,omitempty" yaml:"mock,omitempty"`
 }
 
 // Config represents the configuration for the OAS middleware
 type Config struct {
-	APIs         []APIConfig       `json:"apis,omitempty" yaml:"apis,omitempty"`
-	SelectorType string            `json:"selectorType,omitempty" yaml:"selectorType,omitempty"`
-	Selector     map[string]string `json:"selector,omitempty" yaml:"selector,omitempty"`
-	CacheConfig  *oas.CacheConfig  `json:"cacheConfig,omitempty" yaml:"cacheConfig,omitempty"`
+	APIs         []APIConfig           `json:"apis,omitempty" yaml:"apis,omitempty"`
+	SelectorType string                `json:"selectorType,omitempty" yaml:"selectorType,omitempty"`
+	Selector     map[string]string     `json:"selector,omitempty" yaml:"selector,omitempty"`
+	CacheConfig  *oas.CacheConfig      `json:"cacheConfig,omitempty" yaml:"cacheConfig,omitempty"`
+	RemoteRefs   *oas.RemoteRefsConfig `json:"remoteRefs,omitempty" yaml:"remoteRefs,omitempty"`
+	Integrity    *oas.IntegrityConfig  `json:"integrity,omitempty" yaml:"integrity,omitempty"`
+	// VersionPolicy refuses reloaded specs with a stale info.version (`noDowngrade` or `increment`)
+	VersionPolicy oas.VersionPolicy `json:"versionPolicy,omitempty" yaml:"versionPolicy,omitempty"`
+	// StrictSpecs refuses specs with lint errors (undeclared path parameters, unresolvable $refs, invalid patterns...)
+	StrictSpecs bool `json:"strictSpecs,omitempty" yaml:"strictSpecs,omitempty"`
+	// CanonicalHash recognizes reloaded specs differing only by whitespace, key order or format as unchanged
+	CanonicalHash bool `json:"canonicalHash,omitempty" yaml:"canonicalHash,omitempty"`
+	// Responses validates the responses of the next handler (`report` or `enforce`)
+	Responses ResponseMode `json:"responses,omitempty" yaml:"responses,omitempty"`
+	// Requests forwards the requests failing validation instead of rejecting them (`report`)
+	Requests RequestMode `json:"requests,omitempty" yaml:"requests,omitempty"`
+	// CollectAllErrors reports every failure of a request instead of the first one
+	CollectAllErrors bool `json:"collectAllErrors,omitempty" yaml:"collectAllErrors,omitempty"`
+	// PinParameters rejects parameters also sent in undeclared locations or repeated with conflicting values
+	PinParameters bool `json:"pinParameters,omitempty" yaml:"pinParameters,omitempty"`
+	// StrictHeaders rejects request headers not declared by their operation, except standard and allowed headers
+	StrictHeaders bool `json:"strictHeaders,omitempty" yaml:"strictHeaders,omitempty"`
+	// AllowedHeaders are accepted without being declared when StrictHeaders is set, names ending with `*` are prefixes
+	AllowedHeaders []string `json:"allowedHeaders,omitempty" yaml:"allowedHeaders,omitempty"`
+	// StrictNumbers rejects numeric strings with leading zeros, a plus sign, a hexadecimal form or infinite values
+	StrictNumbers validation.NumberStrictness `json:"strictNumbers,omitempty" yaml:"strictNumbers,omitempty"`
+	// MaxSchemaDepth bounds the nesting of the schemas evaluated for a value, 256 by default
+	MaxSchemaDepth int `json:"maxSchemaDepth,omitempty" yaml:"maxSchemaDepth,omitempty"`
+	// MaxSchemaEvaluations bounds the schemas evaluated to validate a request, no limit by default
+	MaxSchemaEvaluations int `json:"maxSchemaEvaluations,omitempty" yaml:"maxSchemaEvaluations,omitempty"`
+	// VerdictCache replays the verdicts of identical idempotent requests instead of validating them again
+	VerdictCache *VerdictCacheConfig `json:"verdictCache,omitempty" yaml:"verdictCache,omitempty"`
+	// ParallelStages validates the bodies of large requests concurrently with their parameters
+	ParallelStages *ParallelStagesConfig `json:"parallelStages,omitempty" yaml:"parallelStages,omitempty"`
+	// Attestation signs validated requests for the next hops of a gateway chain and trusts those of previous hops
+	Attestation *AttestationConfig `json:"attestation,omitempty" yaml:"attestation,omitempty"`
+	// DeprecationHeaders sets the `Deprecation` and `Sunset` response headers of deprecated operations
+	DeprecationHeaders bool `json:"deprecationHeaders,omitempty" yaml:"deprecationHeaders,omitempty"`
+	// EnforceSunset rejects the requests to operations past the date of their `x-sunset` extension with `410 Gone`
+	EnforceSunset bool `json:"enforceSunset,omitempty" yaml:"enforceSunset,omitempty"`
+	// RequestIDHeader propagates the request ID of this header, or generates it, and includes it in error responses
+	RequestIDHeader string `json:"requestIdHeader,omitempty" yaml:"requestIdHeader,omitempty"`
+	// RewriteResponses removes `writeOnly` and `x-internal` properties from JSON responses
+	RewriteResponses bool `json:"rewriteResponses,omitempty" yaml:"rewriteResponses,omitempty"`
+	// MaxResponseBufferSize bounds the size in bytes of the responses buffered to be validated or rewritten
+	MaxResponseBufferSize int64 `json:"maxResponseBufferSize,omitempty" yaml:"maxResponseBufferSize,omitempty"`
+	// GraphQL checks the envelope of GraphQL-over-HTTP requests on `/graphql` routes and `x-graphql` operations
+	GraphQL bool `json:"graphql,omitempty" yaml:"graphql,omitempty"`
+	// LoadPolicy serves the healthy APIs when others fail to load (`degrade`) instead of failing
+	LoadPolicy LoadPolicy `json:"loadPolicy,omitempty" yaml:"loadPolicy,omitempty"`
+	// LoadRetryInterval is the delay between the attempts to load the APIs that failed to, 30s by default
+	LoadRetryInterval oas.Duration `json:"loadRetryInterval,omitempty" yaml:"loadRetryInterval,omitempty"`
+}
+
+// allowedHeaders returns the headers accepted without being declared, including the request ID and attestation
+// headers
+func (c *Config) allowedHeaders() []string {
+	return append(c.AllowedHeaders[:len(c.AllowedHeaders):len(c.AllowedHeaders)], c.hopHeaders()...)
+}
+
+// hopHeaders returns the headers set by the middleware for the next hops, the request ID and attestation headers
+func (c *Config) hopHeaders() []string {
+	var headers []string
+	if c.RequestIDHeader != "" {
+		headers = append(headers, c.RequestIDHeader)
+	}
+	if header := c.Attestation.header(); header != "" {
+		headers = append(headers, header)
+	}
+	return headers
 }
 
 // CreateConfig creates a new Config with default values
@@ -37,13 +132,149 @@ func CreateConfig() *Config {
 
 // OASMiddleware validates requests against OpenAPI specs
 type OASMiddleware struct {
-	next      http.Handler
-	manager   *oas.OASManager
-	validator validation.Validator
+	next         http.Handler
+	state        atomic.Pointer[middlewareState]
+	reloadMu     sync.Mutex
+	eventHandler oas.EventHandler
+	errorEncoder *ErrorEncoder
+	errorHandler ErrorHandler
+	metrics      MetricsCollector
+
+	requestErrorHandler  RequestErrorHandler
+	responseErrorHandler ResponseErrorHandler
+	deprecationHandler   DeprecationHandler
+	validatorOptions     []validation.Option
+}
+
+// Option configures optional OASMiddleware behavior
+type Option func(*OASMiddleware)
+
+// WithEventHandler sets the handler receiving the events of the OAS managers, e.g. specs refused by the version policy
+func WithEventHandler(handler oas.EventHandler) Option {
+	return func(m *OASMiddleware) {
+		m.eventHandler = handler
+	}
+}
+
+// WithBodyDecoder registers the decoder of the request and response bodies of a media type, range or structured syntax suffix
+func WithBodyDecoder(mediaType string, decoder validation.BodyDecoder) Option {
+	return func(m *OASMiddleware) {
+		m.validatorOptions = append(m.validatorOptions, validation.WithDecoder(mediaType, decoder))
+	}
+}
+
+// WithProtoMessages registers the descriptors of the protobuf messages of `x-proto-message` media types
+func WithProtoMessages(messages ...validation.ProtoMessage) Option {
+	return func(m *OASMiddleware) {
+		m.validatorOptions = append(m.validatorOptions, validation.WithProtoMessages(messages...))
+	}
+}
+
+// WithBodyHook registers the hook checking the request bodies of the operation with the given operationId, or of
+// the operations naming the hook in their `x-body-hook` extension
+func WithBodyHook(name string, hook validation.BodyHook) Option {
+	return func(m *OASMiddleware) {
+		m.validatorOptions = append(m.validatorOptions, validation.WithBodyHook(name, hook))
+	}
+}
+
+// WithScopeResolver forbids the requests whose credentials lack the scopes listed by their security requirement,
+// as granted by the resolver
+func WithScopeResolver(resolver validation.ScopeResolver) Option {
+	return func(m *OASMiddleware) {
+		m.validatorOptions = append(m.validatorOptions, validation.WithScopeResolver(resolver))
+	}
+}
+
+// middlewareState holds everything derived from a configuration, swapped atomically on reload
+type middlewareState struct {
+	config       *Config
+	manager      *oas.OASManager
+	apis         map[string]*APIConfig
+	limiter      *concurrencyLimiter
+	verdicts     *validation.VerdictCache // Verdicts shared by the validators of the APIs, nil when disabled
+	stageWorkers *validation.StageWorkers // Workers shared by the validators of the APIs, nil when disabled
+	attestation  *validation.Attestation  // Attestation of validated requests, nil when disabled
+	eventHandler oas.EventHandler
+
+	mu          sync.Mutex
+	watchers    []func()                         // Stop the spec file watchers, load retries and janitor
+	unavailable atomic.Pointer[map[string]error] // Load failures of the APIs served as unavailable, nil when none
+	stopped     bool
+}
+
+// addWatcher registers the function stopping a background watcher, stopping it right away if the state is stopped
+func (s *middlewareState) addWatcher(stop func()) {
+	s.mu.Lock()
+	if s.stopped {
+		s.mu.Unlock()
+		stop()
+		return
+	}
+	s.watchers = append(s.watchers, stop)
+	s.mu.Unlock()
+}
+
+// stopWatching stops the spec file watchers, load retries and janitor of the state
+func (s *middlewareState) stopWatching() {
+	s.mu.Lock()
+	watchers := s.watchers
+	s.watchers = nil
+	s.stopped = true
+	s.mu.Unlock()
+
+	for _, stop := range watchers {
+		stop()
+	}
+}
+
+// emit sends an event to the event handler, if any
+func (s *middlewareState) emit(event oas.Event) {
+	if s.eventHandler != nil {
+		s.eventHandler(event)
+	}
 }
 
 // NewMiddleware creates a new OASMiddleware
-func New(next http.Handler, config *Config) (*OASMiddleware, error) {
+func New(next http.Handler, config *Config, opts ...Option) (*OASMiddleware, error) {
+	m := &OASMiddleware{
+		next:         next,
+		errorEncoder: NewErrorEncoder(),
+	}
+
+	for _, opt := range opts {
+		opt(m)
+	}
+
+	state, err := m.newState(config, nil)
+	if err != nil {
+		return nil, err
+	}
+	m.state.Store(state)
+
+	return m, nil
+}
+
+// newState builds the state of a configuration, carrying the spec versions of the previous state
+func (m *OASMiddleware) newState(config *Config, previous *middlewareState) (*middlewareState, error) {
+	opts := []oas.ManagerOption{oas.WithVersionPolicy(config.VersionPolicy)}
+	if m.eventHandler != nil {
+		opts = append(opts, oas.WithEventHandler(m.eventHandler))
+	}
+	if config.StrictSpecs {
+		opts = append(opts, oas.WithStrictLint())
+	}
+	if config.CanonicalHash {
+		opts = append(opts, oas.WithCanonicalHash())
+	}
+	if previous != nil {
+		opts = append(opts, oas.WithKnownVersions(previous.manager.Versions()))
+	}
+	return newMiddlewareState(config, m.eventHandler, opts...)
+}
+
+// newMiddlewareState builds the selector and manager described by the configuration and loads its APIs
+func newMiddlewareState(config *Config, eventHandler oas.EventHandler, opts ...oas.ManagerOption) (*middlewareState, error) {
 	// Create API selector based on the configuration
 	var selector oas.APISelector
 	switch config.SelectorType {
@@ -60,57 +291,274 @@ func New(next http.Handler, config *Config) (*OASMiddleware, error) {
 	}
 
 	// Create OAS manager with cache config and selector
-	manager := oas.NewOASManager(config.CacheConfig, selector)
+	var resolver oas.RefResolver = oas.FileRefResolver{}
+	if config.RemoteRefs != nil {
+		resolver = config.RemoteRefs.NewResolver()
+		opts = append(opts, oas.WithRefResolver(resolver))
+	}
+	if config.Integrity != nil {
+		verifier, err := config.Integrity.NewVerifier(resolver)
+		if err != nil {
+			return nil, fmt.Errorf("failed to configure integrity verification: %w", err)
+		}
+		opts = append(opts, oas.WithVerifier(verifier))
+	}
+	attestation, err := config.Attestation.newAttestation()
+	if err != nil {
+		return nil, err
+	}
+	manager := oas.NewOASManager(config.CacheConfig, selector, opts...)
 
 	// Load APIs from the configuration
-	for _, apiConfig := range config.APIs {
-		if apiConfig.SpecFile != "" {
-			// Load from file
-			if err := manager.LoadAPIFromFile(apiConfig.Name, apiConfig.SpecFile); err != nil {
-				return nil, fmt.Errorf("failed to load OAS file '%s': %w", apiConfig.SpecFile, err)
-			}
-		} else if apiConfig.SpecText != "" {
-			// Load from text
-			if err := manager.LoadAPI(apiConfig.Name, []byte(apiConfig.SpecText)); err != nil {
-				return nil, fmt.Errorf("failed to load OAS text for API '%s': %w", apiConfig.Name, err)
-			}
-		} else {
-			return nil, fmt.Errorf("API '%s' must have either specFile or specText", apiConfig.Name)
+	state := &middlewareState{
+		config:       config,
+		manager:      manager,
+		apis:         make(map[string]*APIConfig, len(config.APIs)),
+		limiter:      newConcurrencyLimiter(),
+		verdicts:     config.VerdictCache.newCache(config.hopHeaders()...),
+		stageWorkers: config.ParallelStages.newWorkers(),
+		attestation:  attestation,
+		eventHandler: eventHandler,
+	}
+	state.addWatcher(manager.StartJanitor())
+	for i := range config.APIs {
+		apiConfig := &config.APIs[i]
+		state.apis[apiConfig.Name] = apiConfig
+
+		err := loadAPIConfig(state, apiConfig)
+		if err == nil {
+			continue
 		}
+		if config.LoadPolicy != LoadPolicyDegrade || !hasSpecSource(apiConfig) {
+			state.stopWatching()
+			return nil, err
+		}
+		state.markUnavailable(apiConfig, err)
 	}
 
-	// Create validator
-	validator := validation.NewValidator(nil)
+	return state, nil
+}
+
+// loadAPIConfig loads the spec of an API into the manager of the state, watching its file when configured
+func loadAPIConfig(state *middlewareState, apiConfig *APIConfig) error {
+	manager := state.manager
+	switch {
+	case apiConfig.SpecFile != "" && apiConfig.WatchFile:
+		// Load from file, then reload on change
+		stop, err := manager.WatchAPIFile(apiConfig.Name, apiConfig.SpecFile)
+		if err != nil {
+			return fmt.Errorf("failed to load OAS file '%s': %w", apiConfig.SpecFile, err)
+		}
+		state.addWatcher(stop)
+	case apiConfig.SpecFile != "":
+		// Load from file
+		if err := manager.LoadAPIFromFile(apiConfig.Name, apiConfig.SpecFile); err != nil {
+			return fmt.Errorf("failed to load OAS file '%s': %w", apiConfig.SpecFile, err)
+		}
+	case apiConfig.SpecURL != "":
+		// Load from URL
+		if err := manager.LoadAPIFromURL(apiConfig.Name, apiConfig.SpecURL); err != nil {
+			return fmt.Errorf("failed to load OAS URL '%s': %w", apiConfig.SpecURL, err)
+		}
+	case apiConfig.SpecText != "":
+		// Load from text
+		if err := manager.LoadAPI(apiConfig.Name, []byte(apiConfig.SpecText)); err != nil {
+			return fmt.Errorf("failed to load OAS text for API '%s': %w", apiConfig.Name, err)
+		}
+	default:
+		return fmt.Errorf("API '%s' must have either specFile, specURL or specText", apiConfig.Name)
+	}
+	return nil
+}
+
+// validatorOptions returns the validator options configured for the API
+func (c *APIConfig) validatorOptions() []validation.Option {
+	return []validation.Option{
+		validation.WithUnicodeNormalization(c.NormalizeUnicode),
+		validation.WithBooleanMode(c.Booleans),
+		validation.WithBodyBackend(c.BodyBackend),
+		validation.WithMaxBinaryBody(c.MaxBinaryBodySize),
+		validation.WithMaxBodyBuffer(c.MaxBodyBufferSize),
+		validation.WithReadOnlyMode(c.ReadOnlyProperties),
+		validation.WithUnknownFormats(c.UnknownFormats),
+		validation.WithPreciseNumbers(c.PreciseNumbers),
+		validation.WithMissingContentType(c.MissingContentType),
+		validation.WithTagRules(c.Tags),
+	}
+}
+
+// hasSpecSource reports whether the spec of an API has a source to load it from
+func hasSpecSource(apiConfig *APIConfig) bool {
+	return apiConfig.SpecFile != "" || apiConfig.SpecURL != "" || apiConfig.SpecText != ""
+}
 
-	return &OASMiddleware{
-		next:      next,
-		manager:   manager,
-		validator: validator,
-	}, nil
+// Manager returns the OAS manager of the active configuration
+func (m *OASMiddleware) Manager() *oas.OASManager {
+	return m.state.Load().manager
 }
 
 // ServeHTTP validates the request against the OpenAPI spec
 func (m *OASMiddleware) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	state := m.state.Load()
+	admitted, ok := m.admit(state, w, r)
+	if !ok {
+		return
+	}
+	if admitted.oasRequest == nil {
+		// Without operation, neither concurrency limits nor response validation apply
+		m.next.ServeHTTP(w, admitted.request)
+		return
+	}
+	oasRequest := admitted.oasRequest
+
+	// Shed load once the operation is known
+	release, ok := enterLimits(state, w, admitted)
+	if !ok {
+		return
+	}
+	defer release()
+
+	// Mocked APIs answer with the examples of the spec
+	if apiConfig, exists := state.apis[admitted.api]; exists && apiConfig.Mock {
+		serveMock(w, oasRequest, admitted.spec)
+		return
+	}
+
+	// WebSocket upgrades are passed through untouched, the next handler hijacks their connection
+	if (state.config.Responses != ResponsesOff || state.config.RewriteResponses) && !validation.IsWebSocketUpgrade(oasRequest) {
+		m.serveValidatedResponse(w, admitted.validator, oasRequest, state.config)
+		return
+	}
+
+	// Call next handler with the request carrying the negotiated values
+	m.next.ServeHTTP(w, oasRequest.Request)
+}
+
+// admission is a request accepted by the middleware
+type admission struct {
+	request    *http.Request   // Request to forward
+	api        string          // Name of the API of the request
+	oasRequest *oas.OASRequest // Validated request, nil when no operation was resolved
+	spec       *oas.APISpec
+	validator  validation.Validator
+}
+
+// Admit validates a request like ServeHTTP without forwarding it, for the adapters of other HTTP frameworks.
+// Rejected requests get their response written and false is returned. Accepted requests are returned with their
+// validated request in their context, see ValidatedRequest. Concurrency limits and response validation do not apply,
+// see AdmitLimited.
+func (m *OASMiddleware) Admit(w http.ResponseWriter, r *http.Request) (*http.Request, bool) {
+	admitted, ok := m.admit(m.state.Load(), w, r)
+	if !ok {
+		return nil, false
+	}
+	return admitted.request, true
+}
+
+// AdmitLimited validates a request like Admit, then applies the concurrency limits of its operation, for the
+// adapters of other HTTP frameworks. Requests exceeding the limits get the load shedding response and false is
+// returned. Accepted requests are returned with the function releasing their slot, to call once they are served.
+// Response validation does not apply.
+func (m *OASMiddleware) AdmitLimited(w http.ResponseWriter, r *http.Request) (*http.Request, func(), bool) {
+	state := m.state.Load()
+	admitted, ok := m.admit(state, w, r)
+	if !ok {
+		return nil, nil, false
+	}
+	release, ok := enterLimits(state, w, admitted)
+	if !ok {
+		return nil, nil, false
+	}
+	return admitted.request, release, true
+}
+
+// enterLimits applies the concurrency limits of the operation of an admitted request, writing the load shedding
+// response when they are exceeded. The returned function releases the slot of the request.
+func enterLimits(state *middlewareState, w http.ResponseWriter, admitted *admission) (func(), bool) {
+	apiConfig, exists := state.apis[admitted.api]
+	if !exists || admitted.oasRequest == nil {
+		return func() {}, true
+	}
+	release, limit := state.limiter.enter(apiConfig, admitted.oasRequest)
+	if release == nil {
+		rejectOverload(w, apiConfig.Concurrency, limit)
+		return nil, false
+	}
+	return release, true
+}
+
+// admit validates a request, writing its response when it is rejected
+func (m *OASMiddleware) admit(state *middlewareState, w http.ResponseWriter, r *http.Request) (*admission, bool) {
+	start := time.Now()
+
+	if header := state.config.RequestIDHeader; header != "" {
+		r = withRequestID(r, header)
+		w.Header().Set(header, RequestID(r))
+	}
+
+	// APIs that failed to load are unavailable until a retry loads them
+	if unavailable := state.unavailableAPIs(); unavailable != nil {
+		if name := state.manager.SelectAPI(r); name != "" && unavailable[name] != nil {
+			rejectUnavailable(w, state.config, name)
+			return nil, false
+		}
+	}
+
 	// Get API spec for request
-	spec, err := m.manager.GetApiSpecForRequest(r)
+	spec, err := state.manager.GetApiSpecForRequest(r)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
-		return
+		// No API serves the request
+		err = &validation.ValidationError{
+			Stage:    validation.StagePath,
+			Category: validation.CategoryPathNotFound,
+			Message:  err.Error(),
+		}
+		m.observe("", start, err)
+		if m.rejectRequest(w, r, err, state.config.Requests) {
+			return nil, false
+		}
+		return &admission{request: r}, true
 	}
 
-	// Set spec in validator
-	m.validator.SetApiSpec(spec)
+	validatorOptions := []validation.Option{
+		validation.WithCollectAll(state.config.CollectAllErrors),
+		validation.WithGraphQL(state.config.GraphQL),
+		validation.WithNumberStrictness(state.config.StrictNumbers),
+		validation.WithParameterPinning(state.config.PinParameters),
+		validation.WithStrictHeaders(state.config.StrictHeaders, state.config.allowedHeaders()...),
+		validation.WithVerdictCache(state.verdicts),
+		validation.WithStageWorkers(state.stageWorkers),
+		validation.WithAttestation(state.attestation),
+		validation.WithSunsetEnforcement(state.config.EnforceSunset),
+		validation.WithMaxEvaluations(state.config.MaxSchemaEvaluations),
+	}
+	if state.config.MaxSchemaDepth > 0 {
+		validatorOptions = append(validatorOptions, validation.WithMaxDepth(state.config.MaxSchemaDepth))
+	}
+	if apiConfig, exists := state.apis[spec.Name]; exists {
+		validatorOptions = append(validatorOptions, apiConfig.validatorOptions()...)
+	}
+	validatorOptions = append(validatorOptions, m.validatorOptions...)
+	validator := validation.NewValidator(spec, validatorOptions...)
 
 	oasRequest := oas.NewOASRequest(r)
 
 	// Validate request
-	if ok, err := m.validator.ValidateRequest(oasRequest); !ok {
-		http.Error(w, err.Error(), http.StatusBadRequest)
-		return
+	ok, err := validator.ValidateRequest(oasRequest)
+	m.observe(spec.Name, start, err)
+	if !ok {
+		announceSunset(w, oasRequest, err)
+		if m.rejectMockRequest(w, state, spec, oasRequest, err) || m.rejectRequest(w, r, err, state.config.Requests) {
+			return nil, false
+		}
+		if oasRequest.Operation == nil {
+			return &admission{request: oasRequest.Request, api: spec.Name}, true
+		}
 	}
 
-	// Call next handler
-	m.next.ServeHTTP(w, r)
+	m.reportDeprecations(w, spec.Name, oasRequest, state.config)
+	withValidatedRequest(oasRequest, spec)
+	return &admission{request: oasRequest.Request, api: spec.Name, oasRequest: oasRequest, spec: spec, validator: validator}, true
 }
 
 func LoadConfigFromFile(configPath string) (*Config, error) {
@@ -3,8 +3,11 @@ package middleware
 import (
 	"net/http"
 	"net/http/httptest"
+	"net/url"
 	"path/filepath"
 	"testing"
+
+	"github.com/stretchr/testify/assert"
 )
 
 func TestMiddleware(t *testing.T) {
@@ -40,3 +43,28 @@ func TestMiddleware(t *testing.T) {
 	// Output:
 	// OK
 }
+
+func TestNormalizeUnicode(t *testing.T) {
+	nextHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("OK"))
+	})
+	spec := `{"openapi": "3.0.0", "paths": {"/pets": {"get": {"parameters": [
+        {"name": "tag", "in": "query", "schema": {"type": "string", "enum": ["café"]}}
+    ]}}}}`
+	query := url.Values{"tag": {"cafe\u0301"}}.Encode() // Decomposed "café"
+
+	for _, normalize := range []bool{false, true} {
+		config := inlineConfig(spec)
+		config.APIs[0].NormalizeUnicode = normalize
+		middleware, err := New(nextHandler, config)
+		assert.NoError(t, err)
+
+		rr := httptest.NewRecorder()
+		middleware.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/pets?"+query, nil))
+		if normalize {
+			assert.Equal(t, http.StatusOK, rr.Code)
+		} else {
+			assert.Equal(t, http.StatusBadRequest, rr.Code)
+		}
+	}
+}
@@ -0,0 +1,212 @@
+package middleware
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"mime"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/lionelgarnier/validate-api-request/oas"
+	"github.com/lionelgarnier/validate-api-request/validation"
+)
+
+// mockResponse is a documented response chosen to answer a mocked request
+type mockResponse struct {
+	status    int
+	mediaType string      // Media type of the example, empty for responses without content
+	example   interface{} // Example of the media type, nil if it documents none
+	applied   []string    // Preferences of the request honored by the choice, for the Preference-Applied header
+}
+
+// serveMock answers a validated request with an example of the response its operation documents. The status is the
+// one preferred with `Prefer: code=201`, or the first documented success, the media type is negotiated with the
+// Accept header, and the example is the one named with `Prefer: example=name`, or the first documented one.
+func serveMock(w http.ResponseWriter, req *oas.OASRequest, spec *oas.APISpec) {
+	preferences := parsePrefer(req.Request.Header.Values("Prefer"))
+
+	key, applied := mockStatus(req.Operation.Responses, preferences["code"])
+	if key == "" {
+		http.Error(w, "the operation documents no response to mock", http.StatusNotImplemented)
+		return
+	}
+	response, ok := selectExample(req.Request, spec, req.Operation, key, preferences)
+	if !ok {
+		http.Error(w, "no documented media type is acceptable", http.StatusNotAcceptable)
+		return
+	}
+	if applied {
+		response.applied = append([]string{"code=" + preferences["code"]}, response.applied...)
+	}
+	writeMockResponse(w, response)
+}
+
+// rejectMockRequest rejects a request of a mocked API failing validation with the example of the response its
+// operation documents for the status of the failure, e.g. a 400 error page, and reports whether it did. Without
+// such an example, or with an error handler, the request is rejected like in other APIs.
+func (m *OASMiddleware) rejectMockRequest(w http.ResponseWriter, state *middlewareState, spec *oas.APISpec, req *oas.OASRequest, err error) bool {
+	apiConfig, exists := state.apis[spec.Name]
+	if !exists || !apiConfig.Mock || req.Operation == nil || state.config.Requests == RequestsReport || m.errorHandler != nil {
+		return false
+	}
+	status := m.errorEncoder.Status(err)
+	key := statusKey(req.Operation.Responses, status)
+	if key == "" {
+		return false
+	}
+	response, ok := selectExample(req.Request, spec, req.Operation, key, parsePrefer(req.Request.Header.Values("Prefer")))
+	if !ok || response.example == nil {
+		return false
+	}
+
+	if m.requestErrorHandler != nil {
+		m.requestErrorHandler(req.Request, err)
+	}
+	var validationErr *validation.ValidationError
+	if status == http.StatusUnauthorized && errors.As(err, &validationErr) {
+		for _, challenge := range validationErr.Challenges {
+			w.Header().Add("WWW-Authenticate", challenge)
+		}
+	}
+	response.status = status
+	writeMockResponse(w, response)
+	return true
+}
+
+// selectExample returns the example of the documented response with the given key, in the media type negotiated
+// with the Accept header, and false if none of its media types is acceptable
+func selectExample(r *http.Request, spec *oas.APISpec, operation *oas.Operation, key string, preferences map[string]string) (*mockResponse, bool) {
+	documented := operation.Responses[key]
+	response := &mockResponse{status: keyStatus(key)}
+	resolved, err := spec.ResolveResponse(&documented)
+	if err != nil || len(resolved.Content) == 0 {
+		return response, true
+	}
+
+	offers := slices.Sorted(maps.Keys(resolved.Content))
+	response.mediaType = offers[0]
+	if accept := r.Header.Get("Accept"); accept != "" {
+		values, err := validation.ParseQualityValues(accept)
+		if err != nil {
+			return nil, false
+		}
+		mediaType, ok := validation.Negotiate(values, offers)
+		if !ok {
+			return nil, false
+		}
+		response.mediaType = mediaType
+	}
+
+	content := resolved.Content[response.mediaType]
+	name := preferences["example"]
+	switch example, named := content.Examples[name]; {
+	case name != "" && named:
+		response.example = example.Value
+		response.applied = append(response.applied, "example="+name)
+	case content.Example != nil:
+		response.example = content.Example
+	case len(content.Examples) > 0:
+		response.example = content.Examples[slices.Min(slices.Collect(maps.Keys(content.Examples)))].Value
+	case content.Schema != nil:
+		response.example = content.Schema.Example
+	}
+	return response, true
+}
+
+// writeMockResponse writes a mock response, encoding its example as JSON unless it is a string of a non-JSON
+// media type
+func writeMockResponse(w http.ResponseWriter, response *mockResponse) {
+	w.Header().Add("Vary", "Accept, Prefer")
+	if len(response.applied) > 0 {
+		w.Header().Set("Preference-Applied", strings.Join(response.applied, ", "))
+	}
+	if response.mediaType == "" || response.example == nil {
+		w.WriteHeader(response.status)
+		return
+	}
+
+	var body []byte
+	essence, _, _ := mime.ParseMediaType(response.mediaType)
+	if str, ok := response.example.(string); ok && essence != "application/json" && !strings.HasSuffix(essence, "+json") {
+		body = []byte(str)
+	} else {
+		var err error
+		if body, err = json.Marshal(response.example); err != nil {
+			http.Error(w, fmt.Sprintf("failed to encode example: %v", err), http.StatusInternalServerError)
+			return
+		}
+	}
+	w.Header().Set("Content-Type", response.mediaType)
+	w.Header().Set("Content-Length", strconv.Itoa(len(body)))
+	w.WriteHeader(response.status)
+	w.Write(body)
+}
+
+// mockStatus returns the key of the documented response answering a mocked request: the preferred status when
+// documented, which is reported as applied, or the first documented success, or the default response
+func mockStatus(responses map[string]oas.Response, preferred string) (string, bool) {
+	if status, err := strconv.Atoi(preferred); err == nil {
+		if key := statusKey(responses, status); key != "" {
+			return key, true
+		}
+	}
+
+	keys := slices.Sorted(maps.Keys(responses))
+	for _, key := range keys {
+		if strings.HasPrefix(key, "2") {
+			return key, false
+		}
+	}
+	if _, exists := responses["default"]; exists {
+		return "default", false
+	}
+	if len(keys) > 0 {
+		return keys[0], false
+	}
+	return "", false
+}
+
+// statusKey returns the key of the response documented for a status, exact or by range such as `4XX`, or an
+// empty string
+func statusKey(responses map[string]oas.Response, status int) string {
+	code := strconv.Itoa(status)
+	if _, exists := responses[code]; exists {
+		return code
+	}
+	if _, exists := responses[code[:1]+"XX"]; exists {
+		return code[:1] + "XX"
+	}
+	return ""
+}
+
+// keyStatus returns the status of a response key, the first status of ranges and 200 for the default response
+func keyStatus(key string) int {
+	if status, err := strconv.Atoi(key); err == nil {
+		return status
+	}
+	if len(key) == 3 && strings.HasSuffix(strings.ToUpper(key), "XX") && key[0] >= '1' && key[0] <= '5' {
+		return int(key[0]-'0') * 100
+	}
+	return http.StatusOK
+}
+
+// parsePrefer returns the preferences of Prefer headers (RFC 7240) by lowercased name, ignoring their parameters.
+// The first occurrence of a preference wins.
+func parsePrefer(headers []string) map[string]string {
+	preferences := make(map[string]string)
+	for _, header := range headers {
+		for _, preference := range strings.Split(header, ",") {
+			preference, _, _ = strings.Cut(preference, ";")
+			name, value, _ := strings.Cut(strings.TrimSpace(preference), "=")
+			name = strings.ToLower(strings.TrimSpace(name))
+			if _, exists := preferences[name]; name != "" && !exists {
+				preferences[name] = strings.Trim(strings.TrimSpace(value), `"`)
+			}
+		}
+	}
+	return preferences
+}
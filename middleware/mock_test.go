@@ -0,0 +1,102 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMock(t *testing.T) {
+	nextHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("mocked requests must not reach the next handler")
+	})
+
+	config := inlineConfig(`{
+        "openapi": "3.0.0",
+        "paths": {
+            "/pets/{petId}": {
+                "get": {
+                    "parameters": [{"name": "petId", "in": "path", "required": true, "schema": {"type": "integer"}}],
+                    "responses": {
+                        "200": {
+                            "description": "A pet",
+                            "content": {
+                                "application/json": {
+                                    "examples": {
+                                        "dog": {"value": {"name": "Rex"}},
+                                        "cat": {"value": {"name": "Tom"}}
+                                    }
+                                },
+                                "text/plain": {"example": "Rex"}
+                            }
+                        },
+                        "400": {
+                            "description": "Invalid pet ID",
+                            "content": {"application/json": {"example": {"code": "badPetId"}}}
+                        },
+                        "404": {
+                            "description": "Not found",
+                            "content": {"application/json": {"schema": {"type": "object", "example": {"code": "notFound"}}}}
+                        }
+                    }
+                }
+            },
+            "/pets": {
+                "post": {"responses": {"201": {"description": "Created"}}}
+            }
+        }
+    }`)
+	config.APIs[0].Mock = true
+	middleware, err := New(nextHandler, config)
+	assert.NoError(t, err)
+
+	tests := []struct {
+		name              string
+		method            string
+		path              string
+		headers           map[string]string
+		status            int
+		contentType       string
+		body              string
+		preferenceApplied string
+	}{
+		{name: "First example", path: "/pets/1", status: http.StatusOK, contentType: "application/json", body: `{"name":"Tom"}`},
+		{name: "Preferred example", path: "/pets/1", headers: map[string]string{"Prefer": "example=dog"},
+			status: http.StatusOK, contentType: "application/json", body: `{"name":"Rex"}`, preferenceApplied: "example=dog"},
+		{name: "Unknown example", path: "/pets/1", headers: map[string]string{"Prefer": "example=bird"},
+			status: http.StatusOK, contentType: "application/json", body: `{"name":"Tom"}`},
+		{name: "Negotiated media type", path: "/pets/1", headers: map[string]string{"Accept": "text/plain, application/json;q=0.5"},
+			status: http.StatusOK, contentType: "text/plain", body: "Rex"},
+		{name: "Not acceptable", path: "/pets/1", headers: map[string]string{"Accept": "application/xml"}, status: http.StatusNotAcceptable},
+		{name: "Preferred status with schema example", path: "/pets/1", headers: map[string]string{"Prefer": "code=404, example=dog"},
+			status: http.StatusNotFound, contentType: "application/json", body: `{"code":"notFound"}`, preferenceApplied: "code=404"},
+		{name: "Documented error page", path: "/pets/abc",
+			status: http.StatusBadRequest, contentType: "application/json", body: `{"code":"badPetId"}`},
+		{name: "Response without content", method: http.MethodPost, path: "/pets", status: http.StatusCreated},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			method := tt.method
+			if method == "" {
+				method = http.MethodGet
+			}
+			req := httptest.NewRequest(method, tt.path, nil)
+			for key, value := range tt.headers {
+				req.Header.Set(key, value)
+			}
+			rr := httptest.NewRecorder()
+			middleware.ServeHTTP(rr, req)
+
+			assert.Equal(t, tt.status, rr.Code)
+			if tt.body != "" {
+				assert.Equal(t, tt.contentType, rr.Header().Get("Content-Type"))
+				assert.Equal(t, tt.body, strings.TrimSpace(rr.Body.String()))
+			}
+			assert.Equal(t, tt.preferenceApplied, rr.Header().Get("Preference-Applied"))
+		})
+	}
+}
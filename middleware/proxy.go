@@ -0,0 +1,38 @@
+package middleware
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+)
+
+// NewReverseProxy creates a middleware proxying the requests it accepts to an upstream, e.g. to enforce the contract
+// of a service as a sidecar. Responses are validated according to config.Responses, and upstream failures are
+// answered with `502 Bad Gateway`.
+func NewReverseProxy(upstream *url.URL, config *Config, opts ...Option) (*OASMiddleware, error) {
+	if upstream == nil || upstream.Scheme == "" || upstream.Host == "" {
+		return nil, fmt.Errorf("invalid upstream URL: %v", upstream)
+	}
+	return New(newReverseProxy(upstream), config, opts...)
+}
+
+// newReverseProxy creates the proxy forwarding requests to the upstream, with the X-Forwarded headers set.
+// Responses are requested uncompressed so that they can be validated and rewritten: the Accept-Encoding of clients is
+// not forwarded and the transport does not negotiate compression, as responses it decompresses have no length and
+// would be streamed unvalidated.
+func newReverseProxy(upstream *url.URL) *httputil.ReverseProxy {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.DisableCompression = true
+	return &httputil.ReverseProxy{
+		Rewrite: func(r *httputil.ProxyRequest) {
+			r.SetURL(upstream)
+			r.SetXForwarded()
+			r.Out.Header.Del("Accept-Encoding")
+		},
+		Transport: transport,
+		ErrorHandler: func(w http.ResponseWriter, r *http.Request, err error) {
+			http.Error(w, fmt.Sprintf("upstream unavailable: %v", err), http.StatusBadGateway)
+		},
+	}
+}
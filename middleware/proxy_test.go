@@ -0,0 +1,106 @@
+package middleware
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReverseProxy(t *testing.T) {
+	var forwarded atomic.Int32
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		forwarded.Add(1)
+		body, _ := io.ReadAll(r.Body)
+		if r.URL.Query().Get("drift") != "" {
+			body = []byte(`{}`)
+		}
+		if strings.Contains(r.Header.Get("Accept-Encoding"), "gzip") {
+			var compressed bytes.Buffer
+			writer := gzip.NewWriter(&compressed)
+			writer.Write(body)
+			writer.Close()
+			body = compressed.Bytes()
+			w.Header().Set("Content-Encoding", "gzip")
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Content-Length", strconv.Itoa(len(body)))
+		w.Write(body)
+	}))
+	defer upstream.Close()
+
+	spec := `{
+        "openapi": "3.0.0",
+        "paths": {
+            "/pets": {
+                "post": {
+                    "parameters": [{"name": "drift", "in": "query", "schema": {"type": "boolean"}}],
+                    "requestBody": {
+                        "content": {"application/json": {"schema": {"type": "object", "required": ["name"]}}}
+                    },
+                    "responses": {
+                        "200": {
+                            "description": "A pet",
+                            "content": {"application/json": {"schema": {"type": "object", "required": ["name"]}}}
+                        }
+                    }
+                }
+            }
+        }
+    }`
+
+	tests := []struct {
+		name           string
+		upstream       string
+		path           string
+		body           string
+		acceptEncoding string
+		status         int
+		forwarded      int32
+	}{
+		{name: "Valid exchange", upstream: upstream.URL, path: "/pets", body: `{"name": "Fluffy"}`, status: http.StatusOK, forwarded: 1},
+		{name: "Invalid request", upstream: upstream.URL, path: "/pets", body: `{}`, status: http.StatusBadRequest, forwarded: 0},
+		{name: "Invalid response", upstream: upstream.URL, path: "/pets?drift=true", body: `{"name": "Fluffy"}`, status: http.StatusBadGateway, forwarded: 1},
+		{name: "Client accepting gzip", upstream: upstream.URL, path: "/pets", body: `{"name": "Fluffy"}`, acceptEncoding: "gzip", status: http.StatusOK, forwarded: 1},
+		{name: "Invalid response to a client accepting gzip", upstream: upstream.URL, path: "/pets?drift=true", body: `{"name": "Fluffy"}`, acceptEncoding: "gzip", status: http.StatusBadGateway, forwarded: 1},
+		{name: "Upstream unavailable", upstream: "http://127.0.0.1:1", path: "/pets", body: `{"name": "Fluffy"}`, status: http.StatusBadGateway, forwarded: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			forwarded.Store(0)
+			upstreamURL, err := url.Parse(tt.upstream)
+			assert.NoError(t, err)
+
+			config := inlineConfig(spec)
+			config.Responses = ResponsesEnforce
+			proxy, err := NewReverseProxy(upstreamURL, config)
+			assert.NoError(t, err)
+
+			req := httptest.NewRequest(http.MethodPost, tt.path, strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			if tt.acceptEncoding != "" {
+				req.Header.Set("Accept-Encoding", tt.acceptEncoding)
+			}
+			rr := httptest.NewRecorder()
+			proxy.ServeHTTP(rr, req)
+
+			assert.Equal(t, tt.status, rr.Code, rr.Body.String())
+			assert.Equal(t, tt.forwarded, forwarded.Load())
+			if tt.status == http.StatusOK {
+				assert.JSONEq(t, tt.body, rr.Body.String())
+			}
+		})
+	}
+
+	_, err := NewReverseProxy(&url.URL{Path: "/relative"}, inlineConfig(spec))
+	assert.Error(t, err)
+}
@@ -0,0 +1,42 @@
+package middleware
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// LimitHeadersConfig configures the headers describing a limit policy when a request is rejected
+type LimitHeadersConfig struct {
+	RateLimit      bool   `json:"rateLimit,omitempty" yaml:"rateLimit,omitempty"`
+	Policy         string `json:"policy,omitempty" yaml:"policy,omitempty"`
+	OmitRetryAfter bool   `json:"omitRetryAfter,omitempty" yaml:"omitRetryAfter,omitempty"`
+}
+
+// LimitInfo describes the state of the limit that rejected a request
+type LimitInfo struct {
+	Limit     int
+	Remaining int
+	Reset     time.Duration
+}
+
+// WriteLimitHeaders sets the Retry-After and RateLimit-Limit/Remaining/Reset headers for a rejected request.
+// A nil config only emits Retry-After.
+func WriteLimitHeaders(w http.ResponseWriter, info LimitInfo, config *LimitHeadersConfig) {
+	reset := strconv.Itoa(int(math.Ceil(info.Reset.Seconds())))
+
+	if config == nil || !config.OmitRetryAfter {
+		w.Header().Set("Retry-After", reset)
+	}
+	if config == nil || !config.RateLimit {
+		return
+	}
+
+	w.Header().Set("RateLimit-Limit", strconv.Itoa(info.Limit))
+	w.Header().Set("RateLimit-Remaining", strconv.Itoa(info.Remaining))
+	w.Header().Set("RateLimit-Reset", reset)
+	if config.Policy != "" {
+		w.Header().Set("RateLimit-Policy", config.Policy)
+	}
+}
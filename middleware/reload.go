@@ -0,0 +1,103 @@
+package middleware
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+// ConfigLoader returns the configuration to apply on reload
+type ConfigLoader func() (*Config, error)
+
+// FileConfigLoader returns a ConfigLoader reading the YAML configuration at the given path
+func FileConfigLoader(configPath string) ConfigLoader {
+	return func() (*Config, error) {
+		return LoadConfigFromFile(configPath)
+	}
+}
+
+// Reload rebuilds the middleware state from the given configuration and swaps it in atomically.
+// The current configuration keeps serving requests if the new one fails to load.
+func (m *OASMiddleware) Reload(config *Config) error {
+	m.reloadMu.Lock()
+	defer m.reloadMu.Unlock()
+
+	previous := m.state.Load()
+	state, err := m.newState(config, previous)
+	if err != nil {
+		return fmt.Errorf("failed to reload configuration: %w", err)
+	}
+
+	m.state.Store(state)
+	previous.stopWatching()
+	return nil
+}
+
+// Close stops watching the spec files of the active configuration, and its janitor
+func (m *OASMiddleware) Close() error {
+	m.reloadMu.Lock()
+	defer m.reloadMu.Unlock()
+
+	m.state.Load().stopWatching()
+	return nil
+}
+
+// ReloadHandler returns an admin handler reloading the configuration on POST requests
+func (m *OASMiddleware) ReloadHandler(loader ConfigLoader) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.Header().Set("Allow", http.MethodPost)
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		config, err := loader()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if err := m.Reload(config); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte("configuration reloaded"))
+	})
+}
+
+// WatchSignals reloads the configuration each time one of the given signals is received (SIGHUP by default).
+// Reload failures are reported to onError when set. The returned function stops watching.
+func (m *OASMiddleware) WatchSignals(loader ConfigLoader, onError func(error), signals ...os.Signal) func() {
+	if len(signals) == 0 {
+		signals = []os.Signal{syscall.SIGHUP}
+	}
+
+	sigCh := make(chan os.Signal, 1)
+	done := make(chan struct{})
+	signal.Notify(sigCh, signals...)
+
+	go func() {
+		for {
+			select {
+			case <-sigCh:
+				config, err := loader()
+				if err == nil {
+					err = m.Reload(config)
+				}
+				if err != nil && onError != nil {
+					onError(err)
+				}
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	return func() {
+		signal.Stop(sigCh)
+		close(done)
+	}
+}
@@ -0,0 +1,157 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/lionelgarnier/validate-api-request/oas"
+)
+
+func inlineConfig(spec string) *Config {
+	config := CreateConfig()
+	config.SelectorType = "fixed"
+	config.Selector = map[string]string{"default": "inline"}
+	config.APIs = []APIConfig{{Name: "inline", SpecText: spec}}
+	return config
+}
+
+func TestReload(t *testing.T) {
+	nextHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("OK"))
+	})
+
+	middleware, err := New(nextHandler, inlineConfig(`{"openapi": "3.0.0", "paths": {"/pets": {"get": {}}}}`))
+	assert.NoError(t, err)
+
+	serve := func(path string) int {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		rr := httptest.NewRecorder()
+		middleware.ServeHTTP(rr, req)
+		return rr.Code
+	}
+
+	assert.Equal(t, http.StatusOK, serve("/pets"))
+	assert.Equal(t, http.StatusNotFound, serve("/users"))
+
+	// A failing configuration keeps the current one active
+	err = middleware.Reload(&Config{SelectorType: "unknown"})
+	assert.Error(t, err)
+	assert.Equal(t, http.StatusOK, serve("/pets"))
+
+	loader := func() (*Config, error) {
+		return inlineConfig(`{"openapi": "3.0.0", "paths": {"/users": {"get": {}}}}`), nil
+	}
+	admin := middleware.ReloadHandler(loader)
+
+	rr := httptest.NewRecorder()
+	admin.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/admin/reload", nil))
+	assert.Equal(t, http.StatusMethodNotAllowed, rr.Code)
+
+	rr = httptest.NewRecorder()
+	admin.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/admin/reload", nil))
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	assert.Equal(t, http.StatusNotFound, serve("/pets"))
+	assert.Equal(t, http.StatusOK, serve("/users"))
+}
+
+func TestReloadVersionPolicy(t *testing.T) {
+	nextHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("OK"))
+	})
+
+	versionedConfig := func(version, path string) *Config {
+		config := inlineConfig(`{"openapi": "3.0.0", "info": {"title": "Pets", "version": "` + version + `"}, "paths": {"` + path + `": {"get": {}}}}`)
+		config.VersionPolicy = oas.VersionNoDowngrade
+		return config
+	}
+
+	var events []oas.Event
+	middleware, err := New(nextHandler, versionedConfig("1.1.0", "/pets"), WithEventHandler(func(event oas.Event) {
+		events = append(events, event)
+	}))
+	assert.NoError(t, err)
+
+	// A stale spec is refused and the current configuration stays active
+	err = middleware.Reload(versionedConfig("1.0.0", "/users"))
+	assert.ErrorContains(t, err, "is lower than the loaded version '1.1.0'")
+	assert.Equal(t, "1.1.0", middleware.Manager().Versions()["inline"])
+
+	req := httptest.NewRequest(http.MethodGet, "/pets", nil)
+	rr := httptest.NewRecorder()
+	middleware.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	assert.NoError(t, middleware.Reload(versionedConfig("1.2.0", "/users")))
+	assert.Equal(t, "1.2.0", middleware.Manager().Versions()["inline"])
+
+	assert.Len(t, events, 3)
+	assert.Equal(t, oas.EventRejected, events[1].Type)
+}
+
+func TestSummaryHandler(t *testing.T) {
+	nextHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("OK"))
+	})
+
+	middleware, err := New(nextHandler, inlineConfig(`{"openapi": "3.0.0", "paths": {"/pets": {"get": {}, "post": {}}}}`))
+	assert.NoError(t, err)
+	admin := middleware.SummaryHandler()
+
+	rr := httptest.NewRecorder()
+	admin.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/admin/summary", nil))
+	assert.Equal(t, http.StatusMethodNotAllowed, rr.Code)
+
+	rr = httptest.NewRecorder()
+	admin.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/admin/summary", nil))
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.JSONEq(t, `{"inline": {"paths": 1, "webhooks": 0, "operations": {"GET": 1, "POST": 1}, "securitySchemes": {}, "mediaTypes": {}, "schemaKeywords": {}, "formats": {}}}`, rr.Body.String())
+
+	rr = httptest.NewRecorder()
+	admin.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/admin/summary?api=inline", nil))
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Contains(t, rr.Body.String(), `"paths":1`)
+
+	rr = httptest.NewRecorder()
+	admin.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/admin/summary?api=unknown", nil))
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+}
+
+func TestConformanceHandler(t *testing.T) {
+	nextHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("OK"))
+	})
+
+	middleware, err := New(nextHandler, inlineConfig(`{"openapi": "3.0.0", "paths": {"/pets": {"get": {"responses": {"200": {"description": "OK"}}}}, "/owners": {}}}`))
+	assert.NoError(t, err)
+	admin := middleware.ConformanceHandler()
+
+	middleware.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/pets", nil))
+
+	rr := httptest.NewRecorder()
+	admin.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/admin/conformance", nil))
+	assert.Equal(t, http.StatusMethodNotAllowed, rr.Code)
+
+	rr = httptest.NewRecorder()
+	admin.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/admin/conformance", nil))
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Contains(t, rr.Body.String(), `"inline":{"api":"inline"`)
+
+	rr = httptest.NewRecorder()
+	admin.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/admin/conformance?api=inline", nil))
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Contains(t, rr.Body.String(), `"coverage":{"paths":2,"requested":1,"ratio":0.5,"missing":["/owners"]}`)
+
+	rr = httptest.NewRecorder()
+	admin.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/admin/conformance?api=inline&format=markdown", nil))
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "text/markdown; charset=utf-8", rr.Header().Get("Content-Type"))
+	assert.Contains(t, rr.Body.String(), "1 of 2 paths requested (50%)")
+
+	rr = httptest.NewRecorder()
+	admin.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/admin/conformance?api=unknown", nil))
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+}
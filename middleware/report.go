@@ -0,0 +1,42 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/lionelgarnier/validate-api-request/validation"
+)
+
+// RequestMode controls the handling of requests failing validation
+type RequestMode string
+
+const (
+	// RequestsEnforce rejects requests failing validation, after reporting them
+	RequestsEnforce RequestMode = ""
+	// RequestsReport forwards requests failing validation to the next handler after reporting them,
+	// to deploy validation in shadow mode before enforcing it
+	RequestsReport RequestMode = "report"
+)
+
+// RequestErrorHandler receives the requests failing validation, e.g. to log them or count them
+type RequestErrorHandler func(r *http.Request, err error)
+
+// WithRequestErrorHandler sets the handler receiving the requests failing validation
+func WithRequestErrorHandler(handler RequestErrorHandler) Option {
+	return func(m *OASMiddleware) {
+		m.requestErrorHandler = handler
+	}
+}
+
+// rejectRequest reports a request failing validation and rejects it, unless requests are only reported.
+// Canceled requests are always rejected, as nobody waits for their response. It reports whether the request was
+// rejected.
+func (m *OASMiddleware) rejectRequest(w http.ResponseWriter, r *http.Request, err error, mode RequestMode) bool {
+	if m.requestErrorHandler != nil {
+		m.requestErrorHandler(r, err)
+	}
+	if mode == RequestsReport && validation.CategoryOf(err) != validation.CategoryCanceled {
+		return false
+	}
+	m.handleError(w, r, err)
+	return true
+}
@@ -0,0 +1,70 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReportOnlyRequests(t *testing.T) {
+	nextHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("OK"))
+	})
+
+	spec := `{
+        "openapi": "3.0.0",
+        "paths": {
+            "/pet": {
+                "get": {
+                    "parameters": [{"name": "limit", "in": "query", "schema": {"type": "integer"}}]
+                }
+            }
+        }
+    }`
+
+	tests := []struct {
+		name     string
+		mode     RequestMode
+		method   string
+		path     string
+		status   int
+		body     string
+		reported string
+		canceled bool
+	}{
+		{name: "Valid request", mode: RequestsReport, method: http.MethodGet, path: "/pet?limit=10", status: http.StatusOK, body: "OK"},
+		{name: "Enforced", mode: RequestsEnforce, method: http.MethodGet, path: "/pet?limit=ten", status: http.StatusBadRequest, body: "invalid type for parameter 'limit': limit: expected integer\n", reported: "invalid type for parameter 'limit': limit: expected integer"},
+		{name: "Reported", mode: RequestsReport, method: http.MethodGet, path: "/pet?limit=ten", status: http.StatusOK, body: "OK", reported: "invalid type for parameter 'limit': limit: expected integer"},
+		{name: "Reported unknown operation", mode: RequestsReport, method: http.MethodDelete, path: "/pet", status: http.StatusOK, body: "OK", reported: "method 'DELETE' not allowed for path '/pet'"},
+		{name: "Canceled", mode: RequestsReport, method: http.MethodGet, path: "/pet?limit=10", status: StatusClientClosedRequest, body: "request canceled: context canceled\n", reported: "request canceled: context canceled", canceled: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := inlineConfig(spec)
+			config.Requests = tt.mode
+
+			reported := ""
+			middleware, err := New(nextHandler, config, WithRequestErrorHandler(func(r *http.Request, err error) {
+				reported = err.Error()
+			}))
+			assert.NoError(t, err)
+
+			req := httptest.NewRequest(tt.method, tt.path, nil)
+			if tt.canceled {
+				ctx, cancel := context.WithCancel(req.Context())
+				cancel()
+				req = req.WithContext(ctx)
+			}
+			rr := httptest.NewRecorder()
+			middleware.ServeHTTP(rr, req)
+
+			assert.Equal(t, tt.status, rr.Code)
+			assert.Equal(t, tt.body, rr.Body.String())
+			assert.Equal(t, tt.reported, reported)
+		})
+	}
+}
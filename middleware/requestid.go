@@ -0,0 +1,52 @@
+package middleware
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+)
+
+// maxRequestIDLength bounds the length of the request IDs propagated from clients
+const maxRequestIDLength = 128
+
+// requestIDKey is the context key of the request ID
+type requestIDKey struct{}
+
+// RequestID returns the ID of a request set by the middleware, or an empty string when request IDs are disabled.
+// Error and event handlers use it to correlate their logs with the responses seen by clients.
+func RequestID(r *http.Request) string {
+	id, _ := r.Context().Value(requestIDKey{}).(string)
+	return id
+}
+
+// withRequestID propagates the request ID of the header, or generates one when it is missing or not a printable
+// ASCII token. The ID is set on the request header forwarded to the next handler and stored in the request context.
+func withRequestID(r *http.Request, header string) *http.Request {
+	id := r.Header.Get(header)
+	if !validRequestID(id) {
+		id = newRequestID()
+		r.Header.Set(header, id)
+	}
+	return r.WithContext(context.WithValue(r.Context(), requestIDKey{}, id))
+}
+
+// validRequestID reports whether a propagated request ID can be safely echoed in responses and logs
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] <= ' ' || id[i] > '~' {
+			return false
+		}
+	}
+	return true
+}
+
+// newRequestID generates a random request ID
+func newRequestID() string {
+	id := make([]byte, 16)
+	rand.Read(id)
+	return hex.EncodeToString(id)
+}
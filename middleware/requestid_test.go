@@ -0,0 +1,111 @@
+package middleware
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRequestIDs(t *testing.T) {
+	var forwarded, contextID string
+	nextHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		forwarded = r.Header.Get("X-Request-Id")
+		contextID = RequestID(r)
+		w.Write([]byte("OK"))
+	})
+
+	config := inlineConfig(`{
+        "openapi": "3.0.0",
+        "paths": {
+            "/pet": {
+                "get": {
+                    "parameters": [{"name": "limit", "in": "query", "schema": {"type": "integer"}}]
+                }
+            }
+        }
+    }`)
+	config.RequestIDHeader = "X-Request-Id"
+
+	encoder := NewErrorEncoder()
+	encoder.Format = ErrorFormatJSON
+	reported := ""
+	middleware, err := New(nextHandler, config, WithErrorEncoder(encoder), WithRequestErrorHandler(func(r *http.Request, err error) {
+		reported = RequestID(r)
+	}))
+	assert.NoError(t, err)
+
+	tests := []struct {
+		name      string
+		path      string
+		requestID string
+		generated bool
+	}{
+		{name: "Propagated", path: "/pet", requestID: "client-42"},
+		{name: "Generated", path: "/pet", generated: true},
+		{name: "Unsafe ID replaced", path: "/pet", requestID: "evil\tid", generated: true},
+		{name: "Propagated to errors", path: "/pet?limit=ten", requestID: "client-43"},
+		{name: "Generated for errors", path: "/pet?limit=ten", generated: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			forwarded, contextID, reported = "", "", ""
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			if tt.requestID != "" {
+				req.Header.Set("X-Request-Id", tt.requestID)
+			}
+			rr := httptest.NewRecorder()
+			middleware.ServeHTTP(rr, req)
+
+			id := rr.Header().Get("X-Request-Id")
+			if tt.generated {
+				assert.Regexp(t, "^[0-9a-f]{32}$", id)
+			} else {
+				assert.Equal(t, tt.requestID, id)
+			}
+
+			if rr.Code == http.StatusOK {
+				assert.Equal(t, id, forwarded)
+				assert.Equal(t, id, contextID)
+				return
+			}
+			var body errorBody
+			assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &body))
+			assert.Equal(t, id, body.RequestID)
+			assert.Equal(t, id, reported)
+		})
+	}
+}
+
+func TestRequestIDTextErrors(t *testing.T) {
+	nextHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("OK"))
+	})
+
+	config := inlineConfig(`{
+        "openapi": "3.0.0",
+        "paths": {
+            "/pet": {
+                "get": {
+                    "parameters": [{"name": "limit", "in": "query", "schema": {"type": "integer"}}]
+                }
+            }
+        }
+    }`)
+	config.RequestIDHeader = "X-Request-Id"
+	middleware, err := New(nextHandler, config)
+	assert.NoError(t, err)
+
+	req := httptest.NewRequest(http.MethodGet, "/pet?limit=ten", nil)
+	req.Header.Set("X-Request-Id", "client-44")
+	rr := httptest.NewRecorder()
+	middleware.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+	assert.Equal(t, "client-44", rr.Header().Get("X-Request-Id"))
+	assert.True(t, strings.HasSuffix(rr.Body.String(), "\nrequest ID: client-44\n"), rr.Body.String())
+}
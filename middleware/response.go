@@ -0,0 +1,251 @@
+package middleware
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/lionelgarnier/validate-api-request/oas"
+	"github.com/lionelgarnier/validate-api-request/validation"
+)
+
+// ResponseMode controls the validation of the responses of the next handler
+type ResponseMode string
+
+const (
+	// ResponsesOff forwards responses without validating them
+	ResponsesOff ResponseMode = ""
+	// ResponsesReport forwards responses unchanged and reports the invalid ones to the response handler
+	ResponsesReport ResponseMode = "report"
+	// ResponsesEnforce replaces invalid responses with a `502 Bad Gateway` error, after reporting them
+	ResponsesEnforce ResponseMode = "enforce"
+)
+
+// ResponseErrorHandler receives the responses failing validation, e.g. to detect drift between a service and its spec
+type ResponseErrorHandler func(r *http.Request, status int, err error)
+
+// WithResponseErrorHandler sets the handler receiving the responses failing validation
+func WithResponseErrorHandler(handler ResponseErrorHandler) Option {
+	return func(m *OASMiddleware) {
+		m.responseErrorHandler = handler
+	}
+}
+
+// responseRecorder buffers the response of the next handler until it is validated.
+// Streamed responses, event streams or responses flushed by the handler such as long-polls, are written through
+// once their status and headers are validated, as their body may never end. So are the responses whose body is not
+// validated, and those exceeding the buffer. When responses are rewritten, flushed JSON responses are buffered
+// anyway, as their hidden properties can only be removed from the whole body.
+type responseRecorder struct {
+	w           http.ResponseWriter
+	header      http.Header
+	status      int
+	wroteHeader bool
+	body        bytes.Buffer
+
+	validateStream func(status int, header http.Header) bool // Validates a streamed response, reporting whether to write it
+	validatesBody  func(status int, header http.Header) bool // Reports whether the body of a response is validated
+	streaming      bool                                      // Whether the response is written through
+	discarded      bool                                      // Whether the streamed response was replaced
+	rewrite        bool                                      // Whether JSON responses are buffered to be rewritten
+	maxBuffer      int64                                     // Size of the buffered responses in bytes, 0 for no limit
+	exceeded       bool                                      // Whether a JSON response to rewrite exceeded the buffer
+}
+
+// newResponseRecorder creates a recorder with the default status, writing streamed responses to w
+func newResponseRecorder(w http.ResponseWriter, rewrite bool, maxBuffer int64, validateStream, validatesBody func(status int, header http.Header) bool) *responseRecorder {
+	return &responseRecorder{
+		w:              w,
+		header:         make(http.Header),
+		status:         http.StatusOK,
+		validateStream: validateStream,
+		validatesBody:  validatesBody,
+		rewrite:        rewrite,
+		maxBuffer:      maxBuffer,
+	}
+}
+
+// Header returns the buffered response headers
+func (rec *responseRecorder) Header() http.Header {
+	return rec.header
+}
+
+// WriteHeader records the status of the first call, and starts streaming event streams and the responses whose
+// body is not validated
+func (rec *responseRecorder) WriteHeader(status int) {
+	if rec.wroteHeader {
+		return
+	}
+	rec.wroteHeader = true
+	rec.status = status
+
+	essence, _, _ := strings.Cut(rec.header.Get("Content-Type"), ";")
+	if strings.EqualFold(strings.TrimSpace(essence), "text/event-stream") || !rec.validatesBody(rec.status, rec.header) {
+		rec.startStream()
+	}
+}
+
+// Write buffers the response body, or writes it through when streaming or when it exceeds the buffer.
+// JSON responses to rewrite exceeding the buffer are discarded, to be replaced.
+func (rec *responseRecorder) Write(data []byte) (int, error) {
+	rec.WriteHeader(http.StatusOK)
+	if !rec.streaming && !rec.discarded && rec.maxBuffer > 0 && int64(rec.body.Len()+len(data)) > rec.maxBuffer {
+		rec.startStream()
+		if !rec.streaming && !rec.discarded {
+			rec.exceeded = true
+			rec.discarded = true
+			rec.body.Reset()
+		}
+	}
+	switch {
+	case rec.streaming:
+		return rec.w.Write(data)
+	case rec.discarded:
+		return len(data), nil
+	default:
+		return rec.body.Write(data)
+	}
+}
+
+// Flush starts streaming the response, a handler flushing its response expects it to be sent before it ends
+func (rec *responseRecorder) Flush() {
+	rec.WriteHeader(http.StatusOK)
+	if !rec.streaming && !rec.discarded {
+		rec.startStream()
+	}
+	if flusher, ok := rec.w.(http.Flusher); ok && rec.streaming {
+		flusher.Flush()
+	}
+}
+
+// Unwrap returns the response writer, for http.ResponseController
+func (rec *responseRecorder) Unwrap() http.ResponseWriter {
+	return rec.w
+}
+
+// startStream validates the status and headers of a streamed response, then writes them with the body buffered so far.
+// JSON responses to rewrite stay buffered.
+func (rec *responseRecorder) startStream() {
+	if rec.rewrite && isJSONContentType(rec.header.Get("Content-Type")) {
+		return
+	}
+	if !rec.validateStream(rec.status, rec.header) {
+		rec.discarded = true
+		return
+	}
+	rec.streaming = true
+	rec.flush(rec.w)
+	rec.body.Reset()
+}
+
+// flush writes the buffered response
+func (rec *responseRecorder) flush(w http.ResponseWriter) {
+	for name, values := range rec.header {
+		w.Header()[name] = values
+	}
+	w.WriteHeader(rec.status)
+	w.Write(rec.body.Bytes())
+}
+
+// decodeContent decodes the buffered body of a gzip-encoded response, so that it can be rewritten and validated, and
+// is forwarded decoded. Bodies of other encodings, or failing to decode, are left encoded for the validator to reject.
+func (rec *responseRecorder) decodeContent() {
+	coding := strings.ToLower(strings.TrimSpace(rec.header.Get("Content-Encoding")))
+	if (coding != "gzip" && coding != "x-gzip") || rec.body.Len() == 0 {
+		return
+	}
+	reader, err := gzip.NewReader(bytes.NewReader(rec.body.Bytes()))
+	if err != nil {
+		return
+	}
+	decoded, err := io.ReadAll(reader)
+	if err != nil {
+		return
+	}
+	rec.body.Reset()
+	rec.body.Write(decoded)
+	rec.header.Del("Content-Encoding")
+	if rec.header.Get("Content-Length") != "" {
+		rec.header.Set("Content-Length", strconv.Itoa(len(decoded)))
+	}
+}
+
+// serveValidatedResponse calls the next handler and rewrites its response, then validates it, before forwarding it
+func (m *OASMiddleware) serveValidatedResponse(w http.ResponseWriter, validator validation.Validator, oasRequest *oas.OASRequest, config *Config) {
+	mode := config.Responses
+	validateStream := func(status int, header http.Header) bool {
+		if mode == ResponsesOff {
+			return true
+		}
+		ok, err := validator.ValidateResponseStream(oasRequest, status, header)
+		return m.acceptResponse(w, oasRequest, status, ok, err, mode)
+	}
+	validatesBody := func(status int, header http.Header) bool {
+		return validation.ValidatesResponseBody(validator, oasRequest, status, header)
+	}
+	rec := newResponseRecorder(w, config.RewriteResponses, config.MaxResponseBufferSize, validateStream, validatesBody)
+	m.next.ServeHTTP(rec, oasRequest.Request)
+	if rec.exceeded {
+		// A response that cannot be rewritten could leak hidden properties
+		m.handleError(w, oasRequest.Request, &validation.ValidationError{
+			Stage:   validation.StageResponse,
+			Message: fmt.Sprintf("response body exceeds %d bytes", config.MaxResponseBufferSize),
+		})
+		return
+	}
+	if rec.streaming || rec.discarded {
+		return
+	}
+	rec.decodeContent()
+
+	if config.RewriteResponses {
+		// A response that cannot be rewritten could leak hidden properties
+		body, err := validator.RewriteResponse(oasRequest, rec.status, rec.header, rec.body.Bytes())
+		if err != nil {
+			m.handleError(w, oasRequest.Request, err)
+			return
+		}
+		if rec.header.Get("Content-Length") != "" {
+			rec.header.Set("Content-Length", strconv.Itoa(len(body)))
+		}
+		rec.body.Reset()
+		rec.body.Write(body)
+	}
+
+	// The rewritten response is validated, as writeOnly properties are not allowed in responses
+	if mode != ResponsesOff {
+		ok, err := validator.ValidateResponse(oasRequest, rec.status, rec.header, rec.body.Bytes())
+		if !m.acceptResponse(w, oasRequest, rec.status, ok, err, mode) {
+			return
+		}
+	}
+
+	rec.flush(w)
+}
+
+// isJSONContentType reports whether a content type is `application/json` or a `+json` media type
+func isJSONContentType(contentType string) bool {
+	essence, _, _ := mime.ParseMediaType(contentType)
+	return essence == "application/json" || strings.HasSuffix(essence, "+json")
+}
+
+// acceptResponse reports a response failing validation and replaces it when enforcing validation.
+// It reports whether the response is forwarded.
+func (m *OASMiddleware) acceptResponse(w http.ResponseWriter, oasRequest *oas.OASRequest, status int, ok bool, err error, mode ResponseMode) bool {
+	if ok {
+		return true
+	}
+	if m.responseErrorHandler != nil {
+		m.responseErrorHandler(oasRequest.Request, status, err)
+	}
+	if mode == ResponsesEnforce {
+		m.handleError(w, oasRequest.Request, err)
+		return false
+	}
+	return true
+}
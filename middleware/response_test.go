@@ -0,0 +1,297 @@
+package middleware
+
+import (
+	"bytes"
+	"compress/gzip"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestResponseValidation(t *testing.T) {
+	nextHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Query().Get("drift") != "" {
+			w.WriteHeader(http.StatusCreated)
+		}
+		w.Write([]byte(`{"name": "Fluffy"}`))
+	})
+
+	spec := `{
+        "openapi": "3.0.0",
+        "paths": {
+            "/pet": {
+                "get": {
+                    "parameters": [{"name": "drift", "in": "query", "schema": {"type": "boolean"}}],
+                    "responses": {
+                        "200": {
+                            "description": "A pet",
+                            "content": {"application/json": {"schema": {"type": "object", "required": ["name"]}}}
+                        }
+                    }
+                }
+            }
+        }
+    }`
+
+	tests := []struct {
+		name     string
+		mode     ResponseMode
+		path     string
+		status   int
+		body     string
+		reported int
+	}{
+		{name: "Valid response", mode: ResponsesEnforce, path: "/pet", status: http.StatusOK, body: `{"name": "Fluffy"}`},
+		{name: "Disabled", mode: ResponsesOff, path: "/pet?drift=true", status: http.StatusCreated, body: `{"name": "Fluffy"}`},
+		{name: "Reported", mode: ResponsesReport, path: "/pet?drift=true", status: http.StatusCreated, body: `{"name": "Fluffy"}`, reported: http.StatusCreated},
+		{name: "Enforced", mode: ResponsesEnforce, path: "/pet?drift=true", status: http.StatusBadGateway, body: "undeclared response status 201\n", reported: http.StatusCreated},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := inlineConfig(spec)
+			config.Responses = tt.mode
+
+			reported := 0
+			middleware, err := New(nextHandler, config, WithResponseErrorHandler(func(r *http.Request, status int, err error) {
+				reported = status
+			}))
+			assert.NoError(t, err)
+
+			rr := httptest.NewRecorder()
+			middleware.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, tt.path, nil))
+
+			assert.Equal(t, tt.status, rr.Code)
+			assert.Equal(t, tt.body, rr.Body.String())
+			assert.Equal(t, tt.reported, reported)
+		})
+	}
+}
+
+func TestStreamedResponses(t *testing.T) {
+	spec := `{
+        "openapi": "3.0.0",
+        "paths": {
+            "/events": {
+                "get": {
+                    "parameters": [{"name": "type", "in": "query", "schema": {"type": "string"}}],
+                    "responses": {
+                        "200": {
+                            "description": "Events",
+                            "content": {
+                                "text/event-stream": {"schema": {"type": "string"}},
+                                "application/json": {"schema": {"type": "object", "required": ["name"]}}
+                            }
+                        }
+                    }
+                }
+            }
+        }
+    }`
+
+	tests := []struct {
+		name        string
+		mode        ResponseMode
+		contentType string
+		flush       bool
+		status      int
+		body        string
+		streamed    bool
+		reported    int
+	}{
+		{name: "Event stream", mode: ResponsesEnforce, contentType: "text/event-stream", status: http.StatusOK, body: "data: 1\n\ndata: 2\n\n", streamed: true},
+		{name: "Long-poll", mode: ResponsesEnforce, contentType: "application/json", flush: true, status: http.StatusOK, body: "data: 1\n\ndata: 2\n\n", streamed: true},
+		{name: "Buffered", mode: ResponsesEnforce, contentType: "application/json", status: http.StatusBadGateway, body: "invalid response body: invalid character 'd' looking for beginning of value\n", reported: http.StatusOK},
+		{name: "Undeclared stream enforced", mode: ResponsesEnforce, contentType: "text/plain", flush: true, status: http.StatusBadGateway, body: "undeclared response content type 'text/plain'\n", reported: http.StatusOK},
+		{name: "Undeclared stream reported", mode: ResponsesReport, contentType: "text/plain", flush: true, status: http.StatusOK, body: "data: 1\n\ndata: 2\n\n", streamed: true, reported: http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rr := httptest.NewRecorder()
+
+			// The handler checks whether its first event reached the client before it ends
+			streamed := false
+			nextHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", tt.contentType)
+				w.Write([]byte("data: 1\n\n"))
+				if tt.flush {
+					w.(http.Flusher).Flush()
+				}
+				streamed = strings.HasPrefix(rr.Body.String(), "data: 1")
+				w.Write([]byte("data: 2\n\n"))
+			})
+
+			config := inlineConfig(spec)
+			config.Responses = tt.mode
+
+			reported := 0
+			middleware, err := New(nextHandler, config, WithResponseErrorHandler(func(r *http.Request, status int, err error) {
+				reported = status
+			}))
+			assert.NoError(t, err)
+
+			middleware.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/events", nil))
+
+			assert.Equal(t, tt.status, rr.Code)
+			assert.Equal(t, tt.body, rr.Body.String())
+			assert.Equal(t, tt.streamed, streamed)
+			assert.Equal(t, tt.reported, reported)
+		})
+	}
+}
+
+func TestResponseBuffer(t *testing.T) {
+	spec := `{
+        "openapi": "3.0.0",
+        "paths": {
+            "/report": {
+                "get": {
+                    "responses": {
+                        "200": {
+                            "description": "A report",
+                            "content": {
+                                "application/json": {"schema": {"type": "object", "required": ["name"], "properties": {"secret": {"type": "string", "writeOnly": true}}}},
+                                "text/plain": {"schema": {"type": "string"}}
+                            }
+                        }
+                    }
+                }
+            }
+        }
+    }`
+	large := `{"id": 1, "secret": "` + strings.Repeat("x", 64) + `"}`
+
+	tests := []struct {
+		name        string
+		contentType string
+		body        string
+		maxBuffer   int64
+		rewrite     bool
+		status      int
+		expected    string
+		streamed    bool
+	}{
+		{name: "Within the buffer", contentType: "application/json", body: `{"id": 1}`, maxBuffer: 64, status: http.StatusBadGateway, expected: "response body does not match schema: name: required property is missing\n"},
+		{name: "Exceeding the buffer", contentType: "application/json", body: large, maxBuffer: 64, status: http.StatusOK, expected: large, streamed: true},
+		{name: "Without limit", contentType: "application/json", body: large, status: http.StatusBadGateway, expected: "response body does not match schema: name: required property is missing\n"},
+		{name: "Exceeding the buffer rewritten", contentType: "application/json", body: large, maxBuffer: 64, rewrite: true, status: http.StatusBadGateway, expected: "response body exceeds 64 bytes\n"},
+		{name: "Body without decoder", contentType: "text/plain", body: "Monthly report", status: http.StatusOK, expected: "Monthly report", streamed: true},
+		{name: "Undeclared content type exceeding the buffer", contentType: "application/xml", body: "<report>" + strings.Repeat("x", 64) + "</report>", maxBuffer: 64, status: http.StatusBadGateway, expected: "undeclared response content type 'application/xml'\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rr := httptest.NewRecorder()
+
+			// The body is written in two halves, each within the buffer, and is streamed once both are written
+			streamed := false
+			nextHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", tt.contentType)
+				w.Write([]byte(tt.body[:len(tt.body)/2]))
+				w.Write([]byte(tt.body[len(tt.body)/2:]))
+				streamed = rr.Body.String() == tt.body
+			})
+
+			config := inlineConfig(spec)
+			config.Responses = ResponsesEnforce
+			config.RewriteResponses = tt.rewrite
+			config.MaxResponseBufferSize = tt.maxBuffer
+			middleware, err := New(nextHandler, config)
+			assert.NoError(t, err)
+
+			middleware.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/report", nil))
+
+			assert.Equal(t, tt.status, rr.Code)
+			assert.Equal(t, tt.expected, rr.Body.String())
+			assert.Equal(t, tt.streamed, streamed)
+		})
+	}
+}
+
+func TestRewriteResponses(t *testing.T) {
+	nextHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body := []byte(`{"name": "Rex", "password": "secret"}`)
+		encoding := r.URL.Query().Get("encoding")
+		if encoding == "gzip" {
+			var compressed bytes.Buffer
+			writer := gzip.NewWriter(&compressed)
+			writer.Write(body)
+			writer.Close()
+			body = compressed.Bytes()
+		}
+		w.Header().Set("Content-Type", "application/json")
+		if encoding != "" {
+			w.Header().Set("Content-Encoding", encoding)
+		}
+		w.Header().Set("Content-Length", strconv.Itoa(len(body)))
+		w.Write(body)
+		if r.URL.Query().Get("flush") == "true" {
+			w.(http.Flusher).Flush()
+		}
+	})
+
+	spec := `{
+        "openapi": "3.0.0",
+        "paths": {
+            "/user": {
+                "get": {
+                    "responses": {
+                        "200": {
+                            "description": "A user",
+                            "content": {"application/json": {"schema": {"type": "object", "properties": {"password": {"type": "string", "writeOnly": true}}}}}
+                        }
+                    }
+                }
+            }
+        }
+    }`
+
+	tests := []struct {
+		name      string
+		rewrite   bool
+		flush     bool
+		encoding  string
+		responses ResponseMode
+		status    int
+		body      string
+	}{
+		{name: "Disabled", status: http.StatusOK, body: `{"name": "Rex", "password": "secret"}`},
+		{name: "Rewritten", rewrite: true, status: http.StatusOK, body: `{"name":"Rex"}`},
+		{name: "Rewritten then validated", rewrite: true, responses: ResponsesEnforce, status: http.StatusOK, body: `{"name":"Rex"}`},
+		{name: "Flushed response rewritten", rewrite: true, flush: true, status: http.StatusOK, body: `{"name":"Rex"}`},
+		{name: "Flushed response rewritten then validated", rewrite: true, flush: true, responses: ResponsesEnforce, status: http.StatusOK, body: `{"name":"Rex"}`},
+		{name: "Write-only property validated", responses: ResponsesEnforce, status: http.StatusBadGateway},
+		{name: "Compressed response rewritten", rewrite: true, encoding: "gzip", status: http.StatusOK, body: `{"name":"Rex"}`},
+		{name: "Response of unsupported encoding", rewrite: true, encoding: "br", status: http.StatusBadGateway},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := inlineConfig(spec)
+			config.RewriteResponses = tt.rewrite
+			config.Responses = tt.responses
+			middleware, err := New(nextHandler, config)
+			assert.NoError(t, err)
+
+			rr := httptest.NewRecorder()
+			middleware.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/user?flush="+strconv.FormatBool(tt.flush)+"&encoding="+tt.encoding, nil))
+
+			assert.Equal(t, tt.status, rr.Code)
+			if tt.rewrite {
+				assert.NotContains(t, rr.Body.String(), "secret")
+			}
+			if tt.status != http.StatusOK {
+				return
+			}
+			assert.Equal(t, tt.body, rr.Body.String())
+			assert.Empty(t, rr.Header().Get("Content-Encoding"))
+			assert.Equal(t, strconv.Itoa(len(tt.body)), rr.Header().Get("Content-Length"))
+		})
+	}
+}
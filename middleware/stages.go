@@ -0,0 +1,32 @@
+package middleware
+
+import (
+	"runtime"
+
+	"github.com/lionelgarnier/validate-api-request/validation"
+)
+
+// ParallelStagesConfig configures the validation of the bodies of large requests concurrently with their parameters
+type ParallelStagesConfig struct {
+	// Workers bounds the bodies validated concurrently across requests, GOMAXPROCS by default
+	Workers int `json:"workers,omitempty" yaml:"workers,omitempty"`
+	// MinBodySize is the Content-Length from which bodies are validated concurrently, 64KiB by default. Bodies of
+	// unknown length always are.
+	MinBodySize int64 `json:"minBodySize,omitempty" yaml:"minBodySize,omitempty"`
+}
+
+// newWorkers creates the stage workers described by the configuration, nil when it is not configured
+func (c *ParallelStagesConfig) newWorkers() *validation.StageWorkers {
+	if c == nil {
+		return nil
+	}
+	workers := c.Workers
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+	minBodySize := c.MinBodySize
+	if minBodySize <= 0 {
+		minBodySize = 64 << 10
+	}
+	return validation.NewStageWorkers(workers, minBodySize)
+}
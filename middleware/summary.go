@@ -0,0 +1,41 @@
+package middleware
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/lionelgarnier/validate-api-request/oas"
+)
+
+// SummaryHandler returns an admin handler answering GET requests with the summaries of the loaded specs by API
+// name, or of the API named by the `api` query parameter
+func (m *OASMiddleware) SummaryHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			w.Header().Set("Allow", http.MethodGet)
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		manager := m.Manager()
+		var body interface{}
+		if name := r.URL.Query().Get("api"); name != "" {
+			spec, err := manager.GetApiSpec(name)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusNotFound)
+				return
+			}
+			body = spec.Summary()
+		} else {
+			summaries := make(map[string]oas.Summary)
+			manager.RangeSpecs(func(name string, spec *oas.APISpec) bool {
+				summaries[name] = spec.Summary()
+				return true
+			})
+			body = summaries
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(body)
+	})
+}
@@ -0,0 +1,35 @@
+package middleware
+
+import (
+	"time"
+
+	"github.com/lionelgarnier/validate-api-request/oas"
+	"github.com/lionelgarnier/validate-api-request/validation"
+)
+
+// VerdictCacheConfig configures the cache of the verdicts of idempotent requests without body
+type VerdictCacheConfig struct {
+	// MaxEntries bounds the cached verdicts, 10000 by default
+	MaxEntries int `json:"maxEntries,omitempty" yaml:"maxEntries,omitempty"`
+	// TTL is the time a verdict is replayed for, 1s by default
+	TTL oas.Duration `json:"ttl,omitempty" yaml:"ttl,omitempty"`
+	// IgnoreHeaders are left out of the request hash, e.g. trace IDs, the request ID and attestation headers always are
+	IgnoreHeaders []string `json:"ignoreHeaders,omitempty" yaml:"ignoreHeaders,omitempty"`
+}
+
+// newCache creates the verdict cache described by the configuration, nil when it is not configured
+func (c *VerdictCacheConfig) newCache(hopHeaders ...string) *validation.VerdictCache {
+	if c == nil {
+		return nil
+	}
+	maxEntries := c.MaxEntries
+	if maxEntries <= 0 {
+		maxEntries = 10000
+	}
+	ttl := c.TTL.Duration
+	if ttl <= 0 {
+		ttl = time.Second
+	}
+	ignored := append(c.IgnoreHeaders[:len(c.IgnoreHeaders):len(c.IgnoreHeaders)], hopHeaders...)
+	return validation.NewVerdictCache(maxEntries, ttl, ignored...)
+}
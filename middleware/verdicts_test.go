@@ -0,0 +1,45 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVerdictCache(t *testing.T) {
+	nextHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("OK"))
+	})
+
+	config := inlineConfig(`{
+        "openapi": "3.0.0",
+        "paths": {"/pets": {"get": {"parameters": [{"name": "limit", "in": "query", "schema": {"type": "integer"}}]}}}
+    }`)
+	config.RequestIDHeader = "X-Request-Id"
+	config.VerdictCache = &VerdictCacheConfig{}
+	middleware, err := New(nextHandler, config)
+	assert.NoError(t, err)
+	verdicts := middleware.state.Load().verdicts
+
+	serve := func(path string) int {
+		rr := httptest.NewRecorder()
+		middleware.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, path, nil))
+		return rr.Code
+	}
+
+	// Requests only differing by their generated request ID share their verdict
+	assert.Equal(t, http.StatusOK, serve("/pets?limit=10"))
+	assert.Equal(t, http.StatusOK, serve("/pets?limit=10"))
+	assert.Equal(t, int64(1), verdicts.Stats().Hits)
+
+	assert.Equal(t, http.StatusBadRequest, serve("/pets?limit=ten"))
+	assert.Equal(t, http.StatusBadRequest, serve("/pets?limit=ten"))
+	assert.Equal(t, int64(2), verdicts.Stats().Hits)
+
+	// Disabled by default
+	middleware, err = New(nextHandler, inlineConfig(`{"openapi": "3.0.0", "paths": {}}`))
+	assert.NoError(t, err)
+	assert.Nil(t, middleware.state.Load().verdicts)
+}
@@ -0,0 +1,90 @@
+package middleware
+
+import (
+	"bufio"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWebSocketPassthrough(t *testing.T) {
+	// The next handler switches protocols and echoes a line over the hijacked connection
+	nextHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hijacker, ok := w.(http.Hijacker)
+		if !ok {
+			http.Error(w, "cannot hijack", http.StatusInternalServerError)
+			return
+		}
+		conn, rw, err := hijacker.Hijack()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		rw.WriteString("HTTP/1.1 101 Switching Protocols\r\nConnection: Upgrade\r\nUpgrade: websocket\r\n\r\n")
+		rw.Flush()
+		line, _ := rw.ReadString('\n')
+		rw.WriteString("echo " + line)
+		rw.Flush()
+	})
+
+	config := inlineConfig(`{
+        "openapi": "3.0.0",
+        "paths": {
+            "/events": {
+                "get": {
+                    "x-websocket": true,
+                    "parameters": [{"name": "topic", "in": "query", "required": true, "schema": {"type": "string"}}],
+                    "responses": {"200": {"description": "Events"}}
+                }
+            }
+        }
+    }`)
+	config.Responses = ResponsesEnforce
+
+	middleware, err := New(nextHandler, config)
+	assert.NoError(t, err)
+	server := httptest.NewServer(middleware)
+	defer server.Close()
+
+	tests := []struct {
+		name     string
+		path     string
+		expected string
+		echo     bool
+	}{
+		{name: "Upgrade", path: "/events?topic=pets", expected: "HTTP/1.1 101 Switching Protocols\r\n", echo: true},
+		{name: "Invalid upgrade", path: "/events", expected: "HTTP/1.1 400 Bad Request\r\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conn, err := net.Dial("tcp", strings.TrimPrefix(server.URL, "http://"))
+			assert.NoError(t, err)
+			defer conn.Close()
+
+			conn.Write([]byte("GET " + tt.path + " HTTP/1.1\r\nHost: localhost\r\nConnection: Upgrade\r\nUpgrade: websocket\r\n\r\n"))
+			reader := bufio.NewReader(conn)
+			status, err := reader.ReadString('\n')
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, status)
+			if !tt.echo {
+				return
+			}
+
+			// Skip the headers, then talk over the upgraded connection
+			for line := ""; line != "\r\n"; {
+				line, err = reader.ReadString('\n')
+				assert.NoError(t, err)
+			}
+			conn.Write([]byte("ping\n"))
+			echo, err := reader.ReadString('\n')
+			assert.NoError(t, err)
+			assert.Equal(t, "echo ping\n", echo)
+		})
+	}
+}
@@ -0,0 +1,114 @@
+package oas
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// maxSpecSize bounds the decompressed size of a specification or archive
+const maxSpecSize = 64 << 20
+
+// rootDocumentNames lists the names of the root document of an archived specification, by preference
+var rootDocumentNames = []string{"openapi.yaml", "openapi.yml", "openapi.json", "swagger.yaml", "swagger.yml", "swagger.json"}
+
+// decompress returns the content of a gzip-compressed document, or the document itself if it is not compressed
+func decompress(content []byte) ([]byte, error) {
+	if len(content) < 2 || content[0] != 0x1f || content[1] != 0x8b {
+		return content, nil
+	}
+
+	reader, err := gzip.NewReader(bytes.NewReader(content))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read gzip document: %v", err)
+	}
+	defer reader.Close()
+
+	decompressed, err := io.ReadAll(io.LimitReader(reader, maxSpecSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read gzip document: %v", err)
+	}
+	if len(decompressed) > maxSpecSize {
+		return nil, fmt.Errorf("decompressed document exceeds %d bytes", maxSpecSize)
+	}
+	return decompressed, nil
+}
+
+// isTar reports whether the content is a tar archive
+func isTar(content []byte) bool {
+	return len(content) >= 262 && string(content[257:262]) == "ustar"
+}
+
+// readTar returns the files of a tar archive and the name of its root document
+func readTar(content []byte) (string, map[string][]byte, error) {
+	files := make(map[string][]byte)
+	reader := tar.NewReader(bytes.NewReader(content))
+	for {
+		header, err := reader.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return "", nil, fmt.Errorf("failed to read tar archive: %v", err)
+		}
+		if header.Typeflag != tar.TypeReg {
+			continue
+		}
+
+		data, err := io.ReadAll(reader)
+		if err != nil {
+			return "", nil, fmt.Errorf("failed to read '%s' from tar archive: %v", header.Name, err)
+		}
+		files[path.Clean(strings.TrimPrefix(header.Name, "./"))] = data
+	}
+
+	// The root document is the shallowest file with a conventional name
+	root := ""
+	for name := range files {
+		if !isRootDocumentName(name) {
+			continue
+		}
+		if root == "" || strings.Count(name, "/") < strings.Count(root, "/") ||
+			(strings.Count(name, "/") == strings.Count(root, "/") && rootNameRank(name) < rootNameRank(root)) {
+			root = name
+		}
+	}
+	if root == "" {
+		return "", nil, fmt.Errorf("tar archive has no root document (expected one of %v)", rootDocumentNames)
+	}
+	return root, files, nil
+}
+
+// isRootDocumentName reports whether a file name is a root document name
+func isRootDocumentName(name string) bool {
+	return rootNameRank(name) < len(rootDocumentNames)
+}
+
+// rootNameRank returns the preference of a root document name
+func rootNameRank(name string) int {
+	for i, rootName := range rootDocumentNames {
+		if path.Base(name) == rootName {
+			return i
+		}
+	}
+	return len(rootDocumentNames)
+}
+
+// archiveResolver resolves references to the files of an archive, then falls back to another resolver
+type archiveResolver struct {
+	files    map[string][]byte
+	fallback RefResolver
+}
+
+// Load returns the archived file at location
+func (r *archiveResolver) Load(location string) ([]byte, error) {
+	if content, exists := r.files[path.Clean(filepath.ToSlash(location))]; exists {
+		return content, nil
+	}
+	return r.fallback.Load(location)
+}
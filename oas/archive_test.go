@@ -0,0 +1,100 @@
+package oas
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const archivedSpec = `
+openapi: 3.0.3
+info:
+  title: Pets
+  version: "1.0"
+paths:
+  /pets:
+    post:
+      requestBody:
+        content:
+          application/json:
+            schema:
+              $ref: "models/pet.yaml"
+      responses:
+        "201":
+          description: Created
+`
+
+func gzipContent(t *testing.T, content []byte) []byte {
+	var buf bytes.Buffer
+	writer := gzip.NewWriter(&buf)
+	_, err := writer.Write(content)
+	assert.NoError(t, err)
+	assert.NoError(t, writer.Close())
+	return buf.Bytes()
+}
+
+func tarContent(t *testing.T, files map[string]string) []byte {
+	var buf bytes.Buffer
+	writer := tar.NewWriter(&buf)
+	for name, content := range files {
+		assert.NoError(t, writer.WriteHeader(&tar.Header{Name: name, Mode: 0o644, Size: int64(len(content)), Typeflag: tar.TypeReg}))
+		_, err := writer.Write([]byte(content))
+		assert.NoError(t, err)
+	}
+	assert.NoError(t, writer.Close())
+	return buf.Bytes()
+}
+
+func TestLoadCompressedSpecs(t *testing.T) {
+	dir := t.TempDir()
+	archive := gzipContent(t, tarContent(t, map[string]string{
+		"./spec/openapi.yaml":          archivedSpec,
+		"./spec/models/pet.yaml":       "type: object\nrequired: [name]",
+		"./spec/examples/openapi.json": "{}",
+	}))
+
+	files := map[string][]byte{
+		"openapi.yaml.gz": gzipContent(t, []byte(testSpec)),
+		"bundle.tar.gz":   archive,
+	}
+	for name, content := range files {
+		assert.NoError(t, os.WriteFile(filepath.Join(dir, name), content, 0o644))
+	}
+
+	manager := NewOASManager(nil, FixedSelector(map[string]string{"test": "test"}))
+
+	assert.NoError(t, manager.LoadAPIFromFile("gzip", filepath.Join(dir, "openapi.yaml.gz")))
+	spec, err := manager.GetApiSpec("gzip")
+	assert.NoError(t, err)
+	assert.NotEmpty(t, spec.Paths)
+
+	assert.NoError(t, manager.LoadAPIFromFile("bundle", filepath.Join(dir, "bundle.tar.gz")))
+	spec, err = manager.GetApiSpec("bundle")
+	assert.NoError(t, err)
+	assert.Equal(t, "#/components/schemas/pet", spec.Paths["/pets"].Item.Post.RequestBody.Content["application/json"].Schema.Ref)
+	assert.Equal(t, []string{"name"}, spec.Components.Schemas["pet"].Required)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/bundle.tar.gz" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write(archive)
+	}))
+	defer server.Close()
+
+	assert.NoError(t, manager.LoadAPIFromURL("remote", server.URL+"/bundle.tar.gz"))
+	spec, err = manager.GetApiSpec("remote")
+	assert.NoError(t, err)
+	assert.Contains(t, spec.Components.Schemas, "pet")
+
+	assert.ErrorContains(t, manager.LoadAPIFromURL("missing", server.URL+"/missing.yaml"), "unexpected status 404")
+	assert.ErrorContains(t, manager.loadDocument("empty", "empty.tar", tarContent(t, map[string]string{"README.md": "none"}), manager.resolver), "no root document")
+}
@@ -0,0 +1,247 @@
+package oas
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/zeebo/xxh3"
+
+	"github.com/lionelgarnier/validate-api-request/pkg/helpers"
+)
+
+// bundler inlines the documents targeted by external $refs into a single specification.
+// Referenced schemas are moved to `components/schemas` so recursive schemas stay finite,
+// other referenced objects are inlined in place.
+type bundler struct {
+	resolver  RefResolver
+	documents map[string]interface{} // Decoded referenced documents by location
+	names     map[string]string      // Component names of the moved schemas by target
+	schemas   map[string]interface{} // Schemas of the bundled document
+	inlining  map[string]bool        // Targets being inlined, to detect circular references
+	hashes    map[string]uint64      // Hashes of the contents of the referenced documents by location
+}
+
+var invalidComponentName = regexp.MustCompile(`[^A-Za-z0-9._-]`)
+
+// bundleExternalRefs replaces the external $refs of a JSON document located at base with local ones.
+// It also returns the hashes of the contents of the referenced documents by location, to detect their changes.
+func bundleExternalRefs(content []byte, base string, resolver RefResolver) ([]byte, map[string]uint64, error) {
+	if !bytes.Contains(content, []byte(`"$ref"`)) {
+		return content, nil, nil
+	}
+
+	var document map[string]interface{}
+	if err := json.Unmarshal(content, &document); err != nil {
+		return nil, nil, err
+	}
+
+	b := &bundler{
+		resolver:  resolver,
+		documents: make(map[string]interface{}),
+		names:     make(map[string]string),
+		schemas:   make(map[string]interface{}),
+		inlining:  make(map[string]bool),
+		hashes:    make(map[string]uint64),
+	}
+	if components, ok := document["components"].(map[string]interface{}); ok {
+		if schemas, ok := components["schemas"].(map[string]interface{}); ok {
+			for name := range schemas {
+				b.schemas[name] = nil
+			}
+		}
+	}
+
+	bundled, err := b.walk(document, base, false, false)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(b.hashes) == 0 {
+		return content, nil, nil
+	}
+
+	// Add the moved schemas to the bundled components
+	root := bundled.(map[string]interface{})
+	if len(b.names) > 0 {
+		components, ok := root["components"].(map[string]interface{})
+		if !ok {
+			components = make(map[string]interface{})
+			root["components"] = components
+		}
+		schemas, ok := components["schemas"].(map[string]interface{})
+		if !ok {
+			schemas = make(map[string]interface{})
+			components["schemas"] = schemas
+		}
+		for _, name := range b.names {
+			schemas[name] = b.schemas[name]
+		}
+	}
+
+	bundledContent, err := json.Marshal(root)
+	if err != nil {
+		return nil, nil, err
+	}
+	return bundledContent, b.hashes, nil
+}
+
+// walk returns a copy of a node where the external $refs are replaced.
+// Local $refs of referenced documents are external to the bundled document as well.
+func (b *bundler) walk(node interface{}, location string, external bool, inSchema bool) (interface{}, error) {
+	switch n := node.(type) {
+	case map[string]interface{}:
+		if ref, ok := n["$ref"].(string); ok && (external || !strings.HasPrefix(ref, "#")) {
+			return b.replaceRef(n, ref, location, inSchema)
+		}
+
+		copied := make(map[string]interface{}, len(n))
+		for key, value := range n {
+			// Example and default values are data, not specification objects
+			if key == "example" || key == "default" || key == "enum" || key == "const" || (inSchema && key == "examples") {
+				copied[key] = value
+				continue
+			}
+			walked, err := b.walk(value, location, external, inSchema || key == "schema" || key == "schemas")
+			if err != nil {
+				return nil, err
+			}
+			copied[key] = walked
+		}
+		return copied, nil
+	case []interface{}:
+		copied := make([]interface{}, len(n))
+		for i, value := range n {
+			walked, err := b.walk(value, location, external, inSchema)
+			if err != nil {
+				return nil, err
+			}
+			copied[i] = walked
+		}
+		return copied, nil
+	default:
+		return node, nil
+	}
+}
+
+// replaceRef replaces an external $ref by a local schema $ref or by the referenced object
+func (b *bundler) replaceRef(node map[string]interface{}, ref, location string, inSchema bool) (interface{}, error) {
+	targetLocation, fragment, _ := strings.Cut(ref, "#")
+	if targetLocation == "" {
+		targetLocation = location
+	} else {
+		targetLocation = resolveLocation(location, targetLocation)
+	}
+
+	if inSchema {
+		name, err := b.moveSchema(targetLocation, fragment)
+		if err != nil {
+			return nil, err
+		}
+		copied := make(map[string]interface{}, len(node))
+		for key, value := range node {
+			copied[key] = value
+		}
+		copied["$ref"] = "#/components/schemas/" + helpers.EscapeJSONPointer(name)
+		return copied, nil
+	}
+
+	key := targetLocation + "#" + fragment
+	if b.inlining[key] {
+		return nil, fmt.Errorf("circular reference '%s'", ref)
+	}
+	b.inlining[key] = true
+	defer delete(b.inlining, key)
+
+	target, err := b.target(targetLocation, fragment)
+	if err != nil {
+		return nil, err
+	}
+	return b.walk(target, targetLocation, true, false)
+}
+
+// moveSchema moves a referenced schema to the bundled components and returns its name
+func (b *bundler) moveSchema(location, fragment string) (string, error) {
+	key := location + "#" + fragment
+	if name, exists := b.names[key]; exists {
+		return name, nil
+	}
+
+	name := b.componentName(location, fragment)
+	// Register the name first so recursive references resolve to it
+	b.names[key] = name
+	b.schemas[name] = nil
+
+	target, err := b.target(location, fragment)
+	if err != nil {
+		return "", err
+	}
+	schema, err := b.walk(target, location, true, true)
+	if err != nil {
+		return "", err
+	}
+	b.schemas[name] = schema
+	return name, nil
+}
+
+// componentName returns an unused component name for a referenced schema
+func (b *bundler) componentName(location, fragment string) string {
+	name := path.Base(strings.ReplaceAll(location, "\\", "/"))
+	name = strings.TrimSuffix(name, path.Ext(name))
+	if fragment != "" {
+		tokens := strings.Split(fragment, "/")
+		name = strings.ReplaceAll(strings.ReplaceAll(tokens[len(tokens)-1], "~1", "/"), "~0", "~")
+	}
+	name = invalidComponentName.ReplaceAllString(name, "_")
+
+	candidate := name
+	for i := 2; ; i++ {
+		if _, exists := b.schemas[candidate]; !exists {
+			return candidate
+		}
+		candidate = fmt.Sprintf("%s_%d", name, i)
+	}
+}
+
+// target returns the object referenced by a location and a JSON pointer fragment
+func (b *bundler) target(location, fragment string) (interface{}, error) {
+	document, exists := b.documents[location]
+	if !exists {
+		content, err := b.resolver.Load(location)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load '%s': %v", location, err)
+		}
+		b.hashes[location] = xxh3.Hash(content)
+		document, err = decodeDocument(content)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse '%s': %v", location, err)
+		}
+		b.documents[location] = document
+	}
+
+	target, err := helpers.ResolveJSONPointer(document, fragment)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve '%s#%s': %v", location, fragment, err)
+	}
+	return target, nil
+}
+
+// decodeDocument decodes a JSON or YAML document
+func decodeDocument(content []byte) (interface{}, error) {
+	trimmed := bytes.TrimSpace(content)
+	if len(trimmed) > 0 && trimmed[0] != '{' && trimmed[0] != '[' {
+		converted, err := yamlToJSON(trimmed)
+		if err != nil {
+			return nil, err
+		}
+		content = converted
+	}
+
+	var document interface{}
+	if err := json.Unmarshal(content, &document); err != nil {
+		return nil, err
+	}
+	return document, nil
+}
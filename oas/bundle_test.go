@@ -0,0 +1,175 @@
+package oas
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoadExternalFileRefs(t *testing.T) {
+	dir := t.TempDir()
+	assert.NoError(t, os.MkdirAll(filepath.Join(dir, "models"), 0o755))
+
+	files := map[string]string{
+		"openapi.yaml": `
+openapi: 3.0.3
+info:
+  title: Pets
+  version: "1.0"
+paths:
+  /pets:
+    get:
+      parameters:
+        - $ref: "./parameters.json#/limit"
+      responses:
+        "200":
+          description: OK
+    post:
+      requestBody:
+        content:
+          application/json:
+            schema:
+              $ref: "./models/pet.yaml#/Pet"
+      responses:
+        "201":
+          description: Created
+`,
+		"parameters.json": `{"limit": {"name": "limit", "in": "query", "schema": {"type": "integer"}}}`,
+		"models/pet.yaml": `
+Pet:
+  type: object
+  properties:
+    owner:
+      $ref: "#/Owner"
+    parent:
+      $ref: "#/Pet"
+    tag:
+      $ref: "../tag.yaml"
+Owner:
+  type: object
+  properties:
+    name:
+      type: string
+`,
+		"tag.yaml": `
+type: string
+maxLength: 10
+`,
+	}
+	for name, content := range files {
+		assert.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644))
+	}
+
+	manager := NewOASManager(nil, FixedSelector(map[string]string{"pets": "pets"}))
+	assert.NoError(t, manager.LoadAPIFromFile("pets", filepath.Join(dir, "openapi.yaml")))
+
+	spec, err := manager.GetApiSpec("pets")
+	assert.NoError(t, err)
+
+	item := spec.Paths["/pets"].Item
+	assert.Len(t, item.Get.Parameters, 1)
+	assert.Equal(t, "limit", item.Get.Parameters[0].Name)
+	assert.Equal(t, "integer", item.Get.Parameters[0].Schema.Type)
+
+	assert.Equal(t, "#/components/schemas/Pet", item.Post.RequestBody.Content["application/json"].Schema.Ref)
+	assert.Contains(t, spec.Components.Schemas, "Pet")
+	assert.Contains(t, spec.Components.Schemas, "Owner")
+	assert.Contains(t, spec.Components.Schemas, "tag")
+
+	pet := spec.Components.Schemas["Pet"]
+	assert.Equal(t, "#/components/schemas/Owner", pet.Properties["owner"].Ref)
+	assert.Equal(t, "#/components/schemas/Pet", pet.Properties["parent"].Ref)
+	assert.Equal(t, "#/components/schemas/tag", pet.Properties["tag"].Ref)
+	assert.Equal(t, uint64(10), *spec.Components.Schemas["tag"].MaxLength)
+
+	err = manager.LoadAPI("missing", []byte(`{"openapi": "3.0.3", "info": {"title": "Missing", "version": "1.0"}, "paths": {}, "components": {"schemas": {"Pet": {"$ref": "./does-not-exist.yaml"}}}}`))
+	assert.ErrorContains(t, err, "failed to resolve external references")
+}
+
+func TestExternalFileRefsConfined(t *testing.T) {
+	dir := t.TempDir()
+	assert.NoError(t, os.MkdirAll(filepath.Join(dir, "api"), 0o755))
+
+	secret := filepath.Join(dir, "secret.yaml")
+	files := map[string]string{
+		"secret.yaml":       `{"type": "string"}`,
+		"api/tag.yaml":      `{"type": "string"}`,
+		"api/inner.json":    `{"openapi": "3.0.3", "info": {"title": "Inner", "version": "1.0"}, "paths": {}, "components": {"schemas": {"Tag": {"$ref": "./tag.yaml"}}}}`,
+		"api/parent.json":   `{"openapi": "3.0.3", "info": {"title": "Parent", "version": "1.0"}, "paths": {}, "components": {"schemas": {"Tag": {"$ref": "../secret.yaml"}}}}`,
+		"api/absolute.json": `{"openapi": "3.0.3", "info": {"title": "Absolute", "version": "1.0"}, "paths": {}, "components": {"schemas": {"Tag": {"$ref": "file://` + filepath.ToSlash(secret) + `"}}}}`,
+	}
+	for name, content := range files {
+		assert.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644))
+	}
+
+	manager := NewOASManager(nil, FixedSelector(map[string]string{"pets": "pets"}))
+	assert.NoError(t, manager.LoadAPIFromFile("inner", filepath.Join(dir, "api", "inner.json")))
+	assert.ErrorContains(t, manager.LoadAPIFromFile("parent", filepath.Join(dir, "api", "parent.json")), "outside of the specification directory")
+	assert.ErrorContains(t, manager.LoadAPIFromFile("absolute", filepath.Join(dir, "api", "absolute.json")), "outside of the specification directory")
+
+	// Inline specifications have no directory
+	err := manager.LoadAPI("inline", []byte(`{"openapi": "3.0.3", "info": {"title": "Inline", "version": "1.0"}, "paths": {}, "components": {"schemas": {"Tag": {"$ref": "`+filepath.ToSlash(secret)+`"}}}}`))
+	assert.ErrorContains(t, err, "not loaded from a file")
+}
+
+func TestLoadAPIFromFS(t *testing.T) {
+	fsys := fstest.MapFS{
+		"api/openapi.yaml": {Data: []byte(`
+openapi: 3.0.3
+info:
+  title: Pets
+  version: "1.0"
+paths:
+  /pets:
+    post:
+      requestBody:
+        content:
+          application/json:
+            schema:
+              $ref: "./models/pet.yaml#/Pet"
+      responses:
+        "201":
+          description: Created
+`)},
+		"api/models/pet.yaml": {Data: []byte(`
+Pet:
+  type: object
+  properties:
+    tag:
+      $ref: "../../common/tag.yaml"
+`)},
+		"common/tag.yaml": {Data: []byte(`
+type: string
+maxLength: 10
+`)},
+		"escaping.yaml": {Data: []byte(`
+openapi: 3.0.3
+info:
+  title: Escaping
+  version: "1.0"
+paths:
+  /pets:
+    get:
+      parameters:
+        - $ref: "../parameters.yaml#/limit"
+      responses:
+        "200":
+          description: OK
+`)},
+	}
+
+	manager := NewOASManager(nil, FixedSelector(map[string]string{"pets": "pets"}))
+	assert.NoError(t, manager.LoadAPIFromFS("pets", fsys, "api/openapi.yaml"))
+
+	spec, err := manager.GetApiSpec("pets")
+	assert.NoError(t, err)
+	assert.Equal(t, "#/components/schemas/Pet", spec.Paths["/pets"].Item.Post.RequestBody.Content["application/json"].Schema.Ref)
+	assert.Contains(t, spec.Components.Schemas, "Pet")
+	assert.Contains(t, spec.Components.Schemas, "tag")
+
+	assert.ErrorContains(t, manager.LoadAPIFromFS("missing", fsys, "api/missing.yaml"), "failed to read file")
+	assert.ErrorContains(t, manager.LoadAPIFromFS("escaping", fsys, "escaping.yaml"), "outside of the file system")
+}
@@ -1,6 +1,10 @@
 package oas
 
 import (
+	"crypto/ed25519"
+	"encoding/base64"
+	"fmt"
+	"net/http"
 	"time"
 )
 
@@ -15,6 +19,75 @@ type CacheConfig struct {
 	APIExpiryTime   Duration `yaml:"apiExpiryTime" json:"apiExpiryTime"`
 	MinPathHits     int64    `yaml:"minPathHits" json:"minPathHits"`
 	CleanupInterval Duration `yaml:"cleanupInterval" json:"cleanupInterval"`
+	// MaxResidentAPIs bounds the compiled specs kept in memory, the least recently used are spilled (0 keeps them all)
+	MaxResidentAPIs int `yaml:"maxResidentAPIs" json:"maxResidentAPIs"`
+	// OverflowDir holds the documents of the spilled specs, which are kept in memory as raw bytes without it
+	OverflowDir string `yaml:"overflowDir" json:"overflowDir"`
+}
+
+// RemoteRefsConfig configures the resolution of $refs pointing to remote URLs
+type RemoteRefsConfig struct {
+	AllowedHosts []string `yaml:"allowedHosts" json:"allowedHosts"`
+	CacheTTL     Duration `yaml:"cacheTTL" json:"cacheTTL"`
+	Timeout      Duration `yaml:"timeout" json:"timeout"`
+}
+
+// NewResolver creates the HTTP resolver described by the configuration
+func (c *RemoteRefsConfig) NewResolver() *HTTPRefResolver {
+	var opts []HTTPResolverOption
+	if c.Timeout.Duration > 0 {
+		opts = append(opts, WithHTTPClient(&http.Client{Timeout: c.Timeout.Duration}))
+	}
+	ttl := c.CacheTTL.Duration
+	if ttl <= 0 {
+		ttl = 5 * time.Minute
+	}
+	return NewHTTPRefResolver(c.AllowedHosts, ttl, opts...)
+}
+
+// IntegrityConfig configures the verification of the specifications loaded from files and URLs
+type IntegrityConfig struct {
+	PublicKey         string `yaml:"publicKey" json:"publicKey"`                 // Base64 encoded ed25519 public key
+	Manifest          string `yaml:"manifest" json:"manifest"`                   // Location of a SHA-256 checksum manifest
+	ManifestSignature string `yaml:"manifestSignature" json:"manifestSignature"` // Location of the detached signature of the manifest
+}
+
+// NewVerifier creates the verifier described by the configuration, loading the manifest with the loader.
+// Without a manifest, specifications must have a detached signature (`<location>.sig`).
+func (c *IntegrityConfig) NewVerifier(loader RefResolver) (Verifier, error) {
+	var publicKey ed25519.PublicKey
+	if c.PublicKey != "" {
+		decoded, err := base64.StdEncoding.DecodeString(c.PublicKey)
+		if err != nil || len(decoded) != ed25519.PublicKeySize {
+			return nil, fmt.Errorf("invalid ed25519 public key")
+		}
+		publicKey = decoded
+	}
+
+	if c.Manifest == "" {
+		if publicKey == nil {
+			return nil, fmt.Errorf("integrity requires a manifest or a public key")
+		}
+		return NewSignatureVerifier(publicKey, loader), nil
+	}
+
+	manifest, err := loader.Load(c.Manifest)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load checksum manifest: %v", err)
+	}
+	if publicKey == nil {
+		return NewChecksumVerifier(manifest)
+	}
+
+	signatureLocation := c.ManifestSignature
+	if signatureLocation == "" {
+		signatureLocation = c.Manifest + ".sig"
+	}
+	signature, err := loader.Load(signatureLocation)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load checksum manifest signature: %v", err)
+	}
+	return NewSignedChecksumVerifier(manifest, signature, publicKey)
 }
 
 // DefaultCacheConfig returns a default cache configuration
@@ -0,0 +1,41 @@
+package oas
+
+// EventType identifies a change of the specifications held by the manager
+type EventType string
+
+const (
+	// EventLoaded is emitted when a specification is activated
+	EventLoaded EventType = "loaded"
+	// EventRejected is emitted when a specification is refused by the version policy
+	EventRejected EventType = "rejected"
+	// EventReloadFailed is emitted when a watched specification file fails to reload, the loaded one is kept
+	EventReloadFailed EventType = "reloadFailed"
+	// EventLoadFailed is emitted when a specification fails to load and its API is served as unavailable
+	EventLoadFailed EventType = "loadFailed"
+)
+
+// Event describes a change of the specifications held by the manager
+type Event struct {
+	Type            EventType
+	API             string
+	Version         string // info.version of the loaded specification
+	PreviousVersion string // info.version of the replaced specification, if any
+	Err             error  // Reason of a rejection or failure
+}
+
+// EventHandler receives the manager events. It must not call back into the manager.
+type EventHandler func(Event)
+
+// WithEventHandler sets the handler receiving the manager events
+func WithEventHandler(handler EventHandler) ManagerOption {
+	return func(m *OASManager) {
+		m.eventHandler = handler
+	}
+}
+
+// emit sends an event to the event handler, if any
+func (m *OASManager) emit(event Event) {
+	if m.eventHandler != nil {
+		m.eventHandler(event)
+	}
+}
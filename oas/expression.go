@@ -0,0 +1,230 @@
+package oas
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/lionelgarnier/validate-api-request/pkg/helpers"
+)
+
+// RuntimeContext holds the request/response exchange runtime expressions are evaluated against.
+type RuntimeContext struct {
+	Request        *http.Request
+	RequestBody    interface{}
+	PathParams     map[string]string
+	StatusCode     int
+	ResponseHeader http.Header
+	ResponseBody   interface{}
+}
+
+// NewRuntimeContext builds a runtime context from a validated request and its decoded body.
+func NewRuntimeContext(req *OASRequest, body interface{}) *RuntimeContext {
+	ctx := &RuntimeContext{
+		Request:     req.Request,
+		RequestBody: body,
+		PathParams:  make(map[string]string),
+	}
+
+	if req.Route != "" && req.Request != nil {
+		routeParts := strings.Split(req.Route, "/")
+		pathParts := strings.Split(req.Request.URL.Path, "/")
+		for i, part := range routeParts {
+			if i < len(pathParts) && strings.HasPrefix(part, "{") && strings.HasSuffix(part, "}") {
+				ctx.PathParams[part[1:len(part)-1]] = pathParts[i]
+			}
+		}
+	}
+
+	return ctx
+}
+
+// WithResponse sets the response side of the exchange and returns the context.
+func (c *RuntimeContext) WithResponse(resp *http.Response) (*RuntimeContext, error) {
+	c.StatusCode = resp.StatusCode
+	c.ResponseHeader = resp.Header
+
+	if resp.Body == nil {
+		return c, nil
+	}
+
+	content, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return c, fmt.Errorf("failed to read response body: %v", err)
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(content))
+
+	if len(content) > 0 {
+		if err := json.Unmarshal(content, &c.ResponseBody); err != nil {
+			c.ResponseBody = string(content)
+		}
+	}
+	return c, nil
+}
+
+// EvaluateExpression evaluates a single runtime expression such as `$request.path.id` or `$response.body#/id`.
+func EvaluateExpression(expression string, ctx *RuntimeContext) (interface{}, error) {
+	if ctx == nil {
+		return nil, fmt.Errorf("no runtime context provided")
+	}
+
+	switch expression {
+	case "$url":
+		if ctx.Request == nil {
+			return nil, fmt.Errorf("expression '%s' requires a request", expression)
+		}
+		return ctx.Request.URL.String(), nil
+	case "$method":
+		if ctx.Request == nil {
+			return nil, fmt.Errorf("expression '%s' requires a request", expression)
+		}
+		return ctx.Request.Method, nil
+	case "$statusCode":
+		return ctx.StatusCode, nil
+	}
+
+	switch {
+	case strings.HasPrefix(expression, "$request."):
+		if ctx.Request == nil {
+			return nil, fmt.Errorf("expression '%s' requires a request", expression)
+		}
+		return evaluateRequestSource(strings.TrimPrefix(expression, "$request."), ctx)
+	case strings.HasPrefix(expression, "$response."):
+		return evaluateResponseSource(strings.TrimPrefix(expression, "$response."), ctx)
+	default:
+		return nil, fmt.Errorf("invalid runtime expression '%s'", expression)
+	}
+}
+
+// evaluateRequestSource evaluates the source part of a `$request.` expression
+func evaluateRequestSource(source string, ctx *RuntimeContext) (interface{}, error) {
+	switch {
+	case strings.HasPrefix(source, "header."):
+		name := strings.TrimPrefix(source, "header.")
+		if values := ctx.Request.Header.Values(name); len(values) > 0 {
+			return values[0], nil
+		}
+		return nil, fmt.Errorf("request header '%s' not found", name)
+	case strings.HasPrefix(source, "query."):
+		name := strings.TrimPrefix(source, "query.")
+		query := ctx.Request.URL.Query()
+		if _, exists := query[name]; exists {
+			return query.Get(name), nil
+		}
+		return nil, fmt.Errorf("request query parameter '%s' not found", name)
+	case strings.HasPrefix(source, "path."):
+		name := strings.TrimPrefix(source, "path.")
+		if value, exists := ctx.PathParams[name]; exists {
+			return value, nil
+		}
+		return nil, fmt.Errorf("request path parameter '%s' not found", name)
+	case source == "body" || strings.HasPrefix(source, "body#"):
+		return helpers.ResolveJSONPointer(ctx.RequestBody, strings.TrimPrefix(strings.TrimPrefix(source, "body"), "#"))
+	default:
+		return nil, fmt.Errorf("invalid request source '%s'", source)
+	}
+}
+
+// evaluateResponseSource evaluates the source part of a `$response.` expression
+func evaluateResponseSource(source string, ctx *RuntimeContext) (interface{}, error) {
+	switch {
+	case strings.HasPrefix(source, "header."):
+		name := strings.TrimPrefix(source, "header.")
+		if values := ctx.ResponseHeader.Values(name); len(values) > 0 {
+			return values[0], nil
+		}
+		return nil, fmt.Errorf("response header '%s' not found", name)
+	case source == "body" || strings.HasPrefix(source, "body#"):
+		return helpers.ResolveJSONPointer(ctx.ResponseBody, strings.TrimPrefix(strings.TrimPrefix(source, "body"), "#"))
+	default:
+		return nil, fmt.Errorf("invalid response source '%s'", source)
+	}
+}
+
+// ExpandExpressions replaces every `{expression}` embedded in a template, as used by callback URLs.
+func ExpandExpressions(template string, ctx *RuntimeContext) (string, error) {
+	var result strings.Builder
+
+	for {
+		start := strings.Index(template, "{$")
+		if start < 0 {
+			result.WriteString(template)
+			return result.String(), nil
+		}
+		end := strings.Index(template[start:], "}")
+		if end < 0 {
+			return "", fmt.Errorf("unterminated runtime expression in '%s'", template)
+		}
+
+		value, err := EvaluateExpression(template[start+1:start+end], ctx)
+		if err != nil {
+			return "", err
+		}
+
+		result.WriteString(template[:start])
+		result.WriteString(stringifyValue(value))
+		template = template[start+end+1:]
+	}
+}
+
+// ResolveParameters evaluates the link parameters, keeping constant values as they are.
+func (l *Link) ResolveParameters(ctx *RuntimeContext) (map[string]interface{}, error) {
+	params := make(map[string]interface{}, len(l.Parameters))
+	for name, raw := range l.Parameters {
+		value, err := evaluateLinkValue(raw, ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to resolve link parameter '%s': %v", name, err)
+		}
+		params[name] = value
+	}
+	return params, nil
+}
+
+// ResolveRequestBody evaluates the link request body, if any.
+func (l *Link) ResolveRequestBody(ctx *RuntimeContext) (interface{}, error) {
+	if l.RequestBody == nil {
+		return nil, nil
+	}
+	return evaluateLinkValue(l.RequestBody, ctx)
+}
+
+// evaluateLinkValue evaluates a link value which is either a runtime expression, an embedded template or a constant
+func evaluateLinkValue(raw interface{}, ctx *RuntimeContext) (interface{}, error) {
+	str, ok := raw.(string)
+	if !ok {
+		return raw, nil
+	}
+	if strings.HasPrefix(str, "$") {
+		return EvaluateExpression(str, ctx)
+	}
+	if strings.Contains(str, "{$") {
+		return ExpandExpressions(str, ctx)
+	}
+	return str, nil
+}
+
+// stringifyValue converts an evaluated value to its string form for template substitution
+func stringifyValue(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case int:
+		return strconv.Itoa(v)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(v)
+	case nil:
+		return ""
+	default:
+		encoded, err := json.Marshal(v)
+		if err != nil {
+			return fmt.Sprintf("%v", v)
+		}
+		return string(encoded)
+	}
+}
@@ -0,0 +1,90 @@
+package oas
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEvaluateExpression(t *testing.T) {
+	req, err := http.NewRequest(http.MethodPost, "/pets/42?verbose=true", nil)
+	assert.NoError(t, err)
+	req.Header.Set("X-Request-Id", "abc")
+
+	var body interface{}
+	json.Unmarshal([]byte(`{"id": 7, "owner": {"email": "john@example.com"}, "tags": ["a", "b"]}`), &body)
+
+	ctx := NewRuntimeContext(&OASRequest{Request: req, Route: "/pets/{petId}"}, body)
+	_, err = ctx.WithResponse(&http.Response{
+		StatusCode: http.StatusCreated,
+		Header:     http.Header{"Location": []string{"/pets/7"}},
+		Body:       io.NopCloser(strings.NewReader(`{"id": 7}`)),
+	})
+	assert.NoError(t, err)
+
+	tests := []struct {
+		name       string
+		expression string
+		expected   interface{}
+		wantErr    bool
+	}{
+		{name: "method", expression: "$method", expected: http.MethodPost},
+		{name: "status code", expression: "$statusCode", expected: http.StatusCreated},
+		{name: "path parameter", expression: "$request.path.petId", expected: "42"},
+		{name: "query parameter", expression: "$request.query.verbose", expected: "true"},
+		{name: "request header", expression: "$request.header.X-Request-Id", expected: "abc"},
+		{name: "request body pointer", expression: "$request.body#/owner/email", expected: "john@example.com"},
+		{name: "request body array pointer", expression: "$request.body#/tags/1", expected: "b"},
+		{name: "response body pointer", expression: "$response.body#/id", expected: float64(7)},
+		{name: "response header", expression: "$response.header.Location", expected: "/pets/7"},
+		{name: "missing path parameter", expression: "$request.path.ownerId", wantErr: true},
+		{name: "missing pointer", expression: "$request.body#/unknown", wantErr: true},
+		{name: "invalid expression", expression: "$foo.bar", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			value, err := EvaluateExpression(tt.expression, ctx)
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.expected, value)
+			}
+		})
+	}
+}
+
+func TestLinkResolution(t *testing.T) {
+	req, err := http.NewRequest(http.MethodGet, "/pets/42", nil)
+	assert.NoError(t, err)
+
+	ctx := NewRuntimeContext(&OASRequest{Request: req, Route: "/pets/{petId}"}, nil)
+	ctx.ResponseBody = map[string]interface{}{"owner": map[string]interface{}{"id": float64(3)}}
+
+	link := Link{
+		OperationId: "getOwner",
+		Parameters: map[string]interface{}{
+			"ownerId": "$response.body#/owner/id",
+			"petId":   "$request.path.petId",
+			"limit":   float64(10),
+		},
+		RequestBody: "pet-{$request.path.petId}",
+	}
+
+	params, err := link.ResolveParameters(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"ownerId": float64(3), "petId": "42", "limit": float64(10)}, params)
+
+	body, err := link.ResolveRequestBody(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, "pet-42", body)
+
+	url, err := ExpandExpressions("http://notify.example.com?pet={$request.path.petId}&owner={$response.body#/owner/id}", ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, "http://notify.example.com?pet=42&owner=3", url)
+}
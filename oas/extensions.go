@@ -0,0 +1,109 @@
+package oas
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+// UnmarshalJSON decodes an operation, collecting its `x-` specification extensions.
+func (o *Operation) UnmarshalJSON(data []byte) error {
+	type operationAlias Operation
+	var alias operationAlias
+	if err := json.Unmarshal(data, &alias); err != nil {
+		return err
+	}
+
+	extensions, err := parseExtensions(data)
+	if err != nil {
+		return err
+	}
+	alias.Extensions = extensions
+
+	*o = Operation(alias)
+	return nil
+}
+
+// UnmarshalJSON decodes a parameter, collecting its `x-` specification extensions and whether explode is declared.
+func (p *Parameter) UnmarshalJSON(data []byte) error {
+	type parameterAlias Parameter
+	var aux struct {
+		parameterAlias
+		Explode *bool `json:"explode,omitempty"`
+	}
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+	alias := aux.parameterAlias
+	if aux.Explode != nil {
+		alias.Explode, alias.explodeSet = *aux.Explode, true
+	}
+
+	extensions, err := parseExtensions(data)
+	if err != nil {
+		return err
+	}
+	alias.Extensions = extensions
+
+	*p = Parameter(alias)
+	return nil
+}
+
+// UnmarshalJSON decodes a media type, collecting its `x-` specification extensions.
+func (m *MediaType) UnmarshalJSON(data []byte) error {
+	type mediaTypeAlias MediaType
+	var alias mediaTypeAlias
+	if err := json.Unmarshal(data, &alias); err != nil {
+		return err
+	}
+
+	extensions, err := parseExtensions(data)
+	if err != nil {
+		return err
+	}
+	alias.Extensions = extensions
+
+	*m = MediaType(alias)
+	return nil
+}
+
+// Extension returns the value of a specification extension declared on the parameter.
+func (p *Parameter) Extension(name string) (interface{}, bool) {
+	value, exists := p.Extensions[name]
+	return value, exists
+}
+
+// Extension returns the value of a specification extension declared on the operation.
+func (o *Operation) Extension(name string) (interface{}, bool) {
+	value, exists := o.Extensions[name]
+	return value, exists
+}
+
+// parseExtensions extracts the `x-` prefixed keys of a JSON object
+func parseExtensions(data []byte) (map[string]interface{}, error) {
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, err
+	}
+
+	var extensions map[string]interface{}
+	for key, rawValue := range raw {
+		if !strings.HasPrefix(key, "x-") {
+			continue
+		}
+		var value interface{}
+		if err := json.Unmarshal(rawValue, &value); err != nil {
+			return nil, err
+		}
+		if extensions == nil {
+			extensions = make(map[string]interface{})
+		}
+		extensions[key] = value
+	}
+	return extensions, nil
+}
+
+// Extension returns the value of a specification extension declared on the media type.
+func (m *MediaType) Extension(name string) (interface{}, bool) {
+	value, exists := m.Extensions[name]
+	return value, exists
+}
@@ -0,0 +1,57 @@
+package oas
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"slices"
+
+	"github.com/zeebo/xxh3"
+)
+
+// WithCanonicalHash identifies the loaded documents by a hash of their canonical form, so uploads differing only by
+// whitespace, key order or format (YAML or JSON) are recognized as unchanged and not reloaded
+func WithCanonicalHash() ManagerOption {
+	return func(m *OASManager) {
+		m.canonicalHash = true
+	}
+}
+
+// documentHash returns the hash identifying the content of a document, canonicalized with WithCanonicalHash.
+// Documents that cannot be canonicalized are hashed as is, they fail to load anyway.
+func (m *OASManager) documentHash(content []byte) uint64 {
+	if m.canonicalHash {
+		if canonical, err := canonicalDocument(content); err == nil {
+			return xxh3.Hash(canonical)
+		}
+	}
+	return xxh3.Hash(content)
+}
+
+// canonicalDocument returns the compact JSON form of a document with its object keys sorted, numbers kept as written
+func canonicalDocument(content []byte) ([]byte, error) {
+	content, err := ParseDocument(content)
+	if err != nil {
+		return nil, err
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(content))
+	decoder.UseNumber()
+	var document interface{}
+	if err := decoder.Decode(&document); err != nil {
+		return nil, err
+	}
+	return json.Marshal(document)
+}
+
+// sourceHash combines the hash of a root document with the hashes of the documents it references by location, so
+// that editing a referenced document reloads the specification
+func sourceHash(hash uint64, referenced map[string]uint64) uint64 {
+	hasher := xxh3.New()
+	fmt.Fprintf(hasher, "%016x", hash)
+	for _, location := range slices.Sorted(maps.Keys(referenced)) {
+		fmt.Fprintf(hasher, "\x00%s\x00%016x", location, referenced[location])
+	}
+	return hasher.Sum64()
+}
@@ -0,0 +1,86 @@
+package oas
+
+import (
+	"sync"
+	"time"
+)
+
+const (
+	hitMinutes = 60 // Minute buckets, covering the last hour
+	hitHours   = 24 // Hour buckets, covering the last day
+)
+
+// hitBucket counts the hits of one period, identified by its index since the Unix epoch
+type hitBucket struct {
+	period int64
+	count  int64
+}
+
+// HitCounter counts hits in ring buffers of minute and hour buckets, so that the recent traffic of a path or spec
+// can be told from its all-time total
+type HitCounter struct {
+	mu      sync.Mutex
+	total   int64
+	minutes [hitMinutes]hitBucket
+	hours   [hitHours]hitBucket
+}
+
+// Add records a hit at the given time
+func (c *HitCounter) Add(now time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.total++
+	addHit(c.minutes[:], now.Unix()/60)
+	addHit(c.hours[:], now.Unix()/3600)
+}
+
+// addHit increments the bucket of a period, resetting it when it held an older period
+func addHit(buckets []hitBucket, period int64) {
+	bucket := &buckets[period%int64(len(buckets))]
+	if bucket.period != period {
+		bucket.period = period
+		bucket.count = 0
+	}
+	bucket.count++
+}
+
+// Total returns the hits recorded since the counter was created
+func (c *HitCounter) Total() int64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.total
+}
+
+// Recent returns the hits recorded within the window before the given time, rounded up to whole minutes up to an
+// hour and to whole hours beyond. Windows are capped to a day.
+func (c *HitCounter) Recent(window time.Duration, now time.Time) int64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if window <= time.Hour {
+		return recentHits(c.minutes[:], now.Unix()/60, window, time.Minute)
+	}
+	return recentHits(c.hours[:], now.Unix()/3600, window, time.Hour)
+}
+
+// recentHits sums the buckets of the periods within the window ending with the current period
+func recentHits(buckets []hitBucket, current int64, window, period time.Duration) int64 {
+	periods := min(int64((window+period-1)/period), int64(len(buckets)))
+	var hits int64
+	for _, bucket := range buckets {
+		if bucket.period > current-periods && bucket.period <= current {
+			hits += bucket.count
+		}
+	}
+	return hits
+}
+
+// PathHits returns the requests matching each path of the spec within the window before the given time, by route
+func (s *APISpec) PathHits(window time.Duration, now time.Time) map[string]int64 {
+	hits := make(map[string]int64, len(s.Paths))
+	for route, path := range s.Paths {
+		hits[route] = path.Hits.Recent(window, now)
+	}
+	return hits
+}
@@ -0,0 +1,47 @@
+package oas
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHitCounter(t *testing.T) {
+	start := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+	var counter HitCounter
+
+	counter.Add(start)
+	counter.Add(start.Add(30 * time.Second))
+	assert.Equal(t, int64(2), counter.Recent(time.Minute, start.Add(45*time.Second)))
+
+	counter.Add(start.Add(5 * time.Minute))
+	assert.Equal(t, int64(3), counter.Recent(10*time.Minute, start.Add(5*time.Minute)))
+	assert.Equal(t, int64(1), counter.Recent(90*time.Second, start.Add(6*time.Minute)), "partial minutes are rounded up")
+	assert.Equal(t, int64(0), counter.Recent(time.Minute, start.Add(6*time.Minute)))
+
+	// Old hits are left out of the last hour, even when their buckets are not overwritten yet
+	assert.Equal(t, int64(0), counter.Recent(time.Hour, start.Add(2*time.Hour)))
+
+	counter.Add(start.Add(3 * time.Hour))
+	now := start.Add(3*time.Hour + time.Minute)
+	assert.Equal(t, int64(1), counter.Recent(time.Hour, now))
+	assert.Equal(t, int64(1), counter.Recent(2*time.Hour, now))
+	assert.Equal(t, int64(4), counter.Recent(4*time.Hour, now))
+	assert.Equal(t, int64(1), counter.Recent(48*time.Hour, start.Add(25*time.Hour)), "windows are capped to a day")
+	assert.Equal(t, int64(4), counter.Total())
+}
+
+func TestPathHits(t *testing.T) {
+	manager := NewOASManager(nil, FixedSelector(map[string]string{"test": "test"}))
+	err := manager.LoadAPI("test", []byte(`{"openapi": "3.0.0", "paths": {"/pets": {"get": {}}, "/users": {"get": {}}}}`))
+	assert.NoError(t, err)
+	spec, _ := manager.GetApiSpec("test")
+
+	now := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+	spec.Paths["/pets"].Hits.Add(now.Add(-2 * time.Hour))
+	spec.Paths["/pets"].Hits.Add(now)
+
+	assert.Equal(t, map[string]int64{"/pets": 1, "/users": 0}, spec.PathHits(time.Hour, now))
+	assert.Equal(t, map[string]int64{"/pets": 2, "/users": 0}, spec.PathHits(24*time.Hour, now))
+}
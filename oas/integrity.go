@@ -0,0 +1,165 @@
+package oas
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"net/url"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// Verifier checks the integrity of a specification before it is activated
+type Verifier interface {
+	// Verify returns an error if the content loaded from location must not be activated
+	Verify(location string, content []byte) error
+}
+
+// WithVerifier sets the verifier of the specifications loaded from files and URLs
+func WithVerifier(verifier Verifier) ManagerOption {
+	return func(m *OASManager) {
+		m.verifier = verifier
+	}
+}
+
+// ChecksumVerifier verifies specifications against a manifest of SHA-256 checksums
+type ChecksumVerifier struct {
+	checksums map[string]string // Hex encoded checksums by file path, as listed in the manifest
+}
+
+// NewChecksumVerifier parses a checksum manifest in the `sha256sum` format (`<checksum>  <file path>` per line).
+// File paths are relative to the directory the manifest was generated in, so files of the same name in different
+// directories have their own checksum. Files listed twice are refused.
+func NewChecksumVerifier(manifest []byte) (*ChecksumVerifier, error) {
+	checksums := make(map[string]string)
+	scanner := bufio.NewScanner(bytes.NewReader(manifest))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		fields := strings.Fields(line)
+		if len(fields) != 2 {
+			return nil, fmt.Errorf("invalid checksum manifest line '%s'", line)
+		}
+		checksum, err := hex.DecodeString(fields[0])
+		if err != nil || len(checksum) != sha256.Size {
+			return nil, fmt.Errorf("invalid SHA-256 checksum '%s'", fields[0])
+		}
+		// Binary mode entries are prefixed with `*`
+		filePath := path.Clean(filepath.ToSlash(strings.TrimPrefix(fields[1], "*")))
+		if _, exists := checksums[filePath]; exists {
+			return nil, fmt.Errorf("duplicate checksum manifest entry '%s'", filePath)
+		}
+		checksums[filePath] = hex.EncodeToString(checksum)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read checksum manifest: %v", err)
+	}
+
+	return &ChecksumVerifier{checksums: checksums}, nil
+}
+
+// NewSignedChecksumVerifier verifies the ed25519 signature of a checksum manifest before parsing it
+func NewSignedChecksumVerifier(manifest, signature []byte, publicKey ed25519.PublicKey) (*ChecksumVerifier, error) {
+	if err := verifySignature(manifest, signature, publicKey); err != nil {
+		return nil, fmt.Errorf("checksum manifest: %v", err)
+	}
+	return NewChecksumVerifier(manifest)
+}
+
+// Verify checks the content against the checksum listed for its file, the entry whose path ends the path of the
+// location the most completely
+func (v *ChecksumVerifier) Verify(location string, content []byte) error {
+	locationPath := locationPath(location)
+	var name, expected string
+	for filePath, checksum := range v.checksums {
+		if (locationPath == filePath || strings.HasSuffix(locationPath, "/"+filePath)) && len(filePath) > len(name) {
+			name, expected = filePath, checksum
+		}
+	}
+	if name == "" {
+		return fmt.Errorf("no checksum for '%s' in manifest", location)
+	}
+
+	sum := sha256.Sum256(content)
+	if hex.EncodeToString(sum[:]) != expected {
+		return fmt.Errorf("checksum mismatch for '%s'", name)
+	}
+	return nil
+}
+
+// SignatureVerifier verifies detached ed25519 signatures stored next to the specifications (`<location>.sig`)
+type SignatureVerifier struct {
+	publicKey ed25519.PublicKey
+	loader    RefResolver
+}
+
+// NewSignatureVerifier creates a verifier loading the detached signatures with the loader
+func NewSignatureVerifier(publicKey ed25519.PublicKey, loader RefResolver) *SignatureVerifier {
+	return &SignatureVerifier{publicKey: publicKey, loader: loader}
+}
+
+// Verify checks the detached signature of the content
+func (v *SignatureVerifier) Verify(location string, content []byte) error {
+	signature, err := v.loader.Load(location + ".sig")
+	if err != nil {
+		return fmt.Errorf("failed to load signature: %v", err)
+	}
+	return verifySignature(content, signature, v.publicKey)
+}
+
+// verifySignature verifies a raw or base64 encoded ed25519 signature of the content
+func verifySignature(content, signature []byte, publicKey ed25519.PublicKey) error {
+	if len(publicKey) != ed25519.PublicKeySize {
+		return fmt.Errorf("invalid ed25519 public key")
+	}
+
+	if len(signature) != ed25519.SignatureSize {
+		decoded, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(signature)))
+		if err != nil {
+			return fmt.Errorf("invalid signature encoding")
+		}
+		signature = decoded
+	}
+
+	if !ed25519.Verify(publicKey, content, signature) {
+		return fmt.Errorf("invalid signature")
+	}
+	return nil
+}
+
+// locationPath returns the slash-separated path of a URL, or the absolute one of a file path
+func locationPath(location string) string {
+	if target, err := url.Parse(location); err == nil && (target.Scheme == "http" || target.Scheme == "https") {
+		return path.Clean(target.Path)
+	}
+	if absolute, err := filepath.Abs(location); err == nil {
+		location = absolute
+	}
+	return filepath.ToSlash(location)
+}
+
+// verifiedResolver verifies the documents targeted by external $refs before they are bundled
+type verifiedResolver struct {
+	resolver RefResolver
+	verifier Verifier
+}
+
+// Load loads the referenced document and verifies it
+func (r *verifiedResolver) Load(location string) ([]byte, error) {
+	content, err := r.resolver.Load(location)
+	if err != nil {
+		return nil, err
+	}
+	if err := r.verifier.Verify(location, content); err != nil {
+		return nil, fmt.Errorf("integrity verification failed: %v", err)
+	}
+	return content, nil
+}
@@ -0,0 +1,106 @@
+package oas
+
+import (
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSpecIntegrity(t *testing.T) {
+	dir := t.TempDir()
+	publicKey, privateKey, err := ed25519.GenerateKey(nil)
+	assert.NoError(t, err)
+
+	specPath := filepath.Join(dir, "openapi.json")
+	tamperedPath := filepath.Join(dir, "tampered.json")
+	petsSpec := `{"openapi": "3.0.0", "info": {"title": "Pets", "version": "1.0.0"}, "paths": {}, "components": {"schemas": {"Pet": {"$ref": "schemas/pet.json"}}}}`
+	petSchema := `{"type": "object", "required": ["name"]}`
+
+	// Files are listed by path, tampered files with the checksum and signature of their original content
+	documents := []struct {
+		path     string
+		content  string
+		original string
+	}{
+		{path: "openapi.json", content: testSpec},
+		{path: "tampered.json", content: testSpec + " ", original: testSpec},
+		{path: "pets/openapi.json", content: petsSpec},
+		{path: "pets/schemas/pet.json", content: petSchema},
+		{path: "tampered/openapi.json", content: petsSpec},
+		{path: "tampered/schemas/pet.json", content: `{"type": "object"}`, original: petSchema},
+	}
+	var manifest []byte
+	for _, document := range documents {
+		signed := document.content
+		if document.original != "" {
+			signed = document.original
+		}
+		sum := sha256.Sum256([]byte(signed))
+		manifest = append(manifest, hex.EncodeToString(sum[:])+"  "+document.path+"\n"...)
+
+		file := filepath.Join(dir, filepath.FromSlash(document.path))
+		assert.NoError(t, os.MkdirAll(filepath.Dir(file), 0o755))
+		assert.NoError(t, os.WriteFile(file, []byte(document.content), 0o644))
+		signature := base64.StdEncoding.EncodeToString(ed25519.Sign(privateKey, []byte(signed)))
+		assert.NoError(t, os.WriteFile(file+".sig", []byte(signature), 0o644))
+	}
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "SHA256SUMS"), manifest, 0o644))
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "SHA256SUMS.sig"), ed25519.Sign(privateKey, manifest), 0o644))
+
+	tests := []struct {
+		name          string
+		config        IntegrityConfig
+		expectedError string
+	}{
+		{
+			name:   "Checksum manifest",
+			config: IntegrityConfig{Manifest: filepath.Join(dir, "SHA256SUMS")},
+		},
+		{
+			name:   "Signed checksum manifest",
+			config: IntegrityConfig{Manifest: filepath.Join(dir, "SHA256SUMS"), PublicKey: base64.StdEncoding.EncodeToString(publicKey)},
+		},
+		{
+			name:   "Detached signature",
+			config: IntegrityConfig{PublicKey: base64.StdEncoding.EncodeToString(publicKey)},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			verifier, err := tt.config.NewVerifier(FileRefResolver{})
+			assert.NoError(t, err)
+
+			manager := NewOASManager(nil, FixedSelector(map[string]string{"test": "test"}), WithVerifier(verifier))
+			assert.NoError(t, manager.LoadAPIFromFile("valid", specPath))
+			assert.NoError(t, manager.LoadAPIFromFile("pets", filepath.Join(dir, "pets", "openapi.json")))
+
+			err = manager.LoadAPIFromFile("tampered", tamperedPath)
+			assert.ErrorContains(t, err, "integrity verification failed")
+			_, err = manager.GetApiSpec("tampered")
+			assert.Error(t, err)
+
+			// Referenced documents are verified too
+			err = manager.LoadAPIFromFile("tamperedRef", filepath.Join(dir, "tampered", "openapi.json"))
+			assert.ErrorContains(t, err, "integrity verification failed")
+			_, err = manager.GetApiSpec("tamperedRef")
+			assert.Error(t, err)
+		})
+	}
+
+	_, err = NewChecksumVerifier([]byte(strings.Repeat("0", 64) + "  specs/openapi.json\n" + strings.Repeat("1", 64) + "  ./specs/openapi.json\n"))
+	assert.ErrorContains(t, err, "duplicate checksum manifest entry 'specs/openapi.json'")
+
+	otherKey, _, err := ed25519.GenerateKey(nil)
+	assert.NoError(t, err)
+	config := IntegrityConfig{Manifest: filepath.Join(dir, "SHA256SUMS"), PublicKey: base64.StdEncoding.EncodeToString(otherKey)}
+	_, err = config.NewVerifier(FileRefResolver{})
+	assert.ErrorContains(t, err, "invalid signature")
+}
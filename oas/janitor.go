@@ -0,0 +1,91 @@
+package oas
+
+import (
+	"slices"
+	"sync"
+	"time"
+)
+
+// StartJanitor cleans the manager every CleanupInterval: cold paths are evicted with CleanPaths and, when cold
+// specifications are spilled (MaxResidentAPIs), expired specifications are spilled with CleanApiSpec. Without
+// spilling, specifications are never removed by the janitor, as they could not be served again.
+// The returned function stops the janitor.
+func (m *OASManager) StartJanitor() func() {
+	interval := m.config.CleanupInterval.Duration
+	if interval <= 0 {
+		return func() {}
+	}
+
+	done := make(chan struct{})
+	var once sync.Once
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				if m.overflowEnabled() {
+					m.CleanApiSpec()
+				}
+				m.CleanPaths()
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	return func() {
+		once.Do(func() { close(done) })
+	}
+}
+
+// CleanPaths evicts the path items of the loaded specifications matched by fewer than MinPathHits requests within
+// PathExpiryTime, then the coldest ones beyond MaxPathsPerAPI. Evicted path items are decoded again from the
+// specification on their next request; their route and hit counters are kept.
+func (m *OASManager) CleanPaths() {
+	now := m.clock.Now()
+	for _, spec := range m.GetApiSpecs() {
+		spec.evictPaths(now, m.config.PathExpiryTime.Duration, m.config.MinPathHits, m.config.MaxPathsPerAPI)
+	}
+}
+
+// evictPaths evicts the path items with fewer than minHits hits within the window, then the coldest ones beyond
+// maxPaths decoded path items (0 for no limit). It returns the number of evicted path items.
+func (s *APISpec) evictPaths(now time.Time, window time.Duration, minHits int64, maxPaths int) int {
+	type pathHits struct {
+		path *PathCache
+		hits int64
+	}
+
+	var decoded []pathHits
+	for _, path := range s.Paths {
+		path.mu.Lock()
+		evictable := path.Item != nil && path.raw != nil
+		path.mu.Unlock()
+		if evictable {
+			decoded = append(decoded, pathHits{path: path, hits: path.Hits.Recent(window, now)})
+		}
+	}
+
+	// The hottest paths are kept
+	slices.SortFunc(decoded, func(a, b pathHits) int {
+		switch {
+		case a.hits > b.hits:
+			return -1
+		case a.hits < b.hits:
+			return 1
+		}
+		return 0
+	})
+
+	evicted := 0
+	for i, candidate := range decoded {
+		if window > 0 && candidate.hits < minHits || maxPaths > 0 && i >= maxPaths {
+			candidate.path.mu.Lock()
+			candidate.path.Item = nil
+			candidate.path.mu.Unlock()
+			evicted++
+		}
+	}
+	return evicted
+}
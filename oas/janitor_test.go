@@ -0,0 +1,57 @@
+package oas
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/lionelgarnier/validate-api-request/pkg/clock"
+)
+
+func TestCleanPaths(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	mockClock := clock.NewMock(start)
+
+	config := DefaultCacheConfig()
+	config.PathExpiryTime = Duration{time.Hour}
+	config.MinPathHits = 2
+	config.MaxPathsPerAPI = 1
+	manager := NewOASManager(config, FixedSelector(map[string]string{"test": "test"}), WithClock(mockClock))
+	assert.NoError(t, manager.LoadAPI("test", []byte(`{
+		"openapi": "3.0.0",
+		"info": {"title": "Test API", "version": "1.0.0"},
+		"paths": {"/pets": {"get": {}}, "/owners": {"get": {}}, "/toys": {"get": {}}}
+	}`)))
+	spec, err := manager.GetApiSpec("test")
+	assert.NoError(t, err)
+
+	for range 3 {
+		spec.Paths["/pets"].Access(mockClock.Now())
+	}
+	for range 2 {
+		spec.Paths["/owners"].Access(mockClock.Now())
+	}
+	spec.Paths["/toys"].Access(mockClock.Now())
+
+	manager.CleanPaths()
+
+	// The cold path and the paths beyond the limit are evicted
+	assert.NotNil(t, spec.Paths["/pets"].Item)
+	assert.Nil(t, spec.Paths["/owners"].Item)
+	assert.Nil(t, spec.Paths["/toys"].Item)
+
+	// Evicted paths are decoded again on their next request, their counters are kept
+	item, err := spec.Paths["/toys"].Access(mockClock.Now())
+	assert.NoError(t, err)
+	assert.NotNil(t, item.Get)
+	assert.Equal(t, int64(2), spec.Paths["/toys"].Hits.Total())
+	assert.Equal(t, int64(2), spec.Paths["/toys"].HitCount)
+
+	// Paths are cold once their hits are out of the window
+	mockClock.Advance(2 * time.Hour)
+	manager.CleanPaths()
+	assert.Nil(t, spec.Paths["/pets"].Item)
+	assert.Nil(t, spec.Paths["/toys"].Item)
+	assert.Equal(t, int64(1), spec.HitCount)
+}
@@ -0,0 +1,56 @@
+package oas
+
+import (
+	"net/http"
+	"time"
+)
+
+// Lifecycle is the deprecation schedule of an operation, declared by its `x-deprecated-at` and `x-sunset`
+// extensions as HTTP dates (e.g. `Wed, 01 Jul 2026 00:00:00 GMT`), RFC 3339 date-times or full dates. Missing or
+// invalid dates are zero.
+type Lifecycle struct {
+	DeprecatedAt time.Time // Date the operation is deprecated from
+	Sunset       time.Time // Date the operation stops being served
+}
+
+// Lifecycle returns the deprecation schedule of the operation
+func (o *Operation) Lifecycle() Lifecycle {
+	return Lifecycle{
+		DeprecatedAt: o.extensionDate("x-deprecated-at"),
+		Sunset:       o.extensionDate("x-sunset"),
+	}
+}
+
+// DeprecatedAt reports whether the operation is deprecated at a time: marked `deprecated`, or past the date of
+// its `x-deprecated-at` or `x-sunset` extension
+func (o *Operation) DeprecatedAt(now time.Time) bool {
+	if o.Deprecated {
+		return true
+	}
+	lifecycle := o.Lifecycle()
+	return !lifecycle.DeprecatedAt.IsZero() && !now.Before(lifecycle.DeprecatedAt) ||
+		!lifecycle.Sunset.IsZero() && !now.Before(lifecycle.Sunset)
+}
+
+// SunsetAt reports whether the operation is past the date of its `x-sunset` extension at a time
+func (o *Operation) SunsetAt(now time.Time) bool {
+	sunset := o.Lifecycle().Sunset
+	return !sunset.IsZero() && !now.Before(sunset)
+}
+
+// extensionDate returns the date of an extension of the operation, zero if it is missing or invalid
+func (o *Operation) extensionDate(name string) time.Time {
+	value, ok := o.Extensions[name].(string)
+	if !ok {
+		return time.Time{}
+	}
+	if date, err := http.ParseTime(value); err == nil {
+		return date
+	}
+	for _, layout := range []string{time.RFC3339, time.DateOnly} {
+		if date, err := time.Parse(layout, value); err == nil {
+			return date
+		}
+	}
+	return time.Time{}
+}
@@ -0,0 +1,47 @@
+package oas
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOperationLifecycle(t *testing.T) {
+	now := time.Date(2026, 7, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name       string
+		operation  Operation
+		lifecycle  Lifecycle
+		deprecated bool
+		sunset     bool
+	}{
+		{name: "No schedule", operation: Operation{}},
+		{name: "Deprecated", operation: Operation{Deprecated: true}, deprecated: true},
+		{name: "HTTP dates", operation: Operation{Extensions: map[string]interface{}{
+			"x-deprecated-at": "Mon, 01 Jun 2026 00:00:00 GMT",
+			"x-sunset":        "Wed, 01 Jul 2026 00:00:00 GMT",
+		}}, lifecycle: Lifecycle{DeprecatedAt: time.Date(2026, 6, 1, 0, 0, 0, 0, time.UTC), Sunset: now}, deprecated: true, sunset: true},
+		{name: "Future deprecation", operation: Operation{Extensions: map[string]interface{}{
+			"x-deprecated-at": "2026-07-01T00:00:01Z",
+		}}, lifecycle: Lifecycle{DeprecatedAt: now.Add(time.Second)}},
+		{name: "Sunset implies deprecation", operation: Operation{Extensions: map[string]interface{}{
+			"x-sunset": "2026-06-30",
+		}}, lifecycle: Lifecycle{Sunset: now.Add(-24 * time.Hour)}, deprecated: true, sunset: true},
+		{name: "Invalid dates", operation: Operation{Extensions: map[string]interface{}{
+			"x-deprecated-at": "last year",
+			"x-sunset":        20260630,
+		}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lifecycle := tt.operation.Lifecycle()
+			assert.True(t, tt.lifecycle.DeprecatedAt.Equal(lifecycle.DeprecatedAt), lifecycle.DeprecatedAt)
+			assert.True(t, tt.lifecycle.Sunset.Equal(lifecycle.Sunset), lifecycle.Sunset)
+			assert.Equal(t, tt.deprecated, tt.operation.DeprecatedAt(now))
+			assert.Equal(t, tt.sunset, tt.operation.SunsetAt(now))
+		})
+	}
+}
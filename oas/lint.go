@@ -0,0 +1,435 @@
+package oas
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/lionelgarnier/validate-api-request/pkg/helpers"
+)
+
+// LintSeverity is the severity of a specification issue
+type LintSeverity string
+
+const (
+	// LintWarning issues do not prevent validation, e.g. unknown keywords
+	LintWarning LintSeverity = "warning"
+	// LintError issues make validation unreliable, e.g. unresolvable $refs
+	LintError LintSeverity = "error"
+)
+
+// LintIssue is a problem found in a specification, located by a JSON pointer
+type LintIssue struct {
+	Severity LintSeverity
+	Pointer  string
+	Message  string
+}
+
+// String returns the severity, location and message of the issue
+func (i LintIssue) String() string {
+	return fmt.Sprintf("%s at '%s': %s", i.Severity, i.Pointer, i.Message)
+}
+
+// LintErrors is returned when strict mode refuses a specification with lint errors
+type LintErrors struct {
+	API    string
+	Issues []LintIssue
+}
+
+// Error lists the lint errors of the specification
+func (e *LintErrors) Error() string {
+	messages := make([]string, 0, len(e.Issues))
+	for _, issue := range e.Issues {
+		if issue.Severity == LintError {
+			messages = append(messages, issue.String())
+		}
+	}
+	return fmt.Sprintf("spec '%s' has lint errors: %s", e.API, strings.Join(messages, "; "))
+}
+
+// hasLintErrors reports whether the issues include errors
+func hasLintErrors(issues []LintIssue) bool {
+	for _, issue := range issues {
+		if issue.Severity == LintError {
+			return true
+		}
+	}
+	return false
+}
+
+// WithStrictLint refuses to load specifications with lint errors
+func WithStrictLint() ManagerOption {
+	return func(m *OASManager) {
+		m.strictLint = true
+	}
+}
+
+// Known keywords of the specification objects, other keywords are reported unless prefixed with `x-`
+var (
+	rootKeywords        = keywords("openapi", "info", "jsonSchemaDialect", "servers", "paths", "webhooks", "components", "security", "tags", "externalDocs")
+	infoKeywords        = keywords("title", "summary", "description", "termsOfService", "contact", "license", "version")
+	componentsKeywords  = keywords("schemas", "responses", "parameters", "examples", "requestBodies", "headers", "securitySchemes", "links", "callbacks", "pathItems")
+	pathItemKeywords    = keywords("$ref", "summary", "description", "get", "put", "post", "delete", "options", "head", "patch", "trace", "servers", "parameters")
+	operationKeywords   = keywords("tags", "summary", "description", "externalDocs", "operationId", "parameters", "requestBody", "responses", "callbacks", "deprecated", "security", "servers")
+	parameterKeywords   = keywords("name", "in", "description", "required", "deprecated", "allowEmptyValue", "style", "explode", "allowReserved", "schema", "example", "examples", "content")
+	requestBodyKeywords = keywords("description", "content", "required")
+	mediaTypeKeywords   = keywords("schema", "example", "examples", "encoding")
+	responseKeywords    = keywords("description", "headers", "content", "links")
+	headerKeywords      = keywords("description", "required", "deprecated", "allowEmptyValue", "style", "explode", "allowReserved", "schema", "example", "examples", "content")
+	schemaKeywords      = keywords(
+		"type", "format", "title", "description", "default", "example", "examples", "enum", "const",
+		"properties", "patternProperties", "additionalProperties", "required", "minProperties", "maxProperties", "propertyNames", "unevaluatedProperties", "dependentRequired", "dependentSchemas", "dependencies",
+		"items", "prefixItems", "additionalItems", "contains", "minContains", "maxContains", "minItems", "maxItems", "uniqueItems", "unevaluatedItems",
+		"allOf", "oneOf", "anyOf", "not", "if", "then", "else",
+		"minimum", "maximum", "exclusiveMinimum", "exclusiveMaximum", "multipleOf", "minLength", "maxLength", "pattern",
+		"nullable", "discriminator", "readOnly", "writeOnly", "xml", "externalDocs", "deprecated",
+		"contentEncoding", "contentMediaType", "contentSchema", "$id", "$schema", "$anchor", "$dynamicAnchor", "$dynamicRef", "$defs", "$comment", "$vocabulary",
+	)
+	pathTemplateParameter = regexp.MustCompile(`\{([^}]+)\}`)
+)
+
+// keywords builds a set of keywords
+func keywords(names ...string) map[string]bool {
+	set := make(map[string]bool, len(names))
+	for _, name := range names {
+		set[name] = true
+	}
+	return set
+}
+
+// linter collects the issues of an OpenAPI 3.x document
+type linter struct {
+	document map[string]interface{}
+	issues   []LintIssue
+}
+
+// Lint checks an OpenAPI 3.x JSON document for unknown keywords, a missing info, undeclared path parameters,
+// unresolvable local $refs, invalid regular expressions and undefined security schemes.
+func Lint(content []byte) ([]LintIssue, error) {
+	var document map[string]interface{}
+	if err := json.Unmarshal(content, &document); err != nil {
+		return nil, fmt.Errorf("failed to parse OAS document: %v", err)
+	}
+
+	l := &linter{document: document}
+	l.lintRoot()
+	l.lintRefs(document, "")
+
+	// Objects are walked in map order, sort the issues to report them consistently
+	sort.SliceStable(l.issues, func(i, j int) bool {
+		if l.issues[i].Pointer != l.issues[j].Pointer {
+			return l.issues[i].Pointer < l.issues[j].Pointer
+		}
+		return l.issues[i].Message < l.issues[j].Message
+	})
+	return l.issues, nil
+}
+
+// report adds an issue
+func (l *linter) report(severity LintSeverity, pointer, format string, args ...interface{}) {
+	l.issues = append(l.issues, LintIssue{Severity: severity, Pointer: pointer, Message: fmt.Sprintf(format, args...)})
+}
+
+// lintKeywords reports the keywords of an object missing from the known keywords
+func (l *linter) lintKeywords(object map[string]interface{}, pointer string, known map[string]bool) {
+	// Siblings of a $ref are ignored, they are not validated as keywords
+	if _, isRef := object["$ref"]; isRef {
+		return
+	}
+	for key := range object {
+		if !known[key] && !strings.HasPrefix(key, "x-") {
+			l.report(LintWarning, pointer, "unknown keyword '%s'", key)
+		}
+	}
+}
+
+// lintRoot checks the root object and its children
+func (l *linter) lintRoot() {
+	l.lintKeywords(l.document, "", rootKeywords)
+
+	info, ok := l.document["info"].(map[string]interface{})
+	if !ok {
+		l.report(LintError, "/info", "missing info")
+	} else {
+		l.lintKeywords(info, "/info", infoKeywords)
+		for _, field := range []string{"title", "version"} {
+			if _, exists := info[field]; !exists {
+				l.report(LintError, "/info", "missing %s", field)
+			}
+		}
+	}
+
+	paths, _ := l.document["paths"].(map[string]interface{})
+	for route, item := range paths {
+		if pathItem, ok := item.(map[string]interface{}); ok {
+			l.lintPathItem(route, pathItem, "/paths/"+helpers.EscapeJSONPointer(route))
+		}
+	}
+
+	l.lintSecurity()
+
+	components, ok := l.document["components"].(map[string]interface{})
+	if !ok {
+		return
+	}
+	l.lintKeywords(components, "/components", componentsKeywords)
+	for name, schema := range objectMap(components["schemas"]) {
+		l.lintSchema(schema, "/components/schemas/"+helpers.EscapeJSONPointer(name))
+	}
+	for name, parameter := range objectMap(components["parameters"]) {
+		l.lintParameter(parameter, "/components/parameters/"+helpers.EscapeJSONPointer(name))
+	}
+	for name, requestBody := range objectMap(components["requestBodies"]) {
+		l.lintContent(requestBody, "/components/requestBodies/"+helpers.EscapeJSONPointer(name), requestBodyKeywords)
+	}
+	for name, response := range objectMap(components["responses"]) {
+		l.lintResponse(response, "/components/responses/"+helpers.EscapeJSONPointer(name))
+	}
+	for name, header := range objectMap(components["headers"]) {
+		l.lintParameterLike(header, "/components/headers/"+helpers.EscapeJSONPointer(name), headerKeywords)
+	}
+}
+
+// lintSecurity reports the security schemes required but not defined in the components, which no request can
+// satisfy, with the operations requiring them, or the root requirements when no operation inherits them
+func (l *linter) lintSecurity() {
+	components, _ := l.document["components"].(map[string]interface{})
+	defined, _ := components["securitySchemes"].(map[string]interface{})
+
+	undefined := make(map[string][]string)
+	require := func(requirements interface{}, usage string) {
+		list, _ := requirements.([]interface{})
+		for _, requirement := range list {
+			schemes, _ := requirement.(map[string]interface{})
+			for name := range schemes {
+				if _, exists := defined[name]; !exists && !slices.Contains(undefined[name], usage) {
+					undefined[name] = append(undefined[name], usage)
+				}
+			}
+		}
+	}
+
+	rootSecurity, inherited := l.document["security"], false
+	paths, _ := l.document["paths"].(map[string]interface{})
+	for route, item := range paths {
+		pathItem, _ := item.(map[string]interface{})
+		if _, isRef := pathItem["$ref"]; isRef {
+			continue
+		}
+		for _, method := range []string{"get", "put", "post", "delete", "options", "head", "patch", "trace"} {
+			operation, ok := pathItem[method].(map[string]interface{})
+			if !ok {
+				continue
+			}
+			usage := strings.ToUpper(method) + " " + route
+			if security, exists := operation["security"]; exists {
+				require(security, usage)
+			} else {
+				inherited = true
+				require(rootSecurity, usage)
+			}
+		}
+	}
+	if !inherited {
+		require(rootSecurity, "root security")
+	}
+
+	for name, usages := range undefined {
+		sort.Strings(usages)
+		l.report(LintError, "/components/securitySchemes", "security scheme '%s' is not defined, required by %s", name, strings.Join(usages, ", "))
+	}
+}
+
+// lintPathItem checks a path item, its operations and the declaration of the path template parameters
+func (l *linter) lintPathItem(route string, pathItem map[string]interface{}, pointer string) {
+	l.lintKeywords(pathItem, pointer, pathItemKeywords)
+	if _, isRef := pathItem["$ref"]; isRef {
+		return
+	}
+
+	pathParameters := l.lintParameters(pathItem["parameters"], pointer+"/parameters")
+
+	var templateParameters []string
+	for _, match := range pathTemplateParameter.FindAllStringSubmatch(route, -1) {
+		templateParameters = append(templateParameters, match[1])
+	}
+
+	for _, method := range []string{"get", "put", "post", "delete", "options", "head", "patch", "trace"} {
+		operation, ok := pathItem[method].(map[string]interface{})
+		if !ok {
+			continue
+		}
+		operationPointer := pointer + "/" + method
+		l.lintKeywords(operation, operationPointer, operationKeywords)
+
+		declared := make(map[string]bool)
+		for name := range pathParameters {
+			declared[name] = true
+		}
+		for name := range l.lintParameters(operation["parameters"], operationPointer+"/parameters") {
+			declared[name] = true
+		}
+		for _, name := range templateParameters {
+			if !declared[name] {
+				l.report(LintError, operationPointer, "path parameter '%s' is not declared", name)
+			}
+			delete(declared, name)
+		}
+		for name := range declared {
+			l.report(LintError, operationPointer, "path parameter '%s' is not in the path template '%s'", name, route)
+		}
+
+		if requestBody, ok := operation["requestBody"].(map[string]interface{}); ok {
+			l.lintContent(requestBody, operationPointer+"/requestBody", requestBodyKeywords)
+		}
+		for status, response := range objectMap(operation["responses"]) {
+			l.lintResponse(response, operationPointer+"/responses/"+helpers.EscapeJSONPointer(status))
+		}
+	}
+}
+
+// lintParameters checks a list of parameters and returns the names of the path parameters
+func (l *linter) lintParameters(value interface{}, pointer string) map[string]bool {
+	pathParameters := make(map[string]bool)
+	parameters, _ := value.([]interface{})
+	for i, item := range parameters {
+		parameter, ok := item.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		parameterPointer := fmt.Sprintf("%s/%d", pointer, i)
+		l.lintParameter(parameter, parameterPointer)
+
+		// Follow local references to find the parameter location and name
+		if ref, ok := parameter["$ref"].(string); ok && strings.HasPrefix(ref, "#") {
+			target, err := helpers.ResolveJSONPointer(l.document, ref[1:])
+			if err != nil {
+				continue
+			}
+			if parameter, ok = target.(map[string]interface{}); !ok {
+				continue
+			}
+		}
+		if in, _ := parameter["in"].(string); in == "path" {
+			if name, ok := parameter["name"].(string); ok {
+				pathParameters[name] = true
+			}
+		}
+	}
+	return pathParameters
+}
+
+// lintParameter checks a parameter
+func (l *linter) lintParameter(parameter map[string]interface{}, pointer string) {
+	l.lintParameterLike(parameter, pointer, parameterKeywords)
+}
+
+// lintParameterLike checks a parameter or header and its schemas
+func (l *linter) lintParameterLike(object map[string]interface{}, pointer string, known map[string]bool) {
+	l.lintKeywords(object, pointer, known)
+	if schema, ok := object["schema"].(map[string]interface{}); ok {
+		l.lintSchema(schema, pointer+"/schema")
+	}
+	l.lintMediaTypes(object, pointer)
+}
+
+// lintContent checks an object holding media types, such as a request body
+func (l *linter) lintContent(object map[string]interface{}, pointer string, known map[string]bool) {
+	l.lintKeywords(object, pointer, known)
+	l.lintMediaTypes(object, pointer)
+}
+
+// lintResponse checks a response, its headers and media types
+func (l *linter) lintResponse(response map[string]interface{}, pointer string) {
+	l.lintContent(response, pointer, responseKeywords)
+	for name, header := range objectMap(response["headers"]) {
+		l.lintParameterLike(header, pointer+"/headers/"+helpers.EscapeJSONPointer(name), headerKeywords)
+	}
+}
+
+// lintMediaTypes checks the media types of the content of an object
+func (l *linter) lintMediaTypes(object map[string]interface{}, pointer string) {
+	for name, mediaType := range objectMap(object["content"]) {
+		mediaTypePointer := pointer + "/content/" + helpers.EscapeJSONPointer(name)
+		l.lintKeywords(mediaType, mediaTypePointer, mediaTypeKeywords)
+		if schema, ok := mediaType["schema"].(map[string]interface{}); ok {
+			l.lintSchema(schema, mediaTypePointer+"/schema")
+		}
+	}
+}
+
+// lintSchema checks a schema, its regular expressions and its subschemas
+func (l *linter) lintSchema(schema map[string]interface{}, pointer string) {
+	l.lintKeywords(schema, pointer, schemaKeywords)
+
+	if pattern, ok := schema["pattern"].(string); ok {
+		if _, err := regexp.Compile(pattern); err != nil {
+			l.report(LintError, pointer+"/pattern", "invalid regular expression '%s': %v", pattern, err)
+		}
+	}
+
+	for pattern, subschema := range objectMap(schema["patternProperties"]) {
+		subschemaPointer := pointer + "/patternProperties/" + helpers.EscapeJSONPointer(pattern)
+		if _, err := regexp.Compile(pattern); err != nil {
+			l.report(LintError, subschemaPointer, "invalid regular expression '%s': %v", pattern, err)
+		}
+		l.lintSchema(subschema, subschemaPointer)
+	}
+
+	for _, keyword := range []string{"properties", "$defs", "dependentSchemas", "dependencies"} {
+		for name, subschema := range objectMap(schema[keyword]) {
+			l.lintSchema(subschema, pointer+"/"+keyword+"/"+helpers.EscapeJSONPointer(name))
+		}
+	}
+	for _, keyword := range []string{"items", "additionalItems", "additionalProperties", "not", "contains", "if", "then", "else", "propertyNames", "unevaluatedProperties", "unevaluatedItems"} {
+		if subschema, ok := schema[keyword].(map[string]interface{}); ok {
+			l.lintSchema(subschema, pointer+"/"+keyword)
+		}
+	}
+	for _, keyword := range []string{"allOf", "oneOf", "anyOf", "prefixItems"} {
+		subschemas, _ := schema[keyword].([]interface{})
+		for i, item := range subschemas {
+			if subschema, ok := item.(map[string]interface{}); ok {
+				l.lintSchema(subschema, fmt.Sprintf("%s/%s/%d", pointer, keyword, i))
+			}
+		}
+	}
+}
+
+// lintRefs reports the local $refs of the document that do not resolve
+func (l *linter) lintRefs(node interface{}, pointer string) {
+	switch n := node.(type) {
+	case map[string]interface{}:
+		if ref, ok := n["$ref"].(string); ok && strings.HasPrefix(ref, "#") {
+			if _, err := helpers.ResolveJSONPointer(l.document, ref[1:]); err != nil {
+				l.report(LintError, pointer, "unresolvable $ref '%s'", ref)
+			}
+		}
+		for key, value := range n {
+			// Example and default values are data, not specification objects
+			if key == "example" || key == "default" || key == "enum" || key == "const" {
+				continue
+			}
+			l.lintRefs(value, pointer+"/"+helpers.EscapeJSONPointer(key))
+		}
+	case []interface{}:
+		for i, value := range n {
+			l.lintRefs(value, fmt.Sprintf("%s/%d", pointer, i))
+		}
+	}
+}
+
+// objectMap returns the object values of a JSON object
+func objectMap(value interface{}) map[string]map[string]interface{} {
+	object, _ := value.(map[string]interface{})
+	objects := make(map[string]map[string]interface{}, len(object))
+	for key, item := range object {
+		if child, ok := item.(map[string]interface{}); ok {
+			objects[key] = child
+		}
+	}
+	return objects
+}
@@ -0,0 +1,164 @@
+package oas
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLint(t *testing.T) {
+	tests := []struct {
+		name     string
+		spec     string
+		expected []LintIssue
+	}{
+		{
+			name:     "Valid spec",
+			spec:     testSpec,
+			expected: nil,
+		},
+		{
+			name: "Missing info",
+			spec: `{"openapi": "3.0.0", "paths": {}}`,
+			expected: []LintIssue{
+				{Severity: LintError, Pointer: "/info", Message: "missing info"},
+			},
+		},
+		{
+			name: "Missing info version",
+			spec: `{"openapi": "3.0.0", "info": {"title": "Test API"}, "paths": {}}`,
+			expected: []LintIssue{
+				{Severity: LintError, Pointer: "/info", Message: "missing version"},
+			},
+		},
+		{
+			name: "Unknown keywords",
+			spec: `{
+				"openapi": "3.0.0",
+				"info": {"title": "Test API", "version": "1.0.0", "x-owner": "team"},
+				"paths": {"/pets": {"get": {"response": {}}}},
+				"components": {"schemas": {"Pet": {"type": "object", "propertys": {}}}}
+			}`,
+			expected: []LintIssue{
+				{Severity: LintWarning, Pointer: "/components/schemas/Pet", Message: "unknown keyword 'propertys'"},
+				{Severity: LintWarning, Pointer: "/paths/~1pets/get", Message: "unknown keyword 'response'"},
+			},
+		},
+		{
+			name: "Path parameters",
+			spec: `{
+				"openapi": "3.0.0",
+				"info": {"title": "Test API", "version": "1.0.0"},
+				"paths": {
+					"/pets/{petId}/toys/{toyId}": {
+						"parameters": [{"$ref": "#/components/parameters/PetId"}],
+						"get": {"parameters": [{"name": "ownerId", "in": "path", "required": true}]}
+					}
+				},
+				"components": {"parameters": {"PetId": {"name": "petId", "in": "path", "required": true}}}
+			}`,
+			expected: []LintIssue{
+				{Severity: LintError, Pointer: "/paths/~1pets~1{petId}~1toys~1{toyId}/get", Message: "path parameter 'ownerId' is not in the path template '/pets/{petId}/toys/{toyId}'"},
+				{Severity: LintError, Pointer: "/paths/~1pets~1{petId}~1toys~1{toyId}/get", Message: "path parameter 'toyId' is not declared"},
+			},
+		},
+		{
+			name: "Unresolvable ref",
+			spec: `{
+				"openapi": "3.0.0",
+				"info": {"title": "Test API", "version": "1.0.0"},
+				"paths": {"/pets": {"get": {"responses": {"200": {"$ref": "#/components/responses/Missing"}}}}}
+			}`,
+			expected: []LintIssue{
+				{Severity: LintError, Pointer: "/paths/~1pets/get/responses/200", Message: "unresolvable $ref '#/components/responses/Missing'"},
+			},
+		},
+		{
+			name: "Invalid patterns",
+			spec: `{
+				"openapi": "3.0.0",
+				"info": {"title": "Test API", "version": "1.0.0"},
+				"paths": {},
+				"components": {"schemas": {"Pet": {
+					"properties": {"name": {"type": "string", "pattern": "^[a-z"}},
+					"patternProperties": {"(": {"type": "string"}}
+				}}}
+			}`,
+			expected: []LintIssue{
+				{Severity: LintError, Pointer: "/components/schemas/Pet/patternProperties/(", Message: "invalid regular expression '(': error parsing regexp: missing closing ): `(`"},
+				{Severity: LintError, Pointer: "/components/schemas/Pet/properties/name/pattern", Message: "invalid regular expression '^[a-z': error parsing regexp: missing closing ]: `[a-z`"},
+			},
+		},
+		{
+			name: "Undefined security schemes",
+			spec: `{
+				"openapi": "3.0.0",
+				"info": {"title": "Test API", "version": "1.0.0"},
+				"security": [{"apiKey": []}, {"oauth": ["read"]}],
+				"paths": {
+					"/pets": {
+						"get": {},
+						"post": {"security": [{"apiKey": [], "basic": []}]},
+						"delete": {"security": []}
+					},
+					"/users": {"get": {"security": [{"basic": []}, {}]}}
+				},
+				"components": {"securitySchemes": {"apiKey": {"type": "apiKey", "in": "header", "name": "X-API-Key"}}}
+			}`,
+			expected: []LintIssue{
+				{Severity: LintError, Pointer: "/components/securitySchemes", Message: "security scheme 'basic' is not defined, required by GET /users, POST /pets"},
+				{Severity: LintError, Pointer: "/components/securitySchemes", Message: "security scheme 'oauth' is not defined, required by GET /pets"},
+			},
+		},
+		{
+			name: "Undefined root security scheme",
+			spec: `{
+				"openapi": "3.0.0",
+				"info": {"title": "Test API", "version": "1.0.0"},
+				"security": [{"oauth": []}],
+				"paths": {"/pets": {"get": {"security": []}}}
+			}`,
+			expected: []LintIssue{
+				{Severity: LintError, Pointer: "/components/securitySchemes", Message: "security scheme 'oauth' is not defined, required by root security"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			issues, err := Lint([]byte(tt.spec))
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, issues)
+		})
+	}
+}
+
+func TestStrictLint(t *testing.T) {
+	const brokenSpec = `{
+		"openapi": "3.0.0",
+		"info": {"title": "Test API", "version": "1.0.0"},
+		"paths": {"/pets/{petId}": {"get": {}}}
+	}`
+
+	lenient := NewOASManager(DefaultCacheConfig(), FixedSelector(map[string]string{"test": "test"}))
+	assert.NoError(t, lenient.LoadAPI("test", []byte(brokenSpec)))
+	spec, err := lenient.GetApiSpec("test")
+	assert.NoError(t, err)
+	assert.Equal(t, []LintIssue{
+		{Severity: LintError, Pointer: "/paths/~1pets~1{petId}/get", Message: "path parameter 'petId' is not declared"},
+	}, spec.LintIssues())
+
+	var events []Event
+	strict := NewOASManager(DefaultCacheConfig(), FixedSelector(map[string]string{"test": "test"}),
+		WithStrictLint(), WithEventHandler(func(event Event) { events = append(events, event) }))
+	err = strict.LoadAPI("test", []byte(brokenSpec))
+	var lintErrors *LintErrors
+	assert.True(t, errors.As(err, &lintErrors))
+	assert.Len(t, events, 1)
+	assert.Equal(t, EventRejected, events[0].Type)
+	_, err = strict.GetApiSpec("test")
+	assert.Error(t, err)
+
+	assert.NoError(t, strict.LoadAPI("valid", []byte(testSpec)))
+}
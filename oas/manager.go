@@ -3,14 +3,23 @@ package oas
 import (
 	"encoding/json"
 	"fmt"
+	"io"
+	"io/fs"
+	"maps"
 	"net/http"
+	"net/url"
 	"os"
 	"regexp"
+	"slices"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/zeebo/xxh3"
+
+	"github.com/lionelgarnier/validate-api-request/cache"
+	"github.com/lionelgarnier/validate-api-request/pkg/clock"
 )
 
 // APISelector is a function that determines the API specification for a given request.
@@ -21,6 +30,7 @@ type Manager interface {
 	EvictApiSpec(name string)
 	EvictAllApiSpecs()
 	GetApiSpecs() map[string]*APISpec
+	RangeSpecs(fn func(name string, spec *APISpec) bool)
 }
 
 // APISelector is a function that determines the API specification for a given request.
@@ -28,31 +38,103 @@ type OASManager struct {
 	apiSpecs    map[string]*APISpec // Maps API name/version to context
 	config      *CacheConfig
 	apiSelector APISelector
-	mu          sync.RWMutex
+	clock       clock.Clock
+	resolver    RefResolver
+	client      *http.Client
+	verifier    Verifier
+	strictLint  bool
+
+	canonicalHash bool // Hash documents in canonical form
+
+	versionPolicy VersionPolicy
+	versions      map[string]string // info.version last activated per API
+	eventHandler  EventHandler
+	hits          atomic.Int64           // Spec lookups finding a loaded spec
+	misses        atomic.Int64           // Spec lookups of unknown APIs
+	sources       map[string]*specSource // Normalized documents of the specs, when cold specs are spilled
+	spills        atomic.Int64           // Compiled specs spilled
+	overflowID    string                 // Prefix of the overflow files of the manager
+	mu            sync.RWMutex
+}
+
+// ManagerOption configures optional OASManager behavior
+type ManagerOption func(*OASManager)
+
+// WithSpecClient sets the HTTP client used by LoadAPIFromURL
+func WithSpecClient(client *http.Client) ManagerOption {
+	return func(m *OASManager) {
+		m.client = client
+	}
+}
+
+// WithClock sets the clock used for access tracking and expiry
+func WithClock(clk clock.Clock) ManagerOption {
+	return func(m *OASManager) {
+		m.clock = clk
+	}
 }
 
 // APISelector is a function that determines the API specification for a given request.
 type APISpec struct {
+	Name         string                // API name in the manager
 	openapi      string                // OpenAPI version
 	info         json.RawMessage       // Info
+	version      string                // Info version
 	servers      []json.RawMessage     // Servers
 	Paths        map[string]*PathCache // Hot paths
+	Webhooks     map[string]*PathItem  // Webhooks (OpenAPI 3.1)
 	Components   *ComponentCache       // Warm components
 	Security     []SecurityRequirement // Security
 	tags         []json.RawMessage     // Tags
 	externalDocs json.RawMessage       // ExternalDocs
 	hash         uint64                // Quick comparison
+	references   []string              // Locations of the documents targeted by external $refs
+	lintIssues   []LintIssue           // Issues found when loading
 	LastAccess   time.Time
-	HitCount     int64
+	Hits         HitCounter // Lookups of the spec
+	// Deprecated: HitCount counts the lookups since the spec was loaded, use Hits for the recent ones.
+	HitCount int64
+
+	accessMu sync.Mutex // Serializes the LastAccess updates of concurrent lookups
 }
 
 // APISelector is a function that determines the API specification for a given request.
 type PathCache struct {
-	Item          *PathItem
+	Item          *PathItem // Nil once evicted by the janitor, see PathItem
 	CompiledRegex *regexp.Regexp
 	Route         string
 	LastAccess    time.Time
-	HitCount      int64
+	Hits          HitCounter // Requests matching the path
+	// Deprecated: HitCount counts the requests since the spec was loaded, use Hits for the recent ones.
+	HitCount int64
+
+	mu  sync.Mutex
+	raw json.RawMessage // Document of the path item, decoded again once evicted
+}
+
+// PathItem returns the path item, decoding it again when it was evicted by the janitor
+func (p *PathCache) PathItem() (*PathItem, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.Item == nil && p.raw != nil {
+		var item PathItem
+		if err := json.Unmarshal(p.raw, &item); err != nil {
+			return nil, fmt.Errorf("failed to decode path '%s': %v", p.Route, err)
+		}
+		p.Item = &item
+	}
+	return p.Item, nil
+}
+
+// Access records a request matching the path at the given time and returns its path item
+func (p *PathCache) Access(now time.Time) (*PathItem, error) {
+	p.Hits.Add(now)
+	atomic.AddInt64(&p.HitCount, 1)
+	p.mu.Lock()
+	p.LastAccess = now
+	p.mu.Unlock()
+	return p.PathItem()
 }
 
 // APISelector is a function that determines the API specification for a given request.
@@ -66,6 +148,7 @@ type ComponentCache struct {
 	SecuritySchemes map[string]*SecurityScheme
 	Links           map[string]*Link
 	Callbacks       map[string]*Callback
+	PathItems       map[string]*PathItem
 }
 
 type OASRequest struct {
@@ -73,30 +156,84 @@ type OASRequest struct {
 	Route     string
 	PathItem  *PathItem
 	Operation *Operation
+	Coercions []Coercion // String values of the request accepted as another type by lenient validation
+	// Deprecated operation, parameters and properties used by the request
+	Deprecations []Deprecation
+	// Security requirement satisfied by the request, nil when the operation allows anonymous requests
+	Security SecurityRequirement
+	// Schemas evaluated to validate the request
+	Evaluations int
+}
+
+// Coercion is a string value of a request validated as the number, boolean or array its schema declares,
+// e.g. "42" for an integer or "true" for a boolean
+type Coercion struct {
+	Location string // Location of the value: query, header, path, cookie or body
+	Path     string // Parameter name or property path of the value, empty for the whole body
+	Value    string
+	Type     string // Type the value was accepted as
+}
+
+// Deprecation is a deprecated part of the spec used by a request: its operation, a parameter sent, or a property
+// of the body matching a schema marked `deprecated: true`
+type Deprecation struct {
+	Location string // Location of the deprecated part: operation, query, header, path, cookie or body
+	Path     string // Parameter name or property path, empty for the operation and the whole body
 }
 
 func NewOASRequest(r *http.Request) *OASRequest {
 	return &OASRequest{Request: r}
 }
 
+// Tags returns the tags of the operation of the request, nil until the operation is resolved
+func (r *OASRequest) Tags() []string {
+	if r.Operation == nil {
+		return nil
+	}
+	return r.Operation.Tags
+}
+
 // NewOASManager creates a new OAS manager with the given configuration and API selector.
-func NewOASManager(config *CacheConfig, selector APISelector) *OASManager {
+func NewOASManager(config *CacheConfig, selector APISelector, opts ...ManagerOption) *OASManager {
 	if config == nil {
 		config = DefaultCacheConfig()
 	}
 
-	return &OASManager{
+	manager := &OASManager{
 		apiSpecs:    make(map[string]*APISpec),
+		versions:    make(map[string]string),
+		sources:     make(map[string]*specSource),
+		overflowID:  newOverflowID(),
 		config:      config,
 		apiSelector: selector,
+		clock:       clock.Real(),
+		resolver:    FileRefResolver{},
+		client:      &http.Client{Timeout: 30 * time.Second},
 		mu:          sync.RWMutex{},
 	}
+
+	for _, opt := range opts {
+		opt(manager)
+	}
+
+	return manager
+}
+
+// Clock returns the clock used by the manager.
+func (m *OASManager) Clock() clock.Clock {
+	return m.clock
+}
+
+// SelectAPI returns the name of the API selected for the given request, empty if none.
+func (m *OASManager) SelectAPI(r *http.Request) string {
+	return m.apiSelector(r)
 }
 
 // GetApiSpecForRequest returns the API specification for the given request.
 func (m *OASManager) GetApiSpecForRequest(r *http.Request) (*APISpec, error) {
 	apiName := m.apiSelector(r)
 	if apiName == "" {
+		m.misses.Add(1)
 		return nil, fmt.Errorf("could not determine API specification")
 	}
 
@@ -109,23 +246,167 @@ func (m *OASManager) GetApiSpecForRequest(r *http.Request) (*APISpec, error) {
 }
 
 // LoadAPI loads an API specification into the manager.
+// External $refs to local files are refused, as the specification has no directory.
 func (m *OASManager) LoadAPI(name string, content []byte) error {
-	m.mu.Lock()
-	defer m.mu.Unlock()
+	return m.loadAPI(name, content, "", confineFiles("", m.resolver), m.documentHash(content))
+}
+
+// loadDocument loads a specification located at location, which may be gzip-compressed or a tar archive of a multi-file specification.
+// The content is verified first when the manager has a verifier, as are the documents targeted by its external
+// $refs, except the files of archives which are verified with their archive. External $refs are loaded with the resolver.
+func (m *OASManager) loadDocument(name, location string, content []byte, resolver RefResolver) error {
+	if m.verifier != nil {
+		if err := m.verifier.Verify(location, content); err != nil {
+			return fmt.Errorf("integrity verification failed: %v", err)
+		}
+		resolver = &verifiedResolver{resolver: resolver, verifier: m.verifier}
+	}
 
-	hash := xxh3.HashString(string(content))
+	hash := xxh3.Hash(content)
 
-	// Check if API exists with same hash
-	if existing, exists := m.apiSpecs[name]; exists {
-		if existing.hash == hash {
-			// Same content, skip loading
+	content, err := decompress(content)
+	if err != nil {
+		return err
+	}
+	if !isTar(content) {
+		return m.loadAPI(name, content, location, resolver, m.documentHash(content))
+	}
+
+	root, files, err := readTar(content)
+	if err != nil {
+		return err
+	}
+	// Archives are self-contained, the files outside of them are not read
+	return m.loadAPI(name, files[root], root, &archiveResolver{files: files, fallback: confineFiles("", resolver)}, hash)
+}
+
+// loadAPI loads an API specification located at base into the manager, resolving its external $refs with the resolver.
+// The hash identifies the loaded source to skip reloading unchanged specifications.
+// The specification is parsed, bundled, linted and compiled without holding the lock, as fetching remote references
+// may take long and lookups must not wait for it; the lock is only taken to swap the loaded spec.
+func (m *OASManager) loadAPI(name string, content []byte, base string, resolver RefResolver, hash uint64) error {
+	m.mu.RLock()
+	unchanged := m.unchanged(name, hash)
+	m.mu.RUnlock()
+	if unchanged {
+		return nil
+	}
+
+	// Normalize YAML and Swagger 2.0 documents to OpenAPI 3.x JSON
+	content, err := ParseDocument(content)
+	if err != nil {
+		return err
+	}
+
+	// Bundle the documents targeted by external $refs
+	content, referenced, err := bundleExternalRefs(content, base, resolver)
+	if err != nil {
+		return fmt.Errorf("failed to resolve external references: %v", err)
+	}
+	// The referenced documents may have changed with the same root document
+	if len(referenced) > 0 {
+		hash = sourceHash(hash, referenced)
+		m.mu.RLock()
+		unchanged := m.unchanged(name, hash)
+		m.mu.RUnlock()
+		if unchanged {
 			return nil
 		}
-		// Different content, remove old spec and continue
-		delete(m.apiSpecs, name)
 	}
+	references := slices.Sorted(maps.Keys(referenced))
+
+	// Check the specification
+	issues, err := Lint(content)
+	if err != nil {
+		return err
+	}
+
+	// Parse the structure, paths and components
+	spec, err := compileSpec(name, content)
+	if err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	var event *Event
+	defer func() {
+		m.mu.Unlock()
+		// Events are emitted without holding the lock
+		if event != nil {
+			m.emit(*event)
+		}
+	}()
+
+	// The same content may have been loaded concurrently
+	if m.unchanged(name, hash) {
+		return nil
+	}
+
+	// Strict mode refuses specifications with lint errors
+	previousVersion := m.versions[name]
+	if m.strictLint && hasLintErrors(issues) {
+		err := &LintErrors{API: name, Issues: issues}
+		event = &Event{Type: EventRejected, API: name, PreviousVersion: previousVersion, Err: err}
+		return err
+	}
+
+	// Refuse stale documents according to the version policy
+	if err := m.versionPolicy.Check(previousVersion, spec.version); err != nil {
+		err = fmt.Errorf("spec '%s' rejected: %v", name, err)
+		event = &Event{Type: EventRejected, API: name, Version: spec.version, PreviousVersion: previousVersion, Err: err}
+		return err
+	}
+
+	// Keep the normalized document to recompile the spec once spilled
+	if err := m.storeSource(name, content, hash, references, issues); err != nil {
+		return err
+	}
+
+	spec.hash = hash
+	spec.references = references
+	spec.lintIssues = issues
+	spec.LastAccess = m.clock.Now()
+
+	m.apiSpecs[name] = spec
+	m.spillColdSpecs(name)
+	m.versions[name] = spec.version
+	event = &Event{Type: EventLoaded, API: name, Version: spec.version, PreviousVersion: previousVersion}
+	return nil
+}
+
+// unchanged reports whether the source identified by the hash is loaded, or spilled, as the API.
+// The caller holds the lock.
+func (m *OASManager) unchanged(name string, hash uint64) bool {
+	if existing, exists := m.apiSpecs[name]; exists && existing.hash == hash {
+		return true
+	}
+	source, exists := m.sources[name]
+	return exists && source.hash == hash
+}
+
+// referencedFiles returns the local files targeted by the external $refs of the loaded API
+func (m *OASManager) referencedFiles(name string) []string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var references []string
+	if spec, exists := m.apiSpecs[name]; exists {
+		references = spec.references
+	} else if source, exists := m.sources[name]; exists {
+		references = source.references
+	}
+	var files []string
+	for _, location := range references {
+		if target, err := url.Parse(location); err == nil && len(target.Scheme) > 1 {
+			continue
+		}
+		files = append(files, strings.TrimPrefix(location, "file://"))
+	}
+	return files
+}
 
-	// Parse initial structure
+// compileSpec parses the structure, paths and components of a normalized specification
+func compileSpec(name string, content []byte) (*APISpec, error) {
 	var raw struct {
 		Info         json.RawMessage       `json:"info"`
 		OpenAPI      string                `json:"openapi"`
@@ -133,72 +414,198 @@ func (m *OASManager) LoadAPI(name string, content []byte) error {
 		Security     []SecurityRequirement `json:"security"`
 		Tags         []json.RawMessage     `json:"tags"`
 		ExternalDocs json.RawMessage       `json:"externalDocs"`
+		Webhooks     map[string]PathItem   `json:"webhooks"`
 	}
 
 	if err := json.Unmarshal(content, &raw); err != nil {
-		return fmt.Errorf("failed to parse OAS base structure: %v", err)
+		return nil, fmt.Errorf("failed to parse OAS base structure: %v", err)
+	}
+
+	var info struct {
+		Version string `json:"version"`
+	}
+	if len(raw.Info) > 0 {
+		if err := json.Unmarshal(raw.Info, &info); err != nil {
+			return nil, fmt.Errorf("failed to parse OAS info: %v", err)
+		}
 	}
 
 	// Parse paths with minimal memory footprint
 	paths, err := parsePathsFromRaw(content)
 	if err != nil {
-		return fmt.Errorf("failed to parse paths: %v", err)
+		return nil, fmt.Errorf("failed to parse paths: %v", err)
 	}
 
 	// Initialize component cache
 	components, err := parseComponentHeaders(content)
 	if err != nil {
-		return fmt.Errorf("failed to parse components: %v", err)
+		return nil, fmt.Errorf("failed to parse components: %v", err)
 	}
 
-	spec := &APISpec{
+	return &APISpec{
+		Name:         name,
 		info:         raw.Info,
+		version:      info.Version,
 		openapi:      raw.OpenAPI,
 		Paths:        paths,
+		Webhooks:     mapToPointers(raw.Webhooks),
 		Components:   components,
 		servers:      raw.Servers,
 		Security:     raw.Security,
 		tags:         raw.Tags,
 		externalDocs: raw.ExternalDocs,
-		hash:         hash,
-		LastAccess:   time.Now(),
-		HitCount:     0,
-	}
+	}, nil
+}
 
-	m.apiSpecs[name] = spec
-	return nil
+// Version returns the info.version declared by the specification.
+func (s *APISpec) Version() string {
+	return s.version
+}
+
+// Hash returns the hash of the documents of the specification, changing when it is reloaded with another content of
+// its root document or of a referenced document.
+func (s *APISpec) Hash() uint64 {
+	return s.hash
+}
+
+// LintIssues returns the issues found in the specification when it was loaded.
+func (s *APISpec) LintIssues() []LintIssue {
+	return s.lintIssues
+}
+
+// OpenAPIVersion returns the OpenAPI version declared by the specification.
+func (s *APISpec) OpenAPIVersion() string {
+	return s.openapi
+}
+
+// IsOpenAPI31 reports whether the specification is an OpenAPI 3.1 document.
+func (s *APISpec) IsOpenAPI31() bool {
+	return strings.HasPrefix(s.openapi, "3.1")
 }
 
 // LoadAPIFromFile loads an API specification from a file into the manager.
+// Relative external $refs are resolved against the directory of the file, and local files outside of it are refused.
+// Gzip-compressed documents and tar archives of multi-file specifications are supported.
 func (m *OASManager) LoadAPIFromFile(name, filePath string) error {
 	content, err := os.ReadFile(filePath)
 	if err != nil {
 		return fmt.Errorf("failed to read file: %v", err)
 	}
 
-	return m.LoadAPI(name, content)
+	return m.loadDocument(name, filePath, content, confineFiles(filePath, m.resolver))
+}
+
+// LoadAPIFromURL loads an API specification from an HTTP(S) URL into the manager.
+// Relative external $refs are resolved against the URL, external $refs to local files are refused.
+// Gzip-compressed documents and tar archives of multi-file specifications are supported.
+func (m *OASManager) LoadAPIFromURL(name, specURL string) error {
+	resp, err := m.client.Get(specURL)
+	if err != nil {
+		return fmt.Errorf("failed to fetch spec: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("failed to fetch spec: unexpected status %d", resp.StatusCode)
+	}
+
+	content, err := io.ReadAll(io.LimitReader(resp.Body, maxSpecSize+1))
+	if err != nil {
+		return fmt.Errorf("failed to read spec: %v", err)
+	}
+	if len(content) > maxSpecSize {
+		return fmt.Errorf("spec exceeds %d bytes", maxSpecSize)
+	}
+
+	return m.loadDocument(name, specURL, content, confineFiles("", m.resolver))
+}
+
+// LoadAPIFromFS loads an API specification from a file system into the manager, e.g. one embedded with go:embed.
+// Relative external $refs are resolved against the directory of the file within the file system, and absolute
+// URLs with the resolver of the manager. Gzip-compressed documents and tar archives of multi-file specifications
+// are supported.
+func (m *OASManager) LoadAPIFromFS(name string, fsys fs.FS, filePath string) error {
+	content, err := fs.ReadFile(fsys, filePath)
+	if err != nil {
+		return fmt.Errorf("failed to read file: %v", err)
+	}
+
+	return m.loadDocument(name, filePath, content, &fsResolver{fsys: fsys, fallback: confineFiles("", m.resolver)})
 }
 
-// GetApiSpec returns the API specification for the given name.
+// GetApiSpec returns the API specification for the given name, recompiling it if it was spilled.
 func (m *OASManager) GetApiSpec(name string) (*APISpec, error) {
 	m.mu.RLock()
-	defer m.mu.RUnlock()
-
 	spec, exists := m.apiSpecs[name]
 	if exists {
-		spec.HitCount++
-		spec.LastAccess = time.Now()
+		m.hits.Add(1)
+		now := m.clock.Now()
+		spec.Hits.Add(now)
+		atomic.AddInt64(&spec.HitCount, 1)
+		spec.accessMu.Lock()
+		spec.LastAccess = now
+		spec.accessMu.Unlock()
+		m.mu.RUnlock()
 		return spec, nil
 	}
+	_, spilled := m.sources[name]
+	m.mu.RUnlock()
+
+	if spilled {
+		spec, err := m.rehydrate(name)
+		if err == nil {
+			return spec, nil
+		}
+		m.misses.Add(1)
+		return nil, err
+	}
+	m.misses.Add(1)
 	return nil, fmt.Errorf("API spec '%s' not found", name)
 }
 
+// Stats returns the spec lookups finding a loaded spec or not, and the number of loaded specs
+func (m *OASManager) Stats() cache.CacheStats {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	return cache.CacheStats{
+		Hits:       m.hits.Load(),
+		Misses:     m.misses.Load(),
+		Size:       len(m.apiSpecs),
+		MaxSize:    m.config.MaxAPIs,
+		EvictCount: m.spills.Load(),
+	}
+}
+
+// CleanApiSpec removes the API specifications that have not been accessed within the configured expiry time.
+// They are spilled instead when cold specifications are, to be recompiled on demand.
+func (m *OASManager) CleanApiSpec() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.config.APIExpiryTime.Duration <= 0 {
+		return
+	}
+
+	now := m.clock.Now()
+	for name, spec := range m.apiSpecs {
+		if now.Sub(spec.LastAccess) > m.config.APIExpiryTime.Duration {
+			if m.overflowEnabled() {
+				m.spill(name)
+				continue
+			}
+			delete(m.apiSpecs, name)
+		}
+	}
+}
+
 // EvictApiSpec removes the API specification with the given name.
 func (m *OASManager) EvictApiSpec(name string) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
 	delete(m.apiSpecs, name)
+	m.removeSource(name)
 }
 
 // EvictAllApiSpecs removes all API specifications from the manager.
@@ -207,14 +614,32 @@ func (m *OASManager) EvictAllApiSpecs() {
 	defer m.mu.Unlock()
 
 	m.apiSpecs = make(map[string]*APISpec)
+	for name := range m.sources {
+		m.removeSource(name)
+	}
 }
 
-// GetApiSpecs returns all API specifications in the manager.
+// GetApiSpecs returns a snapshot of the API specifications in the manager, except the spilled ones.
+// Changing the returned map does not change the manager.
 func (m *OASManager) GetApiSpecs() map[string]*APISpec {
 	m.mu.RLock()
 	defer m.mu.RUnlock()
 
-	return m.apiSpecs
+	specs := make(map[string]*APISpec, len(m.apiSpecs))
+	for name, spec := range m.apiSpecs {
+		specs[name] = spec
+	}
+	return specs
+}
+
+// RangeSpecs calls fn for each API specification in the manager, except the spilled ones, until fn returns false.
+// fn runs on a snapshot, so it may call the methods of the manager.
+func (m *OASManager) RangeSpecs(fn func(name string, spec *APISpec) bool) {
+	for name, spec := range m.GetApiSpecs() {
+		if !fn(name, spec) {
+			return
+		}
+	}
 }
 
 // DefaultCacheConfig returns a default cache configuration.
@@ -236,9 +661,9 @@ func parsePathsFromRaw(content []byte) (map[string]*PathCache, error) {
 
 		// Initialize PathCache
 		pathCache := &PathCache{
-			Item:     &pathItem,
-			Route:    path,
-			HitCount: 0,
+			Item:  &pathItem,
+			Route: path,
+			raw:   rawPath,
 		}
 
 		// If the path contains parameters, compile the regex
@@ -288,6 +713,7 @@ func parseComponentHeaders(content []byte) (*ComponentCache, error) {
 		SecuritySchemes: mapToPointers(raw.Components.SecuritySchemes),
 		Links:           mapToPointers(raw.Components.Links),
 		Callbacks:       mapToPointers(raw.Components.Callbacks),
+		PathItems:       mapToPointers(raw.Components.PathItems),
 	}, nil
 }
 
@@ -0,0 +1,184 @@
+package oas
+
+import (
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/lionelgarnier/validate-api-request/pkg/clock"
+)
+
+const testSpec = `{
+	"openapi": "3.0.0",
+	"info": {"title": "Test API", "version": "1.0.0"},
+	"paths": {"/pets": {"get": {}}}
+}`
+
+func TestCleanApiSpec(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	mockClock := clock.NewMock(start)
+
+	config := DefaultCacheConfig()
+	config.APIExpiryTime = Duration{time.Hour}
+	manager := NewOASManager(config, FixedSelector(map[string]string{"test": "test"}), WithClock(mockClock))
+
+	assert.NoError(t, manager.LoadAPI("idle", []byte(testSpec)))
+	assert.NoError(t, manager.LoadAPI("active", []byte(testSpec)))
+
+	mockClock.Advance(45 * time.Minute)
+	spec, err := manager.GetApiSpec("active")
+	assert.NoError(t, err)
+	assert.Equal(t, start.Add(45*time.Minute), spec.LastAccess)
+
+	mockClock.Advance(30 * time.Minute)
+	manager.CleanApiSpec()
+
+	_, err = manager.GetApiSpec("idle")
+	assert.Error(t, err)
+	_, err = manager.GetApiSpec("active")
+	assert.NoError(t, err)
+}
+
+func TestVersionPolicy(t *testing.T) {
+	// Titles differ so that identical versions are not skipped as unchanged content
+	loads := 0
+	specWithVersion := func(version string) []byte {
+		loads++
+		return []byte(`{"openapi": "3.0.0", "info": {"title": "Test API ` + strconv.Itoa(loads) + `", "version": "` + version + `"}, "paths": {}}`)
+	}
+
+	tests := []struct {
+		name      string
+		policy    VersionPolicy
+		versions  []string
+		expected  string
+		rejected  int
+		wantError string
+	}{
+		{name: "Any version", policy: VersionAny, versions: []string{"2.0.0", "1.0.0"}, expected: "1.0.0"},
+		{name: "Upgrade", policy: VersionNoDowngrade, versions: []string{"1.0.0", "1.1.0-beta.1", "1.1.0"}, expected: "1.1.0"},
+		{name: "Same version without downgrade", policy: VersionNoDowngrade, versions: []string{"1.0.0", "v1.0.0"}, expected: "v1.0.0"},
+		{name: "Downgrade", policy: VersionNoDowngrade, versions: []string{"1.2.0", "1.10.0", "1.9.0"}, expected: "1.10.0", rejected: 1, wantError: "is lower than"},
+		{name: "Pre-release downgrade", policy: VersionNoDowngrade, versions: []string{"2.0.0", "2.0.0-rc.1"}, expected: "2.0.0", rejected: 1, wantError: "is lower than"},
+		{name: "Non incremented version", policy: VersionIncrement, versions: []string{"1.0.0", "1.0.0"}, expected: "1.0.0", rejected: 1, wantError: "is not greater than"},
+		{name: "Invalid version", policy: VersionIncrement, versions: []string{"1.0.0", "latest"}, expected: "1.0.0", rejected: 1, wantError: "is not a semantic version"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var events []Event
+			manager := NewOASManager(nil, FixedSelector(map[string]string{"test": "test"}),
+				WithVersionPolicy(tt.policy), WithEventHandler(func(event Event) { events = append(events, event) }))
+
+			var err error
+			for _, version := range tt.versions {
+				err = manager.LoadAPI("test", specWithVersion(version))
+			}
+			if tt.wantError != "" {
+				assert.ErrorContains(t, err, tt.wantError)
+			} else {
+				assert.NoError(t, err)
+			}
+
+			spec, err := manager.GetApiSpec("test")
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, spec.Version())
+			assert.Equal(t, tt.expected, manager.Versions()["test"])
+
+			rejected := 0
+			for _, event := range events {
+				if event.Type == EventRejected {
+					rejected++
+					assert.Equal(t, tt.expected, event.PreviousVersion)
+					assert.Error(t, event.Err)
+				}
+			}
+			assert.Equal(t, tt.rejected, rejected)
+			assert.Len(t, events, len(tt.versions))
+		})
+	}
+
+	// Known versions carry the gate over to a new manager
+	manager := NewOASManager(nil, FixedSelector(map[string]string{"test": "test"}),
+		WithVersionPolicy(VersionNoDowngrade), WithKnownVersions(map[string]string{"test": "3.0.0"}))
+	assert.Error(t, manager.LoadAPI("test", specWithVersion("2.0.0")))
+}
+
+func TestCanonicalHash(t *testing.T) {
+	reformatted := `{"paths": {"/pets": {"get": {}}},
+		"info": {"version": "1.0.0", "title": "Test API"}, "openapi": "3.0.0"}`
+	yamlSpec := "openapi: 3.0.0\ninfo:\n  title: Test API\n  version: 1.0.0\npaths:\n  /pets:\n    get: {}\n"
+	changed := `{"openapi": "3.0.0", "info": {"title": "Test API", "version": "1.0.1"}, "paths": {"/pets": {"get": {}}}}`
+
+	tests := []struct {
+		name      string
+		canonical bool
+		documents []string
+		loads     int
+	}{
+		{name: "Raw hash", documents: []string{testSpec, reformatted, yamlSpec}, loads: 3},
+		{name: "Canonical hash", canonical: true, documents: []string{testSpec, reformatted, yamlSpec}, loads: 1},
+		{name: "Canonical hash of changed content", canonical: true, documents: []string{testSpec, changed}, loads: 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			loads := 0
+			opts := []ManagerOption{WithEventHandler(func(event Event) {
+				if event.Type == EventLoaded {
+					loads++
+				}
+			})}
+			if tt.canonical {
+				opts = append(opts, WithCanonicalHash())
+			}
+			manager := NewOASManager(nil, FixedSelector(map[string]string{"test": "test"}), opts...)
+
+			for _, document := range tt.documents {
+				assert.NoError(t, manager.LoadAPI("test", []byte(document)))
+			}
+			assert.Equal(t, tt.loads, loads)
+		})
+	}
+}
+
+func TestGetApiSpecs(t *testing.T) {
+	manager := NewOASManager(DefaultCacheConfig(), FixedSelector(map[string]string{"test": "test"}))
+	assert.NoError(t, manager.LoadAPI("first", []byte(testSpec)))
+	assert.NoError(t, manager.LoadAPI("second", []byte(testSpec)))
+
+	specs := manager.GetApiSpecs()
+	assert.Len(t, specs, 2)
+	delete(specs, "first")
+	specs["third"] = specs["second"]
+	assert.Len(t, manager.GetApiSpecs(), 2)
+	_, err := manager.GetApiSpec("first")
+	assert.NoError(t, err)
+	_, err = manager.GetApiSpec("third")
+	assert.Error(t, err)
+}
+
+func TestRangeSpecs(t *testing.T) {
+	manager := NewOASManager(DefaultCacheConfig(), FixedSelector(map[string]string{"test": "test"}))
+	assert.NoError(t, manager.LoadAPI("first", []byte(testSpec)))
+	assert.NoError(t, manager.LoadAPI("second", []byte(testSpec)))
+
+	names := []string{}
+	manager.RangeSpecs(func(name string, spec *APISpec) bool {
+		assert.NotNil(t, spec)
+		names = append(names, name)
+		return true
+	})
+	assert.ElementsMatch(t, []string{"first", "second"}, names)
+
+	calls := 0
+	manager.RangeSpecs(func(name string, spec *APISpec) bool {
+		calls++
+		manager.EvictApiSpec(name) // Does not deadlock
+		return false
+	})
+	assert.Equal(t, 1, calls)
+	assert.Len(t, manager.GetApiSpecs(), 1)
+}
@@ -2,19 +2,22 @@ package oas
 
 // Base OAS structure
 type OpenAPI struct {
-	OpenAPI      string                `json:"openapi" yaml:"openapi"`
-	Info         Info                  `json:"info" yaml:"info"`
-	Servers      []Server              `json:"servers,omitempty" yaml:"servers,omitempty"`
-	Paths        map[string]PathItem   `json:"paths" yaml:"paths"`
-	Components   *Components           `json:"components,omitempty" yaml:"components,omitempty"`
-	Security     []SecurityRequirement `json:"security,omitempty" yaml:"security,omitempty"`
-	Tags         []Tag                 `json:"tags,omitempty" yaml:"tags,omitempty"`
-	ExternalDocs *ExternalDocs         `json:"externalDocs,omitempty" yaml:"externalDocs,omitempty"`
+	OpenAPI           string                `json:"openapi" yaml:"openapi"`
+	Info              Info                  `json:"info" yaml:"info"`
+	JSONSchemaDialect string                `json:"jsonSchemaDialect,omitempty" yaml:"jsonSchemaDialect,omitempty"`
+	Servers           []Server              `json:"servers,omitempty" yaml:"servers,omitempty"`
+	Paths             map[string]PathItem   `json:"paths" yaml:"paths"`
+	Webhooks          map[string]PathItem   `json:"webhooks,omitempty" yaml:"webhooks,omitempty"`
+	Components        *Components           `json:"components,omitempty" yaml:"components,omitempty"`
+	Security          []SecurityRequirement `json:"security,omitempty" yaml:"security,omitempty"`
+	Tags              []Tag                 `json:"tags,omitempty" yaml:"tags,omitempty"`
+	ExternalDocs      *ExternalDocs         `json:"externalDocs,omitempty" yaml:"externalDocs,omitempty"`
 }
 
 // Components is a container for reusable schemas and responses.
 type Info struct {
 	Title          string   `json:"title" yaml:"title"`
+	Summary        string   `json:"summary,omitempty" yaml:"summary,omitempty"`
 	Description    string   `json:"description,omitempty" yaml:"description,omitempty"`
 	TermsOfService string   `json:"termsOfService,omitempty" yaml:"termsOfService,omitempty"`
 	Contact        *Contact `json:"contact,omitempty" yaml:"contact,omitempty"`
@@ -33,6 +36,7 @@ type Components struct {
 	SecuritySchemes map[string]SecurityScheme `json:"securitySchemes,omitempty" yaml:"securitySchemes,omitempty"`
 	Links           map[string]Link           `json:"links,omitempty" yaml:"links,omitempty"`
 	Callbacks       map[string]Callback       `json:"callbacks,omitempty" yaml:"callbacks,omitempty"`
+	PathItems       map[string]PathItem       `json:"pathItems,omitempty" yaml:"pathItems,omitempty"`
 }
 
 // PathItem is a list of operations that can be performed on a path.
@@ -71,42 +75,53 @@ type Operation struct {
 
 // Schema is a JSON Schema object.
 type Schema struct {
-	Ref                  string                 `json:"$ref,omitempty" yaml:"$ref,omitempty"`
-	Type                 string                 `json:"type,omitempty" yaml:"type,omitempty"`
-	Format               string                 `json:"format,omitempty" yaml:"format,omitempty"`
-	Properties           map[string]Schema      `json:"properties,omitempty" yaml:"properties,omitempty"`
-	Items                *Schema                `json:"items,omitempty" yaml:"items,omitempty"`
-	Required             []string               `json:"required,omitempty" yaml:"required,omitempty"`
-	Enum                 []interface{}          `json:"enum,omitempty" yaml:"enum,omitempty"`
-	AllOf                []Schema               `json:"allOf,omitempty" yaml:"allOf,omitempty"`
-	OneOf                []Schema               `json:"oneOf,omitempty" yaml:"oneOf,omitempty"`
-	AnyOf                []Schema               `json:"anyOf,omitempty" yaml:"anyOf,omitempty"`
-	Not                  *Schema                `json:"not,omitempty" yaml:"not,omitempty"`
-	AdditionalProperties interface{}            `json:"additionalProperties,omitempty" yaml:"additionalProperties,omitempty"`
-	Maximum              *float64               `json:"maximum,omitempty" yaml:"maximum,omitempty"`
-	Minimum              *float64               `json:"minimum,omitempty" yaml:"minimum,omitempty"`
-	MultipleOf           *float64               `json:"multipleOf,omitempty" yaml:"multipleOf,omitempty"`
-	MinLength            *uint64                `json:"minLength,omitempty" yaml:"minLength,omitempty"`
-	MaxLength            *uint64                `json:"maxLength,omitempty" yaml:"maxLength,omitempty"`
-	Pattern              string                 `json:"pattern,omitempty" yaml:"pattern,omitempty"`
-	MinItems             *uint64                `json:"minItems,omitempty" yaml:"minItems,omitempty"`
-	MaxItems             *uint64                `json:"maxItems,omitempty" yaml:"maxItems,omitempty"`
-	UniqueItems          bool                   `json:"uniqueItems,omitempty" yaml:"uniqueItems,omitempty"`
-	Example              interface{}            `json:"example,omitempty" yaml:"example,omitempty"`
-	Deprecated           bool                   `json:"deprecated,omitempty" yaml:"deprecated,omitempty"`
-	Nullable             bool                   `json:"nullable,omitempty" yaml:"nullable,omitempty"`
-	Discriminator        *Discriminator         `json:"discriminator,omitempty" yaml:"discriminator,omitempty"`
-	XML                  *XML                   `json:"xml,omitempty" yaml:"xml,omitempty"`
-	Title                string                 `json:"title,omitempty" yaml:"title,omitempty"`
-	Description          string                 `json:"description,omitempty" yaml:"description,omitempty"`
-	Default              interface{}            `json:"default,omitempty" yaml:"default,omitempty"`
-	ExclusiveMaximum     bool                   `json:"exclusiveMaximum,omitempty" yaml:"exclusiveMaximum,omitempty"`
-	ExclusiveMinimum     bool                   `json:"exclusiveMinimum,omitempty" yaml:"exclusiveMinimum,omitempty"`
-	MinProperties        uint64                 `json:"minProperties,omitempty" yaml:"minProperties,omitempty"`
-	MaxProperties        uint64                 `json:"maxProperties,omitempty" yaml:"maxProperties,omitempty"`
-	ReadOnly             bool                   `json:"readOnly,omitempty" yaml:"readOnly,omitempty"`
-	WriteOnly            bool                   `json:"writeOnly,omitempty" yaml:"writeOnly,omitempty"`
-	Extensions           map[string]interface{} `json:"-" yaml:"-"`
+	Ref                   string                 `json:"$ref,omitempty" yaml:"$ref,omitempty"`
+	Type                  string                 `json:"type,omitempty" yaml:"type,omitempty"`
+	Types                 []string               `json:"-" yaml:"-"`
+	Format                string                 `json:"format,omitempty" yaml:"format,omitempty"`
+	Properties            map[string]Schema      `json:"properties,omitempty" yaml:"properties,omitempty"`
+	PatternProperties     map[string]Schema      `json:"patternProperties,omitempty" yaml:"patternProperties,omitempty"`
+	PrefixItems           []Schema               `json:"prefixItems,omitempty" yaml:"prefixItems,omitempty"`
+	Items                 *Schema                `json:"items,omitempty" yaml:"items,omitempty"` // Items after PrefixItems
+	Required              []string               `json:"required,omitempty" yaml:"required,omitempty"`
+	DependentRequired     map[string][]string    `json:"dependentRequired,omitempty" yaml:"dependentRequired,omitempty"`
+	DependentSchemas      map[string]Schema      `json:"dependentSchemas,omitempty" yaml:"dependentSchemas,omitempty"`
+	Enum                  []interface{}          `json:"enum,omitempty" yaml:"enum,omitempty"`
+	AllOf                 []Schema               `json:"allOf,omitempty" yaml:"allOf,omitempty"`
+	OneOf                 []Schema               `json:"oneOf,omitempty" yaml:"oneOf,omitempty"`
+	AnyOf                 []Schema               `json:"anyOf,omitempty" yaml:"anyOf,omitempty"`
+	Not                   *Schema                `json:"not,omitempty" yaml:"not,omitempty"`
+	AdditionalProperties  interface{}            `json:"additionalProperties,omitempty" yaml:"additionalProperties,omitempty"`   // bool or *Schema once decoded
+	UnevaluatedProperties interface{}            `json:"unevaluatedProperties,omitempty" yaml:"unevaluatedProperties,omitempty"` // bool or *Schema once decoded
+	UnevaluatedItems      interface{}            `json:"unevaluatedItems,omitempty" yaml:"unevaluatedItems,omitempty"`           // bool or *Schema once decoded
+	Maximum               *float64               `json:"maximum,omitempty" yaml:"maximum,omitempty"`
+	Minimum               *float64               `json:"minimum,omitempty" yaml:"minimum,omitempty"`
+	MultipleOf            *float64               `json:"multipleOf,omitempty" yaml:"multipleOf,omitempty"`
+	MinLength             *uint64                `json:"minLength,omitempty" yaml:"minLength,omitempty"`
+	MaxLength             *uint64                `json:"maxLength,omitempty" yaml:"maxLength,omitempty"`
+	Pattern               string                 `json:"pattern,omitempty" yaml:"pattern,omitempty"`
+	MinItems              *uint64                `json:"minItems,omitempty" yaml:"minItems,omitempty"`
+	MaxItems              *uint64                `json:"maxItems,omitempty" yaml:"maxItems,omitempty"`
+	UniqueItems           bool                   `json:"uniqueItems,omitempty" yaml:"uniqueItems,omitempty"`
+	Example               interface{}            `json:"example,omitempty" yaml:"example,omitempty"`
+	Examples              []interface{}          `json:"examples,omitempty" yaml:"examples,omitempty"`
+	Const                 interface{}            `json:"const,omitempty" yaml:"const,omitempty"`
+	Deprecated            bool                   `json:"deprecated,omitempty" yaml:"deprecated,omitempty"`
+	Nullable              bool                   `json:"nullable,omitempty" yaml:"nullable,omitempty"`
+	Discriminator         *Discriminator         `json:"discriminator,omitempty" yaml:"discriminator,omitempty"`
+	XML                   *XML                   `json:"xml,omitempty" yaml:"xml,omitempty"`
+	Title                 string                 `json:"title,omitempty" yaml:"title,omitempty"`
+	Description           string                 `json:"description,omitempty" yaml:"description,omitempty"`
+	Default               interface{}            `json:"default,omitempty" yaml:"default,omitempty"`
+	ExclusiveMaximum      bool                   `json:"exclusiveMaximum,omitempty" yaml:"exclusiveMaximum,omitempty"`
+	ExclusiveMinimum      bool                   `json:"exclusiveMinimum,omitempty" yaml:"exclusiveMinimum,omitempty"`
+	MinProperties         uint64                 `json:"minProperties,omitempty" yaml:"minProperties,omitempty"`
+	MaxProperties         *uint64                `json:"maxProperties,omitempty" yaml:"maxProperties,omitempty"`
+	ReadOnly              bool                   `json:"readOnly,omitempty" yaml:"readOnly,omitempty"`
+	WriteOnly             bool                   `json:"writeOnly,omitempty" yaml:"writeOnly,omitempty"`
+	Extensions            map[string]interface{} `json:"-" yaml:"-"`
+
+	constNull bool // Whether const is null, which a nil Const cannot tell from a missing const
 }
 
 // Server is a URL to the target host.
@@ -125,23 +140,28 @@ type ServerVariable struct {
 
 // Parameter is a list of parameters that can be used across operations.
 type Parameter struct {
-	Name            string               `json:"name" yaml:"name"`
-	In              string               `json:"in" yaml:"in"`
-	Description     string               `json:"description,omitempty" yaml:"description,omitempty"`
-	Required        bool                 `json:"required,omitempty" yaml:"required,omitempty"`
-	Deprecated      bool                 `json:"deprecated,omitempty" yaml:"deprecated,omitempty"`
-	AllowEmptyValue bool                 `json:"allowEmptyValue,omitempty" yaml:"allowEmptyValue,omitempty"`
-	Style           string               `json:"style,omitempty" yaml:"style,omitempty"`
-	Explode         bool                 `json:"explode,omitempty" yaml:"explode,omitempty"`
-	AllowReserved   bool                 `json:"allowReserved,omitempty" yaml:"allowReserved,omitempty"`
-	Schema          *Schema              `json:"schema,omitempty" yaml:"schema,omitempty"`
-	Example         interface{}          `json:"example,omitempty" yaml:"example,omitempty"`
-	Examples        map[string]Example   `json:"examples,omitempty" yaml:"examples,omitempty"`
-	Content         map[string]MediaType `json:"content,omitempty" yaml:"content,omitempty"`
+	Ref             string                 `json:"$ref,omitempty" yaml:"$ref,omitempty"`
+	Name            string                 `json:"name" yaml:"name"`
+	In              string                 `json:"in" yaml:"in"`
+	Description     string                 `json:"description,omitempty" yaml:"description,omitempty"`
+	Required        bool                   `json:"required,omitempty" yaml:"required,omitempty"`
+	Deprecated      bool                   `json:"deprecated,omitempty" yaml:"deprecated,omitempty"`
+	AllowEmptyValue bool                   `json:"allowEmptyValue,omitempty" yaml:"allowEmptyValue,omitempty"`
+	Style           string                 `json:"style,omitempty" yaml:"style,omitempty"`
+	Explode         bool                   `json:"explode,omitempty" yaml:"explode,omitempty"`
+	AllowReserved   bool                   `json:"allowReserved,omitempty" yaml:"allowReserved,omitempty"`
+	Schema          *Schema                `json:"schema,omitempty" yaml:"schema,omitempty"`
+	Example         interface{}            `json:"example,omitempty" yaml:"example,omitempty"`
+	Examples        map[string]Example     `json:"examples,omitempty" yaml:"examples,omitempty"`
+	Content         map[string]MediaType   `json:"content,omitempty" yaml:"content,omitempty"`
+	Extensions      map[string]interface{} `json:"-" yaml:"-"`
+
+	explodeSet bool // Whether explode is declared, which a false Explode cannot tell from the default of the style
 }
 
 // RequestBody is a request body object that can be passed to an operation.
 type RequestBody struct {
+	Ref         string               `json:"$ref,omitempty" yaml:"$ref,omitempty"`
 	Description string               `json:"description,omitempty" yaml:"description,omitempty"`
 	Content     map[string]MediaType `json:"content" yaml:"content"`
 	Required    bool                 `json:"required,omitempty" yaml:"required,omitempty"`
@@ -153,6 +173,8 @@ type MediaType struct {
 	Example  interface{}         `json:"example,omitempty" yaml:"example,omitempty"`
 	Examples map[string]Example  `json:"examples,omitempty" yaml:"examples,omitempty"`
 	Encoding map[string]Encoding `json:"encoding,omitempty" yaml:"encoding,omitempty"`
+
+	Extensions map[string]interface{} `json:"-" yaml:"-"`
 }
 
 // Encoding is a single encoding definition applied to a single schema property.
@@ -166,6 +188,7 @@ type Encoding struct {
 
 // Response is a list of possible responses as they are returned from executing this operation.
 type Response struct {
+	Ref         string               `json:"$ref,omitempty" yaml:"$ref,omitempty"`
 	Description string               `json:"description" yaml:"description"`
 	Headers     map[string]Header    `json:"headers,omitempty" yaml:"headers,omitempty"`
 	Content     map[string]MediaType `json:"content,omitempty" yaml:"content,omitempty"`
@@ -195,6 +218,7 @@ type Link struct {
 
 // Header is a list of headers that can be used across operations.
 type Header struct {
+	Ref             string               `json:"$ref,omitempty" yaml:"$ref,omitempty"`
 	Description     string               `json:"description,omitempty" yaml:"description,omitempty"`
 	Required        bool                 `json:"required,omitempty" yaml:"required,omitempty"`
 	Deprecated      bool                 `json:"deprecated,omitempty" yaml:"deprecated,omitempty"`
@@ -230,8 +254,9 @@ type Contact struct {
 
 // License information for the exposed API.
 type License struct {
-	Name string `json:"name" yaml:"name"`
-	URL  string `json:"url,omitempty" yaml:"url,omitempty"`
+	Name       string `json:"name" yaml:"name"`
+	Identifier string `json:"identifier,omitempty" yaml:"identifier,omitempty"`
+	URL        string `json:"url,omitempty" yaml:"url,omitempty"`
 }
 
 type SecurityRequirement map[string][]string
@@ -253,6 +278,7 @@ type Discriminator struct {
 
 // OAuth2 configuration
 type SecurityScheme struct {
+	Ref              string      `json:"$ref,omitempty" yaml:"$ref,omitempty"`
 	Type             string      `json:"type,omitempty" yaml:"type,omitempty"`
 	Description      string      `json:"description,omitempty" yaml:"description,omitempty"`
 	Name             string      `json:"name,omitempty" yaml:"name,omitempty"`
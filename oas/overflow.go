@@ -0,0 +1,149 @@
+package oas
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"time"
+
+	"github.com/zeebo/xxh3"
+)
+
+// specSource is the normalized document of a specification, kept to recompile it once spilled
+type specSource struct {
+	content    []byte // Normalized document, nil when written to the overflow directory
+	path       string // File of the normalized document in the overflow directory
+	hash       uint64
+	references []string // Locations of the documents targeted by external $refs
+	lintIssues []LintIssue
+}
+
+// overflowEnabled reports whether cold specifications are spilled
+func (m *OASManager) overflowEnabled() bool {
+	return m.config.MaxResidentAPIs > 0
+}
+
+// storeSource keeps the normalized document of a specification when cold specifications are spilled,
+// in the overflow directory if configured, in memory otherwise. The caller holds the write lock.
+func (m *OASManager) storeSource(name string, content []byte, hash uint64, references []string, issues []LintIssue) error {
+	if !m.overflowEnabled() {
+		return nil
+	}
+
+	source := &specSource{hash: hash, references: references, lintIssues: issues}
+	if dir := m.config.OverflowDir; dir != "" {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return fmt.Errorf("failed to create overflow directory: %v", err)
+		}
+		// Files are named after the manager too, as several managers, possibly of several processes, may share the
+		// overflow directory
+		source.path = filepath.Join(dir, fmt.Sprintf("%s-%016x.json", m.overflowID, xxh3.HashString(name)))
+		if err := os.WriteFile(source.path, content, 0o644); err != nil {
+			return fmt.Errorf("failed to write overflow file: %v", err)
+		}
+	} else {
+		source.content = content
+	}
+
+	m.sources[name] = source
+	return nil
+}
+
+// spillColdSpecs drops the least recently used compiled specifications beyond the resident limit, except the
+// one just used, keeping their source to recompile them on demand. The caller holds the write lock.
+func (m *OASManager) spillColdSpecs(keep string) {
+	if !m.overflowEnabled() {
+		return
+	}
+
+	for len(m.apiSpecs) > m.config.MaxResidentAPIs {
+		coldest := ""
+		for name, spec := range m.apiSpecs {
+			if name != keep && (coldest == "" || spec.LastAccess.Before(m.apiSpecs[coldest].LastAccess)) {
+				coldest = name
+			}
+		}
+		if coldest == "" {
+			return
+		}
+		m.spill(coldest)
+	}
+}
+
+// spill drops the compiled specification of an API whose source is kept. The caller holds the write lock.
+func (m *OASManager) spill(name string) {
+	if _, exists := m.sources[name]; !exists {
+		return
+	}
+	delete(m.apiSpecs, name)
+	m.spills.Add(1)
+}
+
+// rehydrate recompiles a spilled specification from its source and records its lookup
+func (m *OASManager) rehydrate(name string) (*APISpec, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	// The specification may have been rehydrated, reloaded or evicted while waiting for the lock
+	if spec, exists := m.apiSpecs[name]; exists {
+		m.recordLookup(spec)
+		return spec, nil
+	}
+	source, exists := m.sources[name]
+	if !exists {
+		return nil, fmt.Errorf("API spec '%s' not found", name)
+	}
+
+	content := source.content
+	if content == nil {
+		var err error
+		content, err = os.ReadFile(source.path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read overflow file of API spec '%s': %v", name, err)
+		}
+	}
+
+	spec, err := compileSpec(name, content)
+	if err != nil {
+		return nil, fmt.Errorf("failed to recompile API spec '%s': %v", name, err)
+	}
+	spec.hash = source.hash
+	spec.references = source.references
+	spec.lintIssues = source.lintIssues
+	m.recordLookup(spec)
+
+	m.apiSpecs[name] = spec
+	m.spillColdSpecs(name)
+	return spec, nil
+}
+
+// recordLookup records a lookup finding a specification. The caller holds the write lock.
+func (m *OASManager) recordLookup(spec *APISpec) {
+	now := m.clock.Now()
+	m.hits.Add(1)
+	spec.Hits.Add(now)
+	atomic.AddInt64(&spec.HitCount, 1)
+	spec.LastAccess = now
+}
+
+// newOverflowID returns a random identifier naming the overflow files of a manager
+func newOverflowID() string {
+	var id [8]byte
+	if _, err := rand.Read(id[:]); err != nil {
+		return fmt.Sprintf("%016x", time.Now().UnixNano())
+	}
+	return hex.EncodeToString(id[:])
+}
+
+// removeSource forgets the source of a specification, deleting its overflow file. The caller holds the write lock.
+func (m *OASManager) removeSource(name string) {
+	if source, exists := m.sources[name]; exists {
+		if source.path != "" {
+			os.Remove(source.path)
+		}
+		delete(m.sources, name)
+	}
+}
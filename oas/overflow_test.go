@@ -0,0 +1,114 @@
+package oas
+
+import (
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/lionelgarnier/validate-api-request/pkg/clock"
+)
+
+func TestSpillColdSpecs(t *testing.T) {
+	tests := []struct {
+		name        string
+		overflowDir bool
+	}{
+		{name: "Raw bytes in memory"},
+		{name: "Overflow directory", overflowDir: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockClock := clock.NewMock(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+			config := DefaultCacheConfig()
+			config.MaxResidentAPIs = 2
+			if tt.overflowDir {
+				config.OverflowDir = t.TempDir()
+			}
+			manager := NewOASManager(config, FixedSelector(nil), WithClock(mockClock))
+
+			for _, name := range []string{"first", "second", "third"} {
+				assert.NoError(t, manager.LoadAPI(name, []byte(testSpec)))
+				mockClock.Advance(time.Minute)
+			}
+
+			// The least recently used spec is spilled
+			assert.Len(t, manager.GetApiSpecs(), 2)
+			assert.NotContains(t, manager.GetApiSpecs(), "first")
+			if tt.overflowDir {
+				files, err := os.ReadDir(config.OverflowDir)
+				assert.NoError(t, err)
+				assert.Len(t, files, 3)
+			}
+
+			// It is recompiled on demand, spilling the next coldest one
+			spec, err := manager.GetApiSpec("first")
+			assert.NoError(t, err)
+			assert.Equal(t, "1.0.0", spec.Version())
+			assert.Contains(t, spec.Paths, "/pets")
+			assert.NotContains(t, manager.GetApiSpecs(), "second")
+			assert.Equal(t, int64(2), manager.Stats().EvictCount)
+
+			// Expired specs are spilled rather than removed
+			config.APIExpiryTime = Duration{time.Minute}
+			mockClock.Advance(time.Hour)
+			manager.CleanApiSpec()
+			assert.Empty(t, manager.GetApiSpecs())
+			_, err = manager.GetApiSpec("third")
+			assert.NoError(t, err)
+
+			// Evicted specs are gone, with their overflow file
+			manager.EvictAllApiSpecs()
+			_, err = manager.GetApiSpec("third")
+			assert.Error(t, err)
+			if tt.overflowDir {
+				files, err := os.ReadDir(config.OverflowDir)
+				assert.NoError(t, err)
+				assert.Empty(t, files)
+			}
+		})
+	}
+}
+
+func TestSharedOverflowDir(t *testing.T) {
+	dir := t.TempDir()
+	config := DefaultCacheConfig()
+	config.MaxResidentAPIs = 1
+	config.OverflowDir = dir
+
+	// Managers sharing the overflow directory load different versions of the same API
+	first := NewOASManager(config, FixedSelector(nil))
+	second := NewOASManager(config, FixedSelector(nil))
+	for _, manager := range []*OASManager{first, second} {
+		version := "1.0.0"
+		if manager == second {
+			version = "2.0.0"
+		}
+		assert.NoError(t, manager.LoadAPI("pets", []byte(strings.Replace(testSpec, "1.0.0", version, 1))))
+		assert.NoError(t, manager.LoadAPI("other", []byte(testSpec)))
+	}
+	files, err := os.ReadDir(dir)
+	assert.NoError(t, err)
+	assert.Len(t, files, 4)
+
+	// Each recompiles its own version, counting concurrent lookups
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			spec, err := first.GetApiSpec("pets")
+			assert.NoError(t, err)
+			assert.Equal(t, "1.0.0", spec.Version())
+		}()
+	}
+	wg.Wait()
+	spec, err := second.GetApiSpec("pets")
+	assert.NoError(t, err)
+	assert.Equal(t, "2.0.0", spec.Version())
+	assert.Equal(t, int64(8), first.Stats().Hits)
+}
@@ -0,0 +1,22 @@
+package oas
+
+// SerializationStyle returns the style of the parameter, or its default for its location: `form` for query and
+// cookie parameters, `simple` for path and header parameters.
+func (p *Parameter) SerializationStyle() string {
+	if p.Style != "" {
+		return p.Style
+	}
+	if p.In == "query" || p.In == "cookie" {
+		return "form"
+	}
+	return "simple"
+}
+
+// Exploded reports whether the items of an array or object parameter are serialized as separate parameters,
+// which is the default for the `form` style only.
+func (p *Parameter) Exploded() bool {
+	if p.Explode || p.explodeSet {
+		return p.Explode
+	}
+	return p.SerializationStyle() == "form"
+}
@@ -0,0 +1,33 @@
+package oas
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParameterExploded(t *testing.T) {
+	tests := []struct {
+		name     string
+		document string
+		exploded bool
+	}{
+		{name: "Default of form style", document: `{"name": "tags", "in": "query"}`, exploded: true},
+		{name: "Default of simple style", document: `{"name": "tags", "in": "path"}`, exploded: false},
+		{name: "Form style not exploded", document: `{"name": "tags", "in": "query", "explode": false}`, exploded: false},
+		{name: "Simple style exploded", document: `{"name": "tags", "in": "header", "explode": true}`, exploded: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var param Parameter
+			assert.NoError(t, json.Unmarshal([]byte(tt.document), &param))
+			assert.Equal(t, tt.exploded, param.Exploded())
+		})
+	}
+
+	// Parameters built in code are exploded by their Explode field or the default of their style
+	assert.True(t, (&Parameter{In: "path", Explode: true}).Exploded())
+	assert.True(t, (&Parameter{In: "query"}).Exploded())
+}
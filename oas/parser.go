@@ -0,0 +1,76 @@
+package oas
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ParseDocument normalizes a specification into an OpenAPI 3.x JSON document.
+// YAML documents are converted to JSON and Swagger 2.0 documents are converted to OpenAPI 3.0.
+func ParseDocument(content []byte) ([]byte, error) {
+	trimmed := bytes.TrimSpace(content)
+	if len(trimmed) > 0 && trimmed[0] != '{' {
+		converted, err := yamlToJSON(trimmed)
+		if err != nil {
+			return nil, err
+		}
+		content = converted
+	}
+
+	var version struct {
+		Swagger string `json:"swagger"`
+	}
+	if err := json.Unmarshal(content, &version); err != nil {
+		return nil, fmt.Errorf("failed to parse OAS base structure: %v", err)
+	}
+
+	if version.Swagger != "" {
+		if version.Swagger != "2.0" {
+			return nil, fmt.Errorf("unsupported swagger version '%s'", version.Swagger)
+		}
+		return ConvertSwagger2(content)
+	}
+
+	return content, nil
+}
+
+// yamlToJSON converts a YAML document to JSON
+func yamlToJSON(content []byte) ([]byte, error) {
+	var document interface{}
+	if err := yaml.Unmarshal(content, &document); err != nil {
+		return nil, fmt.Errorf("failed to parse YAML document: %v", err)
+	}
+
+	converted, err := json.Marshal(normalizeYAML(document))
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert YAML document: %v", err)
+	}
+	return converted, nil
+}
+
+// normalizeYAML converts YAML mappings with non-string keys (e.g. response codes) to JSON compatible maps
+func normalizeYAML(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, item := range v {
+			v[key] = normalizeYAML(item)
+		}
+		return v
+	case map[interface{}]interface{}:
+		converted := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			converted[fmt.Sprint(key)] = normalizeYAML(item)
+		}
+		return converted
+	case []interface{}:
+		for i, item := range v {
+			v[i] = normalizeYAML(item)
+		}
+		return v
+	default:
+		return v
+	}
+}
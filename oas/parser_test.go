@@ -0,0 +1,138 @@
+package oas
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const swagger2Spec = `
+swagger: "2.0"
+info:
+  title: Legacy API
+  version: "1.0"
+host: api.example.com
+basePath: /v1
+schemes: [https]
+consumes: [application/json]
+produces: [application/json]
+securityDefinitions:
+  basicAuth:
+    type: basic
+  oauth:
+    type: oauth2
+    flow: accessCode
+    authorizationUrl: https://auth.example.com/authorize
+    tokenUrl: https://auth.example.com/token
+    scopes:
+      read: Read access
+parameters:
+  petId:
+    name: petId
+    in: path
+    required: true
+    type: integer
+    format: int64
+  petBody:
+    name: body
+    in: body
+    required: true
+    schema:
+      $ref: "#/definitions/Pet"
+paths:
+  /pets:
+    get:
+      parameters:
+        - name: tags
+          in: query
+          type: array
+          items:
+            type: string
+          collectionFormat: multi
+      responses:
+        200:
+          description: OK
+          schema:
+            type: array
+            items:
+              $ref: "#/definitions/Pet"
+    post:
+      parameters:
+        - $ref: "#/parameters/petBody"
+      responses:
+        201:
+          description: Created
+  /pets/{petId}:
+    parameters:
+      - $ref: "#/parameters/petId"
+    get:
+      responses:
+        200:
+          description: OK
+  /pets/{petId}/photo:
+    post:
+      consumes: [multipart/form-data]
+      parameters:
+        - $ref: "#/parameters/petId"
+        - name: file
+          in: formData
+          type: file
+          required: true
+      responses:
+        200:
+          description: OK
+definitions:
+  Pet:
+    type: object
+    required: [name]
+    properties:
+      name:
+        type: string
+      tag:
+        type: string
+        x-nullable: true
+`
+
+func TestLoadSwagger2(t *testing.T) {
+	manager := NewOASManager(nil, FixedSelector(map[string]string{"test": "test"}))
+	assert.NoError(t, manager.LoadAPI("test", []byte(swagger2Spec)))
+
+	spec, err := manager.GetApiSpec("test")
+	assert.NoError(t, err)
+	assert.Equal(t, "3.0.3", spec.OpenAPIVersion())
+
+	list := spec.Paths["/pets"].Item.Get
+	assert.Equal(t, "form", list.Parameters[0].Style)
+	assert.True(t, list.Parameters[0].Exploded())
+	assert.Equal(t, "array", list.Parameters[0].Schema.Type)
+	assert.Equal(t, "#/components/schemas/Pet", list.Responses["200"].Content["application/json"].Schema.Items.Ref)
+
+	create := spec.Paths["/pets"].Item.Post
+	assert.True(t, create.RequestBody.Required)
+	assert.Equal(t, "#/components/schemas/Pet", create.RequestBody.Content["application/json"].Schema.Ref)
+
+	pathParams := spec.Paths["/pets/{petId}"].Item.Parameters
+	assert.Equal(t, "petId", pathParams[0].Name)
+	assert.Equal(t, "integer", pathParams[0].Schema.Type)
+
+	upload := spec.Paths["/pets/{petId}/photo"].Item.Post
+	uploadSchema := upload.RequestBody.Content["multipart/form-data"].Schema
+	assert.Equal(t, "binary", uploadSchema.Properties["file"].Format)
+	assert.Equal(t, []string{"file"}, uploadSchema.Required)
+
+	assert.True(t, spec.Components.Schemas["Pet"].Properties["tag"].Nullable)
+	assert.Equal(t, "http", spec.Components.SecuritySchemes["basicAuth"].Type)
+	assert.Equal(t, "basic", spec.Components.SecuritySchemes["basicAuth"].Scheme)
+	assert.Equal(t, "https://auth.example.com/token", spec.Components.SecuritySchemes["oauth"].Flows.AuthorizationCode.TokenURL)
+}
+
+func TestLoadYAMLSpec(t *testing.T) {
+	manager := NewOASManager(nil, FixedSelector(map[string]string{"test": "test"}))
+	err := manager.LoadAPIFromFile("test", filepath.Join("..", "oas_files", "petstore3.swagger.io_api_v3.yaml"))
+	assert.NoError(t, err)
+
+	spec, err := manager.GetApiSpec("test")
+	assert.NoError(t, err)
+	assert.NotNil(t, spec.Paths["/pet/{petId}"].Item.Get)
+}
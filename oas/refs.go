@@ -0,0 +1,65 @@
+package oas
+
+import (
+	"fmt"
+	"strings"
+)
+
+// maxRefHops bounds the chains of components referencing other components
+const maxRefHops = 32
+
+// ResolveParameter returns the parameter, following its $ref to the parameter components
+func (s *APISpec) ResolveParameter(param *Parameter) (*Parameter, error) {
+	return resolveComponent(s.components().Parameters, "parameters", param, func(p *Parameter) string { return p.Ref })
+}
+
+// ResolveRequestBody returns the request body, following its $ref to the request body components
+func (s *APISpec) ResolveRequestBody(body *RequestBody) (*RequestBody, error) {
+	return resolveComponent(s.components().RequestBodies, "requestBodies", body, func(b *RequestBody) string { return b.Ref })
+}
+
+// ResolveResponse returns the response, following its $ref to the response components
+func (s *APISpec) ResolveResponse(resp *Response) (*Response, error) {
+	return resolveComponent(s.components().Responses, "responses", resp, func(r *Response) string { return r.Ref })
+}
+
+// ResolveHeader returns the header, following its $ref to the header components
+func (s *APISpec) ResolveHeader(header *Header) (*Header, error) {
+	return resolveComponent(s.components().Headers, "headers", header, func(h *Header) string { return h.Ref })
+}
+
+// ResolveSecurityScheme returns the security scheme, following its $ref to the security scheme components
+func (s *APISpec) ResolveSecurityScheme(scheme *SecurityScheme) (*SecurityScheme, error) {
+	return resolveComponent(s.components().SecuritySchemes, "securitySchemes", scheme, func(sc *SecurityScheme) string { return sc.Ref })
+}
+
+// components returns the component cache, empty if the specification has no components
+func (s *APISpec) components() *ComponentCache {
+	if s.Components == nil {
+		return &ComponentCache{}
+	}
+	return s.Components
+}
+
+// resolveComponent follows the $refs of an object to the components of the given type
+func resolveComponent[T any](components map[string]*T, componentType string, object *T, ref func(*T) string) (*T, error) {
+	prefix := "#/components/" + componentType + "/"
+	for hops := 0; ref(object) != ""; hops++ {
+		if hops == maxRefHops {
+			return nil, fmt.Errorf("circular reference '%s'", ref(object))
+		}
+
+		name, found := strings.CutPrefix(ref(object), prefix)
+		if !found {
+			return nil, fmt.Errorf("reference '%s' does not target %s", ref(object), componentType)
+		}
+		name = strings.ReplaceAll(strings.ReplaceAll(name, "~1", "/"), "~0", "~")
+
+		component, exists := components[name]
+		if !exists {
+			return nil, fmt.Errorf("%s reference '%s' not found", componentType, ref(object))
+		}
+		object = component
+	}
+	return object, nil
+}
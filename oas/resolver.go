@@ -0,0 +1,237 @@
+package oas
+
+import (
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/lionelgarnier/validate-api-request/cache"
+)
+
+// RefResolver loads the documents targeted by external $refs
+type RefResolver interface {
+	// Load returns the content of the document at the given location
+	Load(location string) ([]byte, error)
+}
+
+// FileRefResolver loads referenced documents from the local file system
+type FileRefResolver struct{}
+
+// Load reads the referenced file
+func (FileRefResolver) Load(location string) ([]byte, error) {
+	content, err := os.ReadFile(strings.TrimPrefix(location, "file://"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read referenced file: %v", err)
+	}
+	return content, nil
+}
+
+// confinedResolver restricts the files read by another resolver to the directory of the root document, so that a
+// specification cannot reference arbitrary local files. Files cannot be referenced by a specification that is not
+// loaded from a file, i.e. an inline or remote one. Other locations, e.g. HTTP(S) URLs, are loaded by the resolver.
+type confinedResolver struct {
+	root     string // Directory of the root document, empty when it is not a local file
+	resolver RefResolver
+}
+
+// Load reads the referenced file when it is within the root directory
+func (r *confinedResolver) Load(location string) ([]byte, error) {
+	if target, err := url.Parse(location); err == nil && len(target.Scheme) > 1 && target.Scheme != "file" {
+		return r.resolver.Load(location)
+	}
+	if r.root == "" {
+		return nil, fmt.Errorf("referenced file '%s' cannot be read by a specification not loaded from a file", location)
+	}
+
+	name, err := filepath.Abs(strings.TrimPrefix(location, "file://"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve referenced file: %v", err)
+	}
+	relative, err := filepath.Rel(r.root, name)
+	if err != nil || relative == ".." || strings.HasPrefix(relative, ".."+string(filepath.Separator)) {
+		return nil, fmt.Errorf("referenced file '%s' is outside of the specification directory", location)
+	}
+	return r.resolver.Load(name)
+}
+
+// confineFiles returns a resolver reading the files within the directory of the root document at filePath, or no
+// files when filePath is empty
+func confineFiles(filePath string, resolver RefResolver) RefResolver {
+	if filePath == "" {
+		return &confinedResolver{resolver: resolver}
+	}
+	root, err := filepath.Abs(filepath.Dir(filePath))
+	if err != nil {
+		return &confinedResolver{resolver: resolver}
+	}
+	return &confinedResolver{root: root, resolver: resolver}
+}
+
+// fsResolver resolves references to the files of a file system, e.g. one embedded with go:embed, then falls back to
+// another resolver for absolute URLs
+type fsResolver struct {
+	fsys     fs.FS
+	fallback RefResolver
+}
+
+// Load reads the referenced file from the file system
+func (r *fsResolver) Load(location string) ([]byte, error) {
+	if target, err := url.Parse(location); err == nil && len(target.Scheme) > 1 {
+		return r.fallback.Load(location)
+	}
+
+	name := path.Clean(filepath.ToSlash(location))
+	if !fs.ValidPath(name) {
+		return nil, fmt.Errorf("referenced file '%s' is outside of the file system", location)
+	}
+	content, err := fs.ReadFile(r.fsys, name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read referenced file: %v", err)
+	}
+	return content, nil
+}
+
+// WithRefResolver sets the resolver loading documents targeted by external $refs
+func WithRefResolver(resolver RefResolver) ManagerOption {
+	return func(m *OASManager) {
+		m.resolver = resolver
+	}
+}
+
+// resolveLocation resolves the location of a referenced document against the location of the referencing document
+func resolveLocation(base, location string) string {
+	if target, err := url.Parse(location); err == nil && target.Scheme != "" && len(target.Scheme) > 1 {
+		return location
+	}
+
+	if baseURL, err := url.Parse(base); err == nil && (baseURL.Scheme == "http" || baseURL.Scheme == "https") {
+		target, err := url.Parse(location)
+		if err != nil {
+			return location
+		}
+		return baseURL.ResolveReference(target).String()
+	}
+
+	if filepath.IsAbs(location) {
+		return location
+	}
+	return filepath.Join(filepath.Dir(strings.TrimPrefix(base, "file://")), location)
+}
+
+// maxRemoteDocumentSize bounds the size of a document loaded by HTTPRefResolver
+const maxRemoteDocumentSize = 10 << 20
+
+// HTTPRefResolver loads referenced documents from allowed HTTP(S) hosts and caches them.
+// Locations that are not HTTP(S) URLs are read from the file system.
+type HTTPRefResolver struct {
+	client       *http.Client
+	allowedHosts []string
+	cache        *cache.BaseCache[[]byte]
+	files        FileRefResolver
+}
+
+// HTTPResolverOption configures optional HTTPRefResolver behavior
+type HTTPResolverOption func(*HTTPRefResolver)
+
+// WithHTTPClient sets the client used to fetch remote documents
+func WithHTTPClient(client *http.Client) HTTPResolverOption {
+	return func(r *HTTPRefResolver) {
+		r.client = client
+	}
+}
+
+// NewHTTPRefResolver creates a resolver fetching documents from the allowed hosts and caching them for ttl.
+// Hosts may start with a `*.` wildcard (e.g. `*.example.com`); no host is allowed when the list is empty.
+// Redirects are only followed to allowed hosts, including with a client set with WithHTTPClient.
+func NewHTTPRefResolver(allowedHosts []string, ttl time.Duration, opts ...HTTPResolverOption) *HTTPRefResolver {
+	resolver := &HTTPRefResolver{
+		client:       &http.Client{Timeout: 10 * time.Second},
+		allowedHosts: allowedHosts,
+		cache:        cache.NewBaseCache[[]byte](100, ttl),
+	}
+
+	for _, opt := range opts {
+		opt(resolver)
+	}
+
+	// The client is copied, so that the client of the option is not changed
+	client := *resolver.client
+	checkRedirect := client.CheckRedirect
+	client.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+		if !resolver.isAllowed(req.URL.Hostname()) {
+			return fmt.Errorf("redirect to host '%s' is not allowed", req.URL.Hostname())
+		}
+		if checkRedirect != nil {
+			return checkRedirect(req, via)
+		}
+		if len(via) >= 10 {
+			return fmt.Errorf("stopped after 10 redirects")
+		}
+		return nil
+	}
+	resolver.client = &client
+
+	return resolver
+}
+
+// Load fetches the referenced document, or returns it from the cache
+func (r *HTTPRefResolver) Load(location string) ([]byte, error) {
+	target, err := url.Parse(location)
+	if err != nil || (target.Scheme != "http" && target.Scheme != "https") {
+		return r.files.Load(location)
+	}
+
+	if !r.isAllowed(target.Hostname()) {
+		return nil, fmt.Errorf("host '%s' is not allowed", target.Hostname())
+	}
+
+	// The fragment is not sent, documents are cached by URL
+	target.Fragment = ""
+	key := target.String()
+	if content, exists := r.cache.Get(key); exists {
+		return content, nil
+	}
+
+	resp, err := r.client.Get(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch referenced document: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to fetch referenced document: unexpected status %d", resp.StatusCode)
+	}
+
+	content, err := io.ReadAll(io.LimitReader(resp.Body, maxRemoteDocumentSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read referenced document: %v", err)
+	}
+	if len(content) > maxRemoteDocumentSize {
+		return nil, fmt.Errorf("referenced document exceeds %d bytes", maxRemoteDocumentSize)
+	}
+
+	r.cache.Set(key, content)
+	return content, nil
+}
+
+// isAllowed reports whether documents can be fetched from the host
+func (r *HTTPRefResolver) isAllowed(host string) bool {
+	host = strings.ToLower(host)
+	for _, allowed := range r.allowedHosts {
+		allowed = strings.ToLower(allowed)
+		if allowed == host {
+			return true
+		}
+		if strings.HasPrefix(allowed, "*.") && strings.HasSuffix(host, allowed[1:]) {
+			return true
+		}
+	}
+	return false
+}
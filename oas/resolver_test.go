@@ -0,0 +1,135 @@
+package oas
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHTTPRefResolver(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		switch r.URL.Path {
+		case "/models/pet.json":
+			w.Write([]byte(`{"Pet": {"type": "object", "properties": {"owner": {"$ref": "owner.json"}}}}`))
+		case "/models/owner.json":
+			w.Write([]byte(`{"type": "object", "required": ["name"]}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	spec := []byte(`{
+        "openapi": "3.0.3",
+        "info": {"title": "Pets", "version": "1.0"},
+        "paths": {},
+        "components": {"schemas": {"Pets": {"type": "array", "items": {"$ref": "` + server.URL + `/models/pet.json#/Pet"}}}}
+    }`)
+
+	resolver := NewHTTPRefResolver([]string{"127.0.0.1"}, time.Minute)
+	manager := NewOASManager(nil, FixedSelector(map[string]string{"pets": "pets"}), WithRefResolver(resolver))
+
+	assert.NoError(t, manager.LoadAPI("pets", spec))
+	loaded, _ := manager.GetApiSpec("pets")
+	assert.Equal(t, "#/components/schemas/Pet", loaded.Components.Schemas["Pets"].Items.Ref)
+	assert.Equal(t, "#/components/schemas/owner", loaded.Components.Schemas["Pet"].Properties["owner"].Ref)
+	assert.Equal(t, []string{"name"}, loaded.Components.Schemas["owner"].Required)
+	assert.Equal(t, 2, requests)
+
+	// Documents are served from the cache
+	assert.NoError(t, manager.LoadAPI("pets-copy", spec))
+	assert.Equal(t, 2, requests)
+
+	_, err := resolver.Load(server.URL + "/missing.json")
+	assert.ErrorContains(t, err, "unexpected status 404")
+
+	denied := NewOASManager(nil, FixedSelector(map[string]string{"pets": "pets"}), WithRefResolver(NewHTTPRefResolver([]string{"*.example.com"}, time.Minute)))
+	assert.ErrorContains(t, denied.LoadAPI("pets", spec), "host '127.0.0.1' is not allowed")
+}
+
+func TestHTTPRefResolverRedirects(t *testing.T) {
+	internal := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"type": "object"}`))
+	}))
+	defer internal.Close()
+	internalURL, err := url.Parse(internal.URL)
+	assert.NoError(t, err)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/allowed.json":
+			http.Redirect(w, r, internal.URL+"/pet.json", http.StatusFound)
+		case "/internal.json":
+			// localhost is not on the allowlist, unlike 127.0.0.1
+			http.Redirect(w, r, "http://localhost:"+internalURL.Port()+"/pet.json", http.StatusFound)
+		}
+	}))
+	defer server.Close()
+
+	for name, resolver := range map[string]*HTTPRefResolver{
+		"default client": NewHTTPRefResolver([]string{"127.0.0.1"}, time.Minute),
+		"custom client":  NewHTTPRefResolver([]string{"127.0.0.1"}, time.Minute, WithHTTPClient(&http.Client{})),
+	} {
+		t.Run(name, func(t *testing.T) {
+			content, err := resolver.Load(server.URL + "/allowed.json")
+			assert.NoError(t, err)
+			assert.JSONEq(t, `{"type": "object"}`, string(content))
+
+			_, err = resolver.Load(server.URL + "/internal.json")
+			assert.ErrorContains(t, err, "redirect to host 'localhost' is not allowed")
+		})
+	}
+}
+
+// blockingResolver loads documents once released, signaling the loads it starts
+type blockingResolver struct {
+	started chan struct{}
+	release chan struct{}
+}
+
+func (r *blockingResolver) Load(location string) ([]byte, error) {
+	r.started <- struct{}{}
+	<-r.release
+	return []byte(`{"type": "object"}`), nil
+}
+
+func TestSlowRefResolver(t *testing.T) {
+	resolver := &blockingResolver{started: make(chan struct{}), release: make(chan struct{})}
+	manager := NewOASManager(nil, FixedSelector(map[string]string{"pets": "pets"}), WithRefResolver(resolver))
+	assert.NoError(t, manager.LoadAPI("owners", []byte(`{"openapi": "3.0.3", "info": {"title": "Owners", "version": "1.0"}, "paths": {}}`)))
+
+	loaded := make(chan error)
+	go func() {
+		loaded <- manager.LoadAPI("pets", []byte(`{
+            "openapi": "3.0.3",
+            "info": {"title": "Pets", "version": "1.0"},
+            "paths": {},
+            "components": {"schemas": {"Pet": {"$ref": "https://models.example.com/pet.json"}}}
+        }`))
+	}()
+	<-resolver.started
+
+	// Lookups do not wait for the references of the specs being loaded
+	looked := make(chan error)
+	go func() {
+		_, err := manager.GetApiSpec("owners")
+		looked <- err
+	}()
+	select {
+	case err := <-looked:
+		assert.NoError(t, err)
+	case <-time.After(time.Second):
+		t.Fatal("lookup blocked by the load of another spec")
+	}
+
+	close(resolver.release)
+	assert.NoError(t, <-loaded)
+	_, err := manager.GetApiSpec("pets")
+	assert.NoError(t, err)
+}
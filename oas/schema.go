@@ -0,0 +1,278 @@
+package oas
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+
+	"gopkg.in/yaml.v3"
+)
+
+// UnmarshalJSON decodes a schema, normalizing OpenAPI 3.1 (JSON Schema 2020-12) keywords into the 3.0 model:
+// `type` arrays fill Types (and Nullable when "null" is listed) and numeric
+// exclusiveMinimum/exclusiveMaximum become the corresponding bound with the exclusive flag set, unless the
+// minimum/maximum declared along is stricter.
+func (s *Schema) UnmarshalJSON(data []byte) error {
+	type schemaAlias Schema
+	var aux struct {
+		schemaAlias
+		Type             json.RawMessage `json:"type,omitempty"`
+		ExclusiveMaximum json.RawMessage `json:"exclusiveMaximum,omitempty"`
+		ExclusiveMinimum json.RawMessage `json:"exclusiveMinimum,omitempty"`
+		Const            json.RawMessage `json:"const,omitempty"`
+
+		Items                 json.RawMessage            `json:"items,omitempty"`
+		AdditionalItems       json.RawMessage            `json:"additionalItems,omitempty"`
+		AdditionalProperties  json.RawMessage            `json:"additionalProperties,omitempty"`
+		UnevaluatedProperties json.RawMessage            `json:"unevaluatedProperties,omitempty"`
+		UnevaluatedItems      json.RawMessage            `json:"unevaluatedItems,omitempty"`
+		Dependencies          map[string]json.RawMessage `json:"dependencies,omitempty"`
+	}
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+	schema := Schema(aux.schemaAlias)
+
+	var err error
+	if err := schema.decodeType(aux.Type); err != nil {
+		return err
+	}
+	if err := decodeExclusiveBound(aux.ExclusiveMaximum, &schema.Maximum, &schema.ExclusiveMaximum, -1); err != nil {
+		return fmt.Errorf("invalid exclusiveMaximum: %v", err)
+	}
+	if err := decodeExclusiveBound(aux.ExclusiveMinimum, &schema.Minimum, &schema.ExclusiveMinimum, 1); err != nil {
+		return fmt.Errorf("invalid exclusiveMinimum: %v", err)
+	}
+	if err := schema.decodeConst(aux.Const); err != nil {
+		return fmt.Errorf("invalid const: %v", err)
+	}
+	if err := schema.decodeItems(aux.Items, aux.AdditionalItems); err != nil {
+		return err
+	}
+	if schema.AdditionalProperties, err = decodeSchemaOrBool(aux.AdditionalProperties); err != nil {
+		return fmt.Errorf("invalid additionalProperties: %v", err)
+	}
+	if schema.UnevaluatedProperties, err = decodeSchemaOrBool(aux.UnevaluatedProperties); err != nil {
+		return fmt.Errorf("invalid unevaluatedProperties: %v", err)
+	}
+	if schema.UnevaluatedItems, err = decodeSchemaOrBool(aux.UnevaluatedItems); err != nil {
+		return fmt.Errorf("invalid unevaluatedItems: %v", err)
+	}
+	if err := schema.decodeDependencies(aux.Dependencies); err != nil {
+		return fmt.Errorf("invalid dependencies: %v", err)
+	}
+
+	extensions, err := parseExtensions(data)
+	if err != nil {
+		return err
+	}
+	schema.Extensions = extensions
+
+	*s = schema
+	return nil
+}
+
+// decodeType decodes `type` given either as a single type or as a 3.1 list of types
+func (s *Schema) decodeType(raw json.RawMessage) error {
+	raw = bytes.TrimSpace(raw)
+	if len(raw) == 0 {
+		return nil
+	}
+
+	if raw[0] != '[' {
+		if err := json.Unmarshal(raw, &s.Type); err != nil {
+			return fmt.Errorf("invalid type: %v", err)
+		}
+		if s.Type == "null" {
+			s.Nullable = true
+		}
+		return nil
+	}
+
+	var types []string
+	if err := json.Unmarshal(raw, &types); err != nil {
+		return fmt.Errorf("invalid type: %v", err)
+	}
+
+	var nonNull []string
+	for _, t := range types {
+		if t == "null" {
+			s.Nullable = true
+			continue
+		}
+		nonNull = append(nonNull, t)
+	}
+
+	s.Types = types
+	switch len(nonNull) {
+	case 0:
+		s.Type = "null"
+	case 1:
+		s.Type = nonNull[0]
+	}
+	return nil
+}
+
+// decodeItems decodes `items` with the JSON Schema 2020-12 semantics of OpenAPI 3.1: the schema of the items
+// following the prefixItems tuple, or of every item without prefixItems. The legacy tuple form, an `items` list
+// followed by `additionalItems`, fills the same fields. A `false` schema of the following items bounds the array to
+// its tuple, as maxItems.
+func (s *Schema) decodeItems(items, additionalItems json.RawMessage) error {
+	items = bytes.TrimSpace(items)
+	if len(items) > 0 && items[0] == '[' {
+		if err := json.Unmarshal(items, &s.PrefixItems); err != nil {
+			return fmt.Errorf("invalid items: %v", err)
+		}
+		items = bytes.TrimSpace(additionalItems)
+	}
+	if len(items) == 0 || bytes.Equal(items, []byte("null")) {
+		return nil
+	}
+
+	if items[0] == 't' || items[0] == 'f' {
+		var allowed bool
+		if err := json.Unmarshal(items, &allowed); err != nil {
+			return fmt.Errorf("invalid items: %v", err)
+		}
+		if prefixItems := uint64(len(s.PrefixItems)); !allowed && (s.MaxItems == nil || *s.MaxItems > prefixItems) {
+			s.MaxItems = &prefixItems
+		}
+		return nil
+	}
+
+	var schema Schema
+	if err := json.Unmarshal(items, &schema); err != nil {
+		return fmt.Errorf("invalid items: %v", err)
+	}
+	s.Items = &schema
+	return nil
+}
+
+// decodeSchemaOrBool decodes a keyword given either as a boolean or as a schema, e.g. `additionalProperties`, into
+// a bool or a *Schema, nil when absent
+func decodeSchemaOrBool(raw json.RawMessage) (interface{}, error) {
+	raw = bytes.TrimSpace(raw)
+	if len(raw) == 0 || bytes.Equal(raw, []byte("null")) {
+		return nil, nil
+	}
+
+	if raw[0] == 't' || raw[0] == 'f' {
+		var allowed bool
+		if err := json.Unmarshal(raw, &allowed); err != nil {
+			return nil, err
+		}
+		return allowed, nil
+	}
+
+	var schema Schema
+	if err := json.Unmarshal(raw, &schema); err != nil {
+		return nil, err
+	}
+	return &schema, nil
+}
+
+// decodeDependencies decodes the legacy `dependencies` keyword (JSON Schema draft 7 and earlier) into the
+// dependentRequired and dependentSchemas keywords that split it: a list of names is the properties required when the
+// property is present, an object is the schema the object must also match. Keywords declared by the schema itself
+// take precedence.
+func (s *Schema) decodeDependencies(dependencies map[string]json.RawMessage) error {
+	for name, raw := range dependencies {
+		raw = bytes.TrimSpace(raw)
+		if len(raw) > 0 && raw[0] == '[' {
+			var required []string
+			if err := json.Unmarshal(raw, &required); err != nil {
+				return fmt.Errorf("%s: %v", name, err)
+			}
+			if _, exists := s.DependentRequired[name]; !exists {
+				if s.DependentRequired == nil {
+					s.DependentRequired = make(map[string][]string)
+				}
+				s.DependentRequired[name] = required
+			}
+			continue
+		}
+
+		var schema Schema
+		if err := json.Unmarshal(raw, &schema); err != nil {
+			return fmt.Errorf("%s: %v", name, err)
+		}
+		if _, exists := s.DependentSchemas[name]; !exists {
+			if s.DependentSchemas == nil {
+				s.DependentSchemas = make(map[string]Schema)
+			}
+			s.DependentSchemas[name] = schema
+		}
+	}
+	return nil
+}
+
+// UnmarshalYAML decodes a YAML schema like its JSON form, so additionalProperties and 3.1 keywords are normalized
+// the same way
+func (s *Schema) UnmarshalYAML(node *yaml.Node) error {
+	var document interface{}
+	if err := node.Decode(&document); err != nil {
+		return err
+	}
+	content, err := json.Marshal(normalizeYAML(document))
+	if err != nil {
+		return err
+	}
+	return s.UnmarshalJSON(content)
+}
+
+// decodeExclusiveBound decodes a 3.0 boolean exclusive flag or a 3.1 numeric exclusive bound.
+// A 3.1 schema may declare both an inclusive and an exclusive bound, the stricter one is kept: the greater one for
+// minimums (direction 1) and the lower one for maximums (direction -1), the exclusive one when they are equal.
+func decodeExclusiveBound(raw json.RawMessage, bound **float64, exclusive *bool, direction float64) error {
+	raw = bytes.TrimSpace(raw)
+	if len(raw) == 0 {
+		return nil
+	}
+
+	if raw[0] == 't' || raw[0] == 'f' {
+		return json.Unmarshal(raw, exclusive)
+	}
+
+	var value float64
+	if err := json.Unmarshal(raw, &value); err != nil {
+		return err
+	}
+	if *bound != nil && (**bound-value)*direction > 0 {
+		return nil
+	}
+	*bound = &value
+	*exclusive = true
+	return nil
+}
+
+// decodeConst decodes `const`, recording a null const that Const cannot tell from a missing one
+func (s *Schema) decodeConst(raw json.RawMessage) error {
+	raw = bytes.TrimSpace(raw)
+	if len(raw) == 0 {
+		return nil
+	}
+	if bytes.Equal(raw, []byte("null")) {
+		s.Const = nil
+		s.constNull = true
+		return nil
+	}
+	return json.Unmarshal(raw, &s.Const)
+}
+
+// HasConst reports whether the schema declares a const value, which may be null
+func (s *Schema) HasConst() bool {
+	return s.Const != nil || s.constNull
+}
+
+// HasType reports whether the schema declares the given type
+func (s *Schema) HasType(t string) bool {
+	if len(s.Types) > 0 {
+		for _, declared := range s.Types {
+			if declared == t {
+				return true
+			}
+		}
+		return false
+	}
+	return s.Type == t
+}
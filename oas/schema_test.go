@@ -0,0 +1,132 @@
+package oas
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/yaml.v3"
+)
+
+func TestSchemaUnmarshalJSON(t *testing.T) {
+	var schema Schema
+	err := json.Unmarshal([]byte(`{
+		"type": "object",
+		"x-internal": true,
+		"properties": {
+			"name": {"type": ["string", "null"], "examples": ["Fluffy"]},
+			"age": {"type": "integer", "exclusiveMinimum": 0, "maximum": 30, "exclusiveMaximum": true},
+			"id": {"type": ["integer", "string"]},
+			"kind": {"const": "pet"},
+			"weight": {"type": "number", "minimum": 5, "exclusiveMinimum": 3, "maximum": 40, "exclusiveMaximum": 40},
+			"owner": {"const": null}
+		}
+	}`), &schema)
+	assert.NoError(t, err)
+
+	assert.Equal(t, "object", schema.Type)
+	assert.Equal(t, true, schema.Extensions["x-internal"])
+
+	name := schema.Properties["name"]
+	assert.Equal(t, "string", name.Type)
+	assert.True(t, name.Nullable)
+	assert.Equal(t, []interface{}{"Fluffy"}, name.Examples)
+
+	age := schema.Properties["age"]
+	assert.Equal(t, 0.0, *age.Minimum)
+	assert.True(t, age.ExclusiveMinimum)
+	assert.Equal(t, 30.0, *age.Maximum)
+	assert.True(t, age.ExclusiveMaximum)
+
+	id := schema.Properties["id"]
+	assert.Equal(t, "", id.Type)
+	assert.True(t, id.HasType("integer"))
+	assert.True(t, id.HasType("string"))
+	assert.False(t, id.Nullable)
+
+	// The stricter bound is kept when a 3.1 schema declares both
+	weight := schema.Properties["weight"]
+	assert.Equal(t, 5.0, *weight.Minimum)
+	assert.False(t, weight.ExclusiveMinimum)
+	assert.Equal(t, 40.0, *weight.Maximum)
+	assert.True(t, weight.ExclusiveMaximum)
+
+	kind := schema.Properties["kind"]
+	assert.Equal(t, "pet", kind.Const)
+	assert.True(t, kind.HasConst())
+	owner := schema.Properties["owner"]
+	assert.Nil(t, owner.Const)
+	assert.True(t, owner.HasConst())
+	assert.False(t, id.HasConst())
+}
+
+func TestSchemaAdditionalProperties(t *testing.T) {
+	tests := []struct {
+		name     string
+		json     string
+		yaml     string
+		expected interface{}
+	}{
+		{name: "Absent", json: `{"type": "object"}`, yaml: "type: object"},
+		{name: "Allowed", json: `{"additionalProperties": true}`, yaml: "additionalProperties: true", expected: true},
+		{name: "Forbidden", json: `{"additionalProperties": false}`, yaml: "additionalProperties: false", expected: false},
+		{
+			name:     "Inline schema",
+			json:     `{"additionalProperties": {"type": ["integer", "null"], "exclusiveMinimum": 0}}`,
+			yaml:     "additionalProperties:\n  type: [integer, \"null\"]\n  exclusiveMinimum: 0",
+			expected: &Schema{Type: "integer", Types: []string{"integer", "null"}, Nullable: true, Minimum: new(float64), ExclusiveMinimum: true},
+		},
+		{
+			name:     "Nested schemas",
+			json:     `{"additionalProperties": {"additionalProperties": false}}`,
+			yaml:     "additionalProperties:\n  additionalProperties: false",
+			expected: &Schema{AdditionalProperties: false},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var fromJSON, fromYAML Schema
+			assert.NoError(t, json.Unmarshal([]byte(tt.json), &fromJSON))
+			assert.NoError(t, yaml.Unmarshal([]byte(tt.yaml), &fromYAML))
+			assert.Equal(t, tt.expected, fromJSON.AdditionalProperties)
+			assert.Equal(t, tt.expected, fromYAML.AdditionalProperties)
+		})
+	}
+
+	var schema Schema
+	assert.Error(t, json.Unmarshal([]byte(`{"additionalProperties": "yes"}`), &schema))
+}
+
+func TestSchemaDependencies(t *testing.T) {
+	var schema Schema
+	assert.NoError(t, json.Unmarshal([]byte(`{
+		"dependentRequired": {"a": ["b"]},
+		"dependencies": {"a": ["c"], "d": ["e", "f"], "g": {"required": ["h"]}}
+	}`), &schema))
+
+	// The keywords declared by the schema take precedence over the legacy form
+	assert.Equal(t, map[string][]string{"a": {"b"}, "d": {"e", "f"}}, schema.DependentRequired)
+	assert.Equal(t, []string{"h"}, schema.DependentSchemas["g"].Required)
+
+	assert.Error(t, json.Unmarshal([]byte(`{"dependencies": {"a": "b"}}`), &schema))
+}
+
+func TestLoadOpenAPI31(t *testing.T) {
+	manager := NewOASManager(nil, FixedSelector(map[string]string{"test": "test"}))
+
+	err := manager.LoadAPI("test", []byte(`{
+		"openapi": "3.1.0",
+		"info": {"title": "Test API", "summary": "3.1 API", "version": "1.0.0", "license": {"name": "MIT", "identifier": "MIT"}},
+		"paths": {},
+		"webhooks": {
+			"newPet": {"post": {"requestBody": {"content": {"application/json": {"schema": {"type": "object"}}}}}}
+		}
+	}`))
+	assert.NoError(t, err)
+
+	spec, err := manager.GetApiSpec("test")
+	assert.NoError(t, err)
+	assert.True(t, spec.IsOpenAPI31())
+	assert.NotNil(t, spec.Webhooks["newPet"].Post)
+}
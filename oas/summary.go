@@ -0,0 +1,192 @@
+package oas
+
+import (
+	"net/http"
+	"reflect"
+	"strings"
+)
+
+// Summary reports statistics of a spec, e.g. to plan capacity or review uploaded specs
+type Summary struct {
+	Paths           int            `json:"paths"`
+	Webhooks        int            `json:"webhooks"`
+	Operations      map[string]int `json:"operations"`      // Operations by method
+	SecuritySchemes map[string]int `json:"securitySchemes"` // Operations requiring each security scheme
+	MediaTypes      map[string]int `json:"mediaTypes"`      // Request bodies and responses using each media type
+	SchemaKeywords  map[string]int `json:"schemaKeywords"`  // Schemas using each keyword
+	Formats         map[string]int `json:"formats"`         // Schemas using each format
+}
+
+// Summary returns the statistics of the spec. Operations include webhooks, and schema keywords are counted over
+// the schemas defined by the spec, inline or in components, references counting as `$ref`.
+func (s *APISpec) Summary() Summary {
+	summary := Summary{
+		Paths:           len(s.Paths),
+		Webhooks:        len(s.Webhooks),
+		Operations:      make(map[string]int),
+		SecuritySchemes: make(map[string]int),
+		MediaTypes:      make(map[string]int),
+		SchemaKeywords:  make(map[string]int),
+		Formats:         make(map[string]int),
+	}
+
+	components := s.components()
+	for name := range components.SecuritySchemes {
+		summary.SecuritySchemes[name] = 0
+	}
+	for _, schema := range components.Schemas {
+		summary.countSchema(schema)
+	}
+	for _, param := range components.Parameters {
+		summary.countSchema(param.Schema)
+	}
+	for _, header := range components.Headers {
+		summary.countSchema(header.Schema)
+	}
+	for _, body := range components.RequestBodies {
+		summary.countContentSchemas(body.Content)
+	}
+	for _, resp := range components.Responses {
+		summary.countResponseSchemas(resp)
+	}
+
+	for _, path := range s.Paths {
+		if item, err := path.PathItem(); err == nil {
+			s.summarizePathItem(&summary, item)
+		}
+	}
+	for _, item := range s.Webhooks {
+		s.summarizePathItem(&summary, item)
+	}
+	return summary
+}
+
+// summarizePathItem adds the operations of a path item to the summary
+func (s *APISpec) summarizePathItem(summary *Summary, item *PathItem) {
+	if item == nil {
+		return
+	}
+	for _, param := range item.Parameters {
+		summary.countSchema(param.Schema)
+	}
+	operations := map[string]*Operation{
+		http.MethodGet:     item.Get,
+		http.MethodPut:     item.Put,
+		http.MethodPost:    item.Post,
+		http.MethodDelete:  item.Delete,
+		http.MethodOptions: item.Options,
+		http.MethodHead:    item.Head,
+		http.MethodPatch:   item.Patch,
+		http.MethodTrace:   item.Trace,
+	}
+	for method, operation := range operations {
+		if operation != nil {
+			summary.Operations[method]++
+			s.summarizeOperation(summary, operation)
+		}
+	}
+}
+
+// summarizeOperation adds the security schemes, media types and schemas of an operation to the summary
+func (s *APISpec) summarizeOperation(summary *Summary, operation *Operation) {
+	security := operation.Security
+	if security == nil {
+		security = s.Security
+	}
+	schemes := make(map[string]bool)
+	for _, requirement := range security {
+		for name := range requirement {
+			schemes[name] = true
+		}
+	}
+	for name := range schemes {
+		summary.SecuritySchemes[name]++
+	}
+
+	for _, param := range operation.Parameters {
+		summary.countSchema(param.Schema)
+	}
+	if operation.RequestBody != nil {
+		summary.countContentSchemas(operation.RequestBody.Content)
+		if body, err := s.ResolveRequestBody(operation.RequestBody); err == nil {
+			summary.countMediaTypes(body.Content)
+		}
+	}
+	for _, response := range operation.Responses {
+		summary.countResponseSchemas(&response)
+		if resp, err := s.ResolveResponse(&response); err == nil {
+			summary.countMediaTypes(resp.Content)
+		}
+	}
+}
+
+// countMediaTypes adds the media types of a request body or response content to the summary
+func (summary *Summary) countMediaTypes(content map[string]MediaType) {
+	for mediaType := range content {
+		summary.MediaTypes[mediaType]++
+	}
+}
+
+// countContentSchemas adds the schemas of a request body or response content to the summary
+func (summary *Summary) countContentSchemas(content map[string]MediaType) {
+	for _, mediaType := range content {
+		summary.countSchema(mediaType.Schema)
+	}
+}
+
+// countResponseSchemas adds the schemas of the headers and content of a response to the summary
+func (summary *Summary) countResponseSchemas(resp *Response) {
+	for _, header := range resp.Headers {
+		summary.countSchema(header.Schema)
+	}
+	summary.countContentSchemas(resp.Content)
+}
+
+// countSchema adds the keywords of a schema and its subschemas to the summary
+func (summary *Summary) countSchema(schema *Schema) {
+	if schema == nil {
+		return
+	}
+
+	value := reflect.ValueOf(schema).Elem()
+	for i := 0; i < value.NumField(); i++ {
+		keyword, _, _ := strings.Cut(value.Type().Field(i).Tag.Get("json"), ",")
+		if keyword != "" && keyword != "-" && !value.Field(i).IsZero() {
+			summary.SchemaKeywords[keyword]++
+		}
+	}
+	if schema.Type == "" && len(schema.Types) > 0 {
+		summary.SchemaKeywords["type"]++
+	}
+	if schema.Format != "" {
+		summary.Formats[schema.Format]++
+	}
+
+	for _, property := range schema.Properties {
+		summary.countSchema(&property)
+	}
+	for _, property := range schema.PatternProperties {
+		summary.countSchema(&property)
+	}
+	for _, dependent := range schema.DependentSchemas {
+		summary.countSchema(&dependent)
+	}
+	for _, subschemas := range [][]Schema{schema.AllOf, schema.OneOf, schema.AnyOf} {
+		for i := range subschemas {
+			summary.countSchema(&subschemas[i])
+		}
+	}
+	for i := range schema.PrefixItems {
+		summary.countSchema(&schema.PrefixItems[i])
+	}
+	summary.countSchema(schema.Items)
+	summary.countSchema(schema.Not)
+	for _, keyword := range []interface{}{schema.AdditionalProperties, schema.UnevaluatedProperties, schema.UnevaluatedItems} {
+		switch subschema := keyword.(type) {
+		case *Schema:
+			summary.countSchema(subschema)
+		case Schema:
+			summary.countSchema(&subschema)
+		}
+	}
+}
@@ -0,0 +1,78 @@
+package oas
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSummary(t *testing.T) {
+	manager := NewOASManager(nil, FixedSelector(map[string]string{"test": "test"}))
+	err := manager.LoadAPI("test", []byte(`{
+        "openapi": "3.1.0",
+        "info": {"title": "Test API", "version": "1.0.0"},
+        "security": [{"apiKey": []}],
+        "paths": {
+            "/pets": {
+                "get": {
+                    "parameters": [{"name": "limit", "in": "query", "schema": {"type": "integer", "maximum": 100}}],
+                    "responses": {"200": {"$ref": "#/components/responses/Pets"}}
+                },
+                "post": {
+                    "security": [{"apiKey": [], "oauth": ["write"]}, {"oauth": ["admin"]}],
+                    "requestBody": {"content": {
+                        "application/json": {"schema": {"$ref": "#/components/schemas/Pet"}},
+                        "application/xml": {"schema": {"$ref": "#/components/schemas/Pet"}}
+                    }},
+                    "responses": {"201": {"description": "Created"}}
+                }
+            },
+            "/health": {
+                "get": {"security": [], "responses": {"200": {"description": "OK", "content": {"application/json": {}}}}}
+            }
+        },
+        "webhooks": {
+            "petCreated": {"post": {"requestBody": {"content": {"application/json": {"schema": {"$ref": "#/components/schemas/Pet"}}}}}}
+        },
+        "components": {
+            "schemas": {
+                "Pet": {
+                    "type": "object",
+                    "required": ["name"],
+                    "properties": {"name": {"type": "string", "format": "email", "maxLength": 64}, "tags": {"type": "array", "items": {"type": "string"}}},
+                    "additionalProperties": {"type": "string"}
+                }
+            },
+            "responses": {
+                "Pets": {"description": "Pets", "content": {"application/json": {"schema": {"type": "array", "items": {"$ref": "#/components/schemas/Pet"}}}}}
+            },
+            "securitySchemes": {
+                "apiKey": {"type": "apiKey", "in": "header", "name": "X-API-Key"},
+                "oauth": {"type": "oauth2", "flows": {}},
+                "basic": {"type": "http", "scheme": "basic"}
+            }
+        }
+    }`))
+	assert.NoError(t, err)
+	spec, err := manager.GetApiSpec("test")
+	assert.NoError(t, err)
+
+	summary := spec.Summary()
+	assert.Equal(t, 2, summary.Paths)
+	assert.Equal(t, 1, summary.Webhooks)
+	assert.Equal(t, map[string]int{"GET": 2, "POST": 2}, summary.Operations)
+	assert.Equal(t, map[string]int{"apiKey": 3, "oauth": 1, "basic": 0}, summary.SecuritySchemes)
+	assert.Equal(t, map[string]int{"application/json": 4, "application/xml": 1}, summary.MediaTypes)
+	assert.Equal(t, map[string]int{
+		"type":                 7,
+		"maximum":              1,
+		"maxLength":            1,
+		"format":               1,
+		"required":             1,
+		"properties":           1,
+		"items":                2,
+		"additionalProperties": 1,
+		"$ref":                 4,
+	}, summary.SchemaKeywords)
+	assert.Equal(t, map[string]int{"email": 1}, summary.Formats)
+}
@@ -0,0 +1,494 @@
+package oas
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/lionelgarnier/validate-api-request/pkg/helpers"
+)
+
+// swagger2SchemaKeys are the parameter and header keywords moved into the schema object by the conversion
+var swagger2SchemaKeys = []string{
+	"type", "format", "items", "enum", "default", "minimum", "maximum", "exclusiveMinimum", "exclusiveMaximum",
+	"multipleOf", "minLength", "maxLength", "pattern", "minItems", "maxItems", "uniqueItems",
+}
+
+// swagger2Converter holds the global definitions of a Swagger 2.0 document while converting it
+type swagger2Converter struct {
+	doc        map[string]interface{}
+	parameters map[string]interface{}
+	consumes   []string
+	produces   []string
+}
+
+// ConvertSwagger2 converts a Swagger 2.0 JSON document into an OpenAPI 3.0 JSON document.
+func ConvertSwagger2(content []byte) ([]byte, error) {
+	var doc map[string]interface{}
+	if err := json.Unmarshal(content, &doc); err != nil {
+		return nil, fmt.Errorf("failed to parse swagger document: %v", err)
+	}
+
+	c := &swagger2Converter{
+		doc:        doc,
+		parameters: asMap(doc["parameters"]),
+		consumes:   asStrings(doc["consumes"]),
+		produces:   asStrings(doc["produces"]),
+	}
+
+	converted, err := json.Marshal(c.convert())
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert swagger document: %v", err)
+	}
+	return converted, nil
+}
+
+// convert builds the OpenAPI 3.0 document
+func (c *swagger2Converter) convert() map[string]interface{} {
+	result := map[string]interface{}{
+		"openapi": "3.0.3",
+		"info":    c.doc["info"],
+	}
+	for _, key := range []string{"security", "tags", "externalDocs"} {
+		if value, exists := c.doc[key]; exists {
+			result[key] = value
+		}
+	}
+	for key, value := range c.doc {
+		if strings.HasPrefix(key, "x-") {
+			result[key] = value
+		}
+	}
+
+	if servers := c.convertServers(); len(servers) > 0 {
+		result["servers"] = servers
+	}
+
+	paths := make(map[string]interface{})
+	for path, rawItem := range asMap(c.doc["paths"]) {
+		paths[path] = c.convertPathItem(asMap(rawItem))
+	}
+	result["paths"] = paths
+
+	components := make(map[string]interface{})
+	if definitions := asMap(c.doc["definitions"]); len(definitions) > 0 {
+		schemas := make(map[string]interface{}, len(definitions))
+		for name, schema := range definitions {
+			schemas[name] = convertSchema(schema)
+		}
+		components["schemas"] = schemas
+	}
+	if len(c.parameters) > 0 {
+		parameters := make(map[string]interface{})
+		for name, param := range c.parameters {
+			paramMap := asMap(param)
+			if in := paramMap["in"]; in != "body" && in != "formData" {
+				parameters[name] = convertParameter(paramMap)
+			}
+		}
+		if len(parameters) > 0 {
+			components["parameters"] = parameters
+		}
+	}
+	if responses := asMap(c.doc["responses"]); len(responses) > 0 {
+		converted := make(map[string]interface{}, len(responses))
+		for name, response := range responses {
+			converted[name] = c.convertResponse(asMap(response), c.produces)
+		}
+		components["responses"] = converted
+	}
+	if securityDefinitions := asMap(c.doc["securityDefinitions"]); len(securityDefinitions) > 0 {
+		schemes := make(map[string]interface{}, len(securityDefinitions))
+		for name, scheme := range securityDefinitions {
+			schemes[name] = convertSecurityScheme(asMap(scheme))
+		}
+		components["securitySchemes"] = schemes
+	}
+	if len(components) > 0 {
+		result["components"] = components
+	}
+
+	return result
+}
+
+// convertServers builds the servers list from host, basePath and schemes
+func (c *swagger2Converter) convertServers() []interface{} {
+	host, _ := c.doc["host"].(string)
+	basePath, _ := c.doc["basePath"].(string)
+	if host == "" && basePath == "" {
+		return nil
+	}
+	if host == "" {
+		return []interface{}{map[string]interface{}{"url": basePath}}
+	}
+
+	schemes := asStrings(c.doc["schemes"])
+	if len(schemes) == 0 {
+		schemes = []string{"https"}
+	}
+	servers := make([]interface{}, 0, len(schemes))
+	for _, scheme := range schemes {
+		servers = append(servers, map[string]interface{}{"url": scheme + "://" + host + basePath})
+	}
+	return servers
+}
+
+// convertPathItem converts the operations and shared parameters of a path item
+func (c *swagger2Converter) convertPathItem(item map[string]interface{}) map[string]interface{} {
+	result := make(map[string]interface{})
+	var sharedBody map[string]interface{}
+	var sharedForm []map[string]interface{}
+
+	for key, value := range item {
+		switch key {
+		case "parameters":
+			params, body, form := c.convertParameters(value)
+			if len(params) > 0 {
+				result["parameters"] = params
+			}
+			sharedBody, sharedForm = body, form
+		case "get", "put", "post", "delete", "options", "head", "patch":
+		default:
+			result[key] = value
+		}
+	}
+
+	for _, method := range []string{"get", "put", "post", "delete", "options", "head", "patch"} {
+		if operation, exists := item[method]; exists {
+			result[method] = c.convertOperation(asMap(operation), sharedBody, sharedForm)
+		}
+	}
+	return result
+}
+
+// convertOperation converts parameters, request body and responses of an operation
+func (c *swagger2Converter) convertOperation(operation map[string]interface{}, sharedBody map[string]interface{}, sharedForm []map[string]interface{}) map[string]interface{} {
+	consumes := c.consumes
+	if opConsumes := asStrings(operation["consumes"]); len(opConsumes) > 0 {
+		consumes = opConsumes
+	}
+	produces := c.produces
+	if opProduces := asStrings(operation["produces"]); len(opProduces) > 0 {
+		produces = opProduces
+	}
+
+	result := make(map[string]interface{})
+	body, form := sharedBody, sharedForm
+	for key, value := range operation {
+		switch key {
+		case "consumes", "produces", "schemes":
+		case "parameters":
+			params, opBody, opForm := c.convertParameters(value)
+			if len(params) > 0 {
+				result["parameters"] = params
+			}
+			if opBody != nil {
+				body = opBody
+			}
+			if len(opForm) > 0 {
+				form = append(append([]map[string]interface{}{}, form...), opForm...)
+			}
+		case "responses":
+			responses := make(map[string]interface{})
+			for code, response := range asMap(value) {
+				responses[code] = c.convertResponse(asMap(response), produces)
+			}
+			result["responses"] = responses
+		default:
+			result[key] = value
+		}
+	}
+
+	if body != nil {
+		result["requestBody"] = convertBodyParameter(body, consumes)
+	} else if len(form) > 0 {
+		result["requestBody"] = convertFormParameters(form, consumes)
+	}
+	return result
+}
+
+// convertParameters splits Swagger 2.0 parameters into regular parameters, the body parameter and form parameters
+func (c *swagger2Converter) convertParameters(value interface{}) ([]interface{}, map[string]interface{}, []map[string]interface{}) {
+	var params []interface{}
+	var body map[string]interface{}
+	var form []map[string]interface{}
+
+	for _, rawParam := range asSlice(value) {
+		param := asMap(rawParam)
+		if ref, ok := param["$ref"].(string); ok {
+			// Referenced parameters are inlined as body and form parameters have no 3.0 parameter equivalent
+			param = asMap(c.parameters[strings.TrimPrefix(ref, "#/parameters/")])
+		}
+
+		switch param["in"] {
+		case "body":
+			body = param
+		case "formData":
+			form = append(form, param)
+		case nil:
+		default:
+			params = append(params, convertParameter(param))
+		}
+	}
+	return params, body, form
+}
+
+// convertResponse moves the response schema into a content map and converts headers
+func (c *swagger2Converter) convertResponse(response map[string]interface{}, produces []string) map[string]interface{} {
+	if ref, ok := response["$ref"].(string); ok {
+		return map[string]interface{}{"$ref": rewriteRef(ref)}
+	}
+
+	result := make(map[string]interface{})
+	for key, value := range response {
+		switch key {
+		case "schema":
+			result["content"] = contentFor(convertSchema(value), produces)
+		case "headers":
+			headers := make(map[string]interface{})
+			for name, header := range asMap(value) {
+				headers[name] = convertParameter(asMap(header))
+			}
+			result["headers"] = headers
+		case "examples":
+		default:
+			result[key] = value
+		}
+	}
+	if _, exists := result["description"]; !exists {
+		result["description"] = ""
+	}
+	return result
+}
+
+// convertParameter moves the type keywords of a parameter or header into a schema object
+func convertParameter(param map[string]interface{}) map[string]interface{} {
+	result := make(map[string]interface{})
+	schema := make(map[string]interface{})
+
+	for key, value := range param {
+		switch {
+		case key == "collectionFormat":
+			style, explode := collectionFormatStyle(value, param["in"])
+			if style != "" {
+				result["style"] = style
+			}
+			result["explode"] = explode
+		case key == "allowEmptyValue" || !helpers.Contains(swagger2SchemaKeys, key):
+			result[key] = value
+		default:
+			schema[key] = convertSchema(value)
+		}
+	}
+
+	if len(schema) > 0 {
+		if schema["type"] == "file" {
+			schema["type"] = "string"
+			schema["format"] = "binary"
+		}
+		result["schema"] = schema
+	}
+	return result
+}
+
+// convertBodyParameter converts a body parameter into a request body
+func convertBodyParameter(param map[string]interface{}, consumes []string) map[string]interface{} {
+	requestBody := map[string]interface{}{
+		"content": contentFor(convertSchema(param["schema"]), consumes),
+	}
+	if required, ok := param["required"].(bool); ok && required {
+		requestBody["required"] = true
+	}
+	if description, ok := param["description"]; ok {
+		requestBody["description"] = description
+	}
+	return requestBody
+}
+
+// convertFormParameters converts form parameters into an object schema request body
+func convertFormParameters(params []map[string]interface{}, consumes []string) map[string]interface{} {
+	properties := make(map[string]interface{})
+	var required []interface{}
+
+	mediaTypes := []string{}
+	for _, consume := range consumes {
+		if consume == "application/x-www-form-urlencoded" || consume == "multipart/form-data" {
+			mediaTypes = append(mediaTypes, consume)
+		}
+	}
+
+	for _, param := range params {
+		name, _ := param["name"].(string)
+		converted := convertParameter(param)
+		schema := asMap(converted["schema"])
+		if schema["format"] == "binary" && !helpers.Contains(mediaTypes, "multipart/form-data") {
+			mediaTypes = append(mediaTypes, "multipart/form-data")
+		}
+		properties[name] = schema
+		if isRequired, ok := param["required"].(bool); ok && isRequired {
+			required = append(required, name)
+		}
+	}
+	if len(mediaTypes) == 0 {
+		mediaTypes = []string{"application/x-www-form-urlencoded"}
+	}
+
+	schema := map[string]interface{}{
+		"type":       "object",
+		"properties": properties,
+	}
+	requestBody := map[string]interface{}{}
+	if len(required) > 0 {
+		schema["required"] = required
+		requestBody["required"] = true
+	}
+	requestBody["content"] = contentFor(schema, mediaTypes)
+	return requestBody
+}
+
+// convertSecurityScheme converts a security definition into a security scheme
+func convertSecurityScheme(scheme map[string]interface{}) map[string]interface{} {
+	result := make(map[string]interface{})
+	for key, value := range scheme {
+		switch key {
+		case "flow", "authorizationUrl", "tokenUrl", "scopes":
+		default:
+			result[key] = value
+		}
+	}
+
+	switch scheme["type"] {
+	case "basic":
+		result["type"] = "http"
+		result["scheme"] = "basic"
+	case "oauth2":
+		flow := map[string]interface{}{"scopes": scheme["scopes"]}
+		if flow["scopes"] == nil {
+			flow["scopes"] = map[string]interface{}{}
+		}
+		if url, ok := scheme["authorizationUrl"]; ok {
+			flow["authorizationUrl"] = url
+		}
+		if url, ok := scheme["tokenUrl"]; ok {
+			flow["tokenUrl"] = url
+		}
+		flowNames := map[string]string{
+			"implicit":    "implicit",
+			"password":    "password",
+			"application": "clientCredentials",
+			"accessCode":  "authorizationCode",
+		}
+		if name, ok := flowNames[fmt.Sprint(scheme["flow"])]; ok {
+			result["flows"] = map[string]interface{}{name: flow}
+		}
+	}
+	return result
+}
+
+// convertSchema rewrites references and Swagger 2.0 specific keywords of a schema
+func convertSchema(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		result := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			switch key {
+			case "$ref":
+				if ref, ok := item.(string); ok {
+					result[key] = rewriteRef(ref)
+					continue
+				}
+				result[key] = item
+			case "x-nullable":
+				result["nullable"] = item
+			case "discriminator":
+				// Swagger 2.0 discriminators are a plain property name
+				if name, ok := item.(string); ok {
+					result[key] = map[string]interface{}{"propertyName": name}
+					continue
+				}
+				result[key] = item
+			default:
+				result[key] = convertSchema(item)
+			}
+		}
+		if result["type"] == "file" {
+			result["type"] = "string"
+			result["format"] = "binary"
+		}
+		return result
+	case []interface{}:
+		result := make([]interface{}, len(v))
+		for i, item := range v {
+			result[i] = convertSchema(item)
+		}
+		return result
+	default:
+		return v
+	}
+}
+
+// rewriteRef maps Swagger 2.0 reference locations to their OpenAPI 3.0 components
+func rewriteRef(ref string) string {
+	replacer := strings.NewReplacer(
+		"#/definitions/", "#/components/schemas/",
+		"#/parameters/", "#/components/parameters/",
+		"#/responses/", "#/components/responses/",
+	)
+	return replacer.Replace(ref)
+}
+
+// collectionFormatStyle maps a collectionFormat to the equivalent style and explode values
+func collectionFormatStyle(collectionFormat interface{}, in interface{}) (string, bool) {
+	switch collectionFormat {
+	case "multi":
+		return "form", true
+	case "ssv":
+		return "spaceDelimited", false
+	case "pipes":
+		return "pipeDelimited", false
+	default:
+		if in == "query" || in == "cookie" {
+			return "form", false
+		}
+		return "simple", false
+	}
+}
+
+// contentFor builds a content map sharing the schema across the given media types
+func contentFor(schema interface{}, mediaTypes []string) map[string]interface{} {
+	if len(mediaTypes) == 0 {
+		mediaTypes = []string{"application/json"}
+	}
+	content := make(map[string]interface{}, len(mediaTypes))
+	for _, mediaType := range mediaTypes {
+		content[mediaType] = map[string]interface{}{"schema": schema}
+	}
+	return content
+}
+
+// asMap returns the value as a JSON object, or an empty one
+func asMap(value interface{}) map[string]interface{} {
+	if m, ok := value.(map[string]interface{}); ok {
+		return m
+	}
+	return map[string]interface{}{}
+}
+
+// asSlice returns the value as a JSON array, or nil
+func asSlice(value interface{}) []interface{} {
+	if s, ok := value.([]interface{}); ok {
+		return s
+	}
+	return nil
+}
+
+// asStrings returns the string items of a JSON array
+func asStrings(value interface{}) []string {
+	var result []string
+	for _, item := range asSlice(value) {
+		if str, ok := item.(string); ok {
+			result = append(result, str)
+		}
+	}
+	return result
+}
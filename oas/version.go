@@ -0,0 +1,69 @@
+package oas
+
+import (
+	"fmt"
+
+	"github.com/lionelgarnier/validate-api-request/pkg/helpers"
+)
+
+// VersionPolicy decides whether a specification can replace the one loaded under the same name
+type VersionPolicy string
+
+const (
+	// VersionAny accepts any info.version
+	VersionAny VersionPolicy = ""
+	// VersionNoDowngrade refuses specifications with a lower info.version
+	VersionNoDowngrade VersionPolicy = "noDowngrade"
+	// VersionIncrement refuses specifications whose info.version is not greater
+	VersionIncrement VersionPolicy = "increment"
+)
+
+// WithVersionPolicy sets the policy applied when a specification replaces another one
+func WithVersionPolicy(policy VersionPolicy) ManagerOption {
+	return func(m *OASManager) {
+		m.versionPolicy = policy
+	}
+}
+
+// WithKnownVersions sets the versions previously activated per API, e.g. by the manager being replaced
+func WithKnownVersions(versions map[string]string) ManagerOption {
+	return func(m *OASManager) {
+		for name, version := range versions {
+			m.versions[name] = version
+		}
+	}
+}
+
+// Versions returns the info.version last activated for each API
+func (m *OASManager) Versions() map[string]string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	versions := make(map[string]string, len(m.versions))
+	for name, version := range m.versions {
+		versions[name] = version
+	}
+	return versions
+}
+
+// Check returns an error if the policy refuses to replace the previous version by the next one
+func (p VersionPolicy) Check(previous, next string) error {
+	if p == VersionAny || previous == "" {
+		return nil
+	}
+	if p != VersionNoDowngrade && p != VersionIncrement {
+		return fmt.Errorf("unknown version policy '%s'", p)
+	}
+
+	cmp, err := helpers.CompareSemver(next, previous)
+	if err != nil {
+		return err
+	}
+	if cmp < 0 {
+		return fmt.Errorf("version '%s' is lower than the loaded version '%s'", next, previous)
+	}
+	if p == VersionIncrement && cmp == 0 {
+		return fmt.Errorf("version '%s' is not greater than the loaded version '%s'", next, previous)
+	}
+	return nil
+}
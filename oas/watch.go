@@ -0,0 +1,86 @@
+package oas
+
+import (
+	"fmt"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// watchDebounce coalesces the events of a file being written into a single reload
+const watchDebounce = 100 * time.Millisecond
+
+// WatchAPIFile reloads an API specification from its file each time the file, or a local file targeted by its
+// external $refs, changes, after loading it once. The directories of the files are watched, so files replaced by
+// editors or deployment tools are followed. Reloads are atomic and skipped when the content is unchanged; failed
+// reloads keep the loaded specification and emit an EventReloadFailed event. The returned function stops watching.
+func (m *OASManager) WatchAPIFile(name, filePath string) (func(), error) {
+	if err := m.LoadAPIFromFile(name, filePath); err != nil {
+		return nil, err
+	}
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, fmt.Errorf("failed to watch file: %v", err)
+	}
+	filePath = filepath.Clean(filePath)
+	if err := watcher.Add(filepath.Dir(filePath)); err != nil {
+		watcher.Close()
+		return nil, fmt.Errorf("failed to watch file: %v", err)
+	}
+	watched := map[string]bool{filePath: true}
+	m.watchReferencedFiles(name, watcher, watched)
+
+	done := make(chan struct{})
+	go func() {
+		var reload <-chan time.Time
+		for {
+			select {
+			case event, ok := <-watcher.Events:
+				if !ok {
+					return
+				}
+				if watched[filepath.Clean(event.Name)] && !event.Has(fsnotify.Chmod) {
+					reload = time.After(watchDebounce)
+				}
+			case err, ok := <-watcher.Errors:
+				if !ok {
+					return
+				}
+				m.emit(Event{Type: EventReloadFailed, API: name, Err: err})
+			case <-reload:
+				reload = nil
+				if err := m.LoadAPIFromFile(name, filePath); err != nil {
+					m.emit(Event{Type: EventReloadFailed, API: name, Err: err})
+				}
+				m.watchReferencedFiles(name, watcher, watched)
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			close(done)
+			watcher.Close()
+		})
+	}, nil
+}
+
+// watchReferencedFiles adds the local files targeted by the external $refs of the loaded API to the watched files,
+// watching their directories. Directories that cannot be watched are skipped, e.g. the members of an archive.
+func (m *OASManager) watchReferencedFiles(name string, watcher *fsnotify.Watcher, watched map[string]bool) {
+	for _, file := range m.referencedFiles(name) {
+		file = filepath.Clean(file)
+		if watched[file] {
+			continue
+		}
+		if err := watcher.Add(filepath.Dir(file)); err == nil {
+			watched[file] = true
+		}
+	}
+}
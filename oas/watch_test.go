@@ -0,0 +1,110 @@
+package oas
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWatchAPIFile(t *testing.T) {
+	dir := t.TempDir()
+	specFile := filepath.Join(dir, "openapi.json")
+	spec := func(version string) []byte {
+		return []byte(`{"openapi": "3.0.0", "info": {"title": "Test API", "version": "` + version + `"}, "paths": {"/pets": {"get": {}}}}`)
+	}
+	assert.NoError(t, os.WriteFile(specFile, spec("1.0.0"), 0o644))
+
+	var mu sync.Mutex
+	var events []Event
+	manager := NewOASManager(nil, FixedSelector(map[string]string{"default": "test"}), WithEventHandler(func(event Event) {
+		mu.Lock()
+		defer mu.Unlock()
+		events = append(events, event)
+	}))
+	eventTypes := func() []EventType {
+		mu.Lock()
+		defer mu.Unlock()
+		var types []EventType
+		for _, event := range events {
+			types = append(types, event.Type)
+		}
+		return types
+	}
+	version := func() string {
+		spec, err := manager.GetApiSpec("test")
+		assert.NoError(t, err)
+		return spec.version
+	}
+
+	stop, err := manager.WatchAPIFile("test", specFile)
+	assert.NoError(t, err)
+	defer stop()
+	assert.Equal(t, "1.0.0", version())
+
+	// A changed file is reloaded
+	assert.NoError(t, os.WriteFile(specFile, spec("1.1.0"), 0o644))
+	assert.Eventually(t, func() bool { return version() == "1.1.0" }, 5*time.Second, 10*time.Millisecond)
+
+	// A file replaced by a rename is followed
+	replacement := filepath.Join(dir, "openapi.json.tmp")
+	assert.NoError(t, os.WriteFile(replacement, spec("1.2.0"), 0o644))
+	assert.NoError(t, os.Rename(replacement, specFile))
+	assert.Eventually(t, func() bool { return version() == "1.2.0" }, 5*time.Second, 10*time.Millisecond)
+
+	// An invalid file keeps the loaded spec
+	assert.NoError(t, os.WriteFile(specFile, []byte(`{"openapi": `), 0o644))
+	assert.Eventually(t, func() bool {
+		types := eventTypes()
+		return len(types) > 0 && types[len(types)-1] == EventReloadFailed
+	}, 5*time.Second, 10*time.Millisecond)
+	assert.Equal(t, "1.2.0", version())
+	assert.Equal(t, []EventType{EventLoaded, EventLoaded, EventLoaded, EventReloadFailed}, eventTypes())
+
+	// Stopped watchers no longer reload
+	stop()
+	stop()
+	assert.NoError(t, os.WriteFile(specFile, spec("2.0.0"), 0o644))
+	time.Sleep(3 * watchDebounce)
+	assert.Equal(t, "1.2.0", version())
+}
+
+func TestWatchReferencedFile(t *testing.T) {
+	dir := t.TempDir()
+	specFile := filepath.Join(dir, "openapi.json")
+	schemaFile := filepath.Join(dir, "schemas", "pet.json")
+	assert.NoError(t, os.Mkdir(filepath.Dir(schemaFile), 0o755))
+	assert.NoError(t, os.WriteFile(specFile, []byte(`{
+		"openapi": "3.0.0",
+		"info": {"title": "Test API", "version": "1.0.0"},
+		"paths": {},
+		"components": {"schemas": {"Pet": {"$ref": "schemas/pet.json"}}}
+	}`), 0o644))
+	assert.NoError(t, os.WriteFile(schemaFile, []byte(`{"type": "object", "required": ["name"]}`), 0o644))
+
+	manager := NewOASManager(nil, FixedSelector(map[string]string{"default": "test"}))
+	hash := func() uint64 {
+		spec, err := manager.GetApiSpec("test")
+		assert.NoError(t, err)
+		return spec.Hash()
+	}
+
+	stop, err := manager.WatchAPIFile("test", specFile)
+	assert.NoError(t, err)
+	defer stop()
+	loaded := hash()
+
+	// Unchanged documents are not reloaded
+	assert.NoError(t, manager.LoadAPIFromFile("test", specFile))
+	assert.Equal(t, loaded, hash())
+
+	// A changed referenced file is reloaded
+	assert.NoError(t, os.WriteFile(schemaFile, []byte(`{"type": "object", "required": ["id"]}`), 0o644))
+	assert.Eventually(t, func() bool { return hash() != loaded }, 5*time.Second, 10*time.Millisecond)
+	spec, err := manager.GetApiSpec("test")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"id"}, spec.Components.Schemas["pet"].Required)
+}
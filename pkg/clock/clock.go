@@ -0,0 +1,56 @@
+package clock
+
+import (
+	"sync"
+	"time"
+)
+
+// Clock provides the current time
+type Clock interface {
+	Now() time.Time
+}
+
+// realClock reads the system clock
+type realClock struct{}
+
+// Now returns the current system time
+func (realClock) Now() time.Time {
+	return time.Now()
+}
+
+// Real returns a Clock backed by the system clock
+func Real() Clock {
+	return realClock{}
+}
+
+// Mock is a manually driven Clock for deterministic tests
+type Mock struct {
+	now time.Time
+	mu  sync.RWMutex
+}
+
+// NewMock returns a Mock clock set to the given time
+func NewMock(now time.Time) *Mock {
+	return &Mock{now: now}
+}
+
+// Now returns the mocked time
+func (m *Mock) Now() time.Time {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.now
+}
+
+// Set moves the mocked time to the given instant
+func (m *Mock) Set(now time.Time) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.now = now
+}
+
+// Advance moves the mocked time forward by the given duration
+func (m *Mock) Advance(d time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.now = m.now.Add(d)
+}
@@ -1,13 +1,20 @@
 package helpers
 
 import (
+	"cmp"
+	"container/list"
 	"crypto/md5"
 	"encoding/base64"
+	"encoding/json"
 	"fmt"
+	"math"
+	"math/big"
 	"net"
+	"net/url"
 	"regexp"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 )
 
@@ -21,9 +28,75 @@ func Contains(slice []string, item string) bool {
 	return false
 }
 
+// maxCompiledPatterns bounds the compiled patterns cached, the least recently used ones are compiled again
+const maxCompiledPatterns = 4096
+
+// compiledPatterns caches the compiled patterns, or their compilation error, by pattern
+var compiledPatterns = newPatternCache(maxCompiledPatterns)
+
+// compiledPattern is a cached pattern compilation
+type compiledPattern struct {
+	pattern string
+	re      *regexp.Regexp
+	err     error
+}
+
+// patternCache is a least recently used cache of pattern compilations
+type patternCache struct {
+	mu       sync.Mutex
+	entries  map[string]*list.Element
+	order    *list.List // Compilations from the most to the least recently used
+	capacity int
+}
+
+// newPatternCache returns a cache keeping up to capacity compilations
+func newPatternCache(capacity int) *patternCache {
+	return &patternCache{entries: make(map[string]*list.Element), order: list.New(), capacity: capacity}
+}
+
+// get returns the cached compilation of a pattern, marking it as recently used
+func (c *patternCache) get(pattern string) (compiledPattern, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	element, exists := c.entries[pattern]
+	if !exists {
+		return compiledPattern{}, false
+	}
+	c.order.MoveToFront(element)
+	return element.Value.(compiledPattern), true
+}
+
+// add caches the compilation of a pattern, evicting the least recently used one beyond the capacity
+func (c *patternCache) add(compiled compiledPattern) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if element, exists := c.entries[compiled.pattern]; exists {
+		c.order.MoveToFront(element)
+		return
+	}
+	c.entries[compiled.pattern] = c.order.PushFront(compiled)
+	if c.order.Len() > c.capacity {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(compiledPattern).pattern)
+	}
+}
+
+// CompilePattern compiles a pattern once, returning the cached compilation on the following calls.
+// Patterns come from the loaded specifications, which may be replaced, so the least recently used compilations are
+// evicted beyond maxCompiledPatterns.
+func CompilePattern(pattern string) (*regexp.Regexp, error) {
+	if compiled, ok := compiledPatterns.get(pattern); ok {
+		return compiled.re, compiled.err
+	}
+	re, err := regexp.Compile(pattern)
+	compiledPatterns.add(compiledPattern{pattern: pattern, re: re, err: err})
+	return re, err
+}
+
 // MatchPattern checks if a string matches a given pattern
 func MatchPattern(value, pattern string) bool {
-	re, err := regexp.Compile(pattern)
+	re, err := CompilePattern(pattern)
 	if err != nil {
 		return false
 	}
@@ -34,6 +107,12 @@ func MatchPattern(value, pattern string) bool {
 func UniqueItems(arr []interface{}) bool {
 	seen := make(map[interface{}]bool)
 	for _, item := range arr {
+		// Numbers decoded as json.Number are equal by value, e.g. 1 and 1.0
+		if number, ok := item.(json.Number); ok {
+			if exact, ok := ExactNumber(number); ok {
+				item = json.Number(exact.RatString())
+			}
+		}
 		if seen[item] {
 			return false
 		}
@@ -58,6 +137,9 @@ func IsHostnameValid(value interface{}) bool {
 // IsInt32 checks if value is within int32 range
 func IsInt32(value interface{}) bool {
 	const minInt32, maxInt32 = -2147483648, 2147483647
+	if exact, ok := ExactNumber(value); ok {
+		return isIntInRange(exact, minInt32, maxInt32)
+	}
 	switch v := value.(type) {
 	case string:
 		if floatVal, err := strconv.ParseFloat(v, 64); err == nil {
@@ -71,9 +153,13 @@ func IsInt32(value interface{}) bool {
 	return false
 }
 
-// IsInt64 checks if value is within int64 range
+// IsInt64 checks if value is within int64 range. Numeric strings and json.Number values are checked exactly, e.g.
+// 9223372036854775807 is an int64 but 9223372036854775808 is not, while they are the same float64.
 func IsInt64(value interface{}) bool {
 	const minInt64, maxInt64 = -9223372036854775808, 9223372036854775807
+	if exact, ok := ExactNumber(value); ok {
+		return isIntInRange(exact, minInt64, maxInt64)
+	}
 	switch v := value.(type) {
 	case string:
 		if floatVal, err := strconv.ParseFloat(v, 64); err == nil {
@@ -87,6 +173,43 @@ func IsInt64(value interface{}) bool {
 	return false
 }
 
+// isIntInRange checks if an exact number is an integer between low and high
+func isIntInRange(exact *big.Rat, low, high int64) bool {
+	if !exact.IsInt() {
+		return false
+	}
+	return exact.Num().Cmp(big.NewInt(low)) >= 0 && exact.Num().Cmp(big.NewInt(high)) <= 0
+}
+
+// decimalNumberPattern matches decimal number literals, with an exponent short enough to convert them exactly
+var decimalNumberPattern = regexp.MustCompile(`^[+-]?([0-9]+(\.[0-9]*)?|\.[0-9]+)([eE][+-]?[0-9]{1,3})?$`)
+
+// ExactNumber returns the exact value of a decimal number literal, a json.Number or a numeric string, which float64
+// would round beyond 2^53. Other values, and hexadecimal or non-finite literals, have no exact value.
+func ExactNumber(value interface{}) (*big.Rat, bool) {
+	var literal string
+	switch v := value.(type) {
+	case json.Number:
+		literal = string(v)
+	case string:
+		literal = v
+	default:
+		return nil, false
+	}
+	if !decimalNumberPattern.MatchString(literal) {
+		return nil, false
+	}
+	return new(big.Rat).SetString(literal)
+}
+
+// CompareNumber compares a number to a bound, like cmp.Compare, exactly when the number has an exact value
+func CompareNumber(value float64, exact *big.Rat, bound float64) int {
+	if exact == nil || math.IsInf(bound, 0) || math.IsNaN(bound) {
+		return cmp.Compare(value, bound)
+	}
+	return exact.Cmp(new(big.Rat).SetFloat64(bound))
+}
+
 // IsFloat checks if value is a float
 func IsFloat(value interface{}) bool {
 	_, err := strconv.ParseFloat(fmt.Sprintf("%v", value), 32)
@@ -99,6 +222,32 @@ func IsDouble(value interface{}) bool {
 	return err == nil
 }
 
+// IsMultipleOf checks if value is a multiple of divisor, comparing their shortest decimal representations so that
+// fractional divisors such as 0.01 are exact despite binary floating point. Divisors that are not positive accept
+// any value.
+func IsMultipleOf(value, divisor float64) bool {
+	if divisor <= 0 || math.IsInf(value, 0) || math.IsNaN(value) {
+		return divisor <= 0
+	}
+	numerator, ok := new(big.Rat).SetString(strconv.FormatFloat(value, 'g', -1, 64))
+	if !ok {
+		return false
+	}
+	return IsExactMultipleOf(numerator, divisor)
+}
+
+// IsExactMultipleOf checks if an exact value is a multiple of divisor, like IsMultipleOf
+func IsExactMultipleOf(value *big.Rat, divisor float64) bool {
+	if divisor <= 0 || math.IsInf(divisor, 0) || math.IsNaN(divisor) {
+		return divisor <= 0
+	}
+	denominator, ok := new(big.Rat).SetString(strconv.FormatFloat(divisor, 'g', -1, 64))
+	if !ok {
+		return false
+	}
+	return new(big.Rat).Quo(value, denominator).IsInt()
+}
+
 // IsBoolean validates boolean values
 func IsBoolean(value interface{}) bool {
 	switch v := value.(type) {
@@ -122,6 +271,105 @@ func IsISO8601(value interface{}) bool {
 	return err == nil
 }
 
+// IsFullDate validates RFC 3339 full-date format, e.g. 2023-06-15, rejecting date-times and impossible days
+func IsFullDate(value interface{}) bool {
+	str, ok := value.(string)
+	if !ok {
+		return false
+	}
+	regex := regexp.MustCompile(`^\d{4}-\d{2}-\d{2}$`)
+	if !regex.MatchString(str) {
+		return false
+	}
+	_, err := time.Parse(time.DateOnly, str)
+	return err == nil
+}
+
+// IsDateTime validates RFC 3339 date-time format, e.g. 2023-06-15T10:00:00Z, with a mandatory time offset and
+// case-insensitive T and Z separators. A leap second is accepted at the end of a UTC day.
+func IsDateTime(value interface{}) bool {
+	str, ok := value.(string)
+	if !ok {
+		return false
+	}
+	regex := regexp.MustCompile(`^\d{4}-\d{2}-\d{2}[Tt]\d{2}:\d{2}:\d{2}(\.\d+)?([Zz]|[+-]\d{2}:\d{2})$`)
+	if !regex.MatchString(str) {
+		return false
+	}
+	str = strings.ToUpper(str)
+	parsed, err := time.Parse(time.RFC3339Nano, str)
+	if err == nil {
+		return true
+	}
+	if str[17:19] != "60" {
+		return false
+	}
+	// time rejects leap seconds, which RFC 3339 allows as the last second of a UTC day
+	parsed, err = time.Parse(time.RFC3339Nano, str[:17]+"59"+str[19:])
+	if err != nil {
+		return false
+	}
+	parsed = parsed.UTC()
+	return parsed.Hour() == 23 && parsed.Minute() == 59
+}
+
+// IsTime validates RFC 3339 partial-time format, e.g. 10:00:00 or 10:00:00.5, optionally followed by a time offset
+// (full-time), e.g. 10:00:00Z or 10:00:00+02:00. A leap second is accepted at 23:59 UTC, or at 23:59 without offset.
+func IsTime(value interface{}) bool {
+	str, ok := value.(string)
+	if !ok {
+		return false
+	}
+	regex := regexp.MustCompile(`^(\d{2}):(\d{2}):(\d{2})(?:\.\d+)?(?:([Zz])|([+-])(\d{2}):(\d{2}))?$`)
+	match := regex.FindStringSubmatch(str)
+	if match == nil {
+		return false
+	}
+	hour, _ := strconv.Atoi(match[1])
+	minute, _ := strconv.Atoi(match[2])
+	second, _ := strconv.Atoi(match[3])
+	if hour > 23 || minute > 59 || second > 60 {
+		return false
+	}
+	if match[5] != "" {
+		offsetHour, _ := strconv.Atoi(match[6])
+		offsetMinute, _ := strconv.Atoi(match[7])
+		if offsetHour > 23 || offsetMinute > 59 {
+			return false
+		}
+		offset := offsetHour*60 + offsetMinute
+		if match[5] == "+" {
+			offset = -offset
+		}
+		utc := ((hour*60+minute+offset)%(24*60) + 24*60) % (24 * 60)
+		hour, minute = utc/60, utc%60
+	}
+	return second < 60 || (hour == 23 && minute == 59)
+}
+
+// IsDuration validates ISO 8601 duration format, e.g. P1Y2M10DT2H30M, PT0.5S or P2W. At least one component is
+// required, and only the last one may have a fraction.
+func IsDuration(value interface{}) bool {
+	str, ok := value.(string)
+	if !ok {
+		return false
+	}
+	regex := regexp.MustCompile(`^P(?:\d+(?:[.,]\d+)?W|` +
+		`(?:\d+(?:[.,]\d+)?Y)?(?:\d+(?:[.,]\d+)?M)?(?:\d+(?:[.,]\d+)?D)?` +
+		`(?:T(?:\d+(?:[.,]\d+)?H)?(?:\d+(?:[.,]\d+)?M)?(?:\d+(?:[.,]\d+)?S)?)?)$`)
+	if str == "P" || strings.HasSuffix(str, "T") || !regex.MatchString(str) {
+		return false
+	}
+	switch strings.Count(str, ".") + strings.Count(str, ",") {
+	case 0:
+		return true
+	case 1:
+		return regexp.MustCompile(`[.,]\d+[A-Z]$`).MatchString(str)
+	default:
+		return false
+	}
+}
+
 // IsString validates string values
 func IsString(value interface{}) bool {
 	_, ok := value.(string)
@@ -158,6 +406,66 @@ func IsURL(value interface{}) bool {
 	return regex.MatchString(str)
 }
 
+// IsURIReference validates RFC 3986 URI-reference format, an absolute URI or a relative reference such as
+// ../pets/42?tag=dog#name, made of unreserved, reserved and percent-encoded characters
+func IsURIReference(value interface{}) bool {
+	str, ok := value.(string)
+	if !ok {
+		return false
+	}
+	regex := regexp.MustCompile(`^(?:[A-Za-z0-9\-._~:/?#\[\]@!$&'()*+,;=]|%[0-9A-Fa-f]{2})*$`)
+	if !regex.MatchString(str) {
+		return false
+	}
+	_, err := url.Parse(str)
+	return err == nil
+}
+
+// IsURITemplate validates RFC 6570 URI template format, e.g. /pets/{id}{?fields*,limit:3}
+func IsURITemplate(value interface{}) bool {
+	str, ok := value.(string)
+	if !ok {
+		return false
+	}
+	varchar := `(?:[A-Za-z0-9_]|%[0-9A-Fa-f]{2})`
+	varspec := varchar + `(?:\.?` + varchar + `)*(?::[1-9][0-9]{0,3}|\*)?`
+	expression := `\{[+#./;?&=,!@|]?` + varspec + `(?:,` + varspec + `)*\}`
+	literal := "[^\\x00-\\x20\\x7f\"'%<>\\\\^`{|}]|%[0-9A-Fa-f]{2}"
+	regex := regexp.MustCompile(`^(?:` + literal + `|` + expression + `)*$`)
+	return regex.MatchString(str)
+}
+
+// IsJSONPointer validates RFC 6901 JSON pointer format, e.g. /pets/0/name, where `~` is only escaped as ~0 or ~1
+func IsJSONPointer(value interface{}) bool {
+	str, ok := value.(string)
+	if !ok {
+		return false
+	}
+	regex := regexp.MustCompile(`^(?:/(?:[^~/]|~[01])*)*$`)
+	return regex.MatchString(str)
+}
+
+// IsRelativeJSONPointer validates relative JSON pointer format, e.g. 1/name or 0#: a number of levels up from the
+// current value, followed by a JSON pointer or by `#` for the key or index of the value reached
+func IsRelativeJSONPointer(value interface{}) bool {
+	str, ok := value.(string)
+	if !ok {
+		return false
+	}
+	regex := regexp.MustCompile(`^(?:0|[1-9][0-9]*)(?:#|(?:/(?:[^~/]|~[01])*)*)$`)
+	return regex.MatchString(str)
+}
+
+// IsRegex validates regular expression format, as the expressions compiled for `pattern`, in Go RE2 syntax
+func IsRegex(value interface{}) bool {
+	str, ok := value.(string)
+	if !ok {
+		return false
+	}
+	_, err := regexp.Compile(str)
+	return err == nil
+}
+
 // IsArray checks if value is an array or slice
 func IsArray(value interface{}) bool {
 	switch value.(type) {
@@ -204,6 +512,105 @@ func ParseDuration(duration string) (time.Duration, error) {
 	return time.ParseDuration(duration)
 }
 
+// CompareSemver compares two semantic versions (an optional `v` prefix is accepted).
+// It returns -1, 0 or 1 when a is lower than, equal to or greater than b; build metadata is ignored.
+func CompareSemver(a, b string) (int, error) {
+	va, err := parseSemver(a)
+	if err != nil {
+		return 0, err
+	}
+	vb, err := parseSemver(b)
+	if err != nil {
+		return 0, err
+	}
+
+	for i := 0; i < 3; i++ {
+		if va.core[i] != vb.core[i] {
+			if va.core[i] < vb.core[i] {
+				return -1, nil
+			}
+			return 1, nil
+		}
+	}
+	return comparePrerelease(va.prerelease, vb.prerelease), nil
+}
+
+type semver struct {
+	core       [3]uint64
+	prerelease []string
+}
+
+// parseSemver parses a MAJOR.MINOR.PATCH[-prerelease][+build] version
+func parseSemver(version string) (semver, error) {
+	var parsed semver
+	v := strings.TrimPrefix(strings.TrimSpace(version), "v")
+	v, _, _ = strings.Cut(v, "+")
+	v, prerelease, hasPrerelease := strings.Cut(v, "-")
+
+	parts := strings.Split(v, ".")
+	if len(parts) != 3 {
+		return parsed, fmt.Errorf("'%s' is not a semantic version", version)
+	}
+	for i, part := range parts {
+		n, err := strconv.ParseUint(part, 10, 64)
+		if err != nil {
+			return parsed, fmt.Errorf("'%s' is not a semantic version", version)
+		}
+		parsed.core[i] = n
+	}
+	if hasPrerelease {
+		if prerelease == "" {
+			return parsed, fmt.Errorf("'%s' is not a semantic version", version)
+		}
+		parsed.prerelease = strings.Split(prerelease, ".")
+	}
+	return parsed, nil
+}
+
+// comparePrerelease compares pre-release identifiers, a version without pre-release having precedence
+func comparePrerelease(a, b []string) int {
+	switch {
+	case len(a) == 0 && len(b) == 0:
+		return 0
+	case len(a) == 0:
+		return 1
+	case len(b) == 0:
+		return -1
+	}
+
+	for i := 0; i < len(a) && i < len(b); i++ {
+		if a[i] == b[i] {
+			continue
+		}
+		na, errA := strconv.ParseUint(a[i], 10, 64)
+		nb, errB := strconv.ParseUint(b[i], 10, 64)
+		switch {
+		case errA == nil && errB == nil:
+			if na < nb {
+				return -1
+			}
+			return 1
+		case errA == nil:
+			// Numeric identifiers have lower precedence than alphanumeric ones
+			return -1
+		case errB == nil:
+			return 1
+		case a[i] < b[i]:
+			return -1
+		default:
+			return 1
+		}
+	}
+
+	switch {
+	case len(a) < len(b):
+		return -1
+	case len(a) > len(b):
+		return 1
+	}
+	return 0
+}
+
 // HashKeyMD5Base64 generates a base64 encoded MD5 hash of a string
 func HashKeyMD5Base64(key string) string {
 	hash := md5.Sum([]byte(key))
@@ -238,6 +645,43 @@ var TypeRegexMap = map[string]string{
 		`)`,
 }
 
+// ResolveJSONPointer returns the value referenced by an RFC 6901 JSON pointer within a decoded JSON document
+func ResolveJSONPointer(document interface{}, pointer string) (interface{}, error) {
+	if pointer == "" {
+		return document, nil
+	}
+	if !strings.HasPrefix(pointer, "/") {
+		return nil, fmt.Errorf("invalid JSON pointer '%s'", pointer)
+	}
+
+	current := document
+	for _, token := range strings.Split(pointer[1:], "/") {
+		token = strings.ReplaceAll(strings.ReplaceAll(token, "~1", "/"), "~0", "~")
+		switch node := current.(type) {
+		case map[string]interface{}:
+			value, exists := node[token]
+			if !exists {
+				return nil, fmt.Errorf("JSON pointer '%s' not found", pointer)
+			}
+			current = value
+		case []interface{}:
+			index, err := strconv.Atoi(token)
+			if err != nil || index < 0 || index >= len(node) {
+				return nil, fmt.Errorf("JSON pointer '%s' index out of range", pointer)
+			}
+			current = node[index]
+		default:
+			return nil, fmt.Errorf("JSON pointer '%s' not found", pointer)
+		}
+	}
+	return current, nil
+}
+
+// EscapeJSONPointer escapes a single JSON pointer reference token
+func EscapeJSONPointer(token string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(token, "~", "~0"), "/", "~1")
+}
+
 // SanitizeString replaces special characters in a string
 func SanitizeString(value string) string {
 	// Replace slashes with underscores and remove other special characters
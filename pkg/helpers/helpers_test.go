@@ -0,0 +1,44 @@
+package helpers
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPatternCache(t *testing.T) {
+	cache := newPatternCache(2)
+	for _, pattern := range []string{"^a", "^b"} {
+		cache.add(compiledPattern{pattern: pattern, re: regexp.MustCompile(pattern)})
+	}
+	// Using a compilation keeps it over the least recently used one
+	_, ok := cache.get("^a")
+	assert.True(t, ok)
+	cache.add(compiledPattern{pattern: "^c", re: regexp.MustCompile("^c")})
+
+	tests := []struct {
+		pattern string
+		cached  bool
+	}{
+		{pattern: "^a", cached: true},
+		{pattern: "^b", cached: false},
+		{pattern: "^c", cached: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.pattern, func(t *testing.T) {
+			compiled, ok := cache.get(tt.pattern)
+			assert.Equal(t, tt.cached, ok)
+			if tt.cached {
+				assert.Equal(t, tt.pattern, compiled.re.String())
+			}
+		})
+	}
+	assert.Equal(t, 2, cache.order.Len())
+
+	// Invalid patterns are cached with their error
+	_, err := CompilePattern("(")
+	assert.Error(t, err)
+	_, err = CompilePattern("(")
+	assert.Error(t, err)
+}
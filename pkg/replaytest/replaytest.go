@@ -0,0 +1,280 @@
+// Package replaytest records the responses of an upstream into cassettes, JSON files checked into repositories, and
+// replays them, to run end-to-end tests of a gateway without the real backend: a Cassette handler behind the
+// middleware validates live requests and serves the recorded responses.
+package replaytest
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+// Cassette is a sequence of recorded interactions with an upstream
+type Cassette struct {
+	Interactions []Interaction `json:"interactions"`
+
+	mu     sync.Mutex
+	played []bool
+}
+
+// Interaction is a request to the upstream and its response
+type Interaction struct {
+	Request  RecordedRequest  `json:"request"`
+	Response RecordedResponse `json:"response"`
+}
+
+// RecordedRequest is a recorded request. Its headers are not recorded, so that cassettes hold no credentials.
+type RecordedRequest struct {
+	Method string `json:"method"`
+	URL    string `json:"url"` // Path and query of the request
+	Body   Body   `json:"body,omitempty"`
+}
+
+// RecordedResponse is a recorded response. The values of its cookies are redacted, so that cassettes hold no
+// session.
+type RecordedResponse struct {
+	Status int         `json:"status"`
+	Header http.Header `json:"header,omitempty"`
+	Body   Body        `json:"body,omitempty"`
+}
+
+// Body is a recorded body, encoded as a string when it is valid UTF-8 and as base64 otherwise
+type Body []byte
+
+// MarshalJSON encodes the body as a string, or as an object with a `base64` member when it is not valid UTF-8
+func (b Body) MarshalJSON() ([]byte, error) {
+	if utf8.Valid(b) {
+		return json.Marshal(string(b))
+	}
+	return json.Marshal(map[string]string{"base64": base64.StdEncoding.EncodeToString(b)})
+}
+
+// UnmarshalJSON decodes a body encoded by MarshalJSON
+func (b *Body) UnmarshalJSON(data []byte) error {
+	var str string
+	if err := json.Unmarshal(data, &str); err == nil {
+		*b = Body(str)
+		return nil
+	}
+	var encoded struct {
+		Base64 string `json:"base64"`
+	}
+	if err := json.Unmarshal(data, &encoded); err != nil {
+		return fmt.Errorf("body must be a string or a base64 object: %w", err)
+	}
+	decoded, err := base64.StdEncoding.DecodeString(encoded.Base64)
+	if err != nil {
+		return fmt.Errorf("invalid base64 body: %w", err)
+	}
+	*b = decoded
+	return nil
+}
+
+// Load reads a cassette from a file
+func Load(path string) (*Cassette, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read cassette: %w", err)
+	}
+	var cassette Cassette
+	if err := json.Unmarshal(data, &cassette); err != nil {
+		return nil, fmt.Errorf("failed to parse cassette %s: %w", path, err)
+	}
+	return &cassette, nil
+}
+
+// Save writes the cassette to a file, indented to be reviewed in diffs
+func (c *Cassette) Save(path string) error {
+	c.mu.Lock()
+	data, err := json.MarshalIndent(c, "", "  ")
+	c.mu.Unlock()
+	if err != nil {
+		return fmt.Errorf("failed to encode cassette: %w", err)
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0o644); err != nil {
+		return fmt.Errorf("failed to write cassette: %w", err)
+	}
+	return nil
+}
+
+// ServeHTTP replays the response of the first interaction matching the request that was not played yet, or of the
+// last matching one once all were played, e.g. for polling. A request matches an interaction of the same method,
+// path and query, in any order, and of the same body when one was recorded. Requests matching no interaction get
+// a 502 Bad Gateway, like a proxy without upstream.
+func (c *Cassette) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, err := readBody(r)
+	if err != nil {
+		http.Error(w, "failed to read request body", http.StatusBadRequest)
+		return
+	}
+	interaction, found := c.match(r.Method, r.URL, body)
+	if !found {
+		http.Error(w, fmt.Sprintf("replaytest: no recorded interaction for %s %s", r.Method, r.URL.RequestURI()), http.StatusBadGateway)
+		return
+	}
+	for name, values := range interaction.Response.Header {
+		w.Header()[name] = append([]string(nil), values...)
+	}
+	w.WriteHeader(interaction.Response.Status)
+	w.Write(interaction.Response.Body)
+}
+
+// Unplayed returns the interactions that were not replayed, to check that tests sent all the recorded requests
+func (c *Cassette) Unplayed() []Interaction {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var unplayed []Interaction
+	for i, interaction := range c.Interactions {
+		if i >= len(c.played) || !c.played[i] {
+			unplayed = append(unplayed, interaction)
+		}
+	}
+	return unplayed
+}
+
+// match returns the interaction to replay for a request
+func (c *Cassette) match(method string, u *url.URL, body []byte) (Interaction, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(c.played) < len(c.Interactions) {
+		c.played = append(c.played, make([]bool, len(c.Interactions)-len(c.played))...)
+	}
+	last := -1
+	for i, interaction := range c.Interactions {
+		if !matches(interaction.Request, method, u, body) {
+			continue
+		}
+		if !c.played[i] {
+			c.played[i] = true
+			return interaction, true
+		}
+		last = i
+	}
+	if last < 0 {
+		return Interaction{}, false
+	}
+	return c.Interactions[last], true
+}
+
+// matches reports whether a request matches a recorded one
+func matches(recorded RecordedRequest, method string, u *url.URL, body []byte) bool {
+	if recorded.Method != method {
+		return false
+	}
+	recordedURL, err := url.Parse(recorded.URL)
+	if err != nil || recordedURL.Path != u.Path || recordedURL.Query().Encode() != u.Query().Encode() {
+		return false
+	}
+	return len(recorded.Body) == 0 || bytes.Equal(recorded.Body, body)
+}
+
+// Recorder proxies requests to an upstream and records them with their responses
+type Recorder struct {
+	Cassette *Cassette // Interactions recorded so far
+
+	proxy *httputil.ReverseProxy
+}
+
+// NewRecorder returns a recorder proxying to the upstream
+func NewRecorder(upstream *url.URL) *Recorder {
+	r := &Recorder{Cassette: &Cassette{}}
+	r.proxy = &httputil.ReverseProxy{
+		Rewrite: func(pr *httputil.ProxyRequest) {
+			pr.SetURL(upstream)
+			pr.SetXForwarded()
+		},
+		ModifyResponse: r.record,
+	}
+	return r
+}
+
+// ServeHTTP forwards the request to the upstream, recording it with its response
+func (r *Recorder) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	body, err := readBody(req)
+	if err != nil {
+		http.Error(w, "failed to read request body", http.StatusBadRequest)
+		return
+	}
+	r.proxy.ServeHTTP(w, req.WithContext(context.WithValue(req.Context(), incomingKey{}, RecordedRequest{
+		Method: req.Method,
+		URL:    req.URL.RequestURI(),
+		Body:   body,
+	})))
+}
+
+// Save writes the recorded interactions to a file
+func (r *Recorder) Save(path string) error {
+	return r.Cassette.Save(path)
+}
+
+// incomingKey is the context key of the request received by Recorder.ServeHTTP, recorded instead of the forwarded
+// request, whose body is consumed and whose path holds the one of the upstream
+type incomingKey struct{}
+
+// record appends the interaction of a response to the cassette
+func (r *Recorder) record(resp *http.Response) error {
+	request, _ := resp.Request.Context().Value(incomingKey{}).(RecordedRequest)
+	responseBody, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return fmt.Errorf("failed to read upstream response: %w", err)
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(responseBody))
+
+	header := resp.Header.Clone()
+	for _, name := range []string{"Date", "Content-Length", "Connection", "Transfer-Encoding", "Keep-Alive"} {
+		header.Del(name)
+	}
+	for i, cookie := range header.Values("Set-Cookie") {
+		header["Set-Cookie"][i] = redactCookie(cookie)
+	}
+	if len(header) == 0 {
+		header = nil
+	}
+	interaction := Interaction{
+		Request:  request,
+		Response: RecordedResponse{Status: resp.StatusCode, Header: header, Body: responseBody},
+	}
+	r.Cassette.mu.Lock()
+	r.Cassette.Interactions = append(r.Cassette.Interactions, interaction)
+	r.Cassette.mu.Unlock()
+	return nil
+}
+
+// redactedValue replaces the values of the recorded cookies
+const redactedValue = "REDACTED"
+
+// redactCookie replaces the value of a Set-Cookie header, keeping the name and attributes of the cookie
+func redactCookie(cookie string) string {
+	pair, attributes, _ := strings.Cut(cookie, ";")
+	name, _, _ := strings.Cut(pair, "=")
+	redacted := strings.TrimSpace(name) + "=" + redactedValue
+	if attributes != "" {
+		redacted += ";" + attributes
+	}
+	return redacted
+}
+
+// readBody returns the body of a request, and replaces it with a copy to be read again
+func readBody(r *http.Request) ([]byte, error) {
+	if r.Body == nil || r.Body == http.NoBody {
+		return nil, nil
+	}
+	body, err := io.ReadAll(r.Body)
+	r.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	r.Body = io.NopCloser(bytes.NewReader(body))
+	return body, nil
+}
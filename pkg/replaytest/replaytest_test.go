@@ -0,0 +1,124 @@
+package replaytest
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRecordAndReplay(t *testing.T) {
+	var polls atomic.Int32
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/pets":
+			w.Header().Set("Content-Type", "application/json")
+			if r.Method == http.MethodPost {
+				body, _ := io.ReadAll(r.Body)
+				w.WriteHeader(http.StatusCreated)
+				w.Write(body)
+				return
+			}
+			w.Write([]byte(`[{"name": "Fluffy", "poll": ` + string(rune('0'+polls.Add(1))) + `}]`))
+		case "/api/login":
+			http.SetCookie(w, &http.Cookie{Name: "session", Value: "s3cr3t", Path: "/", HttpOnly: true})
+			w.WriteHeader(http.StatusNoContent)
+		case "/api/photo":
+			w.Header().Set("Content-Type", "image/png")
+			w.Write([]byte{0x89, 'P', 'N', 'G', 0xff})
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer upstream.Close()
+	upstreamURL, err := url.Parse(upstream.URL + "/api")
+	assert.NoError(t, err)
+
+	send := func(handler http.Handler, method, target, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, target, strings.NewReader(body))
+		req.Header.Set("Authorization", "Bearer secret")
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+		return rr
+	}
+
+	recorder := NewRecorder(upstreamURL)
+	assert.Equal(t, http.StatusCreated, send(recorder, http.MethodPost, "/pets", `{"name": "Fluffy"}`).Code)
+	assert.Equal(t, http.StatusCreated, send(recorder, http.MethodPost, "/pets", `{"name": "Rex"}`).Code)
+	assert.JSONEq(t, `[{"name": "Fluffy", "poll": 1}]`, send(recorder, http.MethodGet, "/pets?limit=1&sort=name", "").Body.String())
+	assert.JSONEq(t, `[{"name": "Fluffy", "poll": 2}]`, send(recorder, http.MethodGet, "/pets?limit=1&sort=name", "").Body.String())
+	assert.Equal(t, http.StatusOK, send(recorder, http.MethodGet, "/photo", "").Code)
+	assert.Equal(t, "session=s3cr3t; Path=/; HttpOnly", send(recorder, http.MethodPost, "/login", "").Header().Get("Set-Cookie"))
+
+	path := filepath.Join(t.TempDir(), "pets.json")
+	assert.NoError(t, recorder.Save(path))
+	assert.Equal(t, "/pets", recorder.Cassette.Interactions[0].Request.URL)
+	assert.Nil(t, recorder.Cassette.Interactions[0].Response.Header["Date"])
+	cassette, err := Load(path)
+	assert.NoError(t, err)
+	assert.Len(t, cassette.Interactions, 6)
+	assert.Len(t, cassette.Unplayed(), 6)
+
+	// Cookies are recorded without their value
+	saved, err := os.ReadFile(path)
+	assert.NoError(t, err)
+	assert.NotContains(t, string(saved), "s3cr3t")
+	assert.Equal(t, "session=REDACTED; Path=/; HttpOnly", cassette.Interactions[5].Response.Header.Get("Set-Cookie"))
+	upstream.Close()
+
+	tests := []struct {
+		name   string
+		method string
+		target string
+		body   string
+		status int
+		want   string
+	}{
+		{name: "Matching body", method: http.MethodPost, target: "/pets", body: `{"name": "Rex"}`, status: http.StatusCreated, want: `{"name": "Rex"}`},
+		{name: "Other body", method: http.MethodPost, target: "/pets", body: `{"name": "Felix"}`, status: http.StatusBadGateway},
+		{name: "Query in any order", method: http.MethodGet, target: "/pets?sort=name&limit=1", status: http.StatusOK, want: `[{"name": "Fluffy", "poll": 1}]`},
+		{name: "Next interaction", method: http.MethodGet, target: "/pets?limit=1&sort=name", status: http.StatusOK, want: `[{"name": "Fluffy", "poll": 2}]`},
+		{name: "Last interaction replayed", method: http.MethodGet, target: "/pets?limit=1&sort=name", status: http.StatusOK, want: `[{"name": "Fluffy", "poll": 2}]`},
+		{name: "Other query", method: http.MethodGet, target: "/pets?limit=2&sort=name", status: http.StatusBadGateway},
+		{name: "Other method", method: http.MethodDelete, target: "/pets", status: http.StatusBadGateway},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rr := send(cassette, tt.method, tt.target, tt.body)
+			assert.Equal(t, tt.status, rr.Code, rr.Body.String())
+			if tt.want != "" {
+				assert.JSONEq(t, tt.want, rr.Body.String())
+				assert.Equal(t, "application/json", rr.Header().Get("Content-Type"))
+			}
+		})
+	}
+
+	photo := send(cassette, http.MethodGet, "/photo", "")
+	assert.Equal(t, []byte{0x89, 'P', 'N', 'G', 0xff}, photo.Body.Bytes())
+	assert.Equal(t, "image/png", photo.Header().Get("Content-Type"))
+	login := send(cassette, http.MethodPost, "/login", "")
+	assert.Equal(t, "session=REDACTED; Path=/; HttpOnly", login.Header().Get("Set-Cookie"))
+
+	unplayed := cassette.Unplayed()
+	assert.Len(t, unplayed, 1)
+	assert.Equal(t, Body(`{"name": "Fluffy"}`), unplayed[0].Request.Body)
+}
+
+func TestLoadErrors(t *testing.T) {
+	_, err := Load(filepath.Join(t.TempDir(), "missing.json"))
+	assert.ErrorContains(t, err, "failed to read cassette")
+
+	var body Body
+	assert.Error(t, body.UnmarshalJSON([]byte(`{"base64": "not base64!"}`)))
+	assert.Error(t, body.UnmarshalJSON([]byte(`42`)))
+	assert.NoError(t, body.UnmarshalJSON([]byte(`{"base64": "iVBORw=="}`)))
+	assert.Equal(t, Body{0x89, 'P', 'N', 'G'}, body)
+}
@@ -0,0 +1,247 @@
+// Package securitytest provides test doubles of identity providers, to write integration tests of the security of
+// APIs without a real one: an Issuer mints JWT and opaque tokens and serves their JWKS and RFC 7662 introspection
+// endpoints from an httptest server.
+package securitytest
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/lionelgarnier/validate-api-request/pkg/clock"
+)
+
+// Claims are the claims of a token, e.g. `sub` or `scope`
+type Claims map[string]interface{}
+
+// Issuer is a fake identity provider. Tokens are valid for an hour unless their claims set `exp`, and introspection
+// reports them active until they expire or are revoked.
+type Issuer struct {
+	URL   string      // Base URL of the server, also the `iss` claim of the tokens
+	Clock clock.Clock // Clock of the `iat` and `exp` claims and of the expiry of introspected tokens
+
+	server  *httptest.Server
+	key     *rsa.PrivateKey
+	keyID   string
+	mu      sync.Mutex
+	opaque  map[string]Claims
+	revoked map[string]bool
+}
+
+// NewIssuer starts an issuer, which is closed with Close. It serves:
+//   - /.well-known/openid-configuration, the discovery document
+//   - /.well-known/jwks.json, the public key of the JWT tokens
+//   - /introspect, the introspection of JWT and opaque tokens posted as the `token` form value
+func NewIssuer() *Issuer {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		panic(fmt.Sprintf("securitytest: failed to generate key: %v", err))
+	}
+	i := &Issuer{
+		Clock:   clock.Real(),
+		key:     key,
+		keyID:   randomString(8),
+		opaque:  make(map[string]Claims),
+		revoked: make(map[string]bool),
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", i.serveDiscovery)
+	mux.HandleFunc("/.well-known/jwks.json", i.serveJWKS)
+	mux.HandleFunc("/introspect", i.serveIntrospection)
+	i.server = httptest.NewServer(mux)
+	i.URL = i.server.URL
+	return i
+}
+
+// Close stops the server of the issuer
+func (i *Issuer) Close() {
+	i.server.Close()
+}
+
+// JWKSURL returns the URL of the JSON Web Key Set of the issuer
+func (i *Issuer) JWKSURL() string {
+	return i.URL + "/.well-known/jwks.json"
+}
+
+// IntrospectionURL returns the URL of the introspection endpoint of the issuer
+func (i *Issuer) IntrospectionURL() string {
+	return i.URL + "/introspect"
+}
+
+// JWT returns a token signed with RS256 carrying the claims, completed with the `iss`, `iat` and `exp` claims they
+// do not set
+func (i *Issuer) JWT(claims Claims) string {
+	header := map[string]string{"alg": "RS256", "typ": "JWT", "kid": i.keyID}
+	signingInput := encodeSegment(header) + "." + encodeSegment(i.complete(claims))
+
+	digest := sha256.Sum256([]byte(signingInput))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, i.key, crypto.SHA256, digest[:])
+	if err != nil {
+		panic(fmt.Sprintf("securitytest: failed to sign token: %v", err))
+	}
+	return signingInput + "." + base64.RawURLEncoding.EncodeToString(signature)
+}
+
+// Opaque returns a random opaque token, whose claims are only known by introspection
+func (i *Issuer) Opaque(claims Claims) string {
+	token := randomString(32)
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	i.opaque[token] = i.complete(claims)
+	return token
+}
+
+// Revoke makes introspection report a token inactive
+func (i *Issuer) Revoke(token string) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	i.revoked[token] = true
+}
+
+// Introspect returns the claims of a token minted by the issuer and whether it is active
+func (i *Issuer) Introspect(token string) (Claims, bool) {
+	i.mu.Lock()
+	claims, opaque := i.opaque[token]
+	revoked := i.revoked[token]
+	i.mu.Unlock()
+
+	if !opaque {
+		var ok bool
+		if claims, ok = i.verify(token); !ok {
+			return nil, false
+		}
+	}
+	exp, _ := claims["exp"].(float64)
+	if revoked || float64(i.Clock.Now().Unix()) >= exp {
+		return nil, false
+	}
+	return claims, true
+}
+
+// complete returns a copy of the claims with the `iss`, `iat` and `exp` claims they do not set
+func (i *Issuer) complete(claims Claims) Claims {
+	now := i.Clock.Now()
+	completed := Claims{
+		"iss": i.URL,
+		"iat": float64(now.Unix()),
+		"exp": float64(now.Add(time.Hour).Unix()),
+	}
+	for name, value := range claims {
+		completed[name] = value
+	}
+	// Claims go through JSON as they would in a real token
+	content, _ := json.Marshal(completed)
+	var decoded Claims
+	json.Unmarshal(content, &decoded)
+	return decoded
+}
+
+// verify returns the claims of a JWT signed by the issuer
+func (i *Issuer) verify(token string) (Claims, bool) {
+	header, payload, signature, ok := splitJWT(token)
+	if !ok {
+		return nil, false
+	}
+	digest := sha256.Sum256([]byte(header + "." + payload))
+	if rsa.VerifyPKCS1v15(&i.key.PublicKey, crypto.SHA256, digest[:], signature) != nil {
+		return nil, false
+	}
+
+	content, err := base64.RawURLEncoding.DecodeString(payload)
+	if err != nil {
+		return nil, false
+	}
+	var claims Claims
+	if json.Unmarshal(content, &claims) != nil {
+		return nil, false
+	}
+	return claims, true
+}
+
+// serveDiscovery serves the OpenID Connect discovery document of the issuer
+func (i *Issuer) serveDiscovery(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, map[string]interface{}{
+		"issuer":                                i.URL,
+		"jwks_uri":                              i.JWKSURL(),
+		"introspection_endpoint":                i.IntrospectionURL(),
+		"id_token_signing_alg_values_supported": []string{"RS256"},
+	})
+}
+
+// serveJWKS serves the public key of the issuer as a JSON Web Key Set (RFC 7517)
+func (i *Issuer) serveJWKS(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, map[string]interface{}{
+		"keys": []map[string]string{{
+			"kty": "RSA",
+			"use": "sig",
+			"alg": "RS256",
+			"kid": i.keyID,
+			"n":   base64.RawURLEncoding.EncodeToString(i.key.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(i.key.E)).Bytes()),
+		}},
+	})
+}
+
+// serveIntrospection serves the introspection of the token posted as a form value (RFC 7662)
+func (i *Issuer) serveIntrospection(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	claims, active := i.Introspect(r.PostFormValue("token"))
+	if !active {
+		writeJSON(w, map[string]interface{}{"active": false})
+		return
+	}
+
+	response := map[string]interface{}{"active": true, "token_type": "Bearer"}
+	for name, value := range claims {
+		response[name] = value
+	}
+	writeJSON(w, response)
+}
+
+// splitJWT returns the encoded header and payload and the decoded signature of a JWT
+func splitJWT(token string) (string, string, []byte, bool) {
+	segments := strings.Split(token, ".")
+	if len(segments) != 3 {
+		return "", "", nil, false
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(segments[2])
+	if err != nil {
+		return "", "", nil, false
+	}
+	return segments[0], segments[1], signature, true
+}
+
+// encodeSegment returns a value as a base64url encoded JSON segment of a JWT
+func encodeSegment(value interface{}) string {
+	content, _ := json.Marshal(value)
+	return base64.RawURLEncoding.EncodeToString(content)
+}
+
+// writeJSON writes a value as a JSON response
+func writeJSON(w http.ResponseWriter, value interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(value)
+}
+
+// randomString returns a random hexadecimal string of 2*n characters
+func randomString(n int) string {
+	b := make([]byte, n)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
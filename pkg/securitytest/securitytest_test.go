@@ -0,0 +1,57 @@
+package securitytest
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/lionelgarnier/validate-api-request/pkg/clock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIssuer(t *testing.T) {
+	issuer := NewIssuer()
+	defer issuer.Close()
+	mock := clock.NewMock(time.Unix(1700000000, 0))
+	issuer.Clock = mock
+
+	introspect := func(token string) map[string]interface{} {
+		resp, err := http.PostForm(issuer.IntrospectionURL(), url.Values{"token": {token}})
+		assert.NoError(t, err)
+		defer resp.Body.Close()
+		var result map[string]interface{}
+		assert.NoError(t, json.NewDecoder(resp.Body).Decode(&result))
+		return result
+	}
+
+	jwt := issuer.JWT(Claims{"sub": "alice", "scope": "read"})
+	result := introspect(jwt)
+	assert.Equal(t, true, result["active"])
+	assert.Equal(t, "alice", result["sub"])
+	assert.Equal(t, issuer.URL, result["iss"])
+
+	opaque := issuer.Opaque(Claims{"sub": "bob"})
+	assert.Equal(t, "bob", introspect(opaque)["sub"])
+
+	assert.Equal(t, map[string]interface{}{"active": false}, introspect(jwt[:len(jwt)-4]+"AAAA"))
+	assert.Equal(t, map[string]interface{}{"active": false}, introspect("unknown"))
+
+	issuer.Revoke(opaque)
+	assert.Equal(t, false, introspect(opaque)["active"])
+
+	mock.Advance(time.Hour)
+	assert.Equal(t, false, introspect(jwt)["active"])
+
+	resp, err := http.Get(issuer.JWKSURL())
+	assert.NoError(t, err)
+	defer resp.Body.Close()
+	var jwks struct {
+		Keys []map[string]string `json:"keys"`
+	}
+	assert.NoError(t, json.NewDecoder(resp.Body).Decode(&jwks))
+	assert.Len(t, jwks.Keys, 1)
+	assert.Equal(t, "RS256", jwks.Keys[0]["alg"])
+	assert.Equal(t, "AQAB", jwks.Keys[0]["e"])
+}
@@ -0,0 +1,163 @@
+package validation
+
+import (
+	"cmp"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/lionelgarnier/validate-api-request/oas"
+)
+
+// DefaultAttestationHeader is the header carrying the attestations of validated requests
+const DefaultAttestationHeader = "X-OAS-Validated"
+
+// Attestation signs the requests a validator accepts, in a header trusted by the validators of the next hops of a
+// gateway chain sharing its key, so that they do not validate them again. The signature is an HMAC-SHA256 of the
+// name and spec hash of the API, of the method, path, query, Content-Type essence, declared header and cookie
+// parameters and body hash of the request and of the signing time, e.g. `t=1700000000, sig=9f86d0...`: attestations
+// cannot be forged without the key, nor moved to another request, API or version of its spec, and they expire after
+// maxAge. Other headers and cookies are not signed, as proxies rewrite them, so the security of trusted requests is
+// validated again, as are their undeclared headers with WithStrictHeaders and their parameter locations with
+// WithParameterPinning.
+type Attestation struct {
+	key    []byte
+	header string
+	maxAge time.Duration
+	sign   bool
+	trust  bool
+}
+
+// NewAttestation returns an attestation signing the accepted requests when sign is set, and trusting the
+// attestations of the previous hops when trust is set, in the header, DefaultAttestationHeader when empty.
+// Attestations older than maxAge, or signed more than maxAge in the future, are not trusted.
+func NewAttestation(key []byte, header string, maxAge time.Duration, sign, trust bool) *Attestation {
+	if header == "" {
+		header = DefaultAttestationHeader
+	}
+	return &Attestation{key: key, header: http.CanonicalHeaderKey(header), maxAge: maxAge, sign: sign, trust: trust}
+}
+
+// WithAttestation signs the accepted requests and trusts the attestations of the previous hops as configured
+func WithAttestation(attestation *Attestation) Option {
+	return func(v *DefaultValidator) {
+		v.attestation = attestation
+	}
+}
+
+// carried reports whether the request carries an attestation to check
+func (a *Attestation) carried(req *oas.OASRequest) bool {
+	return a != nil && a.trust && req.Request.Header.Get(a.header) != ""
+}
+
+// trusted reports whether the request carries a valid attestation of a previous hop for its declared header and
+// cookie parameters, reading bodies of up to maxBody bytes, 0 for no limit, to check it
+func (a *Attestation) trusted(req *oas.OASRequest, spec *oas.APISpec, parameters []oas.Parameter, now time.Time, maxBody int64) bool {
+	if !a.carried(req) {
+		return false
+	}
+	value := req.Request.Header.Get(a.header)
+	var timestamp, signature string
+	for _, field := range strings.Split(value, ",") {
+		name, fieldValue, _ := strings.Cut(strings.TrimSpace(field), "=")
+		switch name {
+		case "t":
+			timestamp = fieldValue
+		case "sig":
+			signature = fieldValue
+		}
+	}
+	seconds, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return false
+	}
+	if age := now.Sub(time.Unix(seconds, 0)); age > a.maxAge || age < -a.maxAge {
+		return false
+	}
+	expected, err := a.signature(req.Request, spec, parameters, timestamp, maxBody)
+	if err != nil {
+		return false
+	}
+	decoded, err := hex.DecodeString(signature)
+	return err == nil && hmac.Equal(decoded, expected)
+}
+
+// attest sets the attestation header of a request: signed when the request was accepted and attestations are
+// signed, kept when it carried a trusted attestation, and removed otherwise, so that unverified attestations are
+// not forwarded. Bodies are read up to maxBody bytes, 0 for no limit, larger ones are not signed.
+func (a *Attestation) attest(req *oas.OASRequest, spec *oas.APISpec, parameters []oas.Parameter, ok, trusted bool, now time.Time, maxBody int64) {
+	if a == nil {
+		return
+	}
+	switch {
+	case ok && a.sign:
+		timestamp := strconv.FormatInt(now.Unix(), 10)
+		if signature, err := a.signature(req.Request, spec, parameters, timestamp, maxBody); err == nil {
+			req.Request.Header.Set(a.header, fmt.Sprintf("t=%s, sig=%x", timestamp, signature))
+			return
+		}
+	case ok && trusted:
+		return
+	}
+	req.Request.Header.Del(a.header)
+}
+
+// signature returns the HMAC of a request to the API of a spec and of the values of its header and cookie parameters
+// signed at the timestamp, reading its body up to maxBody bytes, 0 for no limit
+func (a *Attestation) signature(r *http.Request, spec *oas.APISpec, parameters []oas.Parameter, timestamp string, maxBody int64) ([]byte, error) {
+	body, exceeded, err := bufferBodyUpTo(r, maxBody)
+	if err != nil {
+		return nil, err
+	}
+	if exceeded {
+		return nil, fmt.Errorf("request body exceeds %d bytes", maxBody)
+	}
+	bodyHash := sha256.Sum256(body)
+	mac := hmac.New(sha256.New, a.key)
+	essence, _ := parseMediaType(r.Header.Get("Content-Type"))
+	fmt.Fprintf(mac, "%q\x00%016x\x00%s\x00%s\x00%s\x00%s\x00%x\x00%s", spec.Name, spec.Hash(), r.Method, r.URL.EscapedPath(),
+		r.URL.RawQuery, essence, bodyHash, timestamp)
+	for _, param := range parameters {
+		var values []string
+		switch param.In {
+		case "header":
+			values = r.Header.Values(param.Name)
+		case "cookie":
+			for _, cookie := range r.Cookies() {
+				if cookie.Name == param.Name {
+					values = append(values, cookie.Value)
+				}
+			}
+		default:
+			continue
+		}
+		// Values are quoted, so that values of a parameter cannot be moved to another one
+		fmt.Fprintf(mac, "\x00%s %q %q", param.In, param.Name, values)
+	}
+	return mac.Sum(nil), nil
+}
+
+// signedParameters returns the header and cookie parameters of the operation of a request, sorted by location and
+// name, which attestations sign. Requests without operation have none.
+func (v *DefaultValidator) signedParameters(req *oas.OASRequest) []oas.Parameter {
+	if req.PathItem == nil || req.Operation == nil {
+		return nil
+	}
+	parameters, err := v.operationParameters(req)
+	if err != nil {
+		return nil
+	}
+	parameters = slices.DeleteFunc(parameters, func(param oas.Parameter) bool {
+		return param.In != "header" && param.In != "cookie"
+	})
+	slices.SortFunc(parameters, func(a, b oas.Parameter) int {
+		return cmp.Or(cmp.Compare(a.In, b.In), cmp.Compare(http.CanonicalHeaderKey(a.Name), http.CanonicalHeaderKey(b.Name)))
+	})
+	return parameters
+}
@@ -0,0 +1,197 @@
+package validation
+
+import (
+	"encoding/hex"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/lionelgarnier/validate-api-request/oas"
+	"github.com/lionelgarnier/validate-api-request/pkg/clock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAttestation(t *testing.T) {
+	manager := oas.NewOASManager(nil, oas.FixedSelector(map[string]string{"test": "test"}))
+	err := manager.LoadAPI("test", []byte(`{
+        "openapi": "3.0.0",
+        "info": {"title": "Test API", "version": "1.0.0"},
+        "paths": {
+            "/pets": {
+                "post": {
+                    "parameters": [
+                        {"name": "dryRun", "in": "query", "schema": {"type": "boolean"}},
+                        {"name": "X-Tenant", "in": "header", "schema": {"type": "string"}}
+                    ],
+                    "requestBody": {
+                        "content": {"application/json": {"schema": {"type": "object", "required": ["name"]}}}
+                    },
+                    "security": [{"apiKey": []}]
+                }
+            },
+            "/files": {
+                "post": {"requestBody": {"content": {"application/octet-stream": {}}}}
+            }
+        },
+        "components": {
+            "securitySchemes": {"apiKey": {"type": "apiKey", "in": "header", "name": "X-API-Key"}}
+        }
+    }`))
+	assert.NoError(t, err)
+	spec, _ := manager.GetApiSpec("test")
+
+	key := []byte("0123456789abcdef0123456789abcdef")
+	clk := clock.NewMock(time.Unix(1700000000, 0))
+	first := NewValidator(spec, WithClock(clk), WithAttestation(NewAttestation(key, "", time.Minute, true, false)))
+	last := NewValidator(spec, WithClock(clk), WithAttestation(NewAttestation(key, "", time.Minute, false, true)))
+
+	newRequest := func(target, body, attestation string) *oas.OASRequest {
+		req, err := http.NewRequest(http.MethodPost, target, strings.NewReader(body))
+		assert.NoError(t, err)
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("X-API-Key", "secret")
+		req.Header.Set("X-Tenant", "acme")
+		if attestation != "" {
+			req.Header.Set(DefaultAttestationHeader, attestation)
+		}
+		return oas.NewOASRequest(req)
+	}
+
+	// The first hop signs the requests it accepts, and removes the attestations it did not sign
+	signed := newRequest("/pets?dryRun=true", `{"name": "Rex"}`, "")
+	ok, err := first.ValidateRequest(signed)
+	assert.True(t, ok)
+	assert.NoError(t, err)
+	attestation := signed.Request.Header.Get(DefaultAttestationHeader)
+	assert.Regexp(t, `^t=1700000000, sig=[0-9a-f]{64}$`, attestation)
+	body, _ := io.ReadAll(signed.Request.Body)
+	assert.JSONEq(t, `{"name": "Rex"}`, string(body))
+
+	rejected := newRequest("/pets", `{}`, attestation)
+	ok, _ = first.ValidateRequest(rejected)
+	assert.False(t, ok)
+	assert.Empty(t, rejected.Request.Header.Get(DefaultAttestationHeader))
+
+	// The last hop trusts the attestations of the exact requests they were signed for, even invalid ones
+	clk.Advance(30 * time.Second)
+
+	tests := []struct {
+		name        string
+		target      string
+		body        string
+		header      string
+		value       string
+		attestation string
+		advance     time.Duration
+		ok          bool
+	}{
+		{name: "Trusted attestation", target: "/pets?dryRun=true", body: `{"name": "Rex"}`, attestation: attestation, ok: true},
+		{name: "Trusted invalid request", target: "/pets?dryRun=true", body: `{"name": 1}`, attestation: signRequest(t, first, "/pets?dryRun=true", `{"name": 1}`), ok: true},
+		{name: "Other body", target: "/pets?dryRun=true", body: `{}`, attestation: attestation, ok: false},
+		{name: "Other query", target: "/pets?dryRun=maybe", body: `{"name": "Rex"}`, attestation: attestation, ok: false},
+		{name: "Forged signature", target: "/pets", body: `{}`, attestation: "t=1700000030, sig=" + strings.Repeat("0", 64), ok: false},
+		{name: "Malformed attestation", target: "/pets", body: `{}`, attestation: "valid", ok: false},
+		{name: "Other header parameter", target: "/pets?dryRun=true", body: `{}`, header: "X-Tenant", value: "globex", attestation: signRequest(t, first, "/pets?dryRun=true", `{}`), ok: false},
+		{name: "Same header parameter", target: "/pets?dryRun=true", body: `{}`, attestation: signRequest(t, first, "/pets?dryRun=true", `{}`), ok: true},
+		{name: "Other content type", target: "/pets?dryRun=true", body: `{"name": "Rex"}`, header: "Content-Type", value: "application/merge-patch+json", attestation: attestation, ok: false},
+		{name: "Content type parameters", target: "/pets?dryRun=true", body: `{"name": "Rex"}`, header: "Content-Type", value: "application/json; charset=utf-8", attestation: attestation, ok: true},
+		{name: "Other undeclared header", target: "/pets?dryRun=true", body: `{"name": "Rex"}`, header: "X-Forwarded-For", value: "10.0.0.1", attestation: attestation, ok: true},
+		{name: "Missing credentials", target: "/pets?dryRun=true", body: `{"name": "Rex"}`, header: "X-API-Key", attestation: attestation, ok: false},
+		{name: "Expired attestation", target: "/pets?dryRun=true", body: `{}`, attestation: signRequest(t, first, "/pets?dryRun=true", `{}`), advance: 2 * time.Minute, ok: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clk.Advance(tt.advance)
+			req := newRequest(tt.target, tt.body, tt.attestation)
+			if tt.header != "" {
+				req.Request.Header.Set(tt.header, tt.value)
+			}
+			ok, err := last.ValidateRequest(req)
+			assert.Equal(t, tt.ok, ok, err)
+			if tt.ok {
+				assert.NotNil(t, req.Operation)
+				assert.Equal(t, tt.attestation, req.Request.Header.Get(DefaultAttestationHeader))
+			} else {
+				assert.Empty(t, req.Request.Header.Get(DefaultAttestationHeader))
+			}
+		})
+	}
+
+	// Bodies are read up to the buffer limit to be signed, larger ones are accepted without attestation
+	bounded := NewValidator(spec, WithClock(clk), WithMaxBodyBuffer(4), WithAttestation(NewAttestation(key, "", time.Minute, true, false)))
+	upload, err := http.NewRequest(http.MethodPost, "/files", strings.NewReader("0123456789"))
+	assert.NoError(t, err)
+	upload.Header.Set("Content-Type", "application/octet-stream")
+	upload.Header.Set(DefaultAttestationHeader, attestation)
+	ok, err = bounded.ValidateRequest(oas.NewOASRequest(upload))
+	assert.True(t, ok)
+	assert.NoError(t, err)
+	assert.Empty(t, upload.Header.Get(DefaultAttestationHeader))
+	body, _ = io.ReadAll(upload.Body)
+	assert.Equal(t, "0123456789", string(body))
+
+	// Attestations are signed for the API and the spec of the validator
+	assert.NoError(t, manager.LoadAPI("other", []byte(`{
+        "openapi": "3.0.0",
+        "info": {"title": "Other API", "version": "1.0.0"},
+        "paths": {
+            "/pets": {
+                "post": {
+                    "parameters": [{"name": "X-Tenant", "in": "header", "schema": {"type": "string"}}],
+                    "requestBody": {
+                        "content": {"application/json": {"schema": {"type": "object", "required": ["id"]}}}
+                    }
+                }
+            }
+        }
+    }`)))
+	otherSpec, _ := manager.GetApiSpec("other")
+	other := NewValidator(otherSpec, WithClock(clk), WithAttestation(NewAttestation(key, "", time.Minute, false, true)))
+	attested := newRequest("/pets", `{"name": "Rex"}`, signRequest(t, first, "/pets", `{"name": "Rex"}`))
+	ok, _ = other.ValidateRequest(attested)
+	assert.False(t, ok)
+	assert.Empty(t, attested.Request.Header.Get(DefaultAttestationHeader))
+
+	// Undeclared headers and parameter locations are checked again, as they are not signed
+	strict := NewValidator(spec, WithClock(clk), WithStrictHeaders(true), WithParameterPinning(true),
+		WithAttestation(NewAttestation(key, "", time.Minute, false, true)))
+	attestation = signRequest(t, first, "/pets?dryRun=true", `{"name": "Rex"}`)
+	ok, err = strict.ValidateRequest(newRequest("/pets?dryRun=true", `{"name": "Rex"}`, attestation))
+	assert.True(t, ok)
+	assert.NoError(t, err)
+	undeclared := newRequest("/pets?dryRun=true", `{"name": "Rex"}`, attestation)
+	undeclared.Request.Header.Set("X-Debug", "true")
+	ok, err = strict.ValidateRequest(undeclared)
+	assert.False(t, ok)
+	assert.ErrorContains(t, err, "header 'X-Debug' is not declared")
+	assert.Equal(t, StageParameters, StageOf(err))
+	moved := newRequest("/pets?dryRun=true", `{"name": "Rex"}`, attestation)
+	moved.Request.Header.Set("DryRun", "false")
+	ok, err = strict.ValidateRequest(moved)
+	assert.False(t, ok)
+	assert.Equal(t, StageParameters, StageOf(err))
+
+	// Trusted requests still need a known operation
+	unknown := newRequest("/owners", `{}`, signRequest(t, first, "/owners", `{}`))
+	ok, err = last.ValidateRequest(unknown)
+	assert.False(t, ok)
+	assert.ErrorContains(t, err, "path")
+}
+
+// signRequest returns the attestation a validator signs for a request, which it would not accept otherwise
+func signRequest(t *testing.T, v Validator, target, body string) string {
+	r, err := http.NewRequest(http.MethodPost, target, strings.NewReader(body))
+	assert.NoError(t, err)
+	r.Header.Set("Content-Type", "application/json")
+	r.Header.Set("X-Tenant", "acme")
+	req := oas.NewOASRequest(r)
+	validator := v.(*DefaultValidator)
+	validator.resolveOperation(req)
+	timestamp := "1700000030"
+	signature, err := validator.attestation.signature(r, validator.apiSpec, validator.signedParameters(req), timestamp, 0)
+	assert.NoError(t, err)
+	return "t=" + timestamp + ", sig=" + hex.EncodeToString(signature)
+}
@@ -0,0 +1,62 @@
+package validation
+
+import (
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+
+	"github.com/lionelgarnier/validate-api-request/oas"
+)
+
+// bearerFormats match the surface of the bearer tokens of each `bearerFormat`, lowercased, so that malformed
+// tokens are rejected without verifying them. Tokens of other formats are not checked.
+var bearerFormats = map[string]*regexp.Regexp{
+	// Three base64url segments, header, payload and signature, the signature being empty for unsecured JWTs
+	"jwt": regexp.MustCompile(`^[A-Za-z0-9_-]+\.[A-Za-z0-9_-]+\.[A-Za-z0-9_-]*$`),
+	// Version, purpose, base64url payload and optional footer, e.g. `v4.public.payload`
+	"paseto": regexp.MustCompile(`^v[1-4]\.(local|public)\.[A-Za-z0-9_-]+(\.[A-Za-z0-9_-]+)?$`),
+	// The token68 characters of RFC 7235, long enough not to be a placeholder
+	"opaque": regexp.MustCompile(`^[A-Za-z0-9._~+/-]{16,}=*$`),
+}
+
+// bearerToken returns the token of a bearer Authorization header, and whether the header is one
+func bearerToken(r *http.Request) (string, bool) {
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	return strings.TrimSpace(token), ok
+}
+
+// checkBearerFormat returns the failure of a bearer token which does not have the surface of the `bearerFormat`
+// of its security scheme
+func checkBearerFormat(token string, secScheme *oas.SecurityScheme) error {
+	pattern, exists := bearerFormats[strings.ToLower(secScheme.BearerFormat)]
+	if !exists || pattern.MatchString(token) {
+		return nil
+	}
+	return fmt.Errorf("bearer token is not a well-formed %s token", secScheme.BearerFormat)
+}
+
+// malformedBearerToken returns the failure of the first bearer token of the request which does not have the format of
+// a bearer scheme of the requirements, nil when the requirements failed for other reasons
+func (v *DefaultValidator) malformedBearerToken(r *http.Request, securityRequirements []oas.SecurityRequirement) error {
+	token, ok := bearerToken(r)
+	if !ok || v.apiSpec.Components == nil {
+		return nil
+	}
+	for _, secReq := range securityRequirements {
+		for name := range secReq {
+			secScheme, exists := v.apiSpec.Components.SecuritySchemes[name]
+			if !exists {
+				continue
+			}
+			secScheme, err := v.apiSpec.ResolveSecurityScheme(secScheme)
+			if err != nil || secScheme.Type != "http" || !strings.EqualFold(secScheme.Scheme, "bearer") {
+				continue
+			}
+			if err := checkBearerFormat(token, secScheme); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
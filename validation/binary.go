@@ -0,0 +1,119 @@
+package validation
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+
+	"github.com/lionelgarnier/validate-api-request/oas"
+)
+
+// WithMaxBinaryBody sets the maximum size in bytes of binary request bodies, 0 for no limit
+func WithMaxBinaryBody(size int64) Option {
+	return func(v *DefaultValidator) {
+		v.maxBinaryBody = size
+	}
+}
+
+// isBinaryBody reports whether a request body of the media type is binary: `application/octet-stream` or described
+// by a `type: string, format: binary` schema, without a decoder registered for its content type
+func (v *DefaultValidator) isBinaryBody(contentType string, mediaType oas.MediaType) bool {
+	if _, registered := v.registeredDecoder(contentType); registered {
+		return false
+	}
+	if essence, _ := parseMediaType(contentType); essence == "application/octet-stream" {
+		return true
+	}
+	schema := v.binarySchema(mediaType)
+	return schema != nil && schema.Type == "string" && schema.Format == "binary"
+}
+
+// binarySchema returns the schema of a binary body, resolving its reference
+func (v *DefaultValidator) binarySchema(mediaType oas.MediaType) *oas.Schema {
+	schema := mediaType.Schema
+	if schema != nil && schema.Ref != "" {
+		resolved, err := v.resolveSchemaReference(schema.Ref)
+		if err != nil {
+			return nil
+		}
+		schema = resolved
+	}
+	return schema
+}
+
+// validateBinaryBody checks the length of a binary body in bytes against the `minLength` and `maxLength` of its
+// schema and the configured maximum, without decoding it. Bodies of known length are passed through untouched,
+// without being read; bodies of unknown length are buffered up to their maximum, or their minimum length without
+// maximum.
+func (v *DefaultValidator) validateBinaryBody(req *oas.OASRequest, mediaType oas.MediaType, mediaTypePointer string) (bool, error) {
+	var minLength, maxLength *uint64
+	if schema := v.binarySchema(mediaType); schema != nil {
+		minLength, maxLength = schema.MinLength, schema.MaxLength
+	}
+	if minLength == nil && maxLength == nil && v.maxBinaryBody <= 0 {
+		return true, nil
+	}
+
+	length := req.Request.ContentLength
+	if length < 0 {
+		limit := int64(-1)
+		if v.maxBinaryBody > 0 {
+			limit = v.maxBinaryBody
+		}
+		if maxLength != nil && (limit < 0 || int64(*maxLength) < limit) {
+			limit = int64(*maxLength)
+		}
+		// Without maximum, reading the minimum length is enough to check the body
+		if limit < 0 {
+			if *minLength == 0 {
+				return true, nil
+			}
+			limit = math.MaxInt64 - 1
+			if *minLength < math.MaxInt64 {
+				limit = int64(*minLength) - 1
+			}
+		}
+		var err error
+		if length, err = bufferBinaryBody(req.Request, limit); err != nil {
+			return false, readFailure(req, err, mediaTypePointer)
+		}
+	}
+
+	if v.maxBinaryBody > 0 && length > v.maxBinaryBody {
+		return false, &ValidationError{
+			Stage:       StageBody,
+			Category:    CategoryBodyTooLarge,
+			Message:     fmt.Sprintf("request body exceeds %d bytes", v.maxBinaryBody),
+			SpecPointer: mediaTypePointer,
+		}
+	}
+	failure := ValidationError{Stage: StageBody, Message: "request body does not match schema"}
+	if minLength != nil && uint64(length) < *minLength {
+		return false, schemaFailures(failure, mediaTypePointer+"/schema", newSchemaError("", "length must be at least %d bytes", *minLength))
+	}
+	if maxLength != nil && uint64(length) > *maxLength {
+		return false, schemaFailures(failure, mediaTypePointer+"/schema", newSchemaError("", "length must be at most %d bytes", *maxLength))
+	}
+	return true, nil
+}
+
+// bufferBinaryBody reads a body of unknown length, up to one byte past the limit when it is not negative, and
+// replaces it with the content read followed by the rest of the body. It returns the length of the body, or a
+// length past the limit when the body exceeds it.
+func bufferBinaryBody(r *http.Request, limit int64) (int64, error) {
+	if r.Body == nil || r.Body == http.NoBody {
+		return 0, nil
+	}
+	reader := io.Reader(r.Body)
+	if limit >= 0 {
+		reader = io.LimitReader(r.Body, limit+1)
+	}
+	content, err := io.ReadAll(reader)
+	r.Body = struct {
+		io.Reader
+		io.Closer
+	}{io.MultiReader(bytes.NewReader(content), r.Body), r.Body}
+	return int64(len(content)), err
+}
@@ -0,0 +1,125 @@
+package validation
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"testing/iotest"
+
+	"github.com/lionelgarnier/validate-api-request/oas"
+	"github.com/stretchr/testify/assert"
+)
+
+// unknownLength hides the length of a body, like chunked requests
+type unknownLength struct {
+	io.Reader
+}
+
+func TestValidateBinaryBody(t *testing.T) {
+	manager := oas.NewOASManager(nil, oas.FixedSelector(map[string]string{"test": "test"}))
+	err := manager.LoadAPI("test", []byte(`{
+        "openapi": "3.0.0",
+        "paths": {
+            "/files": {
+                "put": {
+                    "requestBody": {"required": true, "content": {
+                        "application/octet-stream": {"schema": {"type": "string", "format": "binary", "minLength": 2, "maxLength": 8}}
+                    }},
+                    "responses": {"204": {"description": "Stored"}}
+                }
+            },
+            "/images": {
+                "put": {
+                    "requestBody": {"content": {"image/png": {"schema": {"$ref": "#/components/schemas/Image"}}}},
+                    "responses": {"204": {"description": "Stored"}}
+                }
+            },
+            "/archives": {
+                "put": {
+                    "requestBody": {"content": {"application/octet-stream": {"schema": {"type": "string", "format": "binary", "minLength": 4}}}},
+                    "responses": {"204": {"description": "Stored"}}
+                }
+            },
+            "/blobs": {
+                "put": {
+                    "requestBody": {"content": {"application/octet-stream": {}}},
+                    "responses": {"204": {"description": "Stored"}}
+                }
+            }
+        },
+        "components": {
+            "schemas": {"Image": {"type": "string", "format": "binary", "maxLength": 4}}
+        }
+    }`))
+	assert.NoError(t, err)
+	spec, err := manager.GetApiSpec("test")
+	assert.NoError(t, err)
+
+	tests := []struct {
+		name          string
+		path          string
+		contentType   string
+		body          string
+		unknownLength bool
+		maxBody       int64
+		category      Category
+	}{
+		{name: "Valid upload", path: "/files", contentType: "application/octet-stream", body: "\x00\xff\x10\x80"},
+		{name: "Lengths count bytes", path: "/files", contentType: "application/octet-stream", body: "é"},
+		{name: "Too short", path: "/files", contentType: "application/octet-stream", body: "a", category: CategoryInvalidBody},
+		{name: "Too long", path: "/files", contentType: "application/octet-stream", body: "123456789", category: CategoryInvalidBody},
+		{name: "Unknown length", path: "/files", contentType: "application/octet-stream", body: "1234", unknownLength: true},
+		{name: "Unknown length too long", path: "/files", contentType: "application/octet-stream", body: "123456789", unknownLength: true, category: CategoryInvalidBody},
+		{name: "Unknown length without maximum", path: "/archives", contentType: "application/octet-stream", body: "123456789", unknownLength: true},
+		{name: "Unknown length without maximum too short", path: "/archives", contentType: "application/octet-stream", body: "123", unknownLength: true, category: CategoryInvalidBody},
+		{name: "Binary schema of another media type", path: "/images", contentType: "image/png", body: "\x89PNG"},
+		{name: "Referenced binary schema too long", path: "/images", contentType: "image/png", body: "\x89PNG\r\n", category: CategoryInvalidBody},
+		{name: "Without schema", path: "/blobs", contentType: "application/octet-stream", body: "not JSON"},
+		{name: "Configured maximum", path: "/blobs", contentType: "application/octet-stream", body: "12345", maxBody: 4, category: CategoryBodyTooLarge},
+		{name: "Configured maximum with unknown length", path: "/blobs", contentType: "application/octet-stream", body: "12345", unknownLength: true, maxBody: 4, category: CategoryBodyTooLarge},
+		{name: "Configured maximum and schema", path: "/files", contentType: "application/octet-stream", body: "123456", maxBody: 100},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var body io.Reader = strings.NewReader(tt.body)
+			if tt.unknownLength {
+				body = unknownLength{body}
+			}
+			r := httptest.NewRequest(http.MethodPut, tt.path, body)
+			r.Header.Set("Content-Type", tt.contentType)
+			req := oas.NewOASRequest(r)
+
+			ok, err := NewValidator(spec, WithMaxBinaryBody(tt.maxBody)).ValidateRequest(req)
+			if tt.category != "" {
+				assert.False(t, ok)
+				var validationErr *ValidationError
+				if assert.True(t, errors.As(err, &validationErr)) {
+					assert.Equal(t, tt.category, validationErr.Category)
+				}
+				return
+			}
+			assert.True(t, ok)
+			assert.NoError(t, err)
+
+			// The body is passed through untouched
+			content, err := io.ReadAll(req.Request.Body)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.body, string(content))
+		})
+	}
+
+	t.Run("Only the minimum length is read without maximum", func(t *testing.T) {
+		// Reading past the first 4 bytes fails
+		body := io.MultiReader(strings.NewReader("1234"), iotest.ErrReader(errors.New("read past the minimum length")))
+		r := httptest.NewRequest(http.MethodPut, "/archives", unknownLength{body})
+		r.Header.Set("Content-Type", "application/octet-stream")
+
+		ok, err := NewValidator(spec).ValidateRequest(oas.NewOASRequest(r))
+		assert.True(t, ok)
+		assert.NoError(t, err)
+	})
+}
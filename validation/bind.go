@@ -0,0 +1,321 @@
+package validation
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"math/big"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/lionelgarnier/validate-api-request/oas"
+	"github.com/lionelgarnier/validate-api-request/pkg/helpers"
+)
+
+// bindLocations are the struct tags binding fields to the parameters of each location
+var bindLocations = []string{"path", "query", "header", "cookie"}
+
+// Binder binds validated requests into structs, as DefaultValidator does
+type Binder interface {
+	Bind(req *oas.OASRequest, dst interface{}) error
+}
+
+// Bind validates the request with a validator implementing Binder, then populates the struct pointed to by dst,
+// see DefaultValidator.Bind
+func Bind(validator Validator, req *oas.OASRequest, dst interface{}) error {
+	binder, ok := validator.(Binder)
+	if !ok {
+		return fmt.Errorf("validator %T does not bind requests", validator)
+	}
+	return binder.Bind(req, dst)
+}
+
+// Bind validates the request, then populates the struct pointed to by dst from its parameters and body. Fields
+// tagged `path:"petId"`, `query:"status"`, `header:"X-Api-Key"` or `cookie:"session"` receive the parameter of
+// that location, decoded by its style when the operation declares it and read as is otherwise, e.g. for the
+// headers of security schemes. A JSON body is decoded into dst with encoding/json, so fields are bound to its
+// properties by their `json` tags. The fields of nested and embedded structs are bound too.
+func (v *DefaultValidator) Bind(req *oas.OASRequest, dst interface{}) error {
+	target := reflect.ValueOf(dst)
+	if target.Kind() != reflect.Pointer || target.IsNil() || target.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("bind destination must be a non-nil pointer to a struct, got %T", dst)
+	}
+
+	if _, err := v.ValidateRequest(req); err != nil {
+		return err
+	}
+
+	if err := v.bindBody(req, dst); err != nil {
+		return err
+	}
+	parameters, err := v.operationParameters(req)
+	if err != nil {
+		return err
+	}
+	_, err = v.bindParameters(req, parameters, target.Elem())
+	return err
+}
+
+// bindBody decodes the JSON body of the request into dst, other bodies are not bound
+func (v *DefaultValidator) bindBody(req *oas.OASRequest, dst interface{}) error {
+	contentType := req.Request.Header.Get("Content-Type")
+	if contentType != "" && !isJSONMediaType(contentType) {
+		return nil
+	}
+	content, err := bufferBody(req.Request)
+	if err != nil {
+		return fmt.Errorf("failed to read request body: %w", err)
+	}
+	if len(content) == 0 {
+		return nil
+	}
+	if err := json.Unmarshal(content, dst); err != nil {
+		return fmt.Errorf("failed to bind request body: %w", err)
+	}
+	return nil
+}
+
+// bindParameters sets the fields of a struct tagged with a parameter location, overriding the values of the body,
+// and the fields of its nested structs. It reports whether a field was set.
+func (v *DefaultValidator) bindParameters(req *oas.OASRequest, parameters []oas.Parameter, target reflect.Value) (bool, error) {
+	bound := false
+	for i := 0; i < target.NumField(); i++ {
+		field := target.Type().Field(i)
+		if !field.IsExported() && !field.Anonymous {
+			continue
+		}
+
+		tagged := false
+		for _, location := range bindLocations {
+			name, ok := field.Tag.Lookup(location)
+			if !ok || name == "" || name == "-" {
+				continue
+			}
+			tagged = true
+
+			value, present := v.bindValue(req, parameters, location, name)
+			if !present {
+				continue
+			}
+			if err := v.setField(target.Field(i), value); err != nil {
+				return bound, fmt.Errorf("failed to bind %s parameter '%s' to field %s: %w", location, name, field.Name, err)
+			}
+			bound = true
+		}
+		if tagged {
+			continue
+		}
+
+		nested, err := v.bindNested(req, parameters, target.Field(i))
+		if err != nil {
+			return bound, err
+		}
+		bound = bound || nested
+	}
+	return bound, nil
+}
+
+// bindNested binds the fields of a nested or embedded struct, or of a pointer to one, allocated once a field is set
+func (v *DefaultValidator) bindNested(req *oas.OASRequest, parameters []oas.Parameter, field reflect.Value) (bool, error) {
+	switch {
+	case field.Kind() == reflect.Struct:
+		// The exported fields of embedded structs of unexported types are settable, like their promoted fields
+		return v.bindParameters(req, parameters, field)
+	case field.Kind() == reflect.Pointer && field.Type().Elem().Kind() == reflect.Struct:
+		if !field.CanSet() {
+			return false, nil
+		}
+		if !field.IsNil() {
+			return v.bindParameters(req, parameters, field.Elem())
+		}
+		elem := reflect.New(field.Type().Elem())
+		bound, err := v.bindParameters(req, parameters, elem.Elem())
+		if bound {
+			field.Set(elem)
+		}
+		return bound, err
+	}
+	return false, nil
+}
+
+// operationParameters returns the resolved parameters of the operation of a validated request
+func (v *DefaultValidator) operationParameters(req *oas.OASRequest) ([]oas.Parameter, error) {
+	pathParameters, err := v.resolveParameters(req.PathItem.Parameters)
+	if err != nil {
+		return nil, err
+	}
+	operationParameters, err := v.resolveParameters(req.Operation.Parameters)
+	if err != nil {
+		return nil, err
+	}
+	return mergeParameters(pathParameters, operationParameters), nil
+}
+
+// bindValue returns the value of the parameter with the given location and name, decoded by its style when
+// declared, and the raw values of the request otherwise
+func (v *DefaultValidator) bindValue(req *oas.OASRequest, parameters []oas.Parameter, location string, name string) (interface{}, bool) {
+	for i := range parameters {
+		if parameters[i].In == location && (parameters[i].Name == name || location == "header" && http.CanonicalHeaderKey(parameters[i].Name) == http.CanonicalHeaderKey(name)) {
+			return v.parameterValue(req, &parameters[i])
+		}
+	}
+
+	var values []string
+	switch location {
+	case "path":
+		if value, exists := PathParams(req)[name]; exists {
+			values = []string{value}
+		}
+	case "query":
+		values = req.Request.URL.Query()[name]
+	case "header":
+		values = req.Request.Header.Values(name)
+	case "cookie":
+		for _, cookie := range req.Request.Cookies() {
+			if cookie.Name == name {
+				values = append(values, cookie.Value)
+			}
+		}
+	}
+	switch len(values) {
+	case 0:
+		return nil, false
+	case 1:
+		return values[0], true
+	default:
+		return arrayItems(values), true
+	}
+}
+
+// setField sets a struct field from a parameter value, a string, an array of strings or an object of strings,
+// converting strings to the numeric and boolean kinds of the field as validation reads them
+func (v *DefaultValidator) setField(field reflect.Value, value interface{}) error {
+	switch field.Kind() {
+	case reflect.Pointer:
+		elem := reflect.New(field.Type().Elem())
+		if err := v.setField(elem.Elem(), value); err != nil {
+			return err
+		}
+		field.Set(elem)
+		return nil
+	case reflect.Interface:
+		field.Set(reflect.ValueOf(value))
+		return nil
+	case reflect.Slice:
+		items, ok := value.([]interface{})
+		if !ok {
+			items = []interface{}{value}
+		}
+		slice := reflect.MakeSlice(field.Type(), len(items), len(items))
+		for i, item := range items {
+			if err := v.setField(slice.Index(i), item); err != nil {
+				return err
+			}
+		}
+		field.Set(slice)
+		return nil
+	case reflect.Map:
+		object, ok := value.(map[string]interface{})
+		if !ok || field.Type().Key().Kind() != reflect.String {
+			return fmt.Errorf("cannot bind %T to %s", value, field.Type())
+		}
+		m := reflect.MakeMapWithSize(field.Type(), len(object))
+		for key, property := range object {
+			elem := reflect.New(field.Type().Elem()).Elem()
+			if err := v.setField(elem, property); err != nil {
+				return err
+			}
+			m.SetMapIndex(reflect.ValueOf(key).Convert(field.Type().Key()), elem)
+		}
+		field.Set(m)
+		return nil
+	}
+
+	str, ok := value.(string)
+	if !ok {
+		return fmt.Errorf("cannot bind %T to %s", value, field.Type())
+	}
+	switch field.Kind() {
+	case reflect.String:
+		field.SetString(str)
+	case reflect.Bool:
+		b, err := parseBoolean(str)
+		if err != nil {
+			return err
+		}
+		field.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := v.parseInteger(str)
+		if err != nil {
+			return err
+		}
+		if !n.IsInt64() || field.OverflowInt(n.Int64()) {
+			return fmt.Errorf("%s overflows %s", str, field.Type())
+		}
+		field.SetInt(n.Int64())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := v.parseInteger(str)
+		if err != nil {
+			return err
+		}
+		if !n.IsUint64() || field.OverflowUint(n.Uint64()) {
+			return fmt.Errorf("%s overflows %s", str, field.Type())
+		}
+		field.SetUint(n.Uint64())
+	case reflect.Float32, reflect.Float64:
+		ungrouped, err := v.ungroupNumber(str)
+		if err != nil {
+			return err
+		}
+		f, err := strconv.ParseFloat(ungrouped, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+		field.SetFloat(f)
+	default:
+		return fmt.Errorf("cannot bind %T to %s", value, field.Type())
+	}
+	return nil
+}
+
+// parseBoolean parses the booleans accepted by the boolean modes: `true` and `false` in any case, `1` and `0`
+func parseBoolean(str string) (bool, error) {
+	switch strings.ToLower(str) {
+	case "true", "1":
+		return true, nil
+	case "false", "0":
+		return false, nil
+	}
+	return false, fmt.Errorf("invalid boolean %q", str)
+}
+
+// parseInteger parses the integers accepted by validation, e.g. "1e3" or "2.0", and grouped by thousands when allowed
+func (v *DefaultValidator) parseInteger(str string) (*big.Int, error) {
+	ungrouped, err := v.ungroupNumber(str)
+	if err != nil {
+		return nil, err
+	}
+	if exact, ok := helpers.ExactNumber(ungrouped); ok {
+		if !exact.IsInt() {
+			return nil, fmt.Errorf("invalid integer %q", str)
+		}
+		return exact.Num(), nil
+	}
+	f, err := strconv.ParseFloat(ungrouped, 64)
+	if err != nil || math.IsInf(f, 0) || f != math.Trunc(f) {
+		return nil, fmt.Errorf("invalid integer %q", str)
+	}
+	n, _ := big.NewFloat(f).Int(nil)
+	return n, nil
+}
+
+// ungroupNumber removes the thousands separators of a numeric string when the number strictness allows them
+func (v *DefaultValidator) ungroupNumber(str string) (string, error) {
+	ungrouped, err := v.numberStrictness.ungroup(str, "")
+	if err != nil {
+		return "", err
+	}
+	return ungrouped.(string), nil
+}
@@ -0,0 +1,172 @@
+package validation
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/lionelgarnier/validate-api-request/oas"
+	"github.com/stretchr/testify/assert"
+)
+
+type petUpdate struct {
+	PetID    int64             `path:"petId"`
+	Status   string            `query:"status"`
+	Tags     []string          `query:"tags"`
+	Limit    *int              `query:"limit"`
+	Point    map[string]string `header:"X-Point"`
+	APIKey   string            `header:"X-Api-Key"`
+	Session  string            `cookie:"session"`
+	Name     string            `json:"name"`
+	Age      float64           `json:"age"`
+	internal string            `query:"status"`
+}
+
+func TestBind(t *testing.T) {
+	manager := oas.NewOASManager(nil, oas.FixedSelector(map[string]string{"test": "test"}))
+	err := manager.LoadAPI("test", []byte(`{
+        "openapi": "3.0.0",
+        "info": {"title": "Test API", "version": "1.0.0"},
+        "paths": {
+            "/pets/{petId}": {
+                "parameters": [{"name": "petId", "in": "path", "required": true, "schema": {"type": "integer"}}],
+                "put": {
+                    "parameters": [
+                        {"name": "status", "in": "query", "schema": {"type": "string", "enum": ["available", "sold"]}},
+                        {"name": "tags", "in": "query", "explode": false, "schema": {"type": "array", "items": {"type": "string"}}},
+                        {"name": "limit", "in": "query", "schema": {"type": "integer"}},
+                        {"name": "x-point", "in": "header", "explode": true, "schema": {"type": "object"}}
+                    ],
+                    "requestBody": {"content": {"application/json": {"schema": {
+                        "type": "object",
+                        "properties": {"name": {"type": "string"}, "age": {"type": "number"}}
+                    }}}}
+                }
+            }
+        }
+    }`))
+	assert.NoError(t, err)
+	spec, _ := manager.GetApiSpec("test")
+	limit := 5
+
+	tests := []struct {
+		name          string
+		url           string
+		body          string
+		expected      petUpdate
+		expectedError string
+	}{
+		{
+			name: "All locations",
+			url:  "/pets/42?status=sold&tags=a,b&limit=5",
+			body: `{"name": "Rex", "age": 3.5}`,
+			expected: petUpdate{PetID: 42, Status: "sold", Tags: []string{"a", "b"}, Limit: &limit,
+				Point: map[string]string{"x": "1", "y": "2"}, APIKey: "secret", Session: "abc", Name: "Rex", Age: 3.5},
+		},
+		{
+			name:     "Absent parameters keep their zero value",
+			url:      "/pets/42",
+			body:     `{}`,
+			expected: petUpdate{PetID: 42, Point: map[string]string{"x": "1", "y": "2"}, APIKey: "secret", Session: "abc"},
+		},
+		{name: "Invalid request", url: "/pets/42?status=lost", body: `{}`, expectedError: "invalid type for parameter 'status'"},
+		{name: "Invalid body", url: "/pets/42", body: `{"name": 1}`, expectedError: "request body does not match schema"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequest(http.MethodPut, tt.url, strings.NewReader(tt.body))
+			assert.NoError(t, err)
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set("X-Point", "x=1,y=2")
+			req.Header.Set("X-Api-Key", "secret")
+			req.AddCookie(&http.Cookie{Name: "session", Value: "abc"})
+
+			var dst petUpdate
+			err = Bind(NewValidator(spec), oas.NewOASRequest(req), &dst)
+			if tt.expectedError != "" {
+				assert.ErrorContains(t, err, tt.expectedError)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, dst)
+		})
+	}
+
+	t.Run("Destination must point to a struct", func(t *testing.T) {
+		req, _ := http.NewRequest(http.MethodPut, "/pets/42", nil)
+		var dst petUpdate
+		assert.ErrorContains(t, Bind(NewValidator(spec), oas.NewOASRequest(req), dst), "non-nil pointer to a struct")
+	})
+
+	t.Run("Conversion failure", func(t *testing.T) {
+		req, _ := http.NewRequest(http.MethodPut, "/pets/42?status=sold", strings.NewReader(`{}`))
+		var dst struct {
+			Status int `query:"status"`
+		}
+		assert.ErrorContains(t, Bind(NewValidator(spec), oas.NewOASRequest(req), &dst), "failed to bind query parameter 'status' to field Status")
+	})
+}
+
+type pagination struct {
+	Limit  int  `query:"limit"`
+	Offset uint `query:"offset"`
+}
+
+type petFilter struct {
+	pagination
+	Vaccinated bool `query:"vaccinated"`
+	Weight     struct {
+		Max float64 `query:"maxWeight"`
+	}
+	Owner *struct {
+		ID string `header:"X-Owner"`
+	}
+	Breeder *struct {
+		ID string `header:"X-Breeder"`
+	}
+}
+
+func TestBindConversions(t *testing.T) {
+	manager := oas.NewOASManager(nil, oas.FixedSelector(map[string]string{"test": "test"}))
+	err := manager.LoadAPI("test", []byte(`{
+        "openapi": "3.0.0",
+        "info": {"title": "Test API", "version": "1.0.0"},
+        "paths": {
+            "/pets": {
+                "get": {
+                    "parameters": [
+                        {"name": "limit", "in": "query", "schema": {"type": "integer"}},
+                        {"name": "offset", "in": "query", "schema": {"type": "integer", "minimum": 0}},
+                        {"name": "vaccinated", "in": "query", "schema": {"type": "boolean"}},
+                        {"name": "maxWeight", "in": "query", "schema": {"type": "number"}}
+                    ]
+                }
+            }
+        }
+    }`))
+	assert.NoError(t, err)
+	spec, _ := manager.GetApiSpec("test")
+
+	req, err := http.NewRequest(http.MethodGet, "/pets?limit=1e3&offset=1,000&vaccinated=TRUE&maxWeight=1,250.5", nil)
+	assert.NoError(t, err)
+	req.Header.Set("X-Owner", "alice")
+
+	// Values accepted by validation are bound, into nested and embedded structs too
+	var dst petFilter
+	validator := NewValidator(spec, WithNumberStrictness(NumberStrictness{AllowThousandsSeparators: true}))
+	assert.NoError(t, Bind(validator, oas.NewOASRequest(req), &dst))
+	assert.Equal(t, 1000, dst.Limit)
+	assert.Equal(t, uint(1000), dst.Offset)
+	assert.True(t, dst.Vaccinated)
+	assert.Equal(t, 1250.5, dst.Weight.Max)
+	if assert.NotNil(t, dst.Owner) {
+		assert.Equal(t, "alice", dst.Owner.ID)
+	}
+	assert.Nil(t, dst.Breeder, "pointers to structs without bound fields stay nil")
+
+	t.Run("Validator without binding", func(t *testing.T) {
+		var validator struct{ Validator }
+		assert.ErrorContains(t, Bind(validator, oas.NewOASRequest(req), &dst), "does not bind requests")
+	})
+}
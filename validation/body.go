@@ -1,10 +1,13 @@
 package validation
 
 import (
-	"encoding/json"
+	"bytes"
 	"fmt"
+	"io"
+	"net/http"
 
 	"github.com/lionelgarnier/validate-api-request/oas"
+	"github.com/lionelgarnier/validate-api-request/pkg/helpers"
 )
 
 // ValidateRequestPath validates the request path
@@ -19,43 +22,177 @@ func (v *DefaultValidator) ValidateRequestBody(req *oas.OASRequest) (bool, error
 	operation := req.Operation
 
 	requestBody := operation.RequestBody
-	// Skip validation if no request body defined
-	if requestBody == nil {
+	// Skip validation if no request body defined, or for WebSocket upgrades whose connection is not a body
+	if requestBody == nil || IsWebSocketUpgrade(req) {
 		return true, nil
 	}
+	if _, skip := v.taggedWith(req, func(rule TagRule) bool { return rule.SkipBody }); skip {
+		return true, nil
+	}
+
+	bodyPointer := refPointer(requestBody.Ref, operationPointer(req)+"/requestBody")
+
+	// Resolve request body reference if necessary
+	requestBody, err := v.apiSpec.ResolveRequestBody(requestBody)
+	if err != nil {
+		return false, err
+	}
 
 	// Check if request body is required
 	if requestBody.Required && req.Request.ContentLength == 0 {
-		return false, fmt.Errorf("request body is required")
+		return false, &ValidationError{Stage: StageBody, Message: "request body is required", SpecPointer: bodyPointer}
 	}
 
 	// Get content type from request
 	contentType := req.Request.Header.Get("Content-Type")
 	if contentType == "" {
-		contentType = "application/json" // Default to JSON if not specified
+		var ok bool
+		if contentType, ok = v.assumedContentType(req.Request, requestBody.Content); !ok {
+			return false, &ValidationError{
+				Stage:       StageBody,
+				Category:    CategoryUnsupportedMediaType,
+				Message:     "missing Content-Type header",
+				SpecPointer: bodyPointer + "/content",
+			}
+		}
 	}
 
-	// Check if content type is supported
-	mediaType, exists := requestBody.Content[contentType]
+	// Check if content type is supported, ignoring case, whitespace and the multipart boundary
+	key, mediaType, exists := findMediaType(requestBody.Content, contentType)
 	if !exists {
-		return false, fmt.Errorf("unsupported content type '%s'", contentType)
+		return false, &ValidationError{
+			Stage:       StageBody,
+			Category:    CategoryUnsupportedMediaType,
+			Message:     fmt.Sprintf("unsupported content type '%s'", contentType),
+			SpecPointer: bodyPointer + "/content",
+		}
 	}
+	mediaTypePointer := bodyPointer + "/content/" + helpers.EscapeJSONPointer(key)
 
-	// Skip validation if no schema defined
-	if mediaType.Schema == nil {
-		return true, nil
+	// Binary bodies are passed through untouched, only their length is checked
+	if v.isBinaryBody(contentType, mediaType) {
+		return v.validateBinaryBody(req, mediaType, mediaTypePointer)
 	}
 
-	// Parse request body
+	// Decode request body with the decoder of its media type, skipping validation if no schema defined
+	content, err := v.bufferRequestBody(req, mediaTypePointer)
+	if err != nil {
+		return false, err
+	}
 	var body interface{}
-	if err := json.NewDecoder(req.Request.Body).Decode(&body); err != nil {
-		return false, fmt.Errorf("invalid request body: %v", err)
+	var validate bool
+	graphQL := v.isGraphQLOperation(req)
+	if v.readOnly == ReadOnlyStrip && !graphQL && mediaType.Schema != nil && isJSONMediaType(contentType) {
+		if content, err = v.stripReadOnly(req, content, mediaType.Schema); err != nil {
+			return false, schemaFailures(ValidationError{Stage: StageBody, Message: "request body does not match schema"}, mediaTypePointer+"/schema", err)
+		}
+	}
+	if !graphQL && v.scanRequestBody(req, content, contentType, mediaType) {
+		return true, nil
+	}
+	if err := canceled(req, StageBody); err != nil {
+		return false, err
+	}
+	if graphQL {
+		body, err = v.decodeGraphQLBody(bytes.NewReader(content), contentType)
+		validate = mediaType.Schema != nil
+	} else {
+		body, validate, err = v.decodeBody(bytes.NewReader(content), contentType, mediaType)
+	}
+	if err != nil {
+		return false, &ValidationError{Stage: StageBody, Category: CategoryMalformedBody, Message: "invalid request body", Err: err, SpecPointer: mediaTypePointer}
+	}
+
+	// Check the GraphQL envelope before the declared schema
+	if graphQL {
+		if err := checkGraphQLBody(body); err != nil {
+			return false, &ValidationError{Stage: StageBody, Message: "invalid GraphQL request", Err: err, SpecPointer: mediaTypePointer}
+		}
+	}
+	if !validate {
+		return true, nil
+	}
+	if err := canceled(req, StageBody); err != nil {
+		return false, err
 	}
 
 	// Validate request body against schema
-	if !v.ValidateSchema(body, mediaType.Schema) {
-		return false, fmt.Errorf("request body does not match schema")
+	if err := v.validateRequestSchema(req, "body", body, mediaType.Schema, ""); err != nil {
+		return false, schemaFailures(ValidationError{Stage: StageBody, Message: "request body does not match schema"}, mediaTypePointer+"/schema", err)
+	}
+
+	// Check the body beyond its schema with the hook of the operation
+	if err := v.runBodyHook(req, body, mediaTypePointer+"/schema"); err != nil {
+		return false, err
 	}
 
 	return true, nil
 }
+
+// bufferBody reads the body of a request and replaces it with the content read, so that the handlers and proxies
+// validated requests are passed to can read it
+func bufferBody(r *http.Request) ([]byte, error) {
+	content, _, err := bufferBodyUpTo(r, 0)
+	return content, err
+}
+
+// bufferBodyUpTo reads the body of a request up to limit bytes, 0 for no limit, and replaces it with a body
+// reading the same content, rewindable with GetBody. Bodies exceeding the limit are replaced with the content read
+// followed by the rest of the body, which is not read, and are reported exceeded without content.
+func bufferBodyUpTo(r *http.Request, limit int64) ([]byte, bool, error) {
+	if r.Body == nil || r.Body == http.NoBody {
+		return nil, false, nil
+	}
+	if limit > 0 && r.ContentLength > limit {
+		return nil, true, nil
+	}
+	reader := io.Reader(r.Body)
+	if limit > 0 {
+		reader = io.LimitReader(r.Body, limit+1)
+	}
+	content, err := io.ReadAll(reader)
+	if limit > 0 && int64(len(content)) > limit {
+		r.Body = struct {
+			io.Reader
+			io.Closer
+		}{io.MultiReader(bytes.NewReader(content), r.Body), r.Body}
+		return nil, true, err
+	}
+	r.Body.Close()
+	setBody(r, content)
+	return content, false, err
+}
+
+// setBody replaces the body of a request with the content, rewindable with GetBody
+func setBody(r *http.Request, content []byte) {
+	r.Body = io.NopCloser(bytes.NewReader(content))
+	r.GetBody = func() (io.ReadCloser, error) {
+		return io.NopCloser(bytes.NewReader(content)), nil
+	}
+}
+
+// WithMaxBodyBuffer sets the maximum size in bytes of the request bodies buffered to be validated, 0 for no limit.
+// Binary bodies, which are not buffered, are bounded by WithMaxBinaryBody instead.
+func WithMaxBodyBuffer(size int64) Option {
+	return func(v *DefaultValidator) {
+		v.maxBodyBuffer = size
+	}
+}
+
+// bufferRequestBody reads the body of a request to validate it, and restores it for the next handlers. Bodies
+// exceeding the maximum buffer size fail with CategoryBodyTooLarge, and are forwarded unread in report mode.
+func (v *DefaultValidator) bufferRequestBody(req *oas.OASRequest, specPointer string) ([]byte, error) {
+	content, exceeded, err := bufferBodyUpTo(req.Request, v.maxBodyBuffer)
+	if err != nil {
+		return nil, readFailure(req, err, specPointer)
+	}
+	if exceeded {
+		return nil, &ValidationError{
+			Stage:       StageBody,
+			Category:    CategoryBodyTooLarge,
+			Message:     fmt.Sprintf("request body exceeds %d bytes", v.maxBodyBuffer),
+			SpecPointer: specPointer,
+		}
+	}
+	return content, nil
+}
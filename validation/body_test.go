@@ -1,7 +1,9 @@
 package validation
 
 import (
+	"io"
 	"net/http"
+	"net/url"
 	"strings"
 	"testing"
 
@@ -365,6 +367,464 @@ func TestValidateRequestBody(t *testing.T) {
 				assert.True(t, ok)
 				assert.NoError(t, err)
 			}
+
+			// The body remains readable by the handler of the request
+			body, err := io.ReadAll(req.Body)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.body, string(body))
+		})
+	}
+}
+
+func TestValidateMapRequestBody(t *testing.T) {
+
+	manager := oas.NewOASManager(nil, oas.FixedSelector(map[string]string{"test": "test"}))
+
+	content := []byte(`{
+		"openapi": "3.0.0",
+		"paths": {
+			"/labels": {
+				"post": {
+					"requestBody": {
+						"content": {
+							"application/json": {
+								"schema": {
+									"type": "object",
+									"minProperties": 1,
+									"additionalProperties": {"$ref": "#/components/schemas/Label"}
+								}
+							}
+						}
+					}
+				}
+			},
+			"/counters": {
+				"post": {
+					"requestBody": {
+						"content": {
+							"application/json": {
+								"schema": {
+									"type": "object",
+									"additionalProperties": {"type": "integer", "minimum": 0}
+								}
+							}
+						}
+					}
+				}
+			},
+			"/closed": {
+				"post": {
+					"requestBody": {
+						"content": {
+							"application/json": {
+								"schema": {
+									"type": "object",
+									"properties": {"name": {"type": "string"}},
+									"additionalProperties": false
+								}
+							}
+						}
+					}
+				}
+			},
+			"/open": {
+				"post": {
+					"requestBody": {
+						"content": {
+							"application/json": {
+								"schema": {
+									"type": "object",
+									"properties": {"name": {"type": "string"}},
+									"additionalProperties": true
+								}
+							}
+						}
+					}
+				}
+			}
+		},
+		"components": {
+			"schemas": {
+				"Label": {
+					"type": "object",
+					"required": ["name"],
+					"properties": {
+						"name": {"type": "string"},
+						"owner": {"type": "string", "format": "email"}
+					}
+				}
+			}
+		}
+	}`)
+
+	err := manager.LoadAPI("test", content)
+	assert.NoError(t, err)
+
+	spec, _ := manager.GetApiSpec("test")
+	validator := NewValidator(spec)
+
+	tests := []struct {
+		name          string
+		path          string
+		body          string
+		expectedError string
+	}{
+		{
+			name: "Valid map of referenced schemas",
+			path: "/labels",
+			body: `{"urgent": {"name": "Urgent", "owner": "ops@example.com"}, "low": {"name": "Low"}}`,
+		},
+		{
+			name:          "Map value missing required property",
+			path:          "/labels",
+			body:          `{"urgent": {"owner": "ops@example.com"}}`,
+			expectedError: "request body does not match schema",
+		},
+		{
+			name:          "Map value with invalid format",
+			path:          "/labels",
+			body:          `{"urgent": {"name": "Urgent", "owner": "not-an-email"}}`,
+			expectedError: "request body does not match schema",
+		},
+		{
+			name:          "Map below minProperties",
+			path:          "/labels",
+			body:          `{}`,
+			expectedError: "request body does not match schema",
+		},
+		{
+			name: "Valid map of inline schemas",
+			path: "/counters",
+			body: `{"views": 10, "likes": 0}`,
+		},
+		{
+			name:          "Invalid map of inline schemas",
+			path:          "/counters",
+			body:          `{"views": -1}`,
+			expectedError: "request body does not match schema",
+		},
+		{
+			name:          "Additional properties forbidden",
+			path:          "/closed",
+			body:          `{"name": "Fluffy", "age": 3}`,
+			expectedError: "request body does not match schema",
+		},
+		{
+			name: "Additional properties allowed",
+			path: "/open",
+			body: `{"name": "Fluffy", "age": 3}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequest(http.MethodPost, tt.path, strings.NewReader(tt.body))
+			assert.NoError(t, err)
+			req.Header.Set("Content-Type", "application/json")
+
+			ok, err := validator.ValidateRequestBody(oas.NewOASRequest(req))
+			if tt.expectedError != "" {
+				assert.False(t, ok)
+				assert.Error(t, err)
+				assert.Contains(t, err.Error(), tt.expectedError)
+			} else {
+				assert.True(t, ok)
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestValidateOpenAPI31RequestBody(t *testing.T) {
+
+	manager := oas.NewOASManager(nil, oas.FixedSelector(map[string]string{"test": "test"}))
+
+	content := []byte(`{
+		"openapi": "3.1.0",
+		"paths": {
+			"/pet": {
+				"post": {
+					"requestBody": {
+						"content": {
+							"application/json": {
+								"schema": {
+									"type": "object",
+									"required": ["name", "kind"],
+									"properties": {
+										"name": {"type": ["string", "null"]},
+										"kind": {"const": "pet"},
+										"age": {"type": "integer", "exclusiveMinimum": 0},
+										"tag": {"type": ["string", "integer"]},
+										"weight": {"type": "number", "minimum": 5, "exclusiveMinimum": 3},
+										"owner": {"const": null}
+									}
+								}
+							}
+						}
+					}
+				}
+			}
+		}
+	}`)
+
+	err := manager.LoadAPI("test", content)
+	assert.NoError(t, err)
+
+	spec, _ := manager.GetApiSpec("test")
+	validator := NewValidator(spec)
+
+	tests := []struct {
+		name          string
+		body          string
+		expectedError string
+	}{
+		{name: "Valid body", body: `{"name": "Fluffy", "kind": "pet", "age": 1, "tag": "cute"}`},
+		{name: "Null allowed by type array", body: `{"name": null, "kind": "pet"}`},
+		{name: "Second type of type array", body: `{"name": "Fluffy", "kind": "pet", "tag": 3}`},
+		{name: "No type of type array matches", body: `{"name": "Fluffy", "kind": "pet", "tag": true}`, expectedError: "request body does not match schema"},
+		{name: "Const mismatch", body: `{"name": "Fluffy", "kind": "toy"}`, expectedError: "request body does not match schema"},
+		{name: "Exclusive minimum reached", body: `{"name": "Fluffy", "kind": "pet", "age": 0}`, expectedError: "request body does not match schema"},
+		{name: "Stricter inclusive minimum kept", body: `{"name": "Fluffy", "kind": "pet", "weight": 4}`, expectedError: "request body does not match schema"},
+		{name: "Inclusive minimum reached", body: `{"name": "Fluffy", "kind": "pet", "weight": 5}`},
+		{name: "Null const", body: `{"name": "Fluffy", "kind": "pet", "owner": null}`},
+		{name: "Null const mismatch", body: `{"name": "Fluffy", "kind": "pet", "owner": "Alice"}`, expectedError: "request body does not match schema"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequest(http.MethodPost, "/pet", strings.NewReader(tt.body))
+			assert.NoError(t, err)
+			req.Header.Set("Content-Type", "application/json")
+
+			ok, err := validator.ValidateRequestBody(oas.NewOASRequest(req))
+			if tt.expectedError != "" {
+				assert.False(t, ok)
+				assert.Error(t, err)
+				assert.Contains(t, err.Error(), tt.expectedError)
+			} else {
+				assert.True(t, ok)
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestRequestBodyErrorLocation(t *testing.T) {
+
+	manager := oas.NewOASManager(nil, oas.FixedSelector(map[string]string{"test": "test"}))
+
+	content := []byte(`{
+		"openapi": "3.0.0",
+		"paths": {
+			"/orders": {
+				"post": {
+					"requestBody": {
+						"content": {
+							"application/json": {
+								"schema": {
+									"type": "object",
+									"properties": {
+										"items": {
+											"type": "array",
+											"items": {"$ref": "#/components/schemas/Item"}
+										}
+									}
+								}
+							}
+						}
+					}
+				}
+			}
+		},
+		"components": {
+			"schemas": {
+				"Item": {
+					"type": "object",
+					"required": ["owner"],
+					"properties": {
+						"owner": {
+							"type": "object",
+							"required": ["email"],
+							"properties": {
+								"email": {"type": "string", "format": "email"}
+							}
+						}
+					}
+				}
+			}
+		}
+	}`)
+
+	err := manager.LoadAPI("test", content)
+	assert.NoError(t, err)
+
+	spec, _ := manager.GetApiSpec("test")
+	validator := NewValidator(spec)
+
+	tests := []struct {
+		name         string
+		body         string
+		expectedPath string
+	}{
+		{
+			name:         "Invalid nested property",
+			body:         `{"items": [{"owner": {"email": "a@example.com"}}, {"owner": {"email": "a@example.com"}}, {"owner": {"email": "a@example.com"}}, {"owner": {"email": "not-an-email"}}]}`,
+			expectedPath: "items[3].owner.email",
+		},
+		{
+			name:         "Missing nested property",
+			body:         `{"items": [{"owner": {}}]}`,
+			expectedPath: "items[0].owner.email",
+		},
+		{
+			name:         "Invalid item type",
+			body:         `{"items": [{"owner": {"email": "a@example.com"}}, 42]}`,
+			expectedPath: "items[1]",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequest(http.MethodPost, "/orders", strings.NewReader(tt.body))
+			assert.NoError(t, err)
+			req.Header.Set("Content-Type", "application/json")
+
+			ok, err := validator.ValidateRequestBody(oas.NewOASRequest(req))
+			assert.False(t, ok)
+
+			var validationErr *ValidationError
+			assert.ErrorAs(t, err, &validationErr)
+			assert.Equal(t, StageBody, validationErr.Stage)
+
+			var schemaErr *SchemaError
+			assert.ErrorAs(t, err, &schemaErr)
+			assert.Equal(t, tt.expectedPath, schemaErr.Path)
+		})
+	}
+}
+
+func TestMissingContentType(t *testing.T) {
+	manager := oas.NewOASManager(nil, oas.FixedSelector(map[string]string{"test": "test"}))
+	err := manager.LoadAPI("test", []byte(`{
+        "openapi": "3.0.0",
+        "paths": {
+            "/notes": {
+                "post": {
+                    "requestBody": {"content": {"text/csv": {"schema": {"type": "array", "items": {"type": "object", "required": ["title"]}}}}},
+                    "responses": {"201": {"description": "Created"}}
+                }
+            },
+            "/pets": {
+                "post": {
+                    "requestBody": {"content": {
+                        "application/json": {"schema": {"type": "object", "required": ["name"]}},
+                        "text/csv": {"schema": {"type": "array"}}
+                    }},
+                    "responses": {"201": {"description": "Created"}}
+                }
+            }
+        }
+    }`))
+	assert.NoError(t, err)
+	spec, err := manager.GetApiSpec("test")
+	assert.NoError(t, err)
+
+	tests := []struct {
+		name   string
+		policy MissingContentType
+		path   string
+		body   string
+		valid  bool
+		reason string
+	}{
+		{name: "JSON by default", path: "/pets", body: `{"name": "Rex"}`, valid: true},
+		{name: "JSON by default, undeclared", path: "/notes", body: "title\nRead", reason: "unsupported content type 'application/json'"},
+		{name: "Single declared media type inferred", policy: ContentTypeInfer, path: "/notes", body: "title\nRead", valid: true},
+		{name: "JSON inferred among several", policy: ContentTypeInfer, path: "/pets", body: `{"name": "Rex"}`, valid: true},
+		{name: "Header required", policy: ContentTypeRequire, path: "/pets", body: `{"name": "Rex"}`, reason: "missing Content-Type header"},
+		{name: "Default media type", policy: "text/csv", path: "/notes", body: "title\nRead", valid: true},
+		{name: "Default media type, undeclared", policy: "text/plain", path: "/pets", body: "Rex", reason: "unsupported content type 'text/plain'"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := oas.NewOASRequest(&http.Request{
+				Method:        http.MethodPost,
+				URL:           &url.URL{Path: tt.path},
+				Header:        http.Header{},
+				Body:          io.NopCloser(strings.NewReader(tt.body)),
+				ContentLength: int64(len(tt.body)),
+			})
+			ok, err := NewValidator(spec, WithMissingContentType(tt.policy)).ValidateRequest(req)
+			assert.Equal(t, tt.valid, ok)
+			if tt.valid {
+				assert.NoError(t, err)
+				return
+			}
+			if assert.Error(t, err) {
+				assert.Contains(t, err.Error(), tt.reason)
+			}
+		})
+	}
+}
+
+func TestRestoredRequestBody(t *testing.T) {
+	manager := oas.NewOASManager(nil, oas.FixedSelector(map[string]string{"test": "test"}))
+	err := manager.LoadAPI("test", []byte(`{
+        "openapi": "3.0.0",
+        "paths": {
+            "/pets": {
+                "post": {
+                    "parameters": [{"name": "tag", "in": "query", "schema": {"type": "string"}}],
+                    "requestBody": {"content": {"application/json": {"schema": {"type": "object", "required": ["name"]}}}}
+                }
+            }
+        }
+    }`))
+	assert.NoError(t, err)
+	spec, err := manager.GetApiSpec("test")
+	assert.NoError(t, err)
+
+	tests := []struct {
+		name          string
+		body          string
+		contentLength int64
+		maxBuffer     int64
+		category      Category
+	}{
+		{name: "Valid body", body: `{"name": "Rex"}`, contentLength: 15},
+		{name: "Invalid body", body: `{"age": 3}`, contentLength: 10, category: CategoryInvalidBody},
+		{name: "Within the buffer", body: `{"name": "Rex"}`, contentLength: 15, maxBuffer: 15},
+		{name: "Unknown length within the buffer", body: `{"name": "Rex"}`, contentLength: -1, maxBuffer: 15},
+		{name: "Exceeding the buffer", body: `{"name": "Rex"}`, contentLength: 15, maxBuffer: 14, category: CategoryBodyTooLarge},
+		{name: "Unknown length exceeding the buffer", body: `{"name": "Rex"}`, contentLength: -1, maxBuffer: 14, category: CategoryBodyTooLarge},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := oas.NewOASRequest(&http.Request{
+				Method:        http.MethodPost,
+				URL:           &url.URL{Path: "/pets"},
+				Header:        http.Header{"Content-Type": {"application/json"}},
+				Body:          io.NopCloser(strings.NewReader(tt.body)),
+				ContentLength: tt.contentLength,
+			})
+			ok, err := NewValidator(spec, WithMaxBodyBuffer(tt.maxBuffer), WithParameterPinning(true)).ValidateRequest(req)
+			assert.Equal(t, tt.category == "", ok, err)
+			assert.Equal(t, tt.category, CategoryOf(err))
+
+			// The next handlers read the whole body, even when it was not buffered
+			content, err := io.ReadAll(req.Request.Body)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.body, string(content))
+			if tt.category == CategoryBodyTooLarge {
+				assert.Nil(t, req.Request.GetBody)
+				return
+			}
+			body, err := req.Request.GetBody()
+			assert.NoError(t, err)
+			content, _ = io.ReadAll(body)
+			assert.Equal(t, tt.body, string(content))
 		})
 	}
 }
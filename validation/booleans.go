@@ -0,0 +1,40 @@
+package validation
+
+import (
+	"github.com/lionelgarnier/validate-api-request/pkg/helpers"
+)
+
+// BooleanMode controls the strings accepted as booleans, e.g. in parameters, to match the parsing of the backend
+type BooleanMode string
+
+const (
+	// BooleansLenient accepts `true` and `false` in any case
+	BooleansLenient BooleanMode = ""
+	// BooleansStrict only accepts `true` and `false`
+	BooleansStrict BooleanMode = "strict"
+	// BooleansNumeric accepts `true` and `false`, and `1` and `0`
+	BooleansNumeric BooleanMode = "numeric"
+)
+
+// WithBooleanMode sets the strings accepted as booleans
+func WithBooleanMode(mode BooleanMode) Option {
+	return func(v *DefaultValidator) {
+		v.booleanMode = mode
+	}
+}
+
+// isBoolean reports whether a value is a boolean, or a string accepted as a boolean by the mode
+func (mode BooleanMode) isBoolean(value interface{}) bool {
+	str, ok := value.(string)
+	if !ok || mode == BooleansLenient {
+		return helpers.IsBoolean(value)
+	}
+	switch str {
+	case "true", "false":
+		return true
+	case "1", "0":
+		return mode == BooleansNumeric
+	default:
+		return false
+	}
+}
@@ -0,0 +1,63 @@
+package validation
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/lionelgarnier/validate-api-request/oas"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBooleanMode(t *testing.T) {
+	manager := oas.NewOASManager(nil, oas.FixedSelector(map[string]string{"test": "test"}))
+	err := manager.LoadAPI("test", []byte(`{
+        "openapi": "3.0.0",
+        "info": {"title": "Test API", "version": "1.0.0"},
+        "paths": {
+            "/pets": {
+                "get": {
+                    "parameters": [{"name": "dryRun", "in": "query", "schema": {"type": "boolean"}}]
+                }
+            }
+        }
+    }`))
+	assert.NoError(t, err)
+	spec, _ := manager.GetApiSpec("test")
+
+	tests := []struct {
+		name  string
+		mode  BooleanMode
+		value string
+		valid bool
+	}{
+		{name: "Lenient lowercase", mode: BooleansLenient, value: "true", valid: true},
+		{name: "Lenient any case", mode: BooleansLenient, value: "FALSE", valid: true},
+		{name: "Lenient digit", mode: BooleansLenient, value: "1", valid: false},
+		{name: "Strict lowercase", mode: BooleansStrict, value: "false", valid: true},
+		{name: "Strict any case", mode: BooleansStrict, value: "True", valid: false},
+		{name: "Strict digit", mode: BooleansStrict, value: "0", valid: false},
+		{name: "Numeric lowercase", mode: BooleansNumeric, value: "true", valid: true},
+		{name: "Numeric digits", mode: BooleansNumeric, value: "1", valid: true},
+		{name: "Numeric any case", mode: BooleansNumeric, value: "TRUE", valid: false},
+		{name: "Numeric other digit", mode: BooleansNumeric, value: "2", valid: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequest(http.MethodGet, "/pets?dryRun="+tt.value, nil)
+			assert.NoError(t, err)
+
+			ok, err := NewValidator(spec, WithBooleanMode(tt.mode)).ValidateParameters(oas.NewOASRequest(req))
+			assert.Equal(t, tt.valid, ok)
+			if tt.valid {
+				assert.NoError(t, err)
+			} else {
+				assert.Error(t, err)
+			}
+		})
+	}
+
+	// JSON booleans are accepted whatever the mode
+	ok := NewValidator(spec, WithBooleanMode(BooleansStrict)).ValidateSchema(true, &oas.Schema{Type: "boolean"})
+	assert.True(t, ok)
+}
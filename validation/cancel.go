@@ -0,0 +1,24 @@
+package validation
+
+import (
+	"github.com/lionelgarnier/validate-api-request/oas"
+)
+
+// canceled returns the failure of a request whose context is done, e.g. because its client disconnected, so that
+// its validation stops at the stage instead of wasting work on a response nobody reads. It returns nil otherwise.
+func canceled(req *oas.OASRequest, stage Stage) error {
+	err := req.Request.Context().Err()
+	if err == nil {
+		return nil
+	}
+	return &ValidationError{Stage: stage, Category: CategoryCanceled, Message: "request canceled", Err: err}
+}
+
+// readFailure returns the failure of a request body that could not be read, canceled when the reading failed
+// because the request was
+func readFailure(req *oas.OASRequest, err error, specPointer string) error {
+	if failure := canceled(req, StageBody); failure != nil {
+		return failure
+	}
+	return &ValidationError{Stage: StageBody, Category: CategoryMalformedBody, Message: "failed to read request body", Err: err, SpecPointer: specPointer}
+}
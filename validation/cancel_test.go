@@ -0,0 +1,101 @@
+package validation
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/lionelgarnier/validate-api-request/oas"
+	"github.com/stretchr/testify/assert"
+)
+
+// countdownContext is canceled once its Err method has been called a number of times
+type countdownContext struct {
+	context.Context
+	remaining int
+}
+
+func (c *countdownContext) Err() error {
+	if c.remaining <= 0 {
+		return context.Canceled
+	}
+	c.remaining--
+	return nil
+}
+
+// failingReader fails reading, like the body of a disconnected client
+type failingReader struct{}
+
+func (failingReader) Read([]byte) (int, error) {
+	return 0, errors.New("connection reset by peer")
+}
+
+func TestCancellation(t *testing.T) {
+	manager := oas.NewOASManager(nil, oas.FixedSelector(map[string]string{"test": "test"}))
+	err := manager.LoadAPI("test", []byte(`{
+        "openapi": "3.0.0",
+        "info": {"title": "Test API", "version": "1.0.0"},
+        "paths": {
+            "/pets": {
+                "post": {
+                    "parameters": [{"name": "limit", "in": "query", "schema": {"type": "integer"}}],
+                    "requestBody": {
+                        "content": {"application/json": {"schema": {"type": "array", "items": {"type": "integer"}}}}
+                    }
+                }
+            }
+        }
+    }`))
+	assert.NoError(t, err)
+	spec, _ := manager.GetApiSpec("test")
+	items := strings.TrimSuffix(strings.Repeat("1,", 500), ",")
+
+	tests := []struct {
+		name     string
+		options  []Option
+		body     io.Reader
+		checks   int // Checks of the cancellation before the request is canceled, -1 for never
+		stage    Stage
+		category Category
+	}{
+		{name: "Not canceled", body: strings.NewReader("[" + items + "]"), checks: -1},
+		{name: "Canceled before validation", body: strings.NewReader("[" + items + "]"), checks: 0, stage: StageParameters, category: CategoryCanceled},
+		{name: "Canceled before the body", body: strings.NewReader("[" + items + "]"), checks: 1, stage: StageBody, category: CategoryCanceled},
+		{name: "Canceled while validating the body", body: strings.NewReader("[" + items + "]"), checks: 4, stage: StageBody, category: CategoryCanceled},
+		{name: "Canceled while scanning the body", options: []Option{WithBodyBackend(BodyBackendScan)}, body: strings.NewReader("[" + items + "]"), checks: 3, stage: StageBody, category: CategoryCanceled},
+		{name: "Disconnected while reading the body", body: failingReader{}, checks: 2, stage: StageBody, category: CategoryCanceled},
+		{name: "Body read failure", body: failingReader{}, checks: -1, stage: StageBody, category: CategoryMalformedBody},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Context(context.Background())
+			if tt.checks >= 0 {
+				ctx = &countdownContext{Context: ctx, remaining: tt.checks}
+			}
+			req, err := http.NewRequestWithContext(ctx, http.MethodPost, "/pets?limit=10", tt.body)
+			assert.NoError(t, err)
+			req.Header.Set("Content-Type", "application/json")
+			oasRequest := oas.NewOASRequest(req)
+
+			ok, err := NewValidator(spec, tt.options...).ValidateRequest(oasRequest)
+			if tt.category == "" {
+				assert.True(t, ok, err)
+				assert.Equal(t, 502, oasRequest.Evaluations)
+				return
+			}
+			assert.False(t, ok)
+			var validationErr *ValidationError
+			assert.True(t, errors.As(err, &validationErr), err)
+			assert.Equal(t, tt.stage, validationErr.Stage)
+			assert.Equal(t, tt.category, CategoryOf(err))
+			assert.Less(t, oasRequest.Evaluations, 502)
+			if tt.category == CategoryCanceled {
+				assert.ErrorContains(t, err, context.Canceled.Error())
+			}
+		})
+	}
+}
@@ -0,0 +1,79 @@
+package validation
+
+import (
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/lionelgarnier/validate-api-request/oas"
+)
+
+// challenges returns the WWW-Authenticate challenges of the security schemes of the requirements (RFC 7235).
+// The realm is the API name. API keys and digest authentication have no challenge a client can answer.
+func (v *DefaultValidator) challenges(r *http.Request, requirements []oas.SecurityRequirement) []string {
+	var challenges []string
+	seen := make(map[string]bool)
+	for _, requirement := range requirements {
+		names := make([]string, 0, len(requirement))
+		for name := range requirement {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		for _, name := range names {
+			challenge := v.challenge(r, name, requirement[name])
+			if challenge != "" && !seen[challenge] {
+				seen[challenge] = true
+				challenges = append(challenges, challenge)
+			}
+		}
+	}
+	return challenges
+}
+
+// challenge returns the WWW-Authenticate challenge of a security scheme, or an empty string if it has none
+func (v *DefaultValidator) challenge(r *http.Request, name string, scopes []string) string {
+	if v.apiSpec.Components == nil {
+		return ""
+	}
+	secScheme, exists := v.apiSpec.Components.SecuritySchemes[name]
+	if !exists {
+		return ""
+	}
+	secScheme, err := v.apiSpec.ResolveSecurityScheme(secScheme)
+	if err != nil {
+		return ""
+	}
+
+	realm := "realm=" + quoteAuthParam(v.apiSpec.Name)
+	switch {
+	case secScheme.Type == "http" && strings.EqualFold(secScheme.Scheme, "basic"):
+		return "Basic " + realm + `, charset="UTF-8"`
+	case secScheme.Type == "http" && strings.EqualFold(secScheme.Scheme, "bearer"):
+		// Tokens without the surface of the bearer format of the scheme are invalid tokens
+		if token, ok := bearerToken(r); ok && token != "" {
+			if err := checkBearerFormat(token, secScheme); err != nil {
+				return "Bearer " + realm + bearerChallengeParams(scopes) + `, error="invalid_token", error_description=` + quoteAuthParam(err.Error())
+			}
+		}
+		return "Bearer " + realm + bearerChallengeParams(scopes)
+	case secScheme.Type == "oauth2", secScheme.Type == "openIdConnect":
+		return "Bearer " + realm + bearerChallengeParams(scopes)
+	default:
+		return ""
+	}
+}
+
+// bearerChallengeParams returns the scope parameter of a Bearer challenge (RFC 6750 section 3). Requests sending
+// no credentials, including an empty bearer token, get no error code.
+func bearerChallengeParams(scopes []string) string {
+	if len(scopes) == 0 {
+		return ""
+	}
+	return ", scope=" + quoteAuthParam(strings.Join(scopes, " "))
+}
+
+// quoteAuthParam returns an authentication parameter value as a quoted string
+func quoteAuthParam(value string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(value) + `"`
+}
@@ -0,0 +1,369 @@
+package validation
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/lionelgarnier/validate-api-request/oas"
+)
+
+// Support is the level of enforcement of an OpenAPI feature by the validator
+type Support string
+
+const (
+	SupportEnforced    Support = "enforced"    // Requests not conforming are rejected
+	SupportPartial     Support = "partial"     // Only part of the feature is checked, see the note
+	SupportAnnotation  Support = "annotation"  // Documentation only, nothing to enforce
+	SupportUnsupported Support = "unsupported" // Not checked, or requests using it are rejected
+)
+
+// ConformanceReport compares the OpenAPI features a spec uses with their enforcement by the validator, with the
+// coverage of its paths by the requests observed since it was loaded, e.g. to certify gateways against
+// governance requirements
+type ConformanceReport struct {
+	API      string        `json:"api"`
+	Version  string        `json:"version,omitempty"`
+	Keywords []Conformance `json:"keywords"` // Schema keywords
+	Formats  []Conformance `json:"formats"`  // Schema formats
+	Features []Conformance `json:"features"` // Parameter styles, body media types, security schemes, callbacks and links
+	Coverage Coverage      `json:"coverage"`
+}
+
+// Conformance is the use of an OpenAPI feature by a spec and its enforcement by the validator
+type Conformance struct {
+	Name    string  `json:"name"`
+	Uses    int     `json:"uses"`
+	Support Support `json:"support"`
+	Note    string  `json:"note,omitempty"`
+}
+
+// Coverage is the share of the paths of a spec requested since it was loaded
+type Coverage struct {
+	Paths     int      `json:"paths"`
+	Requested int      `json:"requested"`
+	Ratio     float64  `json:"ratio"`
+	Missing   []string `json:"missing,omitempty"` // Paths never requested
+}
+
+// keywordSupport is the enforcement of the schema keywords, keywords missing from it are not checked
+var keywordSupport = map[string]Conformance{
+	"$ref":                  {Support: SupportEnforced},
+	"type":                  {Support: SupportEnforced},
+	"properties":            {Support: SupportEnforced},
+	"patternProperties":     {Support: SupportEnforced},
+	"additionalProperties":  {Support: SupportEnforced},
+	"unevaluatedProperties": {Support: SupportEnforced},
+	"unevaluatedItems":      {Support: SupportEnforced},
+	"prefixItems":           {Support: SupportEnforced},
+	"items":                 {Support: SupportEnforced},
+	"required":              {Support: SupportEnforced},
+	"dependentRequired":     {Support: SupportEnforced},
+	"dependentSchemas":      {Support: SupportEnforced},
+	"enum":                  {Support: SupportEnforced},
+	"const":                 {Support: SupportEnforced},
+	"allOf":                 {Support: SupportEnforced},
+	"oneOf":                 {Support: SupportEnforced},
+	"anyOf":                 {Support: SupportEnforced},
+	"not":                   {Support: SupportEnforced},
+	"discriminator":         {Support: SupportEnforced},
+	"nullable":              {Support: SupportEnforced},
+	"maximum":               {Support: SupportEnforced},
+	"minimum":               {Support: SupportEnforced},
+	"exclusiveMaximum":      {Support: SupportEnforced},
+	"exclusiveMinimum":      {Support: SupportEnforced},
+	"multipleOf":            {Support: SupportEnforced},
+	"minLength":             {Support: SupportEnforced},
+	"maxLength":             {Support: SupportEnforced},
+	"pattern":               {Support: SupportEnforced},
+	"minItems":              {Support: SupportEnforced},
+	"maxItems":              {Support: SupportEnforced},
+	"uniqueItems":           {Support: SupportEnforced},
+	"minProperties":         {Support: SupportEnforced},
+	"maxProperties":         {Support: SupportEnforced},
+	"format":                {Support: SupportPartial, Note: "see formats"},
+	"deprecated":            {Support: SupportPartial, Note: "reported, not rejected"},
+	"readOnly":              {Support: SupportPartial, Note: "rejected or removed from request bodies when configured"},
+	"writeOnly":             {Support: SupportEnforced, Note: "rejected in responses, removed from rewritten responses"},
+	"title":                 {Support: SupportAnnotation},
+	"description":           {Support: SupportAnnotation},
+	"default":               {Support: SupportAnnotation},
+	"example":               {Support: SupportAnnotation},
+	"examples":              {Support: SupportAnnotation},
+	"xml":                   {Support: SupportAnnotation},
+}
+
+// formatSupport is the enforcement of the string formats, unknown formats are accepted
+var formatSupport = map[string]Conformance{
+	"uuid":                  {Support: SupportEnforced},
+	"email":                 {Support: SupportEnforced},
+	"uri":                   {Support: SupportEnforced},
+	"url":                   {Support: SupportEnforced},
+	"hostname":              {Support: SupportEnforced},
+	"ipv4":                  {Support: SupportEnforced},
+	"ipv6":                  {Support: SupportEnforced},
+	"byte":                  {Support: SupportEnforced},
+	"date":                  {Support: SupportEnforced, Note: "RFC 3339 full-date"},
+	"date-time":             {Support: SupportEnforced, Note: "RFC 3339 date-time"},
+	"time":                  {Support: SupportEnforced, Note: "RFC 3339 partial-time, with an optional offset"},
+	"duration":              {Support: SupportEnforced, Note: "ISO 8601"},
+	"uri-reference":         {Support: SupportEnforced, Note: "RFC 3986"},
+	"uri-template":          {Support: SupportEnforced, Note: "RFC 6570"},
+	"json-pointer":          {Support: SupportEnforced, Note: "RFC 6901"},
+	"relative-json-pointer": {Support: SupportEnforced},
+	"regex":                 {Support: SupportPartial, Note: "Go RE2 syntax, like pattern"},
+	"binary":                {Support: SupportPartial, Note: "lengths count bytes"},
+	"password":              {Support: SupportAnnotation},
+}
+
+// styleSupport is the enforcement of the serialization styles of parameters, by location and style
+var styleSupport = map[string]Conformance{
+	"query form":           {Support: SupportEnforced},
+	"query pipeDelimited":  {Support: SupportEnforced},
+	"query spaceDelimited": {Support: SupportEnforced},
+	"query deepObject":     {Support: SupportEnforced},
+	"path simple":          {Support: SupportEnforced},
+	"header simple":        {Support: SupportEnforced},
+	"cookie form":          {Support: SupportEnforced},
+}
+
+// NewConformanceReport returns the conformance report of a spec for a validator configured with the options, e.g.
+// its decoders
+func NewConformanceReport(spec *oas.APISpec, opts ...Option) ConformanceReport {
+	v := NewValidator(spec, opts...).(*DefaultValidator)
+	summary := spec.Summary()
+
+	report := ConformanceReport{
+		API:      spec.Name,
+		Version:  spec.Version(),
+		Keywords: conformances(summary.SchemaKeywords, keywordSupport, Conformance{Support: SupportUnsupported, Note: "not checked"}),
+		Formats:  v.formatConformances(summary.Formats),
+		Features: v.features(),
+		Coverage: coverage(spec),
+	}
+	return report
+}
+
+// conformances returns the conformance of the features used by a spec, sorted by name
+func conformances(uses map[string]int, support map[string]Conformance, fallback Conformance) []Conformance {
+	result := []Conformance{}
+	for _, name := range slices.Sorted(maps.Keys(uses)) {
+		conformance, exists := support[name]
+		if !exists {
+			conformance = fallback
+		}
+		conformance.Name = name
+		conformance.Uses = uses[name]
+		result = append(result, conformance)
+	}
+	return result
+}
+
+// features returns the conformance of the parameter styles, request body media types, security schemes, callbacks
+// and links used by the operations of the spec
+func (v *DefaultValidator) features() []Conformance {
+	features := make(map[string]Conformance)
+	use := func(name string, conformance Conformance) {
+		if existing, exists := features[name]; exists {
+			conformance = existing
+		}
+		conformance.Name = name
+		conformance.Uses++
+		features[name] = conformance
+	}
+
+	for _, path := range v.apiSpec.Paths {
+		item, err := path.PathItem()
+		if err != nil || item == nil {
+			continue
+		}
+		pathParameters, _ := v.resolveParameters(item.Parameters)
+		for _, operation := range pathItemOperations(item) {
+			operationParameters, _ := v.resolveParameters(operation.Parameters)
+			for _, param := range mergeParameters(pathParameters, operationParameters) {
+				name, conformance := parameterConformance(param)
+				use(name, conformance)
+			}
+			if operation.RequestBody != nil {
+				if body, err := v.apiSpec.ResolveRequestBody(operation.RequestBody); err == nil {
+					for key, mediaType := range body.Content {
+						use("request body "+key, v.mediaTypeConformance(key, mediaType))
+					}
+				}
+			}
+			for _, name := range v.operationSchemes(operation) {
+				use("security "+name, v.securityConformance(name))
+			}
+			if len(operation.Callbacks) > 0 {
+				use("callbacks", Conformance{Support: SupportUnsupported, Note: "callback requests are not validated"})
+			}
+			for _, response := range operation.Responses {
+				if len(response.Links) > 0 {
+					use("links", Conformance{Support: SupportAnnotation})
+				}
+			}
+		}
+	}
+
+	result := []Conformance{}
+	for _, name := range slices.Sorted(maps.Keys(features)) {
+		result = append(result, features[name])
+	}
+	return result
+}
+
+// formatConformances returns the conformance of the formats used by a spec: registered formats are enforced, and
+// unknown formats are rejected or accepted as any string depending on the validator
+func (v *DefaultValidator) formatConformances(uses map[string]int) []Conformance {
+	support := maps.Clone(formatSupport)
+	for name := range uses {
+		if _, registered := registeredFormat(name); registered {
+			support[name] = Conformance{Support: SupportEnforced, Note: "registered"}
+		}
+	}
+	unknown := Conformance{Support: SupportUnsupported, Note: "accepted as any string"}
+	if v.unknownFormats == UnknownFormatsFail {
+		unknown.Note = "rejected as unknown"
+	}
+	return conformances(uses, support, unknown)
+}
+
+// pathItemOperations returns the operations of a path item
+func pathItemOperations(item *oas.PathItem) []*oas.Operation {
+	var operations []*oas.Operation
+	for _, operation := range []*oas.Operation{item.Get, item.Put, item.Post, item.Delete, item.Options, item.Head, item.Patch, item.Trace} {
+		if operation != nil {
+			operations = append(operations, operation)
+		}
+	}
+	return operations
+}
+
+// parameterConformance returns the feature name and conformance of a parameter, by location and style
+func parameterConformance(param oas.Parameter) (string, Conformance) {
+	if param.Schema == nil && len(param.Content) > 0 {
+		return param.In + " parameter content", Conformance{Support: SupportEnforced}
+	}
+	name := param.In + " " + param.SerializationStyle()
+	conformance, exists := styleSupport[name]
+	if !exists {
+		conformance = Conformance{Support: SupportUnsupported, Note: "validated as a plain string"}
+	}
+	return name + " parameter", conformance
+}
+
+// mediaTypeConformance returns the conformance of a request body media type, enforced when it has a decoder
+func (v *DefaultValidator) mediaTypeConformance(key string, mediaType oas.MediaType) Conformance {
+	if _, isProto := protoMessageName(mediaType); isProto {
+		return Conformance{Support: SupportEnforced, Note: "protobuf"}
+	}
+	essence, _ := parseMediaType(key)
+	if essence == "multipart/form-data" {
+		return Conformance{Support: SupportEnforced}
+	}
+	if _, exists := v.registeredDecoder(key); exists {
+		return Conformance{Support: SupportEnforced}
+	}
+	if v.isBinaryBody(key, mediaType) {
+		return Conformance{Support: SupportEnforced, Note: "binary, lengths only"}
+	}
+	return Conformance{Support: SupportPartial, Note: "no decoder, decoded as JSON"}
+}
+
+// operationSchemes returns the names of the security schemes required by an operation
+func (v *DefaultValidator) operationSchemes(operation *oas.Operation) []string {
+	requirements := operation.Security
+	if requirements == nil {
+		requirements = v.apiSpec.Security
+	}
+	var names []string
+	for _, requirement := range requirements {
+		for name := range requirement {
+			if !slices.Contains(names, name) {
+				names = append(names, name)
+			}
+		}
+	}
+	return names
+}
+
+// securityConformance returns the conformance of a security scheme, whose credentials are checked for presence
+func (v *DefaultValidator) securityConformance(name string) Conformance {
+	if v.apiSpec.Components == nil {
+		return Conformance{Support: SupportUnsupported, Note: "scheme not defined"}
+	}
+	secScheme, exists := v.apiSpec.Components.SecuritySchemes[name]
+	if !exists {
+		return Conformance{Support: SupportUnsupported, Note: "scheme not defined"}
+	}
+	secScheme, err := v.apiSpec.ResolveSecurityScheme(secScheme)
+	if err != nil {
+		return Conformance{Support: SupportUnsupported, Note: "scheme not defined"}
+	}
+
+	switch secScheme.Type {
+	case "apiKey":
+		return Conformance{Support: SupportPartial, Note: fmt.Sprintf("apiKey in %s: presence only", secScheme.In)}
+	case "http":
+		if _, checked := bearerFormats[strings.ToLower(secScheme.BearerFormat)]; checked && strings.EqualFold(secScheme.Scheme, "bearer") {
+			return Conformance{Support: SupportPartial, Note: fmt.Sprintf("http bearer: presence and %s format, not verified", secScheme.BearerFormat)}
+		}
+		return Conformance{Support: SupportPartial, Note: fmt.Sprintf("http %s: presence only", strings.ToLower(secScheme.Scheme))}
+	case "oauth2", "openIdConnect":
+		return Conformance{Support: SupportPartial, Note: secScheme.Type + ": bearer token presence only"}
+	default:
+		return Conformance{Support: SupportUnsupported, Note: fmt.Sprintf("%s: requests are rejected", secScheme.Type)}
+	}
+}
+
+// coverage returns the share of the paths of a spec requested since it was loaded
+func coverage(spec *oas.APISpec) Coverage {
+	result := Coverage{Paths: len(spec.Paths)}
+	for _, route := range slices.Sorted(maps.Keys(spec.Paths)) {
+		if spec.Paths[route].Hits.Total() > 0 {
+			result.Requested++
+		} else {
+			result.Missing = append(result.Missing, route)
+		}
+	}
+	if result.Paths > 0 {
+		result.Ratio = float64(result.Requested) / float64(result.Paths)
+	}
+	return result
+}
+
+// Markdown returns the report as a Markdown document
+func (r ConformanceReport) Markdown() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# Conformance of %s", r.API)
+	if r.Version != "" {
+		fmt.Fprintf(&b, " %s", r.Version)
+	}
+	b.WriteString("\n\n")
+
+	fmt.Fprintf(&b, "## Coverage\n\n%d of %d paths requested (%.0f%%)\n", r.Coverage.Requested, r.Coverage.Paths, r.Coverage.Ratio*100)
+	for _, route := range r.Coverage.Missing {
+		fmt.Fprintf(&b, "- `%s` never requested\n", route)
+	}
+
+	for _, section := range []struct {
+		title        string
+		conformances []Conformance
+	}{
+		{"Schema keywords", r.Keywords},
+		{"Formats", r.Formats},
+		{"Features", r.Features},
+	} {
+		fmt.Fprintf(&b, "\n## %s\n\n", section.title)
+		if len(section.conformances) == 0 {
+			b.WriteString("None used\n")
+			continue
+		}
+		b.WriteString("| Name | Uses | Support | Note |\n| --- | --- | --- | --- |\n")
+		for _, c := range section.conformances {
+			fmt.Fprintf(&b, "| `%s` | %d | %s | %s |\n", c.Name, c.Uses, c.Support, c.Note)
+		}
+	}
+	return b.String()
+}
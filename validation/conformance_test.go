@@ -0,0 +1,99 @@
+package validation
+
+import (
+	"testing"
+	"time"
+
+	"github.com/lionelgarnier/validate-api-request/oas"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewConformanceReport(t *testing.T) {
+	manager := oas.NewOASManager(nil, oas.FixedSelector(map[string]string{"test": "test"}))
+	err := manager.LoadAPI("test", []byte(`{
+        "openapi": "3.1.0",
+        "info": {"title": "Test API", "version": "1.2.0"},
+        "paths": {
+            "/pets": {
+                "get": {
+                    "parameters": [
+                        {"name": "tags", "in": "query", "style": "pipeDelimited", "schema": {"type": "array", "items": {"type": "string"}}},
+                        {"name": "color", "in": "path", "style": "matrix", "required": true, "schema": {"type": "string"}}
+                    ],
+                    "responses": {"200": {"description": "OK"}}
+                },
+                "post": {
+                    "security": [{"bearer": []}],
+                    "requestBody": {"content": {
+                        "application/json": {"schema": {"type": "object", "properties": {
+                            "name": {"type": "string", "format": "email", "description": "Owner"},
+                            "code": {"type": "string", "format": "iban"},
+                            "tags": {"type": "array", "maxItems": 3, "maxProperties": 2}
+                        }}},
+                        "application/cbor": {"schema": {"type": "object"}}
+                    }},
+                    "responses": {"201": {"description": "Created"}}
+                }
+            },
+            "/owners": {
+                "get": {"security": [{"mtls": []}], "responses": {"200": {"description": "OK"}}}
+            }
+        },
+        "components": {
+            "securitySchemes": {
+                "bearer": {"type": "http", "scheme": "bearer", "bearerFormat": "JWT"},
+                "mtls": {"type": "mutualTLS"}
+            }
+        }
+    }`))
+	assert.NoError(t, err)
+	spec, err := manager.GetApiSpec("test")
+	assert.NoError(t, err)
+	spec.Paths["/pets"].Hits.Add(time.Now())
+
+	report := NewConformanceReport(spec)
+	assert.Equal(t, "test", report.API)
+	assert.Equal(t, "1.2.0", report.Version)
+
+	keywords := make(map[string]Conformance)
+	for _, keyword := range report.Keywords {
+		keywords[keyword.Name] = keyword
+	}
+	assert.Equal(t, SupportEnforced, keywords["maxItems"].Support)
+	assert.Equal(t, SupportPartial, keywords["format"].Support)
+	assert.Equal(t, SupportAnnotation, keywords["description"].Support)
+	assert.Equal(t, SupportEnforced, keywords["maxProperties"].Support)
+	assert.Equal(t, 2, keywords["format"].Uses)
+
+	assert.Equal(t, []Conformance{
+		{Name: "email", Uses: 1, Support: SupportEnforced},
+		{Name: "iban", Uses: 1, Support: SupportUnsupported, Note: "accepted as any string"},
+	}, report.Formats)
+
+	features := make(map[string]Conformance)
+	for _, feature := range report.Features {
+		features[feature.Name] = feature
+	}
+	assert.Equal(t, SupportEnforced, features["query pipeDelimited parameter"].Support)
+	assert.Equal(t, SupportUnsupported, features["path matrix parameter"].Support)
+	assert.Equal(t, SupportEnforced, features["request body application/json"].Support)
+	assert.Equal(t, SupportPartial, features["request body application/cbor"].Support)
+	assert.Equal(t, SupportPartial, features["security bearer"].Support)
+	assert.Contains(t, features["security bearer"].Note, "JWT format")
+	assert.Equal(t, SupportUnsupported, features["security mtls"].Support)
+
+	assert.Equal(t, Coverage{Paths: 2, Requested: 1, Ratio: 0.5, Missing: []string{"/owners"}}, report.Coverage)
+
+	decoded := NewConformanceReport(spec, WithDecoder("application/cbor", JSONDecoder))
+	for _, feature := range decoded.Features {
+		if feature.Name == "request body application/cbor" {
+			assert.Equal(t, SupportEnforced, feature.Support)
+		}
+	}
+
+	markdown := report.Markdown()
+	assert.Contains(t, markdown, "# Conformance of test 1.2.0")
+	assert.Contains(t, markdown, "1 of 2 paths requested (50%)")
+	assert.Contains(t, markdown, "- `/owners` never requested")
+	assert.Contains(t, markdown, "| `maxProperties` | 1 | enforced |  |")
+}
@@ -0,0 +1,161 @@
+package validation
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"reflect"
+	"strings"
+)
+
+// BodyDecoder decodes a request or response body into the value validated by schemas,
+// made of map[string]interface{}, []interface{}, string, float64 or json.Number, bool and nil values
+type BodyDecoder func(body io.Reader) (interface{}, error)
+
+// JSONDecoder decodes JSON bodies
+func JSONDecoder(body io.Reader) (interface{}, error) {
+	var value interface{}
+	if err := json.NewDecoder(body).Decode(&value); err != nil {
+		return nil, err
+	}
+	return value, nil
+}
+
+// PreciseJSONDecoder decodes JSON bodies with their numbers as json.Number, so that they are validated exactly,
+// e.g. int64 identifiers that float64 would round
+func PreciseJSONDecoder(body io.Reader) (interface{}, error) {
+	decoder := json.NewDecoder(body)
+	decoder.UseNumber()
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil {
+		return nil, err
+	}
+	return value, nil
+}
+
+// WithPreciseNumbers decodes the numbers of JSON bodies as json.Number rather than float64, to validate the
+// integers beyond 2^53, such as int64 identifiers, without rounding them. JSONDecoder is replaced with
+// PreciseJSONDecoder, other registered decoders are unchanged.
+func WithPreciseNumbers(enabled bool) Option {
+	return func(v *DefaultValidator) {
+		v.preciseNumbers = enabled
+	}
+}
+
+// floatNumbers returns a value with its json.Number values converted to float64, like the values of schemas
+func floatNumbers(value interface{}) interface{} {
+	switch typed := value.(type) {
+	case json.Number:
+		if number, err := typed.Float64(); err == nil {
+			return number
+		}
+	case map[string]interface{}:
+		converted := make(map[string]interface{}, len(typed))
+		for key, item := range typed {
+			converted[key] = floatNumbers(item)
+		}
+		return converted
+	case []interface{}:
+		converted := make([]interface{}, len(typed))
+		for i, item := range typed {
+			converted[i] = floatNumbers(item)
+		}
+		return converted
+	}
+	return value
+}
+
+// DefaultDecoders returns the decoders of the media types supported out of the box, keyed by media type.
+// Keys are media types (`application/json`), ranges (`text/*`, `*/*`) or structured syntax suffixes (`*/*+json`).
+func DefaultDecoders() map[string]BodyDecoder {
+	return map[string]BodyDecoder{
+		"application/json":          JSONDecoder,
+		"*/*+json":                  JSONDecoder,
+		"text/csv":                  CSVDecoder(','),
+		"text/tab-separated-values": CSVDecoder('\t'),
+	}
+}
+
+// CSVDecoder returns a decoder of delimited bodies, e.g. CSV or TSV, into an array of objects.
+// The header row gives the property names of the cells of the following rows. Empty cells are omitted, so they
+// fail `required` rather than the type of their property. Cells are strings, like parameters they are coerced to
+// the integer, number or boolean type of their property during validation.
+func CSVDecoder(comma rune) BodyDecoder {
+	return func(body io.Reader) (interface{}, error) {
+		reader := csv.NewReader(body)
+		reader.Comma = comma
+
+		header, err := reader.Read()
+		if err == io.EOF {
+			return []interface{}{}, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		if len(header) > 0 {
+			header[0] = strings.TrimPrefix(header[0], "\ufeff")
+		}
+		for i := range header {
+			header[i] = strings.TrimSpace(header[i])
+		}
+
+		rows := []interface{}{}
+		for {
+			record, err := reader.Read()
+			if err == io.EOF {
+				return rows, nil
+			}
+			if err != nil {
+				return nil, err
+			}
+
+			row := make(map[string]interface{}, len(record))
+			for i, cell := range record {
+				if cell != "" {
+					row[header[i]] = cell
+				}
+			}
+			rows = append(rows, row)
+		}
+	}
+}
+
+// WithDecoder registers the decoder of the bodies of a media type, range or structured syntax suffix
+func WithDecoder(mediaType string, decoder BodyDecoder) Option {
+	return func(v *DefaultValidator) {
+		v.decoders[strings.ToLower(mediaType)] = decoder
+	}
+}
+
+// decoder returns the decoder of a media type: the decoder of the media type itself, of its structured syntax suffix
+// (e.g. `*/*+json` for `application/vnd.company.v2+json`), of its range, then of any media type.
+// Bodies of media types without decoder are decoded as JSON.
+func (v *DefaultValidator) decoder(contentType string) BodyDecoder {
+	decoder, exists := v.registeredDecoder(contentType)
+	if !exists {
+		decoder = JSONDecoder
+	}
+	if v.preciseNumbers && reflect.ValueOf(decoder).Pointer() == reflect.ValueOf(JSONDecoder).Pointer() {
+		return PreciseJSONDecoder
+	}
+	return decoder
+}
+
+// registeredDecoder returns the decoder registered for a content type, by media type, suffix or range
+func (v *DefaultValidator) registeredDecoder(contentType string) (BodyDecoder, bool) {
+	essence, _ := parseMediaType(contentType)
+	mainType, subType, _ := strings.Cut(essence, "/")
+
+	candidates := []string{essence}
+	if i := strings.LastIndex(subType, "+"); i >= 0 {
+		candidates = append(candidates, "*/*"+subType[i:])
+	}
+	candidates = append(candidates, mainType+"/*", "*/*")
+
+	for _, candidate := range candidates {
+		if decoder, exists := v.decoders[candidate]; exists {
+			return decoder, true
+		}
+	}
+	return nil, false
+}
@@ -0,0 +1,155 @@
+package validation
+
+import (
+	"bufio"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/lionelgarnier/validate-api-request/oas"
+)
+
+// lineDecoder decodes `key=value` lines into an object
+func lineDecoder(body io.Reader) (interface{}, error) {
+	value := make(map[string]interface{})
+	scanner := bufio.NewScanner(body)
+	for scanner.Scan() {
+		key, field, _ := strings.Cut(scanner.Text(), "=")
+		value[key] = field
+	}
+	return value, scanner.Err()
+}
+
+func TestBodyDecoders(t *testing.T) {
+	manager := oas.NewOASManager(nil, oas.FixedSelector(map[string]string{"test": "test"}))
+	err := manager.LoadAPI("test", []byte(`{
+        "openapi": "3.0.0",
+        "info": {"title": "Test API", "version": "1.0.0"},
+        "paths": {
+            "/pet": {
+                "post": {
+                    "requestBody": {
+                        "content": {
+                            "application/json": {"schema": {"$ref": "#/components/schemas/Pet"}},
+                            "application/vnd.pets.v2+json": {"schema": {"$ref": "#/components/schemas/Pet"}},
+                            "text/x-lines": {"schema": {"$ref": "#/components/schemas/Pet"}}
+                        }
+                    }
+                }
+            }
+        },
+        "components": {
+            "schemas": {
+                "Pet": {"type": "object", "required": ["name"], "properties": {"name": {"type": "string"}}}
+            }
+        }
+    }`))
+	assert.NoError(t, err)
+	spec, _ := manager.GetApiSpec("test")
+	validator := NewValidator(spec, WithDecoder("text/*", lineDecoder))
+
+	tests := []struct {
+		name          string
+		contentType   string
+		body          string
+		expectedError string
+	}{
+		{name: "JSON", contentType: "application/json", body: `{"name": "Fluffy"}`},
+		{name: "Structured syntax suffix", contentType: "application/vnd.pets.v2+json", body: `{"name": "Fluffy"}`},
+		{name: "Registered range", contentType: "text/x-lines", body: "name=Fluffy\nkind=cat"},
+		{name: "Registered range failing schema", contentType: "text/x-lines", body: "kind=cat", expectedError: "request body does not match schema"},
+		{name: "Malformed body", contentType: "application/json", body: `name=Fluffy`, expectedError: "invalid request body"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequest(http.MethodPost, "/pet", strings.NewReader(tt.body))
+			assert.NoError(t, err)
+			req.Header.Set("Content-Type", tt.contentType)
+
+			ok, err := validator.ValidateRequestBody(oas.NewOASRequest(req))
+			if tt.expectedError != "" {
+				assert.False(t, ok)
+				assert.ErrorContains(t, err, tt.expectedError)
+			} else {
+				assert.True(t, ok)
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestCSVBodies(t *testing.T) {
+	manager := oas.NewOASManager(nil, oas.FixedSelector(map[string]string{"test": "test"}))
+	err := manager.LoadAPI("test", []byte(`{
+        "openapi": "3.0.0",
+        "info": {"title": "Test API", "version": "1.0.0"},
+        "paths": {
+            "/pets/bulk": {
+                "post": {
+                    "requestBody": {
+                        "content": {
+                            "text/csv": {"schema": {"$ref": "#/components/schemas/Pets"}},
+                            "text/tab-separated-values": {"schema": {"$ref": "#/components/schemas/Pets"}}
+                        }
+                    }
+                }
+            }
+        },
+        "components": {
+            "schemas": {
+                "Pets": {
+                    "type": "array",
+                    "maxItems": 2,
+                    "items": {
+                        "type": "object",
+                        "required": ["name"],
+                        "properties": {
+                            "name": {"type": "string"},
+                            "age": {"type": "integer", "minimum": 0},
+                            "vaccinated": {"type": "boolean"}
+                        }
+                    }
+                }
+            }
+        }
+    }`))
+	assert.NoError(t, err)
+	spec, _ := manager.GetApiSpec("test")
+	validator := NewValidator(spec)
+
+	tests := []struct {
+		name          string
+		contentType   string
+		body          string
+		expectedError string
+	}{
+		{name: "Valid CSV", contentType: "text/csv", body: "\ufeffname, age ,vaccinated\nFluffy,3,true\nRex,,false\n"},
+		{name: "Valid TSV", contentType: "text/tab-separated-values; charset=utf-8", body: "name\tage\nFluffy\t3\n"},
+		{name: "Header only", contentType: "text/csv", body: "name,age\n"},
+		{name: "Cell not matching its property type", contentType: "text/csv", body: "name,age\nFluffy,three\n", expectedError: "[0].age: expected integer"},
+		{name: "Empty required cell", contentType: "text/csv", body: "name,age\n,3\n", expectedError: "[0].name: required property is missing"},
+		{name: "Too many rows", contentType: "text/csv", body: "name\nFluffy\nRex\nTom\n", expectedError: "array must have at most 2 items"},
+		{name: "Ragged row", contentType: "text/csv", body: "name,age\nFluffy\n", expectedError: "invalid request body"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequest(http.MethodPost, "/pets/bulk", strings.NewReader(tt.body))
+			assert.NoError(t, err)
+			req.Header.Set("Content-Type", tt.contentType)
+
+			ok, err := validator.ValidateRequestBody(oas.NewOASRequest(req))
+			if tt.expectedError != "" {
+				assert.False(t, ok)
+				assert.ErrorContains(t, err, tt.expectedError)
+			} else {
+				assert.True(t, ok)
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
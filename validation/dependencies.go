@@ -0,0 +1,40 @@
+package validation
+
+import (
+	"maps"
+	"slices"
+
+	"github.com/lionelgarnier/validate-api-request/oas"
+)
+
+// validateDependencies validates the properties an object requires, and the schemas it must match, because some of
+// its properties are present: the dependentRequired and dependentSchemas keywords, or the legacy dependencies
+// keyword they are decoded from
+func (v *DefaultValidator) validateDependencies(obj map[string]interface{}, schema *oas.Schema, path string, state *schemaState) error {
+	var errs SchemaErrors
+	for _, propName := range slices.Sorted(maps.Keys(schema.DependentRequired)) {
+		if _, exists := obj[propName]; !exists {
+			continue
+		}
+		for _, dependency := range schema.DependentRequired[propName] {
+			if _, exists := obj[dependency]; exists {
+				continue
+			}
+			err := newSchemaError(propertyPath(path, dependency), "property is required when '%s' is present", propName)
+			if state.fail(&errs, err) {
+				return err
+			}
+		}
+	}
+
+	for _, propName := range slices.Sorted(maps.Keys(schema.DependentSchemas)) {
+		if _, exists := obj[propName]; !exists {
+			continue
+		}
+		schemaCopy := schema.DependentSchemas[propName]
+		if err := v.evaluateSubschema(obj, &schemaCopy, path, state, "dependentSchemas", propName); err != nil && state.fail(&errs, err) {
+			return err
+		}
+	}
+	return errs.errOrNil()
+}
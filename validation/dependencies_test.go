@@ -0,0 +1,59 @@
+package validation
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/lionelgarnier/validate-api-request/oas"
+)
+
+func TestValidateDependencies(t *testing.T) {
+	validator := NewValidator(&oas.APISpec{}).(*DefaultValidator)
+
+	keywords := `{
+		"type": "object",
+		"properties": {"creditCard": {"type": "string"}, "billingAddress": {"type": "string"}, "express": {"type": "boolean"}},
+		"dependentRequired": {"creditCard": ["billingAddress"]},
+		"dependentSchemas": {"express": {"required": ["phone"], "properties": {"phone": {"type": "string"}}}}
+	}`
+	legacy := `{
+		"type": "object",
+		"properties": {"creditCard": {"type": "string"}, "billingAddress": {"type": "string"}, "express": {"type": "boolean"}},
+		"dependencies": {
+			"creditCard": ["billingAddress"],
+			"express": {"required": ["phone"], "properties": {"phone": {"type": "string"}}}
+		}
+	}`
+
+	tests := []struct {
+		name          string
+		value         string
+		expectedError string
+	}{
+		{name: "Without dependent properties", value: `{"billingAddress": "1 Main St"}`},
+		{name: "Dependency present", value: `{"creditCard": "4111", "billingAddress": "1 Main St"}`},
+		{name: "Dependency missing", value: `{"creditCard": "4111"}`, expectedError: "billingAddress: property is required when 'creditCard' is present"},
+		{name: "Dependent schema matched", value: `{"express": true, "phone": "555-0100"}`},
+		{name: "Dependent schema required property", value: `{"express": true}`, expectedError: "phone: required property is missing"},
+		{name: "Dependent schema property type", value: `{"express": false, "phone": 5550100}`, expectedError: "phone: expected string"},
+	}
+
+	for _, form := range []struct{ name, schema string }{{"Keywords", keywords}, {"Legacy dependencies", legacy}} {
+		var schema oas.Schema
+		assert.NoError(t, json.Unmarshal([]byte(form.schema), &schema))
+		for _, tt := range tests {
+			t.Run(form.name+"/"+tt.name, func(t *testing.T) {
+				var value interface{}
+				assert.NoError(t, json.Unmarshal([]byte(tt.value), &value))
+				err := validator.validateSchema(value, &schema, "")
+				if tt.expectedError == "" {
+					assert.NoError(t, err)
+					return
+				}
+				assert.EqualError(t, err, tt.expectedError)
+			})
+		}
+	}
+}
@@ -0,0 +1,22 @@
+package validation
+
+import (
+	"slices"
+
+	"github.com/lionelgarnier/validate-api-request/oas"
+)
+
+// WithSunsetEnforcement rejects the requests to operations past the date of their `x-sunset` extension
+func WithSunsetEnforcement(enforce bool) Option {
+	return func(v *DefaultValidator) {
+		v.enforceSunset = enforce
+	}
+}
+
+// deprecate records a deprecated part of the spec used by the request, once per location and path
+func deprecate(req *oas.OASRequest, location, path string) {
+	deprecation := oas.Deprecation{Location: location, Path: path}
+	if !slices.Contains(req.Deprecations, deprecation) {
+		req.Deprecations = append(req.Deprecations, deprecation)
+	}
+}
@@ -0,0 +1,79 @@
+package validation
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/lionelgarnier/validate-api-request/oas"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDeprecations(t *testing.T) {
+	manager := oas.NewOASManager(nil, oas.FixedSelector(map[string]string{"test": "test"}))
+	err := manager.LoadAPI("test", []byte(`{
+        "openapi": "3.0.0",
+        "info": {"title": "Test API", "version": "1.0.0"},
+        "paths": {
+            "/pets": {
+                "get": {
+                    "deprecated": true,
+                    "parameters": [{"name": "limit", "in": "query", "schema": {"type": "integer"}}]
+                },
+                "post": {
+                    "parameters": [
+                        {"name": "dryRun", "in": "query", "deprecated": true, "schema": {"type": "boolean"}},
+                        {"name": "X-Legacy", "in": "header", "schema": {"type": "string", "deprecated": true}}
+                    ],
+                    "requestBody": {"content": {"application/json": {"schema": {"$ref": "#/components/schemas/Pet"}}}}
+                }
+            }
+        },
+        "components": {
+            "schemas": {
+                "Pet": {
+                    "type": "object",
+                    "properties": {
+                        "name": {"type": "string"},
+                        "nickname": {"type": "string", "deprecated": true},
+                        "owner": {"oneOf": [{"type": "string", "deprecated": true}, {"type": "integer"}]}
+                    }
+                }
+            }
+        }
+    }`))
+	assert.NoError(t, err)
+	spec, _ := manager.GetApiSpec("test")
+
+	tests := []struct {
+		name     string
+		method   string
+		path     string
+		headers  http.Header
+		body     string
+		expected []oas.Deprecation
+	}{
+		{name: "Deprecated operation", method: http.MethodGet, path: "/pets?limit=10", expected: []oas.Deprecation{{Location: "operation"}}},
+		{name: "Nothing deprecated used", method: http.MethodPost, path: "/pets", body: `{"name": "Rex", "owner": 42}`},
+		{name: "Deprecated parameter", method: http.MethodPost, path: "/pets?dryRun=true", body: `{}`, expected: []oas.Deprecation{{Location: "query", Path: "dryRun"}}},
+		{name: "Parameter with deprecated schema", method: http.MethodPost, path: "/pets", headers: http.Header{"X-Legacy": {"yes"}}, body: `{}`, expected: []oas.Deprecation{{Location: "header", Path: "X-Legacy"}}},
+		{name: "Deprecated properties", method: http.MethodPost, path: "/pets", body: `{"nickname": "R", "owner": "bob"}`, expected: []oas.Deprecation{{Location: "body", Path: "nickname"}, {Location: "body", Path: "owner"}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequest(tt.method, tt.path, strings.NewReader(tt.body))
+			assert.NoError(t, err)
+			req.Header.Set("Content-Type", "application/json")
+			for k, v := range tt.headers {
+				req.Header[k] = v
+			}
+
+			oasRequest := oas.NewOASRequest(req)
+			ok, err := NewValidator(spec).ValidateRequest(oasRequest)
+			assert.True(t, ok)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, oasRequest.Deprecations)
+		})
+	}
+}
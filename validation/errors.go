@@ -0,0 +1,319 @@
+package validation
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Stage identifies the validation step that produced an error
+type Stage string
+
+const (
+	StagePath       Stage = "path"
+	StageMethod     Stage = "method"
+	StageParameters Stage = "parameters"
+	StageBody       Stage = "body"
+	StageSecurity   Stage = "security"
+	StageResponse   Stage = "response"
+)
+
+// Category classifies a validation failure, e.g. to choose the HTTP status of the response
+type Category string
+
+const (
+	CategoryPathNotFound         Category = "pathNotFound"
+	CategoryMethodNotAllowed     Category = "methodNotAllowed"
+	CategorySunset               Category = "sunset" // Operation past the date of its `x-sunset` extension
+	CategoryInvalidParameter     Category = "invalidParameter"
+	CategoryLocaleNumber         Category = "localeNumber" // Number formatted with locale separators, e.g. "1.234,56"
+	CategoryUnsupportedMediaType Category = "unsupportedMediaType"
+	CategoryMalformedBody        Category = "malformedBody"
+	CategoryBodyTooLarge         Category = "bodyTooLarge" // Body exceeding the configured maximum size
+	CategoryInvalidBody          Category = "invalidBody"
+	CategoryComplexityExceeded   Category = "complexityExceeded" // Schemas nested or evaluated beyond the limits
+	CategoryCanceled             Category = "canceled"           // Request canceled, e.g. by the client disconnecting, while validated
+	CategoryUnauthorized         Category = "unauthorized"
+	CategoryMalformedCredentials Category = "malformedCredentials" // Bearer token without the surface of its bearerFormat
+	CategoryForbidden            Category = "forbidden"            // Credentials lacking the scopes required by the operation
+	CategoryUndeclaredStatus     Category = "undeclaredStatus"
+	CategoryInvalidResponse      Category = "invalidResponse"
+)
+
+// stageCategories are the categories of the failures of each stage, unless a failure sets its own
+var stageCategories = map[Stage]Category{
+	StagePath:       CategoryPathNotFound,
+	StageMethod:     CategoryMethodNotAllowed,
+	StageParameters: CategoryInvalidParameter,
+	StageBody:       CategoryInvalidBody,
+	StageSecurity:   CategoryUnauthorized,
+	StageResponse:   CategoryInvalidResponse,
+}
+
+// ValidationError is a failure of one of the request validation stages
+type ValidationError struct {
+	Stage    Stage
+	Category Category
+	Message  string
+	Err      error
+	Allow    []string // Methods allowed on the path, for CategoryMethodNotAllowed failures
+	// Challenges are the WWW-Authenticate challenges of the operation, for CategoryUnauthorized failures
+	Challenges []string
+	// SpecPointer locates the part of the spec the request violates, e.g. `#/paths/~1pet/post/requestBody`
+	SpecPointer string
+}
+
+// Error returns the stage message followed by its cause, if any
+func (e *ValidationError) Error() string {
+	if e.Err == nil {
+		return e.Message
+	}
+	return e.Message + ": " + e.Err.Error()
+}
+
+// Unwrap returns the cause of the error
+func (e *ValidationError) Unwrap() error {
+	return e.Err
+}
+
+// Violation is a validation failure located in the request and in the spec
+type Violation struct {
+	Message string `json:"message"`
+	Path    string `json:"path,omitempty"` // Instance path of the failing value, e.g. `items[3].owner.email`
+	Spec    string `json:"spec,omitempty"` // Pointer of the violated part of the spec, e.g. `#/paths/~1pet/post/requestBody`
+	Reason  string `json:"-"`              // Failure of the value itself, without the message of the stage
+}
+
+// Violations returns the violations of a validation failure, one per failure when every failure is collected
+func Violations(err error) []Violation {
+	var validationErrs ValidationErrors
+	if errors.As(err, &validationErrs) {
+		var violations []Violation
+		for _, validationErr := range validationErrs {
+			violations = append(violations, Violations(validationErr)...)
+		}
+		return violations
+	}
+
+	violation := Violation{Message: err.Error()}
+
+	var validationErr *ValidationError
+	if errors.As(err, &validationErr) {
+		violation.Spec = validationErr.SpecPointer
+		violation.Reason = validationErr.Message
+	}
+	var schemaErr *SchemaError
+	if errors.As(err, &schemaErr) {
+		violation.Path = schemaErr.Path
+		violation.Reason = schemaErr.Message
+	}
+	if violation.Reason == "" {
+		violation.Reason = violation.Message
+	}
+	return []Violation{violation}
+}
+
+// SchemaError is a value failing a schema keyword, located by its instance path (e.g. `items[3].owner.email`)
+type SchemaError struct {
+	Path    string
+	Message string
+	// SchemaPointer locates the failing schema: a JSON pointer relative to the validated schema,
+	// or a `#/...` pointer into the spec when the schema is behind a $ref
+	SchemaPointer string
+	// Category classifies the failure of the value when more specific than the category of its stage
+	Category Category
+}
+
+// Error returns the instance path followed by the failure message
+func (e *SchemaError) Error() string {
+	if e.Path == "" {
+		return e.Message
+	}
+	return e.Path + ": " + e.Message
+}
+
+// SchemaErrors lists the failures of a value when every failure is collected
+type SchemaErrors []*SchemaError
+
+// Error returns the failures separated by semicolons
+func (e SchemaErrors) Error() string {
+	messages := make([]string, len(e))
+	for i, schemaErr := range e {
+		messages[i] = schemaErr.Error()
+	}
+	return strings.Join(messages, "; ")
+}
+
+// Unwrap returns the failures
+func (e SchemaErrors) Unwrap() []error {
+	errs := make([]error, len(e))
+	for i, schemaErr := range e {
+		errs[i] = schemaErr
+	}
+	return errs
+}
+
+// add appends a failure, flattening lists of failures
+func (e *SchemaErrors) add(err error) {
+	switch failure := err.(type) {
+	case *SchemaError:
+		*e = append(*e, failure)
+	case SchemaErrors:
+		*e = append(*e, failure...)
+	default:
+		*e = append(*e, &SchemaError{Message: err.Error()})
+	}
+}
+
+// errOrNil returns nil without failures, the failure itself when there is only one, or the list
+func (e SchemaErrors) errOrNil() error {
+	switch len(e) {
+	case 0:
+		return nil
+	case 1:
+		return e[0]
+	default:
+		return e
+	}
+}
+
+// ValidationErrors lists the failures of a request when every failure is collected
+type ValidationErrors []*ValidationError
+
+// Error returns the failures separated by semicolons
+func (e ValidationErrors) Error() string {
+	messages := make([]string, len(e))
+	for i, validationErr := range e {
+		messages[i] = validationErr.Error()
+	}
+	return strings.Join(messages, "; ")
+}
+
+// Unwrap returns the failures, the first one gives the category of the list
+func (e ValidationErrors) Unwrap() []error {
+	errs := make([]error, len(e))
+	for i, validationErr := range e {
+		errs[i] = validationErr
+	}
+	return errs
+}
+
+// add appends a failure, flattening lists of failures
+func (e *ValidationErrors) add(err error) {
+	var validationErrs ValidationErrors
+	var validationErr *ValidationError
+	switch {
+	case errors.As(err, &validationErrs):
+		*e = append(*e, validationErrs...)
+	case errors.As(err, &validationErr):
+		*e = append(*e, validationErr)
+	default:
+		*e = append(*e, &ValidationError{Message: err.Error()})
+	}
+}
+
+// errOrNil returns nil without failures, the failure itself when there is only one, or the list
+func (e ValidationErrors) errOrNil() error {
+	switch len(e) {
+	case 0:
+		return nil
+	case 1:
+		return e[0]
+	default:
+		return e
+	}
+}
+
+// schemaFailures returns the failure of a value against a schema located at base in the spec,
+// split into a failure per schema error when every failure is collected
+func schemaFailures(failure ValidationError, base string, err error) error {
+	schemaErrs, ok := err.(SchemaErrors)
+	if !ok {
+		failure.Err = err
+		failure.SpecPointer = schemaPointer(base, err)
+		failure.Category = schemaCategory(failure.Category, err)
+		return &failure
+	}
+
+	failures := make(ValidationErrors, len(schemaErrs))
+	for i, schemaErr := range schemaErrs {
+		located := failure
+		located.Err = schemaErr
+		located.SpecPointer = schemaPointer(base, schemaErr)
+		located.Category = schemaCategory(located.Category, schemaErr)
+		failures[i] = &located
+	}
+	return failures
+}
+
+// schemaCategory returns the category of a schema failure when it has one, or the given category
+func schemaCategory(category Category, err error) Category {
+	var schemaErr *SchemaError
+	if errors.As(err, &schemaErr) && schemaErr.Category != "" {
+		return schemaErr.Category
+	}
+	return category
+}
+
+// newSchemaError builds a SchemaError at the given instance path
+func newSchemaError(path string, format string, args ...interface{}) *SchemaError {
+	return &SchemaError{Path: path, Message: fmt.Sprintf(format, args...)}
+}
+
+// stageError attaches a stage to an error not already produced by a stage
+func stageError(stage Stage, err error) error {
+	var validationErrs ValidationErrors
+	if errors.As(err, &validationErrs) {
+		for i, validationErr := range validationErrs {
+			validationErrs[i] = stageError(stage, validationErr).(*ValidationError)
+		}
+		return err
+	}
+
+	var validationErr *ValidationError
+	if errors.As(err, &validationErr) {
+		if validationErr.Stage == "" {
+			validationErr.Stage = stage
+		}
+		if validationErr.Category == "" {
+			validationErr.Category = stageCategories[validationErr.Stage]
+		}
+		return err
+	}
+	return &ValidationError{Stage: stage, Category: stageCategories[stage], Message: err.Error()}
+}
+
+// StageOf returns the stage of a validation failure, or an empty stage for other errors
+func StageOf(err error) Stage {
+	var validationErr *ValidationError
+	if !errors.As(err, &validationErr) {
+		return ""
+	}
+	return validationErr.Stage
+}
+
+// CategoryOf returns the category of a validation failure, or an empty category for other errors
+func CategoryOf(err error) Category {
+	var validationErr *ValidationError
+	if !errors.As(err, &validationErr) {
+		return ""
+	}
+	if validationErr.Category != "" {
+		return validationErr.Category
+	}
+	return stageCategories[validationErr.Stage]
+}
+
+// propertyPath returns the instance path of an object property
+func propertyPath(path, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
+
+// indexPath returns the instance path of an array item
+func indexPath(path string, index int) string {
+	return path + "[" + strconv.Itoa(index) + "]"
+}
@@ -0,0 +1,91 @@
+package validation
+
+import (
+	"sync"
+
+	"github.com/lionelgarnier/validate-api-request/pkg/helpers"
+)
+
+// FormatValidator reports whether a string value is valid for a format
+type FormatValidator func(value string) bool
+
+// UnknownFormatMode controls the validation of strings whose format is neither built in nor registered
+type UnknownFormatMode string
+
+const (
+	// UnknownFormatsIgnore accepts any string for unknown formats, as JSON Schema treats formats as annotations
+	UnknownFormatsIgnore UnknownFormatMode = ""
+	// UnknownFormatsFail rejects the strings of unknown formats, so that a format missing from the registry is noticed
+	UnknownFormatsFail UnknownFormatMode = "fail"
+)
+
+// annotationFormats are the formats accepting any string, such as `password` which only hints user interfaces
+var annotationFormats = map[string]bool{
+	"binary":   true,
+	"password": true,
+}
+
+// builtinFormats are the formats validated out of the box
+var builtinFormats = map[string]FormatValidator{
+	"uuid":                  func(value string) bool { return helpers.IsUUID(value) },
+	"email":                 func(value string) bool { return helpers.IsEmail(value) },
+	"uri":                   func(value string) bool { return helpers.IsURL(value) },
+	"url":                   func(value string) bool { return helpers.IsURL(value) },
+	"hostname":              func(value string) bool { return helpers.IsHostnameValid(value) },
+	"ipv4":                  func(value string) bool { return helpers.IsIPv4(value) },
+	"ipv6":                  func(value string) bool { return helpers.IsIPv6(value) },
+	"byte":                  func(value string) bool { return helpers.IsByte(value) },
+	"date":                  func(value string) bool { return helpers.IsFullDate(value) },
+	"date-time":             func(value string) bool { return helpers.IsDateTime(value) },
+	"time":                  func(value string) bool { return helpers.IsTime(value) },
+	"duration":              func(value string) bool { return helpers.IsDuration(value) },
+	"uri-reference":         func(value string) bool { return helpers.IsURIReference(value) },
+	"uri-template":          func(value string) bool { return helpers.IsURITemplate(value) },
+	"json-pointer":          func(value string) bool { return helpers.IsJSONPointer(value) },
+	"relative-json-pointer": func(value string) bool { return helpers.IsRelativeJSONPointer(value) },
+	"regex":                 func(value string) bool { return helpers.IsRegex(value) },
+}
+
+var (
+	formatsMu sync.RWMutex
+	formats   = make(map[string]FormatValidator)
+)
+
+// RegisterFormat registers the validator of a string format, e.g. an organization-specific `sku`, used by every
+// validator. A registered format replaces the built-in format of the same name. Formats are usually registered at
+// initialization, before validating requests.
+func RegisterFormat(name string, validate FormatValidator) {
+	formatsMu.Lock()
+	defer formatsMu.Unlock()
+	formats[name] = validate
+}
+
+// registeredFormat returns the validator registered for a format
+func registeredFormat(name string) (FormatValidator, bool) {
+	formatsMu.RLock()
+	defer formatsMu.RUnlock()
+	validate, exists := formats[name]
+	return validate, exists
+}
+
+// WithUnknownFormats sets the validation of strings whose format is neither built in nor registered
+func WithUnknownFormats(mode UnknownFormatMode) Option {
+	return func(v *DefaultValidator) {
+		v.unknownFormats = mode
+	}
+}
+
+// validFormat reports whether a string is valid for a format: registered formats first, then built-in ones, then
+// unknown formats according to the mode
+func (mode UnknownFormatMode) validFormat(value, format string) bool {
+	if format == "" {
+		return true
+	}
+	if validate, exists := registeredFormat(format); exists {
+		return validate(value)
+	}
+	if validate, exists := builtinFormats[format]; exists {
+		return validate(value)
+	}
+	return annotationFormats[format] || mode != UnknownFormatsFail
+}
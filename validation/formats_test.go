@@ -0,0 +1,186 @@
+package validation
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/lionelgarnier/validate-api-request/oas"
+)
+
+func TestCustomFormats(t *testing.T) {
+	RegisterFormat("sku", func(value string) bool {
+		return len(value) == 8 && strings.HasPrefix(value, "SKU-")
+	})
+	RegisterFormat("email", func(value string) bool {
+		return strings.HasSuffix(value, "@example.com")
+	})
+	defer func() {
+		formatsMu.Lock()
+		delete(formats, "sku")
+		delete(formats, "email")
+		formatsMu.Unlock()
+	}()
+
+	tests := []struct {
+		name    string
+		format  string
+		value   string
+		mode    UnknownFormatMode
+		isValid bool
+	}{
+		{name: "Registered format", format: "sku", value: "SKU-1234", isValid: true},
+		{name: "Invalid registered format", format: "sku", value: "1234", isValid: false},
+		{name: "Registered format replacing a built-in one", format: "email", value: "rex@example.org", isValid: false},
+		{name: "Built-in format", format: "uuid", value: "123e4567-e89b-12d3-a456-426614174000", mode: UnknownFormatsFail, isValid: true},
+		{name: "Unknown format ignored", format: "isbn", value: "anything", isValid: true},
+		{name: "Unknown format failing", format: "isbn", value: "anything", mode: UnknownFormatsFail, isValid: false},
+		{name: "Annotation format", format: "password", value: "anything", mode: UnknownFormatsFail, isValid: true},
+		{name: "No format", value: "anything", mode: UnknownFormatsFail, isValid: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			validator := NewValidator(&oas.APISpec{}, WithUnknownFormats(tt.mode))
+			assert.Equal(t, tt.isValid, validator.ValidateSchema(tt.value, &oas.Schema{Type: "string", Format: tt.format}))
+		})
+	}
+
+	manager := oas.NewOASManager(nil, oas.FixedSelector(map[string]string{"test": "test"}))
+	assert.NoError(t, manager.LoadAPI("test", []byte(`{
+		"openapi": "3.0.0",
+		"info": {"title": "Test API", "version": "1.0.0"},
+		"paths": {
+			"/items": {
+				"get": {
+					"parameters": [
+						{"name": "sku", "in": "query", "schema": {"type": "string", "format": "sku"}},
+						{"name": "isbn", "in": "query", "schema": {"type": "string", "format": "isbn"}}
+					],
+					"responses": {"200": {"description": "Items"}}
+				}
+			}
+		}
+	}`)))
+	spec, _ := manager.GetApiSpec("test")
+	assert.Equal(t, []Conformance{
+		{Name: "isbn", Uses: 1, Support: SupportUnsupported, Note: "rejected as unknown"},
+		{Name: "sku", Uses: 1, Support: SupportEnforced, Note: "registered"},
+	}, NewConformanceReport(spec, WithUnknownFormats(UnknownFormatsFail)).Formats)
+}
+
+func TestDateFormats(t *testing.T) {
+	tests := []struct {
+		name    string
+		format  string
+		value   string
+		isValid bool
+	}{
+		{name: "Full date", format: "date", value: "2023-06-15", isValid: true},
+		{name: "Leap day", format: "date", value: "2024-02-29", isValid: true},
+		{name: "Date-time as date", format: "date", value: "2023-06-15T10:00:00Z", isValid: false},
+		{name: "Impossible day", format: "date", value: "2023-02-29", isValid: false},
+		{name: "Date without padding", format: "date", value: "2023-6-15", isValid: false},
+		{name: "Date-time", format: "date-time", value: "2023-06-15T10:00:00Z", isValid: true},
+		{name: "Date-time with offset and fraction", format: "date-time", value: "2023-06-15T10:00:00.123+02:00", isValid: true},
+		{name: "Lowercase separators", format: "date-time", value: "2023-06-15t10:00:00z", isValid: true},
+		{name: "Leap second", format: "date-time", value: "2016-12-31T23:59:60Z", isValid: true},
+		{name: "Leap second with offset", format: "date-time", value: "2016-12-31T15:59:60-08:00", isValid: true},
+		{name: "Leap second mid-day", format: "date-time", value: "2016-12-31T12:00:60Z", isValid: false},
+		{name: "Date as date-time", format: "date-time", value: "2023-06-15", isValid: false},
+		{name: "Date-time without offset", format: "date-time", value: "2023-06-15T10:00:00", isValid: false},
+		{name: "Date-time with space", format: "date-time", value: "2023-06-15 10:00:00Z", isValid: false},
+		{name: "Impossible hour", format: "date-time", value: "2023-06-15T24:00:00Z", isValid: false},
+	}
+
+	validator := NewValidator(&oas.APISpec{})
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.isValid, validator.ValidateSchema(tt.value, &oas.Schema{Type: "string", Format: tt.format}))
+		})
+	}
+}
+
+func TestTimeFormats(t *testing.T) {
+	tests := []struct {
+		name    string
+		format  string
+		value   string
+		isValid bool
+	}{
+		{name: "Partial time", format: "time", value: "10:00:00", isValid: true},
+		{name: "Time with fraction", format: "time", value: "10:00:00.250", isValid: true},
+		{name: "Time with UTC offset", format: "time", value: "10:00:00Z", isValid: true},
+		{name: "Time with offset", format: "time", value: "10:00:00-05:30", isValid: true},
+		{name: "Leap second", format: "time", value: "23:59:60Z", isValid: true},
+		{name: "Leap second with offset", format: "time", value: "15:59:60-08:00", isValid: true},
+		{name: "Leap second mid-day", format: "time", value: "12:00:60Z", isValid: false},
+		{name: "Time without seconds", format: "time", value: "10:00", isValid: false},
+		{name: "Impossible minute", format: "time", value: "10:60:00", isValid: false},
+		{name: "Impossible offset", format: "time", value: "10:00:00+24:00", isValid: false},
+		{name: "Date-time as time", format: "time", value: "2023-06-15T10:00:00Z", isValid: false},
+		{name: "Duration", format: "duration", value: "P1Y2M10DT2H30M", isValid: true},
+		{name: "Duration of days", format: "duration", value: "P3D", isValid: true},
+		{name: "Duration of time", format: "duration", value: "PT45M", isValid: true},
+		{name: "Duration of weeks", format: "duration", value: "P2W", isValid: true},
+		{name: "Duration with fraction", format: "duration", value: "PT0.5S", isValid: true},
+		{name: "Duration with comma fraction", format: "duration", value: "PT1H1,5M", isValid: true},
+		{name: "Empty duration", format: "duration", value: "P", isValid: false},
+		{name: "Duration with empty time", format: "duration", value: "P1DT", isValid: false},
+		{name: "Duration with misplaced fraction", format: "duration", value: "PT1.5H30M", isValid: false},
+		{name: "Duration out of order", format: "duration", value: "PT30M2H", isValid: false},
+		{name: "Weeks mixed with days", format: "duration", value: "P2W3D", isValid: false},
+		{name: "Go duration", format: "duration", value: "1h30m", isValid: false},
+	}
+
+	validator := NewValidator(&oas.APISpec{})
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.isValid, validator.ValidateSchema(tt.value, &oas.Schema{Type: "string", Format: tt.format}))
+		})
+	}
+}
+
+func TestReferenceFormats(t *testing.T) {
+	tests := []struct {
+		name    string
+		format  string
+		value   string
+		isValid bool
+	}{
+		{name: "Absolute URI reference", format: "uri-reference", value: "https://example.com/pets?tag=dog#name", isValid: true},
+		{name: "Relative URI reference", format: "uri-reference", value: "../pets/42#name", isValid: true},
+		{name: "Empty URI reference", format: "uri-reference", value: "", isValid: true},
+		{name: "Percent-encoded URI reference", format: "uri-reference", value: "/pets/red%20dog", isValid: true},
+		{name: "URI reference with space", format: "uri-reference", value: "/pets/red dog", isValid: false},
+		{name: "URI reference with bad escape", format: "uri-reference", value: "/pets/%zz", isValid: false},
+		{name: "URI reference with backslash", format: "uri-reference", value: `\\server\pets`, isValid: false},
+		{name: "URI template", format: "uri-template", value: "/pets/{id}{?fields*,limit:3}", isValid: true},
+		{name: "URI template with operators", format: "uri-template", value: "https://example.com{/path}{#section}{+base}", isValid: true},
+		{name: "URI template without expression", format: "uri-template", value: "/pets", isValid: true},
+		{name: "Unclosed URI template", format: "uri-template", value: "/pets/{id", isValid: false},
+		{name: "Empty URI template expression", format: "uri-template", value: "/pets/{}", isValid: false},
+		{name: "URI template with invalid variable", format: "uri-template", value: "/pets/{pet-id}", isValid: false},
+		{name: "URI template with long prefix", format: "uri-template", value: "/pets/{id:10000}", isValid: false},
+		{name: "JSON pointer", format: "json-pointer", value: "/pets/0/name", isValid: true},
+		{name: "Root JSON pointer", format: "json-pointer", value: "", isValid: true},
+		{name: "Escaped JSON pointer", format: "json-pointer", value: "/paths/~1pets~0", isValid: true},
+		{name: "JSON pointer with bad escape", format: "json-pointer", value: "/pets/~2", isValid: false},
+		{name: "Relative JSON pointer as JSON pointer", format: "json-pointer", value: "0/name", isValid: false},
+		{name: "Relative JSON pointer", format: "relative-json-pointer", value: "1/name", isValid: true},
+		{name: "Relative JSON pointer to the key", format: "relative-json-pointer", value: "0#", isValid: true},
+		{name: "Relative JSON pointer with leading zero", format: "relative-json-pointer", value: "01/name", isValid: false},
+		{name: "JSON pointer as relative JSON pointer", format: "relative-json-pointer", value: "/name", isValid: false},
+		{name: "Regular expression", format: "regex", value: `^[a-z]+\d{2,}$`, isValid: true},
+		{name: "Unclosed regular expression", format: "regex", value: "^[a-z", isValid: false},
+		{name: "Lookahead", format: "regex", value: "(?=a)", isValid: false},
+	}
+
+	validator := NewValidator(&oas.APISpec{})
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.isValid, validator.ValidateSchema(tt.value, &oas.Schema{Type: "string", Format: tt.format}))
+		})
+	}
+}
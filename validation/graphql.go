@@ -0,0 +1,159 @@
+package validation
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/lionelgarnier/validate-api-request/oas"
+)
+
+// GraphQLExtension is the operation extension marking a GraphQL-over-HTTP endpoint
+const GraphQLExtension = "x-graphql"
+
+// sha256Hex matches the lowercase hex encoding of a SHA-256 hash
+var sha256Hex = regexp.MustCompile(`^[0-9a-f]{64}$`)
+
+// WithGraphQL enables the shape checks of GraphQL-over-HTTP requests, on operations of `/graphql` routes or
+// declaring `x-graphql: true`. Only the request envelope is checked, queries are not parsed.
+func WithGraphQL(enabled bool) Option {
+	return func(v *DefaultValidator) {
+		v.graphQL = enabled
+	}
+}
+
+// isGraphQLOperation reports whether the operation of a request is a GraphQL endpoint whose requests are checked
+func (v *DefaultValidator) isGraphQLOperation(req *oas.OASRequest) bool {
+	if !v.graphQL {
+		return false
+	}
+	if marked, ok := req.Operation.Extensions[GraphQLExtension].(bool); ok {
+		return marked
+	}
+	return strings.HasSuffix(req.Route, "/graphql")
+}
+
+// decodeGraphQLBody decodes the body of a GraphQL request: the query itself for `application/graphql`,
+// or an envelope decoded with the decoder of its media type
+func (v *DefaultValidator) decodeGraphQLBody(body io.Reader, contentType string) (interface{}, error) {
+	if essence, _ := parseMediaType(contentType); essence == "application/graphql" {
+		query, err := io.ReadAll(body)
+		return string(query), err
+	}
+	return v.decoder(contentType)(body)
+}
+
+// checkGraphQLBody checks the shape of the body of a GraphQL request, a query, an envelope or a batch of envelopes
+func checkGraphQLBody(body interface{}) error {
+	switch value := body.(type) {
+	case string:
+		if strings.TrimSpace(value) == "" {
+			return fmt.Errorf("query must not be empty")
+		}
+		return nil
+	case []interface{}:
+		if len(value) == 0 {
+			return fmt.Errorf("batch must not be empty")
+		}
+		for i, item := range value {
+			if err := checkGraphQLEnvelope(item); err != nil {
+				return fmt.Errorf("%s: %w", indexPath("", i), err)
+			}
+		}
+		return nil
+	default:
+		return checkGraphQLEnvelope(body)
+	}
+}
+
+// checkGraphQLQuery checks the shape of a GraphQL request sent as URL query parameters,
+// whose variables and extensions are JSON encoded
+func checkGraphQLQuery(query url.Values) error {
+	envelope := make(map[string]interface{})
+	for _, name := range []string{"query", "operationName"} {
+		if query.Has(name) {
+			envelope[name] = query.Get(name)
+		}
+	}
+	for _, name := range []string{"variables", "extensions"} {
+		if !query.Has(name) {
+			continue
+		}
+		var value interface{}
+		if err := json.Unmarshal([]byte(query.Get(name)), &value); err != nil {
+			return fmt.Errorf("%s: invalid JSON", name)
+		}
+		envelope[name] = value
+	}
+	return checkGraphQLEnvelope(envelope)
+}
+
+// checkGraphQLEnvelope checks the members of a GraphQL request envelope. The query may be omitted for a
+// persisted query, whose hash must otherwise match the query.
+func checkGraphQLEnvelope(value interface{}) error {
+	envelope, ok := value.(map[string]interface{})
+	if !ok {
+		return fmt.Errorf("expected object")
+	}
+
+	query, hasQuery := envelope["query"].(string)
+	if envelope["query"] != nil && !hasQuery {
+		return fmt.Errorf("query: expected string")
+	}
+	if hasQuery && strings.TrimSpace(query) == "" {
+		return fmt.Errorf("query: must not be empty")
+	}
+	if name, exists := envelope["operationName"]; exists && name != nil {
+		if _, ok := name.(string); !ok {
+			return fmt.Errorf("operationName: expected string")
+		}
+	}
+	for _, name := range []string{"variables", "extensions"} {
+		if member, exists := envelope[name]; exists && member != nil {
+			if _, ok := member.(map[string]interface{}); !ok {
+				return fmt.Errorf("%s: expected object", name)
+			}
+		}
+	}
+
+	extensions, _ := envelope["extensions"].(map[string]interface{})
+	hash, persisted, err := persistedQueryHash(extensions)
+	if err != nil {
+		return err
+	}
+	switch {
+	case !hasQuery && !persisted:
+		return fmt.Errorf("query: required property is missing")
+	case hasQuery && persisted:
+		sum := sha256.Sum256([]byte(query))
+		if hex.EncodeToString(sum[:]) != hash {
+			return fmt.Errorf("extensions.persistedQuery.sha256Hash: does not match query")
+		}
+	}
+	return nil
+}
+
+// persistedQueryHash returns the hash of the automatic persisted query extension, if any
+func persistedQueryHash(extensions map[string]interface{}) (string, bool, error) {
+	value, exists := extensions["persistedQuery"]
+	if !exists || value == nil {
+		return "", false, nil
+	}
+	persistedQuery, ok := value.(map[string]interface{})
+	if !ok {
+		return "", false, fmt.Errorf("extensions.persistedQuery: expected object")
+	}
+	if version := floatNumbers(persistedQuery["version"]); version != 1.0 {
+		return "", false, fmt.Errorf("extensions.persistedQuery.version: unsupported version %v", persistedQuery["version"])
+	}
+	hash, _ := persistedQuery["sha256Hash"].(string)
+	if !sha256Hex.MatchString(hash) {
+		return "", false, fmt.Errorf("extensions.persistedQuery.sha256Hash: expected lowercase hex SHA-256 hash")
+	}
+	return hash, true, nil
+}
@@ -0,0 +1,225 @@
+package validation
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/lionelgarnier/validate-api-request/oas"
+)
+
+func TestGraphQLRequests(t *testing.T) {
+	manager := oas.NewOASManager(nil, oas.FixedSelector(map[string]string{"test": "test"}))
+	err := manager.LoadAPI("test", []byte(`{
+        "openapi": "3.0.0",
+        "info": {"title": "Test API", "version": "1.0.0"},
+        "paths": {
+            "/graphql": {
+                "get": {},
+                "post": {
+                    "requestBody": {
+                        "content": {
+                            "application/json": {},
+                            "application/graphql": {"schema": {"type": "string", "maxLength": 64}}
+                        }
+                    }
+                }
+            },
+            "/query": {
+                "post": {
+                    "x-graphql": true,
+                    "requestBody": {"content": {"application/json": {}}}
+                }
+            },
+            "/legacy/graphql": {
+                "post": {
+                    "x-graphql": false,
+                    "requestBody": {"content": {"application/json": {}}}
+                }
+            }
+        }
+    }`))
+	assert.NoError(t, err)
+	spec, _ := manager.GetApiSpec("test")
+	validator := NewValidator(spec, WithGraphQL(true))
+
+	query := "{ pets { name } }"
+	sum := sha256.Sum256([]byte(query))
+	hash := hex.EncodeToString(sum[:])
+
+	tests := []struct {
+		name          string
+		method        string
+		path          string
+		contentType   string
+		body          string
+		expectedError string
+	}{
+		{
+			name:   "Query",
+			method: http.MethodPost,
+			path:   "/graphql",
+			body:   `{"query": "query Pets { pets { name } }", "operationName": "Pets", "variables": {"limit": 10}}`,
+		},
+		{
+			name:   "Batch",
+			method: http.MethodPost,
+			path:   "/graphql",
+			body:   `[{"query": "{ pets { name } }"}, {"query": "{ owners { name } }", "variables": null}]`,
+		},
+		{
+			name:   "Persisted query",
+			method: http.MethodPost,
+			path:   "/graphql",
+			body:   `{"extensions": {"persistedQuery": {"version": 1, "sha256Hash": "` + hash + `"}}}`,
+		},
+		{
+			name:   "Persisted query with its query",
+			method: http.MethodPost,
+			path:   "/graphql",
+			body:   `{"query": "` + query + `", "extensions": {"persistedQuery": {"version": 1, "sha256Hash": "` + hash + `"}}}`,
+		},
+		{
+			name:          "Missing query",
+			method:        http.MethodPost,
+			path:          "/graphql",
+			body:          `{"variables": {}}`,
+			expectedError: "invalid GraphQL request: query: required property is missing",
+		},
+		{
+			name:          "Empty query",
+			method:        http.MethodPost,
+			path:          "/graphql",
+			body:          `{"query": "  "}`,
+			expectedError: "invalid GraphQL request: query: must not be empty",
+		},
+		{
+			name:          "Invalid variables",
+			method:        http.MethodPost,
+			path:          "/graphql",
+			body:          `{"query": "{ pets { name } }", "variables": "{}"}`,
+			expectedError: "invalid GraphQL request: variables: expected object",
+		},
+		{
+			name:          "Invalid operation name",
+			method:        http.MethodPost,
+			path:          "/graphql",
+			body:          `{"query": "{ pets { name } }", "operationName": 1}`,
+			expectedError: "invalid GraphQL request: operationName: expected string",
+		},
+		{
+			name:          "Invalid batch item",
+			method:        http.MethodPost,
+			path:          "/graphql",
+			body:          `[{"query": "{ pets { name } }"}, {}]`,
+			expectedError: "invalid GraphQL request: [1]: query: required property is missing",
+		},
+		{
+			name:          "Malformed persisted query hash",
+			method:        http.MethodPost,
+			path:          "/graphql",
+			body:          `{"extensions": {"persistedQuery": {"version": 1, "sha256Hash": "ABC"}}}`,
+			expectedError: "invalid GraphQL request: extensions.persistedQuery.sha256Hash: expected lowercase hex SHA-256 hash",
+		},
+		{
+			name:          "Unsupported persisted query version",
+			method:        http.MethodPost,
+			path:          "/graphql",
+			body:          `{"extensions": {"persistedQuery": {"version": 2, "sha256Hash": "` + hash + `"}}}`,
+			expectedError: "invalid GraphQL request: extensions.persistedQuery.version: unsupported version 2",
+		},
+		{
+			name:          "Persisted query hash not matching query",
+			method:        http.MethodPost,
+			path:          "/graphql",
+			body:          `{"query": "{ owners { name } }", "extensions": {"persistedQuery": {"version": 1, "sha256Hash": "` + hash + `"}}}`,
+			expectedError: "invalid GraphQL request: extensions.persistedQuery.sha256Hash: does not match query",
+		},
+		{
+			name:        "GraphQL media type",
+			method:      http.MethodPost,
+			path:        "/graphql",
+			contentType: "application/graphql",
+			body:        query,
+		},
+		{
+			name:          "Empty GraphQL media type",
+			method:        http.MethodPost,
+			path:          "/graphql",
+			contentType:   "application/graphql",
+			body:          " ",
+			expectedError: "invalid GraphQL request: query must not be empty",
+		},
+		{
+			name:          "Declared schema",
+			method:        http.MethodPost,
+			path:          "/graphql",
+			contentType:   "application/graphql",
+			body:          "{ pets { name owner { name address { street city country } } toys { name } } }",
+			expectedError: "request body does not match schema: length must be at most 64",
+		},
+		{
+			name:          "Operation marked as GraphQL",
+			method:        http.MethodPost,
+			path:          "/query",
+			body:          `{}`,
+			expectedError: "invalid GraphQL request: query: required property is missing",
+		},
+		{
+			name:   "Operation opted out",
+			method: http.MethodPost,
+			path:   "/legacy/graphql",
+			body:   `{}`,
+		},
+		{
+			name:   "GET query",
+			method: http.MethodGet,
+			path:   "/graphql?query=" + url.QueryEscape(query) + "&variables=" + url.QueryEscape(`{"limit": 10}`),
+		},
+		{
+			name:   "GET persisted query",
+			method: http.MethodGet,
+			path:   "/graphql?extensions=" + url.QueryEscape(`{"persistedQuery": {"version": 1, "sha256Hash": "`+hash+`"}}`),
+		},
+		{
+			name:          "GET malformed variables",
+			method:        http.MethodGet,
+			path:          "/graphql?query=" + url.QueryEscape(query) + "&variables=" + url.QueryEscape(`{"limit":`),
+			expectedError: "invalid GraphQL request: variables: invalid JSON",
+		},
+		{
+			name:          "GET missing query",
+			method:        http.MethodGet,
+			path:          "/graphql",
+			expectedError: "invalid GraphQL request: query: required property is missing",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, _ := http.NewRequest(tt.method, tt.path, strings.NewReader(tt.body))
+			if tt.body != "" {
+				contentType := tt.contentType
+				if contentType == "" {
+					contentType = "application/json"
+				}
+				req.Header.Set("Content-Type", contentType)
+			}
+
+			oasRequest := oas.NewOASRequest(req)
+			valid, err := validator.ValidateRequest(oasRequest)
+			if tt.expectedError == "" {
+				assert.True(t, valid)
+				assert.NoError(t, err)
+			} else {
+				assert.False(t, valid)
+				assert.EqualError(t, err, tt.expectedError)
+			}
+		})
+	}
+}
@@ -0,0 +1,135 @@
+package validation
+
+import (
+	"fmt"
+	"maps"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/lionelgarnier/validate-api-request/oas"
+	"github.com/lionelgarnier/validate-api-request/pkg/helpers"
+)
+
+// HeaderPatternExtension is the parameter extension declaring a regular expression matching a family of headers
+const HeaderPatternExtension = "x-header-pattern"
+
+// headerFamilyPattern returns the compiled pattern of a header parameter describing a family of headers, compiled
+// once per pattern. A family is declared with the x-header-pattern extension or a name ending with `*` (e.g. `X-Meta-*`).
+func headerFamilyPattern(param *oas.Parameter) (*regexp.Regexp, error) {
+	if value, exists := param.Extension(HeaderPatternExtension); exists {
+		pattern, ok := value.(string)
+		if !ok {
+			return nil, fmt.Errorf("extension '%s' of parameter '%s' must be a string", HeaderPatternExtension, param.Name)
+		}
+		return helpers.CompilePattern("(?i)" + pattern)
+	}
+
+	if strings.HasSuffix(param.Name, "*") {
+		return helpers.CompilePattern("(?i)^" + regexp.QuoteMeta(strings.TrimSuffix(param.Name, "*")))
+	}
+
+	return nil, nil
+}
+
+// patternPropertiesSchema returns the resolved schema of a parameter gathering headers through patternProperties,
+// nil for other parameters
+func (v *DefaultValidator) patternPropertiesSchema(param *oas.Parameter) *oas.Schema {
+	if param.Schema == nil {
+		return nil
+	}
+	schema := param.Schema
+	if schema.Ref != "" {
+		resolvedSchema, err := v.resolveSchemaReference(schema.Ref)
+		if err != nil {
+			return nil
+		}
+		schema = resolvedSchema
+	}
+	if schema.Type != "object" || len(schema.PatternProperties) == 0 {
+		return nil
+	}
+	return schema
+}
+
+// validateHeaderFamily validates every request header matching a header family parameter.
+// It returns false when the parameter does not describe a family of headers.
+func (v *DefaultValidator) validateHeaderFamily(req *oas.OASRequest, param *oas.Parameter) (bool, error) {
+	header := req.Request.Header
+	if schema := v.patternPropertiesSchema(param); schema != nil {
+		// Headers are gathered into an object validated against the patternProperties schema, their names matching
+		// the patterns case-insensitively
+		headers := make(map[string]interface{})
+		for name, values := range header {
+			if len(values) > 0 && matchesPatternProperty(schema, name, true) {
+				headers[name] = values[0]
+			}
+		}
+		if len(headers) == 0 {
+			if param.Required {
+				return true, fmt.Errorf("missing required parameter '%s'", param.Name)
+			}
+			return true, nil
+		}
+		if err := v.validateRequestSchema(req, "header", headers, param.Schema, param.Name); err != nil {
+			return true, &ValidationError{
+				Stage:   StageParameters,
+				Message: fmt.Sprintf("invalid type for parameter '%s'", param.Name),
+				Err:     err,
+			}
+		}
+
+		// The other values of repeated headers are validated against the schemas of the patterns they match
+		for _, name := range slices.Sorted(maps.Keys(headers)) {
+			for _, pattern := range slices.Sorted(maps.Keys(schema.PatternProperties)) {
+				if !matchesPropertyPattern(name, pattern, true) {
+					continue
+				}
+				patternSchema := schema.PatternProperties[pattern]
+				for _, value := range header[name][1:] {
+					if err := v.validateRequestSchema(req, "header", value, &patternSchema, propertyPath(param.Name, name)); err != nil {
+						return true, &ValidationError{
+							Stage:   StageParameters,
+							Message: fmt.Sprintf("invalid type for parameter '%s'", param.Name),
+							Err:     err,
+						}
+					}
+				}
+			}
+		}
+		return true, nil
+	}
+
+	pattern, err := headerFamilyPattern(param)
+	if err != nil {
+		return true, fmt.Errorf("invalid header pattern for parameter '%s': %v", param.Name, err)
+	}
+	if pattern == nil {
+		return false, nil
+	}
+
+	matched := 0
+	for name, values := range header {
+		if !pattern.MatchString(name) {
+			continue
+		}
+		matched++
+		if param.Schema == nil {
+			continue
+		}
+		for _, value := range values {
+			if err := v.validateRequestSchema(req, "header", value, param.Schema, name); err != nil {
+				return true, &ValidationError{
+					Stage:   StageParameters,
+					Message: fmt.Sprintf("invalid type for parameter '%s'", name),
+					Err:     err,
+				}
+			}
+		}
+	}
+
+	if matched == 0 && param.Required {
+		return true, fmt.Errorf("missing required parameter '%s'", param.Name)
+	}
+	return true, nil
+}
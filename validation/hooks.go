@@ -0,0 +1,51 @@
+package validation
+
+import (
+	"errors"
+
+	"github.com/lionelgarnier/validate-api-request/oas"
+)
+
+// BodyHook checks a decoded request body beyond its schema, e.g. that `endDate` follows `startDate`. It is only
+// called with bodies matching their schema. Returned SchemaError and SchemaErrors values locate the failures at
+// instance paths of the body, other errors fail the whole body.
+type BodyHook func(req *oas.OASRequest, body interface{}) error
+
+// WithBodyHook registers the hook checking the request bodies of the operation with the given operationId, or of
+// the operations naming the hook in their `x-body-hook` extension
+func WithBodyHook(name string, hook BodyHook) Option {
+	return func(v *DefaultValidator) {
+		v.bodyHooks[name] = hook
+	}
+}
+
+// bodyHook returns the hook registered for the operation of the request, nil if it has none
+func (v *DefaultValidator) bodyHook(req *oas.OASRequest) BodyHook {
+	if hook, exists := v.bodyHooks[req.Operation.OperationId]; exists && req.Operation.OperationId != "" {
+		return hook
+	}
+	if name, ok := req.Operation.Extensions["x-body-hook"].(string); ok {
+		return v.bodyHooks[name]
+	}
+	return nil
+}
+
+// runBodyHook checks a body matching its schema with the hook of its operation, returning its failures as
+// failures of the body located under the schema of its media type
+func (v *DefaultValidator) runBodyHook(req *oas.OASRequest, body interface{}, schemaPointer string) error {
+	hook := v.bodyHook(req)
+	if hook == nil {
+		return nil
+	}
+	err := hook(req, body)
+	if err == nil {
+		return nil
+	}
+
+	var schemaErr *SchemaError
+	var schemaErrs SchemaErrors
+	if !errors.As(err, &schemaErr) && !errors.As(err, &schemaErrs) {
+		err = &SchemaError{Message: err.Error()}
+	}
+	return schemaFailures(ValidationError{Stage: StageBody, Message: "request body fails checks"}, schemaPointer, err)
+}
@@ -0,0 +1,104 @@
+package validation
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/lionelgarnier/validate-api-request/oas"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBodyHooks(t *testing.T) {
+	manager := oas.NewOASManager(nil, oas.FixedSelector(map[string]string{"test": "test"}))
+	err := manager.LoadAPI("test", []byte(`{
+        "openapi": "3.0.0",
+        "info": {"title": "Test API", "version": "1.0.0"},
+        "paths": {
+            "/bookings": {
+                "post": {
+                    "operationId": "createBooking",
+                    "requestBody": {"content": {"application/json": {"schema": {"$ref": "#/components/schemas/Period"}}}}
+                },
+                "put": {
+                    "x-body-hook": "period",
+                    "requestBody": {"content": {"application/json": {"schema": {"$ref": "#/components/schemas/Period"}}}}
+                },
+                "patch": {
+                    "requestBody": {"content": {"application/json": {"schema": {"$ref": "#/components/schemas/Period"}}}}
+                }
+            }
+        },
+        "components": {
+            "schemas": {
+                "Period": {
+                    "type": "object",
+                    "required": ["startDate", "endDate"],
+                    "properties": {"startDate": {"type": "string"}, "endDate": {"type": "string"}}
+                }
+            }
+        }
+    }`))
+	assert.NoError(t, err)
+	spec, _ := manager.GetApiSpec("test")
+
+	period := func(req *oas.OASRequest, body interface{}) error {
+		dates := body.(map[string]interface{})
+		if dates["endDate"].(string) <= dates["startDate"].(string) {
+			return &SchemaError{Path: "endDate", Message: "must be after startDate"}
+		}
+		return nil
+	}
+	quota := func(req *oas.OASRequest, body interface{}) error {
+		return fmt.Errorf("booking quota exceeded")
+	}
+
+	hooks := map[string]BodyHook{"createBooking": period, "period": period}
+	tests := []struct {
+		name          string
+		method        string
+		body          string
+		hooks         map[string]BodyHook
+		expectedError string
+		violation     Violation
+	}{
+		{name: "Hook by operationId", method: http.MethodPost, body: `{"startDate": "2024-01-01", "endDate": "2024-01-02"}`, hooks: hooks},
+		{name: "Hook by operationId failing", method: http.MethodPost, body: `{"startDate": "2024-01-02", "endDate": "2024-01-01"}`, hooks: hooks,
+			expectedError: "request body fails checks: endDate: must be after startDate",
+			violation:     Violation{Path: "endDate", Spec: "#/paths/~1bookings/post/requestBody/content/application~1json/schema", Reason: "must be after startDate"}},
+		{name: "Hook by extension failing", method: http.MethodPut, body: `{"startDate": "2024-01-02", "endDate": "2024-01-01"}`, hooks: hooks, expectedError: "endDate: must be after startDate"},
+		{name: "Operation without hook", method: http.MethodPatch, body: `{"startDate": "2024-01-02", "endDate": "2024-01-01"}`, hooks: hooks},
+		{name: "Plain error", method: http.MethodPost, body: `{"startDate": "2024-01-01", "endDate": "2024-01-02"}`, hooks: map[string]BodyHook{"createBooking": quota},
+			expectedError: "request body fails checks: booking quota exceeded"},
+		{name: "Not called for bodies failing their schema", method: http.MethodPost, body: `{"startDate": "2024-01-02"}`, hooks: map[string]BodyHook{"createBooking": quota},
+			expectedError: "request body does not match schema"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequest(tt.method, "/bookings", strings.NewReader(tt.body))
+			assert.NoError(t, err)
+			req.Header.Set("Content-Type", "application/json")
+
+			var opts []Option
+			for name, hook := range tt.hooks {
+				opts = append(opts, WithBodyHook(name, hook))
+			}
+			ok, err := NewValidator(spec, opts...).ValidateRequest(oas.NewOASRequest(req))
+			if tt.expectedError == "" {
+				assert.True(t, ok)
+				assert.NoError(t, err)
+				return
+			}
+			assert.False(t, ok)
+			assert.ErrorContains(t, err, tt.expectedError)
+			assert.Equal(t, StageBody, StageOf(err))
+			if tt.violation.Reason != "" {
+				violation := Violations(err)[0]
+				violation.Message = ""
+				assert.Equal(t, tt.violation, violation)
+			}
+		})
+	}
+}
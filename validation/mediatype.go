@@ -0,0 +1,137 @@
+package validation
+
+import (
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/lionelgarnier/validate-api-request/oas"
+)
+
+// parseMediaType returns the lowercase type/subtype of a media type and its parameters, except `boundary`
+// which differs on every multipart request. Malformed parameters are ignored.
+func parseMediaType(value string) (string, map[string]string) {
+	essence, params := parseMediaTypeParams(value)
+	delete(params, "boundary")
+	return essence, params
+}
+
+// parseMediaTypeParams returns the lowercase type/subtype of a media type and all its parameters, with lowercase
+// names. When some parameters are malformed, e.g. `charset` without a value or a trailing `;`, the others are kept
+// rather than dropped with them.
+func parseMediaTypeParams(value string) (string, map[string]string) {
+	essence, params, err := mime.ParseMediaType(value)
+	if err == nil {
+		return essence, params
+	}
+	essence, rest, _ := strings.Cut(value, ";")
+	essence = strings.ToLower(strings.TrimSpace(essence))
+	params = make(map[string]string)
+	for _, param := range strings.Split(rest, ";") {
+		name, paramValue, found := strings.Cut(param, "=")
+		name = strings.ToLower(strings.TrimSpace(name))
+		if !found || name == "" {
+			continue
+		}
+		paramValue = strings.TrimSpace(paramValue)
+		if unquoted, err := strconv.Unquote(paramValue); err == nil && strings.HasPrefix(paramValue, `"`) {
+			paramValue = unquoted
+		}
+		if _, exists := params[name]; !exists {
+			params[name] = paramValue
+		}
+	}
+	return essence, params
+}
+
+// findMediaType returns the declared media type matching a request content type.
+// Exact types are preferred over `type/*` ranges, then `*/*`. Parameters only narrow the choice: among declarations
+// of the same type, those whose parameters the request has are preferred, with the most parameters first, then those
+// whose parameters differ, e.g. `application/json; charset=utf-8` sent with `charset=iso-8859-1`.
+// The declared key is returned with the media type, to locate it in the spec.
+func findMediaType(content map[string]oas.MediaType, contentType string) (string, oas.MediaType, bool) {
+	essence, params := parseMediaType(contentType)
+	mainType, _, _ := strings.Cut(essence, "/")
+
+	var best oas.MediaType
+	bestKey, bestRank, bestParams := "", 0, -1
+	for key, mediaType := range content {
+		declared, declaredParams := parseMediaType(key)
+
+		rank := 0
+		switch declared {
+		case essence:
+			rank = 6
+		case mainType + "/*":
+			rank = 4
+		case "*/*":
+			rank = 2
+		}
+		if rank == 0 {
+			continue
+		}
+		matched := len(declaredParams)
+		if !matchParams(declaredParams, params) {
+			rank, matched = rank-1, 0
+		}
+
+		if rank > bestRank || (rank == bestRank && matched > bestParams) ||
+			(rank == bestRank && matched == bestParams && key < bestKey) {
+			best, bestKey, bestRank, bestParams = mediaType, key, rank, matched
+		}
+	}
+	return bestKey, best, bestRank > 0
+}
+
+// matchParams reports whether the request parameters include the declared ones, with case-insensitive values
+func matchParams(declared, actual map[string]string) bool {
+	for name, value := range declared {
+		if !strings.EqualFold(actual[name], value) {
+			return false
+		}
+	}
+	return true
+}
+
+// MissingContentType is the handling of request bodies sent without a Content-Type header: one of the policies
+// below, or the media type they are assumed to have, e.g. `application/x-www-form-urlencoded`
+type MissingContentType string
+
+const (
+	// ContentTypeDefaultJSON assumes bodies without Content-Type are `application/json`
+	ContentTypeDefaultJSON MissingContentType = ""
+	// ContentTypeInfer assumes the media type of bodies without Content-Type when their operation declares a single
+	// one, and `application/json` otherwise
+	ContentTypeInfer MissingContentType = "infer"
+	// ContentTypeRequire rejects bodies without Content-Type
+	ContentTypeRequire MissingContentType = "require"
+)
+
+// WithMissingContentType sets the handling of request bodies sent without a Content-Type header
+func WithMissingContentType(policy MissingContentType) Option {
+	return func(v *DefaultValidator) {
+		v.missingContentType = policy
+	}
+}
+
+// assumedContentType returns the content type assumed for a request body without Content-Type, declared by the
+// request body content, and false when the body must be rejected
+func (v *DefaultValidator) assumedContentType(r *http.Request, content map[string]oas.MediaType) (string, bool) {
+	switch v.missingContentType {
+	case ContentTypeDefaultJSON:
+		return "application/json", true
+	case ContentTypeInfer:
+		if len(content) == 1 {
+			for key := range content {
+				return key, true
+			}
+		}
+		return "application/json", true
+	case ContentTypeRequire:
+		// Requests without body have no content type to send
+		return "application/json", r.ContentLength == 0
+	default:
+		return string(v.missingContentType), true
+	}
+}
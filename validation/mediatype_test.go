@@ -0,0 +1,85 @@
+package validation
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/lionelgarnier/validate-api-request/oas"
+)
+
+func TestFindMediaType(t *testing.T) {
+	content := map[string]oas.MediaType{
+		"application/json":                 {Example: "json"},
+		"application/json; charset=utf-16": {Example: "utf-16"},
+		"application/vnd.company.v2+json":  {Example: "vendor"},
+		"multipart/form-data":              {Example: "multipart"},
+		"text/*":                           {Example: "text"},
+	}
+
+	tests := []struct {
+		name        string
+		contentType string
+		expected    interface{}
+		exists      bool
+	}{
+		{name: "Exact type", contentType: "application/json", expected: "json", exists: true},
+		{name: "Case and whitespace", contentType: "  Application/JSON ", expected: "json", exists: true},
+		{name: "Unmatched parameter", contentType: "application/json; charset=utf-8", expected: "json", exists: true},
+		{name: "Matched parameter", contentType: "application/json; charset=UTF-16", expected: "utf-16", exists: true},
+		{name: "Vendor type", contentType: "application/VND.company.v2+json; charset=utf-8", expected: "vendor", exists: true},
+		{name: "Multipart boundary", contentType: "multipart/form-data; boundary=----abc", expected: "multipart", exists: true},
+		{name: "Range", contentType: "text/csv", expected: "text", exists: true},
+		{name: "Unsupported type", contentType: "application/xml", expected: nil, exists: false},
+		{name: "Malformed parameter", contentType: "application/json; charset; ", expected: "json", exists: true},
+		{name: "Quoted parameter", contentType: `application/json; charset="utf-16"; q`, expected: "utf-16", exists: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, mediaType, exists := findMediaType(content, tt.contentType)
+			assert.Equal(t, tt.exists, exists)
+			assert.Equal(t, tt.expected, mediaType.Example)
+		})
+	}
+
+	declaredParams := map[string]oas.MediaType{
+		"application/json; charset=utf-8": {Example: "utf-8"},
+		"text/plain; charset=utf-8":       {Example: "text utf-8"},
+		"text/*":                          {Example: "text"},
+	}
+	for contentType, expected := range map[string]interface{}{
+		"application/json":                         "utf-8",
+		"application/json; charset=utf-16":         "utf-8",
+		"text/plain; charset=iso-8859-1":           "text utf-8",
+		"text/plain; charset=UTF-8; format=flowed": "text utf-8",
+		"text/csv; charset=utf-8":                  "text",
+	} {
+		_, mediaType, exists := findMediaType(declaredParams, contentType)
+		assert.True(t, exists, contentType)
+		assert.Equal(t, expected, mediaType.Example, contentType)
+	}
+}
+
+func TestParseMediaTypeParams(t *testing.T) {
+	tests := []struct {
+		value    string
+		essence  string
+		expected map[string]string
+	}{
+		{value: "Application/JSON; Charset=UTF-8", essence: "application/json", expected: map[string]string{"charset": "UTF-8"}},
+		{value: "application/json;charset=utf-8;", essence: "application/json", expected: map[string]string{"charset": "utf-8"}},
+		{value: "multipart/form-data; boundary=abc; charset", essence: "multipart/form-data", expected: map[string]string{"boundary": "abc"}},
+		{value: `multipart/form-data; boundary="a b"; =x; charset=utf-8; charset=latin1`, essence: "multipart/form-data",
+			expected: map[string]string{"boundary": "a b", "charset": "utf-8"}},
+		{value: "application/json", essence: "application/json", expected: map[string]string{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			essence, params := parseMediaTypeParams(tt.value)
+			assert.Equal(t, tt.essence, essence)
+			assert.Equal(t, tt.expected, params)
+		})
+	}
+}
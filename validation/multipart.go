@@ -0,0 +1,113 @@
+package validation
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"strings"
+
+	"github.com/lionelgarnier/validate-api-request/oas"
+)
+
+// decodeMultipart decodes a multipart/form-data body into an object of its fields, validated against the properties
+// of the schema. Fields repeating a name are arrays, and so are the single fields of array properties. Parts are
+// strings, files included, so that `type: string, format: binary` properties accept uploads, unless they are JSON
+// according to their Content-Type or the `encoding` of their property. Parts whose Content-Type does not match the
+// `encoding` of their property are rejected.
+func (v *DefaultValidator) decodeMultipart(body io.Reader, contentType string, mediaType oas.MediaType) (interface{}, error) {
+	_, params := parseMediaTypeParams(contentType)
+	if params["boundary"] == "" {
+		return nil, fmt.Errorf("missing multipart boundary")
+	}
+
+	fields := make(map[string][]interface{})
+	var names []string
+	reader := multipart.NewReader(body, params["boundary"])
+	for {
+		part, err := reader.NextPart()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		name := part.FormName()
+		if name == "" {
+			continue
+		}
+		value, err := decodePart(part, mediaType.Encoding[name])
+		if err != nil {
+			return nil, fmt.Errorf("part '%s': %v", name, err)
+		}
+		if _, exists := fields[name]; !exists {
+			names = append(names, name)
+		}
+		fields[name] = append(fields[name], value)
+	}
+
+	properties := v.multipartProperties(mediaType.Schema)
+	object := make(map[string]interface{}, len(fields))
+	for _, name := range names {
+		property, declared := properties[name]
+		if len(fields[name]) > 1 || declared && v.schemaHasType(&property, "array") {
+			object[name] = fields[name]
+		} else {
+			object[name] = fields[name][0]
+		}
+	}
+	return object, nil
+}
+
+// decodePart returns the value of a part, checking its Content-Type against the `encoding` of its property
+func decodePart(part *multipart.Part, encoding oas.Encoding) (interface{}, error) {
+	content, err := io.ReadAll(part)
+	if err != nil {
+		return nil, err
+	}
+
+	partType := part.Header.Get("Content-Type")
+	if encoding.ContentType != "" && partType != "" && !matchesEncoding(partType, encoding.ContentType) {
+		return nil, fmt.Errorf("content type '%s' does not match '%s'", partType, encoding.ContentType)
+	}
+	if partType == "" {
+		partType = encoding.ContentType
+	}
+	if part.FileName() == "" && isJSONMediaType(partType) {
+		var value interface{}
+		if err := json.Unmarshal(content, &value); err != nil {
+			return nil, err
+		}
+		return value, nil
+	}
+	return string(content), nil
+}
+
+// matchesEncoding reports whether the Content-Type of a part matches the comma-separated media types or ranges of
+// the `encoding` of its property, e.g. `image/png, image/*`
+func matchesEncoding(partType, encodingTypes string) bool {
+	essence, _ := parseMediaType(partType)
+	for _, declared := range strings.Split(encodingTypes, ",") {
+		if rangeSpecificity(declared, essence) >= 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// multipartProperties returns the properties of the schema of a multipart body, resolving its reference
+func (v *DefaultValidator) multipartProperties(schema *oas.Schema) map[string]oas.Schema {
+	if schema != nil && schema.Ref != "" {
+		resolved, err := v.resolveSchemaReference(schema.Ref)
+		if err != nil {
+			return nil
+		}
+		schema = resolved
+	}
+	if schema == nil {
+		return nil
+	}
+	return schema.Properties
+}
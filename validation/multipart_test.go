@@ -0,0 +1,123 @@
+package validation
+
+import (
+	"bytes"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"testing"
+
+	"github.com/lionelgarnier/validate-api-request/oas"
+	"github.com/stretchr/testify/assert"
+)
+
+// formPart is a part of a multipart/form-data test body
+type formPart struct {
+	name        string
+	filename    string
+	contentType string
+	content     string
+}
+
+func multipartBody(t *testing.T, parts []formPart) (*bytes.Buffer, string) {
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	for _, part := range parts {
+		header := make(textproto.MIMEHeader)
+		disposition := `form-data; name="` + part.name + `"`
+		if part.filename != "" {
+			disposition += `; filename="` + part.filename + `"`
+		}
+		header.Set("Content-Disposition", disposition)
+		if part.contentType != "" {
+			header.Set("Content-Type", part.contentType)
+		}
+		w, err := writer.CreatePart(header)
+		assert.NoError(t, err)
+		w.Write([]byte(part.content))
+	}
+	assert.NoError(t, writer.Close())
+	return &body, writer.FormDataContentType()
+}
+
+func TestValidateMultipartBody(t *testing.T) {
+	manager := oas.NewOASManager(nil, oas.FixedSelector(map[string]string{"test": "test"}))
+	err := manager.LoadAPI("test", []byte(`{
+        "openapi": "3.0.0",
+        "info": {"title": "Test API", "version": "1.0.0"},
+        "paths": {
+            "/pets": {
+                "post": {
+                    "requestBody": {"content": {"multipart/form-data": {
+                        "schema": {"$ref": "#/components/schemas/Upload"},
+                        "encoding": {"photo": {"contentType": "image/png, image/jpeg"}}
+                    }}}
+                }
+            }
+        },
+        "components": {
+            "schemas": {
+                "Upload": {
+                    "type": "object",
+                    "required": ["name", "photo"],
+                    "properties": {
+                        "name": {"type": "string", "maxLength": 10},
+                        "age": {"type": "integer"},
+                        "tags": {"type": "array", "items": {"type": "string"}},
+                        "metadata": {"type": "object", "properties": {"color": {"type": "string"}}},
+                        "photo": {"type": "string", "format": "binary", "maxLength": 8}
+                    }
+                }
+            }
+        }
+    }`))
+	assert.NoError(t, err)
+	spec, _ := manager.GetApiSpec("test")
+
+	photo := formPart{name: "photo", filename: "rex.png", contentType: "image/png", content: "\x89PNG\xff\xfe"}
+	tests := []struct {
+		name              string
+		parts             []formPart
+		contentType       string
+		contentTypeSuffix string
+		expectedError     string
+	}{
+		{name: "Valid form", parts: []formPart{{name: "name", content: "Rex"}, {name: "age", content: "3"}, {name: "tags", content: "good"}, photo}},
+		{name: "Repeated field", parts: []formPart{{name: "name", content: "Rex"}, {name: "tags", content: "good"}, {name: "tags", content: "dog"}, photo}},
+		{name: "JSON part", parts: []formPart{{name: "name", content: "Rex"}, {name: "metadata", contentType: "application/json", content: `{"color": "brown"}`}, photo}},
+		{name: "Missing required field", parts: []formPart{photo}, expectedError: "name: required property is missing"},
+		{name: "Missing file", parts: []formPart{{name: "name", content: "Rex"}}, expectedError: "photo: required property is missing"},
+		{name: "Invalid field", parts: []formPart{{name: "name", content: "Rex"}, {name: "age", content: "old"}, photo}, expectedError: "age: expected integer"},
+		{name: "Too long field", parts: []formPart{{name: "name", content: "Rex the great dog"}, photo}, expectedError: "name: length must be at most 10"},
+		{name: "File too large", parts: []formPart{{name: "name", content: "Rex"}, {name: "photo", filename: "rex.png", contentType: "image/png", content: "ééééé"}},
+			expectedError: "photo: length must be at most 8"},
+		{name: "File of undeclared content type", parts: []formPart{{name: "name", content: "Rex"}, {name: "photo", filename: "rex.gif", contentType: "image/gif", content: "GIF"}},
+			expectedError: "part 'photo': content type 'image/gif' does not match"},
+		{name: "Invalid JSON part", parts: []formPart{{name: "name", content: "Rex"}, {name: "metadata", contentType: "application/json", content: `{`}, photo},
+			expectedError: "invalid request body: part 'metadata'"},
+		{name: "Missing boundary", parts: []formPart{{name: "name", content: "Rex"}}, contentType: "multipart/form-data", expectedError: "missing multipart boundary"},
+		{name: "Malformed extra parameters", parts: []formPart{{name: "name", content: "Rex"}, photo}, contentTypeSuffix: "; charset; "},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body, contentType := multipartBody(t, tt.parts)
+			if tt.contentType != "" {
+				contentType = tt.contentType
+			}
+			contentType += tt.contentTypeSuffix
+			req, err := http.NewRequest(http.MethodPost, "/pets", body)
+			assert.NoError(t, err)
+			req.Header.Set("Content-Type", contentType)
+
+			ok, err := NewValidator(spec).ValidateRequest(oas.NewOASRequest(req))
+			if tt.expectedError == "" {
+				assert.True(t, ok)
+				assert.NoError(t, err)
+				return
+			}
+			assert.False(t, ok)
+			assert.ErrorContains(t, err, tt.expectedError)
+		})
+	}
+}
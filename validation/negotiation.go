@@ -0,0 +1,179 @@
+package validation
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/lionelgarnier/validate-api-request/oas"
+)
+
+// QualityValue is an entry of a quality-valued list header (e.g. `fr;q=0.8`)
+type QualityValue struct {
+	Value string
+	Q     float64
+}
+
+// qualityHeaders lists the headers holding quality-valued lists
+var qualityHeaders = map[string]bool{
+	"Accept":          true,
+	"Accept-Language": true,
+	"Accept-Charset":  true,
+	"Accept-Encoding": true,
+}
+
+type negotiationKey struct{}
+
+// ParseQualityValues parses a quality-valued list header, ordered by decreasing quality
+func ParseQualityValues(header string) ([]QualityValue, error) {
+	var values []QualityValue
+	for _, entry := range strings.Split(header, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		parts := strings.Split(entry, ";")
+		value := QualityValue{Value: strings.TrimSpace(parts[0]), Q: 1}
+		if value.Value == "" {
+			return nil, fmt.Errorf("empty value in '%s'", entry)
+		}
+		for _, param := range parts[1:] {
+			name, raw, found := strings.Cut(strings.TrimSpace(param), "=")
+			if !found || !strings.EqualFold(strings.TrimSpace(name), "q") {
+				// Media type parameters (e.g. charset) are kept with the value
+				value.Value += ";" + strings.TrimSpace(param)
+				continue
+			}
+			q, err := strconv.ParseFloat(strings.TrimSpace(raw), 64)
+			if err != nil || q < 0 || q > 1 {
+				return nil, fmt.Errorf("invalid quality value '%s'", raw)
+			}
+			value.Q = q
+		}
+		values = append(values, value)
+	}
+
+	sort.SliceStable(values, func(i, j int) bool {
+		return values[i].Q > values[j].Q
+	})
+	return values, nil
+}
+
+// Negotiate returns the offer preferred by the quality-valued list, or false if none is acceptable.
+// Offers are tried in order, so the first offer wins when qualities are equal.
+func Negotiate(values []QualityValue, offers []string) (string, bool) {
+	best, bestQ := "", 0.0
+	for _, offer := range offers {
+		q, specificity := 0.0, -1
+		for _, value := range values {
+			if s := rangeSpecificity(value.Value, offer); s > specificity {
+				q, specificity = value.Q, s
+			}
+		}
+		if q > bestQ {
+			best, bestQ = offer, q
+		}
+	}
+	return best, bestQ > 0
+}
+
+// rangeSpecificity returns how specifically a range (e.g. `text/*`, `fr`, `*`) matches an offer, or -1 if it does not match
+func rangeSpecificity(valueRange, offer string) int {
+	valueRange, _, _ = strings.Cut(valueRange, ";")
+	valueRange = strings.ToLower(strings.TrimSpace(valueRange))
+	offer = strings.ToLower(offer)
+
+	switch {
+	case valueRange == offer:
+		return 3
+	case valueRange == "*" || valueRange == "*/*":
+		return 0
+	case strings.HasSuffix(valueRange, "/*") && strings.HasPrefix(offer, strings.TrimSuffix(valueRange, "*")):
+		return 1
+	case strings.HasPrefix(offer, valueRange+"-"):
+		// Language range prefix (e.g. `fr` matches `fr-CH`)
+		return 2
+	}
+	return -1
+}
+
+// NegotiatedValue returns the value negotiated for a quality-valued header parameter of a validated request
+func NegotiatedValue(r *http.Request, header string) (string, bool) {
+	negotiated, ok := r.Context().Value(negotiationKey{}).(map[string]string)
+	if !ok {
+		return "", false
+	}
+	value, exists := negotiated[http.CanonicalHeaderKey(header)]
+	return value, exists
+}
+
+// validateQualityHeader validates a quality-valued header parameter and records the negotiated value on the request.
+// It returns false when the parameter is not a quality-valued header.
+func (v *DefaultValidator) validateQualityHeader(req *oas.OASRequest, param *oas.Parameter) (bool, error) {
+	name := http.CanonicalHeaderKey(param.Name)
+	if !qualityHeaders[name] {
+		return false, nil
+	}
+
+	header := req.Request.Header.Get(name)
+	if header == "" {
+		if param.Required {
+			return true, fmt.Errorf("missing required parameter '%s'", param.Name)
+		}
+		return true, nil
+	}
+
+	values, err := ParseQualityValues(header)
+	if err != nil {
+		return true, &ValidationError{
+			Stage:   StageParameters,
+			Message: fmt.Sprintf("invalid value for parameter '%s'", param.Name),
+			Err:     err,
+		}
+	}
+
+	schema := param.Schema
+	if schema != nil && schema.Ref != "" {
+		schema, err = v.resolveSchemaReference(schema.Ref)
+		if err != nil {
+			return true, err
+		}
+	}
+
+	var negotiated string
+	if schema != nil && len(schema.Enum) > 0 {
+		offers := make([]string, 0, len(schema.Enum))
+		for _, offer := range schema.Enum {
+			offers = append(offers, fmt.Sprint(offer))
+		}
+		var ok bool
+		if negotiated, ok = Negotiate(values, offers); !ok {
+			return true, &ValidationError{
+				Stage:   StageParameters,
+				Message: fmt.Sprintf("no acceptable value for parameter '%s'", param.Name),
+				Err:     fmt.Errorf("expected one of %v", offers),
+			}
+		}
+	} else if len(values) > 0 && values[0].Q > 0 {
+		negotiated = values[0].Value
+	}
+
+	if negotiated != "" {
+		setNegotiatedValue(req, name, negotiated)
+	}
+	return true, nil
+}
+
+// setNegotiatedValue records a negotiated header value in the request context
+func setNegotiatedValue(req *oas.OASRequest, header, value string) {
+	negotiated, ok := req.Request.Context().Value(negotiationKey{}).(map[string]string)
+	if !ok {
+		negotiated = make(map[string]string)
+		req.Request = req.Request.WithContext(context.WithValue(req.Request.Context(), negotiationKey{}, negotiated))
+	}
+	negotiated[header] = value
+}
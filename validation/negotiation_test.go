@@ -0,0 +1,109 @@
+package validation
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/lionelgarnier/validate-api-request/oas"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNegotiate(t *testing.T) {
+	tests := []struct {
+		name     string
+		header   string
+		offers   []string
+		expected string
+		ok       bool
+	}{
+		{name: "Highest quality wins", header: "fr;q=0.5, en;q=0.9", offers: []string{"fr", "en"}, expected: "en", ok: true},
+		{name: "Language range prefix", header: "fr, en;q=0.5", offers: []string{"en", "fr-CH"}, expected: "fr-CH", ok: true},
+		{name: "Specific range overrides wildcard", header: "*;q=0.5, de;q=0", offers: []string{"de", "it"}, expected: "it", ok: true},
+		{name: "Media range", header: "text/*;q=0.3, application/json", offers: []string{"text/html", "application/json"}, expected: "application/json", ok: true},
+		{name: "Equal quality keeps offer order", header: "en, fr", offers: []string{"fr", "en"}, expected: "fr", ok: true},
+		{name: "No acceptable offer", header: "de", offers: []string{"fr", "en"}, ok: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			values, err := ParseQualityValues(tt.header)
+			assert.NoError(t, err)
+
+			negotiated, ok := Negotiate(values, tt.offers)
+			assert.Equal(t, tt.ok, ok)
+			assert.Equal(t, tt.expected, negotiated)
+		})
+	}
+
+	_, err := ParseQualityValues("en;q=2")
+	assert.Error(t, err)
+}
+
+func TestValidateQualityHeaders(t *testing.T) {
+	manager := oas.NewOASManager(nil, oas.FixedSelector(map[string]string{"test": "test"}))
+
+	content := []byte(`{
+        "openapi": "3.0.0",
+        "info": {
+            "title": "Test API",
+            "version": "1.0.0"
+        },
+        "paths": {
+            "/greeting": {
+                "get": {
+                    "parameters": [
+                        {
+                            "name": "Accept-Language",
+                            "in": "header",
+                            "schema": {
+                                "type": "string",
+                                "enum": ["en", "fr"]
+                            }
+                        }
+                    ]
+                }
+            }
+        }
+    }`)
+
+	err := manager.LoadAPI("test", content)
+	assert.NoError(t, err)
+	spec, _ := manager.GetApiSpec("test")
+	validator := NewValidator(spec)
+
+	tests := []struct {
+		name          string
+		header        string
+		expected      string
+		expectedError string
+	}{
+		{name: "Negotiated language", header: "fr-CH, fr;q=0.9, en;q=0.8", expected: "fr"},
+		{name: "No header", header: ""},
+		{name: "Invalid quality value", header: "fr;q=abc", expectedError: "invalid value for parameter 'Accept-Language'"},
+		{name: "No acceptable language", header: "de, it;q=0.5", expectedError: "no acceptable value for parameter 'Accept-Language'"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequest(http.MethodGet, "/greeting", nil)
+			assert.NoError(t, err)
+			if tt.header != "" {
+				req.Header.Set("Accept-Language", tt.header)
+			}
+
+			oasRequest := oas.NewOASRequest(req)
+			ok, err := validator.ValidateParameters(oasRequest)
+			if tt.expectedError != "" {
+				assert.False(t, ok)
+				assert.ErrorContains(t, err, tt.expectedError)
+				return
+			}
+
+			assert.True(t, ok)
+			assert.NoError(t, err)
+			negotiated, exists := NegotiatedValue(oasRequest.Request, "accept-language")
+			assert.Equal(t, tt.expected != "", exists)
+			assert.Equal(t, tt.expected, negotiated)
+		})
+	}
+}
@@ -0,0 +1,84 @@
+package validation
+
+import (
+	"regexp"
+	"strings"
+)
+
+// NumberStrictness rejects numeric strings that parse as numbers but often reveal client bugs or smuggling attempts.
+// It applies to the string values validated as integers or numbers, such as parameters.
+type NumberStrictness struct {
+	RejectLeadingZeros bool `json:"rejectLeadingZeros,omitempty" yaml:"rejectLeadingZeros,omitempty"` // "007", "-01.5"
+	RejectPlusSign     bool `json:"rejectPlusSign,omitempty" yaml:"rejectPlusSign,omitempty"`         // "+1"
+	RejectHex          bool `json:"rejectHex,omitempty" yaml:"rejectHex,omitempty"`                   // "0x1p4"
+	RejectNonFinite    bool `json:"rejectNonFinite,omitempty" yaml:"rejectNonFinite,omitempty"`       // "Inf", "NaN"
+	// AllowThousandsSeparators accepts digits grouped by thousands with `,`, `_`, `'` or spaces and a `.` decimal
+	// point, e.g. "1,234.5", while migrating clients. Other locale-formatted numbers are still rejected.
+	AllowThousandsSeparators bool `json:"allowThousandsSeparators,omitempty" yaml:"allowThousandsSeparators,omitempty"`
+}
+
+// thousandsSeparators are the separators of the digits grouped by thousands accepted by AllowThousandsSeparators
+var thousandsSeparators = []string{",", "_", "'", " ", "\u00a0", "\u202f"}
+
+// localeNumberPattern matches the numbers formatted with locale separators, e.g. "1.234,56" or "1_000"
+var localeNumberPattern = regexp.MustCompile(`^[+-]?[0-9]+([.,_' \x{a0}\x{202f}][0-9]+)+$`)
+
+// groupedNumberPatterns match the numbers grouped by thousands with a single separator and a `.` decimal point,
+// by separator
+var groupedNumberPatterns = make(map[string]*regexp.Regexp, len(thousandsSeparators))
+
+func init() {
+	for _, separator := range thousandsSeparators {
+		groupedNumberPatterns[separator] = regexp.MustCompile(`^[+-]?[0-9]{1,3}(` + regexp.QuoteMeta(separator) + `[0-9]{3})+(\.[0-9]+)?$`)
+	}
+}
+
+// WithNumberStrictness sets the numeric strings rejected before they are parsed
+func WithNumberStrictness(strictness NumberStrictness) Option {
+	return func(v *DefaultValidator) {
+		v.numberStrictness = strictness
+	}
+}
+
+// check returns the failure of a numeric string rejected by the strictness, other values are not checked
+func (s NumberStrictness) check(value interface{}, path string) error {
+	str, ok := value.(string)
+	if !ok {
+		return nil
+	}
+
+	digits := strings.TrimLeft(str, "+-")
+	switch {
+	case s.RejectPlusSign && strings.HasPrefix(str, "+"):
+		return newSchemaError(path, "number must not have a plus sign")
+	case s.RejectHex && len(digits) > 1 && digits[0] == '0' && (digits[1] == 'x' || digits[1] == 'X'):
+		return newSchemaError(path, "number must not be hexadecimal")
+	case s.RejectLeadingZeros && len(digits) > 1 && digits[0] == '0' && digits[1] >= '0' && digits[1] <= '9':
+		return newSchemaError(path, "number must not have leading zeros")
+	case s.RejectNonFinite && (strings.EqualFold(digits, "inf") || strings.EqualFold(digits, "infinity") || strings.EqualFold(digits, "nan")):
+		return newSchemaError(path, "number must be finite")
+	}
+	return nil
+}
+
+// ungroup returns a numeric string without its thousands separators when they are allowed, and the failure of the
+// locale-formatted numbers, which are rejected with CategoryLocaleNumber rather than as a generic type failure
+func (s NumberStrictness) ungroup(value interface{}, path string) (interface{}, error) {
+	str, ok := value.(string)
+	if !ok || !localeNumberPattern.MatchString(str) {
+		return value, nil
+	}
+	// Numbers with a single `.` are not locale-formatted, while underscores, which Go parses, are
+	if !strings.ContainsAny(str, ",_' \u00a0\u202f") && strings.Count(str, ".") < 2 {
+		return value, nil
+	}
+
+	if s.AllowThousandsSeparators {
+		for _, separator := range thousandsSeparators {
+			if groupedNumberPatterns[separator].MatchString(str) {
+				return strings.ReplaceAll(str, separator, ""), nil
+			}
+		}
+	}
+	return nil, &SchemaError{Path: path, Message: "number must not be locale-formatted", Category: CategoryLocaleNumber}
+}
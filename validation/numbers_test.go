@@ -0,0 +1,209 @@
+package validation
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/lionelgarnier/validate-api-request/oas"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNumberStrictness(t *testing.T) {
+	manager := oas.NewOASManager(nil, oas.FixedSelector(map[string]string{"test": "test"}))
+	err := manager.LoadAPI("test", []byte(`{
+        "openapi": "3.0.0",
+        "info": {"title": "Test API", "version": "1.0.0"},
+        "paths": {
+            "/pets": {
+                "get": {
+                    "parameters": [
+                        {"name": "limit", "in": "query", "schema": {"type": "integer"}},
+                        {"name": "weight", "in": "query", "schema": {"type": "number"}}
+                    ]
+                }
+            }
+        }
+    }`))
+	assert.NoError(t, err)
+	spec, _ := manager.GetApiSpec("test")
+
+	strict := NumberStrictness{RejectLeadingZeros: true, RejectPlusSign: true, RejectHex: true, RejectNonFinite: true}
+
+	tests := []struct {
+		name          string
+		parameter     string
+		value         string
+		strictness    NumberStrictness
+		expectedError string
+	}{
+		{name: "Leading zeros accepted by default", parameter: "limit", value: "007"},
+		{name: "Plus sign accepted by default", parameter: "limit", value: "+1"},
+		{name: "Hexadecimal accepted by default", parameter: "weight", value: "0x1p4"},
+		{name: "Infinity accepted by default", parameter: "weight", value: "Inf"},
+		{name: "Leading zeros", parameter: "limit", value: "007", strictness: strict, expectedError: "number must not have leading zeros"},
+		{name: "Negative leading zeros", parameter: "weight", value: "-01.5", strictness: strict, expectedError: "number must not have leading zeros"},
+		{name: "Plus sign", parameter: "limit", value: "+1", strictness: strict, expectedError: "number must not have a plus sign"},
+		{name: "Hexadecimal", parameter: "weight", value: "0x1p4", strictness: strict, expectedError: "number must not be hexadecimal"},
+		{name: "Infinity", parameter: "weight", value: "-Infinity", strictness: strict, expectedError: "number must be finite"},
+		{name: "NaN", parameter: "weight", value: "nan", strictness: strict, expectedError: "number must be finite"},
+		{name: "Zero", parameter: "limit", value: "0", strictness: strict},
+		{name: "Fraction", parameter: "weight", value: "-0.5", strictness: strict},
+		{name: "Exponent", parameter: "weight", value: "1e3", strictness: strict},
+		{name: "Only the selected rules", parameter: "limit", value: "+1", strictness: NumberStrictness{RejectLeadingZeros: true}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			query := url.Values{tt.parameter: {tt.value}}
+			req, err := http.NewRequest(http.MethodGet, "/pets?"+query.Encode(), nil)
+			assert.NoError(t, err)
+
+			ok, err := NewValidator(spec, WithNumberStrictness(tt.strictness)).ValidateParameters(oas.NewOASRequest(req))
+			if tt.expectedError == "" {
+				assert.True(t, ok)
+				assert.NoError(t, err)
+			} else {
+				assert.False(t, ok)
+				assert.Error(t, err)
+				assert.Contains(t, err.Error(), tt.expectedError)
+			}
+		})
+	}
+}
+
+func TestLocaleNumbers(t *testing.T) {
+	manager := oas.NewOASManager(nil, oas.FixedSelector(map[string]string{"test": "test"}))
+	err := manager.LoadAPI("test", []byte(`{
+        "openapi": "3.0.0",
+        "info": {"title": "Test API", "version": "1.0.0"},
+        "paths": {
+            "/pets": {
+                "get": {
+                    "parameters": [
+                        {"name": "limit", "in": "query", "schema": {"type": "integer"}},
+                        {"name": "price", "in": "query", "schema": {"type": "number", "maximum": 5000}}
+                    ]
+                }
+            }
+        }
+    }`))
+	assert.NoError(t, err)
+	spec, _ := manager.GetApiSpec("test")
+
+	lenient := NumberStrictness{AllowThousandsSeparators: true}
+
+	tests := []struct {
+		name          string
+		parameter     string
+		value         string
+		strictness    NumberStrictness
+		expectedError string
+		category      Category
+	}{
+		{name: "Decimal comma", parameter: "price", value: "1234,56", expectedError: "number must not be locale-formatted", category: CategoryLocaleNumber},
+		{name: "Grouped with dots", parameter: "price", value: "1.234,56", expectedError: "number must not be locale-formatted", category: CategoryLocaleNumber},
+		{name: "Grouped with underscores", parameter: "limit", value: "1_000", expectedError: "number must not be locale-formatted", category: CategoryLocaleNumber},
+		{name: "Grouped with commas", parameter: "limit", value: "1,000", expectedError: "number must not be locale-formatted", category: CategoryLocaleNumber},
+		{name: "Not a number", parameter: "limit", value: "ten", expectedError: "expected integer", category: CategoryInvalidParameter},
+		{name: "Plain number", parameter: "price", value: "1234.56"},
+		{name: "Lenient commas", parameter: "price", value: "1,234.56", strictness: lenient},
+		{name: "Lenient underscores", parameter: "limit", value: "1_000", strictness: lenient},
+		{name: "Lenient spaces", parameter: "limit", value: "1 000", strictness: lenient},
+		{name: "Lenient value still validated", parameter: "price", value: "12,345", strictness: lenient, expectedError: "value must be at most 5000"},
+		{name: "Lenient decimal comma", parameter: "price", value: "1.234,56", strictness: lenient, expectedError: "number must not be locale-formatted", category: CategoryLocaleNumber},
+		{name: "Lenient misplaced separator", parameter: "limit", value: "10,00", strictness: lenient, expectedError: "number must not be locale-formatted", category: CategoryLocaleNumber},
+		{name: "Lenient mixed separators", parameter: "limit", value: "1,000_000", strictness: lenient, expectedError: "number must not be locale-formatted", category: CategoryLocaleNumber},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			query := url.Values{tt.parameter: {tt.value}}
+			req, err := http.NewRequest(http.MethodGet, "/pets?"+query.Encode(), nil)
+			assert.NoError(t, err)
+
+			ok, err := NewValidator(spec, WithNumberStrictness(tt.strictness)).ValidateParameters(oas.NewOASRequest(req))
+			if tt.expectedError == "" {
+				assert.True(t, ok)
+				assert.NoError(t, err)
+			} else {
+				assert.False(t, ok)
+				assert.ErrorContains(t, err, tt.expectedError)
+				if tt.category != "" {
+					assert.Equal(t, tt.category, CategoryOf(err))
+				}
+			}
+		})
+	}
+}
+
+func TestPreciseNumbers(t *testing.T) {
+	manager := oas.NewOASManager(nil, oas.FixedSelector(map[string]string{"test": "test"}))
+	err := manager.LoadAPI("test", []byte(`{
+        "openapi": "3.0.0",
+        "info": {"title": "Test API", "version": "1.0.0"},
+        "paths": {
+            "/orders": {
+                "post": {
+                    "parameters": [
+                        {"name": "after", "in": "query", "schema": {"type": "integer", "format": "int64"}}
+                    ],
+                    "requestBody": {"content": {"application/json": {"schema": {
+                        "type": "object",
+                        "properties": {
+                            "id": {"type": "integer", "format": "int64"},
+                            "quantity": {"type": "integer", "minimum": 9007199254740992, "exclusiveMinimum": true},
+                            "batch": {"type": "integer", "multipleOf": 10},
+                            "tags": {"type": "array", "uniqueItems": true, "items": {"type": "number"}},
+                            "version": {"type": "integer", "const": 2}
+                        }
+                    }}}},
+                    "responses": {"201": {"description": "Created"}}
+                }
+            }
+        }
+    }`))
+	assert.NoError(t, err)
+	spec, _ := manager.GetApiSpec("test")
+
+	tests := []struct {
+		name    string
+		query   string
+		body    string
+		precise bool
+		valid   bool
+	}{
+		{name: "Largest int64", body: `{"id": 9223372036854775807}`, precise: true, valid: true},
+		{name: "Beyond int64", body: `{"id": 9223372036854775808}`, precise: true},
+		{name: "Largest int64 rounded by float64", body: `{"id": 9223372036854775807}`},
+		{name: "Smallest int64", body: `{"id": -9223372036854775808}`, precise: true, valid: true},
+		{name: "Fraction", body: `{"id": 1.5}`, precise: true},
+		{name: "Integral exponent", body: `{"id": 1e3}`, precise: true, valid: true},
+		{name: "Above exclusive minimum", body: `{"quantity": 9007199254740993}`, precise: true, valid: true},
+		{name: "Above exclusive minimum rounded by float64", body: `{"quantity": 9007199254740993}`},
+		{name: "Exclusive minimum", body: `{"quantity": 9007199254740992}`, precise: true},
+		{name: "Exact multiple", body: `{"batch": 90071992547409920}`, precise: true, valid: true},
+		{name: "Not an exact multiple", body: `{"batch": 90071992547409921}`, precise: true},
+		{name: "Unique large integers", body: `{"tags": [9007199254740992, 9007199254740993]}`, precise: true, valid: true},
+		{name: "Duplicate numbers", body: `{"tags": [1, 1.0]}`, precise: true},
+		{name: "Const", body: `{"version": 2}`, precise: true, valid: true},
+		{name: "Not const", body: `{"version": 3}`, precise: true},
+		{name: "Largest int64 parameter", query: "after=9223372036854775807", body: `{}`, valid: true},
+		{name: "Parameter beyond int64", query: "after=9223372036854775808", body: `{}`},
+	}
+
+	for _, tt := range tests {
+		for _, backend := range []BodyBackend{BodyBackendReflective, BodyBackendScan} {
+			t.Run(tt.name+" "+string(backend), func(t *testing.T) {
+				req, err := http.NewRequest(http.MethodPost, "/orders?"+tt.query, strings.NewReader(tt.body))
+				assert.NoError(t, err)
+				req.Header.Set("Content-Type", "application/json")
+
+				validator := NewValidator(spec, WithPreciseNumbers(tt.precise), WithBodyBackend(backend))
+				ok, err := validator.ValidateRequest(oas.NewOASRequest(req))
+				assert.Equal(t, tt.valid, ok, err)
+			})
+		}
+	}
+}
@@ -0,0 +1,114 @@
+package validation
+
+import (
+	"fmt"
+	"net/http"
+	"sync"
+	"sync/atomic"
+
+	"github.com/lionelgarnier/validate-api-request/oas"
+)
+
+// StageWorkers bounds the goroutines validating the bodies of large requests concurrently with their parameters.
+// They are shared by validators, e.g. those of a middleware, so that the bound holds across requests.
+type StageWorkers struct {
+	slots       chan struct{}
+	minBodySize int64
+}
+
+// NewStageWorkers returns workers validating up to workers bodies concurrently with the parameters of their
+// requests, for bodies of at least minBodySize bytes or of unknown length. Requests arriving while all workers are
+// busy are validated sequentially rather than waiting.
+func NewStageWorkers(workers int, minBodySize int64) *StageWorkers {
+	return &StageWorkers{
+		slots:       make(chan struct{}, max(workers, 1)),
+		minBodySize: minBodySize,
+	}
+}
+
+// WithStageWorkers validates the bodies of large requests concurrently with their parameters, with the workers
+func WithStageWorkers(workers *StageWorkers) Option {
+	return func(v *DefaultValidator) {
+		v.stageWorkers = workers
+	}
+}
+
+// acquire reserves a worker for the body of a request, and reports whether it did. Requests without body, with a
+// small one, or arriving while all workers are busy are validated sequentially.
+func (w *StageWorkers) acquire(r *http.Request) bool {
+	if w == nil || r.Body == nil || r.Body == http.NoBody || r.ContentLength >= 0 && r.ContentLength < w.minBodySize {
+		return false
+	}
+	select {
+	case w.slots <- struct{}{}:
+		return true
+	default:
+		return false
+	}
+}
+
+// sharedEvaluations returns the evaluations counted by the concurrent stages of a request, nil when its stages
+// are validated sequentially
+func (v *DefaultValidator) sharedEvaluations(req *oas.OASRequest) *atomic.Int64 {
+	if v.stageWorkers == nil {
+		return nil
+	}
+	if shared, exists := v.stageEvaluations.Load(req); exists {
+		return shared.(*atomic.Int64)
+	}
+	return nil
+}
+
+// release frees a worker
+func (w *StageWorkers) release() {
+	<-w.slots
+}
+
+// validateBodyConcurrently starts validating the body of a request on a reserved worker, and returns the function
+// waiting for its verdict. The body is validated on a copy of the request, whose buffered body, coercions and
+// deprecations are merged into the request once it is done, after those of the parameters as when validated
+// sequentially. Requests pinning parameters, whose check reads the body, are not validated concurrently.
+// Parameters and body share the evaluation budget of the request, counting their evaluations together.
+func (v *DefaultValidator) validateBodyConcurrently(req *oas.OASRequest) func() (bool, error) {
+	original := req.Request
+	bodyRequest := *original
+	bodyReq := &oas.OASRequest{Request: &bodyRequest, Route: req.Route, PathItem: req.PathItem, Operation: req.Operation}
+
+	shared := new(atomic.Int64)
+	shared.Store(int64(req.Evaluations))
+	v.stageEvaluations.Store(req, shared)
+	v.stageEvaluations.Store(bodyReq, shared)
+
+	done := make(chan struct{})
+	var ok bool
+	var err error
+	go func() {
+		defer close(done)
+		defer v.stageWorkers.release()
+		defer func() {
+			if p := recover(); p != nil {
+				ok, err = false, fmt.Errorf("body validation panicked: %v", p)
+			}
+		}()
+		ok, err = v.ValidateRequestBody(bodyReq)
+	}()
+
+	return sync.OnceValues(func() (bool, error) {
+		<-done
+		v.stageEvaluations.Delete(req)
+		v.stageEvaluations.Delete(bodyReq)
+		// Parameters may have replaced the request with a copy carrying negotiated values. The body may have been
+		// buffered, or rewritten with a new length.
+		for _, r := range []*http.Request{original, req.Request} {
+			r.Body = bodyRequest.Body
+			r.GetBody = bodyRequest.GetBody
+			r.ContentLength = bodyRequest.ContentLength
+		}
+		req.Coercions = append(req.Coercions, bodyReq.Coercions...)
+		req.Evaluations += bodyReq.Evaluations
+		for _, deprecation := range bodyReq.Deprecations {
+			deprecate(req, deprecation.Location, deprecation.Path)
+		}
+		return ok, err
+	})
+}
@@ -0,0 +1,156 @@
+package validation
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/lionelgarnier/validate-api-request/oas"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStageWorkers(t *testing.T) {
+	manager := oas.NewOASManager(nil, oas.FixedSelector(map[string]string{"test": "test"}))
+	err := manager.LoadAPI("test", []byte(`{
+        "openapi": "3.0.0",
+        "paths": {
+            "/pets": {
+                "post": {
+                    "parameters": [
+                        {"name": "limit", "in": "query", "schema": {"type": "integer"}},
+                        {"name": "legacy", "in": "query", "deprecated": true, "schema": {"type": "string"}},
+                        {"name": "Accept-Language", "in": "header", "schema": {"type": "string", "enum": ["en", "fr"]}}
+                    ],
+                    "requestBody": {"content": {"application/json": {"schema": {
+                        "type": "object",
+                        "required": ["name"],
+                        "properties": {
+                            "id": {"type": "integer", "readOnly": true},
+                            "name": {"type": "string"},
+                            "nickname": {"type": "string", "deprecated": true}
+                        }
+                    }}}},
+                    "responses": {"201": {"description": "Created"}}
+                }
+            }
+        }
+    }`))
+	assert.NoError(t, err)
+	spec, err := manager.GetApiSpec("test")
+	assert.NoError(t, err)
+
+	newRequest := func(query, body string) *oas.OASRequest {
+		r := httptest.NewRequest(http.MethodPost, "/pets?"+query, strings.NewReader(body))
+		r.Header.Set("Content-Type", "application/json")
+		r.Header.Set("Accept-Language", "fr")
+		return oas.NewOASRequest(r)
+	}
+
+	t.Run("valid request", func(t *testing.T) {
+		validator := NewValidator(spec, WithStageWorkers(NewStageWorkers(2, 0)))
+		req := newRequest("limit=10&legacy=x", `{"name": "Rex", "nickname": "R"}`)
+		ok, err := validator.ValidateRequest(req)
+		assert.True(t, ok)
+		assert.NoError(t, err)
+		assert.Equal(t, []oas.Deprecation{{Location: "query", Path: "legacy"}, {Location: "body", Path: "nickname"}}, req.Deprecations)
+		assert.Equal(t, []oas.Coercion{{Location: "query", Path: "limit", Value: "10", Type: "integer"}}, req.Coercions)
+
+		language, ok := NegotiatedValue(req.Request, "Accept-Language")
+		assert.True(t, ok)
+		assert.Equal(t, "fr", language)
+		body, err := io.ReadAll(req.Request.Body)
+		assert.NoError(t, err)
+		assert.JSONEq(t, `{"name": "Rex", "nickname": "R"}`, string(body))
+	})
+
+	t.Run("failures reported in stage order", func(t *testing.T) {
+		validator := NewValidator(spec, WithStageWorkers(NewStageWorkers(2, 0)), WithCollectAll(true))
+		ok, err := validator.ValidateRequest(newRequest("limit=ten", `{}`))
+		assert.False(t, ok)
+		var errs ValidationErrors
+		assert.True(t, errors.As(err, &errs))
+		if assert.Len(t, errs, 2) {
+			assert.Equal(t, StageParameters, errs[0].Stage)
+			assert.Equal(t, StageBody, errs[1].Stage)
+		}
+
+		validator = NewValidator(spec, WithStageWorkers(NewStageWorkers(2, 0)))
+		ok, err = validator.ValidateRequest(newRequest("limit=10", `{}`))
+		assert.False(t, ok)
+		var validationErr *ValidationError
+		assert.True(t, errors.As(err, &validationErr))
+		assert.Equal(t, StageBody, validationErr.Stage)
+	})
+
+	t.Run("small bodies and busy workers validated sequentially", func(t *testing.T) {
+		workers := NewStageWorkers(1, 1024)
+		validator := NewValidator(spec, WithStageWorkers(workers))
+		req := newRequest("limit=10", `{"name": "Rex"}`)
+		assert.False(t, workers.acquire(req.Request))
+		ok, err := validator.ValidateRequest(req)
+		assert.True(t, ok)
+		assert.NoError(t, err)
+
+		workers = NewStageWorkers(1, 0)
+		validator = NewValidator(spec, WithStageWorkers(workers))
+		assert.True(t, workers.acquire(newRequest("", `{}`).Request))
+		ok, err = validator.ValidateRequest(newRequest("limit=ten", `{"name": "Rex"}`))
+		assert.False(t, ok)
+		assert.Error(t, err)
+		workers.release()
+		assert.Len(t, workers.slots, 0)
+	})
+
+	t.Run("rewritten body merged", func(t *testing.T) {
+		validator := NewValidator(spec, WithStageWorkers(NewStageWorkers(2, 0)), WithReadOnlyMode(ReadOnlyStrip))
+		req := newRequest("limit=10", `{"id": 1, "name": "Rex"}`)
+		ok, err := validator.ValidateRequest(req)
+		assert.True(t, ok)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(len(`{"name":"Rex"}`)), req.Request.ContentLength)
+		body, err := req.Request.GetBody()
+		assert.NoError(t, err)
+		content, err := io.ReadAll(body)
+		assert.NoError(t, err)
+		assert.Equal(t, `{"name":"Rex"}`, string(content))
+	})
+
+	t.Run("evaluation budget shared", func(t *testing.T) {
+		sequential := newRequest("limit=10&legacy=x", `{"name": "Rex", "nickname": "R"}`)
+		ok, err := NewValidator(spec).ValidateRequest(sequential)
+		assert.True(t, ok)
+		assert.NoError(t, err)
+
+		// Parameters and body are counted together, as when validated one after the other
+		validator := NewValidator(spec, WithStageWorkers(NewStageWorkers(2, 0)), WithMaxEvaluations(sequential.Evaluations))
+		req := newRequest("limit=10&legacy=x", `{"name": "Rex", "nickname": "R"}`)
+		ok, err = validator.ValidateRequest(req)
+		assert.True(t, ok)
+		assert.NoError(t, err)
+		assert.Equal(t, sequential.Evaluations, req.Evaluations)
+
+		validator = NewValidator(spec, WithStageWorkers(NewStageWorkers(2, 0)), WithMaxEvaluations(sequential.Evaluations-1))
+		ok, err = validator.ValidateRequest(newRequest("limit=10&legacy=x", `{"name": "Rex", "nickname": "R"}`))
+		assert.False(t, ok)
+		assert.Equal(t, CategoryComplexityExceeded, CategoryOf(err))
+	})
+
+	t.Run("parameters pinned", func(t *testing.T) {
+		validator := NewValidator(spec, WithStageWorkers(NewStageWorkers(4, 0)), WithParameterPinning(true),
+			WithReadOnlyMode(ReadOnlyStrip))
+		for i := 0; i < 50; i++ {
+			req := newRequest("limit=10", `{"id": 1, "name": "Rex"}`)
+			ok, err := validator.ValidateRequest(req)
+			assert.True(t, ok)
+			assert.NoError(t, err)
+			assert.Equal(t, int64(len(`{"name":"Rex"}`)), req.Request.ContentLength)
+		}
+
+		ok, err := validator.ValidateRequest(newRequest("limit=10", `{"name": "Rex", "limit": 20}`))
+		assert.False(t, ok)
+		assert.Error(t, err)
+	})
+}
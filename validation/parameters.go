@@ -2,9 +2,14 @@ package validation
 
 import (
 	"fmt"
+	"maps"
+	"net/http"
+	"net/url"
+	"slices"
 	"strings"
 
 	"github.com/lionelgarnier/validate-api-request/oas"
+	"github.com/lionelgarnier/validate-api-request/pkg/helpers"
 )
 
 // ValidateRequestPath validates the request path
@@ -17,52 +22,351 @@ func (v *DefaultValidator) ValidateParameters(req *oas.OASRequest) (bool, error)
 	}
 
 	pathItem := req.PathItem
-	route := req.Route
 	operation := req.Operation
 
-	parameters := mergeParameters(pathItem.Parameters, operation.Parameters)
-	var err error
+	// Resolve parameter references before merging, as they are merged by location and name
+	pathParameters, err := v.resolveParameters(pathItem.Parameters)
+	if err != nil {
+		return false, err
+	}
+	operationParameters, err := v.resolveParameters(operation.Parameters)
+	if err != nil {
+		return false, err
+	}
+	parameters := mergeParameters(pathParameters, operationParameters)
 
+	var errs ValidationErrors
 	for i := range parameters {
-		param := &parameters[i]
-		// Resolve parameter reference if necessary
-		if param.Schema.Ref != "" {
-			param, err = v.resolveParameterReference(param.Schema.Ref)
-			if err != nil {
+		if err := v.validateParameter(req, &parameters[i]); err != nil {
+			if !v.collectAll {
 				return false, err
 			}
+			errs.add(err)
 		}
+	}
 
-		var value string
-		switch param.In {
-		case "query":
-			value = req.Request.URL.Query().Get(param.Name)
-		case "header":
-			value = req.Request.Header.Get(param.Name)
-		case "path":
-			value = extractPathParam(req.Request.URL.Path, route, param.Name)
-		case "cookie":
-			cookie, err := req.Request.Cookie(param.Name)
-			if err != nil {
-				return false, fmt.Errorf("missing cookie parameter '%s'", param.Name)
+	if v.pinParameters {
+		for _, err := range v.checkParameterSources(req, parameters) {
+			if !v.collectAll {
+				return false, err
 			}
-			value = cookie.Value
+			errs.add(err)
 		}
+	}
 
-		if value == "" && param.Required {
-			return false, fmt.Errorf("missing required parameter '%s'", param.Name)
+	if v.strictHeaders {
+		for _, err := range v.checkUndeclaredHeaders(req, parameters) {
+			if !v.collectAll {
+				return false, err
+			}
+			errs.add(err)
 		}
+	}
 
-		if value != "" {
-			if !v.ValidateSchema(value, param.Schema) {
-				return false, fmt.Errorf("invalid type for parameter '%s'", param.Name)
-			}
+	// GraphQL requests sent with GET carry their envelope in the query string
+	if v.isGraphQLOperation(req) && req.Request.Method == http.MethodGet {
+		if err := checkGraphQLQuery(req.Request.URL.Query()); err != nil {
+			errs.add(&ValidationError{Stage: StageParameters, Message: "invalid GraphQL request", Err: err, SpecPointer: operationPointer(req)})
 		}
 	}
+	if err := errs.errOrNil(); err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// validateUnsignedParameters checks the undeclared headers and the parameter locations of a request when enabled,
+// for requests whose parameter values were validated by a previous hop
+func (v *DefaultValidator) validateUnsignedParameters(req *oas.OASRequest) (bool, error) {
+	parameters, err := v.operationParameters(req)
+	if err != nil {
+		return false, err
+	}
 
+	var errs ValidationErrors
+	if v.pinParameters {
+		errs = append(errs, v.checkParameterSources(req, parameters)...)
+	}
+	if v.strictHeaders {
+		errs = append(errs, v.checkUndeclaredHeaders(req, parameters)...)
+	}
+	if len(errs) > 0 && !v.collectAll {
+		return false, errs[0]
+	}
+	if err := errs.errOrNil(); err != nil {
+		return false, err
+	}
 	return true, nil
 }
 
+// validateParameter validates the value of a parameter in the request
+func (v *DefaultValidator) validateParameter(req *oas.OASRequest, param *oas.Parameter) error {
+	if param.In == "header" {
+		isQuality, err := v.validateQualityHeader(req, param)
+		if err != nil {
+			return err
+		}
+		if isQuality {
+			return nil
+		}
+
+		isFamily, err := v.validateHeaderFamily(req, param)
+		if err != nil {
+			return err
+		}
+		if isFamily {
+			return nil
+		}
+	}
+
+	value, present := v.parameterValue(req, param)
+	if !present && param.Required {
+		return &ValidationError{
+			Stage:       StageParameters,
+			Message:     fmt.Sprintf("missing required parameter '%s'", param.Name),
+			SpecPointer: v.parameterPointer(req, param),
+		}
+	}
+
+	if present && param.Deprecated {
+		deprecate(req, param.In, param.Name)
+	}
+
+	// Parameters may declare the media type of their value instead of a schema
+	if str, ok := value.(string); ok && present && param.Schema == nil && len(param.Content) > 0 {
+		return v.validateParameterContent(req, param, str)
+	}
+
+	if present && param.Schema != nil {
+		if err := v.validateRequestSchema(req, param.In, value, param.Schema, param.Name); err != nil {
+			failure := ValidationError{Stage: StageParameters, Message: fmt.Sprintf("invalid type for parameter '%s'", param.Name)}
+			return schemaFailures(failure, v.parameterPointer(req, param)+"/schema", err)
+		}
+	}
+
+	return nil
+}
+
+// parameterValue returns the value of a parameter in the request and whether it is present, decoded by the style
+// of its location
+func (v *DefaultValidator) parameterValue(req *oas.OASRequest, param *oas.Parameter) (interface{}, bool) {
+	switch param.In {
+	case "query":
+		return v.queryParameter(req.Request.URL.Query(), param)
+	case "header":
+		return v.simpleParameter(req.Request.Header.Get(param.Name), param)
+	case "path":
+		return v.simpleParameter(extractPathParam(req.Request.URL.Path, req.Route, param.Name), param)
+	case "cookie":
+		return v.cookieParameter(req.Request.Cookies(), param)
+	}
+	return nil, false
+}
+
+// validateParameterContent decodes the value of a parameter declaring its media type with `content`, e.g. JSON,
+// with the decoder of the media type, then validates it against the schema of the media type
+func (v *DefaultValidator) validateParameterContent(req *oas.OASRequest, param *oas.Parameter, value string) error {
+	// The content of a parameter declares a single media type
+	key := slices.Min(slices.Collect(maps.Keys(param.Content)))
+	mediaType := param.Content[key]
+	pointer := v.parameterPointer(req, param) + "/content/" + helpers.EscapeJSONPointer(key)
+
+	decoded, err := v.decoder(key)(strings.NewReader(value))
+	if err != nil {
+		return &ValidationError{
+			Stage:       StageParameters,
+			Message:     fmt.Sprintf("invalid %s value for parameter '%s'", key, param.Name),
+			Err:         err,
+			SpecPointer: pointer,
+		}
+	}
+	if mediaType.Schema == nil {
+		return nil
+	}
+
+	if err := v.validateRequestSchema(req, param.In, decoded, mediaType.Schema, param.Name); err != nil {
+		failure := ValidationError{Stage: StageParameters, Message: fmt.Sprintf("invalid type for parameter '%s'", param.Name)}
+		return schemaFailures(failure, pointer+"/schema", err)
+	}
+	return nil
+}
+
+// queryParameter returns the value of a query parameter and whether it is present, decoding the arrays and objects
+// serialized by its style
+func (v *DefaultValidator) queryParameter(query url.Values, param *oas.Parameter) (interface{}, bool) {
+	switch style := param.SerializationStyle(); {
+	case style == "deepObject":
+		return deepObjectParameter(query, param.Name)
+	case arraySeparators[style] != "" && v.schemaHasType(param.Schema, "array"):
+		return arrayParameter(query, param, arraySeparators[style])
+	default:
+		value := query.Get(param.Name)
+		return value, value != ""
+	}
+}
+
+// simpleParameter returns the value of a header or path parameter and whether it is present, decoding the arrays
+// and objects serialized with the `simple` style: array items are separated by commas, `1,2,3`, and so are object
+// properties and their values, `key,value,key2,value2`, or `key=value,key2=value2` when exploded
+func (v *DefaultValidator) simpleParameter(value string, param *oas.Parameter) (interface{}, bool) {
+	if value == "" || param.SerializationStyle() != "simple" {
+		return value, value != ""
+	}
+
+	switch items := strings.Split(value, ","); {
+	case v.schemaHasType(param.Schema, "array"):
+		return arrayItems(items), true
+	case v.schemaHasType(param.Schema, "object"):
+		return simpleObject(items, param.Exploded()), true
+	default:
+		return value, true
+	}
+}
+
+// cookieParameter returns the value of a cookie parameter. Cookies repeating the name are the items of exploded
+// arrays, `ids=1; ids=2`, and only the first one is read otherwise, which is the one with the most specific path for
+// cookies sent by browsers. Arrays and objects which are not exploded are separated by commas, `ids=1,2` and
+// `point=x,1,y,2`.
+func (v *DefaultValidator) cookieParameter(cookies []*http.Cookie, param *oas.Parameter) (interface{}, bool) {
+	var values []string
+	for _, cookie := range cookies {
+		if cookie.Name == param.Name {
+			values = append(values, cookie.Value)
+		}
+	}
+	if len(values) == 0 || len(values) == 1 && values[0] == "" {
+		return nil, false
+	}
+
+	isArray := v.schemaHasType(param.Schema, "array")
+	switch {
+	case isArray && param.Exploded():
+		return arrayItems(values), true
+	case isArray:
+		return arrayItems(strings.Split(values[0], ",")), true
+	case !param.Exploded() && v.schemaHasType(param.Schema, "object"):
+		return simpleObject(strings.Split(values[0], ","), false), true
+	default:
+		return values[0], true
+	}
+}
+
+// simpleObject returns the object of the properties of a `simple` style object, or the items themselves, failing
+// validation as an object, when they are not properties
+func simpleObject(items []string, exploded bool) interface{} {
+	object := make(map[string]interface{}, len(items))
+	if exploded {
+		for _, item := range items {
+			key, value, ok := strings.Cut(item, "=")
+			if !ok {
+				return arrayItems(items)
+			}
+			object[key] = value
+		}
+		return object
+	}
+
+	if len(items)%2 != 0 {
+		return arrayItems(items)
+	}
+	for i := 0; i < len(items); i += 2 {
+		object[items[i]] = items[i+1]
+	}
+	return object
+}
+
+// arraySeparators are the separators of the array items of query parameter styles, when not exploded
+var arraySeparators = map[string]string{
+	"form":           ",",
+	"pipeDelimited":  "|",
+	"spaceDelimited": " ",
+}
+
+// arrayParameter returns the items of an array, which repeat the parameter when exploded, `?ids=1&ids=2`, and are
+// separated by the separator of the style otherwise, e.g. `?ids=1,2` for `form` or `?ids=1|2` for `pipeDelimited`
+func arrayParameter(query url.Values, param *oas.Parameter, separator string) (interface{}, bool) {
+	values := query[param.Name]
+	if len(values) == 0 || len(values) == 1 && values[0] == "" {
+		return nil, false
+	}
+	if !param.Exploded() {
+		values = strings.Split(values[0], separator)
+	}
+	return arrayItems(values), true
+}
+
+// deepObjectParameter returns the object of a `deepObject` style parameter, whose properties are serialized as
+// separate parameters, `?filter[name]=foo&filter[age]=3`. Nested brackets, `?filter[owner][name]=bar`, serialize
+// nested objects, and repeated properties arrays.
+func deepObjectParameter(query url.Values, name string) (interface{}, bool) {
+	object := make(map[string]interface{})
+	for key, values := range query {
+		property, ok := strings.CutPrefix(key, name+"[")
+		if !ok || !strings.HasSuffix(property, "]") {
+			continue
+		}
+		tokens := strings.Split(strings.TrimSuffix(property, "]"), "][")
+
+		// Walk down to the object of the property, ignoring properties conflicting with a value
+		parent := object
+		for _, token := range tokens[:len(tokens)-1] {
+			child, exists := parent[token]
+			if !exists {
+				child = make(map[string]interface{})
+				parent[token] = child
+			}
+			if parent, ok = child.(map[string]interface{}); !ok {
+				break
+			}
+		}
+		if !ok {
+			continue
+		}
+
+		if len(values) == 1 {
+			parent[tokens[len(tokens)-1]] = values[0]
+		} else {
+			parent[tokens[len(tokens)-1]] = arrayItems(values)
+		}
+	}
+	return object, len(object) > 0
+}
+
+// arrayItems returns the string values of a parameter as the items of an array value
+func arrayItems(values []string) []interface{} {
+	items := make([]interface{}, len(values))
+	for i, value := range values {
+		items[i] = value
+	}
+	return items
+}
+
+// schemaHasType reports whether a schema, possibly a reference, declares the type
+func (v *DefaultValidator) schemaHasType(schema *oas.Schema, t string) bool {
+	if schema != nil && schema.Ref != "" {
+		resolved, err := v.resolveSchemaReference(schema.Ref)
+		if err != nil {
+			return false
+		}
+		schema = resolved
+	}
+	return schema != nil && schema.HasType(t)
+}
+
+// resolveParameters returns the parameters with their references resolved
+func (v *DefaultValidator) resolveParameters(params []oas.Parameter) ([]oas.Parameter, error) {
+	resolved := make([]oas.Parameter, 0, len(params))
+	for i := range params {
+		param, err := v.apiSpec.ResolveParameter(&params[i])
+		if err != nil {
+			return nil, err
+		}
+		resolved = append(resolved, *param)
+	}
+	return resolved, nil
+}
+
 func mergeParameters(pathParams, opParams []oas.Parameter) []oas.Parameter {
 	paramMap := make(map[string]oas.Parameter)
 
@@ -78,14 +382,27 @@ func mergeParameters(pathParams, opParams []oas.Parameter) []oas.Parameter {
 		paramMap[key] = param // Operation-level param overrides path-level
 	}
 
-	// Convert map back to slice
+	// Convert map back to slice, in a stable order so the reported failures are too
 	mergedParams := make([]oas.Parameter, 0, len(paramMap))
-	for _, param := range paramMap {
-		mergedParams = append(mergedParams, param)
+	for _, key := range slices.Sorted(maps.Keys(paramMap)) {
+		mergedParams = append(mergedParams, paramMap[key])
 	}
 	return mergedParams
 }
 
+// PathParams returns the values of the path parameters of a request, by name, once its route is resolved
+func PathParams(req *oas.OASRequest) map[string]string {
+	params := make(map[string]string)
+	routeParts := strings.Split(req.Route, "/")
+	pathParts := strings.Split(req.Request.URL.Path, "/")
+	for i, part := range routeParts {
+		if strings.HasPrefix(part, "{") && strings.HasSuffix(part, "}") && i < len(pathParts) {
+			params[part[1:len(part)-1]] = pathParts[i]
+		}
+	}
+	return params
+}
+
 // extractPathParam extracts the value of a path parameter from the request path
 func extractPathParam(requestPath string, route string, paramName string) string {
 	routeParts := strings.Split(route, "/")
@@ -2,6 +2,7 @@ package validation
 
 import (
 	"net/http"
+	"net/url"
 	"testing"
 
 	"github.com/lionelgarnier/validate-api-request/oas"
@@ -183,3 +184,599 @@ func TestValidateParameters(t *testing.T) {
 		})
 	}
 }
+
+func TestValidateHeaderFamilies(t *testing.T) {
+	manager := oas.NewOASManager(nil, oas.FixedSelector(map[string]string{"test": "test"}))
+
+	content := []byte(`{
+        "openapi": "3.0.0",
+        "info": {
+            "title": "Test API",
+            "version": "1.0.0"
+        },
+        "paths": {
+            "/meta": {
+                "get": {
+                    "parameters": [
+                        {
+                            "name": "X-Meta-*",
+                            "in": "header",
+                            "required": true,
+                            "schema": {
+                                "type": "string",
+                                "maxLength": 5
+                            }
+                        }
+                    ]
+                }
+            },
+            "/trace": {
+                "get": {
+                    "parameters": [
+                        {
+                            "name": "trace",
+                            "in": "header",
+                            "x-header-pattern": "^X-Trace-[0-9]+$",
+                            "schema": {
+                                "type": "integer"
+                            }
+                        }
+                    ]
+                }
+            },
+            "/labels": {
+                "get": {
+                    "parameters": [
+                        {
+                            "name": "labels",
+                            "in": "header",
+                            "schema": {
+                                "type": "object",
+                                "patternProperties": {
+                                    "^X-Label-": {
+                                        "type": "string",
+                                        "enum": ["red", "green"]
+                                    },
+                                    "^x-tag-": {
+                                        "type": "string",
+                                        "enum": ["prod", "staging"]
+                                    }
+                                },
+                                "additionalProperties": false
+                            }
+                        }
+                    ]
+                }
+            }
+        }
+    }`)
+
+	err := manager.LoadAPI("test", content)
+	assert.NoError(t, err)
+	spec, _ := manager.GetApiSpec("test")
+	validator := NewValidator(spec)
+
+	tests := []struct {
+		name          string
+		path          string
+		headers       map[string]string
+		values        map[string][]string // Headers repeated with several values
+		expectedError string
+	}{
+		{
+			name:    "Valid header family",
+			path:    "/meta",
+			headers: map[string]string{"X-Meta-Owner": "bob", "x-meta-team": "core"},
+		},
+		{
+			name:          "Missing required header family",
+			path:          "/meta",
+			headers:       map[string]string{"X-Other": "value"},
+			expectedError: "missing required parameter 'X-Meta-*'",
+		},
+		{
+			name:          "Invalid header family value",
+			path:          "/meta",
+			headers:       map[string]string{"X-Meta-Owner": "too long"},
+			expectedError: "invalid type for parameter 'X-Meta-Owner'",
+		},
+		{
+			name:    "Valid header pattern extension",
+			path:    "/trace",
+			headers: map[string]string{"X-Trace-1": "42", "X-Trace-Id": "abc"},
+		},
+		{
+			name:          "Invalid header pattern extension value",
+			path:          "/trace",
+			headers:       map[string]string{"X-Trace-2": "abc"},
+			expectedError: "invalid type for parameter 'X-Trace-2'",
+		},
+		{
+			name:    "Valid pattern properties headers",
+			path:    "/labels",
+			headers: map[string]string{"X-Label-Color": "red"},
+		},
+		{
+			name:          "Invalid pattern properties headers",
+			path:          "/labels",
+			headers:       map[string]string{"X-Label-Color": "blue"},
+			expectedError: "X-Label-Color",
+		},
+		{
+			name:    "Lowercase pattern properties headers",
+			path:    "/labels",
+			headers: map[string]string{"X-Tag-Env": "prod"},
+		},
+		{
+			name:          "Invalid lowercase pattern properties headers",
+			path:          "/labels",
+			headers:       map[string]string{"X-Tag-Env": "dev"},
+			expectedError: "X-Tag-Env",
+		},
+		{
+			name:   "Repeated pattern properties headers",
+			path:   "/labels",
+			values: map[string][]string{"X-Label-Color": {"red", "green"}},
+		},
+		{
+			name:          "Invalid repeated pattern properties headers",
+			path:          "/labels",
+			values:        map[string][]string{"X-Label-Color": {"red", "blue"}},
+			expectedError: "X-Label-Color",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequest(http.MethodGet, tt.path, nil)
+			assert.NoError(t, err)
+			for name, value := range tt.headers {
+				req.Header.Set(name, value)
+			}
+			for name, values := range tt.values {
+				for _, value := range values {
+					req.Header.Add(name, value)
+				}
+			}
+
+			ok, err := validator.ValidateParameters(oas.NewOASRequest(req))
+			if tt.expectedError == "" {
+				assert.True(t, ok)
+				assert.NoError(t, err)
+			} else {
+				assert.False(t, ok)
+				assert.Error(t, err)
+				assert.Contains(t, err.Error(), tt.expectedError)
+			}
+		})
+	}
+}
+
+func TestValidateArrayParameters(t *testing.T) {
+	manager := oas.NewOASManager(nil, oas.FixedSelector(map[string]string{"test": "test"}))
+
+	content := []byte(`{
+        "openapi": "3.0.0",
+        "info": {
+            "title": "Test API",
+            "version": "1.0.0"
+        },
+        "paths": {
+            "/pets": {
+                "get": {
+                    "parameters": [
+                        {
+                            "name": "ids",
+                            "in": "query",
+                            "required": true,
+                            "schema": {"$ref": "#/components/schemas/Ids"}
+                        }
+                    ]
+                }
+            },
+            "/owners": {
+                "get": {
+                    "parameters": [
+                        {
+                            "name": "ids",
+                            "in": "query",
+                            "explode": false,
+                            "schema": {"type": "array", "items": {"type": "integer"}}
+                        }
+                    ]
+                }
+            },
+            "/tags": {
+                "get": {
+                    "parameters": [
+                        {
+                            "name": "tags",
+                            "in": "query",
+                            "style": "pipeDelimited",
+                            "schema": {"type": "array", "items": {"type": "string", "enum": ["cute", "small"]}}
+                        },
+                        {
+                            "name": "sizes",
+                            "in": "query",
+                            "style": "spaceDelimited",
+                            "schema": {"type": "array", "items": {"type": "integer"}}
+                        }
+                    ]
+                }
+            }
+        },
+        "components": {
+            "schemas": {
+                "Ids": {"type": "array", "items": {"type": "integer"}, "maxItems": 3}
+            }
+        }
+    }`)
+
+	err := manager.LoadAPI("test", content)
+	assert.NoError(t, err)
+	spec, _ := manager.GetApiSpec("test")
+	validator := NewValidator(spec)
+
+	tests := []struct {
+		name          string
+		path          string
+		expectedError string
+	}{
+		{name: "Repeated parameter", path: "/pets?ids=1&ids=2"},
+		{name: "Single item", path: "/pets?ids=1"},
+		{name: "Invalid item", path: "/pets?ids=1&ids=abc", expectedError: "invalid type for parameter 'ids'"},
+		{name: "Too many items", path: "/pets?ids=1&ids=2&ids=3&ids=4", expectedError: "at most 3 items"},
+		{name: "Missing required array", path: "/pets", expectedError: "missing required parameter 'ids'"},
+		{name: "Empty required array", path: "/pets?ids=", expectedError: "missing required parameter 'ids'"},
+		{name: "Comma separated items", path: "/owners?ids=1,2"},
+		{name: "Invalid comma separated item", path: "/owners?ids=1,abc", expectedError: "invalid type for parameter 'ids'"},
+		{name: "Missing optional array", path: "/owners"},
+		{name: "Pipe delimited items", path: "/tags?tags=cute|small"},
+		{name: "Invalid pipe delimited item", path: "/tags?tags=cute|big", expectedError: "invalid type for parameter 'tags'"},
+		{name: "Space delimited items", path: "/tags?sizes=1%202+3"},
+		{name: "Invalid space delimited item", path: "/tags?sizes=1%20big", expectedError: "invalid type for parameter 'sizes'"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequest(http.MethodGet, tt.path, nil)
+			assert.NoError(t, err)
+
+			ok, err := validator.ValidateParameters(oas.NewOASRequest(req))
+			if tt.expectedError == "" {
+				assert.True(t, ok)
+				assert.NoError(t, err)
+			} else {
+				assert.False(t, ok)
+				assert.Error(t, err)
+				assert.Contains(t, err.Error(), tt.expectedError)
+			}
+		})
+	}
+}
+
+func TestValidateDeepObjectParameters(t *testing.T) {
+	manager := oas.NewOASManager(nil, oas.FixedSelector(map[string]string{"test": "test"}))
+
+	content := []byte(`{
+        "openapi": "3.0.0",
+        "info": {
+            "title": "Test API",
+            "version": "1.0.0"
+        },
+        "paths": {
+            "/pets": {
+                "get": {
+                    "parameters": [
+                        {
+                            "name": "filter",
+                            "in": "query",
+                            "style": "deepObject",
+                            "explode": true,
+                            "schema": {
+                                "type": "object",
+                                "additionalProperties": false,
+                                "properties": {
+                                    "name": {"type": "string"},
+                                    "age": {"type": "integer"},
+                                    "tags": {"type": "array", "items": {"type": "string"}},
+                                    "owner": {"type": "object", "required": ["name"], "properties": {"name": {"type": "string"}}}
+                                }
+                            }
+                        }
+                    ]
+                }
+            },
+            "/owners": {
+                "get": {
+                    "parameters": [
+                        {
+                            "name": "filter",
+                            "in": "query",
+                            "style": "deepObject",
+                            "required": true,
+                            "schema": {"type": "object", "required": ["name"]}
+                        }
+                    ]
+                }
+            }
+        }
+    }`)
+
+	err := manager.LoadAPI("test", content)
+	assert.NoError(t, err)
+	spec, _ := manager.GetApiSpec("test")
+	validator := NewValidator(spec)
+
+	tests := []struct {
+		name          string
+		path          string
+		expectedError string
+	}{
+		{name: "Valid object", path: "/pets?filter[name]=foo&filter[age]=3"},
+		{name: "Repeated property", path: "/pets?filter[tags]=cute&filter[tags]=small"},
+		{name: "Nested object", path: "/pets?filter[owner][name]=bar"},
+		{name: "Invalid property", path: "/pets?filter[age]=old", expectedError: "invalid type for parameter 'filter'"},
+		{name: "Undeclared property", path: "/pets?filter[color]=red", expectedError: "invalid type for parameter 'filter'"},
+		{name: "Invalid nested object", path: "/pets?filter[owner][age]=3", expectedError: "invalid type for parameter 'filter'"},
+		{name: "Unrelated parameters", path: "/pets?filters[age]=old&filter=old"},
+		{name: "Missing required object", path: "/owners", expectedError: "missing required parameter 'filter'"},
+		{name: "Missing required property", path: "/owners?filter[age]=3", expectedError: "invalid type for parameter 'filter'"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequest(http.MethodGet, tt.path, nil)
+			assert.NoError(t, err)
+
+			ok, err := validator.ValidateParameters(oas.NewOASRequest(req))
+			if tt.expectedError == "" {
+				assert.True(t, ok)
+				assert.NoError(t, err)
+			} else {
+				assert.False(t, ok)
+				assert.Error(t, err)
+				assert.Contains(t, err.Error(), tt.expectedError)
+			}
+		})
+	}
+}
+
+func TestValidateSimpleParameters(t *testing.T) {
+	manager := oas.NewOASManager(nil, oas.FixedSelector(map[string]string{"test": "test"}))
+
+	content := []byte(`{
+        "openapi": "3.0.0",
+        "info": {
+            "title": "Test API",
+            "version": "1.0.0"
+        },
+        "paths": {
+            "/pets/{ids}": {
+                "get": {
+                    "parameters": [
+                        {
+                            "name": "ids",
+                            "in": "path",
+                            "required": true,
+                            "schema": {"type": "array", "items": {"type": "integer"}}
+                        },
+                        {
+                            "name": "X-Ids",
+                            "in": "header",
+                            "schema": {"type": "array", "items": {"type": "integer"}, "maxItems": 3}
+                        },
+                        {
+                            "name": "X-Filter",
+                            "in": "header",
+                            "schema": {"type": "object", "properties": {"age": {"type": "integer"}}, "additionalProperties": {"type": "string"}}
+                        },
+                        {
+                            "name": "X-Exploded-Filter",
+                            "in": "header",
+                            "explode": true,
+                            "schema": {"type": "object", "properties": {"age": {"type": "integer"}}}
+                        }
+                    ]
+                }
+            }
+        }
+    }`)
+
+	err := manager.LoadAPI("test", content)
+	assert.NoError(t, err)
+	spec, _ := manager.GetApiSpec("test")
+	validator := NewValidator(spec)
+
+	tests := []struct {
+		name          string
+		path          string
+		headers       map[string]string
+		expectedError string
+	}{
+		{name: "Path array", path: "/pets/1,2,3"},
+		{name: "Invalid path array item", path: "/pets/1,two", expectedError: "invalid type for parameter 'ids'"},
+		{name: "Header array", path: "/pets/1", headers: map[string]string{"X-Ids": "1,2,3"}},
+		{name: "Invalid header array item", path: "/pets/1", headers: map[string]string{"X-Ids": "1,two"}, expectedError: "invalid type for parameter 'X-Ids'"},
+		{name: "Too many header array items", path: "/pets/1", headers: map[string]string{"X-Ids": "1,2,3,4"}, expectedError: "invalid type for parameter 'X-Ids'"},
+		{name: "Header object", path: "/pets/1", headers: map[string]string{"X-Filter": "age,3,name,foo"}},
+		{name: "Invalid header object property", path: "/pets/1", headers: map[string]string{"X-Filter": "age,old"}, expectedError: "invalid type for parameter 'X-Filter'"},
+		{name: "Malformed header object", path: "/pets/1", headers: map[string]string{"X-Filter": "age,3,name"}, expectedError: "invalid type for parameter 'X-Filter'"},
+		{name: "Exploded header object", path: "/pets/1", headers: map[string]string{"X-Exploded-Filter": "age=3"}},
+		{name: "Invalid exploded header object property", path: "/pets/1", headers: map[string]string{"X-Exploded-Filter": "age=old"}, expectedError: "invalid type for parameter 'X-Exploded-Filter'"},
+		{name: "Malformed exploded header object", path: "/pets/1", headers: map[string]string{"X-Exploded-Filter": "age,3"}, expectedError: "invalid type for parameter 'X-Exploded-Filter'"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequest(http.MethodGet, tt.path, nil)
+			assert.NoError(t, err)
+			for k, v := range tt.headers {
+				req.Header.Set(k, v)
+			}
+
+			ok, err := validator.ValidateParameters(oas.NewOASRequest(req))
+			if tt.expectedError == "" {
+				assert.True(t, ok)
+				assert.NoError(t, err)
+			} else {
+				assert.False(t, ok)
+				assert.Error(t, err)
+				assert.Contains(t, err.Error(), tt.expectedError)
+			}
+		})
+	}
+}
+
+func TestValidateCookieParameters(t *testing.T) {
+	manager := oas.NewOASManager(nil, oas.FixedSelector(map[string]string{"test": "test"}))
+
+	content := []byte(`{
+        "openapi": "3.0.0",
+        "info": {
+            "title": "Test API",
+            "version": "1.0.0"
+        },
+        "paths": {
+            "/pets": {
+                "get": {
+                    "parameters": [
+                        {"name": "session", "in": "cookie", "schema": {"type": "string", "minLength": 3}},
+                        {"name": "ids", "in": "cookie", "explode": false, "schema": {"type": "array", "items": {"type": "integer"}}},
+                        {"name": "tags", "in": "cookie", "schema": {"type": "array", "items": {"type": "string"}, "maxItems": 2}},
+                        {"name": "point", "in": "cookie", "explode": false, "schema": {"type": "object", "properties": {"x": {"type": "integer"}, "y": {"type": "integer"}}}},
+                        {"name": "theme", "in": "cookie", "required": true, "schema": {"type": "string"}}
+                    ]
+                }
+            }
+        }
+    }`)
+
+	err := manager.LoadAPI("test", content)
+	assert.NoError(t, err)
+	spec, _ := manager.GetApiSpec("test")
+	validator := NewValidator(spec)
+
+	tests := []struct {
+		name          string
+		cookie        string
+		expectedError string
+	}{
+		{name: "Only required cookie", cookie: "theme=dark"},
+		{name: "Missing required cookie", cookie: "session=abcd", expectedError: "missing required parameter 'theme'"},
+		{name: "Array", cookie: "theme=dark; ids=1,2,3"},
+		{name: "Invalid array item", cookie: "theme=dark; ids=1,two", expectedError: "invalid type for parameter 'ids'"},
+		{name: "Exploded array", cookie: "theme=dark; tags=a; tags=b"},
+		{name: "Too many exploded array items", cookie: "theme=dark; tags=a; tags=b; tags=c", expectedError: "invalid type for parameter 'tags'"},
+		{name: "Object", cookie: "theme=dark; point=x,1,y,2"},
+		{name: "Invalid object property", cookie: "theme=dark; point=x,one", expectedError: "invalid type for parameter 'point'"},
+		{name: "Malformed object", cookie: "theme=dark; point=x,1,y", expectedError: "invalid type for parameter 'point'"},
+		{name: "First repeated cookie read", cookie: "theme=dark; session=abcd; session=a"},
+		{name: "First repeated cookie invalid", cookie: "theme=dark; session=a; session=abcd", expectedError: "invalid type for parameter 'session'"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequest(http.MethodGet, "/pets", nil)
+			assert.NoError(t, err)
+			req.Header.Set("Cookie", tt.cookie)
+
+			ok, err := validator.ValidateParameters(oas.NewOASRequest(req))
+			if tt.expectedError == "" {
+				assert.True(t, ok)
+				assert.NoError(t, err)
+			} else {
+				assert.False(t, ok)
+				assert.Error(t, err)
+				assert.Contains(t, err.Error(), tt.expectedError)
+			}
+		})
+	}
+}
+
+func TestValidateContentParameters(t *testing.T) {
+	manager := oas.NewOASManager(nil, oas.FixedSelector(map[string]string{"test": "test"}))
+
+	content := []byte(`{
+        "openapi": "3.0.0",
+        "info": {
+            "title": "Test API",
+            "version": "1.0.0"
+        },
+        "paths": {
+            "/pets": {
+                "get": {
+                    "parameters": [
+                        {
+                            "name": "filter",
+                            "in": "query",
+                            "required": true,
+                            "content": {
+                                "application/json": {
+                                    "schema": {"type": "object", "required": ["age"], "properties": {"age": {"type": "integer"}}}
+                                }
+                            }
+                        },
+                        {
+                            "name": "X-Tags",
+                            "in": "header",
+                            "content": {
+                                "application/json": {
+                                    "schema": {"type": "array", "items": {"type": "string"}}
+                                }
+                            }
+                        }
+                    ]
+                }
+            }
+        }
+    }`)
+
+	err := manager.LoadAPI("test", content)
+	assert.NoError(t, err)
+	spec, _ := manager.GetApiSpec("test")
+	validator := NewValidator(spec)
+
+	tests := []struct {
+		name          string
+		filter        string
+		headers       map[string]string
+		expectedError string
+		expectedSpec  string
+	}{
+		{name: "Valid JSON parameter", filter: `{"age": 3}`},
+		{name: "Valid JSON header", filter: `{"age": 3}`, headers: map[string]string{"X-Tags": `["cute", "small"]`}},
+		{name: "Missing required parameter", expectedError: "missing required parameter 'filter'"},
+		{name: "Malformed JSON", filter: `{"age": `, expectedError: "invalid application/json value for parameter 'filter'", expectedSpec: "#/paths/~1pets/get/parameters/0/content/application~1json"},
+		{name: "Invalid JSON value", filter: `{"age": "old"}`, expectedError: "invalid type for parameter 'filter'", expectedSpec: "#/paths/~1pets/get/parameters/0/content/application~1json/schema/properties/age"},
+		{name: "Invalid JSON header", filter: `{"age": 3}`, headers: map[string]string{"X-Tags": `[1]`}, expectedError: "invalid type for parameter 'X-Tags'"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequest(http.MethodGet, "/pets", nil)
+			assert.NoError(t, err)
+			if tt.filter != "" {
+				req.URL.RawQuery = url.Values{"filter": {tt.filter}}.Encode()
+			}
+			for k, v := range tt.headers {
+				req.Header.Set(k, v)
+			}
+
+			ok, err := validator.ValidateParameters(oas.NewOASRequest(req))
+			if tt.expectedError == "" {
+				assert.True(t, ok)
+				assert.NoError(t, err)
+				return
+			}
+			assert.False(t, ok)
+			assert.Error(t, err)
+			assert.Contains(t, err.Error(), tt.expectedError)
+			if tt.expectedSpec != "" {
+				violations := Violations(err)
+				assert.Len(t, violations, 1)
+				assert.Equal(t, tt.expectedSpec, violations[0].Spec)
+			}
+		})
+	}
+}
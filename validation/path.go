@@ -4,7 +4,6 @@ import (
 	"fmt"
 	"net/http"
 	"strings"
-	"time"
 
 	"github.com/lionelgarnier/validate-api-request/oas"
 )
@@ -38,13 +37,15 @@ func (v *DefaultValidator) ResolveRequestPath(req *oas.OASRequest) (*oas.PathCac
 		return nil, fmt.Errorf("no schema found for path '%s'", path)
 	}
 
-	// Update cache stats
-	pathCache.HitCount++
-	pathCache.LastAccess = time.Now()
+	// Update cache stats, the path item is decoded again if it was evicted
+	pathItem, err := pathCache.Access(v.clock.Now())
+	if err != nil {
+		return nil, err
+	}
 
 	// Set route in request
 	req.Route = pathCache.Route
-	req.PathItem = pathCache.Item
+	req.PathItem = pathItem
 	return pathCache, nil
 
 }
@@ -53,7 +54,7 @@ func (v *DefaultValidator) ResolveRequestPath(req *oas.OASRequest) (*oas.PathCac
 func (v *DefaultValidator) ValidateRequestPath(req *oas.OASRequest) (bool, error) {
 	_, err := v.ResolveRequestPath(req)
 	if err != nil {
-		return false, err
+		return false, &ValidationError{Stage: StagePath, Message: err.Error(), SpecPointer: "#/paths"}
 	}
 	return true, nil
 }
@@ -74,14 +75,47 @@ func (v *DefaultValidator) ValidateRequestMethod(req *oas.OASRequest) (bool, err
 
 	operation := v.GetOperation(pathItem, method)
 	if operation == nil {
-		return false, fmt.Errorf("method '%s' not allowed for path '%s'", method, route)
+		return false, &ValidationError{
+			Stage:    StageMethod,
+			Category: CategoryMethodNotAllowed,
+			Message:  fmt.Sprintf("method '%s' not allowed for path '%s'", method, route),
+			Allow:    v.AllowedMethods(pathItem),
+			// The route is set by the path resolution
+			SpecPointer: pathPointer(req),
+		}
 	}
 
 	req.Operation = operation
+	now := v.clock.Now()
+	if v.enforceSunset && operation.SunsetAt(now) {
+		return false, &ValidationError{
+			Stage:       StageMethod,
+			Category:    CategorySunset,
+			Message:     fmt.Sprintf("operation '%s %s' was sunset on %s", method, route, operation.Lifecycle().Sunset.UTC().Format(http.TimeFormat)),
+			SpecPointer: operationPointer(req),
+		}
+	}
+	if operation.DeprecatedAt(now) {
+		deprecate(req, "operation", "")
+	}
 	return true, nil
 
 }
 
+// AllowedMethods returns the methods having an operation on the path
+func (v *DefaultValidator) AllowedMethods(pathItem *oas.PathItem) []string {
+	var methods []string
+	for _, method := range []string{
+		http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut,
+		http.MethodPatch, http.MethodDelete, http.MethodOptions, http.MethodTrace,
+	} {
+		if v.GetOperation(pathItem, method) != nil {
+			methods = append(methods, method)
+		}
+	}
+	return methods
+}
+
 // GetOperation returns the operation for a given route and method
 func (v *DefaultValidator) GetOperation(pathItem *oas.PathItem, method string) *oas.Operation {
 
@@ -0,0 +1,194 @@
+package validation
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"net/http"
+	"net/url"
+	"slices"
+
+	"github.com/lionelgarnier/validate-api-request/oas"
+)
+
+// parameterLocations are the locations a parameter may be sent in, the body standing for the fields of form and
+// JSON object bodies that backends merging locations read parameters from
+var parameterLocations = []string{"path", "query", "header", "cookie", "body"}
+
+// WithParameterPinning rejects the parameters sent in another location than the one they are declared in, e.g. a
+// query parameter also sent as a header or a body field, which backends merging locations may read instead, and the
+// parameters repeated with conflicting values, whose value depends on the parser of the backend
+func WithParameterPinning(enabled bool) Option {
+	return func(v *DefaultValidator) {
+		v.pinParameters = enabled
+	}
+}
+
+// parameterSources are the values sent by a request in each location, by parameter name
+type parameterSources struct {
+	req     *oas.OASRequest
+	body    map[string][]string // Fields of the body, decoded on first use
+	maxBody int64               // Size of the bodies decoded, larger ones have no fields
+}
+
+// values returns the values sent for a parameter name in a location
+func (s *parameterSources) values(location, name string) []string {
+	r := s.req.Request
+	switch location {
+	case "path":
+		if value, exists := PathParams(s.req)[name]; exists {
+			return []string{value}
+		}
+	case "query":
+		return r.URL.Query()[name]
+	case "header":
+		return r.Header.Values(name)
+	case "cookie":
+		var values []string
+		for _, cookie := range r.Cookies() {
+			if cookie.Name == name {
+				values = append(values, cookie.Value)
+			}
+		}
+		return values
+	case "body":
+		if s.body == nil {
+			s.body = bodyFields(r, s.maxBody)
+		}
+		return s.body[name]
+	}
+	return nil
+}
+
+// bodyFields returns the fields of a form body, or the top-level properties of a JSON object body, of up to limit
+// bytes, 0 for no limit
+func bodyFields(r *http.Request, limit int64) map[string][]string {
+	fields := make(map[string][]string)
+	mediaType, _ := parseMediaType(r.Header.Get("Content-Type"))
+	content, exceeded, err := bufferBodyUpTo(r, limit)
+	if err != nil || exceeded || len(content) == 0 {
+		return fields
+	}
+
+	switch {
+	case mediaType == "application/x-www-form-urlencoded":
+		if form, err := url.ParseQuery(string(content)); err == nil {
+			fields = form
+		}
+	case isJSONMediaType(mediaType):
+		var object map[string]interface{}
+		if json.Unmarshal(content, &object) == nil {
+			for name, value := range object {
+				fields[name] = []string{fmt.Sprint(value)}
+			}
+		}
+	}
+	return fields
+}
+
+// checkParameterSources reports the parameters sent in a location they are not declared in, the parameters sent
+// with conflicting values in several locations declaring them, e.g. a path parameter and a body property, and the
+// single-valued parameters repeated with conflicting values
+func (v *DefaultValidator) checkParameterSources(req *oas.OASRequest, parameters []oas.Parameter) ValidationErrors {
+	declared := make(map[string]bool, len(parameters))
+	for _, param := range parameters {
+		declared[parameterKey(param.In, param.Name)] = true
+	}
+	for _, name := range v.bodyProperties(req) {
+		declared[parameterKey("body", name)] = true
+	}
+
+	sources := &parameterSources{req: req, maxBody: v.maxBodyBuffer}
+	var errs ValidationErrors
+	for i := range parameters {
+		param := &parameters[i]
+		violation := func(format string, args ...interface{}) {
+			errs.add(&ValidationError{
+				Stage:       StageParameters,
+				Message:     fmt.Sprintf(format, args...),
+				SpecPointer: v.parameterPointer(req, param),
+			})
+		}
+
+		values := sources.values(param.In, param.Name)
+		if conflicting(values) && !v.multiValued(param) {
+			violation("parameter '%s' is repeated with conflicting values", param.Name)
+		}
+		for _, location := range parameterLocations {
+			if location == param.In {
+				continue
+			}
+			others := sources.values(location, param.Name)
+			switch {
+			case len(others) == 0:
+			case !declared[parameterKey(location, param.Name)]:
+				violation("parameter '%s' is declared in %s but also sent in %s", param.Name, param.In, location)
+			case len(values) > 0 && conflicting(append(values[:1:1], others...)) && reportsConflict(param.In, location):
+				violation("parameter '%s' has conflicting values in %s and %s", param.Name, param.In, location)
+			}
+		}
+	}
+	return errs
+}
+
+// reportsConflict reports whether the conflict of a parameter declared in two locations is reported by the
+// parameter of the first location, so that it is reported once
+func reportsConflict(location, other string) bool {
+	return other == "body" || slices.Index(parameterLocations, location) < slices.Index(parameterLocations, other)
+}
+
+// bodyProperties returns the top-level properties declared by the schemas of the request body of the operation
+func (v *DefaultValidator) bodyProperties(req *oas.OASRequest) []string {
+	if req.Operation == nil || req.Operation.RequestBody == nil {
+		return nil
+	}
+	requestBody, err := v.apiSpec.ResolveRequestBody(req.Operation.RequestBody)
+	if err != nil {
+		return nil
+	}
+
+	var properties []string
+	for _, mediaType := range requestBody.Content {
+		schema := mediaType.Schema
+		if schema != nil && schema.Ref != "" {
+			if schema, err = v.resolveSchemaReference(schema.Ref); err != nil {
+				continue
+			}
+		}
+		if schema != nil {
+			properties = slices.AppendSeq(properties, maps.Keys(schema.Properties))
+		}
+	}
+	return properties
+}
+
+// multiValued reports whether a parameter is serialized as repeated values, exploded query and cookie arrays and
+// repeated header array lines
+func (v *DefaultValidator) multiValued(param *oas.Parameter) bool {
+	switch param.In {
+	case "query", "cookie":
+		return param.Exploded() && v.schemaHasType(param.Schema, "array")
+	case "header":
+		return v.schemaHasType(param.Schema, "array")
+	default:
+		return false
+	}
+}
+
+// parameterKey identifies a parameter by location and name, header names being case-insensitive
+func parameterKey(location, name string) string {
+	if location == "header" {
+		name = http.CanonicalHeaderKey(name)
+	}
+	return location + ":" + name
+}
+
+// conflicting reports whether values differ
+func conflicting(values []string) bool {
+	for _, value := range values {
+		if value != values[0] {
+			return true
+		}
+	}
+	return false
+}
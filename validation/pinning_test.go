@@ -0,0 +1,94 @@
+package validation
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/lionelgarnier/validate-api-request/oas"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParameterPinning(t *testing.T) {
+	manager := oas.NewOASManager(nil, oas.FixedSelector(map[string]string{"test": "test"}))
+	err := manager.LoadAPI("test", []byte(`{
+        "openapi": "3.0.0",
+        "info": {"title": "Test API", "version": "1.0.0"},
+        "paths": {
+            "/users/{id}": {
+                "put": {
+                    "parameters": [
+                        {"name": "id", "in": "path", "required": true, "schema": {"type": "integer"}},
+                        {"name": "role", "in": "query", "schema": {"type": "string"}},
+                        {"name": "tags", "in": "query", "schema": {"type": "array", "items": {"type": "string"}}},
+                        {"name": "X-Tenant", "in": "header", "schema": {"type": "string"}}
+                    ],
+                    "requestBody": {
+                        "content": {
+                            "application/json": {"schema": {"$ref": "#/components/schemas/User"}},
+                            "application/x-www-form-urlencoded": {"schema": {"$ref": "#/components/schemas/User"}}
+                        }
+                    }
+                }
+            }
+        },
+        "components": {
+            "schemas": {
+                "User": {"type": "object", "properties": {"id": {"type": "integer"}, "name": {"type": "string"}}}
+            }
+        }
+    }`))
+	assert.NoError(t, err)
+	spec, _ := manager.GetApiSpec("test")
+
+	tests := []struct {
+		name          string
+		path          string
+		headers       http.Header
+		cookie        string
+		contentType   string
+		body          string
+		pinning       bool
+		expectedError string
+	}{
+		{name: "Declared locations", path: "/users/1?role=admin&tags=a&tags=b", headers: http.Header{"X-Tenant": {"acme"}}, pinning: true},
+		{name: "Repeated with same value", path: "/users/1?role=admin&role=admin", pinning: true},
+		{name: "Repeated with conflicting values", path: "/users/1?role=user&role=admin", pinning: true, expectedError: "parameter 'role' is repeated with conflicting values"},
+		{name: "Repeated header with conflicting values", path: "/users/1", headers: http.Header{"X-Tenant": {"acme", "other"}}, pinning: true, expectedError: "parameter 'X-Tenant' is repeated with conflicting values"},
+		{name: "Query parameter sent as header", path: "/users/1", headers: http.Header{"Role": {"admin"}}, pinning: true, expectedError: "parameter 'role' is declared in query but also sent in header"},
+		{name: "Header parameter sent in query", path: "/users/1?X-Tenant=acme", pinning: true, expectedError: "parameter 'X-Tenant' is declared in header but also sent in query"},
+		{name: "Query parameter sent as cookie", path: "/users/1", cookie: "role=admin", pinning: true, expectedError: "parameter 'role' is declared in query but also sent in cookie"},
+		{name: "Query parameter sent in form body", path: "/users/1", contentType: "application/x-www-form-urlencoded", body: "name=bob&role=admin", pinning: true, expectedError: "parameter 'role' is declared in query but also sent in body"},
+		{name: "Query parameter sent in JSON body", path: "/users/1", contentType: "application/json", body: `{"role": "admin"}`, pinning: true, expectedError: "parameter 'role' is declared in query but also sent in body"},
+		{name: "Path parameter matching body property", path: "/users/1", contentType: "application/json", body: `{"id": 1, "name": "bob"}`, pinning: true},
+		{name: "Path parameter conflicting with body property", path: "/users/1", contentType: "application/json", body: `{"id": 2}`, pinning: true, expectedError: "parameter 'id' has conflicting values in path and body"},
+		{name: "Path parameter sent in query", path: "/users/1?id=2", pinning: true, expectedError: "parameter 'id' is declared in path but also sent in query"},
+		{name: "Disabled", path: "/users/1?role=user&role=admin&id=2", headers: http.Header{"Role": {"admin"}}, pinning: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequest(http.MethodPut, tt.path, strings.NewReader(tt.body))
+			assert.NoError(t, err)
+			for k, v := range tt.headers {
+				req.Header[k] = v
+			}
+			if tt.cookie != "" {
+				req.Header.Set("Cookie", tt.cookie)
+			}
+			if tt.contentType != "" {
+				req.Header.Set("Content-Type", tt.contentType)
+			}
+
+			ok, err := NewValidator(spec, WithParameterPinning(tt.pinning)).ValidateParameters(oas.NewOASRequest(req))
+			if tt.expectedError == "" {
+				assert.True(t, ok)
+				assert.NoError(t, err)
+			} else {
+				assert.False(t, ok)
+				assert.Error(t, err)
+				assert.Contains(t, err.Error(), tt.expectedError)
+			}
+		})
+	}
+}
@@ -0,0 +1,60 @@
+package validation
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+
+	"github.com/lionelgarnier/validate-api-request/oas"
+	"github.com/lionelgarnier/validate-api-request/pkg/helpers"
+)
+
+// pathPointer returns the pointer of the path item of a request in the spec
+func pathPointer(req *oas.OASRequest) string {
+	return "#/paths/" + helpers.EscapeJSONPointer(req.Route)
+}
+
+// operationPointer returns the pointer of the operation of a request in the spec
+func operationPointer(req *oas.OASRequest) string {
+	return pathPointer(req) + "/" + strings.ToLower(req.Request.Method)
+}
+
+// refPointer returns the pointer of a referenced object when ref is local, or the pointer of the object itself
+func refPointer(ref, pointer string) string {
+	if strings.HasPrefix(ref, "#/") {
+		return ref
+	}
+	return pointer
+}
+
+// schemaPointer returns the pointer of the schema failing validation, given the pointer of the validated schema
+func schemaPointer(base string, err error) string {
+	var schemaErr *SchemaError
+	if !errors.As(err, &schemaErr) || schemaErr.SchemaPointer == "" {
+		return base
+	}
+	if strings.HasPrefix(schemaErr.SchemaPointer, "#") {
+		return schemaErr.SchemaPointer
+	}
+	return base + schemaErr.SchemaPointer
+}
+
+// parameterPointer returns the pointer of a parameter declaration, on the operation or else on the path item
+func (v *DefaultValidator) parameterPointer(req *oas.OASRequest, param *oas.Parameter) string {
+	if pointer, found := v.findParameter(req.Operation.Parameters, param, operationPointer(req)); found {
+		return pointer
+	}
+	pointer, _ := v.findParameter(req.PathItem.Parameters, param, pathPointer(req))
+	return pointer
+}
+
+// findParameter returns the pointer of the parameter with the same location and name in a list of parameters
+func (v *DefaultValidator) findParameter(params []oas.Parameter, param *oas.Parameter, base string) (string, bool) {
+	for i := range params {
+		resolved, err := v.apiSpec.ResolveParameter(&params[i])
+		if err == nil && resolved.In == param.In && resolved.Name == param.Name {
+			return base + "/parameters/" + strconv.Itoa(i), true
+		}
+	}
+	return base + "/parameters", false
+}
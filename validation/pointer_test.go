@@ -0,0 +1,107 @@
+package validation
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/lionelgarnier/validate-api-request/oas"
+)
+
+func TestSpecPointers(t *testing.T) {
+	manager := oas.NewOASManager(nil, oas.FixedSelector(map[string]string{"test": "test"}))
+
+	content := []byte(`{
+		"openapi": "3.0.0",
+		"info": {"title": "Test API", "version": "1.0.0"},
+		"paths": {
+			"/pets/{petId}": {
+				"parameters": [{"name": "petId", "in": "path", "required": true, "schema": {"type": "integer"}}],
+				"post": {
+					"parameters": [
+						{"name": "dryRun", "in": "query", "schema": {"type": "boolean"}},
+						{"$ref": "#/components/parameters/Limit"}
+					],
+					"requestBody": {
+						"content": {
+							"application/json": {
+								"schema": {
+									"type": "object",
+									"properties": {
+										"owner": {"$ref": "#/components/schemas/Owner"},
+										"tags": {"type": "array", "items": {"type": "string", "maxLength": 3}}
+									}
+								}
+							}
+						}
+					}
+				}
+			}
+		},
+		"components": {
+			"parameters": {
+				"Limit": {"name": "limit", "in": "query", "required": true, "schema": {"type": "integer"}}
+			},
+			"schemas": {
+				"Owner": {"type": "object", "properties": {"email": {"type": "string", "format": "email"}}}
+			}
+		}
+	}`)
+	assert.NoError(t, manager.LoadAPI("test", content))
+
+	spec, _ := manager.GetApiSpec("test")
+	validator := NewValidator(spec)
+
+	tests := []struct {
+		name         string
+		method       string
+		path         string
+		body         string
+		specPointer  string
+		instancePath string
+	}{
+		{name: "Unknown path", method: http.MethodPost, path: "/owners", specPointer: "#/paths"},
+		{name: "Method not allowed", method: http.MethodGet, path: "/pets/1", specPointer: "#/paths/~1pets~1{petId}"},
+		{name: "Path item parameter", method: http.MethodPost, path: "/pets/one?limit=1", body: `{}`, specPointer: "#/paths/~1pets~1{petId}/parameters/0/schema", instancePath: "petId"},
+		{name: "Operation parameter", method: http.MethodPost, path: "/pets/1?limit=1&dryRun=maybe", body: `{}`, specPointer: "#/paths/~1pets~1{petId}/post/parameters/0/schema", instancePath: "dryRun"},
+		{name: "Referenced parameter", method: http.MethodPost, path: "/pets/1", body: `{}`, specPointer: "#/paths/~1pets~1{petId}/post/parameters/1"},
+		{
+			name:         "Body item",
+			method:       http.MethodPost,
+			path:         "/pets/1?limit=1",
+			body:         `{"tags": ["cute", "fluffy"]}`,
+			specPointer:  "#/paths/~1pets~1{petId}/post/requestBody/content/application~1json/schema/properties/tags/items",
+			instancePath: "tags[0]",
+		},
+		{
+			name:         "Referenced body schema",
+			method:       http.MethodPost,
+			path:         "/pets/1?limit=1",
+			body:         `{"owner": {"email": "nobody"}}`,
+			specPointer:  "#/components/schemas/Owner/properties/email",
+			instancePath: "owner.email",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequest(tt.method, tt.path, strings.NewReader(tt.body))
+			assert.NoError(t, err)
+			req.Header.Set("Content-Type", "application/json")
+
+			ok, err := validator.ValidateRequest(oas.NewOASRequest(req))
+			assert.False(t, ok)
+
+			var validationErr *ValidationError
+			assert.True(t, errors.As(err, &validationErr))
+			assert.Equal(t, tt.specPointer, validationErr.SpecPointer)
+			violations := Violations(err)
+			assert.Len(t, violations, 1)
+			assert.Equal(t, tt.instancePath, violations[0].Path)
+			assert.Equal(t, tt.specPointer, violations[0].Spec)
+		})
+	}
+}
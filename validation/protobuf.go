@@ -0,0 +1,324 @@
+package validation
+
+import (
+	"encoding/base64"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/lionelgarnier/validate-api-request/oas"
+)
+
+// ProtoMessageExtension is the media type extension naming the protobuf message of its bodies
+const ProtoMessageExtension = "x-proto-message"
+
+// ProtoType is the type of a protobuf field
+type ProtoType string
+
+const (
+	ProtoTypeDouble   ProtoType = "double"
+	ProtoTypeFloat    ProtoType = "float"
+	ProtoTypeInt32    ProtoType = "int32"
+	ProtoTypeInt64    ProtoType = "int64"
+	ProtoTypeUint32   ProtoType = "uint32"
+	ProtoTypeUint64   ProtoType = "uint64"
+	ProtoTypeSint32   ProtoType = "sint32"
+	ProtoTypeSint64   ProtoType = "sint64"
+	ProtoTypeFixed32  ProtoType = "fixed32"
+	ProtoTypeFixed64  ProtoType = "fixed64"
+	ProtoTypeSfixed32 ProtoType = "sfixed32"
+	ProtoTypeSfixed64 ProtoType = "sfixed64"
+	ProtoTypeBool     ProtoType = "bool"
+	ProtoTypeString   ProtoType = "string"
+	ProtoTypeBytes    ProtoType = "bytes"
+	ProtoTypeEnum     ProtoType = "enum"
+	ProtoTypeMessage  ProtoType = "message"
+)
+
+// ProtoMessage describes a protobuf message, to decode its binary encoding into the value validated by schemas
+type ProtoMessage struct {
+	Name   string             // Fully qualified name, e.g. `pets.v1.Pet`
+	Fields map[int]ProtoField // Fields by number
+}
+
+// ProtoField describes a field of a protobuf message
+type ProtoField struct {
+	Name     string         // Property name of the field in the decoded object
+	Type     ProtoType      // Scalar type, `enum` or `message`
+	Repeated bool           // Whether the field is decoded into an array
+	Message  string         // Fully qualified name of the message type of `message` fields
+	Values   map[int]string // Names of the values of `enum` fields, decoded as numbers without a name
+}
+
+// Protobuf wire types
+const (
+	wireVarint = 0
+	wireI64    = 1
+	wireLen    = 2
+	wireI32    = 5
+)
+
+// WithProtoMessages registers the descriptors of the protobuf messages named by `x-proto-message` media types,
+// and of the messages they nest
+func WithProtoMessages(messages ...ProtoMessage) Option {
+	return func(v *DefaultValidator) {
+		for i := range messages {
+			v.messages[messages[i].Name] = &messages[i]
+		}
+	}
+}
+
+// decodeBody decodes a body of a declared media type, and reports whether its value is validated against the schema.
+// Bodies of media types naming their protobuf message with `x-proto-message` are decoded with the registered
+// descriptor of the message. Without descriptor, they are only checked to be well-formed protobuf.
+func (v *DefaultValidator) decodeBody(body io.Reader, contentType string, mediaType oas.MediaType) (interface{}, bool, error) {
+	name, isProto := protoMessageName(mediaType)
+	if !isProto {
+		if mediaType.Schema == nil {
+			return nil, false, nil
+		}
+		// Multipart bodies need their boundary, unless a decoder is registered for them
+		if essence, _ := parseMediaType(contentType); essence == "multipart/form-data" && v.decoders[essence] == nil {
+			value, err := v.decodeMultipart(body, contentType, mediaType)
+			return value, err == nil, err
+		}
+		value, err := v.decoder(contentType)(body)
+		return value, err == nil, err
+	}
+
+	data, err := io.ReadAll(body)
+	if err != nil {
+		return nil, false, err
+	}
+	message, exists := v.messages[name]
+	if !exists {
+		return nil, false, checkProto(data)
+	}
+	value, err := v.decodeProto(message, data, 0)
+	return value, err == nil && mediaType.Schema != nil, err
+}
+
+// protoMessageName returns the protobuf message named by a media type
+func protoMessageName(mediaType oas.MediaType) (string, bool) {
+	value, exists := mediaType.Extension(ProtoMessageExtension)
+	if !exists {
+		return "", false
+	}
+	name, ok := value.(string)
+	return strings.TrimPrefix(name, "."), ok && name != ""
+}
+
+// protoRecord is a field record of an encoded protobuf message
+type protoRecord struct {
+	number   int
+	wireType int
+	value    uint64 // Value of varint and fixed size records
+	data     []byte // Value of length-delimited records
+}
+
+// readProtoRecord reads the record at the start of data and returns the remaining data.
+// Length-delimited records must fit in the remaining data.
+func readProtoRecord(data []byte) (protoRecord, []byte, error) {
+	tag, n := binary.Uvarint(data)
+	if n <= 0 {
+		return protoRecord{}, nil, fmt.Errorf("truncated field tag")
+	}
+	data = data[n:]
+
+	record := protoRecord{number: int(tag >> 3), wireType: int(tag & 7)}
+	if record.number <= 0 || tag>>3 > math.MaxInt32 {
+		return protoRecord{}, nil, fmt.Errorf("invalid field number %d", tag>>3)
+	}
+
+	switch record.wireType {
+	case wireVarint:
+		record.value, n = binary.Uvarint(data)
+		if n <= 0 {
+			return protoRecord{}, nil, fmt.Errorf("field %d: truncated varint", record.number)
+		}
+		return record, data[n:], nil
+	case wireI64:
+		if len(data) < 8 {
+			return protoRecord{}, nil, fmt.Errorf("field %d: truncated 64-bit value", record.number)
+		}
+		record.value = binary.LittleEndian.Uint64(data)
+		return record, data[8:], nil
+	case wireI32:
+		if len(data) < 4 {
+			return protoRecord{}, nil, fmt.Errorf("field %d: truncated 32-bit value", record.number)
+		}
+		record.value = uint64(binary.LittleEndian.Uint32(data))
+		return record, data[4:], nil
+	case wireLen:
+		length, n := binary.Uvarint(data)
+		if n <= 0 || length > uint64(len(data)-n) {
+			return protoRecord{}, nil, fmt.Errorf("field %d: length exceeds message size", record.number)
+		}
+		end := n + int(length)
+		record.data = data[n:end]
+		return record, data[end:], nil
+	default:
+		return protoRecord{}, nil, fmt.Errorf("field %d: unsupported wire type %d", record.number, record.wireType)
+	}
+}
+
+// checkProto checks that data is a well-formed protobuf message, without descriptor
+func checkProto(data []byte) error {
+	for len(data) > 0 {
+		var err error
+		if _, data, err = readProtoRecord(data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// decodeProto decodes an encoded protobuf message nested at the given depth into an object keyed by field name.
+// Unknown fields are skipped, absent fields are omitted and the last value of a singular field wins. Messages
+// nested beyond the maximum depth fail, so that crafted payloads of recursive messages cannot exhaust the stack.
+func (v *DefaultValidator) decodeProto(message *ProtoMessage, data []byte, depth int) (map[string]interface{}, error) {
+	if depth >= v.maxDepth {
+		return nil, fmt.Errorf("message nesting exceeds maximum depth %d", v.maxDepth)
+	}
+	object := make(map[string]interface{})
+	for len(data) > 0 {
+		record, rest, err := readProtoRecord(data)
+		if err != nil {
+			return nil, err
+		}
+		data = rest
+
+		field, known := message.Fields[record.number]
+		if !known {
+			continue
+		}
+
+		// Repeated scalars are packed into a single length-delimited record
+		if field.Repeated && record.wireType == wireLen && field.Type.packable() {
+			values, err := field.decodePacked(record.data)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", field.Name, err)
+			}
+			items, _ := object[field.Name].([]interface{})
+			object[field.Name] = append(items, values...)
+			continue
+		}
+
+		value, err := v.decodeProtoField(field, record, depth)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", field.Name, err)
+		}
+		if field.Repeated {
+			items, _ := object[field.Name].([]interface{})
+			object[field.Name] = append(items, value)
+		} else {
+			object[field.Name] = value
+		}
+	}
+	return object, nil
+}
+
+// decodeProtoField decodes the value of a field record of a message nested at the given depth
+func (v *DefaultValidator) decodeProtoField(field ProtoField, record protoRecord, depth int) (interface{}, error) {
+	if expected := field.Type.wireType(); record.wireType != expected {
+		return nil, fmt.Errorf("wire type %d does not match type %s", record.wireType, field.Type)
+	}
+
+	switch field.Type {
+	case ProtoTypeString:
+		if !utf8.Valid(record.data) {
+			return nil, fmt.Errorf("invalid UTF-8 string")
+		}
+		return string(record.data), nil
+	case ProtoTypeBytes:
+		return base64.StdEncoding.EncodeToString(record.data), nil
+	case ProtoTypeMessage:
+		message, exists := v.messages[strings.TrimPrefix(field.Message, ".")]
+		if !exists {
+			return nil, fmt.Errorf("unknown protobuf message '%s'", field.Message)
+		}
+		return v.decodeProto(message, record.data, depth+1)
+	default:
+		return field.scalar(record.value), nil
+	}
+}
+
+// decodePacked decodes the values of a packed repeated scalar field
+func (field ProtoField) decodePacked(data []byte) ([]interface{}, error) {
+	values := []interface{}{}
+	for len(data) > 0 {
+		var value uint64
+		switch field.Type.wireType() {
+		case wireVarint:
+			var n int
+			value, n = binary.Uvarint(data)
+			if n <= 0 {
+				return nil, fmt.Errorf("truncated varint")
+			}
+			data = data[n:]
+		case wireI64:
+			if len(data) < 8 {
+				return nil, fmt.Errorf("truncated 64-bit value")
+			}
+			value, data = binary.LittleEndian.Uint64(data), data[8:]
+		case wireI32:
+			if len(data) < 4 {
+				return nil, fmt.Errorf("truncated 32-bit value")
+			}
+			value, data = uint64(binary.LittleEndian.Uint32(data)), data[4:]
+		}
+		values = append(values, field.scalar(value))
+	}
+	return values, nil
+}
+
+// scalar converts the raw value of a varint or fixed size record to the value of the field type
+func (field ProtoField) scalar(value uint64) interface{} {
+	switch field.Type {
+	case ProtoTypeDouble:
+		return math.Float64frombits(value)
+	case ProtoTypeFloat:
+		return float64(math.Float32frombits(uint32(value)))
+	case ProtoTypeInt32:
+		return float64(int32(value))
+	case ProtoTypeInt64, ProtoTypeSfixed64:
+		return float64(int64(value))
+	case ProtoTypeSfixed32:
+		return float64(int32(uint32(value)))
+	case ProtoTypeUint32, ProtoTypeFixed32:
+		return float64(uint32(value))
+	case ProtoTypeSint32, ProtoTypeSint64:
+		return float64(int64(value>>1) ^ -int64(value&1))
+	case ProtoTypeBool:
+		return value != 0
+	case ProtoTypeEnum:
+		if name, exists := field.Values[int(int32(value))]; exists {
+			return name
+		}
+		return float64(int32(value))
+	default:
+		return float64(value)
+	}
+}
+
+// wireType returns the wire type of the records of a field type
+func (t ProtoType) wireType() int {
+	switch t {
+	case ProtoTypeDouble, ProtoTypeFixed64, ProtoTypeSfixed64:
+		return wireI64
+	case ProtoTypeFloat, ProtoTypeFixed32, ProtoTypeSfixed32:
+		return wireI32
+	case ProtoTypeString, ProtoTypeBytes, ProtoTypeMessage:
+		return wireLen
+	default:
+		return wireVarint
+	}
+}
+
+// packable reports whether repeated fields of the type may be packed
+func (t ProtoType) packable() bool {
+	return t.wireType() != wireLen
+}
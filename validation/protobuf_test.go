@@ -0,0 +1,245 @@
+package validation
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/lionelgarnier/validate-api-request/oas"
+)
+
+// protoVarint encodes a varint field
+func protoVarint(number int, value uint64) []byte {
+	data := binary.AppendUvarint(nil, uint64(number)<<3|wireVarint)
+	return binary.AppendUvarint(data, value)
+}
+
+// protoBytes encodes a length-delimited field
+func protoBytes(number int, value []byte) []byte {
+	data := binary.AppendUvarint(nil, uint64(number)<<3|wireLen)
+	data = binary.AppendUvarint(data, uint64(len(value)))
+	return append(data, value...)
+}
+
+// protoDouble encodes a double field
+func protoDouble(number int, value float64) []byte {
+	data := binary.AppendUvarint(nil, uint64(number)<<3|wireI64)
+	return binary.LittleEndian.AppendUint64(data, math.Float64bits(value))
+}
+
+// protoConcat concatenates encoded fields
+func protoConcat(fields ...[]byte) []byte {
+	return bytes.Join(fields, nil)
+}
+
+func TestProtobufBodies(t *testing.T) {
+	manager := oas.NewOASManager(nil, oas.FixedSelector(map[string]string{"test": "test"}))
+	err := manager.LoadAPI("test", []byte(`{
+        "openapi": "3.0.0",
+        "info": {"title": "Test API", "version": "1.0.0"},
+        "paths": {
+            "/pet": {
+                "post": {
+                    "requestBody": {
+                        "content": {
+                            "application/x-protobuf": {
+                                "x-proto-message": "pets.v1.Pet",
+                                "schema": {
+                                    "type": "object",
+                                    "required": ["name"],
+                                    "properties": {
+                                        "name": {"type": "string", "minLength": 1},
+                                        "age": {"type": "integer", "minimum": 0},
+                                        "weight": {"type": "number"},
+                                        "status": {"type": "string", "enum": ["AVAILABLE", "SOLD"]},
+                                        "tags": {"type": "array", "items": {"type": "integer"}, "maxItems": 3},
+                                        "owner": {"type": "object", "required": ["id"]}
+                                    }
+                                }
+                            },
+                            "application/vnd.pets.toy+protobuf": {"x-proto-message": "pets.v1.Toy"}
+                        }
+                    }
+                }
+            }
+        }
+    }`))
+	assert.NoError(t, err)
+	spec, _ := manager.GetApiSpec("test")
+	validator := NewValidator(spec, WithProtoMessages(
+		ProtoMessage{Name: "pets.v1.Pet", Fields: map[int]ProtoField{
+			1: {Name: "name", Type: ProtoTypeString},
+			2: {Name: "age", Type: ProtoTypeSint32},
+			3: {Name: "weight", Type: ProtoTypeDouble},
+			4: {Name: "status", Type: ProtoTypeEnum, Values: map[int]string{1: "AVAILABLE", 2: "SOLD"}},
+			5: {Name: "tags", Type: ProtoTypeInt32, Repeated: true},
+			6: {Name: "owner", Type: ProtoTypeMessage, Message: ".pets.v1.Owner"},
+		}},
+		ProtoMessage{Name: "pets.v1.Owner", Fields: map[int]ProtoField{
+			1: {Name: "id", Type: ProtoTypeInt64},
+		}},
+	))
+
+	tests := []struct {
+		name          string
+		contentType   string
+		body          []byte
+		expectedError string
+	}{
+		{
+			name:        "Valid message",
+			contentType: "application/x-protobuf",
+			body: protoConcat(
+				protoBytes(1, []byte("Rex")),
+				protoVarint(2, 6), // zigzag 3
+				protoDouble(3, 12.5),
+				protoVarint(4, 1),
+				protoBytes(5, []byte{1, 2}), // packed
+				protoVarint(5, 3),
+				protoBytes(6, protoVarint(1, 42)),
+				protoVarint(99, 7), // unknown field
+			),
+		},
+		{
+			name:          "Missing required field",
+			contentType:   "application/x-protobuf",
+			body:          protoVarint(2, 6),
+			expectedError: "request body does not match schema: name: required property is missing",
+		},
+		{
+			name:          "Negative value",
+			contentType:   "application/x-protobuf",
+			body:          protoConcat(protoBytes(1, []byte("Rex")), protoVarint(2, 1)),
+			expectedError: "request body does not match schema: age: value must be at least 0",
+		},
+		{
+			name:          "Unnamed enum value",
+			contentType:   "application/x-protobuf",
+			body:          protoConcat(protoBytes(1, []byte("Rex")), protoVarint(4, 5)),
+			expectedError: "request body does not match schema: status: expected string",
+		},
+		{
+			name:          "Too many repeated values",
+			contentType:   "application/x-protobuf",
+			body:          protoConcat(protoBytes(1, []byte("Rex")), protoBytes(5, []byte{1, 2, 3, 4})),
+			expectedError: "request body does not match schema: tags: array must have at most 3 items",
+		},
+		{
+			name:          "Invalid nested message",
+			contentType:   "application/x-protobuf",
+			body:          protoConcat(protoBytes(1, []byte("Rex")), protoBytes(6, nil)),
+			expectedError: "request body does not match schema: owner.id: required property is missing",
+		},
+		{
+			name:          "Truncated field",
+			contentType:   "application/x-protobuf",
+			body:          protoBytes(1, []byte("Rex"))[:3],
+			expectedError: "invalid request body: field 1: length exceeds message size",
+		},
+		{
+			name:          "Mismatched wire type",
+			contentType:   "application/x-protobuf",
+			body:          protoVarint(1, 3),
+			expectedError: "invalid request body: name: wire type 0 does not match type string",
+		},
+		{
+			name:        "Message without descriptor",
+			contentType: "application/vnd.pets.toy+protobuf",
+			body:        protoConcat(protoBytes(1, []byte("ball")), protoVarint(2, 3)),
+		},
+		{
+			name:          "Malformed message without descriptor",
+			contentType:   "application/vnd.pets.toy+protobuf",
+			body:          []byte{0x0f},
+			expectedError: "invalid request body: field 1: unsupported wire type 7",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, _ := http.NewRequest(http.MethodPost, "/pet", bytes.NewReader(tt.body))
+			req.Header.Set("Content-Type", tt.contentType)
+
+			oasRequest := oas.NewOASRequest(req)
+			valid, err := validator.ValidateRequest(oasRequest)
+			if tt.expectedError == "" {
+				assert.True(t, valid)
+				assert.NoError(t, err)
+			} else {
+				assert.False(t, valid)
+				assert.EqualError(t, err, tt.expectedError)
+			}
+		})
+	}
+}
+
+func TestProtobufNestingDepth(t *testing.T) {
+	manager := oas.NewOASManager(nil, oas.FixedSelector(map[string]string{"test": "test"}))
+	err := manager.LoadAPI("test", []byte(`{
+        "openapi": "3.0.0",
+        "info": {"title": "Test API", "version": "1.0.0"},
+        "paths": {
+            "/node": {
+                "post": {
+                    "requestBody": {
+                        "content": {
+                            "application/x-protobuf": {"x-proto-message": "pets.v1.Node"}
+                        }
+                    }
+                }
+            }
+        }
+    }`))
+	assert.NoError(t, err)
+	spec, _ := manager.GetApiSpec("test")
+	validator := NewValidator(spec, WithMaxDepth(3), WithProtoMessages(
+		ProtoMessage{Name: "pets.v1.Node", Fields: map[int]ProtoField{
+			1: {Name: "child", Type: ProtoTypeMessage, Message: ".pets.v1.Node"},
+		}},
+	))
+
+	// nest wraps a leaf message in the given number of child messages
+	nest := func(levels int) []byte {
+		body := []byte{}
+		for range levels {
+			body = protoBytes(1, body)
+		}
+		return body
+	}
+
+	tests := []struct {
+		name          string
+		body          []byte
+		expectedError string
+	}{
+		{
+			name: "Within maximum depth",
+			body: nest(2),
+		},
+		{
+			name:          "Exceeds maximum depth",
+			body:          nest(10),
+			expectedError: "message nesting exceeds maximum depth 3",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, _ := http.NewRequest(http.MethodPost, "/node", bytes.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/x-protobuf")
+
+			valid, err := validator.ValidateRequest(oas.NewOASRequest(req))
+			if tt.expectedError == "" {
+				assert.True(t, valid)
+				assert.NoError(t, err)
+			} else {
+				assert.False(t, valid)
+				assert.ErrorContains(t, err, tt.expectedError)
+			}
+		})
+	}
+}
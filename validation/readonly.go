@@ -0,0 +1,101 @@
+package validation
+
+import (
+	"bytes"
+	"encoding/json"
+
+	"github.com/lionelgarnier/validate-api-request/oas"
+)
+
+// ReadOnlyMode is the handling of the `readOnly` properties sent in request bodies, managed by the server, e.g. `id`
+// or `createdAt`
+type ReadOnlyMode string
+
+const (
+	// ReadOnlyIgnore validates readOnly properties like the others
+	ReadOnlyIgnore ReadOnlyMode = ""
+	// ReadOnlyReject rejects the request bodies setting readOnly properties
+	ReadOnlyReject ReadOnlyMode = "reject"
+	// ReadOnlyStrip removes the readOnly properties from JSON request bodies before validating and forwarding them.
+	// Other bodies, which cannot be rewritten, are rejected as with ReadOnlyReject.
+	ReadOnlyStrip ReadOnlyMode = "strip"
+)
+
+// WithReadOnlyMode sets the handling of the readOnly properties sent in request bodies. Unless they are ignored,
+// required readOnly properties are not required in requests, as OpenAPI specifies.
+func WithReadOnlyMode(mode ReadOnlyMode) Option {
+	return func(v *DefaultValidator) {
+		v.readOnly = mode
+	}
+}
+
+// schemaReadOnly reports whether a schema is `readOnly`
+func schemaReadOnly(schema *oas.Schema) bool {
+	return schema.ReadOnly
+}
+
+// isReadOnly reports whether a property schema, or the schema it references, is `readOnly`
+func (v *DefaultValidator) isReadOnly(schema *oas.Schema) bool {
+	readOnly, err := v.isMarked(schema, schemaReadOnly)
+	return err == nil && readOnly
+}
+
+// stripReadOnly removes the readOnly properties of a JSON request body, replacing the body of the request when
+// any is removed, and returns the resulting body. Malformed bodies are returned unchanged, to be reported when
+// decoded.
+func (v *DefaultValidator) stripReadOnly(req *oas.OASRequest, content []byte, schema *oas.Schema) ([]byte, error) {
+	// Numbers are kept as written, the body is only re-encoded when properties are removed
+	decoder := json.NewDecoder(bytes.NewReader(content))
+	decoder.UseNumber()
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil {
+		return content, nil
+	}
+	changed, err := v.stripMarked(value, schema, schemaReadOnly, 0)
+	if err != nil || !changed {
+		return content, err
+	}
+	stripped, err := encodeJSON(value)
+	if err != nil {
+		return content, err
+	}
+	setBody(req.Request, stripped)
+	req.Request.ContentLength = int64(len(stripped))
+	return stripped, nil
+}
+
+// schemaWriteOnly reports whether a schema is `writeOnly`
+func schemaWriteOnly(schema *oas.Schema) bool {
+	return schema.WriteOnly
+}
+
+// exemptRequired reports whether a required property missing from a value is not required where the value is sent:
+// readOnly properties in request bodies, unless they are ignored, and hidden properties, `writeOnly` or `x-internal`,
+// in response bodies, which rewritten responses never carry
+func (v *DefaultValidator) exemptRequired(schema *oas.Schema, propName string, state *schemaState) bool {
+	property, declared := schema.Properties[propName]
+	if !declared {
+		return false
+	}
+	if state.response {
+		hidden, err := v.isMarked(&property, schemaHidden)
+		return err == nil && hidden
+	}
+	return state.readOnly != ReadOnlyIgnore && v.isReadOnly(&property)
+}
+
+// forbiddenProperty returns the failure of a property not allowed where the value is sent, nil when it is allowed:
+// writeOnly properties in response bodies, and readOnly properties in request bodies unless they are ignored.
+// Stripped readOnly properties are left only in the bodies that cannot be rewritten, which are rejected too.
+func (v *DefaultValidator) forbiddenProperty(schema *oas.Schema, path string, state *schemaState) error {
+	if state.response {
+		if writeOnly, err := v.isMarked(schema, schemaWriteOnly); err == nil && writeOnly {
+			return newSchemaError(path, "write-only property is not allowed")
+		}
+		return nil
+	}
+	if state.readOnly != ReadOnlyIgnore && v.isReadOnly(schema) {
+		return newSchemaError(path, "read-only property is not allowed")
+	}
+	return nil
+}
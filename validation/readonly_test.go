@@ -0,0 +1,108 @@
+package validation
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/lionelgarnier/validate-api-request/oas"
+)
+
+func TestReadOnlyProperties(t *testing.T) {
+	manager := oas.NewOASManager(nil, oas.FixedSelector(map[string]string{"test": "test"}))
+	assert.NoError(t, manager.LoadAPI("test", []byte(`{
+		"openapi": "3.0.0",
+		"info": {"title": "Test API", "version": "1.0.0"},
+		"paths": {
+			"/users": {
+				"post": {
+					"requestBody": {"content": {
+						"application/json": {"schema": {"$ref": "#/components/schemas/User"}},
+						"application/yaml": {"schema": {"$ref": "#/components/schemas/User"}}
+					}},
+					"responses": {"201": {"description": "Created"}}
+				}
+			}
+		},
+		"components": {
+			"schemas": {
+				"User": {
+					"type": "object",
+					"required": ["id", "name"],
+					"additionalProperties": false,
+					"properties": {
+						"id": {"type": "integer", "readOnly": true},
+						"name": {"type": "string"},
+						"createdAt": {"$ref": "#/components/schemas/Timestamp"},
+						"address": {
+							"type": "object",
+							"properties": {"city": {"type": "string"}, "geocoded": {"type": "boolean", "readOnly": true}}
+						}
+					}
+				},
+				"Timestamp": {"type": "string", "format": "date-time", "readOnly": true}
+			}
+		}
+	}`)))
+	spec, _ := manager.GetApiSpec("test")
+
+	tests := []struct {
+		name        string
+		mode        ReadOnlyMode
+		contentType string
+		body        string
+		valid       bool
+		expected    string
+	}{
+		{name: "Ignored", mode: ReadOnlyIgnore, body: `{"id": 1, "name": "Rex"}`, valid: true, expected: `{"id": 1, "name": "Rex"}`},
+		{name: "Ignored and required", mode: ReadOnlyIgnore, body: `{"name": "Rex"}`},
+		{name: "Rejected", mode: ReadOnlyReject, body: `{"id": 1, "name": "Rex"}`},
+		{name: "Rejected by reference", mode: ReadOnlyReject, body: `{"name": "Rex", "createdAt": "2024-01-01T00:00:00Z"}`},
+		{name: "Rejected nested", mode: ReadOnlyReject, body: `{"name": "Rex", "address": {"geocoded": true}}`},
+		{name: "Not required when rejected", mode: ReadOnlyReject, body: `{"name": "Rex"}`, valid: true, expected: `{"name": "Rex"}`},
+		{
+			name:     "Stripped",
+			mode:     ReadOnlyStrip,
+			body:     `{"id": "x", "name": "Rex", "createdAt": "now", "address": {"city": "Paris", "geocoded": true}}`,
+			valid:    true,
+			expected: `{"address":{"city":"Paris"},"name":"Rex"}`,
+		},
+		{name: "Unchanged when none stripped", mode: ReadOnlyStrip, body: `{"name": "Rex"}`, valid: true, expected: `{"name": "Rex"}`},
+		{name: "Stripped body still validated", mode: ReadOnlyStrip, body: `{"id": 1, "name": 2}`},
+		{name: "Other media types cannot be stripped", mode: ReadOnlyStrip, contentType: "application/yaml", body: `{"id": 1, "name": "Rex"}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodPost, "/users", strings.NewReader(tt.body))
+			contentType := tt.contentType
+			if contentType == "" {
+				contentType = "application/json"
+			}
+			r.Header.Set("Content-Type", contentType)
+			req := oas.NewOASRequest(r)
+
+			ok, err := NewValidator(spec, WithReadOnlyMode(tt.mode)).ValidateRequest(req)
+			if !tt.valid {
+				assert.False(t, ok)
+				var validationErr *ValidationError
+				if assert.True(t, errors.As(err, &validationErr)) {
+					assert.Equal(t, CategoryInvalidBody, validationErr.Category)
+				}
+				return
+			}
+			assert.True(t, ok)
+			assert.NoError(t, err)
+
+			content, err := io.ReadAll(req.Request.Body)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, string(content))
+			assert.Equal(t, int64(len(tt.expected)), req.Request.ContentLength)
+		})
+	}
+}
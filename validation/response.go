@@ -0,0 +1,215 @@
+package validation
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/lionelgarnier/validate-api-request/oas"
+	"github.com/lionelgarnier/validate-api-request/pkg/helpers"
+)
+
+// ValidateResponse validates the status, headers and body of the response to a validated request
+func (v *DefaultValidator) ValidateResponse(req *oas.OASRequest, status int, header http.Header, body []byte) (bool, error) {
+	if ok, err := v.validateResponse(req, status, header, body, false); !ok {
+		return false, stageError(StageResponse, err)
+	}
+	return true, nil
+}
+
+// ValidateResponseStream validates the status and headers of a streamed response, e.g. server-sent events, and that
+// its content type is declared. Its body is not validated as it is not buffered.
+func (v *DefaultValidator) ValidateResponseStream(req *oas.OASRequest, status int, header http.Header) (bool, error) {
+	if ok, err := v.validateResponse(req, status, header, nil, true); !ok {
+		return false, stageError(StageResponse, err)
+	}
+	return true, nil
+}
+
+// ResponseBodyValidator reports which response bodies it validates, as DefaultValidator does
+type ResponseBodyValidator interface {
+	ValidatesResponseBody(req *oas.OASRequest, status int, header http.Header) bool
+}
+
+// ValidatesResponseBody reports whether a validator implementing ResponseBodyValidator validates the body of a
+// response, see DefaultValidator.ValidatesResponseBody. Bodies are assumed validated by other validators.
+func ValidatesResponseBody(validator Validator, req *oas.OASRequest, status int, header http.Header) bool {
+	bodyValidator, ok := validator.(ResponseBodyValidator)
+	if !ok {
+		return true
+	}
+	return bodyValidator.ValidatesResponseBody(req, status, header)
+}
+
+// ValidatesResponseBody reports whether the body of a response to a validated request is validated, rather than
+// passed through because its declared media type has no decoder, e.g. text, XML or binary. The bodies of undeclared
+// statuses and content types are reported as validated, as ValidateResponse rejects them.
+func (v *DefaultValidator) ValidatesResponseBody(req *oas.OASRequest, status int, header http.Header) bool {
+	if req.Operation == nil {
+		return true
+	}
+	_, response, exists := findResponse(req.Operation.Responses, status)
+	if !exists {
+		return true
+	}
+	response, err := v.apiSpec.ResolveResponse(response)
+	if err != nil || len(response.Content) == 0 {
+		return true
+	}
+	contentType := header.Get("Content-Type")
+	_, mediaType, exists := findMediaType(response.Content, contentType)
+	return !exists || v.decodable(contentType, mediaType)
+}
+
+// validateResponse validates a response, failures not produced by the response checks are attached to the response stage by the caller
+func (v *DefaultValidator) validateResponse(req *oas.OASRequest, status int, header http.Header, body []byte, stream bool) (bool, error) {
+	if req.PathItem == nil || req.Route == "" || req.Operation == nil {
+		_, err := v.ValidateRequestMethod(req)
+		if err != nil {
+			return false, err
+		}
+	}
+
+	key, response, exists := findResponse(req.Operation.Responses, status)
+	if !exists {
+		return false, &ValidationError{
+			Stage:       StageResponse,
+			Category:    CategoryUndeclaredStatus,
+			Message:     fmt.Sprintf("undeclared response status %d", status),
+			SpecPointer: operationPointer(req) + "/responses",
+		}
+	}
+	responsePointer := refPointer(response.Ref, operationPointer(req)+"/responses/"+helpers.EscapeJSONPointer(key))
+
+	response, err := v.apiSpec.ResolveResponse(response)
+	if err != nil {
+		return false, err
+	}
+
+	if err := v.validateResponseHeaders(response, header, responsePointer); err != nil {
+		return false, err
+	}
+
+	return v.validateResponseBody(response, header, body, stream, responsePointer)
+}
+
+// findResponse returns the key and the response declared for a status:
+// its exact code first, then its range (e.g. `2XX`), then `default`
+func findResponse(responses map[string]oas.Response, status int) (string, *oas.Response, bool) {
+	code := strconv.Itoa(status)
+	for _, key := range []string{code, code[:1] + "XX", code[:1] + "xx", "default"} {
+		if response, exists := responses[key]; exists {
+			return key, &response, true
+		}
+	}
+	return "", nil, false
+}
+
+// validateResponseHeaders validates the declared headers of a response
+func (v *DefaultValidator) validateResponseHeaders(response *oas.Response, header http.Header, responsePointer string) error {
+	for name := range response.Headers {
+		// A Content-Type header definition is ignored, the content map describes it
+		if strings.EqualFold(name, "Content-Type") {
+			continue
+		}
+
+		declared := response.Headers[name]
+		headerPointer := refPointer(declared.Ref, responsePointer+"/headers/"+helpers.EscapeJSONPointer(name))
+		resolved, err := v.apiSpec.ResolveHeader(&declared)
+		if err != nil {
+			return err
+		}
+
+		value := header.Get(name)
+		if value == "" && resolved.Required {
+			return &ValidationError{Stage: StageResponse, Message: fmt.Sprintf("missing required response header '%s'", name), SpecPointer: headerPointer}
+		}
+		if value != "" && resolved.Schema != nil {
+			if err := v.validateSchema(value, resolved.Schema, name); err != nil {
+				failure := ValidationError{Stage: StageResponse, Message: fmt.Sprintf("invalid type for response header '%s'", name)}
+				return schemaFailures(failure, headerPointer+"/schema", err)
+			}
+		}
+	}
+	return nil
+}
+
+// validateResponseBody validates the body of a response against the schema of its content type.
+// Only the content type of a streamed body is validated.
+func (v *DefaultValidator) validateResponseBody(response *oas.Response, header http.Header, body []byte, stream bool, responsePointer string) (bool, error) {
+	if len(response.Content) == 0 {
+		if len(body) > 0 || stream {
+			return false, &ValidationError{Stage: StageResponse, Message: "undeclared response body", SpecPointer: responsePointer}
+		}
+		return true, nil
+	}
+	if len(body) == 0 && !stream {
+		return true, nil
+	}
+
+	contentType := header.Get("Content-Type")
+	key, mediaType, exists := findMediaType(response.Content, contentType)
+	if !exists {
+		return false, &ValidationError{
+			Stage:       StageResponse,
+			Message:     fmt.Sprintf("undeclared response content type '%s'", contentType),
+			SpecPointer: responsePointer + "/content",
+		}
+	}
+	if stream {
+		return true, nil
+	}
+	// Bodies of media types without decoder, e.g. text, XML or binary, are passed through rather than read as JSON
+	if !v.decodable(contentType, mediaType) {
+		return true, nil
+	}
+	if coding := contentCoding(header); coding != "" {
+		return false, &ValidationError{
+			Stage:       StageResponse,
+			Message:     fmt.Sprintf("unsupported response Content-Encoding '%s'", coding),
+			SpecPointer: responsePointer + "/content",
+		}
+	}
+	mediaTypePointer := responsePointer + "/content/" + helpers.EscapeJSONPointer(key)
+
+	// Decode response body with the decoder of its media type, skipping validation if no schema defined
+	value, validate, err := v.decodeBody(bytes.NewReader(body), contentType, mediaType)
+	if err != nil {
+		return false, &ValidationError{Stage: StageResponse, Message: "invalid response body", Err: err, SpecPointer: mediaTypePointer}
+	}
+	if !validate {
+		return true, nil
+	}
+	state := newSchemaState()
+	state.collectAll = v.collectAll
+	state.response = true
+	if err := v.evaluateSchema(value, mediaType.Schema, "", state); err != nil {
+		return false, schemaFailures(ValidationError{Stage: StageResponse, Message: "response body does not match schema"}, mediaTypePointer+"/schema", err)
+	}
+
+	return true, nil
+}
+
+// decodable reports whether bodies of a content type declared by a media type can be decoded: JSON, protobuf and
+// multipart form bodies, and the bodies of the media types with a registered decoder
+func (v *DefaultValidator) decodable(contentType string, mediaType oas.MediaType) bool {
+	if _, isProto := protoMessageName(mediaType); isProto {
+		return true
+	}
+	if essence, _ := parseMediaType(contentType); essence == "multipart/form-data" {
+		return true
+	}
+	_, registered := v.registeredDecoder(contentType)
+	return registered || isJSONMediaType(contentType)
+}
+
+// contentCoding returns the content coding applied to a body, e.g. gzip, or an empty string when it is not encoded
+func contentCoding(header http.Header) string {
+	coding := strings.ToLower(strings.TrimSpace(header.Get("Content-Encoding")))
+	if coding == "identity" {
+		return ""
+	}
+	return coding
+}
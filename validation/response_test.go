@@ -0,0 +1,337 @@
+package validation
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/lionelgarnier/validate-api-request/oas"
+)
+
+func TestValidateResponse(t *testing.T) {
+	manager := oas.NewOASManager(nil, oas.FixedSelector(map[string]string{"test": "test"}))
+
+	content := []byte(`{
+		"openapi": "3.0.0",
+		"info": {"title": "Test API", "version": "1.0.0"},
+		"paths": {
+			"/pet": {
+				"get": {
+					"responses": {
+						"200": {
+							"description": "A pet",
+							"headers": {
+								"X-Rate-Limit": {"required": true, "schema": {"type": "integer"}},
+								"X-Request-Id": {"$ref": "#/components/headers/RequestId"}
+							},
+							"content": {
+								"application/json": {
+									"schema": {
+										"type": "object",
+										"required": ["name", "password"],
+										"properties": {"name": {"type": "string"}, "password": {"type": "string", "writeOnly": true}}
+									}
+								}
+							}
+						},
+						"4XX": {"description": "Client error"},
+						"default": {"$ref": "#/components/responses/Error"}
+					}
+				}
+			},
+			"/pets": {
+				"get": {
+					"responses": {
+						"200": {"description": "Pets"}
+					}
+				}
+			},
+			"/report": {
+				"get": {
+					"responses": {
+						"200": {
+							"description": "A report",
+							"content": {
+								"text/plain": {"schema": {"type": "string"}},
+								"application/xml": {"schema": {"type": "object"}},
+								"application/octet-stream": {"schema": {"type": "string", "format": "binary"}},
+								"text/csv": {"schema": {"type": "array", "items": {"type": "object", "required": ["id"]}}}
+							}
+						}
+					}
+				}
+			}
+		},
+		"components": {
+			"headers": {
+				"RequestId": {"schema": {"type": "string", "format": "uuid"}}
+			},
+			"responses": {
+				"Error": {
+					"description": "Server error",
+					"content": {"application/json": {"schema": {"type": "object", "required": ["message"]}}}
+				}
+			}
+		}
+	}`)
+	assert.NoError(t, manager.LoadAPI("test", content))
+
+	spec, _ := manager.GetApiSpec("test")
+	validator := NewValidator(spec)
+
+	tests := []struct {
+		name             string
+		path             string
+		status           int
+		headers          map[string]string
+		body             string
+		expectedError    string
+		expectedCategory Category
+	}{
+		{
+			name:    "Valid response",
+			path:    "/pet",
+			status:  http.StatusOK,
+			headers: map[string]string{"Content-Type": "application/json", "X-Rate-Limit": "10", "X-Request-Id": "123e4567-e89b-12d3-a456-426614174000"},
+			body:    `{"name": "Fluffy"}`,
+		},
+		{
+			name:             "Missing required header",
+			path:             "/pet",
+			status:           http.StatusOK,
+			headers:          map[string]string{"Content-Type": "application/json"},
+			body:             `{"name": "Fluffy"}`,
+			expectedError:    "missing required response header 'X-Rate-Limit'",
+			expectedCategory: CategoryInvalidResponse,
+		},
+		{
+			name:             "Invalid referenced header",
+			path:             "/pet",
+			status:           http.StatusOK,
+			headers:          map[string]string{"Content-Type": "application/json", "X-Rate-Limit": "10", "X-Request-Id": "abc"},
+			body:             `{"name": "Fluffy"}`,
+			expectedError:    "invalid type for response header 'X-Request-Id'",
+			expectedCategory: CategoryInvalidResponse,
+		},
+		{
+			name:             "Body not matching schema",
+			path:             "/pet",
+			status:           http.StatusOK,
+			headers:          map[string]string{"Content-Type": "application/json", "X-Rate-Limit": "10"},
+			body:             `{}`,
+			expectedError:    "response body does not match schema",
+			expectedCategory: CategoryInvalidResponse,
+		},
+		{
+			name:             "Write-only property",
+			path:             "/pet",
+			status:           http.StatusOK,
+			headers:          map[string]string{"Content-Type": "application/json", "X-Rate-Limit": "10"},
+			body:             `{"name": "Fluffy", "password": "secret"}`,
+			expectedError:    "write-only property is not allowed",
+			expectedCategory: CategoryInvalidResponse,
+		},
+		{
+			name:             "Compressed body",
+			path:             "/pet",
+			status:           http.StatusOK,
+			headers:          map[string]string{"Content-Type": "application/json", "Content-Encoding": "gzip", "X-Rate-Limit": "10"},
+			body:             "\x1f\x8b\x08\x00",
+			expectedError:    "unsupported response Content-Encoding 'gzip'",
+			expectedCategory: CategoryInvalidResponse,
+		},
+		{
+			name:   "Status range",
+			path:   "/pet",
+			status: http.StatusNotFound,
+		},
+		{
+			name:    "Default response",
+			path:    "/pet",
+			status:  http.StatusInternalServerError,
+			headers: map[string]string{"Content-Type": "application/json"},
+			body:    `{"message": "failure"}`,
+		},
+		{
+			name:             "Undeclared status",
+			path:             "/pets",
+			status:           http.StatusNotFound,
+			expectedError:    "undeclared response status 404",
+			expectedCategory: CategoryUndeclaredStatus,
+		},
+		{
+			name:    "Text body without decoder",
+			path:    "/report",
+			status:  http.StatusOK,
+			headers: map[string]string{"Content-Type": "text/plain; charset=utf-8"},
+			body:    `Monthly report`,
+		},
+		{
+			name:    "XML body without decoder",
+			path:    "/report",
+			status:  http.StatusOK,
+			headers: map[string]string{"Content-Type": "application/xml"},
+			body:    `<report><month>May</month></report>`,
+		},
+		{
+			name:    "Binary body without decoder",
+			path:    "/report",
+			status:  http.StatusOK,
+			headers: map[string]string{"Content-Type": "application/octet-stream"},
+			body:    "\x00\x01\x02",
+		},
+		{
+			name:             "Body with registered decoder",
+			path:             "/report",
+			status:           http.StatusOK,
+			headers:          map[string]string{"Content-Type": "text/csv"},
+			body:             "name\nFluffy\n",
+			expectedError:    "response body does not match schema",
+			expectedCategory: CategoryInvalidResponse,
+		},
+		{
+			name:             "Undeclared body",
+			path:             "/pets",
+			status:           http.StatusOK,
+			body:             `[]`,
+			expectedError:    "undeclared response body",
+			expectedCategory: CategoryInvalidResponse,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequest(http.MethodGet, tt.path, nil)
+			assert.NoError(t, err)
+
+			header := make(http.Header)
+			for k, v := range tt.headers {
+				header.Set(k, v)
+			}
+
+			ok, err := validator.ValidateResponse(oas.NewOASRequest(req), tt.status, header, []byte(tt.body))
+			if tt.expectedError != "" {
+				assert.False(t, ok)
+				assert.Error(t, err)
+				assert.Contains(t, err.Error(), tt.expectedError)
+				assert.Equal(t, tt.expectedCategory, CategoryOf(err))
+			} else {
+				assert.True(t, ok)
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestValidateResponseStream(t *testing.T) {
+	manager := oas.NewOASManager(nil, oas.FixedSelector(map[string]string{"test": "test"}))
+	assert.NoError(t, manager.LoadAPI("test", []byte(`{
+		"openapi": "3.0.0",
+		"info": {"title": "Test API", "version": "1.0.0"},
+		"paths": {
+			"/events": {
+				"get": {
+					"responses": {
+						"200": {
+							"description": "Events",
+							"headers": {"X-Stream-Id": {"required": true, "schema": {"type": "string"}}},
+							"content": {"text/event-stream": {"schema": {"type": "string"}}}
+						},
+						"204": {"description": "No events"}
+					}
+				}
+			}
+		}
+	}`)))
+	spec, _ := manager.GetApiSpec("test")
+	validator := NewValidator(spec)
+
+	tests := []struct {
+		name          string
+		status        int
+		headers       map[string]string
+		expectedError string
+	}{
+		{name: "Declared stream", status: http.StatusOK, headers: map[string]string{"Content-Type": "text/event-stream", "X-Stream-Id": "1"}},
+		{name: "Missing header", status: http.StatusOK, headers: map[string]string{"Content-Type": "text/event-stream"}, expectedError: "missing required response header 'X-Stream-Id'"},
+		{name: "Undeclared content type", status: http.StatusOK, headers: map[string]string{"Content-Type": "application/json", "X-Stream-Id": "1"}, expectedError: "undeclared response content type 'application/json'"},
+		{name: "Undeclared body", status: http.StatusNoContent, headers: map[string]string{"Content-Type": "text/event-stream"}, expectedError: "undeclared response body"},
+		{name: "Undeclared status", status: http.StatusAccepted, expectedError: "undeclared response status 202"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequest(http.MethodGet, "/events", nil)
+			assert.NoError(t, err)
+
+			header := make(http.Header)
+			for k, v := range tt.headers {
+				header.Set(k, v)
+			}
+
+			ok, err := validator.ValidateResponseStream(oas.NewOASRequest(req), tt.status, header)
+			if tt.expectedError != "" {
+				assert.False(t, ok)
+				assert.EqualError(t, err, tt.expectedError)
+			} else {
+				assert.True(t, ok)
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestValidatesResponseBody(t *testing.T) {
+	manager := oas.NewOASManager(nil, oas.FixedSelector(map[string]string{"test": "test"}))
+	assert.NoError(t, manager.LoadAPI("test", []byte(`{
+		"openapi": "3.0.0",
+		"info": {"title": "Test API", "version": "1.0.0"},
+		"paths": {
+			"/report": {
+				"get": {
+					"responses": {
+						"200": {
+							"description": "A report",
+							"content": {
+								"application/json": {"schema": {"type": "object"}},
+								"text/plain": {"schema": {"type": "string"}},
+								"text/csv": {"schema": {"type": "array"}}
+							}
+						},
+						"204": {"description": "No report"}
+					}
+				}
+			}
+		}
+	}`)))
+	spec, _ := manager.GetApiSpec("test")
+	validator := NewValidator(spec, WithDecoder("text/csv", JSONDecoder))
+
+	tests := []struct {
+		name        string
+		status      int
+		contentType string
+		expected    bool
+	}{
+		{name: "JSON body", status: http.StatusOK, contentType: "application/json", expected: true},
+		{name: "Body without decoder", status: http.StatusOK, contentType: "text/plain; charset=utf-8"},
+		{name: "Body with registered decoder", status: http.StatusOK, contentType: "text/csv", expected: true},
+		{name: "Undeclared content type", status: http.StatusOK, contentType: "application/xml", expected: true},
+		{name: "Undeclared body", status: http.StatusNoContent, contentType: "text/plain", expected: true},
+		{name: "Undeclared status", status: http.StatusAccepted, contentType: "text/plain", expected: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequest(http.MethodGet, "/report", nil)
+			assert.NoError(t, err)
+			oasRequest := oas.NewOASRequest(req)
+			_, err = validator.ValidateRequestMethod(oasRequest)
+			assert.NoError(t, err)
+
+			header := http.Header{"Content-Type": []string{tt.contentType}}
+			assert.Equal(t, tt.expected, ValidatesResponseBody(validator, oasRequest, tt.status, header))
+		})
+	}
+}
@@ -0,0 +1,201 @@
+package validation
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/lionelgarnier/validate-api-request/oas"
+	"github.com/lionelgarnier/validate-api-request/pkg/helpers"
+)
+
+// InternalExtension is the schema extension marking properties never sent to clients
+const InternalExtension = "x-internal"
+
+// RewriteResponse removes the properties of a JSON response body whose schema is `writeOnly` or marked
+// `x-internal: true`, so that they never reach clients. The body is returned unchanged when nothing is removed,
+// or when it is not declared JSON. JSON bodies that cannot be decoded, e.g. compressed ones, fail the rewrite.
+func (v *DefaultValidator) RewriteResponse(req *oas.OASRequest, status int, header http.Header, body []byte) ([]byte, error) {
+	rewritten, err := v.rewriteResponse(req, status, header, body)
+	if err != nil {
+		return body, stageError(StageResponse, err)
+	}
+	return rewritten, nil
+}
+
+// rewriteResponse removes the hidden properties of a response body
+func (v *DefaultValidator) rewriteResponse(req *oas.OASRequest, status int, header http.Header, body []byte) ([]byte, error) {
+	if req.PathItem == nil || req.Route == "" || req.Operation == nil {
+		_, err := v.ValidateRequestMethod(req)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if len(body) == 0 {
+		return body, nil
+	}
+
+	_, response, exists := findResponse(req.Operation.Responses, status)
+	if !exists {
+		return body, nil
+	}
+	response, err := v.apiSpec.ResolveResponse(response)
+	if err != nil {
+		return nil, err
+	}
+	contentType := header.Get("Content-Type")
+	_, mediaType, exists := findMediaType(response.Content, contentType)
+	if !exists || mediaType.Schema == nil || !isJSONMediaType(contentType) {
+		return body, nil
+	}
+	// Bodies that cannot be read could leak hidden properties
+	if coding := contentCoding(header); coding != "" {
+		return nil, &ValidationError{Message: fmt.Sprintf("cannot rewrite response body with Content-Encoding '%s'", coding)}
+	}
+
+	// Numbers are kept as written, the body is only re-encoded when properties are removed
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil {
+		return nil, &ValidationError{Message: "invalid response body", Err: err}
+	}
+	changed, err := v.stripMarked(value, mediaType.Schema, schemaHidden, 0)
+	if err != nil || !changed {
+		return body, err
+	}
+	return encodeJSON(value)
+}
+
+// encodeJSON encodes a value rewritten by stripMarked, without escaping HTML characters
+func encodeJSON(value interface{}) ([]byte, error) {
+	var rewritten bytes.Buffer
+	encoder := json.NewEncoder(&rewritten)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(value); err != nil {
+		return nil, err
+	}
+	return bytes.TrimSuffix(rewritten.Bytes(), []byte("\n")), nil
+}
+
+// stripMarked removes the properties of a value and of its nested values whose schema is marked, e.g. hidden, and
+// reports whether any was removed. Properties marked by any subschema of allOf, anyOf or oneOf are removed.
+func (v *DefaultValidator) stripMarked(value interface{}, schema *oas.Schema, marked func(*oas.Schema) bool, depth int) (bool, error) {
+	if depth >= v.maxDepth {
+		return false, newSchemaError("", "schema nesting exceeds maximum depth %d", v.maxDepth)
+	}
+	schema, err := v.resolveStripSchema(schema)
+	if err != nil {
+		return false, err
+	}
+
+	changed := false
+	for _, subSchemas := range [][]oas.Schema{schema.AllOf, schema.AnyOf, schema.OneOf} {
+		for i := range subSchemas {
+			stripped, err := v.stripMarked(value, &subSchemas[i], marked, depth+1)
+			if err != nil {
+				return false, err
+			}
+			changed = changed || stripped
+		}
+	}
+
+	switch typed := value.(type) {
+	case map[string]interface{}:
+		for name, property := range typed {
+			for _, propertySchema := range propertySchemas(schema, name) {
+				isMarked, err := v.isMarked(propertySchema, marked)
+				if err != nil {
+					return false, err
+				}
+				if isMarked {
+					delete(typed, name)
+					changed = true
+					break
+				}
+				stripped, err := v.stripMarked(property, propertySchema, marked, depth+1)
+				if err != nil {
+					return false, err
+				}
+				changed = changed || stripped
+			}
+		}
+	case []interface{}:
+		for i, item := range typed {
+			itemSchema := schema.Items
+			if i < len(schema.PrefixItems) {
+				itemSchema = &schema.PrefixItems[i]
+			}
+			if itemSchema == nil {
+				break
+			}
+			stripped, err := v.stripMarked(item, itemSchema, marked, depth+1)
+			if err != nil {
+				return false, err
+			}
+			changed = changed || stripped
+		}
+	}
+	return changed, nil
+}
+
+// propertySchemas returns the schemas of an object property: its declared schema and the patternProperties it
+// matches, or else the additionalProperties schema
+func propertySchemas(schema *oas.Schema, name string) []*oas.Schema {
+	var schemas []*oas.Schema
+	if propertySchema, exists := schema.Properties[name]; exists {
+		schemas = append(schemas, &propertySchema)
+	}
+	for pattern, patternSchema := range schema.PatternProperties {
+		if helpers.MatchPattern(name, pattern) {
+			schemas = append(schemas, &patternSchema)
+		}
+	}
+	if len(schemas) == 0 && schema.AdditionalProperties != nil {
+		if additionalSchema, _ := additionalPropertiesSchema(schema.AdditionalProperties); additionalSchema != nil {
+			schemas = append(schemas, additionalSchema)
+		}
+	}
+	return schemas
+}
+
+// isMarked reports whether a property schema, or the schema it references, is marked
+func (v *DefaultValidator) isMarked(schema *oas.Schema, marked func(*oas.Schema) bool) (bool, error) {
+	if marked(schema) {
+		return true, nil
+	}
+	resolved, err := v.resolveStripSchema(schema)
+	if err != nil {
+		return false, err
+	}
+	return marked(resolved), nil
+}
+
+// schemaHidden reports whether a schema is `writeOnly` or `x-internal`
+func schemaHidden(schema *oas.Schema) bool {
+	internal, _ := schema.Extensions[InternalExtension].(bool)
+	return schema.WriteOnly || internal
+}
+
+// resolveStripSchema resolves the chain of references of a schema
+func (v *DefaultValidator) resolveStripSchema(schema *oas.Schema) (*oas.Schema, error) {
+	for i := 0; schema.Ref != ""; i++ {
+		if i >= v.maxDepth {
+			return nil, newSchemaError("", "schema nesting exceeds maximum depth %d", v.maxDepth)
+		}
+		resolved, err := v.resolveSchemaReference(schema.Ref)
+		if err != nil {
+			return nil, err
+		}
+		schema = resolved
+	}
+	return schema, nil
+}
+
+// isJSONMediaType reports whether a media type is JSON, including structured syntax suffixes such as `+json`
+func isJSONMediaType(contentType string) bool {
+	essence, _ := parseMediaType(contentType)
+	return essence == "application/json" || strings.HasSuffix(essence, "+json")
+}
@@ -0,0 +1,146 @@
+package validation
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/lionelgarnier/validate-api-request/oas"
+)
+
+func TestRewriteResponse(t *testing.T) {
+	manager := oas.NewOASManager(nil, oas.FixedSelector(map[string]string{"test": "test"}))
+	assert.NoError(t, manager.LoadAPI("test", []byte(`{
+		"openapi": "3.0.0",
+		"info": {"title": "Test API", "version": "1.0.0"},
+		"paths": {
+			"/user": {
+				"get": {
+					"responses": {
+						"200": {
+							"description": "A user",
+							"content": {
+								"application/json": {"schema": {"$ref": "#/components/schemas/User"}},
+								"text/plain": {"schema": {"type": "string"}}
+							}
+						},
+						"default": {
+							"description": "Users",
+							"content": {"application/vnd.users+json": {"schema": {"type": "array", "items": {"$ref": "#/components/schemas/User"}}}}
+						}
+					}
+				}
+			}
+		},
+		"components": {
+			"schemas": {
+				"User": {
+					"allOf": [{"$ref": "#/components/schemas/Audit"}],
+					"properties": {
+						"name": {"type": "string"},
+						"password": {"type": "string", "writeOnly": true},
+						"secret": {"$ref": "#/components/schemas/Secret"},
+						"settings": {
+							"type": "object",
+							"additionalProperties": {"type": "object", "properties": {"token": {"type": "string", "x-internal": true}}}
+						}
+					}
+				},
+				"Audit": {"properties": {"ip": {"type": "string", "x-internal": true}}},
+				"Secret": {"type": "string", "x-internal": true}
+			}
+		}
+	}`)))
+	spec, _ := manager.GetApiSpec("test")
+	validator := NewValidator(spec)
+
+	tests := []struct {
+		name        string
+		status      int
+		contentType string
+		encoding    string
+		body        string
+		expected    string
+		err         string
+	}{
+		{
+			name:        "Hidden properties",
+			status:      http.StatusOK,
+			contentType: "application/json",
+			body:        `{"name": "<Rex>", "password": "p", "secret": "s", "ip": "10.0.0.1", "age": 1.50}`,
+			expected:    `{"age":1.50,"name":"<Rex>"}`,
+		},
+		{
+			name:        "Nested hidden properties",
+			status:      http.StatusOK,
+			contentType: "application/json",
+			body:        `{"name": "Rex", "settings": {"mail": {"token": "t", "enabled": true}}}`,
+			expected:    `{"name":"Rex","settings":{"mail":{"enabled":true}}}`,
+		},
+		{
+			name:        "Array items",
+			status:      http.StatusNotFound,
+			contentType: "application/vnd.users+json",
+			body:        `[{"name": "Rex", "password": "p"}, {"name": "Fluffy"}]`,
+			expected:    `[{"name":"Rex"},{"name":"Fluffy"}]`,
+		},
+		{
+			name:        "Unchanged body",
+			status:      http.StatusOK,
+			contentType: "application/json",
+			body:        `{ "name": "Rex" }`,
+			expected:    `{ "name": "Rex" }`,
+		},
+		{
+			name:        "Not JSON",
+			status:      http.StatusOK,
+			contentType: "text/plain",
+			body:        `{"password": "p"}`,
+			expected:    `{"password": "p"}`,
+		},
+		{
+			name:        "Invalid JSON",
+			status:      http.StatusOK,
+			contentType: "application/json",
+			body:        `{"password": "p"`,
+			err:         "invalid response body",
+		},
+		{
+			name:        "Compressed body",
+			status:      http.StatusOK,
+			contentType: "application/json",
+			encoding:    "gzip",
+			body:        "\x1f\x8b\x08\x00",
+			err:         "cannot rewrite response body with Content-Encoding 'gzip'",
+		},
+		{
+			name:        "Identity encoding",
+			status:      http.StatusOK,
+			contentType: "application/json",
+			encoding:    "identity",
+			body:        `{"name": "Rex", "password": "p"}`,
+			expected:    `{"name":"Rex"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequest(http.MethodGet, "/user", nil)
+			assert.NoError(t, err)
+			header := http.Header{"Content-Type": {tt.contentType}}
+			if tt.encoding != "" {
+				header.Set("Content-Encoding", tt.encoding)
+			}
+
+			body, err := validator.RewriteResponse(oas.NewOASRequest(req), tt.status, header, []byte(tt.body))
+			if tt.err != "" {
+				assert.ErrorContains(t, err, tt.err)
+				assert.Equal(t, StageResponse, StageOf(err))
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, string(body))
+		})
+	}
+}
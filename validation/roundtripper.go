@@ -0,0 +1,117 @@
+package validation
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/lionelgarnier/validate-api-request/oas"
+)
+
+// RoundTripper validates the requests sent by a client against a spec before sending them, and optionally the
+// responses received, so that client code catches contract violations before they reach the API
+type RoundTripper struct {
+	base      http.RoundTripper
+	validator Validator
+	responses bool
+	basePath  string
+
+	validatorOptions []Option
+}
+
+// RoundTripperOption configures optional RoundTripper behavior
+type RoundTripperOption func(*RoundTripper)
+
+// WithResponseValidation also validates the responses received, a response failing validation is returned as an error
+func WithResponseValidation() RoundTripperOption {
+	return func(t *RoundTripper) {
+		t.responses = true
+	}
+}
+
+// WithBasePath strips the base path of the server, e.g. `/v1`, from the request paths before matching them
+// against the paths of the spec
+func WithBasePath(basePath string) RoundTripperOption {
+	return func(t *RoundTripper) {
+		t.basePath = strings.TrimSuffix(basePath, "/")
+	}
+}
+
+// WithValidatorOptions sets the options of the validator of the requests and responses
+func WithValidatorOptions(opts ...Option) RoundTripperOption {
+	return func(t *RoundTripper) {
+		t.validatorOptions = append(t.validatorOptions, opts...)
+	}
+}
+
+// NewRoundTripper creates a RoundTripper validating requests against the spec, then sending them with base,
+// http.DefaultTransport if nil
+func NewRoundTripper(base http.RoundTripper, spec *oas.APISpec, opts ...RoundTripperOption) *RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	t := &RoundTripper{base: base}
+
+	for _, opt := range opts {
+		opt(t)
+	}
+	t.validator = NewValidator(spec, t.validatorOptions...)
+	return t
+}
+
+// RoundTrip validates the request and sends it. Requests failing validation are not sent and their failure is
+// returned, as are the failures of the responses when they are validated.
+func (t *RoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	body, err := readBody(req.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read request body: %v", err)
+	}
+
+	// Validate a copy of the request, the request sent keeps its body
+	oasRequest := oas.NewOASRequest(t.validatedRequest(req, body))
+	if ok, err := t.validator.ValidateRequest(oasRequest); !ok {
+		return nil, fmt.Errorf("request does not match the spec: %w", err)
+	}
+
+	if body != nil {
+		req = req.Clone(req.Context())
+		req.Body = io.NopCloser(bytes.NewReader(body))
+	}
+	resp, err := t.base.RoundTrip(req)
+	if err != nil || !t.responses {
+		return resp, err
+	}
+
+	respBody, err := readBody(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response body: %v", err)
+	}
+	if ok, err := t.validator.ValidateResponse(oasRequest, resp.StatusCode, resp.Header, respBody); !ok {
+		return nil, fmt.Errorf("response does not match the spec: %w", err)
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(respBody))
+	return resp, nil
+}
+
+// validatedRequest returns the copy of a request that is validated, with its body and its path relative to the base path
+func (t *RoundTripper) validatedRequest(req *http.Request, body []byte) *http.Request {
+	validated := req.Clone(req.Context())
+	validated.Body = io.NopCloser(bytes.NewReader(body))
+	validated.ContentLength = int64(len(body))
+	if t.basePath != "" {
+		validated.URL.Path = strings.TrimPrefix(validated.URL.Path, t.basePath)
+		validated.URL.RawPath = ""
+	}
+	return validated
+}
+
+// readBody reads and closes a body, which may be nil
+func readBody(body io.ReadCloser) ([]byte, error) {
+	if body == nil || body == http.NoBody {
+		return nil, nil
+	}
+	defer body.Close()
+	return io.ReadAll(body)
+}
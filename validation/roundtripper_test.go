@@ -0,0 +1,85 @@
+package validation
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/lionelgarnier/validate-api-request/oas"
+)
+
+func TestRoundTripper(t *testing.T) {
+	manager := oas.NewOASManager(nil, oas.FixedSelector(map[string]string{"test": "test"}))
+	err := manager.LoadAPI("test", []byte(`{
+        "openapi": "3.0.0",
+        "info": {"title": "Test API", "version": "1.0.0"},
+        "paths": {
+            "/pets": {
+                "post": {
+                    "requestBody": {
+                        "required": true,
+                        "content": {"application/json": {"schema": {"type": "object", "required": ["name"]}}}
+                    },
+                    "responses": {
+                        "201": {"description": "Created", "content": {"application/json": {"schema": {"type": "object", "required": ["id"]}}}}
+                    }
+                }
+            }
+        }
+    }`))
+	assert.NoError(t, err)
+	spec, _ := manager.GetApiSpec("test")
+
+	// The server echoes the request body, which is only valid as a response when it has an id
+	sent := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		sent++
+		body, _ := io.ReadAll(r.Body)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		w.Write(body)
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name          string
+		path          string
+		body          string
+		expectedError string
+		sent          bool
+	}{
+		{name: "Valid request and response", path: "/v1/pets", body: `{"name": "Kitty", "id": 1}`, sent: true},
+		{name: "Invalid request", path: "/v1/pets", body: `{"id": 1}`, expectedError: "request does not match the spec"},
+		{name: "Undeclared path", path: "/v1/owners", body: `{"name": "Kitty"}`, expectedError: "request does not match the spec"},
+		{name: "Invalid response", path: "/v1/pets", body: `{"name": "Kitty"}`, expectedError: "response does not match the spec", sent: true},
+	}
+
+	client := &http.Client{Transport: NewRoundTripper(nil, spec, WithResponseValidation(), WithBasePath("/v1"))}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sent = 0
+			resp, err := client.Post(server.URL+tt.path, "application/json", strings.NewReader(tt.body))
+			if tt.sent {
+				assert.Equal(t, 1, sent)
+			} else {
+				assert.Equal(t, 0, sent)
+			}
+			if tt.expectedError != "" {
+				assert.ErrorContains(t, err, tt.expectedError)
+				var validationErr *ValidationError
+				assert.True(t, errors.As(err, &validationErr))
+				return
+			}
+
+			assert.NoError(t, err)
+			defer resp.Body.Close()
+			body, _ := io.ReadAll(resp.Body)
+			assert.Equal(t, tt.body, string(body))
+		})
+	}
+}
@@ -0,0 +1,430 @@
+package validation
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"reflect"
+	"strconv"
+	"sync/atomic"
+	"unicode/utf8"
+
+	"github.com/lionelgarnier/validate-api-request/oas"
+)
+
+// BodyBackend is the implementation validating JSON request bodies against their schema
+type BodyBackend string
+
+const (
+	// BodyBackendReflective decodes bodies into maps and slices, then validates them
+	BodyBackendReflective BodyBackend = ""
+	// BodyBackendScan validates bodies while scanning them, without decoding them, for hot endpoints. Bodies it
+	// does not accept are validated again by the reflective backend, which reports their failures.
+	BodyBackendScan BodyBackend = "scan"
+)
+
+// WithBodyBackend sets the implementation validating JSON request bodies
+func WithBodyBackend(backend BodyBackend) Option {
+	return func(v *DefaultValidator) {
+		v.bodyBackend = backend
+	}
+}
+
+// scanRequestBody reports whether the scan backend accepts the JSON body of a request. Bodies of other media types,
+// decoded by a registered decoder, or checked by a body hook, which all need the decoded body, are not scanned.
+func (v *DefaultValidator) scanRequestBody(req *oas.OASRequest, content []byte, contentType string, mediaType oas.MediaType) bool {
+	if v.bodyBackend != BodyBackendScan || mediaType.Schema == nil || v.normalizeUnicode || v.readOnly != ReadOnlyIgnore || !isJSONMediaType(contentType) {
+		return false
+	}
+	if _, isProto := protoMessageName(mediaType); isProto {
+		return false
+	}
+	if reflect.ValueOf(v.decoder(contentType)).Pointer() != reflect.ValueOf(JSONDecoder).Pointer() || v.bodyHook(req) != nil {
+		return false
+	}
+	s := &jsonScanner{data: content, evaluations: req.Evaluations, shared: v.sharedEvaluations(req), ctx: req.Request.Context()}
+	if !v.scanBody(s, mediaType.Schema) {
+		// The body is validated again by the default backend, which counts its evaluations
+		if s.shared != nil {
+			s.shared.Add(int64(req.Evaluations - s.evaluations))
+		}
+		return false
+	}
+	req.Evaluations = s.evaluations
+	return true
+}
+
+// scanBody reports whether a JSON document is valid against the schema, validating it while scanning it. The scan
+// is stricter than the reflective validation: it does not accept the documents using keywords it does not support,
+// the values the reflective validation would coerce or record as deprecated, nor those exceeding its complexity
+// limits. Scans stop when the request is canceled.
+func (v *DefaultValidator) scanBody(s *jsonScanner, schema *oas.Schema) bool {
+	if !v.scanValue(s, schema, 0) {
+		return false
+	}
+	s.skipSpace()
+	return s.pos == len(s.data)
+}
+
+// scannable reports whether the scan backend supports the keywords of a schema, other schemas are validated by
+// the reflective backend
+func scannable(schema *oas.Schema) bool {
+	return !schema.Deprecated && schema.Discriminator == nil && schema.AllOf == nil && schema.OneOf == nil &&
+		schema.AnyOf == nil && schema.Not == nil && !schema.HasConst() && len(schema.PatternProperties) == 0 &&
+		schema.MinProperties == 0 && schema.MaxProperties == nil && len(schema.DependentRequired) == 0 &&
+		len(schema.DependentSchemas) == 0 && len(schema.PrefixItems) == 0 && schema.UnevaluatedProperties == nil &&
+		schema.UnevaluatedItems == nil && !schema.UniqueItems && (len(schema.Types) <= 1 || schema.Type != "")
+}
+
+// scanValue scans a value, reporting whether it is valid against the schema
+func (v *DefaultValidator) scanValue(s *jsonScanner, schema *oas.Schema, depth int) bool {
+	s.evaluations++
+	evaluations := s.evaluations
+	if s.shared != nil {
+		evaluations = int(s.shared.Add(1))
+	}
+	if depth >= v.maxDepth || v.maxEvaluations > 0 && evaluations > v.maxEvaluations {
+		return false
+	}
+	if s.evaluations%cancelCheckInterval == 0 && s.ctx != nil && s.ctx.Err() != nil {
+		return false
+	}
+	if schema.Ref != "" {
+		resolved, err := v.resolveSchemaReference(schema.Ref)
+		if err != nil {
+			return false
+		}
+		return v.scanValue(s, resolved, depth+1)
+	}
+	if !scannable(schema) {
+		return false
+	}
+
+	s.skipSpace()
+	if s.pos >= len(s.data) {
+		return false
+	}
+	if s.data[s.pos] == 'n' {
+		return s.literal("null") && (schema.Nullable || schema.Type == "null")
+	}
+
+	switch schema.Type {
+	case "string":
+		raw, ok := s.scanString()
+		if !ok {
+			return false
+		}
+		if schema.MinLength == nil && schema.MaxLength == nil && schema.Pattern == "" && schema.Enum == nil && schema.Format == "" {
+			return true
+		}
+		str, ok := unquote(raw)
+		return ok && v.validateString(str, schema, "") == nil
+	case "integer", "number":
+		raw, ok := s.scanNumber()
+		if !ok {
+			return false
+		}
+		if v.preciseNumbers {
+			return validateNumber(json.Number(raw), schema, "") == nil
+		}
+		number, err := strconv.ParseFloat(string(raw), 64)
+		return err == nil && validateNumber(number, schema, "") == nil
+	case "boolean":
+		return s.literal("true") || s.literal("false")
+	case "array":
+		return v.scanArray(s, schema, depth)
+	case "object", "":
+		return v.scanObject(s, schema, depth)
+	default:
+		return false
+	}
+}
+
+// scanArray scans an array, reporting whether it is valid against the schema
+func (v *DefaultValidator) scanArray(s *jsonScanner, schema *oas.Schema, depth int) bool {
+	if !s.consume('[') {
+		return false
+	}
+	var count uint64
+	s.skipSpace()
+	if !s.consume(']') {
+		for {
+			if schema.Items != nil {
+				if !v.scanValue(s, schema.Items, depth+1) {
+					return false
+				}
+			} else if !s.skipValue(v.maxDepth - depth) {
+				return false
+			}
+			count++
+			s.skipSpace()
+			if s.consume(']') {
+				break
+			}
+			if !s.consume(',') {
+				return false
+			}
+		}
+	}
+	return (schema.MinItems == nil || count >= *schema.MinItems) && (schema.MaxItems == nil || count <= *schema.MaxItems)
+}
+
+// scanObject scans an object, reporting whether it is valid against the schema. Required properties are tracked
+// in a bit set, so schemas requiring more than 64 properties are not scanned.
+func (v *DefaultValidator) scanObject(s *jsonScanner, schema *oas.Schema, depth int) bool {
+	if len(schema.Required) > 64 || !s.consume('{') {
+		return false
+	}
+	var seen uint64
+	s.skipSpace()
+	if !s.consume('}') {
+		for {
+			s.skipSpace()
+			raw, ok := s.scanString()
+			if !ok {
+				return false
+			}
+			name, ok := unquoteBytes(raw)
+			if !ok {
+				return false
+			}
+			s.skipSpace()
+			if !s.consume(':') {
+				return false
+			}
+			for i, required := range schema.Required {
+				if string(name) == required {
+					seen |= 1 << i
+				}
+			}
+
+			if property, exists := schema.Properties[string(name)]; exists {
+				if !v.scanValue(s, &property, depth+1) {
+					return false
+				}
+			} else if schema.AdditionalProperties != nil {
+				additional, allowed := additionalPropertiesSchema(schema.AdditionalProperties)
+				switch {
+				case !allowed:
+					return false
+				case additional != nil:
+					if !v.scanValue(s, additional, depth+1) {
+						return false
+					}
+				default:
+					if !s.skipValue(v.maxDepth - depth) {
+						return false
+					}
+				}
+			} else if !s.skipValue(v.maxDepth - depth) {
+				return false
+			}
+
+			s.skipSpace()
+			if s.consume('}') {
+				break
+			}
+			if !s.consume(',') {
+				return false
+			}
+		}
+	}
+	return seen == 1<<len(schema.Required)-1
+}
+
+// unquote returns the value of a scanned JSON string
+func unquote(raw []byte) (string, bool) {
+	content, ok := unquoteBytes(raw)
+	return string(content), ok
+}
+
+// unquoteBytes returns the content of a scanned JSON string. Strings without escapes are not copied, others are
+// decoded like by encoding/json, replacing invalid UTF-8 sequences.
+func unquoteBytes(raw []byte) ([]byte, bool) {
+	content := raw[1 : len(raw)-1]
+	if bytes.IndexByte(content, '\\') < 0 && utf8.Valid(content) {
+		return content, true
+	}
+	var str string
+	if err := json.Unmarshal(raw, &str); err != nil {
+		return nil, false
+	}
+	return []byte(str), true
+}
+
+// jsonScanner reads the tokens of a JSON document, checking its syntax
+type jsonScanner struct {
+	data        []byte
+	pos         int
+	evaluations int             // Schemas evaluated, counted like by the reflective validation
+	shared      *atomic.Int64   // Schemas evaluated by the concurrent stages of the request, nil when sequential
+	ctx         context.Context // Context of the request, whose cancellation stops the scan, nil for none
+}
+
+// skipSpace skips insignificant whitespace
+func (s *jsonScanner) skipSpace() {
+	for s.pos < len(s.data) {
+		switch s.data[s.pos] {
+		case ' ', '\t', '\n', '\r':
+			s.pos++
+		default:
+			return
+		}
+	}
+}
+
+// consume skips the next byte if it is c, and reports whether it did
+func (s *jsonScanner) consume(c byte) bool {
+	if s.pos < len(s.data) && s.data[s.pos] == c {
+		s.pos++
+		return true
+	}
+	return false
+}
+
+// literal skips the next literal if it is the given one, and reports whether it did
+func (s *jsonScanner) literal(literal string) bool {
+	if len(s.data)-s.pos < len(literal) || string(s.data[s.pos:s.pos+len(literal)]) != literal {
+		return false
+	}
+	s.pos += len(literal)
+	return true
+}
+
+// scanString returns the next string, quotes included
+func (s *jsonScanner) scanString() ([]byte, bool) {
+	start := s.pos
+	if !s.consume('"') {
+		return nil, false
+	}
+	for s.pos < len(s.data) {
+		c := s.data[s.pos]
+		switch {
+		case c == '"':
+			s.pos++
+			return s.data[start:s.pos], true
+		case c < 0x20:
+			return nil, false
+		case c == '\\':
+			if s.pos+1 >= len(s.data) {
+				return nil, false
+			}
+			switch s.data[s.pos+1] {
+			case '"', '\\', '/', 'b', 'f', 'n', 'r', 't':
+				s.pos += 2
+			case 'u':
+				if s.pos+6 > len(s.data) {
+					return nil, false
+				}
+				for _, h := range s.data[s.pos+2 : s.pos+6] {
+					if !isHexDigit(h) {
+						return nil, false
+					}
+				}
+				s.pos += 6
+			default:
+				return nil, false
+			}
+		default:
+			s.pos++
+		}
+	}
+	return nil, false
+}
+
+// scanNumber returns the next number
+func (s *jsonScanner) scanNumber() ([]byte, bool) {
+	start := s.pos
+	s.consume('-')
+	switch {
+	case s.consume('0'):
+	case s.digits() > 0:
+	default:
+		return nil, false
+	}
+	if s.consume('.') && s.digits() == 0 {
+		return nil, false
+	}
+	if s.consume('e') || s.consume('E') {
+		if !s.consume('+') {
+			s.consume('-')
+		}
+		if s.digits() == 0 {
+			return nil, false
+		}
+	}
+	return s.data[start:s.pos], true
+}
+
+// digits skips the next digits, and returns how many it skipped
+func (s *jsonScanner) digits() int {
+	start := s.pos
+	for s.pos < len(s.data) && s.data[s.pos] >= '0' && s.data[s.pos] <= '9' {
+		s.pos++
+	}
+	return s.pos - start
+}
+
+// skipValue skips the next value, nested up to depth levels
+func (s *jsonScanner) skipValue(depth int) bool {
+	if depth <= 0 {
+		return false
+	}
+	s.skipSpace()
+	if s.pos >= len(s.data) {
+		return false
+	}
+	switch c := s.data[s.pos]; {
+	case c == '"':
+		_, ok := s.scanString()
+		return ok
+	case c == '-' || c >= '0' && c <= '9':
+		_, ok := s.scanNumber()
+		return ok
+	case c == 't':
+		return s.literal("true")
+	case c == 'f':
+		return s.literal("false")
+	case c == 'n':
+		return s.literal("null")
+	case c == '[' || c == '{':
+		closing := byte(']')
+		if c == '{' {
+			closing = '}'
+		}
+		s.pos++
+		s.skipSpace()
+		if s.consume(closing) {
+			return true
+		}
+		for {
+			if c == '{' {
+				s.skipSpace()
+				if _, ok := s.scanString(); !ok {
+					return false
+				}
+				s.skipSpace()
+				if !s.consume(':') {
+					return false
+				}
+			}
+			if !s.skipValue(depth - 1) {
+				return false
+			}
+			s.skipSpace()
+			if s.consume(closing) {
+				return true
+			}
+			if !s.consume(',') {
+				return false
+			}
+		}
+	default:
+		return false
+	}
+}
+
+// isHexDigit reports whether c is a hexadecimal digit
+func isHexDigit(c byte) bool {
+	return c >= '0' && c <= '9' || c >= 'a' && c <= 'f' || c >= 'A' && c <= 'F'
+}
@@ -0,0 +1,155 @@
+package validation
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/lionelgarnier/validate-api-request/oas"
+	"github.com/stretchr/testify/assert"
+)
+
+const scanSpec = `{
+    "openapi": "3.0.0",
+    "paths": {
+        "/pets": {
+            "post": {
+                "requestBody": {"content": {"application/json": {"schema": {"$ref": "#/components/schemas/Pet"}}}},
+                "responses": {"201": {"description": "Created"}}
+            }
+        }
+    },
+    "components": {
+        "schemas": {
+            "Pet": {
+                "type": "object",
+                "required": ["name", "age"],
+                "properties": {
+                    "name": {"type": "string", "minLength": 1, "maxLength": 8},
+                    "age": {"type": "integer", "minimum": 0},
+                    "weight": {"type": "number", "nullable": true},
+                    "vaccinated": {"type": "boolean"},
+                    "tags": {"type": "array", "maxItems": 2, "items": {"type": "string", "enum": ["cat", "dog"]}},
+                    "owner": {"$ref": "#/components/schemas/Owner"},
+                    "nickname": {"type": "string", "deprecated": true},
+                    "extra": {}
+                },
+                "additionalProperties": false
+            },
+            "Owner": {
+                "type": "object",
+                "properties": {"email": {"type": "string", "format": "email"}},
+                "additionalProperties": {"type": "integer"}
+            }
+        }
+    }
+}`
+
+func TestScanBody(t *testing.T) {
+	manager := oas.NewOASManager(nil, oas.FixedSelector(map[string]string{"test": "test"}))
+	assert.NoError(t, manager.LoadAPI("test", []byte(scanSpec)))
+	spec, err := manager.GetApiSpec("test")
+	assert.NoError(t, err)
+	validator := NewValidator(spec).(*DefaultValidator)
+	schema := &oas.Schema{Ref: "#/components/schemas/Pet"}
+
+	tests := []struct {
+		name    string
+		body    string
+		scanned bool // Accepted by the scan, otherwise validated again by the reflective backend
+	}{
+		{"valid", `{"name": "Rex", "age": 3, "weight": null, "vaccinated": true, "tags": ["dog"], "extra": {"a": [1, "b"]}}`, true},
+		{"nested reference", `{"name": "Rex", "age": 3, "owner": {"email": "a@b.co", "visits": 4}}`, true},
+		{"escaped strings", `{"name": "Réx\n", "age": 3}`, true},
+		{"exponent", `{"name": "Rex", "age": 3e0}`, true},
+		{"missing required", `{"name": "Rex"}`, false},
+		{"too long", `{"name": "Rexanderson", "age": 3}`, false},
+		{"negative", `{"name": "Rex", "age": -1}`, false},
+		{"not an integer", `{"name": "Rex", "age": 3.5}`, false},
+		{"not nullable", `{"name": "Rex", "age": null}`, false},
+		{"enum", `{"name": "Rex", "age": 3, "tags": ["cow"]}`, false},
+		{"max items", `{"name": "Rex", "age": 3, "tags": ["cat", "dog", "cat"]}`, false},
+		{"additional property", `{"name": "Rex", "age": 3, "color": "red"}`, false},
+		{"additional property schema", `{"name": "Rex", "age": 3, "owner": {"visits": "many"}}`, false},
+		{"format", `{"name": "Rex", "age": 3, "owner": {"email": "nobody"}}`, false},
+		{"deprecated", `{"name": "Rex", "age": 3, "nickname": "R"}`, false},
+		{"string coerced", `{"name": "Rex", "age": "3"}`, false},
+		{"trailing data", `{"name": "Rex", "age": 3} {}`, false},
+		{"leading zero", `{"name": "Rex", "age": 03}`, false},
+		{"unterminated", `{"name": "Rex", "age": 3`, false},
+		{"control character", "{\"name\": \"R\tx\", \"age\": 3}", false},
+		{"invalid escape", `{"name": "R\x", "age": 3}`, false},
+		{"empty", ``, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scanned := validator.scanBody(&jsonScanner{data: []byte(tt.body)}, schema)
+			assert.Equal(t, tt.scanned, scanned)
+			if scanned {
+				// The scan never accepts a body the reflective validation rejects
+				value, err := JSONDecoder(strings.NewReader(tt.body))
+				assert.NoError(t, err)
+				assert.NoError(t, validator.validateSchema(value, schema, ""))
+			}
+		})
+	}
+}
+
+func TestBodyBackendScan(t *testing.T) {
+	manager := oas.NewOASManager(nil, oas.FixedSelector(map[string]string{"test": "test"}))
+	assert.NoError(t, manager.LoadAPI("test", []byte(scanSpec)))
+	spec, err := manager.GetApiSpec("test")
+	assert.NoError(t, err)
+
+	for _, body := range []string{
+		`{"name": "Rex", "age": 3}`,
+		`{"name": "Rex", "age": "3"}`,
+		`{"name": "Rex", "age": 3, "nickname": "R"}`,
+		`{"name": "Rex", "age": -1}`,
+		`{"name": "Rex"`,
+	} {
+		results := make(map[BodyBackend]*oas.OASRequest)
+		errs := make(map[BodyBackend]error)
+		for _, backend := range []BodyBackend{BodyBackendReflective, BodyBackendScan} {
+			r := httptest.NewRequest(http.MethodPost, "/pets", strings.NewReader(body))
+			r.Header.Set("Content-Type", "application/json")
+			req := oas.NewOASRequest(r)
+			_, errs[backend] = NewValidator(spec, WithBodyBackend(backend)).ValidateRequest(req)
+			results[backend] = req
+		}
+		assert.Equal(t, errs[BodyBackendReflective], errs[BodyBackendScan], body)
+		assert.Equal(t, results[BodyBackendReflective].Coercions, results[BodyBackendScan].Coercions, body)
+		assert.Equal(t, results[BodyBackendReflective].Deprecations, results[BodyBackendScan].Deprecations, body)
+	}
+}
+
+func BenchmarkBodyBackend(b *testing.B) {
+	manager := oas.NewOASManager(nil, oas.FixedSelector(map[string]string{"test": "test"}))
+	if err := manager.LoadAPI("test", []byte(scanSpec)); err != nil {
+		b.Fatal(err)
+	}
+	spec, _ := manager.GetApiSpec("test")
+	body := []byte(`{"name": "Rex", "age": 3, "weight": 4.5, "vaccinated": true, "tags": ["dog", "cat"], "owner": {"visits": 4}}`)
+	schema := &oas.Schema{Ref: "#/components/schemas/Pet"}
+
+	b.Run("reflective", func(b *testing.B) {
+		validator := NewValidator(spec).(*DefaultValidator)
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			value, _ := JSONDecoder(strings.NewReader(string(body)))
+			if validator.validateSchema(value, schema, "") != nil {
+				b.Fatal("body rejected")
+			}
+		}
+	})
+	b.Run("scan", func(b *testing.B) {
+		validator := NewValidator(spec).(*DefaultValidator)
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			if !validator.scanBody(&jsonScanner{data: body}, schema) {
+				b.Fatal("body rejected")
+			}
+		}
+	})
+}
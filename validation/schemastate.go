@@ -0,0 +1,234 @@
+package validation
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"sync/atomic"
+
+	"github.com/lionelgarnier/validate-api-request/oas"
+	"github.com/lionelgarnier/validate-api-request/pkg/helpers"
+)
+
+// DefaultMaxDepth is the default maximum nesting of schemas evaluated for a value
+const DefaultMaxDepth = 256
+
+// cancelCheckInterval is the number of schemas evaluated between two checks of the cancellation of the request
+const cancelCheckInterval = 64
+
+// schemaState tracks the schemas being evaluated for a value, to stop circular references
+type schemaState struct {
+	depth        int
+	evaluations  int             // Schemas evaluated, for the request when validating one
+	shared       *atomic.Int64   // Schemas evaluated by the concurrent stages of the request, nil when sequential
+	exceeded     *SchemaError    // Complexity or cancellation failure, which stops the evaluation
+	unresolved   *SchemaError    // Last failure to resolve a $ref, which says nothing of the value
+	ctx          context.Context // Context of the request being validated, nil when validating a value alone
+	readOnly     ReadOnlyMode    // Handling of readOnly properties, ignored unless validating a request body
+	response     bool            // Whether the value is a response body, which must not carry writeOnly properties
+	collectAll   bool            // Whether evaluation continues after a failure
+	foldNames    bool            // Whether patternProperties match property names case-insensitively, e.g. header names
+	location     string          // Pointer of the schema being evaluated, relative to the root schema until a $ref is followed
+	refs         map[string]bool // References being evaluated, by instance path
+	dispatched   map[string]bool // Instance paths whose discriminator has been resolved
+	coercions    []oas.Coercion  // String values accepted as another type
+	deprecations []string        // Instance paths of the values matching a deprecated schema
+	tracking     int             // Schemas being evaluated with unevaluatedProperties or unevaluatedItems
+	evaluated    []evaluation    // Properties and items evaluated since tracking started
+}
+
+// evaluation is a property, or an item by index, of the value at an instance path, evaluated by a schema
+type evaluation struct {
+	path string
+	name string
+}
+
+// newSchemaState returns the state of a new schema evaluation
+func newSchemaState() *schemaState {
+	return &schemaState{
+		refs:       make(map[string]bool),
+		dispatched: make(map[string]bool),
+	}
+}
+
+// exceed records that the evaluation exceeds its budget, failing it whatever the outcome of the schemas being
+// evaluated, e.g. of the other branches of oneOf and anyOf
+func (s *schemaState) exceed(path string, format string, args ...interface{}) error {
+	s.exceeded = complexityError(path, format, args...)
+	return s.exceeded
+}
+
+// canceled reports whether the request being validated is canceled, checked every cancelCheckInterval evaluations,
+// in which case the evaluation fails whatever the outcome of the schemas being evaluated
+func (s *schemaState) canceled(path string) error {
+	if s.ctx == nil || s.evaluations%cancelCheckInterval != 0 || s.ctx.Err() == nil {
+		return nil
+	}
+	s.exceeded = newSchemaError(path, "validation canceled: %v", s.ctx.Err())
+	s.exceeded.Category = CategoryCanceled
+	return s.exceeded
+}
+
+// complexityError builds the failure of a value whose validation exceeds the complexity limits
+func complexityError(path string, format string, args ...interface{}) *SchemaError {
+	err := newSchemaError(path, "validation complexity exceeded: "+format, args...)
+	err.Category = CategoryComplexityExceeded
+	return err
+}
+
+// unresolvable records the failure to resolve a reference
+func (s *schemaState) unresolvable(path string, err error) error {
+	s.unresolved = newSchemaError(path, "%v", err)
+	return s.unresolved
+}
+
+// aborted returns the failure of an evaluation that did not decide whether the value matches: its complexity
+// limits are exceeded, the request is canceled, or a reference resolved since the given one could not be
+func (s *schemaState) aborted(err error, unresolved *SchemaError) error {
+	if err == nil {
+		return nil
+	}
+	if s.unresolved != unresolved {
+		return s.unresolved
+	}
+	schemaErrs, ok := err.(SchemaErrors)
+	if !ok {
+		schemaErr, isSchemaErr := err.(*SchemaError)
+		if !isSchemaErr {
+			return nil
+		}
+		schemaErrs = SchemaErrors{schemaErr}
+	}
+	for _, schemaErr := range schemaErrs {
+		if schemaErr.Category == CategoryComplexityExceeded || schemaErr.Category == CategoryCanceled {
+			return schemaErr
+		}
+	}
+	return nil
+}
+
+// enterRef marks a reference as being evaluated at the instance path.
+// It returns false if the reference is already being evaluated there.
+func (s *schemaState) enterRef(ref, path string) bool {
+	key := path + "|" + ref
+	if s.refs[key] {
+		return false
+	}
+	s.refs[key] = true
+	return true
+}
+
+// leaveRef marks a reference as evaluated at the instance path
+func (s *schemaState) leaveRef(ref, path string) {
+	delete(s.refs, path+"|"+ref)
+}
+
+// dispatch evaluates the schema selected by a discriminator.
+// The selected schema referencing the discriminated one (e.g. through allOf) is not a circular reference,
+// so references are tracked again from the selected schema.
+func (s *schemaState) dispatch(path string, evaluate func() error) error {
+	refs := s.refs
+	s.refs = make(map[string]bool)
+	s.dispatched[path] = true
+	defer func() {
+		s.refs = refs
+		delete(s.dispatched, path)
+	}()
+
+	return evaluate()
+}
+
+// enterLocation moves the schema location to a subschema of the current schema, or to a referenced schema
+// for a pointer starting with `#`. It returns the previous location to restore.
+func (s *schemaState) enterLocation(tokens ...string) string {
+	previous := s.location
+	if len(tokens) == 1 && strings.HasPrefix(tokens[0], "#") {
+		s.location = tokens[0]
+		return previous
+	}
+	for _, token := range tokens {
+		s.location += "/" + helpers.EscapeJSONPointer(token)
+	}
+	return previous
+}
+
+// locate sets the schema location of the failures not located by a nested schema
+func (s *schemaState) locate(err error) {
+	switch e := err.(type) {
+	case *SchemaError:
+		if e.SchemaPointer == "" {
+			e.SchemaPointer = s.location
+		}
+	case SchemaErrors:
+		for _, schemaErr := range e {
+			s.locate(schemaErr)
+		}
+	}
+}
+
+// fail records a failure when collecting every failure, it reports whether evaluation stops at the failure instead
+func (s *schemaState) fail(errs *SchemaErrors, err error) bool {
+	if !s.collectAll {
+		return true
+	}
+	errs.add(err)
+	return false
+}
+
+// coerce records a string value accepted as another type at the instance path, once per path
+func (s *schemaState) coerce(path string, value interface{}, typ string) {
+	str, ok := value.(string)
+	if !ok {
+		return
+	}
+	for _, coercion := range s.coercions {
+		if coercion.Path == path {
+			return
+		}
+	}
+	s.coercions = append(s.coercions, oas.Coercion{Path: path, Value: str, Type: typ})
+}
+
+// deprecate records a value matching a deprecated schema at the instance path, once per path
+func (s *schemaState) deprecate(path string) {
+	if !slices.Contains(s.deprecations, path) {
+		s.deprecations = append(s.deprecations, path)
+	}
+}
+
+// evaluate records a property, or an item, of the value at the instance path as evaluated, when a schema being
+// evaluated has unevaluatedProperties or unevaluatedItems
+func (s *schemaState) evaluate(path, name string) {
+	if s.tracking > 0 {
+		s.evaluated = append(s.evaluated, evaluation{path: path, name: name})
+	}
+}
+
+// stopTracking forgets the evaluated properties and items once no schema being evaluated needs them, and returns
+// the outcome of the evaluation
+func (s *schemaState) stopTracking(err error) error {
+	if s.tracking == 0 {
+		s.evaluated = s.evaluated[:0]
+	}
+	return err
+}
+
+// schemaMark is the number of coercions, deprecations and evaluations recorded when a subschema starts being evaluated
+type schemaMark struct {
+	coercions    int
+	deprecations int
+	evaluated    int
+}
+
+// mark returns the records to restore if the subschema about to be evaluated does not match
+func (s *schemaState) mark() schemaMark {
+	return schemaMark{coercions: len(s.coercions), deprecations: len(s.deprecations), evaluated: len(s.evaluated)}
+}
+
+// discard forgets the coercions, deprecations and evaluations recorded since the mark, by a subschema that did
+// not match
+func (s *schemaState) discard(mark schemaMark) {
+	s.coercions = s.coercions[:mark.coercions]
+	s.deprecations = s.deprecations[:mark.deprecations]
+	s.evaluated = s.evaluated[:mark.evaluated]
+}
@@ -0,0 +1,74 @@
+package validation
+
+import (
+	"net/http"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/lionelgarnier/validate-api-request/oas"
+)
+
+// ScopeResolver returns the scopes granted to the credentials a request presents for an oauth2 or openIdConnect
+// security scheme, e.g. the `scope` claim of its access token once verified by the application
+type ScopeResolver func(r *http.Request, scheme string) []string
+
+// WithScopeResolver checks the scopes listed by the oauth2 and openIdConnect security requirements against the
+// scopes granted to the requests. Requests whose credentials lack a required scope are forbidden rather than
+// unauthorized. Without a resolver, scopes are not checked.
+func WithScopeResolver(resolver ScopeResolver) Option {
+	return func(v *DefaultValidator) {
+		v.scopeResolver = resolver
+	}
+}
+
+// missingScopes returns the scopes of a satisfied security requirement that are not granted to the request, by
+// scheme name
+func (v *DefaultValidator) missingScopes(r *http.Request, secReq oas.SecurityRequirement) map[string][]string {
+	if v.scopeResolver == nil {
+		return nil
+	}
+
+	var missing map[string][]string
+	for name, scopes := range secReq {
+		if len(scopes) == 0 {
+			continue
+		}
+		granted := v.scopeResolver(r, name)
+		for _, scope := range scopes {
+			if !slices.Contains(granted, scope) {
+				if missing == nil {
+					missing = make(map[string][]string)
+				}
+				missing[name] = append(missing[name], scope)
+			}
+		}
+	}
+	return missing
+}
+
+// insufficientScopeChallenges returns the Bearer challenges of the schemes missing scopes (RFC 6750 section 3.1)
+func (v *DefaultValidator) insufficientScopeChallenges(missing map[string][]string) []string {
+	names := make([]string, 0, len(missing))
+	for name := range missing {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	challenges := make([]string, 0, len(names))
+	for _, name := range names {
+		challenges = append(challenges, "Bearer realm="+quoteAuthParam(v.apiSpec.Name)+
+			", scope="+quoteAuthParam(strings.Join(missing[name], " "))+`, error="insufficient_scope"`)
+	}
+	return challenges
+}
+
+// formatMissingScopes formats missing scopes by scheme name, sorted to be stable
+func formatMissingScopes(missing map[string][]string) string {
+	parts := make([]string, 0, len(missing))
+	for name, scopes := range missing {
+		parts = append(parts, name+" requires "+strings.Join(scopes, ", "))
+	}
+	sort.Strings(parts)
+	return strings.Join(parts, "; ")
+}
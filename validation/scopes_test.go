@@ -0,0 +1,80 @@
+package validation
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/lionelgarnier/validate-api-request/oas"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestScopeResolver(t *testing.T) {
+	manager := oas.NewOASManager(nil, oas.FixedSelector(map[string]string{"test": "test"}))
+	err := manager.LoadAPI("test", []byte(`{
+        "openapi": "3.0.0",
+        "info": {"title": "Test API", "version": "1.0.0"},
+        "paths": {
+            "/admin": {"get": {"security": [{"oauth": ["admin", "read"]}]}},
+            "/either": {"get": {"security": [{"oauth": ["admin"]}, {"key": []}]}},
+            "/open": {"get": {"security": [{"oauth": ["admin"]}, {}]}}
+        },
+        "components": {
+            "securitySchemes": {
+                "oauth": {"type": "oauth2", "flows": {}},
+                "key": {"type": "apiKey", "in": "header", "name": "X-API-Key"}
+            }
+        }
+    }`))
+	assert.NoError(t, err)
+	spec, _ := manager.GetApiSpec("test")
+
+	// The granted scopes are listed by the X-Scopes header of the test requests
+	resolver := func(r *http.Request, scheme string) []string {
+		assert.Equal(t, "oauth", scheme)
+		return strings.Fields(r.Header.Get("X-Scopes"))
+	}
+
+	tests := []struct {
+		name          string
+		path          string
+		headers       map[string]string
+		expectedError string
+		category      Category
+		challenges    []string
+	}{
+		{name: "Granted scopes", path: "/admin", headers: map[string]string{"Authorization": "Bearer token", "X-Scopes": "read admin write"}},
+		{name: "Missing scope", path: "/admin", headers: map[string]string{"Authorization": "Bearer token", "X-Scopes": "read"},
+			expectedError: "insufficient scope: oauth requires admin", category: CategoryForbidden,
+			challenges: []string{`Bearer realm="test", scope="admin", error="insufficient_scope"`}},
+		{name: "Missing credentials", path: "/admin",
+			expectedError: "request does not satisfy any security requirements", category: CategoryUnauthorized},
+		{name: "Other requirement satisfied", path: "/either", headers: map[string]string{"Authorization": "Bearer token", "X-API-Key": "secret"}},
+		{name: "Anonymous access", path: "/open", headers: map[string]string{"Authorization": "Bearer token"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequest(http.MethodGet, tt.path, nil)
+			assert.NoError(t, err)
+			for key, value := range tt.headers {
+				req.Header.Set(key, value)
+			}
+
+			ok, err := NewValidator(spec, WithScopeResolver(resolver)).ValidateRequest(oas.NewOASRequest(req))
+			if tt.expectedError == "" {
+				assert.True(t, ok)
+				assert.NoError(t, err)
+				return
+			}
+			assert.False(t, ok)
+			assert.ErrorContains(t, err, tt.expectedError)
+			assert.Equal(t, tt.category, CategoryOf(err))
+			if tt.challenges != nil {
+				var validationErr *ValidationError
+				assert.ErrorAs(t, err, &validationErr)
+				assert.Equal(t, tt.challenges, validationErr.Challenges)
+			}
+		})
+	}
+}
@@ -3,6 +3,7 @@ package validation
 import (
 	"fmt"
 	"net/http"
+	"slices"
 	"strings"
 
 	"github.com/lionelgarnier/validate-api-request/oas"
@@ -25,29 +26,99 @@ func (v *DefaultValidator) ValidateSecurity(req *oas.OASRequest) (bool, error) {
 		securityRequirements = v.apiSpec.Security
 	}
 
+	// Operations whose tags require security are not anonymous, even when their spec allows it
+	if tag, required := v.taggedWith(req, func(rule TagRule) bool { return rule.RequireSecurity }); required {
+		securityRequirements = slices.DeleteFunc(slices.Clone(securityRequirements), func(secReq oas.SecurityRequirement) bool {
+			return len(secReq) == 0
+		})
+		if len(securityRequirements) == 0 {
+			return false, &ValidationError{
+				Stage:       StageSecurity,
+				Message:     fmt.Sprintf("operations tagged '%s' require security, but the operation declares none", tag),
+				SpecPointer: operationPointer(req),
+			}
+		}
+	}
+
+	req.Security = nil
 	if len(securityRequirements) == 0 {
 		// No security requirements; request is valid
 		return true, nil
 	}
 
-	// Check if the request satisfies at least one security requirement
+	// Check if the request satisfies at least one security requirement, preferring the requirements identifying
+	// its credentials to the anonymous one
+	anonymous := false
+	var missingScopes map[string][]string
 	for _, secReq := range securityRequirements {
+		if len(secReq) == 0 {
+			anonymous = true
+			continue
+		}
 		if v.validateSecurityRequirement(req.Request, secReq) {
+			// Credentials lacking the scopes of the requirement satisfy it only once no other requirement does
+			if missing := v.missingScopes(req.Request, secReq); missing != nil {
+				if missingScopes == nil {
+					missingScopes = missing
+				}
+				continue
+			}
 			// At least one requirement satisfied
+			req.Security = secReq
 			return true, nil
 		}
 	}
+	if anonymous {
+		return true, nil
+	}
 
-	return false, fmt.Errorf("request does not satisfy any security requirements")
+	securityPointer := "#/security"
+	if operation.Security != nil {
+		securityPointer = operationPointer(req) + "/security"
+	}
+	// Authenticated requests lacking scopes are forbidden rather than unauthorized
+	if missingScopes != nil {
+		return false, &ValidationError{
+			Stage:       StageSecurity,
+			Category:    CategoryForbidden,
+			Message:     "insufficient scope: " + formatMissingScopes(missingScopes),
+			Challenges:  v.insufficientScopeChallenges(missingScopes),
+			SpecPointer: securityPointer,
+		}
+	}
+	// Tokens without the surface of their bearer format are reported apart from missing credentials
+	if err := v.malformedBearerToken(req.Request, securityRequirements); err != nil {
+		return false, &ValidationError{
+			Stage:       StageSecurity,
+			Category:    CategoryMalformedCredentials,
+			Message:     "malformed bearer token",
+			Err:         err,
+			Challenges:  v.challenges(req.Request, securityRequirements),
+			SpecPointer: securityPointer,
+		}
+	}
+	return false, &ValidationError{
+		Stage:       StageSecurity,
+		Message:     "request does not satisfy any security requirements",
+		Challenges:  v.challenges(req.Request, securityRequirements),
+		SpecPointer: securityPointer,
+	}
 }
 
 func (v *DefaultValidator) validateSecurityRequirement(r *http.Request, secReq map[string][]string) bool {
 	for secSchemeName := range secReq {
+		if v.apiSpec.Components == nil {
+			return false
+		}
 		secScheme, exists := v.apiSpec.Components.SecuritySchemes[secSchemeName]
 		if !exists {
 			// Security scheme not defined
 			return false
 		}
+		secScheme, err := v.apiSpec.ResolveSecurityScheme(secScheme)
+		if err != nil {
+			return false
+		}
 
 		switch secScheme.Type {
 		case "apiKey":
@@ -100,7 +171,8 @@ func (v *DefaultValidator) validateHTTPSecurity(r *http.Request, secScheme *oas.
 	case "basic":
 		return strings.HasPrefix(authHeader, "Basic ")
 	case "bearer":
-		return strings.HasPrefix(authHeader, "Bearer ")
+		token, ok := bearerToken(r)
+		return ok && checkBearerFormat(token, secScheme) == nil
 	case "digest":
 		return strings.HasPrefix(authHeader, "Digest ")
 	case "apikey":
@@ -0,0 +1,31 @@
+package validation
+
+import (
+	"github.com/lionelgarnier/validate-api-request/oas"
+)
+
+// TagRule configures the validation of the operations declaring a tag, the way teams already group operations
+type TagRule struct {
+	// SkipBody skips the validation of the request bodies, e.g. for operations tagged `internal`
+	SkipBody bool `json:"skipBody,omitempty" yaml:"skipBody,omitempty"`
+	// RequireSecurity rejects the requests of operations without security requirements, or allowing anonymous
+	// requests with an empty requirement, e.g. for operations tagged `admin`
+	RequireSecurity bool `json:"requireSecurity,omitempty" yaml:"requireSecurity,omitempty"`
+}
+
+// WithTagRules sets the rules of the operations declaring the tags, by tag
+func WithTagRules(rules map[string]TagRule) Option {
+	return func(v *DefaultValidator) {
+		v.tagRules = rules
+	}
+}
+
+// taggedWith returns the first tag of the operation of a request whose rule matches
+func (v *DefaultValidator) taggedWith(req *oas.OASRequest, matches func(rule TagRule) bool) (string, bool) {
+	for _, tag := range req.Tags() {
+		if rule, exists := v.tagRules[tag]; exists && matches(rule) {
+			return tag, true
+		}
+	}
+	return "", false
+}
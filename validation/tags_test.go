@@ -0,0 +1,90 @@
+package validation
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/lionelgarnier/validate-api-request/oas"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTagRules(t *testing.T) {
+	manager := oas.NewOASManager(nil, oas.FixedSelector(map[string]string{"test": "test"}))
+	err := manager.LoadAPI("test", []byte(`{
+        "openapi": "3.0.0",
+        "info": {"title": "Test API", "version": "1.0.0"},
+        "paths": {
+            "/events": {
+                "post": {
+                    "tags": ["internal"],
+                    "requestBody": {"content": {"application/json": {"schema": {"type": "object", "required": ["name"]}}}}
+                }
+            },
+            "/admin/users": {
+                "delete": {"tags": ["users", "admin"]}
+            },
+            "/admin/keys": {
+                "delete": {"tags": ["admin"], "security": [{}, {"apiKey": []}]}
+            },
+            "/admin/audit": {
+                "get": {"tags": ["admin"], "security": [{"apiKey": []}]}
+            }
+        },
+        "components": {
+            "securitySchemes": {"apiKey": {"type": "apiKey", "in": "header", "name": "X-API-Key"}}
+        }
+    }`))
+	assert.NoError(t, err)
+	spec, _ := manager.GetApiSpec("test")
+
+	rules := map[string]TagRule{
+		"internal": {SkipBody: true},
+		"admin":    {RequireSecurity: true},
+	}
+
+	tests := []struct {
+		name          string
+		method        string
+		path          string
+		body          string
+		apiKey        string
+		rules         map[string]TagRule
+		tags          []string
+		expectedError string
+	}{
+		{name: "Body validated without rules", method: http.MethodPost, path: "/events", body: `{}`, tags: []string{"internal"}, expectedError: "required property is missing"},
+		{name: "Body skipped by tag", method: http.MethodPost, path: "/events", body: `{}`, rules: rules, tags: []string{"internal"}},
+		{name: "Unsecured operation allowed without rules", method: http.MethodDelete, path: "/admin/users", tags: []string{"users", "admin"}},
+		{name: "Unsecured operation rejected by tag", method: http.MethodDelete, path: "/admin/users", rules: rules, tags: []string{"users", "admin"}, expectedError: "operations tagged 'admin' require security"},
+		{name: "Anonymous requirement allowed without rules", method: http.MethodDelete, path: "/admin/keys", tags: []string{"admin"}},
+		{name: "Anonymous requirement ignored by tag", method: http.MethodDelete, path: "/admin/keys", rules: rules, tags: []string{"admin"}, expectedError: "request does not satisfy any security requirements"},
+		{name: "Credentials satisfying the other requirement", method: http.MethodDelete, path: "/admin/keys", apiKey: "secret", rules: rules, tags: []string{"admin"}},
+		{name: "Secured operation", method: http.MethodGet, path: "/admin/audit", apiKey: "secret", rules: rules, tags: []string{"admin"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequest(tt.method, tt.path, strings.NewReader(tt.body))
+			assert.NoError(t, err)
+			req.Header.Set("Content-Type", "application/json")
+			if tt.apiKey != "" {
+				req.Header.Set("X-API-Key", tt.apiKey)
+			}
+
+			oasRequest := oas.NewOASRequest(req)
+			assert.Nil(t, oasRequest.Tags())
+
+			ok, err := NewValidator(spec, WithTagRules(tt.rules)).ValidateRequest(oasRequest)
+			assert.Equal(t, tt.tags, oasRequest.Tags())
+			if tt.expectedError == "" {
+				assert.True(t, ok)
+				assert.NoError(t, err)
+			} else {
+				assert.False(t, ok)
+				assert.Error(t, err)
+				assert.Contains(t, err.Error(), tt.expectedError)
+			}
+		})
+	}
+}
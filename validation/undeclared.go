@@ -0,0 +1,100 @@
+package validation
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/lionelgarnier/validate-api-request/oas"
+)
+
+// StandardHeaders are the request headers accepted without being declared by strict header checks: the headers of
+// HTTP itself, content negotiation, caching, CORS, proxies and tracing. Names ending with `*` are prefixes.
+var StandardHeaders = []string{
+	"Host", "User-Agent", "Accept", "Accept-*", "Authorization", "Cache-Control", "Connection", "Content-Encoding",
+	"Content-Length", "Content-Type", "Cookie", "Date", "DNT", "Expect", "If-*", "Keep-Alive", "Origin", "Pragma",
+	"Range", "Referer", "TE", "Trailer", "Transfer-Encoding", "Upgrade", "Via", "Sec-*",
+	"Access-Control-Request-*", "Forwarded", "X-Forwarded-*", "X-Real-Ip",
+	"Traceparent", "Tracestate", "Baggage", "B3", "X-B3-*", "Uber-Trace-Id", "X-Amzn-Trace-Id",
+	"X-Cloud-Trace-Context", "X-Request-Id", "X-Correlation-Id", "Sentry-Trace",
+}
+
+// WithStrictHeaders rejects the request headers not declared by the operation, as header parameters or API key
+// security schemes, except the StandardHeaders and the allowed ones (names ending with `*` are prefixes)
+func WithStrictHeaders(enabled bool, allowed ...string) Option {
+	return func(v *DefaultValidator) {
+		v.strictHeaders = enabled
+		v.allowedHeaders = allowed
+	}
+}
+
+// checkUndeclaredHeaders reports the request headers neither declared nor allowed, in a stable order
+func (v *DefaultValidator) checkUndeclaredHeaders(req *oas.OASRequest, parameters []oas.Parameter) ValidationErrors {
+	var errs ValidationErrors
+	for _, name := range slices.Sorted(maps.Keys(req.Request.Header)) {
+		if headerAllowed(name, StandardHeaders) || headerAllowed(name, v.allowedHeaders) ||
+			v.headerDeclared(name, parameters) || v.securityHeader(name) || v.attestationHeader(name) {
+			continue
+		}
+		errs.add(&ValidationError{
+			Stage:       StageParameters,
+			Message:     fmt.Sprintf("header '%s' is not declared", name),
+			SpecPointer: operationPointer(req),
+		})
+	}
+	return errs
+}
+
+// attestationHeader reports whether a header carries the attestations of the validator
+func (v *DefaultValidator) attestationHeader(name string) bool {
+	return v.attestation != nil && strings.EqualFold(name, v.attestation.header)
+}
+
+// headerAllowed reports whether a header name is in a list of names and prefixes ending with `*`
+func headerAllowed(name string, allowed []string) bool {
+	for _, entry := range allowed {
+		if prefix, isPrefix := strings.CutSuffix(entry, "*"); isPrefix {
+			if len(name) >= len(prefix) && strings.EqualFold(name[:len(prefix)], prefix) {
+				return true
+			}
+		} else if strings.EqualFold(name, entry) {
+			return true
+		}
+	}
+	return false
+}
+
+// headerDeclared reports whether a header is declared by a header parameter, by name or as a member of a family
+func (v *DefaultValidator) headerDeclared(name string, parameters []oas.Parameter) bool {
+	for i := range parameters {
+		param := &parameters[i]
+		if param.In != "header" {
+			continue
+		}
+		if strings.EqualFold(param.Name, name) {
+			return true
+		}
+		if schema := v.patternPropertiesSchema(param); schema != nil && matchesPatternProperty(schema, name, true) {
+			return true
+		}
+		if pattern, err := headerFamilyPattern(param); err == nil && pattern != nil && pattern.MatchString(name) {
+			return true
+		}
+	}
+	return false
+}
+
+// securityHeader reports whether a header carries the key of an API key security scheme of the spec
+func (v *DefaultValidator) securityHeader(name string) bool {
+	if v.apiSpec.Components == nil {
+		return false
+	}
+	for _, scheme := range v.apiSpec.Components.SecuritySchemes {
+		resolved, err := v.apiSpec.ResolveSecurityScheme(scheme)
+		if err == nil && resolved.Type == "apiKey" && resolved.In == "header" && strings.EqualFold(resolved.Name, name) {
+			return true
+		}
+	}
+	return false
+}
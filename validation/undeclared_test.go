@@ -0,0 +1,67 @@
+package validation
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/lionelgarnier/validate-api-request/oas"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStrictHeaders(t *testing.T) {
+	manager := oas.NewOASManager(nil, oas.FixedSelector(map[string]string{"test": "test"}))
+	err := manager.LoadAPI("test", []byte(`{
+        "openapi": "3.0.0",
+        "info": {"title": "Test API", "version": "1.0.0"},
+        "paths": {
+            "/pets": {
+                "parameters": [{"name": "X-Tenant", "in": "header", "schema": {"type": "string"}}],
+                "get": {
+                    "parameters": [
+                        {"name": "x-page-size", "in": "header", "schema": {"type": "integer"}},
+                        {"name": "X-Meta-*", "in": "header", "schema": {"type": "string"}}
+                    ]
+                }
+            }
+        },
+        "components": {
+            "securitySchemes": {"apiKey": {"type": "apiKey", "in": "header", "name": "X-API-Key"}}
+        }
+    }`))
+	assert.NoError(t, err)
+	spec, _ := manager.GetApiSpec("test")
+
+	tests := []struct {
+		name          string
+		headers       http.Header
+		strict        bool
+		allowed       []string
+		expectedError string
+	}{
+		{name: "Declared headers", headers: http.Header{"X-Tenant": {"acme"}, "X-Page-Size": {"10"}, "X-Meta-Owner": {"bob"}}, strict: true},
+		{name: "Standard headers", headers: http.Header{"User-Agent": {"curl"}, "Accept-Language": {"en"}, "Traceparent": {"00-1-2-01"}, "X-B3-Traceid": {"1"}}, strict: true},
+		{name: "Security scheme header", headers: http.Header{"X-Api-Key": {"secret"}}, strict: true},
+		{name: "Undeclared header", headers: http.Header{"X-Debug": {"1"}}, strict: true, expectedError: "header 'X-Debug' is not declared"},
+		{name: "Allowed header", headers: http.Header{"X-Debug": {"1"}}, strict: true, allowed: []string{"x-debug"}},
+		{name: "Allowed prefix", headers: http.Header{"X-Debug-Level": {"1"}}, strict: true, allowed: []string{"X-Debug-*"}},
+		{name: "Disabled", headers: http.Header{"X-Debug": {"1"}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequest(http.MethodGet, "/pets", nil)
+			assert.NoError(t, err)
+			req.Header = tt.headers
+
+			ok, err := NewValidator(spec, WithStrictHeaders(tt.strict, tt.allowed...)).ValidateParameters(oas.NewOASRequest(req))
+			if tt.expectedError == "" {
+				assert.True(t, ok)
+				assert.NoError(t, err)
+			} else {
+				assert.False(t, ok)
+				assert.ErrorContains(t, err, tt.expectedError)
+				assert.Equal(t, "#/paths/~1pets/get", Violations(err)[0].Spec)
+			}
+		})
+	}
+}
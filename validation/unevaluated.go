@@ -0,0 +1,91 @@
+package validation
+
+import (
+	"maps"
+	"slices"
+	"strconv"
+
+	"github.com/lionelgarnier/validate-api-request/oas"
+)
+
+// evaluateUnevaluated validates a value against a schema with unevaluatedProperties or unevaluatedItems: the
+// properties and items that no other keyword of the schema or of its matching subschemas (allOf, anyOf, oneOf,
+// $ref, discriminator, dependentSchemas) evaluated are validated against them, e.g. to lock down objects composed
+// with allOf.
+func (v *DefaultValidator) evaluateUnevaluated(value interface{}, schema *oas.Schema, path string, state *schemaState) error {
+	start := len(state.evaluated)
+	state.tracking++
+	err := v.evaluateKeywords(value, schema, path, state)
+	state.tracking--
+
+	var errs SchemaErrors
+	if err != nil && state.fail(&errs, err) {
+		return state.stopTracking(err)
+	}
+
+	evaluated := make(map[string]bool)
+	for _, record := range state.evaluated[start:] {
+		if record.path == path {
+			evaluated[record.name] = true
+		}
+	}
+	switch typed := value.(type) {
+	case map[string]interface{}:
+		if schema.UnevaluatedProperties == nil {
+			break
+		}
+		unevaluatedSchema, allowed := additionalPropertiesSchema(schema.UnevaluatedProperties)
+		for _, propName := range slices.Sorted(maps.Keys(typed)) {
+			if evaluated[propName] || declaresProperty(schema, propName) {
+				continue
+			}
+			state.evaluate(path, propName)
+			if !allowed {
+				err := newSchemaError(propertyPath(path, propName), "unevaluated property is not allowed")
+				if state.fail(&errs, err) {
+					return state.stopTracking(err)
+				}
+				continue
+			}
+			if unevaluatedSchema != nil {
+				if err := v.evaluateSubschema(typed[propName], unevaluatedSchema, propertyPath(path, propName), state, "unevaluatedProperties"); err != nil && state.fail(&errs, err) {
+					return state.stopTracking(err)
+				}
+			}
+		}
+	case []interface{}:
+		if schema.UnevaluatedItems == nil {
+			break
+		}
+		unevaluatedSchema, allowed := additionalPropertiesSchema(schema.UnevaluatedItems)
+		for i, item := range typed {
+			if evaluated[strconv.Itoa(i)] || i < len(schema.PrefixItems) || schema.Items != nil {
+				continue
+			}
+			state.evaluate(path, strconv.Itoa(i))
+			if !allowed {
+				err := newSchemaError(indexPath(path, i), "unevaluated item is not allowed")
+				if state.fail(&errs, err) {
+					return state.stopTracking(err)
+				}
+				continue
+			}
+			if unevaluatedSchema != nil {
+				if err := v.evaluateSubschema(item, unevaluatedSchema, indexPath(path, i), state, "unevaluatedItems"); err != nil && state.fail(&errs, err) {
+					return state.stopTracking(err)
+				}
+			}
+		}
+	}
+	return state.stopTracking(errs.errOrNil())
+}
+
+// declaresProperty reports whether a property is evaluated by the object keywords of a schema: its properties,
+// patternProperties and additionalProperties. They count as evaluated even next to allOf, anyOf or oneOf, whose
+// sibling keywords are not evaluated.
+func declaresProperty(schema *oas.Schema, propName string) bool {
+	if _, exists := schema.Properties[propName]; exists {
+		return true
+	}
+	return schema.AdditionalProperties != nil || matchesPatternProperty(schema, propName, false)
+}
@@ -0,0 +1,88 @@
+package validation
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/lionelgarnier/validate-api-request/oas"
+)
+
+func TestValidateUnevaluated(t *testing.T) {
+	manager := oas.NewOASManager(nil, oas.FixedSelector(map[string]string{"test": "test"}))
+	assert.NoError(t, manager.LoadAPI("test", []byte(`{
+		"openapi": "3.1.0",
+		"info": {"title": "Test API", "version": "1.0.0"},
+		"paths": {},
+		"components": {
+			"schemas": {
+				"Base": {"type": "object", "properties": {"id": {"type": "integer"}}},
+				"Pet": {
+					"allOf": [{"$ref": "#/components/schemas/Base"}, {"properties": {"name": {"type": "string"}}}],
+					"unevaluatedProperties": false
+				},
+				"Contact": {
+					"anyOf": [
+						{"type": "object", "required": ["email"], "properties": {"email": {"type": "string"}}},
+						{"type": "object", "required": ["phone"], "properties": {"phone": {"type": "string"}}}
+					],
+					"unevaluatedProperties": false
+				},
+				"Shape": {
+					"oneOf": [
+						{"type": "object", "required": ["radius"], "properties": {"radius": {"type": "number"}, "side": {"type": "number"}}},
+						{"type": "object", "required": ["side", "kind"], "properties": {"side": {"type": "number"}, "kind": {"const": "square"}}}
+					],
+					"unevaluatedProperties": false
+				},
+				"Labels": {
+					"allOf": [{"$ref": "#/components/schemas/Base"}],
+					"unevaluatedProperties": {"type": "string"}
+				},
+				"Row": {
+					"allOf": [{"type": "array", "prefixItems": [{"type": "string"}]}],
+					"unevaluatedItems": false
+				}
+			}
+		}
+	}`)))
+	spec, _ := manager.GetApiSpec("test")
+	validator := NewValidator(spec).(*DefaultValidator)
+
+	tests := []struct {
+		name          string
+		schema        string
+		value         string
+		expectedError string
+	}{
+		{name: "Composed properties", schema: "Pet", value: `{"id": 1, "name": "Rex"}`},
+		{name: "Property of no subschema", schema: "Pet", value: `{"id": 1, "name": "Rex", "owner": "Tom"}`, expectedError: "owner: unevaluated property is not allowed"},
+		{name: "Composed properties still validated", schema: "Pet", value: `{"id": "one"}`, expectedError: "id: expected integer"},
+		{name: "Every matching anyOf schema", schema: "Contact", value: `{"email": "a@example.com", "phone": "555"}`},
+		{name: "Property of no anyOf schema", schema: "Contact", value: `{"email": "a@example.com", "fax": "555"}`, expectedError: "fax: unevaluated property is not allowed"},
+		{name: "Only the matching oneOf schema", schema: "Shape", value: `{"radius": 1}`},
+		{name: "Property of a failing oneOf schema", schema: "Shape", value: `{"side": 1, "kind": "circle", "radius": 2}`, expectedError: "kind: unevaluated property is not allowed"},
+		{name: "Unevaluated properties schema", schema: "Labels", value: `{"id": 1, "color": "red"}`},
+		{name: "Invalid unevaluated property", schema: "Labels", value: `{"id": 1, "size": 2}`, expectedError: "size: expected string"},
+		{name: "Composed items", schema: "Row", value: `["a"]`},
+		{name: "Item of no subschema", schema: "Row", value: `["a", "b"]`, expectedError: "[1]: unevaluated item is not allowed"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var value interface{}
+			assert.NoError(t, json.Unmarshal([]byte(tt.value), &value))
+			err := validator.validateSchema(value, &oas.Schema{Ref: "#/components/schemas/" + tt.schema}, "")
+			if tt.expectedError == "" {
+				assert.NoError(t, err)
+				return
+			}
+			assert.EqualError(t, err, tt.expectedError)
+		})
+	}
+
+	// Nested schemas see the properties evaluated by the schemas they compose
+	nested := oas.Schema{AllOf: []oas.Schema{{Ref: "#/components/schemas/Pet"}}, UnevaluatedProperties: false}
+	assert.True(t, validator.ValidateSchema(map[string]interface{}{"id": 1.0}, &nested))
+}
@@ -0,0 +1,22 @@
+package validation
+
+import (
+	"golang.org/x/text/unicode/norm"
+)
+
+// WithUnicodeNormalization normalizes string values to NFC before their length, pattern, enum and format checks,
+// so that values sent in decomposed form, e.g. "e" followed by a combining accent, match like their composed form
+// Lengths then count characters rather than bytes, except for binary strings.
+func WithUnicodeNormalization(enabled bool) Option {
+	return func(v *DefaultValidator) {
+		v.normalizeUnicode = enabled
+	}
+}
+
+// normalizeString returns a string value in NFC form, and other values unchanged
+func normalizeString(value interface{}) interface{} {
+	if str, ok := value.(string); ok {
+		return norm.NFC.String(str)
+	}
+	return value
+}
@@ -0,0 +1,71 @@
+package validation
+
+import (
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/lionelgarnier/validate-api-request/oas"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUnicodeNormalization(t *testing.T) {
+	manager := oas.NewOASManager(nil, oas.FixedSelector(map[string]string{"test": "test"}))
+	err := manager.LoadAPI("test", []byte(`{
+        "openapi": "3.0.0",
+        "info": {"title": "Test API", "version": "1.0.0"},
+        "paths": {
+            "/pets": {
+                "get": {
+                    "parameters": [
+                        {"name": "name", "in": "query", "schema": {"type": "string", "maxLength": 4}},
+                        {"name": "tag", "in": "query", "schema": {"type": "string", "enum": ["café", "thé"]}},
+                        {"name": "city", "in": "query", "schema": {"type": "string", "pattern": "^[a-zé]+$"}},
+                        {"name": "label", "in": "query", "schema": {"type": "string", "maxLength": 3}}
+                    ]
+                }
+            }
+        }
+    }`))
+	assert.NoError(t, err)
+	spec, _ := manager.GetApiSpec("test")
+
+	decomposed := "cafe\u0301" // "café" with a combining acute accent
+
+	tests := []struct {
+		name      string
+		parameter string
+		value     string
+		normalize bool
+		valid     bool
+	}{
+		{name: "Length in bytes", parameter: "label", value: "thé", valid: false},
+		{name: "Length in characters", parameter: "label", value: "thé", normalize: true, valid: true},
+		{name: "Length of decomposed value", parameter: "name", valid: false},
+		{name: "Length of normalized value", parameter: "name", normalize: true, valid: true},
+		{name: "Enum of decomposed value", parameter: "tag", valid: false},
+		{name: "Enum of normalized value", parameter: "tag", normalize: true, valid: true},
+		{name: "Pattern of decomposed value", parameter: "city", valid: false},
+		{name: "Pattern of normalized value", parameter: "city", normalize: true, valid: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			value := tt.value
+			if value == "" {
+				value = decomposed
+			}
+			query := url.Values{tt.parameter: {value}}
+			req, err := http.NewRequest(http.MethodGet, "/pets?"+query.Encode(), nil)
+			assert.NoError(t, err)
+
+			ok, err := NewValidator(spec, WithUnicodeNormalization(tt.normalize)).ValidateParameters(oas.NewOASRequest(req))
+			assert.Equal(t, tt.valid, ok)
+			if tt.valid {
+				assert.NoError(t, err)
+			} else {
+				assert.Error(t, err)
+			}
+		})
+	}
+}
@@ -3,9 +3,17 @@ package validation
 import (
 	"encoding/json"
 	"fmt"
+	"maps"
+	"net/http"
+	"reflect"
+	"slices"
+	"strconv"
 	"strings"
+	"sync"
+	"unicode/utf8"
 
 	"github.com/lionelgarnier/validate-api-request/oas"
+	"github.com/lionelgarnier/validate-api-request/pkg/clock"
 	"github.com/lionelgarnier/validate-api-request/pkg/helpers"
 )
 
@@ -18,20 +26,97 @@ type Validator interface {
 	ValidateParameters(req *oas.OASRequest) (bool, error)
 	ValidateRequestBody(req *oas.OASRequest) (bool, error)
 	ValidateSecurity(req *oas.OASRequest) (bool, error)
+	ValidateResponse(req *oas.OASRequest, status int, header http.Header, body []byte) (bool, error)
+	ValidateResponseStream(req *oas.OASRequest, status int, header http.Header) (bool, error)
+	RewriteResponse(req *oas.OASRequest, status int, header http.Header, body []byte) ([]byte, error)
 	ValidateSchema(value interface{}, schema *oas.Schema) bool
 	SetApiSpec(apiSpec *oas.APISpec)
 }
 
 // DefaultValidator implements the Validator interface
 type DefaultValidator struct {
-	apiSpec *oas.APISpec
+	apiSpec    *oas.APISpec
+	clock      clock.Clock
+	maxDepth   int
+	collectAll bool
+	graphQL    bool
+
+	normalizeUnicode   bool                     // Normalize string values to NFC before checking them
+	numberStrictness   NumberStrictness         // Numeric strings rejected before they are parsed
+	booleanMode        BooleanMode              // Strings accepted as booleans
+	pinParameters      bool                     // Reject parameters sent in undeclared locations or with conflicting values
+	strictHeaders      bool                     // Reject headers not declared by the operation
+	allowedHeaders     []string                 // Headers accepted without being declared, besides StandardHeaders
+	tagRules           map[string]TagRule       // Rules of the operations declaring a tag, by tag
+	decoders           map[string]BodyDecoder   // Body decoders by media type
+	messages           map[string]*ProtoMessage // Protobuf message descriptors by fully qualified name
+	verdicts           *VerdictCache            // Verdicts of identical idempotent requests
+	bodyHooks          map[string]BodyHook      // Checks of request bodies beyond their schema, by operationId or name
+	stageWorkers       *StageWorkers            // Workers validating large bodies concurrently with parameters
+	stageEvaluations   sync.Map                 // Evaluations shared by the concurrent stages of requests, by *oas.OASRequest
+	bodyBackend        BodyBackend              // Implementation validating JSON request bodies
+	maxEvaluations     int                      // Schemas evaluated per request, 0 for no limit
+	maxBinaryBody      int64                    // Size of binary request bodies in bytes, 0 for no limit
+	missingContentType MissingContentType       // Handling of request bodies without Content-Type
+	attestation        *Attestation             // Signature of accepted requests and trust of previous hops
+	enforceSunset      bool                     // Reject the requests to operations past their sunset
+	maxBodyBuffer      int64                    // Size of the request bodies buffered to be validated, 0 for no limit
+	readOnly           ReadOnlyMode             // Handling of the readOnly properties of request bodies
+	unknownFormats     UnknownFormatMode        // Validation of the strings of formats neither built in nor registered
+	preciseNumbers     bool                     // Decode the numbers of JSON bodies as json.Number
+	scopeResolver      ScopeResolver            // Scopes granted to the credentials of the requests
+}
+
+// Option configures optional DefaultValidator behavior
+type Option func(*DefaultValidator)
+
+// WithClock sets the clock used for access tracking and time based checks
+func WithClock(clk clock.Clock) Option {
+	return func(v *DefaultValidator) {
+		v.clock = clk
+	}
+}
+
+// WithMaxDepth sets the maximum nesting of schemas evaluated for a value
+func WithMaxDepth(depth int) Option {
+	return func(v *DefaultValidator) {
+		v.maxDepth = depth
+	}
+}
+
+// WithMaxEvaluations sets the maximum number of schemas evaluated to validate a request, 0 for no limit, against
+// payloads engineered to multiply the work of oneOf and anyOf. Bodies validated concurrently with their parameters
+// have a budget of their own.
+func WithMaxEvaluations(evaluations int) Option {
+	return func(v *DefaultValidator) {
+		v.maxEvaluations = evaluations
+	}
+}
+
+// WithCollectAll makes validation run every stage and schema branch and return all the failures as ValidationErrors,
+// instead of stopping at the first failure
+func WithCollectAll(collectAll bool) Option {
+	return func(v *DefaultValidator) {
+		v.collectAll = collectAll
+	}
 }
 
 // NewValidator returns a new Validator
-func NewValidator(apiSpec *oas.APISpec) Validator {
-	return &DefaultValidator{
-		apiSpec: apiSpec,
+func NewValidator(apiSpec *oas.APISpec, opts ...Option) Validator {
+	v := &DefaultValidator{
+		apiSpec:   apiSpec,
+		clock:     clock.Real(),
+		maxDepth:  DefaultMaxDepth,
+		decoders:  DefaultDecoders(),
+		messages:  make(map[string]*ProtoMessage),
+		bodyHooks: make(map[string]BodyHook),
 	}
+
+	for _, opt := range opts {
+		opt(v)
+	}
+
+	return v
 }
 
 // SetApiSpec sets the current API spec to validate against
@@ -46,19 +131,102 @@ func (v *DefaultValidator) ValidateRequest(req *oas.OASRequest) (bool, error) {
 		return false, fmt.Errorf("no API spec selected, call SetCurrentAPI first")
 	}
 
+	if v.attestation == nil {
+		return v.validateCachedRequest(req)
+	}
+	now := v.clock.Now()
+	if v.attestation.carried(req) {
+		// The operation is resolved first, as attestations sign its declared header and cookie parameters
+		if ok, _ := v.resolveOperation(req); ok && v.attestation.trusted(req, v.apiSpec, v.signedParameters(req), now, v.maxBodyBuffer) {
+			ok, err := v.validateTrustedRequest(req)
+			v.attestation.attest(req, v.apiSpec, v.signedParameters(req), ok, true, now, v.maxBodyBuffer)
+			return ok, err
+		}
+	}
+	ok, err := v.validateCachedRequest(req)
+	v.attestation.attest(req, v.apiSpec, v.signedParameters(req), ok, false, now, v.maxBodyBuffer)
+	return ok, err
+}
+
+// validateTrustedRequest validates what the attestation of a request validated by a previous hop does not sign: its
+// security, as credentials are not signed, then its undeclared headers and parameter locations when checked, as
+// headers are not all signed either
+func (v *DefaultValidator) validateTrustedRequest(req *oas.OASRequest) (bool, error) {
+	if v.strictHeaders || v.pinParameters {
+		if ok, err := v.validateUnsignedParameters(req); !ok {
+			return false, stageError(StageParameters, err)
+		}
+	}
+	if ok, err := v.ValidateSecurity(req); !ok {
+		return false, stageError(StageSecurity, err)
+	}
+	return true, nil
+}
+
+// validateCachedRequest validates a request, or replays the cached verdict of an identical one
+func (v *DefaultValidator) validateCachedRequest(req *oas.OASRequest) (bool, error) {
+	if v.verdicts == nil {
+		return v.validateRequest(req)
+	}
+	key := v.verdicts.key(v.apiSpec, req.Request)
+	if key == "" {
+		return v.validateRequest(req)
+	}
+	if cached, exists := v.verdicts.verdicts.Get(key); exists {
+		return v.replay(req, cached)
+	}
+	ok, err := v.validateRequest(req)
+	v.verdicts.verdicts.Set(key, record(req, ok, err))
+	return ok, err
+}
+
+// resolveOperation validates the path and method of a request, resolving its operation
+func (v *DefaultValidator) resolveOperation(req *oas.OASRequest) (bool, error) {
 	if ok, err := v.ValidateRequestPath(req); !ok {
-		return false, err
+		return false, stageError(StagePath, err)
 	}
 	if ok, err := v.ValidateRequestMethod(req); !ok {
-		return false, err
+		return false, stageError(StageMethod, err)
 	}
-	if ok, err := v.ValidateParameters(req); !ok {
+	return true, nil
+}
+
+// validateRequest validates the path, method, parameters, body and security of a request
+func (v *DefaultValidator) validateRequest(req *oas.OASRequest) (bool, error) {
+	if ok, err := v.resolveOperation(req); !ok {
 		return false, err
 	}
-	if ok, err := v.ValidateRequestBody(req); !ok {
-		return false, err
+
+	// The operation is known, the remaining stages can all report their failures
+	var errs ValidationErrors
+	stages := []struct {
+		stage    Stage
+		validate func(req *oas.OASRequest) (bool, error)
+	}{
+		{StageParameters, v.ValidateParameters},
+		{StageBody, v.ValidateRequestBody},
+		{StageSecurity, v.ValidateSecurity},
+	}
+	// Pinning reads the body fields while checking the parameters, so they are validated one after the other
+	if !v.pinParameters && v.stageWorkers.acquire(req.Request) {
+		// Parameters and body are independent, the body is validated while the parameters are
+		body := v.validateBodyConcurrently(req)
+		defer body()
+		stages[1].validate = func(*oas.OASRequest) (bool, error) { return body() }
+	}
+	for _, s := range stages {
+		// A canceled request is not worth validating further, even to collect its failures
+		if err := canceled(req, s.stage); err != nil {
+			return false, err
+		}
+		if ok, err := s.validate(req); !ok {
+			if !v.collectAll {
+				return false, stageError(s.stage, err)
+			}
+			errs.add(stageError(s.stage, err))
+		}
 	}
-	if ok, err := v.ValidateSecurity(req); !ok {
+	if err := errs.errOrNil(); err != nil {
 		return false, err
 	}
 	return true, nil
@@ -66,56 +234,203 @@ func (v *DefaultValidator) ValidateRequest(req *oas.OASRequest) (bool, error) {
 
 // ValidateSchema validates the request body against the schema
 func (v *DefaultValidator) ValidateSchema(value interface{}, schema *oas.Schema) bool {
-	// Handle discriminator first
-	if schema.Discriminator != nil {
-		resolvedSchema, err := v.resolveDiscriminator(value, schema)
-		if err != nil {
-			return false
+	return v.validateSchema(value, schema, "") == nil
+}
+
+// validateSchema validates a value against the schema, locating failures at the given instance path
+func (v *DefaultValidator) validateSchema(value interface{}, schema *oas.Schema, path string) error {
+	state := newSchemaState()
+	state.collectAll = v.collectAll
+	return v.evaluateSchema(value, schema, path, state)
+}
+
+// validateRequestSchema validates a value of a request at a location against the schema, recording the string
+// values accepted as another type in the coercions of the request, and the values matching deprecated schemas in
+// its deprecations
+func (v *DefaultValidator) validateRequestSchema(req *oas.OASRequest, location string, value interface{}, schema *oas.Schema, path string) error {
+	state := newSchemaState()
+	state.collectAll = v.collectAll
+	state.evaluations = req.Evaluations
+	state.shared = v.sharedEvaluations(req)
+	state.ctx = req.Request.Context()
+	if location == "body" {
+		state.readOnly = v.readOnly
+	}
+	// Header names are case-insensitive, as are the names of the headers gathered by patternProperties
+	state.foldNames = location == "header"
+	err := v.evaluateSchema(value, schema, path, state)
+	req.Evaluations = state.evaluations
+	for _, coercion := range state.coercions {
+		coercion.Location = location
+		req.Coercions = append(req.Coercions, coercion)
+	}
+	for _, path := range state.deprecations {
+		deprecate(req, location, path)
+	}
+	return err
+}
+
+// evaluateSchema validates a value against the schema, tracking the schemas being evaluated in state
+func (v *DefaultValidator) evaluateSchema(value interface{}, schema *oas.Schema, path string, state *schemaState) (err error) {
+	defer func() { state.locate(err) }()
+	defer func() {
+		if state.exceeded != nil {
+			err = state.exceeded
 		}
-		return v.ValidateSchema(value, resolvedSchema)
+	}()
+
+	if state.depth >= v.maxDepth {
+		return complexityError(path, "schema nesting exceeds maximum depth %d", v.maxDepth)
 	}
+	state.evaluations++
+	evaluations := state.evaluations
+	if state.shared != nil {
+		evaluations = int(state.shared.Add(1))
+	}
+	if v.maxEvaluations > 0 && evaluations > v.maxEvaluations {
+		return state.exceed(path, "schema evaluations exceed maximum %d", v.maxEvaluations)
+	}
+	if err := state.canceled(path); err != nil {
+		return err
+	}
+	state.depth++
+	defer func() { state.depth-- }()
 
 	// Resolve the schema reference if necessary
 	if schema.Ref != "" {
+		if !state.enterRef(schema.Ref, path) {
+			// Circular reference: the value is already being validated against this schema
+			return nil
+		}
+		defer state.leaveRef(schema.Ref, path)
+
 		resolvedSchema, err := v.resolveSchemaReference(schema.Ref)
 		if err != nil {
-			return false
+			return state.unresolvable(path, err)
 		}
-		schema = resolvedSchema
+		return v.evaluateSubschema(value, resolvedSchema, path, state, schema.Ref)
+	}
+
+	if schema.Deprecated {
+		state.deprecate(path)
+	}
+
+	if schema.UnevaluatedProperties != nil || schema.UnevaluatedItems != nil {
+		return v.evaluateUnevaluated(value, schema, path, state)
+	}
+	return v.evaluateKeywords(value, schema, path, state)
+}
+
+// evaluateKeywords validates a value against the keywords of a resolved schema, but unevaluatedProperties and
+// unevaluatedItems which depend on all the others
+func (v *DefaultValidator) evaluateKeywords(value interface{}, schema *oas.Schema, path string, state *schemaState) error {
+	// The value must not match the negated schema, whose coercions and deprecations are never kept
+	if schema.Not != nil {
+		negated := schema.Not
+		// An untyped negated schema constrains the values of the type of the schema, e.g. strings not matching a pattern
+		if negated.Ref == "" && negated.Type == "" && len(negated.Types) == 0 && schema.Type != "" {
+			typed := *negated
+			typed.Type = schema.Type
+			negated = &typed
+		}
+		mark, unresolved := state.mark(), state.unresolved
+		err := v.evaluateSubschema(value, negated, path, state, "not")
+		state.discard(mark)
+		// Failing the negated schema matches not, unless the failure is not about the value
+		if abortErr := state.aborted(err, unresolved); abortErr != nil {
+			return abortErr
+		}
+		if err == nil {
+			notErr := newSchemaError(path, "value must not match the schema of not")
+			notErr.SchemaPointer = state.location + "/not"
+			return notErr
+		}
+	}
+
+	// Handle discriminator once per value, the selected schema usually extends the current one
+	if schema.Discriminator != nil && !state.dispatched[path] {
+		resolvedSchema, err := v.resolveDiscriminator(value, schema)
+		if err != nil {
+			return newSchemaError(path, "%v", err)
+		}
+		return state.dispatch(path, func() error {
+			return v.evaluateSchema(value, resolvedSchema, path, state)
+		})
 	}
 
 	if schema.AllOf != nil {
-		for _, subSchema := range schema.AllOf {
+		var errs SchemaErrors
+		for i, subSchema := range schema.AllOf {
 			schemaCopy := subSchema
-			if !v.ValidateSchema(value, &schemaCopy) {
-				return false
+			if err := v.evaluateSubschema(value, &schemaCopy, path, state, "allOf", strconv.Itoa(i)); err != nil && state.fail(&errs, err) {
+				return err
 			}
 		}
-		return true
+		return errs.errOrNil()
 	}
 
 	if schema.OneOf != nil {
 		validCount := 0
-		for _, subSchema := range schema.OneOf {
+		var lastErr error
+		for i, subSchema := range schema.OneOf {
 			schemaCopy := subSchema
-			if v.ValidateSchema(value, &schemaCopy) {
+			mark := state.mark()
+			if err := v.evaluateSubschema(value, &schemaCopy, path, state, "oneOf", strconv.Itoa(i)); err != nil {
+				state.discard(mark)
+				lastErr = err
+			} else {
 				validCount++
 			}
 		}
-		return validCount == 1
+		switch {
+		case validCount == 1:
+			return nil
+		case validCount == 0 && len(schema.OneOf) == 1:
+			return lastErr
+		case validCount == 0:
+			return newSchemaError(path, "value does not match any schema of oneOf")
+		default:
+			return newSchemaError(path, "value matches %d schemas of oneOf, expected exactly one", validCount)
+		}
 	}
 
 	if schema.AnyOf != nil {
-		for _, subSchema := range schema.AnyOf {
+		// Every matching schema evaluates properties and items for unevaluatedProperties and unevaluatedItems
+		matched := false
+		var lastErr error
+		for i, subSchema := range schema.AnyOf {
 			schemaCopy := subSchema
-			if v.ValidateSchema(value, &schemaCopy) {
-				return true
+			mark := state.mark()
+			err := v.evaluateSubschema(value, &schemaCopy, path, state, "anyOf", strconv.Itoa(i))
+			if err == nil {
+				if state.tracking == 0 {
+					return nil
+				}
+				matched = true
+				continue
 			}
+			state.discard(mark)
+			lastErr = err
+		}
+		switch {
+		case matched:
+			return nil
+		case len(schema.AnyOf) == 1:
+			return lastErr
+		default:
+			return newSchemaError(path, "value does not match any schema of anyOf")
 		}
-		return false
 	}
 
-	return v.ValidateSchemaType(value, schema)
+	return v.evaluateSchemaType(value, schema, path, state)
+}
+
+// evaluateSubschema evaluates a subschema located by keyword tokens under the current schema, or by a $ref
+func (v *DefaultValidator) evaluateSubschema(value interface{}, schema *oas.Schema, path string, state *schemaState, tokens ...string) error {
+	previous := state.enterLocation(tokens...)
+	defer func() { state.location = previous }()
+
+	return v.evaluateSchema(value, schema, path, state)
 }
 
 // GetRequestOperation returns the operation for a given request
@@ -125,7 +440,7 @@ func (v *DefaultValidator) GetRequestOperation(req *oas.OASRequest) (*oas.Operat
 		return nil, err
 	}
 
-	pathItem := pathCache.Item
+	pathItem := req.PathItem
 	method := strings.ToUpper(req.Request.Method)
 
 	// Look for route & method in spec
@@ -138,12 +453,12 @@ func (v *DefaultValidator) GetRequestOperation(req *oas.OASRequest) (*oas.Operat
 }
 
 // validateArray validates an array value against the schema
-func (v *DefaultValidator) validateArray(value interface{}, schema *oas.Schema) bool {
+func (v *DefaultValidator) validateArray(value interface{}, schema *oas.Schema, path string, state *schemaState) error {
 	// Resolve the schema reference if necessary
 	if schema.Ref != "" {
 		resolvedSchema, err := v.resolveSchemaReference(schema.Ref)
 		if err != nil {
-			return false
+			return newSchemaError(path, "%v", err)
 		}
 		schema = resolvedSchema
 	}
@@ -160,47 +475,53 @@ func (v *DefaultValidator) validateArray(value interface{}, schema *oas.Schema)
 		// Otherwise, assert it as a slice of interfaces
 		arr, ok = value.([]interface{})
 		if !ok {
-			return false
+			return newSchemaError(path, "expected array")
 		}
 	}
 
 	if schema.MinItems != nil && uint64(len(arr)) < *schema.MinItems {
-		return false
+		return newSchemaError(path, "array must have at least %d items", *schema.MinItems)
 	}
 	if schema.MaxItems != nil && uint64(len(arr)) > *schema.MaxItems {
-		return false
+		return newSchemaError(path, "array must have at most %d items", *schema.MaxItems)
 	}
 	if schema.UniqueItems {
 		if !helpers.UniqueItems(arr) {
-			return false
+			return newSchemaError(path, "array items must be unique")
 		}
 	}
 
-	for _, item := range arr {
-		if schema.Items.Ref != "" {
-			resolvedSchema, err := v.resolveSchemaReference(schema.Items.Ref)
-			if err != nil {
-				return false
-			}
-			if !v.ValidateSchema(item, resolvedSchema) {
-				return false
-			}
-		} else {
-			if !v.ValidateSchema(item, schema.Items) {
-				return false
-			}
+	if schema.Items == nil && len(schema.PrefixItems) == 0 {
+		return nil
+	}
+
+	// Items are validated by position against prefixItems, then against items
+	var errs SchemaErrors
+	for i, item := range arr {
+		var err error
+		if i < len(schema.PrefixItems) || schema.Items != nil {
+			state.evaluate(path, strconv.Itoa(i))
+		}
+		switch {
+		case i < len(schema.PrefixItems):
+			err = v.evaluateSubschema(item, &schema.PrefixItems[i], indexPath(path, i), state, "prefixItems", strconv.Itoa(i))
+		case schema.Items != nil:
+			err = v.evaluateSubschema(item, schema.Items, indexPath(path, i), state, "items")
+		}
+		if err != nil && state.fail(&errs, err) {
+			return err
 		}
 	}
-	return true
+	return errs.errOrNil()
 }
 
 // validateObject validates an object value against the schema
-func (v *DefaultValidator) validateObject(value interface{}, schema *oas.Schema) bool {
+func (v *DefaultValidator) validateObject(value interface{}, schema *oas.Schema, path string, state *schemaState) error {
 	// Resolve the schema reference if necessary
 	if schema.Ref != "" {
 		resolvedSchema, err := v.resolveSchemaReference(schema.Ref)
 		if err != nil {
-			return false
+			return newSchemaError(path, "%v", err)
 		}
 		schema = resolvedSchema
 	}
@@ -216,67 +537,214 @@ func (v *DefaultValidator) validateObject(value interface{}, schema *oas.Schema)
 		// Otherwise, assert it as a slice of interfaces
 		obj, ok = value.(map[string]interface{})
 		if !ok {
-			return false
+			return newSchemaError(path, "expected object")
 		}
 	}
 
-	for propName, propSchema := range schema.Properties {
+	var errs SchemaErrors
+	for _, propName := range schema.Required {
+		if _, exists := obj[propName]; !exists {
+			if v.exemptRequired(schema, propName, state) {
+				continue
+			}
+			err := newSchemaError(propertyPath(path, propName), "required property is missing")
+			if state.fail(&errs, err) {
+				return err
+			}
+		}
+	}
+	if err := v.validateDependencies(obj, schema, path, state); err != nil && state.fail(&errs, err) {
+		return err
+	}
+
+	// Properties are evaluated in a stable order so the reported failures are too
+	for _, propName := range slices.Sorted(maps.Keys(schema.Properties)) {
 		propValue, exists := obj[propName]
 		if !exists {
-			if helpers.Contains(schema.Required, propName) {
-				return false
-			}
 			continue
 		}
 
-		if propSchema.Ref != "" {
-			resolvedSchema, err := v.resolveSchemaReference(propSchema.Ref)
-			if err != nil {
-				return false
+		state.evaluate(path, propName)
+		schemaCopy := schema.Properties[propName]
+		if err := v.forbiddenProperty(&schemaCopy, propertyPath(path, propName), state); err != nil {
+			if state.fail(&errs, err) {
+				return err
 			}
-			if !v.ValidateSchema(propValue, resolvedSchema) {
-				return false
+			continue
+		}
+		if err := v.evaluateSubschema(propValue, &schemaCopy, propertyPath(path, propName), state, "properties", propName); err != nil && state.fail(&errs, err) {
+			return err
+		}
+	}
+
+	if schema.MinProperties > 0 && uint64(len(obj)) < schema.MinProperties {
+		err := newSchemaError(path, "object must have at least %d properties", schema.MinProperties)
+		if state.fail(&errs, err) {
+			return err
+		}
+	}
+	if schema.MaxProperties != nil && uint64(len(obj)) > *schema.MaxProperties {
+		err := newSchemaError(path, "object must have at most %d properties", *schema.MaxProperties)
+		if state.fail(&errs, err) {
+			return err
+		}
+	}
+
+	propNames := slices.Sorted(maps.Keys(obj))
+	for _, pattern := range slices.Sorted(maps.Keys(schema.PatternProperties)) {
+		for _, propName := range propNames {
+			if !matchesPropertyPattern(propName, pattern, state.foldNames) {
+				continue
 			}
-		} else {
-			if !v.ValidateSchema(propValue, &propSchema) {
-				return false
+			state.evaluate(path, propName)
+			schemaCopy := schema.PatternProperties[pattern]
+			if err := v.evaluateSubschema(obj[propName], &schemaCopy, propertyPath(path, propName), state, "patternProperties", pattern); err != nil && state.fail(&errs, err) {
+				return err
 			}
 		}
 	}
 
 	if schema.AdditionalProperties != nil {
-		for propName := range obj {
-			if _, exists := schema.Properties[propName]; !exists {
-				additionalPropertiesSchema, ok := schema.AdditionalProperties.(*oas.Schema)
-				if !ok {
-					return false
+		additionalPropertiesSchema, allowed := additionalPropertiesSchema(schema.AdditionalProperties)
+		for _, propName := range propNames {
+			if _, exists := schema.Properties[propName]; exists || matchesPatternProperty(schema, propName, state.foldNames) {
+				continue
+			}
+			state.evaluate(path, propName)
+			if !allowed {
+				err := newSchemaError(propertyPath(path, propName), "additional property is not allowed")
+				if state.fail(&errs, err) {
+					return err
 				}
-				if !v.ValidateSchema(obj[propName], additionalPropertiesSchema) {
-					return false
+				continue
+			}
+			if additionalPropertiesSchema != nil {
+				if err := v.evaluateSubschema(obj[propName], additionalPropertiesSchema, propertyPath(path, propName), state, "additionalProperties"); err != nil && state.fail(&errs, err) {
+					return err
 				}
 			}
 		}
 	}
 
-	return true
+	return errs.errOrNil()
+}
+
+// matchesPatternProperty reports whether the property name matches one of the schema patternProperties,
+// case-insensitively when fold is set, e.g. for header names
+func matchesPatternProperty(schema *oas.Schema, propName string, fold bool) bool {
+	for pattern := range schema.PatternProperties {
+		if matchesPropertyPattern(propName, pattern, fold) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchesPropertyPattern reports whether a property name matches a patternProperties pattern, case-insensitively
+// when fold is set
+func matchesPropertyPattern(propName, pattern string, fold bool) bool {
+	if fold {
+		pattern = "(?i)" + pattern
+	}
+	return helpers.MatchPattern(propName, pattern)
+}
+
+// additionalPropertiesSchema returns the value schema of an additionalProperties keyword and whether additional properties are allowed
+func additionalPropertiesSchema(additionalProperties interface{}) (*oas.Schema, bool) {
+	switch ap := additionalProperties.(type) {
+	case bool:
+		return nil, ap
+	case *oas.Schema:
+		return ap, true
+	case oas.Schema:
+		return &ap, true
+	default:
+		return nil, false
+	}
 }
 
 // validateParameterType validates the parameter value against the expected type
 func (v *DefaultValidator) ValidateSchemaType(value interface{}, paramSchema *oas.Schema) bool {
+	return v.evaluateSchemaType(value, paramSchema, "", newSchemaState()) == nil
+}
+
+// evaluateSchemaType validates the value against the type specific keywords of the schema
+func (v *DefaultValidator) evaluateSchemaType(value interface{}, paramSchema *oas.Schema, path string, state *schemaState) error {
+	if value == nil && paramSchema.Nullable {
+		return nil
+	}
+
+	if paramSchema.HasConst() {
+		if !reflect.DeepEqual(floatNumbers(value), paramSchema.Const) {
+			if paramSchema.Const == nil {
+				return newSchemaError(path, "value must be null")
+			}
+			return newSchemaError(path, "value must be %v", paramSchema.Const)
+		}
+		// A null const is matched, untyped schemas are otherwise validated as objects
+		if value == nil && paramSchema.Type == "" && len(paramSchema.Types) == 0 {
+			return nil
+		}
+	}
+
+	// OpenAPI 3.1 type arrays match when any of the listed types does
+	if len(paramSchema.Types) > 1 && paramSchema.Type == "" {
+		for _, t := range paramSchema.Types {
+			if t == "null" {
+				continue
+			}
+			typedSchema := *paramSchema
+			typedSchema.Type = t
+			typedSchema.Types = nil
+			mark := state.mark()
+			if v.evaluateSchemaType(value, &typedSchema, path, state) == nil {
+				return nil
+			}
+			state.discard(mark)
+		}
+		return newSchemaError(path, "expected one of types %s", strings.Join(paramSchema.Types, ", "))
+	}
 
 	switch paramSchema.Type {
+	case "null":
+		if value != nil {
+			return newSchemaError(path, "expected null")
+		}
+		return nil
 	case "string":
-		return validateString(value, paramSchema)
+		if v.normalizeUnicode {
+			value = normalizeString(value)
+		}
+		return v.validateString(value, paramSchema, path)
 	case "integer", "number":
-		return validateNumber(value, paramSchema)
+		if err := v.numberStrictness.check(value, path); err != nil {
+			return err
+		}
+		number, err := v.numberStrictness.ungroup(value, path)
+		if err != nil {
+			return err
+		}
+		if err := validateNumber(number, paramSchema, path); err != nil {
+			return err
+		}
+		state.coerce(path, value, paramSchema.Type)
+		return nil
 	case "boolean":
-		return helpers.IsBoolean(value)
+		if !v.booleanMode.isBoolean(value) {
+			return newSchemaError(path, "expected boolean")
+		}
+		state.coerce(path, value, paramSchema.Type)
+		return nil
 	case "array":
-		return v.validateArray(value, paramSchema)
+		if err := v.validateArray(value, paramSchema, path, state); err != nil {
+			return err
+		}
+		state.coerce(path, value, paramSchema.Type)
+		return nil
 	case "object", "":
-		return v.validateObject(value, paramSchema)
+		return v.validateObject(value, paramSchema, path, state)
 	default:
-		return false
+		return newSchemaError(path, "unsupported type '%s'", paramSchema.Type)
 	}
 }
 
@@ -299,23 +767,6 @@ func (v *DefaultValidator) resolveSchemaReference(ref string) (*oas.Schema, erro
 	return schema, nil
 }
 
-// resolveParameterReference resolves a parameter reference to its actual definition
-func (v *DefaultValidator) resolveParameterReference(ref string) (*oas.Parameter, error) {
-	// Remove the "#/components/parameters/" prefix
-	ref = strings.TrimPrefix(ref, "#/components/parameters/")
-
-	// Check if Components or Parameters are nil
-	if v.apiSpec.Components == nil || v.apiSpec.Components.Parameters == nil {
-		return nil, fmt.Errorf("components or parameters not defined in oas")
-	}
-
-	param, exists := v.apiSpec.Components.Parameters[ref]
-	if !exists {
-		return nil, fmt.Errorf("parameter reference '%s' not found", ref)
-	}
-	return param, nil
-}
-
 // resolveDiscriminator resolves discriminator mapping and returns the correct schema
 func (v *DefaultValidator) resolveDiscriminator(value interface{}, schema *oas.Schema) (*oas.Schema, error) {
 	if schema.Discriminator == nil {
@@ -361,21 +812,26 @@ func (v *DefaultValidator) resolveDiscriminator(value interface{}, schema *oas.S
 }
 
 // validateString validates a string value against the schema
-func validateString(value interface{}, schema *oas.Schema) bool {
+func (v *DefaultValidator) validateString(value interface{}, schema *oas.Schema, path string) error {
 	str, ok := value.(string)
 	if !ok {
-		return false
+		return newSchemaError(path, "expected string")
 	}
 
-	if schema.MinLength != nil && uint64(len(str)) < *schema.MinLength {
-		return false
+	// Lengths count bytes, or characters once normalized to NFC, except for binary strings such as uploaded files
+	length := uint64(len(str))
+	if v.normalizeUnicode && schema.Format != "binary" {
+		length = uint64(utf8.RuneCountInString(str))
+	}
+	if schema.MinLength != nil && length < *schema.MinLength {
+		return newSchemaError(path, "length must be at least %d", *schema.MinLength)
 	}
-	if schema.MaxLength != nil && uint64(len(str)) > *schema.MaxLength {
-		return false
+	if schema.MaxLength != nil && length > *schema.MaxLength {
+		return newSchemaError(path, "length must be at most %d", *schema.MaxLength)
 	}
 	if schema.Pattern != "" {
 		if !helpers.MatchPattern(str, schema.Pattern) {
-			return false
+			return newSchemaError(path, "value does not match pattern '%s'", schema.Pattern)
 		}
 	}
 	if schema.Enum != nil {
@@ -383,79 +839,90 @@ func validateString(value interface{}, schema *oas.Schema) bool {
 		for i, v := range schema.Enum {
 			enumStrings[i], ok = v.(string)
 			if !ok {
-				return false
+				return newSchemaError(path, "enum of a string schema must only contain strings")
 			}
 		}
 		if !helpers.Contains(enumStrings, str) {
-			return false
-		}
-	}
-
-	switch schema.Format {
-	case "uuid":
-		return helpers.IsUUID(value)
-	case "email":
-		return helpers.IsEmail(value)
-	case "url", "uri":
-		return helpers.IsURL(value)
-	case "hostname":
-		return helpers.IsHostnameValid(value)
-	case "ipv4":
-		return helpers.IsIPv4(value)
-	case "ipv6":
-		return helpers.IsIPv6(value)
-	case "byte":
-		return helpers.IsByte(value)
-	case "date", "date-time":
-		return helpers.IsISO8601(value)
-	default:
-		return true
+			return newSchemaError(path, "value must be one of [%s]", strings.Join(enumStrings, ", "))
+		}
 	}
 
+	if !v.unknownFormats.validFormat(str, schema.Format) {
+		return newSchemaError(path, "invalid %s format", schema.Format)
+	}
+	return nil
 }
 
 // validateNumber validates a numeric value against the schema
-func validateNumber(value interface{}, schema *oas.Schema) bool {
+func validateNumber(value interface{}, schema *oas.Schema, path string) error {
 	// Try to convert string to number if needed
-	if str, ok := value.(string); ok {
-		parsed, err := helpers.ParseNumber(str)
+	num, ok := value.(float64)
+	switch typed := value.(type) {
+	case string:
+		parsed, err := helpers.ParseNumber(typed)
+		if err != nil {
+			return newSchemaError(path, "expected %s", schema.Type)
+		}
+		num, ok = parsed, true
+	case json.Number:
+		parsed, err := typed.Float64()
 		if err != nil {
-			return false
+			return newSchemaError(path, "expected %s", schema.Type)
 		}
-		value = parsed
+		num, ok = parsed, true
 	}
-
-	num, ok := value.(float64)
 	if !ok {
-		return false
+		return newSchemaError(path, "expected %s", schema.Type)
 	}
+	// Numeric strings and json.Number values are compared exactly, float64 rounds the integers beyond 2^53
+	exact, _ := helpers.ExactNumber(value)
 
-	if schema.Minimum != nil && num < *schema.Minimum {
-		return false
+	if schema.Minimum != nil {
+		if comparison := helpers.CompareNumber(num, exact, *schema.Minimum); comparison < 0 || schema.ExclusiveMinimum && comparison == 0 {
+			if schema.ExclusiveMinimum {
+				return newSchemaError(path, "value must be greater than %v", *schema.Minimum)
+			}
+			return newSchemaError(path, "value must be at least %v", *schema.Minimum)
+		}
 	}
-	if schema.Maximum != nil && num > *schema.Maximum {
-		return false
+	if schema.Maximum != nil {
+		if comparison := helpers.CompareNumber(num, exact, *schema.Maximum); comparison > 0 || schema.ExclusiveMaximum && comparison == 0 {
+			if schema.ExclusiveMaximum {
+				return newSchemaError(path, "value must be less than %v", *schema.Maximum)
+			}
+			return newSchemaError(path, "value must be at most %v", *schema.Maximum)
+		}
 	}
-	if schema.MultipleOf != nil && int(num)%int(*schema.MultipleOf) != 0 {
-		return false
+	if schema.MultipleOf != nil {
+		multiple := exact != nil && helpers.IsExactMultipleOf(exact, *schema.MultipleOf) ||
+			exact == nil && helpers.IsMultipleOf(num, *schema.MultipleOf)
+		if !multiple {
+			return newSchemaError(path, "value must be a multiple of %v", *schema.MultipleOf)
+		}
 	}
 
+	var valid bool
 	switch schema.Type {
 	case "integer":
 		switch schema.Format {
 		case "int32":
-			return helpers.IsInt32(value)
+			valid = helpers.IsInt32(value)
 		default:
-			return helpers.IsInt64(value)
+			valid = helpers.IsInt64(value)
 		}
 	case "number":
 		switch schema.Format {
 		case "double":
-			return helpers.IsDouble(value)
+			valid = helpers.IsDouble(value)
 		default:
-			return helpers.IsFloat(value)
+			valid = helpers.IsFloat(value)
 		}
-	default:
-		return false
 	}
+	if !valid {
+		if schema.Format != "" {
+			return newSchemaError(path, "expected %s (%s)", schema.Type, schema.Format)
+		}
+		return newSchemaError(path, "expected %s", schema.Type)
+	}
+	return nil
 }
@@ -2,7 +2,9 @@ package validation
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
+	"net/http/httptest"
 	"path/filepath"
 	"strings"
 	"testing"
@@ -253,3 +255,535 @@ func TestValidateWithDiscriminator(t *testing.T) {
 	result := validator.ValidateSchema(dog, &schema)
 	assert.True(t, result)
 }
+
+func TestValidateRecursiveSchemas(t *testing.T) {
+	manager := oas.NewOASManager(nil, oas.FixedSelector(map[string]string{"test": "test"}))
+	err := manager.LoadAPI("test", []byte(`{
+        "openapi": "3.0.3",
+        "info": {"title": "Recursive", "version": "1.0"},
+        "paths": {},
+        "components": {
+            "schemas": {
+                "Node": {
+                    "type": "object",
+                    "required": ["name"],
+                    "properties": {
+                        "name": {"type": "string"},
+                        "children": {"type": "array", "items": {"$ref": "#/components/schemas/Node"}}
+                    }
+                },
+                "SelfExtending": {
+                    "allOf": [
+                        {"$ref": "#/components/schemas/SelfExtending"},
+                        {"type": "object", "required": ["id"]}
+                    ]
+                },
+                "Ping": {"$ref": "#/components/schemas/Pong"},
+                "Pong": {"$ref": "#/components/schemas/Ping"},
+                "Pet": {
+                    "type": "object",
+                    "required": ["petType"],
+                    "properties": {"petType": {"type": "string"}},
+                    "discriminator": {"propertyName": "petType"}
+                },
+                "Cat": {
+                    "allOf": [
+                        {"$ref": "#/components/schemas/Pet"},
+                        {"type": "object", "required": ["lives"], "properties": {"lives": {"type": "integer"}}}
+                    ]
+                }
+            }
+        }
+    }`))
+	assert.NoError(t, err)
+	spec, _ := manager.GetApiSpec("test")
+
+	tests := []struct {
+		name     string
+		schema   string
+		value    string
+		maxDepth int
+		valid    bool
+	}{
+		{name: "Valid tree", schema: "Node", value: `{"name": "root", "children": [{"name": "a", "children": [{"name": "b"}]}]}`, valid: true},
+		{name: "Invalid nested node", schema: "Node", value: `{"name": "root", "children": [{"children": []}]}`, valid: false},
+		{name: "Self extending schema", schema: "SelfExtending", value: `{"id": 1}`, valid: true},
+		{name: "Self extending schema missing property", schema: "SelfExtending", value: `{}`, valid: false},
+		{name: "Reference cycle", schema: "Ping", value: `{"any": true}`, valid: true},
+		{name: "Discriminated schema extending the base", schema: "Pet", value: `{"petType": "Cat", "lives": 9}`, valid: true},
+		{name: "Discriminated schema missing property", schema: "Pet", value: `{"petType": "Cat"}`, valid: false},
+		{name: "Tree deeper than max depth", schema: "Node", value: `{"name": "root", "children": [{"name": "a", "children": [{"name": "b"}]}]}`, maxDepth: 4, valid: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var opts []Option
+			if tt.maxDepth > 0 {
+				opts = append(opts, WithMaxDepth(tt.maxDepth))
+			}
+			validator := NewValidator(spec, opts...)
+
+			var value interface{}
+			assert.NoError(t, json.Unmarshal([]byte(tt.value), &value))
+
+			schema := oas.Schema{Ref: "#/components/schemas/" + tt.schema}
+			assert.Equal(t, tt.valid, validator.ValidateSchema(value, &schema))
+		})
+	}
+}
+
+func TestComplexityLimits(t *testing.T) {
+	manager := oas.NewOASManager(nil, oas.FixedSelector(map[string]string{"test": "test"}))
+	err := manager.LoadAPI("test", []byte(`{
+        "openapi": "3.0.3",
+        "info": {"title": "Complexity", "version": "1.0"},
+        "paths": {
+            "/nodes": {
+                "post": {
+                    "parameters": [{"name": "limit", "in": "query", "schema": {"type": "integer"}}],
+                    "requestBody": {"content": {"application/json": {"schema": {
+                        "type": "object",
+                        "properties": {
+                            "node": {"$ref": "#/components/schemas/Node"},
+                            "tree": {"$ref": "#/components/schemas/Tree"},
+                            "notTree": {"not": {"$ref": "#/components/schemas/Tree"}},
+                            "notMissing": {"not": {"$ref": "#/components/schemas/Missing"}}
+                        }
+                    }}}},
+                    "responses": {"200": {"description": "OK"}}
+                }
+            }
+        },
+        "components": {
+            "schemas": {
+                "Node": {
+                    "oneOf": [
+                        {"type": "integer"},
+                        {"type": "array", "items": {"$ref": "#/components/schemas/Node"}}
+                    ]
+                },
+                "Tree": {"type": "array", "items": {"$ref": "#/components/schemas/Tree"}}
+            }
+        }
+    }`))
+	assert.NoError(t, err)
+	spec, _ := manager.GetApiSpec("test")
+
+	tests := []struct {
+		name        string
+		opts        []Option
+		body        string
+		category    Category
+		message     string
+		evaluations int
+	}{
+		{name: "Within limits", body: `{"node": [[1], 2]}`, evaluations: 18},
+		{name: "Within evaluation limit", opts: []Option{WithMaxEvaluations(18)}, body: `{"node": [[1], 2]}`, evaluations: 18},
+		{name: "Evaluations counted across parameters and body", opts: []Option{WithMaxEvaluations(17)}, body: `{"node": [[1], 2]}`, category: CategoryComplexityExceeded},
+		{name: "Exceeded in a oneOf branch", opts: []Option{WithMaxEvaluations(10)}, body: `{"node": [[[[[1]]]]]}`, category: CategoryComplexityExceeded},
+		{name: "Nested beyond max depth", opts: []Option{WithMaxDepth(6)}, body: `{"tree": [[[[[[]]]]]]}`, category: CategoryComplexityExceeded},
+		{name: "Invalid body", body: `{"node": ["a"]}`, category: CategoryInvalidBody},
+		{name: "Nested beyond max depth under not", opts: []Option{WithMaxDepth(6)}, body: `{"notTree": [[[[[[]]]]]]}`, category: CategoryComplexityExceeded},
+		{name: "Unresolvable reference under not", body: `{"notMissing": 1}`, category: CategoryInvalidBody, message: "schema reference 'Missing' not found"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodPost, "/nodes?limit=10", strings.NewReader(tt.body))
+			r.Header.Set("Content-Type", "application/json")
+			req := oas.NewOASRequest(r)
+			ok, err := NewValidator(spec, tt.opts...).ValidateRequest(req)
+			if tt.category == "" {
+				assert.True(t, ok)
+				assert.NoError(t, err)
+				assert.Equal(t, tt.evaluations, req.Evaluations)
+				return
+			}
+			assert.False(t, ok)
+			var validationErr *ValidationError
+			if assert.True(t, errors.As(err, &validationErr)) {
+				assert.Equal(t, tt.category, validationErr.Category)
+			}
+			if tt.category == CategoryComplexityExceeded {
+				assert.Contains(t, err.Error(), "validation complexity exceeded")
+			}
+			if tt.message != "" {
+				assert.Contains(t, err.Error(), tt.message)
+			}
+		})
+	}
+}
+
+func TestValidateComponentReferences(t *testing.T) {
+	manager := oas.NewOASManager(nil, oas.FixedSelector(map[string]string{"test": "test"}))
+	err := manager.LoadAPI("test", []byte(`{
+        "openapi": "3.0.3",
+        "info": {"title": "References", "version": "1.0"},
+        "paths": {
+            "/pets": {
+                "parameters": [{"$ref": "#/components/parameters/Tenant"}],
+                "post": {
+                    "parameters": [{"$ref": "#/components/parameters/Limit"}],
+                    "requestBody": {"$ref": "#/components/requestBodies/Pet"},
+                    "security": [{"apiKey": []}],
+                    "responses": {"201": {"$ref": "#/components/responses/Created"}}
+                }
+            }
+        },
+        "components": {
+            "parameters": {
+                "Tenant": {"name": "X-Tenant", "in": "header", "required": true, "schema": {"type": "string"}},
+                "Limit": {"$ref": "#/components/parameters/PageLimit"},
+                "PageLimit": {"name": "limit", "in": "query", "schema": {"type": "integer"}}
+            },
+            "requestBodies": {
+                "Pet": {
+                    "required": true,
+                    "content": {"application/json": {"schema": {"type": "object", "required": ["name"]}}}
+                }
+            },
+            "responses": {
+                "Created": {"description": "Created", "headers": {"Location": {"$ref": "#/components/headers/Location"}}}
+            },
+            "headers": {
+                "Location": {"schema": {"type": "string"}}
+            },
+            "securitySchemes": {
+                "apiKey": {"$ref": "#/components/securitySchemes/TenantKey"},
+                "TenantKey": {"type": "apiKey", "in": "header", "name": "X-Api-Key"}
+            }
+        }
+    }`))
+	assert.NoError(t, err)
+	spec, _ := manager.GetApiSpec("test")
+	validator := NewValidator(spec)
+
+	tests := []struct {
+		name       string
+		query      string
+		headers    map[string]string
+		body       string
+		wantErrMsg string
+	}{
+		{name: "Valid request", query: "limit=10", headers: map[string]string{"X-Tenant": "acme", "X-Api-Key": "key"}, body: `{"name": "doggie"}`},
+		{name: "Missing referenced path parameter", headers: map[string]string{"X-Api-Key": "key"}, body: `{"name": "doggie"}`, wantErrMsg: "missing required parameter 'X-Tenant'"},
+		{name: "Invalid chained parameter reference", query: "limit=ten", headers: map[string]string{"X-Tenant": "acme", "X-Api-Key": "key"}, body: `{"name": "doggie"}`, wantErrMsg: "invalid type for parameter 'limit'"},
+		{name: "Invalid referenced request body", headers: map[string]string{"X-Tenant": "acme", "X-Api-Key": "key"}, body: `{}`, wantErrMsg: "request body does not match schema"},
+		{name: "Missing referenced security scheme credentials", headers: map[string]string{"X-Tenant": "acme"}, body: `{"name": "doggie"}`, wantErrMsg: "security requirements"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequest(http.MethodPost, "/pets?"+tt.query, strings.NewReader(tt.body))
+			assert.NoError(t, err)
+			req.Header.Set("Content-Type", "application/json")
+			for name, value := range tt.headers {
+				req.Header.Set(name, value)
+			}
+
+			ok, err := validator.ValidateRequest(oas.NewOASRequest(req))
+			if tt.wantErrMsg == "" {
+				assert.True(t, ok)
+				assert.NoError(t, err)
+			} else {
+				assert.False(t, ok)
+				assert.ErrorContains(t, err, tt.wantErrMsg)
+			}
+		})
+	}
+
+	response, err := spec.ResolveResponse(&oas.Response{Ref: "#/components/responses/Created"})
+	assert.NoError(t, err)
+	location := response.Headers["Location"]
+	header, err := spec.ResolveHeader(&location)
+	assert.NoError(t, err)
+	assert.Equal(t, "string", header.Schema.Type)
+
+	_, err = spec.ResolveResponse(&oas.Response{Ref: "#/components/headers/Location"})
+	assert.ErrorContains(t, err, "does not target responses")
+}
+
+func TestCollectAll(t *testing.T) {
+	manager := oas.NewOASManager(nil, oas.FixedSelector(map[string]string{"test": "test"}))
+	err := manager.LoadAPI("test", []byte(`{
+        "openapi": "3.0.0",
+        "info": {"title": "Test API", "version": "1.0.0"},
+        "paths": {
+            "/pets": {
+                "post": {
+                    "parameters": [
+                        {"name": "limit", "in": "query", "schema": {"type": "integer"}},
+                        {"name": "X-Tenant", "in": "header", "required": true, "schema": {"type": "string"}}
+                    ],
+                    "requestBody": {
+                        "content": {
+                            "application/json": {
+                                "schema": {
+                                    "type": "object",
+                                    "required": ["name"],
+                                    "properties": {
+                                        "age": {"type": "integer"},
+                                        "tags": {"type": "array", "items": {"type": "string"}}
+                                    }
+                                }
+                            }
+                        }
+                    }
+                }
+            }
+        }
+    }`))
+	assert.NoError(t, err)
+	spec, _ := manager.GetApiSpec("test")
+
+	newRequest := func() *oas.OASRequest {
+		req, _ := http.NewRequest(http.MethodPost, "/pets?limit=ten", strings.NewReader(`{"age": "old", "tags": ["cute", 3]}`))
+		req.Header.Set("Content-Type", "application/json")
+		return oas.NewOASRequest(req)
+	}
+
+	// The first failure stops validation by default
+	ok, err := NewValidator(spec).ValidateRequest(newRequest())
+	assert.False(t, ok)
+	assert.Equal(t, "missing required parameter 'X-Tenant'", err.Error())
+
+	ok, err = NewValidator(spec, WithCollectAll(true)).ValidateRequest(newRequest())
+	assert.False(t, ok)
+
+	var validationErrs ValidationErrors
+	assert.True(t, errors.As(err, &validationErrs))
+	assert.Equal(t, CategoryInvalidParameter, CategoryOf(err))
+
+	body := "#/paths/~1pets/post/requestBody/content/application~1json/schema"
+	assert.Equal(t, []Violation{
+		{Message: "missing required parameter 'X-Tenant'", Spec: "#/paths/~1pets/post/parameters/1", Reason: "missing required parameter 'X-Tenant'"},
+		{Message: "invalid type for parameter 'limit': limit: expected integer", Path: "limit", Spec: "#/paths/~1pets/post/parameters/0/schema", Reason: "expected integer"},
+		{Message: "request body does not match schema: name: required property is missing", Path: "name", Spec: body, Reason: "required property is missing"},
+		{Message: "request body does not match schema: age: expected integer", Path: "age", Spec: body + "/properties/age", Reason: "expected integer"},
+		{Message: "request body does not match schema: tags[1]: expected string", Path: "tags[1]", Spec: body + "/properties/tags/items", Reason: "expected string"},
+	}, Violations(err))
+	for _, validationErr := range validationErrs {
+		assert.NotEmpty(t, validationErr.Category)
+	}
+}
+
+func TestCoercions(t *testing.T) {
+	manager := oas.NewOASManager(nil, oas.FixedSelector(map[string]string{"test": "test"}))
+	err := manager.LoadAPI("test", []byte(`{
+        "openapi": "3.0.0",
+        "info": {"title": "Test API", "version": "1.0.0"},
+        "paths": {
+            "/pets": {
+                "post": {
+                    "parameters": [
+                        {"name": "limit", "in": "query", "schema": {"type": "integer"}},
+                        {"name": "ids", "in": "query", "schema": {"type": "array", "items": {"type": "integer"}}},
+                        {"name": "name", "in": "query", "schema": {"type": "string"}},
+                        {"name": "X-Dry-Run", "in": "header", "schema": {"type": "boolean"}}
+                    ],
+                    "requestBody": {
+                        "content": {
+                            "application/json": {
+                                "schema": {
+                                    "type": "object",
+                                    "properties": {
+                                        "age": {"type": "integer"},
+                                        "weight": {"type": "number"},
+                                        "size": {"oneOf": [{"type": "integer", "minimum": 10}, {"type": "string"}]}
+                                    }
+                                }
+                            }
+                        }
+                    }
+                }
+            }
+        }
+    }`))
+	assert.NoError(t, err)
+	spec, _ := manager.GetApiSpec("test")
+
+	req, _ := http.NewRequest(http.MethodPost, "/pets?limit=10&ids=1&ids=2&name=42", strings.NewReader(`{"age": "3", "weight": 4.5, "size": "5"}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Dry-Run", "true")
+	oasRequest := oas.NewOASRequest(req)
+
+	ok, err := NewValidator(spec).ValidateRequest(oasRequest)
+	assert.True(t, ok)
+	assert.NoError(t, err)
+
+	// Values of string schemas, JSON numbers and the branches that did not match are not coercions
+	assert.ElementsMatch(t, []oas.Coercion{
+		{Location: "query", Path: "limit", Value: "10", Type: "integer"},
+		{Location: "query", Path: "ids[0]", Value: "1", Type: "integer"},
+		{Location: "query", Path: "ids[1]", Value: "2", Type: "integer"},
+		{Location: "header", Path: "X-Dry-Run", Value: "true", Type: "boolean"},
+		{Location: "body", Path: "age", Value: "3", Type: "integer"},
+	}, oasRequest.Coercions)
+}
+
+func TestValidateNot(t *testing.T) {
+	manager := oas.NewOASManager(nil, oas.FixedSelector(map[string]string{"test": "test"}))
+	assert.NoError(t, manager.LoadAPI("test", []byte(`{
+        "openapi": "3.0.0",
+        "info": {"title": "Test API", "version": "1.0.0"},
+        "paths": {},
+        "components": {
+            "schemas": {"Reserved": {"type": "string", "enum": ["root", "admin"]}}
+        }
+    }`)))
+	spec, _ := manager.GetApiSpec("test")
+	validator := NewValidator(spec).(*DefaultValidator)
+
+	tests := []struct {
+		name   string
+		schema string
+		value  interface{}
+		valid  bool
+	}{
+		{name: "String not matching the pattern", schema: `{"type": "string", "not": {"pattern": "^admin"}}`, value: "user", valid: true},
+		{name: "String matching the pattern", schema: `{"type": "string", "not": {"pattern": "^admin"}}`, value: "administrator"},
+		{name: "Type still checked", schema: `{"type": "string", "not": {"pattern": "^admin"}}`, value: 1.0},
+		{name: "Negated type", schema: `{"not": {"type": "null"}}`, value: nil},
+		{name: "Negated type mismatch", schema: `{"not": {"type": "null"}}`, value: "x", valid: true},
+		{name: "Referenced negated schema", schema: `{"not": {"$ref": "#/components/schemas/Reserved"}}`, value: "root"},
+		{name: "Referenced negated schema mismatch", schema: `{"not": {"$ref": "#/components/schemas/Reserved"}}`, value: "rex", valid: true},
+		{name: "Negated property", schema: `{"type": "object", "properties": {"role": {"not": {"const": "owner"}}}}`, value: map[string]interface{}{"role": "owner"}},
+		{name: "Double negation", schema: `{"type": "integer", "not": {"not": {"minimum": 5}}}`, value: 7.0, valid: true},
+		{name: "Double negation mismatch", schema: `{"type": "integer", "not": {"not": {"minimum": 5}}}`, value: 3.0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var schema oas.Schema
+			assert.NoError(t, json.Unmarshal([]byte(tt.schema), &schema))
+			assert.Equal(t, tt.valid, validator.ValidateSchema(tt.value, &schema))
+		})
+	}
+
+	// Failures locate the negated schema
+	schema := oas.Schema{Type: "string", Not: &oas.Schema{Pattern: "^admin"}}
+	var schemaErr *SchemaError
+	if assert.True(t, errors.As(validator.validateSchema("admin", &schema, ""), &schemaErr)) {
+		assert.Equal(t, "value must not match the schema of not", schemaErr.Message)
+		assert.Equal(t, "/not", schemaErr.SchemaPointer)
+	}
+}
+
+func TestValidatePropertyCounts(t *testing.T) {
+	validator := NewValidator(&oas.APISpec{}).(*DefaultValidator)
+	maxProperties := uint64(2)
+	schema := oas.Schema{Type: "object", MinProperties: 1, MaxProperties: &maxProperties, AdditionalProperties: &oas.Schema{Type: "string"}}
+
+	tests := []struct {
+		name          string
+		value         map[string]interface{}
+		expectedError string
+	}{
+		{name: "Too few properties", value: map[string]interface{}{}, expectedError: "object must have at least 1 properties"},
+		{name: "Within bounds", value: map[string]interface{}{"a": "1", "b": "2"}},
+		{name: "Too many properties", value: map[string]interface{}{"a": "1", "b": "2", "c": "3"}, expectedError: "object must have at most 2 properties"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validator.validateSchema(tt.value, &schema, "")
+			if tt.expectedError == "" {
+				assert.NoError(t, err)
+				return
+			}
+			assert.EqualError(t, err, tt.expectedError)
+		})
+	}
+
+	// maxProperties: 0 only allows empty objects
+	var empty oas.Schema
+	assert.NoError(t, json.Unmarshal([]byte(`{"type": "object", "maxProperties": 0}`), &empty))
+	assert.NoError(t, validator.validateSchema(map[string]interface{}{}, &empty, ""))
+	assert.EqualError(t, validator.validateSchema(map[string]interface{}{"a": "1"}, &empty, ""), "object must have at most 0 properties")
+}
+
+func TestValidateMultipleOf(t *testing.T) {
+	validator := NewValidator(&oas.APISpec{}).(*DefaultValidator)
+	multipleOf := func(divisor float64) *float64 { return &divisor }
+
+	tests := []struct {
+		name    string
+		schema  oas.Schema
+		value   interface{}
+		isValid bool
+	}{
+		{name: "Integer multiple", schema: oas.Schema{Type: "integer", MultipleOf: multipleOf(5)}, value: 15.0, isValid: true},
+		{name: "Integer not multiple", schema: oas.Schema{Type: "integer", MultipleOf: multipleOf(5)}, value: 12.0},
+		{name: "Cents", schema: oas.Schema{Type: "number", MultipleOf: multipleOf(0.01)}, value: 19.99, isValid: true},
+		{name: "Binary rounding of cents", schema: oas.Schema{Type: "number", MultipleOf: multipleOf(0.01)}, value: 0.07, isValid: true},
+		{name: "Fraction of cents", schema: oas.Schema{Type: "number", MultipleOf: multipleOf(0.01)}, value: 19.999},
+		{name: "Halves", schema: oas.Schema{Type: "number", MultipleOf: multipleOf(0.5)}, value: 2.5, isValid: true},
+		{name: "Not a half", schema: oas.Schema{Type: "number", MultipleOf: multipleOf(0.5)}, value: 2.25},
+		{name: "Fractional value of integer divisor", schema: oas.Schema{Type: "number", MultipleOf: multipleOf(2)}, value: 4.5},
+		{name: "Negative multiple", schema: oas.Schema{Type: "number", MultipleOf: multipleOf(0.1)}, value: -0.3, isValid: true},
+		{name: "Numeric string", schema: oas.Schema{Type: "number", MultipleOf: multipleOf(0.25)}, value: "1.75", isValid: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.isValid, validator.ValidateSchema(tt.value, &tt.schema))
+		})
+	}
+}
+
+func TestValidatePrefixItems(t *testing.T) {
+	validator := NewValidator(&oas.APISpec{}).(*DefaultValidator)
+
+	coordinates := `{"type": "array", "prefixItems": [{"type": "number"}, {"type": "number"}], "items": {"type": "string"}}`
+	tests := []struct {
+		name          string
+		schema        string
+		value         string
+		expectedError string
+	}{
+		{name: "Tuple", schema: coordinates, value: `[48.85, 2.35]`},
+		{name: "Tuple followed by items", schema: coordinates, value: `[48.85, 2.35, "Paris", "France"]`},
+		{name: "Shorter tuple", schema: coordinates, value: `[48.85]`},
+		{name: "Invalid tuple item", schema: coordinates, value: `[48.85, "east"]`, expectedError: "[1]: expected number"},
+		{name: "Invalid following item", schema: coordinates, value: `[48.85, 2.35, 75]`, expectedError: "[2]: expected string"},
+		{
+			name:   "Closed tuple",
+			schema: `{"type": "array", "prefixItems": [{"type": "string"}, {"type": "integer"}], "items": false}`,
+			value:  `["row", 1]`,
+		},
+		{
+			name:          "Closed tuple with following items",
+			schema:        `{"type": "array", "prefixItems": [{"type": "string"}, {"type": "integer"}], "items": false}`,
+			value:         `["row", 1, 2]`,
+			expectedError: "array must have at most 2 items",
+		},
+		{
+			name:          "Legacy tuple",
+			schema:        `{"type": "array", "items": [{"type": "string"}, {"type": "integer"}], "additionalItems": {"type": "boolean"}}`,
+			value:         `["row", 1, true, "yes"]`,
+			expectedError: "[3]: expected boolean",
+		},
+		{
+			name:          "Closed legacy tuple",
+			schema:        `{"type": "array", "items": [{"type": "string"}], "additionalItems": false}`,
+			value:         `["row", 1]`,
+			expectedError: "array must have at most 1 items",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var schema oas.Schema
+			assert.NoError(t, json.Unmarshal([]byte(tt.schema), &schema))
+			var value interface{}
+			assert.NoError(t, json.Unmarshal([]byte(tt.value), &value))
+
+			err := validator.validateSchema(value, &schema, "")
+			if tt.expectedError == "" {
+				assert.NoError(t, err)
+				return
+			}
+			assert.EqualError(t, err, tt.expectedError)
+		})
+	}
+}
@@ -0,0 +1,115 @@
+package validation
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"maps"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/lionelgarnier/validate-api-request/cache"
+	"github.com/lionelgarnier/validate-api-request/oas"
+)
+
+// verdict is the outcome of the validation of a request, replayed on identical requests
+type verdict struct {
+	ok           bool
+	err          error
+	route        string
+	pathItem     *oas.PathItem
+	operation    *oas.Operation
+	coercions    []oas.Coercion
+	deprecations []oas.Deprecation
+	security     oas.SecurityRequirement
+	negotiated   map[string]string
+}
+
+// VerdictCache remembers the verdicts of the validation of idempotent requests without body for a short time,
+// by hash of their API, method, path, query, headers and cookies, so that the identical requests of chatty clients
+// are not validated again. It can be shared by the validators of several APIs.
+type VerdictCache struct {
+	verdicts       *cache.BaseCache[*verdict]
+	ignoredHeaders map[string]bool
+}
+
+// NewVerdictCache returns a cache keeping up to maxEntries verdicts for ttl. Requests differing only by the ignored
+// headers, e.g. request or trace IDs, share their verdict.
+func NewVerdictCache(maxEntries int, ttl time.Duration, ignoredHeaders ...string) *VerdictCache {
+	c := &VerdictCache{
+		verdicts:       cache.NewBaseCache[*verdict](maxEntries, ttl),
+		ignoredHeaders: make(map[string]bool, len(ignoredHeaders)),
+	}
+	for _, header := range ignoredHeaders {
+		c.ignoredHeaders[http.CanonicalHeaderKey(header)] = true
+	}
+	return c
+}
+
+// Stats returns the hits and misses of the cache
+func (c *VerdictCache) Stats() cache.CacheStats {
+	return c.verdicts.Stats()
+}
+
+// WithVerdictCache replays the cached verdicts of identical idempotent requests instead of validating them again
+func WithVerdictCache(verdicts *VerdictCache) Option {
+	return func(v *DefaultValidator) {
+		v.verdicts = verdicts
+	}
+}
+
+// key returns the hash identifying a request validated against a spec, empty if its verdict is not cached
+func (c *VerdictCache) key(spec *oas.APISpec, r *http.Request) string {
+	switch r.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+	default:
+		return ""
+	}
+	if r.ContentLength != 0 || len(r.TransferEncoding) > 0 {
+		return ""
+	}
+
+	hash := sha256.New()
+	fmt.Fprintf(hash, "%s\x00%d\x00%s\x00%s\x00%s\x00", spec.Name, spec.Hash(), r.Method, r.URL.Path, r.URL.Query().Encode())
+	for _, name := range slices.Sorted(maps.Keys(r.Header)) {
+		if c.ignoredHeaders[http.CanonicalHeaderKey(name)] {
+			continue
+		}
+		fmt.Fprintf(hash, "%s\x00%q\x00", http.CanonicalHeaderKey(name), r.Header[name])
+	}
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// replay applies a cached verdict to a request
+func (v *DefaultValidator) replay(req *oas.OASRequest, cached *verdict) (bool, error) {
+	req.Route = cached.route
+	req.PathItem = cached.pathItem
+	req.Operation = cached.operation
+	req.Coercions = slices.Clone(cached.coercions)
+	req.Deprecations = slices.Clone(cached.deprecations)
+	req.Security = cached.security
+	for header, value := range cached.negotiated {
+		setNegotiatedValue(req, header, value)
+	}
+	if pathCache, exists := v.apiSpec.Paths[cached.route]; exists {
+		pathCache.Hits.Add(v.clock.Now())
+	}
+	return cached.ok, cached.err
+}
+
+// record returns the verdict of a validated request
+func record(req *oas.OASRequest, ok bool, err error) *verdict {
+	negotiated, _ := req.Request.Context().Value(negotiationKey{}).(map[string]string)
+	return &verdict{
+		ok:           ok,
+		err:          err,
+		route:        req.Route,
+		pathItem:     req.PathItem,
+		operation:    req.Operation,
+		coercions:    slices.Clone(req.Coercions),
+		deprecations: slices.Clone(req.Deprecations),
+		security:     req.Security,
+		negotiated:   maps.Clone(negotiated),
+	}
+}
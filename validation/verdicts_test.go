@@ -0,0 +1,100 @@
+package validation
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/lionelgarnier/validate-api-request/oas"
+	"github.com/lionelgarnier/validate-api-request/pkg/clock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVerdictCache(t *testing.T) {
+	manager := oas.NewOASManager(nil, oas.FixedSelector(map[string]string{"test": "test"}))
+	err := manager.LoadAPI("test", []byte(`{
+        "openapi": "3.0.0",
+        "info": {"title": "Test API", "version": "1.0.0"},
+        "paths": {
+            "/pets": {
+                "get": {
+                    "parameters": [
+                        {"name": "limit", "in": "query", "schema": {"type": "integer"}},
+                        {"name": "Accept-Language", "in": "header", "schema": {"type": "string", "enum": ["en", "fr"]}}
+                    ]
+                },
+                "post": {}
+            }
+        }
+    }`))
+	assert.NoError(t, err)
+	spec, _ := manager.GetApiSpec("test")
+
+	clk := clock.NewMock(time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC))
+	verdicts := NewVerdictCache(10, time.Second, "X-Request-Id")
+	verdicts.verdicts.SetClock(clk)
+	validator := NewValidator(spec, WithVerdictCache(verdicts))
+
+	validate := func(method, target string, headers http.Header) (*oas.OASRequest, bool, error) {
+		var body *strings.Reader
+		if method == http.MethodPost {
+			body = strings.NewReader("{}")
+		} else {
+			body = strings.NewReader("")
+		}
+		req, err := http.NewRequest(method, target, body)
+		assert.NoError(t, err)
+		for k, v := range headers {
+			req.Header[k] = v
+		}
+		oasRequest := oas.NewOASRequest(req)
+		ok, err := validator.ValidateRequest(oasRequest)
+		return oasRequest, ok, err
+	}
+	hits := func() int64 {
+		return verdicts.Stats().Hits
+	}
+
+	// The verdict of a valid request is replayed with what validation recorded on the request
+	headers := http.Header{"Accept-Language": {"fr;q=0.9, en;q=0.5"}, "X-Request-Id": {"1"}}
+	_, ok, err := validate(http.MethodGet, "/pets?limit=10", headers)
+	assert.True(t, ok)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), hits())
+
+	headers["X-Request-Id"] = []string{"2"}
+	replayed, ok, err := validate(http.MethodGet, "/pets?limit=10", headers)
+	assert.True(t, ok)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), hits())
+	assert.Equal(t, "/pets", replayed.Route)
+	assert.Equal(t, spec.Paths["/pets"].Item.Get, replayed.Operation)
+	assert.Equal(t, []oas.Coercion{{Location: "query", Path: "limit", Value: "10", Type: "integer"}}, replayed.Coercions)
+	negotiated, _ := NegotiatedValue(replayed.Request, "Accept-Language")
+	assert.Equal(t, "fr", negotiated)
+
+	// Other queries and headers are validated
+	_, ok, _ = validate(http.MethodGet, "/pets?limit=ten", headers)
+	assert.False(t, ok)
+	_, ok, _ = validate(http.MethodGet, "/pets?limit=10", http.Header{"Accept-Language": {"de"}})
+	assert.False(t, ok)
+	assert.Equal(t, int64(1), hits())
+
+	// Failures are replayed too
+	_, ok, err = validate(http.MethodGet, "/pets?limit=ten", headers)
+	assert.False(t, ok)
+	assert.Error(t, err)
+	assert.Equal(t, int64(2), hits())
+
+	// Requests with a body are not cached
+	validate(http.MethodPost, "/pets", nil)
+	validate(http.MethodPost, "/pets", nil)
+	assert.Equal(t, int64(2), hits())
+
+	// Verdicts expire
+	clk.Advance(2 * time.Second)
+	_, ok, _ = validate(http.MethodGet, "/pets?limit=10", headers)
+	assert.True(t, ok)
+	assert.Equal(t, int64(2), hits())
+}
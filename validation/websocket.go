@@ -0,0 +1,37 @@
+package validation
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/lionelgarnier/validate-api-request/oas"
+)
+
+// WebSocketExtension is the operation extension marking a WebSocket endpoint
+const WebSocketExtension = "x-websocket"
+
+// IsWebSocketUpgrade reports whether a request is a WebSocket upgrade of an operation declaring `x-websocket: true`.
+// Only the HTTP portion of these requests is validated, the upgraded connection is not a request body.
+func IsWebSocketUpgrade(req *oas.OASRequest) bool {
+	if req.Operation == nil {
+		return false
+	}
+	if marked, _ := req.Operation.Extensions[WebSocketExtension].(bool); !marked {
+		return false
+	}
+	return hasToken(req.Request.Header, "Connection", "upgrade") && hasToken(req.Request.Header, "Upgrade", "websocket")
+}
+
+// hasToken reports whether a header lists a token, ignoring case
+func hasToken(header http.Header, name, token string) bool {
+	for _, value := range header.Values(name) {
+		for _, candidate := range strings.Split(value, ",") {
+			// Upgrade tokens may carry a version, e.g. `websocket/13`
+			candidate, _, _ = strings.Cut(candidate, "/")
+			if strings.EqualFold(strings.TrimSpace(candidate), token) {
+				return true
+			}
+		}
+	}
+	return false
+}
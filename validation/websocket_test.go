@@ -0,0 +1,99 @@
+package validation
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/lionelgarnier/validate-api-request/oas"
+)
+
+func TestWebSocketUpgrades(t *testing.T) {
+	manager := oas.NewOASManager(nil, oas.FixedSelector(map[string]string{"test": "test"}))
+	err := manager.LoadAPI("test", []byte(`{
+        "openapi": "3.0.0",
+        "info": {"title": "Test API", "version": "1.0.0"},
+        "paths": {
+            "/events": {
+                "get": {
+                    "x-websocket": true,
+                    "parameters": [
+                        {"name": "topic", "in": "query", "required": true, "schema": {"type": "string", "enum": ["pets", "owners"]}},
+                        {"name": "Sec-WebSocket-Protocol", "in": "header", "schema": {"type": "string", "enum": ["events.v1"]}}
+                    ],
+                    "requestBody": {"required": true, "content": {"application/json": {"schema": {"type": "object"}}}},
+                    "security": [{"apiKey": []}]
+                }
+            }
+        },
+        "components": {
+            "securitySchemes": {
+                "apiKey": {"type": "apiKey", "in": "header", "name": "X-API-Key"}
+            }
+        }
+    }`))
+	assert.NoError(t, err)
+	spec, _ := manager.GetApiSpec("test")
+	validator := NewValidator(spec)
+
+	tests := []struct {
+		name          string
+		path          string
+		header        http.Header
+		upgrade       bool
+		expectedError string
+	}{
+		{
+			name:    "Upgrade",
+			path:    "/events?topic=pets",
+			header:  http.Header{"Connection": {"keep-alive, Upgrade"}, "Upgrade": {"websocket"}, "Sec-Websocket-Protocol": {"events.v1"}, "X-Api-Key": {"secret"}},
+			upgrade: true,
+		},
+		{
+			name:          "Invalid query parameter",
+			path:          "/events?topic=toys",
+			header:        http.Header{"Connection": {"Upgrade"}, "Upgrade": {"websocket"}, "X-Api-Key": {"secret"}},
+			upgrade:       true,
+			expectedError: "invalid type for parameter 'topic': topic: value must be one of [pets, owners]",
+		},
+		{
+			name:          "Invalid header",
+			path:          "/events?topic=pets",
+			header:        http.Header{"Connection": {"Upgrade"}, "Upgrade": {"websocket"}, "Sec-Websocket-Protocol": {"chat"}, "X-Api-Key": {"secret"}},
+			upgrade:       true,
+			expectedError: "invalid type for parameter 'Sec-WebSocket-Protocol': Sec-WebSocket-Protocol: value must be one of [events.v1]",
+		},
+		{
+			name:          "Missing credentials",
+			path:          "/events?topic=pets",
+			header:        http.Header{"Connection": {"Upgrade"}, "Upgrade": {"websocket"}},
+			upgrade:       true,
+			expectedError: "request does not satisfy any security requirements",
+		},
+		{
+			name:          "Plain request",
+			path:          "/events?topic=pets",
+			header:        http.Header{"X-Api-Key": {"secret"}},
+			expectedError: "request body is required",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, _ := http.NewRequest(http.MethodGet, tt.path, nil)
+			req.Header = tt.header
+
+			oasRequest := oas.NewOASRequest(req)
+			valid, err := validator.ValidateRequest(oasRequest)
+			if tt.expectedError == "" {
+				assert.True(t, valid)
+				assert.NoError(t, err)
+			} else {
+				assert.False(t, valid)
+				assert.EqualError(t, err, tt.expectedError)
+			}
+			assert.Equal(t, tt.upgrade, IsWebSocketUpgrade(oasRequest))
+		})
+	}
+}